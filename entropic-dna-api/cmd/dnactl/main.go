@@ -0,0 +1,24 @@
+// Command dnactl is a command-line client for the Entropic DNA gRPC API:
+//
+//	dnactl create -f arena.yaml
+//	dnactl list -genre FPS -o json
+//	dnactl publish <id>
+//
+// Run "dnactl help" for every command.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/dnactl"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := dnactl.Run(ctx, os.Args[1:], dnactl.IO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+	stop()
+	os.Exit(code)
+}
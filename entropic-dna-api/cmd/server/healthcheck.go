@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const healthcheckTimeout = 3 * time.Second
+
+// runHealthcheck probes the gRPC health service and the REST /healthz endpoint
+// of an instance running with the same configuration. It backs
+// `server healthcheck` for Docker HEALTHCHECK and systemd watchdogs.
+func runHealthcheck(cfg *config.Config) error {
+	host := cfg.Server.Host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+
+	grpcAddr := net.JoinHostPort(host, strconv.Itoa(cfg.Server.GRPCPort))
+	conn, err := grpc.DialContext(ctx, grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to dial gRPC at %s: %w", grpcAddr, err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("gRPC health check failed: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("gRPC server is %s", resp.Status)
+	}
+
+	url := fmt.Sprintf("http://%s/healthz", net.JoinHostPort(host, strconv.Itoa(cfg.Server.HTTPPort)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build REST health request: %w", err)
+	}
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("REST health check failed: %w", err)
+	}
+	httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("REST health check returned %d", httpResp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestHealthcheck checks `server healthcheck` passes only when both the gRPC
+// health service and /healthz report the instance healthy.
+func TestHealthcheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	healthServer := health.NewServer()
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	healthz := http.StatusOK
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(healthz)
+	}))
+	defer rest.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Host = "0.0.0.0"
+	cfg.Server.GRPCPort = portOf(t, lis.Addr().String())
+	cfg.Server.HTTPPort = portOf(t, rest.Listener.Addr().String())
+	if err := runHealthcheck(cfg); err != nil {
+		t.Fatalf("Expected a ready instance to pass, got %v", err)
+	}
+
+	healthz = http.StatusServiceUnavailable
+	if err := runHealthcheck(cfg); err == nil {
+		t.Error("Expected a 503 from /healthz to fail")
+	}
+	healthz = http.StatusOK
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	if err := runHealthcheck(cfg); err == nil {
+		t.Error("Expected a gRPC server that is not serving to fail")
+	}
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	// Nothing listens on a port that was just released.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	cfg.Server.HTTPPort = portOf(t, closed.Addr().String())
+	closed.Close()
+	if err := runHealthcheck(cfg); err == nil {
+		t.Error("Expected a refused REST connection to fail")
+	}
+}
+
+// portOf returns the port of a host:port address.
+func portOf(t *testing.T, addr string) int {
+	t.Helper()
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("SplitHostPort failed: %v", err)
+	}
+	n, err := strconv.Atoi(p)
+	if err != nil {
+		t.Fatalf("Atoi failed: %v", err)
+	}
+	return n
+}
@@ -2,30 +2,151 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/archive"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/cache"
+	"github.com/entropic-engine/entropic-dna-api/internal/compression"
 	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/editing"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/eventsource"
+	"github.com/entropic-engine/entropic-dna-api/internal/faults"
 	"github.com/entropic-engine/entropic-dna-api/internal/ffi"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"github.com/entropic-engine/entropic-dna-api/internal/integrity"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"github.com/entropic-engine/entropic-dna-api/internal/replicas"
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/resilience"
+	"github.com/entropic-engine/entropic-dna-api/internal/server"
+	"github.com/entropic-engine/entropic-dna-api/internal/sheets"
 	"github.com/entropic-engine/entropic-dna-api/internal/storage"
-	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"github.com/entropic-engine/entropic-dna-api/internal/webhooks"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
+	"go.uber.org/zap/zapcore"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := healthcheck(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unhealthy: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "--self-test" {
+		if err := selfTest(); err != nil {
+			fmt.Fprintf(os.Stderr, "Self-test failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "--migrate-down" {
+		if err := migrateDown(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Migrate down failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "--repair-versions" {
+		if err := repairVersions(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Repair versions failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+func healthcheck() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	return runHealthcheck(cfg)
+}
+
+func selfTest() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	logger, err := initLogger(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to init logger: %w", err)
+	}
+	defer logger.Sync()
+
+	return runSelfTest(cfg, logger, os.Stdout)
+}
+
+func migrateDown(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: server --migrate-down N")
+	}
+	steps, err := parseMigrateDownSteps(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	logger, err := initLogger(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to init logger: %w", err)
+	}
+	defer logger.Sync()
+
+	return runMigrateDown(cfg, steps, logger, os.Stdout)
+}
+
+func repairVersions(args []string) error {
+	dryRun, err := parseRepairVersionsArgs(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	logger, err := initLogger(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to init logger: %w", err)
+	}
+	defer logger.Sync()
+
+	return runRepairVersions(cfg, dryRun, logger, os.Stdout)
+}
+
 func run() error {
 	// Load configuration
 	cfg, err := config.Load()
@@ -37,45 +158,54 @@ func run() error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
-	// Initialize logger
-	logger, err := initLogger(cfg.Logging)
+	// Initialize logger; its level can be changed by reloading the config
+	level := zap.NewAtomicLevelAt(logLevel(cfg.Logging.Level))
+	logger, err := newLogger(cfg.Logging, level)
 	if err != nil {
 		return fmt.Errorf("failed to init logger: %w", err)
 	}
 	defer logger.Sync()
 
+	// Recent requests and their log lines are kept for LookupRequest
+	var journal *tracing.Journal
+	if cfg.Server.RequestJournalSize > 0 {
+		journal = tracing.NewJournal(cfg.Server.RequestJournalSize)
+		logger = logger.WithOptions(zap.WrapCore(journal.WrapCore))
+	}
+
 	logger.Info("Starting Entropic DNA API Server",
 		zap.String("grpc_addr", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort)),
 		zap.String("http_addr", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.HTTPPort)),
 	)
 
-	// Initialize storage
+	// Initialize storage, per region when data residency is configured
 	var store storage.Store
-	if cfg.Database.URL != "" && cfg.Database.URL != "memory" {
-		logger.Info("Connecting to PostgreSQL", zap.String("url", cfg.Database.URL))
-		pgStore, err := storage.NewPostgresStore(cfg.Database.URL)
-		if err != nil {
-			if cfg.Database.UseFallback {
-				logger.Warn("Failed to connect to PostgreSQL, falling back to memory storage", zap.Error(err))
-				store = storage.NewMemoryStore()
-			} else {
-				return fmt.Errorf("failed to connect to database: %w", err)
-			}
-		} else {
-			// Run migrations
-			logger.Info("Running database migrations")
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			if err := storage.Migrate(ctx, pgStore.DB()); err != nil {
-				cancel()
-				return fmt.Errorf("failed to run migrations: %w", err)
-			}
-			cancel()
-			store = pgStore
+	if cfg.Residency.Enabled() {
+		store, err = openResidencyStore(cfg, logger)
+	} else {
+		store, err = openStore(cfg, logger)
+	}
+	if err != nil {
+		return err
+	}
+	pgStore, _ := store.(*storage.PostgresStore)
+	eventLog, _ := store.(storage.EventLog)
+	store = guardDatabase(cfg, store, logger)
+	if pgStore != nil && len(cfg.Database.Replicas) > 0 {
+		if store, err = openReplicas(cfg, store, logger); err != nil {
+			pgStore.Close()
+			return err
 		}
+	}
+	// Every version is also logged as an event, which history pruning keeps
+	if cfg.Database.History == config.HistoryEvents {
+		store = eventsource.NewStore(store, eventLog, logger)
+		logger.Info("Keeping version history as an event log")
 	} else {
-		logger.Info("Using in-memory storage")
-		store = storage.NewMemoryStore()
+		eventLog = nil
 	}
+	// Each call is timed by method, and its statements counted against it
+	store = metrics.WrapStore(store)
 	defer store.Close()
 
 	// Initialize Rust FFI
@@ -85,64 +215,530 @@ func run() error {
 		return fmt.Errorf("failed to init Rust FFI: %w", err)
 	}
 	defer rust.Close()
+	if err := configureValidation(rust, cfg, logger); err != nil {
+		return err
+	}
 
-	// Create gRPC server
-	grpcServer := grpc.NewServer()
-	svcServer := api.NewGameDNAServiceServer(store, rust, logger)
-	pb.RegisterGameDNAServiceServer(grpcServer, svcServer)
-	reflection.Register(grpcServer)
+	// Optional Google Sheets integration
+	var sheetsClient *sheets.GoogleClient
+	if cfg.Sheets.CredentialsFile != "" {
+		credentials, err := os.ReadFile(cfg.Sheets.CredentialsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Google Sheets credentials: %w", err)
+		}
+		if sheetsClient, err = sheets.NewGoogleClient(credentials, ""); err != nil {
+			return fmt.Errorf("failed to init Google Sheets client: %w", err)
+		}
+		logger.Info("Google Sheets integration enabled", zap.String("service_account", sheetsClient.Email()))
+	}
 
-	// Start gRPC server
-	grpcAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort)
-	lis, err := net.Listen("tcp", grpcAddr)
+	// Change events are shared by the gRPC service and the REST event stream
+	broker, err := newBroker(cfg.Server.EventBacklog, cfg.Events, logger)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", grpcAddr, err)
+		return err
 	}
+	defer broker.Close()
 
-	go func() {
-		logger.Info("gRPC server listening", zap.String("addr", grpcAddr))
-		if err := grpcServer.Serve(lis); err != nil {
-			logger.Error("gRPC server error", zap.Error(err))
+	// Fault injection for resilience testing; off unless explicitly enabled
+	var injector *faults.Injector
+	if cfg.Faults.Enabled {
+		injector = faults.NewInjector()
+		store = faults.WrapStore(store, injector)
+		rust.SetFaults(injector)
+		broker.SetFaults(injector)
+		logger.Warn("Fault injection enabled; never run this configuration in production")
+	}
+
+	// OpenTelemetry spans for requests, storage queries and validation
+	var tracer *tracing.Tracer
+	if cfg.Telemetry.Enabled {
+		exporter, err := tracing.NewOTLPExporter(cfg.Telemetry.Endpoint, cfg.Telemetry.ServiceName, cfg.Telemetry.Headers)
+		if err != nil {
+			return fmt.Errorf("invalid telemetry config: %w", err)
 		}
-	}()
+		tracer = tracing.NewTracer(exporter, cfg.Telemetry.SampleRatio, time.Duration(cfg.Telemetry.ExportInterval)*time.Second, logger)
+		store = tracing.WrapStore(store)
+		logger.Info("Telemetry enabled",
+			zap.String("endpoint", cfg.Telemetry.Endpoint),
+			zap.Float64("sample_ratio", cfg.Telemetry.SampleRatio))
+	}
+
+	// Recent reads and list pages are served from Redis or an in-process LRU
+	var readCache *cache.ReadStore
+	if cfg.ReadCache.Enabled {
+		backend, err := newReadCacheBackend(cfg.ReadCache)
+		if err != nil {
+			return err
+		}
+		defer backend.Close()
+		readCache = cache.NewReadStore(store, backend, cache.ReadOptions{
+			Prefix:  cfg.ReadCache.KeyPrefix,
+			ReadTTL: time.Duration(cfg.ReadCache.ReadTTL) * time.Second,
+			ListTTL: time.Duration(cfg.ReadCache.ListTTL) * time.Second,
+		}, logger)
+		store = readCache
+		logger.Info("Read cache enabled",
+			zap.String("backend", cfg.ReadCache.Backend),
+			zap.Int("read_ttl", cfg.ReadCache.ReadTTL),
+			zap.Int("list_ttl", cfg.ReadCache.ListTTL))
+	}
 
-	// Start REST gateway
-	ctx := context.Background()
-	httpAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.HTTPPort)
-	gateway, err := api.NewRESTGateway(ctx, grpcAddr, httpAddr, logger)
+	// Published configs are served from memory, warmed before serving and on a schedule
+	warmCtx, stopWarming := context.WithCancel(context.Background())
+	defer stopWarming()
+	var publishedCache *cache.PublishedStore
+	if cfg.Cache.Enabled {
+		publishedCache = cache.NewPublishedStore(store, rust.CalculateChecksum)
+		store = publishedCache
+		warmPublishedCache(warmCtx, publishedCache, logger)
+		if cfg.Cache.RefreshInterval > 0 {
+			go refreshPublishedCache(warmCtx, publishedCache, time.Duration(cfg.Cache.RefreshInterval)*time.Second, logger)
+		}
+	}
+
+	// The event stream carries every tenant's configs, so it stays off under data residency
+	streamEvents := broker
+	if cfg.Residency.Enabled() {
+		streamEvents = nil
+		logger.Info("Change event stream disabled: it is not tenant-aware and data residency is enabled")
+	}
+
+	// Instances sharing a PostgreSQL database see each other's changes
+	if pgStore != nil && cfg.Events.Notify {
+		relayCtx, stopRelay := context.WithCancel(context.Background())
+		defer stopRelay()
+		relayChanges(relayCtx, pgStore, broker, publishedCache, readCache, logger)
+	}
+
+	// Read-only API tokens for published configs, as embedded in game builds
+	var authenticator *tokens.Authenticator
+	if cfg.Tokens.Enabled {
+		authenticator = tokens.NewAuthenticator(store, time.Duration(cfg.Tokens.CacheTTL)*time.Second)
+		logger.Info("API tokens enabled; published configs require a token")
+	}
+
+	// API keys and JWTs, scoped per RPC
+	var keyAuth *auth.Authenticator
+	if cfg.Auth.Enabled {
+		keys, jwt := authCredentials(cfg.Auth)
+		keyAuth, err = auth.New(keys, jwt)
+		if err != nil {
+			return fmt.Errorf("invalid auth config: %w", err)
+		}
+		logger.Info("Authentication enabled",
+			zap.Int("api_keys", len(keys)),
+			zap.Bool("jwt", cfg.Auth.JWT.Secret != ""))
+	}
+
+	// Admin keys for the admin listener, which takes admin RPCs off the
+	// public ports
+	var adminAddr string
+	var adminAuth *auth.Authenticator
+	if cfg.Server.AdminPort != 0 {
+		adminAddr = fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.AdminPort)
+		adminAuth, err = auth.New(adminKeys(cfg.Server.AdminAPIKeys), auth.JWT{})
+		if err != nil {
+			return fmt.Errorf("invalid admin API keys: %w", err)
+		}
+	}
+
+	// Token buckets per caller, identified by its credentials when auth is on
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		rl := rateLimits(cfg.RateLimit)
+		if limiter, err = ratelimit.New(rl, keyAuth); err != nil {
+			return fmt.Errorf("invalid rate limit config: %w", err)
+		}
+		logger.Info("Rate limiting enabled",
+			zap.Float64("rate", rl.Default.Rate),
+			zap.Float64("write_rate", rl.Writes.Rate))
+	}
+
+	// Large responses are compressed for clients that accept it
+	var compressor *compression.Policy
+	if cfg.Compression.Enabled {
+		compressor, err = compression.New(compression.Options{
+			MinSize:    cfg.Compression.MinSize,
+			Algorithms: cfg.Compression.Algorithms,
+		})
+		if err != nil {
+			return fmt.Errorf("invalid compression config: %w", err)
+		}
+		logger.Info("Response compression enabled",
+			zap.Int("min_size", cfg.Compression.MinSize),
+			zap.Strings("algorithms", cfg.Compression.Algorithms))
+	}
+
+	// Checksum migrations run in the background until shutdown
+	checksums := integrity.NewChecksums(store, rust.CalculateChecksum, logger)
+
+	// Lifecycle hooks; post hooks still running are waited for on shutdown
+	lifecycleHooks, err := newHooks(cfg.Hooks, logger)
 	if err != nil {
-		return fmt.Errorf("failed to create REST gateway: %w", err)
+		return err
 	}
+	defer lifecycleHooks.Wait()
 
-	go func() {
-		logger.Info("REST gateway listening", zap.String("addr", httpAddr))
-		if err := gateway.Start(); err != nil && err != context.Canceled {
-			logger.Error("REST gateway error", zap.Error(err))
+	// Webhook deliveries are queued with each change and sent in the background
+	var webhookSender *webhooks.Sender
+	if cfg.Webhooks.Enabled {
+		webhookSender = webhooks.NewSender(webhooks.Options{
+			Timeout:     time.Duration(cfg.Webhooks.TimeoutMs) * time.Millisecond,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Retention:   time.Duration(cfg.Webhooks.RetentionDays) * 24 * time.Hour,
+		})
+		logger.Info("Webhooks enabled", zap.Int("max_attempts", cfg.Webhooks.MaxAttempts))
+	}
+
+	// The log level, rate limits, API keys and write strictness are reloaded
+	// on SIGHUP or ReloadConfig
+	reloader := &configReloader{level: level, limiter: limiter, keyAuth: keyAuth, adminAuth: adminAuth, logger: logger, cfg: cfg}
+
+	svcOpts := serviceOptions(cfg)
+	svcOpts.Hooks = lifecycleHooks
+	svcOpts.Webhooks = webhookSender
+	svcOpts.Events = broker
+	svcOpts.EventLog = eventLog
+	svcOpts.Watch = streamEvents
+	svcOpts.Editing = editing.NewRegistry(time.Duration(cfg.Server.EditingSessionTTL) * time.Second)
+	svcOpts.Sheets = sheetsClient
+	svcOpts.Faults = injector
+	svcOpts.Tokens = authenticator
+	svcOpts.Journal = journal
+	svcOpts.Checksums = checksums
+	svcOpts.Reload = reloader.Reload
+	svcServer := api.NewGameDNAServiceServer(store, rust, svcOpts, logger)
+	reloader.svc = svcServer
+
+	// Temporary unlocks are relocked in the background once their window
+	// closes, canaries promoted or rolled back once they are judged, webhook
+	// deliveries sent once due, version history pruned to its retention, old
+	// versions archived and lapsed idempotency keys forgotten
+	sweepCtx, stopSweeping := context.WithCancel(context.Background())
+	defer stopSweeping()
+	var tenants []string
+	if cfg.Residency.Enabled() {
+		for tenant := range cfg.Residency.Tenants {
+			tenants = append(tenants, tenant)
 		}
-	}()
+	}
+	go sweepTenants(sweepCtx, tenants, time.Duration(cfg.Server.UnlockSweepInterval)*time.Second, func(ctx context.Context) {
+		relockExpiredUnlocks(ctx, svcServer, logger)
+	})
+	go sweepTenants(sweepCtx, tenants, time.Duration(cfg.Server.CanarySweepInterval)*time.Second, func(ctx context.Context) {
+		finishCanaries(ctx, svcServer, logger)
+	})
+	if webhookSender != nil {
+		go sweepTenants(sweepCtx, tenants, time.Duration(cfg.Webhooks.SweepInterval)*time.Second, func(ctx context.Context) {
+			deliverWebhooks(ctx, svcServer, logger)
+		})
+	}
+	if cfg.Limits.MaxHistoryDepth > 0 || cfg.Limits.MaxHistoryAgeDays > 0 {
+		go sweepTenants(sweepCtx, tenants, time.Duration(cfg.Server.HistorySweepInterval)*time.Second, func(ctx context.Context) {
+			pruneVersionHistory(ctx, svcServer, logger)
+		})
+	}
+	if cfg.Server.IdempotencyKeyTTL > 0 {
+		go sweepTenants(sweepCtx, tenants, time.Duration(cfg.Server.IdempotencySweepInterval)*time.Second, func(ctx context.Context) {
+			pruneIdempotencyKeys(ctx, svcServer, logger)
+		})
+	}
+	if cfg.Archive.Enabled {
+		if pgStore != nil {
+			go sweepTenants(sweepCtx, nil, time.Duration(cfg.Archive.SweepInterval)*time.Second, func(ctx context.Context) {
+				archiveVersions(ctx, pgStore, cfg.Archive, logger)
+			})
+		} else {
+			logger.Warn("Version archival needs PostgreSQL; versions stay in memory")
+		}
+	}
+
+	// Recovered panics are optionally forwarded to Sentry
+	var crashReporter server.CrashReporter
+	if cfg.Server.SentryDSN != "" {
+		sentry, err := server.NewSentryReporter(cfg.Server.SentryDSN, logger)
+		if err != nil {
+			return err
+		}
+		defer sentry.Close()
+		crashReporter = sentry
+		logger.Info("Crash reports will be sent to Sentry")
+	}
+
+	// Create and start the gRPC server and REST gateway
+	srv, err := server.New(svcServer, server.Options{
+		GRPCAddr: fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort),
+		HTTPAddr: fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.HTTPPort),
+		Gateway: api.GatewayOptions{
+			ProblemJSON:  cfg.Server.ProblemJSON,
+			ErrorDocsURL: cfg.Server.ErrorDocsURL,
+			Events:       streamEvents,
+
+			APIExplorer:       cfg.Server.APIExplorer,
+			APIExplorerAssets: cfg.Server.APIExplorerAssets,
+			GRPCWeb:           cfg.Server.GRPCWeb,
+			GRPCWebOrigins:    cfg.Server.GRPCWebOrigins,
+		},
+		Middleware:    cfg.Server.Middleware,
+		CrashReporter: crashReporter,
+		Tokens:        authenticator,
+		Auth:          keyAuth,
+		RateLimit:     limiter,
+		Compression:   compressor,
+		Journal:       journal,
+		Tracer:        tracer,
+		Readiness: []api.ReadinessCheck{
+			{Name: "database", Check: store.Ping},
+			{Name: "validation", Check: rust.Check},
+		},
+		Reflection:   cfg.Server.EnableReflection,
+		AdminAddr:    adminAddr,
+		AdminMethods: cfg.Server.AdminMethods,
+		AdminAuth:    adminAuth,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	if err := srv.Start(); err != nil {
+		return err
+	}
 
-	// Wait for shutdown signal
+	// Wait for shutdown signal, reloading the config on SIGHUP
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if _, err := reloader.Reload(); err != nil {
+			logger.Error("Config reload failed; keeping the running config", zap.Error(err))
+		}
+	}
 
 	logger.Info("Shutting down gracefully...")
 
 	// Graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Limits.ShutdownTimeout)*time.Second)
 	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	checksums.Stop()
+	tracer.Shutdown(shutdownCtx)
 
-	if err := gateway.Shutdown(shutdownCtx); err != nil {
-		logger.Error("Error shutting down REST gateway", zap.Error(err))
+	logger.Info("Shutdown complete")
+	return nil
+}
+
+// newBroker creates the change event broker for the configured mode.
+func newBroker(backlog int, cfg config.EventsConfig, logger *zap.Logger) (*events.Broker, error) {
+	if cfg.Mode != config.EventsModeEmbedded {
+		return events.NewBroker(backlog), nil
 	}
+	broker, err := events.NewEmbeddedBroker(backlog, cfg.Dir, cfg.Sync)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded events journal: %w", err)
+	}
+	logger.Info("Embedded event journal opened", zap.String("dir", cfg.Dir), zap.Bool("sync", cfg.Sync))
+	return broker, nil
+}
 
-	grpcServer.GracefulStop()
+// newReadCacheBackend opens the configured read cache backend.
+func newReadCacheBackend(cfg config.ReadCacheConfig) (cache.Backend, error) {
+	if cfg.Backend != config.ReadCacheBackendRedis {
+		return cache.NewLRU(cfg.MaxEntries), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	backend, err := cache.NewRedis(ctx, cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read cache: %w", err)
+	}
+	return backend, nil
+}
 
-	logger.Info("Shutdown complete")
-	return nil
+// warmPublishedCache loads published configs into the cache, logging any
+// whose stored checksum doesn't match their content.
+func warmPublishedCache(ctx context.Context, c *cache.PublishedStore, logger *zap.Logger) {
+	start := time.Now()
+	result, err := c.Warm(ctx)
+	if err != nil {
+		logger.Warn("Failed to warm published config cache", zap.Error(err))
+		return
+	}
+	if len(result.Mismatched) > 0 {
+		logger.Warn("Published configs failed checksum verification and were not cached",
+			zap.Strings("ids", result.Mismatched))
+	}
+	logger.Info("Published config cache warmed",
+		zap.Int("configs", result.Loaded),
+		zap.Duration("took", time.Since(start)),
+	)
+}
+
+// refreshPublishedCache re-warms the cache every interval until ctx ends, so
+// changes made through other instances are picked up.
+func refreshPublishedCache(ctx context.Context, c *cache.PublishedStore, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			warmPublishedCache(ctx, c, logger)
+		}
+	}
+}
+
+// newHooks builds the lifecycle hook registry: it registers the configured
+// HTTP hooks for their stages and loads the hook plugins.
+func newHooks(cfg config.HooksConfig, logger *zap.Logger) (*hooks.Registry, error) {
+	policy, err := hooks.ParseFailurePolicy(cfg.FailurePolicy)
+	if err != nil {
+		return nil, err
+	}
+	registry := hooks.NewRegistry(hooks.Options{
+		Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		Policy:  policy,
+	}, logger)
+
+	for _, hc := range cfg.HTTP {
+		var opts hooks.Options
+		opts.Timeout = time.Duration(hc.TimeoutMs) * time.Millisecond
+		if hc.FailurePolicy != "" {
+			if opts.Policy, err = hooks.ParseFailurePolicy(hc.FailurePolicy); err != nil {
+				return nil, fmt.Errorf("hook %s: %w", hc.Name, err)
+			}
+		}
+		var stages []hooks.Stage
+		for _, name := range hc.Stages {
+			stage, err := hooks.ParseStage(name)
+			if err != nil {
+				return nil, fmt.Errorf("hook %s: %w", hc.Name, err)
+			}
+			stages = append(stages, stage)
+		}
+		if err := registry.Register(hc.Name, hooks.NewHTTPHook(hc.URL, hc.Headers), opts, stages...); err != nil {
+			return nil, err
+		}
+		logger.Info("Lifecycle hook registered", zap.String("hook", hc.Name), zap.Strings("stages", hc.Stages))
+	}
+	for _, path := range cfg.Plugins {
+		if err := hooks.LoadPlugin(path, registry); err != nil {
+			return nil, err
+		}
+		logger.Info("Lifecycle hook plugin loaded", zap.String("path", path))
+	}
+	return registry, nil
+}
+
+// sweepTenants calls sweep every interval until ctx ends. Under data
+// residency stores are reached through a tenant, so it sweeps as each of
+// tenants in turn.
+func sweepTenants(ctx context.Context, tenants []string, interval time.Duration, sweep func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if len(tenants) == 0 {
+				sweep(ctx)
+			}
+			for _, tenant := range tenants {
+				sweep(residency.WithTenant(ctx, tenant))
+			}
+		}
+	}
+}
+
+// archiveVersions moves the versions past the archive's retention to object
+// storage, a batch at a time until none are left.
+func archiveVersions(ctx context.Context, store *storage.PostgresStore, cfg config.ArchiveConfig, logger *zap.Logger) {
+	retention := storage.HistoryRetention{MaxVersions: cfg.KeepVersions}
+	if cfg.AfterDays > 0 {
+		retention.CreatedBefore = time.Now().Add(-time.Duration(cfg.AfterDays) * 24 * time.Hour)
+	}
+	var total int64
+	for ctx.Err() == nil {
+		n, err := store.ArchiveVersions(ctx, retention, cfg.BatchSize)
+		total += n
+		if err != nil {
+			logger.Warn("Failed to archive versions", zap.Error(err))
+			break
+		}
+		if n < int64(cfg.BatchSize) {
+			break
+		}
+	}
+	if total > 0 {
+		logger.Info("Archived versions", zap.Int64("versions", total))
+	}
+}
+
+// relockExpiredUnlocks relocks configs whose temporary unlock has expired.
+func relockExpiredUnlocks(ctx context.Context, svc *api.GameDNAServiceServer, logger *zap.Logger) {
+	n, err := svc.RelockExpired(ctx)
+	// Tenants homed in regions this deployment doesn't serve are skipped
+	if err != nil && !errors.Is(err, storage.ErrForbidden) {
+		logger.Warn("Failed to relock expired temporary unlocks", zap.Error(err))
+	}
+	if n > 0 {
+		logger.Info("Relocked expired temporary unlocks", zap.Int("configs", n))
+	}
+}
+
+// finishCanaries promotes or rolls back canaries that have been judged.
+func finishCanaries(ctx context.Context, svc *api.GameDNAServiceServer, logger *zap.Logger) {
+	n, err := svc.FinishCanaries(ctx)
+	if err != nil && !errors.Is(err, storage.ErrForbidden) {
+		logger.Warn("Failed to finish canaries", zap.Error(err))
+	}
+	if n > 0 {
+		logger.Info("Finished canaries", zap.Int("configs", n))
+	}
+}
+
+// pruneVersionHistory deletes versions past the history retention.
+func pruneVersionHistory(ctx context.Context, svc *api.GameDNAServiceServer, logger *zap.Logger) {
+	n, err := svc.PruneVersionHistory(ctx)
+	if err != nil && !errors.Is(err, storage.ErrForbidden) {
+		logger.Warn("Failed to prune version history", zap.Error(err))
+	}
+	if n > 0 {
+		logger.Info("Pruned version history", zap.Int64("versions", n))
+	}
+}
+
+// pruneIdempotencyKeys deletes idempotency keys whose responses lapsed.
+func pruneIdempotencyKeys(ctx context.Context, svc *api.GameDNAServiceServer, logger *zap.Logger) {
+	n, err := svc.PruneIdempotencyKeys(ctx)
+	if err != nil && !errors.Is(err, storage.ErrForbidden) {
+		logger.Warn("Failed to prune idempotency keys", zap.Error(err))
+	}
+	if n > 0 {
+		logger.Debug("Pruned idempotency keys", zap.Int64("keys", n))
+	}
+}
+
+// deliverWebhooks sends the webhook deliveries that are due.
+func deliverWebhooks(ctx context.Context, svc *api.GameDNAServiceServer, logger *zap.Logger) {
+	n, err := svc.DeliverWebhooks(ctx)
+	if err != nil && !errors.Is(err, storage.ErrForbidden) {
+		logger.Warn("Failed to deliver webhooks", zap.Error(err))
+	}
+	if n > 0 {
+		logger.Debug("Delivered webhooks", zap.Int("deliveries", n))
+	}
 }
 
 func initLogger(cfg config.LoggingConfig) (*zap.Logger, error) {
+	return newLogger(cfg, zap.NewAtomicLevelAt(logLevel(cfg.Level)))
+}
+
+// newLogger builds the logger for cfg, logging at level, which can be changed
+// while it runs.
+func newLogger(cfg config.LoggingConfig, level zap.AtomicLevel) (*zap.Logger, error) {
 	var logConfig zap.Config
 
 	if cfg.Format == "json" {
@@ -150,19 +746,327 @@ func initLogger(cfg config.LoggingConfig) (*zap.Logger, error) {
 	} else {
 		logConfig = zap.NewDevelopmentConfig()
 	}
+	logConfig.Level = level
+
+	return logConfig.Build()
+}
 
-	switch cfg.Level {
+// logLevel parses a configured log level; unknown levels log at info.
+func logLevel(level string) zapcore.Level {
+	switch level {
 	case "debug":
-		logConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+		return zap.DebugLevel
 	case "warn":
-		logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+		return zap.WarnLevel
 	case "error":
-		logConfig.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+		return zap.ErrorLevel
 	}
+	return zap.InfoLevel
+}
 
-	return logConfig.Build()
+// configureValidation loads the validation profile overrides, rules file and
+// rating compliance rules the config names into rust.
+func configureValidation(rust *ffi.RustFFI, cfg *config.Config, logger *zap.Logger) error {
+	overrides := make(map[string]ffi.ProfileOverride, len(cfg.Validation.Projects))
+	for project, o := range cfg.Validation.Projects {
+		overrides[project] = ffi.ProfileOverride{
+			Profile:        o.Profile,
+			MinFPS:         o.MinFPS,
+			MaxEntities:    o.MaxEntities,
+			MaxNPCCount:    o.MaxNPCCount,
+			MaxPlayers:     o.MaxPlayers,
+			RequiredFields: o.RequiredFields,
+		}
+	}
+	if err := rust.SetProfileOverrides(overrides); err != nil {
+		return fmt.Errorf("invalid validation projects: %w", err)
+	}
+	if cfg.Validation.RulesFile != "" {
+		data, err := os.ReadFile(cfg.Validation.RulesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read validation rules: %w", err)
+		}
+		rules, err := ffi.ParseRuleSet(data)
+		if err == nil {
+			err = rust.SetRuleSet(rules)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid validation rules in %s: %w", cfg.Validation.RulesFile, err)
+		}
+		logger.Info("Loaded validation rules", zap.String("version", rust.RulesVersion()), zap.Int("rules", len(rules.Rules)))
+	}
+	if cfg.Validation.Compliance {
+		compliance := ffi.DefaultComplianceRules
+		if cfg.Validation.ComplianceFile != "" {
+			data, err := os.ReadFile(cfg.Validation.ComplianceFile)
+			if err != nil {
+				return fmt.Errorf("failed to read compliance rules: %w", err)
+			}
+			if compliance, err = ffi.ParseComplianceRules(data); err != nil {
+				return fmt.Errorf("invalid compliance rules in %s: %w", cfg.Validation.ComplianceFile, err)
+			}
+		}
+		if err := rust.SetComplianceRules(compliance); err != nil {
+			return fmt.Errorf("invalid compliance rules: %w", err)
+		}
+		logger.Info("Rating compliance checked", zap.String("version", compliance.Version), zap.Int("ratings", len(compliance.Ratings)))
+	}
+	return nil
+}
+
+// serviceOptions returns the service options the config sets. Both the
+// server and the self-test use them, so the self-test exercises the policy
+// the server enforces; the server adds its collaborators.
+func serviceOptions(cfg *config.Config) api.ServerOptions {
+	return api.ServerOptions{
+		PublishMaxWarnings: cfg.Validation.PublishMaxWarnings,
+		WriteStrictness:    cfg.Validation.WriteStrictness,
+		StrictEnvironments: cfg.Validation.StrictEnvironments,
+		MaxWaiverDuration:  time.Duration(cfg.Validation.MaxWaiverDays) * 24 * time.Hour,
+		MaxUnlockWindow:    time.Duration(cfg.Server.MaxUnlockWindow) * time.Second,
+		MaxWait:            time.Duration(cfg.Server.LongPollMaxWait) * time.Second,
+		MaxBatchSize:       cfg.Limits.MaxBatchSize,
+		MaxHistoryDepth:    cfg.Limits.MaxHistoryDepth,
+		MaxHistoryAge:      time.Duration(cfg.Limits.MaxHistoryAgeDays) * 24 * time.Hour,
+		List: api.ListLimits{
+			DefaultPageSize:     int32(cfg.Limits.DefaultPageSize),
+			MaxPageSize:         int32(cfg.List.MaxPageSize),
+			MaxOffset:           int64(cfg.List.MaxOffset),
+			MinNameFilterLength: cfg.List.MinNameFilterLength,
+		},
+		MaterializeDefaults: cfg.Server.MaterializeDefaults,
+		IdempotencyKeyTTL:   time.Duration(cfg.Server.IdempotencyKeyTTL) * time.Second,
+	}
+}
+
+// openStore connects to the configured store, running migrations for
+// PostgreSQL and falling back to memory when allowed.
+func openStore(cfg *config.Config, logger *zap.Logger) (storage.Store, error) {
+	return openDatabase(cfg, cfg.Database.URL, cfg.Database.UseFallback, logger)
+}
+
+// openResidencyStore connects to every regional database and routes each
+// tenant to its home region's. Regional databases never fall back to memory.
+func openResidencyStore(cfg *config.Config, logger *zap.Logger) (storage.Store, error) {
+	stores := make(map[string]storage.Store)
+	for region, url := range cfg.Residency.Databases {
+		regionLogger := logger.With(zap.String("region", region))
+		store, err := openDatabase(cfg, url, false, regionLogger)
+		if err != nil {
+			for _, opened := range stores {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("region %s: %w", region, err)
+		}
+		stores[region] = guardDatabase(cfg, store, regionLogger)
+	}
+	logger.Info("Data residency enabled", zap.Int("regions", len(stores)), zap.Int("tenants", len(cfg.Residency.Tenants)))
+	return residency.NewRouter(stores, cfg.Residency.Tenants), nil
+}
+
+// openReplicas connects to the read replicas and serves published-config
+// reads of primary from them. Replicas are read-only, so no migrations run.
+func openReplicas(cfg *config.Config, primary storage.Store, logger *zap.Logger) (storage.Store, error) {
+	var codecs *storage.CodecChain
+	if len(cfg.Database.Codecs) > 0 {
+		key, err := cfg.Database.EncryptionKeyBytes()
+		if err != nil {
+			return nil, err
+		}
+		if codecs, err = storage.NewCodecs(cfg.Database.Codecs, key); err != nil {
+			return nil, fmt.Errorf("invalid database codecs: %w", err)
+		}
+	}
+
+	var stores []storage.Store
+	for i, url := range cfg.Database.Replicas {
+		replica, err := storage.NewPostgresStore(url, storage.PoolConfig{
+			MaxOpenConns:    cfg.Limits.DBMaxOpenConns,
+			MaxIdleConns:    cfg.Limits.DBMaxIdleConns,
+			ConnMaxLifetime: time.Duration(cfg.Limits.DBConnMaxLifetime) * time.Second,
+		})
+		if err != nil {
+			for _, opened := range stores {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to connect to replica %d: %w", i+1, err)
+		}
+		if codecs != nil {
+			replica.SetCodecs(codecs)
+		}
+		replica.SetQueryObserver(observeQueries(cfg, logger.With(zap.Int("replica", i+1))))
+		stores = append(stores, replica)
+	}
+
+	hedge := cfg.Database.Hedge
+	logger.Info("Serving published configs from read replicas",
+		zap.Int("replicas", len(stores)),
+		zap.Float64("hedge_percentile", hedge.Percentile))
+	return replicas.NewStore(primary, stores, replicas.Options{
+		HedgePercentile: hedge.Percentile,
+		MinDelay:        time.Duration(hedge.MinDelayMs) * time.Millisecond,
+		MaxDelay:        time.Duration(hedge.MaxDelayMs) * time.Millisecond,
+	}), nil
+}
+
+// openDatabase opens the store at url: in-memory for "" or "memory",
+// PostgreSQL otherwise.
+func openDatabase(cfg *config.Config, url string, useFallback bool, logger *zap.Logger) (storage.Store, error) {
+	if url == "" || url == "memory" {
+		logger.Info("Using in-memory storage")
+		return newMemoryStore(cfg), nil
+	}
+
+	logger.Info("Connecting to PostgreSQL", zap.String("url", url))
+	pgStore, err := storage.NewPostgresStore(url, storage.PoolConfig{
+		MaxOpenConns:    cfg.Limits.DBMaxOpenConns,
+		MaxIdleConns:    cfg.Limits.DBMaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Limits.DBConnMaxLifetime) * time.Second,
+	})
+	if err != nil {
+		if useFallback {
+			logger.Warn("Failed to connect to PostgreSQL, falling back to memory storage", zap.Error(err))
+			return newMemoryStore(cfg), nil
+		}
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Run migrations
+	logger.Info("Running database migrations")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := storage.Migrate(ctx, pgStore.DB()); err != nil {
+		pgStore.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	if cfg.Logging.Level == "debug" {
+		checkQueryPlans(pgStore, logger)
+	}
+	pgStore.SetMaxHistoryDepth(cfg.Limits.MaxHistoryDepth)
+	pgStore.SetQueryObserver(observeQueries(cfg, logger))
+
+	// Config data is compressed and encrypted as configured
+	if len(cfg.Database.Codecs) > 0 {
+		key, err := cfg.Database.EncryptionKeyBytes()
+		if err != nil {
+			pgStore.Close()
+			return nil, err
+		}
+		codecs, err := storage.NewCodecs(cfg.Database.Codecs, key)
+		if err != nil {
+			pgStore.Close()
+			return nil, fmt.Errorf("invalid database codecs: %w", err)
+		}
+		pgStore.SetCodecs(codecs)
+		logger.Info("Encoding stored config data", zap.Strings("codecs", codecs.Names()))
+	}
+
+	// Old versions' data is moved to object storage and read back from it
+	if cfg.Archive.Enabled {
+		bucket, err := archive.New(archive.Options{
+			Endpoint:        cfg.Archive.Endpoint,
+			Region:          cfg.Archive.Region,
+			Bucket:          cfg.Archive.Bucket,
+			Prefix:          cfg.Archive.Prefix,
+			AccessKeyID:     cfg.Archive.AccessKeyID,
+			SecretAccessKey: cfg.Archive.SecretAccessKey,
+			Timeout:         time.Duration(cfg.Archive.TimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			pgStore.Close()
+			return nil, fmt.Errorf("invalid archive config: %w", err)
+		}
+		pgStore.SetArchive(bucket)
+		logger.Info("Archiving old versions",
+			zap.String("endpoint", cfg.Archive.Endpoint),
+			zap.String("bucket", cfg.Archive.Bucket),
+			zap.Int("keep_versions", cfg.Archive.KeepVersions),
+			zap.Int("after_days", cfg.Archive.AfterDays))
+	}
+
+	// Configs stored before search existed are indexed in the background;
+	// until then search does not find them.
+	go func() {
+		indexed, err := pgStore.IndexSearchColumns(context.Background(), 500)
+		if err != nil {
+			logger.Warn("Failed to index configs for search", zap.Int("indexed", indexed), zap.Error(err))
+			return
+		}
+		if indexed > 0 {
+			logger.Info("Indexed configs for search", zap.Int("indexed", indexed))
+		}
+	}()
+	return pgStore, nil
+}
+
+// guardDatabase retries transient failures of a PostgreSQL store and fails
+// its calls fast while the database is down. The in-memory store is returned
+// as is.
+func guardDatabase(cfg *config.Config, store storage.Store, logger *zap.Logger) storage.Store {
+	if _, ok := store.(*storage.PostgresStore); !ok {
+		return store
+	}
+	r := cfg.Database.Resilience
+	return resilience.WrapStore(store, resilience.Options{
+		MaxRetries:       r.MaxRetries,
+		InitialBackoff:   time.Duration(r.RetryBackoffMs) * time.Millisecond,
+		MaxBackoff:       time.Duration(r.MaxRetryBackoffMs) * time.Millisecond,
+		FailureThreshold: r.BreakerThreshold,
+		Cooldown:         time.Duration(r.BreakerCooldown) * time.Second,
+		HealthInterval:   time.Duration(r.HealthCheckInterval) * time.Second,
+	}, logger)
+}
+
+// observeQueries counts the statements a PostgreSQL store runs and logs
+// those slower than the configured threshold. The log holds the statement
+// and its parameter count but never the parameters, which may be config data.
+func observeQueries(cfg *config.Config, logger *zap.Logger) storage.QueryObserver {
+	slow := time.Duration(cfg.Database.SlowQueryMs) * time.Millisecond
+	return func(_ context.Context, q storage.QueryStats) {
+		isSlow := slow > 0 && q.Duration > slow
+		metrics.ObserveQuery(q, isSlow)
+		if !isSlow {
+			return
+		}
+		fields := []zap.Field{
+			zap.String("method", q.Method),
+			zap.String("query", strings.Join(strings.Fields(q.Query), " ")),
+			zap.Int("params", q.Args),
+			zap.Int64("rows", q.Rows),
+			zap.Duration("duration", q.Duration),
+		}
+		if q.Err != nil {
+			fields = append(fields, zap.Error(q.Err))
+		}
+		logger.Warn("Slow query", fields...)
+	}
+}
+
+// newMemoryStore creates the in-memory store with the configured limits.
+func newMemoryStore(cfg *config.Config) *storage.MemoryStore {
+	store := storage.NewMemoryStore()
+	store.SetMaxHistoryDepth(cfg.Limits.MaxHistoryDepth)
+	return store
+}
+
+// checkQueryPlans logs whether the hot List queries can use an index. It only
+// warns: a missing index slows the service down but does not break it. It
+// runs only against Postgres; a memory store, including the fallback, has no
+// plans to check.
+func checkQueryPlans(pgStore *storage.PostgresStore, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	checks, err := pgStore.CheckQueryPlans(ctx)
+	if err != nil {
+		logger.Warn("Query plan check failed", zap.Error(err))
+		return
+	}
+	for _, check := range checks {
+		if check.SeqScan {
+			logger.Warn("Query plan uses a sequential scan", zap.String("query", check.Name), zap.String("plan", check.Plan))
+			continue
+		}
+		logger.Debug("Query plan uses an index", zap.String("query", check.Name), zap.String("plan", check.Plan))
+	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+)
+
+const migrateDownTimeout = 5 * time.Minute
+
+// parseMigrateDownSteps parses the argument of --migrate-down.
+func parseMigrateDownSteps(arg string) (int, error) {
+	steps, err := strconv.Atoi(arg)
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("--migrate-down needs a positive number of migrations, got %q", arg)
+	}
+	return steps, nil
+}
+
+// runMigrateDown reverts the steps most recent migrations of the configured
+// database, or of every regional database when data residency is set, and
+// prints what it reverted to out. It backs `server --migrate-down N` for
+// rolling back a failed deployment: run it with the new build, which knows
+// the Down SQL of the migrations it added, before deploying the old one.
+func runMigrateDown(cfg *config.Config, steps int, logger *zap.Logger, out io.Writer) error {
+	urls := map[string]string{"": cfg.Database.URL}
+	if cfg.Residency.Enabled() {
+		urls = cfg.Residency.Databases
+	}
+	regions := make([]string, 0, len(urls))
+	for region := range urls {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateDownTimeout)
+	defer cancel()
+	for _, region := range regions {
+		url := urls[region]
+		label := "database"
+		if region != "" {
+			label = "region " + region
+		}
+		if url == "" || url == "memory" {
+			return fmt.Errorf("%s: in-memory storage has no migrations to revert", label)
+		}
+
+		pgStore, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+		if err != nil {
+			return fmt.Errorf("%s: failed to connect: %w", label, err)
+		}
+		logger.Info("Reverting database migrations", zap.String("region", region), zap.Int("steps", steps))
+		reverted, err := storage.MigrateDown(ctx, pgStore.DB(), steps)
+		pgStore.Close()
+		for _, name := range reverted {
+			fmt.Fprintf(out, "%s: reverted %s\n", label, name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/cache"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// notifyTimeout bounds announcing one change to the other instances.
+const notifyTimeout = 5 * time.Second
+
+// changeRelay shares change events with the other instances using the same
+// PostgreSQL database, so their watchers and caches see every change.
+type changeRelay struct {
+	pg     *storage.PostgresStore
+	origin string
+	logger *zap.Logger
+}
+
+// Announce implements events.Relay. A failed notice is logged and dropped;
+// the change itself is already stored.
+func (r *changeRelay) Announce(eventType, configID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	notice := storage.ChangeNotice{Origin: r.origin, Type: eventType, ConfigID: configID}
+	if err := r.pg.NotifyChange(ctx, notice); err != nil {
+		r.logger.Warn("Failed to relay change event", zap.String("config_id", configID), zap.Error(err))
+	}
+}
+
+// relayChanges announces broker's events through pg and delivers the other
+// instances' events to broker until ctx ends. Their changes are also evicted
+// from publishedCache and readCache, if set.
+func relayChanges(ctx context.Context, pg *storage.PostgresStore, broker *events.Broker, publishedCache *cache.PublishedStore, readCache *cache.ReadStore, logger *zap.Logger) {
+	relay := &changeRelay{pg: pg, origin: uuid.New().String(), logger: logger}
+	broker.SetRelay(relay)
+
+	deliver := func(notice storage.ChangeNotice) {
+		if notice.Origin == relay.origin {
+			return
+		}
+		if publishedCache != nil {
+			publishedCache.Evict(notice.ConfigID)
+		}
+		if readCache != nil {
+			readCache.Evict(notice.ConfigID)
+		}
+		// The config is read as it is now, which may be later than the change.
+		var dna *pb.GameDNA
+		if notice.Type != events.TypeDeleted {
+			var err error
+			if dna, err = pg.Read(ctx, notice.ConfigID); err != nil {
+				logger.Debug("Relayed change refers to an unreadable config", zap.String("config_id", notice.ConfigID), zap.Error(err))
+				return
+			}
+		}
+		broker.Deliver(notice.Type, notice.ConfigID, dna)
+	}
+	onError := func(err error) {
+		logger.Warn("Change event relay error", zap.Error(err))
+	}
+	go func() {
+		if err := pg.ListenChanges(ctx, deliver, onError); err != nil {
+			logger.Error("Change event relay stopped", zap.Error(err))
+		}
+	}()
+	logger.Info("Relaying change events between instances through PostgreSQL")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// authCredentials returns the API keys and JWT settings of cfg.
+func authCredentials(cfg config.AuthConfig) ([]auth.Key, auth.JWT) {
+	keys := make([]auth.Key, 0, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		keys = append(keys, auth.Key{Name: k.Name, KeySHA256: k.KeySHA256, Scopes: k.Scopes, Tenant: k.Tenant})
+	}
+	return keys, auth.JWT{
+		Secret:      cfg.JWT.Secret,
+		Issuer:      cfg.JWT.Issuer,
+		Audience:    cfg.JWT.Audience,
+		ScopeClaim:  cfg.JWT.ScopeClaim,
+		TenantClaim: cfg.JWT.TenantClaim,
+	}
+}
+
+// adminKeys returns the admin API keys of cfg, each granted every scope.
+func adminKeys(cfg []config.AdminKeyConfig) []auth.Key {
+	keys := make([]auth.Key, 0, len(cfg))
+	for _, k := range cfg {
+		keys = append(keys, auth.Key{Name: k.Name, KeySHA256: k.KeySHA256, Scopes: []string{auth.ScopeAdmin}})
+	}
+	return keys
+}
+
+// rateLimits returns the limits of cfg.
+func rateLimits(cfg config.RateLimitConfig) ratelimit.Config {
+	rl := ratelimit.Config{
+		Default: ratelimit.Limit(cfg.Default),
+		Writes:  ratelimit.Limit(cfg.Writes),
+		Methods: make(map[string]ratelimit.Limit, len(cfg.Methods)),
+		Keys:    make(map[string]ratelimit.Limit, len(cfg.Keys)),
+	}
+	for method, rule := range cfg.Methods {
+		rl.Methods[method] = ratelimit.Limit(rule)
+	}
+	for name, rule := range cfg.Keys {
+		rl.Keys[name] = ratelimit.Limit(rule)
+	}
+	return rl
+}
+
+// configReloader applies a reread configuration to the running server: the
+// log level, rate limits, API keys and JWT settings, admin API keys, and write
+// strictness. Turning rate limiting, authentication or the admin listener on
+// or off still needs a restart, as their interceptors and listeners are only
+// set up at startup.
+type configReloader struct {
+	level     zap.AtomicLevel
+	limiter   *ratelimit.Limiter  // nil when rate limiting is off
+	keyAuth   *auth.Authenticator // nil when authentication is off
+	adminAuth *auth.Authenticator // nil without an admin listener
+	svc       *api.GameDNAServiceServer
+	logger    *zap.Logger
+
+	mu  sync.Mutex
+	cfg *config.Config // the configuration applied last
+}
+
+// Reload rereads the configuration and applies what changed, returning the
+// settings it changed. An invalid configuration changes nothing.
+func (r *configReloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	cur := r.cfg
+
+	for _, restart := range []struct {
+		setting string
+		changed bool
+	}{
+		{"rate_limit.enabled", next.RateLimit.Enabled != cur.RateLimit.Enabled},
+		{"auth.enabled", next.Auth.Enabled != cur.Auth.Enabled},
+		{"server.admin_port", next.Server.AdminPort != cur.Server.AdminPort},
+		{"server.admin_methods", !slices.Equal(next.Server.AdminMethods, cur.Server.AdminMethods)},
+		{"server.enable_reflection", next.Server.EnableReflection != cur.Server.EnableReflection},
+	} {
+		if restart.changed {
+			r.logger.Warn("Config setting changed but needs a restart", zap.String("setting", restart.setting))
+		}
+	}
+
+	// Everything is checked before anything is applied
+	rateLimitChanged := r.limiter != nil && next.RateLimit.Enabled && !reflect.DeepEqual(next.RateLimit, cur.RateLimit)
+	if rateLimitChanged {
+		if _, err := ratelimit.New(rateLimits(next.RateLimit), nil); err != nil {
+			return nil, fmt.Errorf("invalid rate limit config: %w", err)
+		}
+	}
+	authChanged := r.keyAuth != nil && next.Auth.Enabled && !reflect.DeepEqual(next.Auth, cur.Auth)
+	if authChanged {
+		if _, err := auth.New(authCredentials(next.Auth)); err != nil {
+			return nil, fmt.Errorf("invalid auth config: %w", err)
+		}
+	}
+	adminAuthChanged := r.adminAuth != nil && next.Server.AdminPort != 0 &&
+		!reflect.DeepEqual(next.Server.AdminAPIKeys, cur.Server.AdminAPIKeys)
+	if adminAuthChanged {
+		if _, err := auth.New(adminKeys(next.Server.AdminAPIKeys), auth.JWT{}); err != nil {
+			return nil, fmt.Errorf("invalid admin API keys: %w", err)
+		}
+	}
+
+	var changed []string
+	if next.Logging.Level != cur.Logging.Level {
+		r.level.SetLevel(logLevel(next.Logging.Level))
+		changed = append(changed, "logging.level")
+	}
+	if rateLimitChanged {
+		r.limiter.SetConfig(rateLimits(next.RateLimit))
+		changed = append(changed, "rate_limit")
+	}
+	if authChanged {
+		r.keyAuth.Reload(authCredentials(next.Auth))
+		changed = append(changed, "auth")
+	}
+	if adminAuthChanged {
+		r.adminAuth.Reload(adminKeys(next.Server.AdminAPIKeys), auth.JWT{})
+		changed = append(changed, "server.admin_api_keys")
+	}
+	if next.Validation.WriteStrictness != cur.Validation.WriteStrictness ||
+		!slices.Equal(next.Validation.StrictEnvironments, cur.Validation.StrictEnvironments) {
+		if err := r.svc.SetWriteStrictness(next.Validation.WriteStrictness, next.Validation.StrictEnvironments); err != nil {
+			return changed, err
+		}
+		changed = append(changed, "validation.write_strictness")
+	}
+	r.cfg = next
+
+	r.logger.Info("Config reloaded", zap.Strings("changed", changed))
+	return changed, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+)
+
+const repairVersionsTimeout = 5 * time.Minute
+
+// parseRepairVersionsArgs parses the arguments of --repair-versions and
+// reports whether it is a dry run.
+func parseRepairVersionsArgs(args []string) (bool, error) {
+	switch {
+	case len(args) == 0:
+		return false, nil
+	case len(args) == 1 && args[0] == "--dry-run":
+		return true, nil
+	}
+	return false, fmt.Errorf("usage: server --repair-versions [--dry-run]")
+}
+
+// runRepairVersions finds the configs of the configured database, or of every
+// regional database when data residency is set, that have no version row, and
+// prints them to out. Unless dryRun, each is given a version 1 holding its
+// current content. It backs `server --repair-versions`, for databases written
+// by builds that did not write a config and its first version in one
+// transaction.
+func runRepairVersions(cfg *config.Config, dryRun bool, logger *zap.Logger, out io.Writer) error {
+	urls := map[string]string{"": cfg.Database.URL}
+	if cfg.Residency.Enabled() {
+		urls = cfg.Residency.Databases
+	}
+	regions := make([]string, 0, len(urls))
+	for region := range urls {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	action := "repaired"
+	if dryRun {
+		action = "missing versions"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), repairVersionsTimeout)
+	defer cancel()
+	for _, region := range regions {
+		url := urls[region]
+		label := "database"
+		if region != "" {
+			label = "region " + region
+		}
+		if url == "" || url == "memory" {
+			return fmt.Errorf("%s: in-memory storage has nothing to repair", label)
+		}
+
+		pgStore, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+		if err != nil {
+			return fmt.Errorf("%s: failed to connect: %w", label, err)
+		}
+		logger.Info("Checking configs for missing versions", zap.String("region", region), zap.Bool("dry_run", dryRun))
+		ids, err := pgStore.RepairMissingVersions(ctx, dryRun)
+		pgStore.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+		for _, id := range ids {
+			fmt.Fprintf(out, "%s: %s %s\n", label, action, id)
+		}
+		fmt.Fprintf(out, "%s: %d configs without versions\n", label, len(ids))
+	}
+	return nil
+}
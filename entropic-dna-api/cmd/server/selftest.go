@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/ffi"
+	"go.uber.org/zap"
+)
+
+const selfTestTimeout = 30 * time.Second
+
+// selfTestStep is one stage of the self-test and what it reported.
+type selfTestStep struct {
+	name    string
+	run     func(ctx context.Context) (string, error)
+	ran     bool
+	detail  string
+	err     error
+	elapsed time.Duration
+}
+
+// runSelfTest drives a throwaway config through the service against the
+// configured store and validation engine, prints a report to out and returns
+// an error if any step failed. It backs `server --self-test` for deployment
+// smoke tests and support diagnostics.
+//
+// Rollback runs before publish: the Postgres store refuses to change a
+// published (locked) config.
+func runSelfTest(cfg *config.Config, logger *zap.Logger, out io.Writer) error {
+	store, err := openStore(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rust, err := ffi.NewRustFFI(cfg.Rust.LibPath, cfg.Rust.Enabled)
+	if err != nil {
+		return fmt.Errorf("failed to init Rust FFI: %w", err)
+	}
+	defer rust.Close()
+	if err := configureValidation(rust, cfg, logger); err != nil {
+		return err
+	}
+
+	svc := api.NewGameDNAServiceServer(store, rust, serviceOptions(cfg), zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	var id string
+	deleted := false
+	steps := []*selfTestStep{
+		{name: "create", run: func(ctx context.Context) (string, error) {
+			resp, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+				Name:            fmt.Sprintf("Self-test %d", time.Now().UnixNano()),
+				Genre:           "FPS",
+				TargetFps:       30,
+				TimeScale:       1,
+				TargetPlatforms: []string{"PC"},
+				CreatedBy:       "self-test",
+			}})
+			if err != nil {
+				return "", err
+			}
+			id = resp.GameDna.Id
+			return "config " + id, nil
+		}},
+		{name: "validate", run: func(ctx context.Context) (string, error) {
+			resp, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{Id: id})
+			if err != nil {
+				return "", err
+			}
+			if !resp.IsValid {
+				return "", fmt.Errorf("stored config is invalid: %d errors", len(resp.Errors))
+			}
+			return fmt.Sprintf("rules %s, %d warnings", resp.RulesVersion, len(resp.Warnings)), nil
+		}},
+		{name: "update", run: func(ctx context.Context) (string, error) {
+			current, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id})
+			if err != nil {
+				return "", err
+			}
+			changed := current.GameDna
+			changed.TargetFps = 60
+			changed.Checksum = ""
+			if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: changed}); err != nil {
+				return "", err
+			}
+			return "target_fps 30 -> 60", nil
+		}},
+		{name: "rollback", run: func(ctx context.Context) (string, error) {
+			resp, err := svc.RollbackToVersion(ctx, &pb.RollbackToVersionRequest{ConfigId: id, VersionNum: 1})
+			if err != nil {
+				return "", err
+			}
+			if resp.GameDna.TargetFps != 30 {
+				return "", fmt.Errorf("expected target_fps 30 after rollback, got %d", resp.GameDna.TargetFps)
+			}
+			return "back to version 1", nil
+		}},
+		{name: "publish", run: func(ctx context.Context) (string, error) {
+			resp, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+			if err != nil {
+				return "", err
+			}
+			if !resp.GameDna.IsLocked {
+				return "", fmt.Errorf("published config is not locked")
+			}
+			return "locked", nil
+		}},
+		{name: "delete", run: func(ctx context.Context) (string, error) {
+			if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: id}); err != nil {
+				return "", err
+			}
+			deleted = true
+			if _, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id}); err == nil {
+				return "", fmt.Errorf("config still readable after delete")
+			}
+			return "removed", nil
+		}},
+	}
+
+	var failure error
+	for _, step := range steps {
+		start := time.Now()
+		step.detail, step.err = step.run(ctx)
+		step.elapsed = time.Since(start)
+		step.ran = true
+		if step.err != nil {
+			failure = fmt.Errorf("%s: %w", step.name, step.err)
+			break
+		}
+	}
+
+	// Don't leave the throwaway config behind when a step failed midway.
+	if id != "" && !deleted {
+		if err := store.Delete(context.Background(), id); err != nil {
+			logger.Warn("Failed to clean up self-test config", zap.String("id", id), zap.Error(err))
+		}
+	}
+
+	fmt.Fprintf(out, "Entropic DNA API self-test\n")
+	fmt.Fprintf(out, "  store:  %T\n", store)
+	fmt.Fprintf(out, "  engine: rust enabled=%t\n\n", cfg.Rust.Enabled)
+	for _, step := range steps {
+		switch {
+		case !step.ran:
+			fmt.Fprintf(out, "  SKIP  %s\n", step.name)
+		case step.err != nil:
+			fmt.Fprintf(out, "  FAIL  %-9s %8s  %v\n", step.name, step.elapsed.Round(time.Microsecond), step.err)
+		default:
+			fmt.Fprintf(out, "  ok    %-9s %8s  %s\n", step.name, step.elapsed.Round(time.Microsecond), step.detail)
+		}
+	}
+
+	if failure != nil {
+		return failure
+	}
+	fmt.Fprintln(out, "\nAll steps passed")
+	return nil
+}
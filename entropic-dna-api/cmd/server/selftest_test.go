@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// TestSelfTestLoadsValidationConfig checks the self-test validates against
+// the rules the server would load, not just the built-in ones.
+func TestSelfTestLoadsValidationConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Database.URL = "memory"
+
+	var out strings.Builder
+	if err := runSelfTest(cfg, zap.NewNop(), &out); err != nil {
+		t.Fatalf("Expected the self-test to pass with the built-in rules, got %v\n%s", err, out.String())
+	}
+
+	// The self-test config is an FPS game, which this rule set retires.
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	data := `version: smoke/1
+rules:
+  - id: FPS_RETIRED
+    severity: error
+    field: genre
+    message: FPS games are retired
+    when: [{field: genre, equals: FPS}]
+    require: [{field: genre, equals: Racing}]
+`
+	if err := os.WriteFile(rules, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cfg.Validation.RulesFile = rules
+	out.Reset()
+	if err := runSelfTest(cfg, zap.NewNop(), &out); err == nil || !strings.Contains(out.String(), "FAIL  create") {
+		t.Errorf("Expected the rules file to fail the create step, got %v\n%s", err, out.String())
+	}
+
+	cfg.Validation.RulesFile = filepath.Join(t.TempDir(), "missing.yaml")
+	if err := runSelfTest(cfg, zap.NewNop(), &out); err == nil || !strings.Contains(err.Error(), "validation rules") {
+		t.Errorf("Expected a missing rules file to fail the self-test, got %v", err)
+	}
+
+	cfg.Validation.RulesFile = ""
+	cfg.Validation.PublishMaxWarnings = 7
+	if got := serviceOptions(cfg).PublishMaxWarnings; got != 7 {
+		t.Errorf("Expected the service options to follow the config, got %d", got)
+	}
+}
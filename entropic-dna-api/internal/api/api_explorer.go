@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// apiExplorerPath serves Swagger UI.
+const apiExplorerPath = "/docs"
+
+// apiExplorerPage loads Swagger UI from the assets URL and points it at the
+// gateway's OpenAPI document. The document's URL is relative, so the page
+// works behind a proxy that mounts the gateway under a prefix.
+var apiExplorerPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Entropic DNA API</title>
+<link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.onload = function () {
+  window.ui = SwaggerUIBundle({
+    url: {{.Spec}},
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    tryItOutEnabled: true,
+  });
+};
+</script>
+</body>
+</html>
+`))
+
+// serveAPIExplorer serves a Swagger UI page for trying the REST API, with
+// its scripts and styles from assets, the base URL of a swagger-ui-dist
+// release.
+func serveAPIExplorer(assets string) (http.Handler, error) {
+	var page bytes.Buffer
+	err := apiExplorerPage.Execute(&page, struct {
+		Assets string
+		Spec   string
+	}{
+		Assets: strings.TrimSuffix(assets, "/"),
+		Spec:   strings.TrimPrefix(openAPIPath, "/"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page.Bytes())
+	}), nil
+}
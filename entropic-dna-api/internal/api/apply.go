@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Apply actions.
+const (
+	applyActionCreate = "create"
+	applyActionUpdate = "update"
+	applyActionNoop   = "noop"
+)
+
+// ApplyGameDNA converges the config identified by the desired state's slug on
+// that state, so infrastructure-as-code pipelines can apply the same
+// definition repeatedly. The desired state is complete: fields it leaves empty
+// are cleared. In plan mode the changes are reported but not written.
+func (s *GameDNAServiceServer) ApplyGameDNA(ctx context.Context, req *pb.ApplyGameDNARequest) (*pb.ApplyGameDNAResponse, error) {
+	desired := req.GetGameDna()
+	if desired == nil {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+	}
+
+	if desired.Slug == "" {
+		return nil, status.Error(codes.InvalidArgument, "slug is required to apply a config")
+	}
+	if err := checkSlug(desired); err != nil {
+		return nil, err
+	}
+	if err := checkEnvironment(desired); err != nil {
+		return nil, err
+	}
+
+	current, err := s.store.ReadBySlug(ctx, desired.Slug)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Error("Failed to read game DNA for apply", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	if err != nil {
+		current = nil
+	}
+
+	dna := applyTarget(current, desired, req.Actor)
+	changes := diff.Compare(current, dna)
+	resp := &pb.ApplyGameDNAResponse{
+		GameDna: dna,
+		Changes: fieldChangesToProto(changes),
+		Planned: req.Plan,
+	}
+
+	// The lock is checked first, as UpdateGameDNA does, so a locked config
+	// is reported even when it matches the desired state.
+	if current != nil && current.IsLocked {
+		return nil, status.Errorf(codes.FailedPrecondition, "config %s is published and locked; %d fields differ from the desired state", desired.Slug, len(changes))
+	}
+	if current != nil && len(changes) == 0 {
+		resp.Action = applyActionNoop
+		resp.GameDna = current
+		resp.Message = "Game DNA already matches the desired state"
+		return resp, nil
+	}
+	resp.Action = applyActionUpdate
+	if current == nil {
+		resp.Action = applyActionCreate
+	}
+
+	validationResp, err := s.validate(ctx, dna)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if !validationResp.IsValid {
+		s.logger.Warn("Validation failed for apply", zap.String("slug", desired.Slug), zap.Int("errors", len(validationResp.Errors)))
+		return nil, validationFailedError(fmt.Sprintf("validation failed: %d errors", len(validationResp.Errors)), validationResp)
+	}
+	resp.Validation = validationResp
+
+	if req.Plan {
+		resp.Message = fmt.Sprintf("Plan: %s with %d changes", resp.Action, len(changes))
+		return resp, nil
+	}
+
+	checksum, err := s.rust.CalculateChecksum(dna)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	dna.Checksum = checksum
+
+	if current == nil {
+		created, err := s.store.Create(ctx, dna, storage.WithValidation(validationResp))
+		if err != nil {
+			s.logger.Error("Failed to create game DNA", zap.Error(err))
+			return nil, fmt.Errorf("failed to create game DNA: %w", err)
+		}
+		s.changed(ctx, events.TypeCreated, created.Id, created)
+		resp.GameDna = created
+		resp.Message = "Game DNA created"
+		return resp, nil
+	}
+
+	updated, err := s.store.Update(ctx, dna, storage.WithValidation(validationResp))
+	if errors.Is(err, storage.ErrNotModified) {
+		resp.Action = applyActionNoop
+		resp.GameDna = updated
+		resp.Changes = nil
+		resp.Message = "Game DNA already matches the desired state"
+		return resp, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to update game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to update game DNA: %w", err)
+	}
+	s.changed(ctx, events.TypeUpdated, updated.Id, updated)
+	resp.GameDna = updated
+	resp.Message = fmt.Sprintf("Game DNA updated: %d changes", len(changes))
+	return resp, nil
+}
+
+// applyTarget builds the config an apply writes: the desired state with the
+// server-managed fields carried over from the current config, if any.
+func applyTarget(current, desired *pb.GameDNA, actor string) *pb.GameDNA {
+	dna := models.Clone(desired)
+	models.ClearServerFields(dna)
+	if current != nil {
+		dna.Id = current.Id
+		dna.CreatedAt = current.CreatedAt
+		dna.LastModified = current.LastModified
+		dna.CreateTime = current.CreateTime
+		dna.UpdateTime = current.UpdateTime
+		dna.Checksum = current.Checksum
+		dna.IsLocked = current.IsLocked
+		dna.PublishedRulesVersion = current.PublishedRulesVersion
+		dna.CreatedBy = current.CreatedBy
+		if dna.Version == "" {
+			dna.Version = current.Version
+		}
+	}
+	if actor != "" {
+		dna.CreatedBy = actor
+	}
+	return dna
+}
+
+func fieldChangesToProto(changes []diff.Change) []*pb.FieldChange {
+	out := make([]*pb.FieldChange, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, &pb.FieldChange{
+			Field:    c.Field,
+			Section:  c.Section,
+			OldValue: c.Old,
+			NewValue: c.New,
+			Risky:    c.Risky,
+		})
+	}
+	return out
+}
+
+// versionChangesProto is fieldChangesToProto leaving versions without
+// recorded changes unset.
+func versionChangesProto(changes []diff.Change) []*pb.FieldChange {
+	if changes == nil {
+		return nil
+	}
+	return fieldChangesToProto(changes)
+}
+
+// fieldChangesFromProto converts an exported version's changes for the store.
+// A version exported without any is taken as not having them recorded.
+func fieldChangesFromProto(changes []*pb.FieldChange) []diff.Change {
+	if len(changes) == 0 {
+		return nil
+	}
+	out := make([]diff.Change, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, diff.Change{
+			Field:   c.Field,
+			Section: c.Section,
+			Old:     c.OldValue,
+			New:     c.NewValue,
+			Risky:   c.Risky,
+		})
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnpublishGameDNA unlocks a published config so it can be edited again. It
+// writes a version and records who unpublished the config, and why, in its
+// audit log.
+func (s *GameDNAServiceServer) UnpublishGameDNA(ctx context.Context, req *pb.UnpublishGameDNARequest) (*pb.UnpublishGameDNAResponse, error) {
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for unpublish", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	unpublished, entry, err := s.store.UnpublishVersion(ctx, current.Id, actor, req.Reason)
+	if err != nil {
+		s.logger.Error("Failed to unpublish game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to unpublish game DNA: %w", err)
+	}
+
+	s.logger.Info("Game DNA unpublished",
+		zap.String("id", unpublished.Id),
+		zap.String("actor", actor),
+		zap.String("reason", req.Reason),
+	)
+	s.changed(ctx, events.TypeUnpublished, unpublished.Id, unpublished)
+
+	return &pb.UnpublishGameDNAResponse{
+		GameDna:    unpublished,
+		AuditEntry: auditEntryProto(entry),
+		Message:    "Game DNA unpublished and unlocked successfully",
+	}, nil
+}
+
+// GetAuditLog lists the administrative actions taken on a config.
+func (s *GameDNAServiceServer) GetAuditLog(ctx context.Context, req *pb.GetAuditLogRequest) (*pb.GetAuditLogResponse, error) {
+	configID := req.ConfigId
+	// The log outlives its config, so only slugs need the config to exist.
+	if current, err := s.readConfig(ctx, req.ConfigId); err == nil {
+		configID = current.Id
+	}
+
+	entries, err := s.store.ListAuditEntries(ctx, configID)
+	if err != nil {
+		s.logger.Error("Failed to list audit entries", zap.Error(err))
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	resp := &pb.GetAuditLogResponse{}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, auditEntryProto(entry))
+	}
+	return resp, nil
+}
+
+func auditEntryProto(e *storage.AuditEntry) *pb.AuditLogEntry {
+	return &pb.AuditLogEntry{
+		Id:         e.ID,
+		ConfigId:   e.ConfigID,
+		Action:     e.Action,
+		Actor:      e.Actor,
+		Reason:     e.Reason,
+		VersionNum: e.VersionNum,
+		CreateTime: models.TimestampProto(e.CreatedAt),
+		ApprovedBy: e.ApprovedBy,
+	}
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Batch item statuses.
+const (
+	batchCreated    = "created"
+	batchUpdated    = "updated"
+	batchUnchanged  = "unchanged"
+	batchInvalid    = "invalid"
+	batchFailed     = "failed"
+	batchNotApplied = "not_applied"
+)
+
+// BatchCreateGameDNA validates every item, then creates them all in one
+// store transaction. If any item fails, nothing is created and the error
+// carries the per-item results.
+func (s *GameDNAServiceServer) BatchCreateGameDNA(ctx context.Context, req *pb.BatchCreateGameDNARequest) (*pb.BatchGameDNAResponse, error) {
+	if err := s.checkBatchSize(len(req.Requests)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchItemResult, len(req.Requests))
+	set := storage.SaveSet{}
+	for i, item := range req.Requests {
+		results[i] = &pb.BatchItemResult{Index: int32(i)}
+		dna := item.GetGameDna()
+		if dna == nil {
+			failBatchItem(results[i], status.Error(codes.InvalidArgument, "game_dna is required"))
+			continue
+		}
+		dna, err := s.runPreHooks(ctx, hooks.PreCreate, "", dna)
+		if err != nil {
+			failBatchItem(results[i], err)
+			continue
+		}
+		if err := checkSlug(dna); err != nil {
+			failBatchItem(results[i], err)
+			continue
+		}
+		if err := checkEnvironment(dna); err != nil {
+			failBatchItem(results[i], err)
+			continue
+		}
+		if err := checkTimestamps(dna); err != nil {
+			failBatchItem(results[i], err)
+			continue
+		}
+		if dna.Slug == "" {
+			dna.Slug = storage.Slugify(dna.Name)
+		}
+		report, err := s.validateBatchItem(ctx, dna, results[i])
+		if err != nil {
+			return nil, err
+		}
+		set.Creates = append(set.Creates, dna)
+		set.CreateReports = append(set.CreateReports, report)
+	}
+	if rejected := rejectedBatch(results); rejected != nil {
+		return nil, rejected
+	}
+
+	saved, err := s.store.SaveSet(ctx, set)
+	if err != nil {
+		s.logger.Error("Failed to batch create game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to batch create game DNA: %w", err)
+	}
+	for i, dna := range saved.Creates {
+		results[i].Status = batchCreated
+		results[i].GameDna = dna
+		s.changed(ctx, events.TypeCreated, dna.Id, dna)
+		s.runPostHooks(ctx, hooks.PostCreate, dna)
+	}
+
+	return &pb.BatchGameDNAResponse{
+		Results: results,
+		Message: fmt.Sprintf("%d configs created", len(saved.Creates)),
+	}, nil
+}
+
+// BatchUpdateGameDNA validates every item, each merged through its update
+// mask when it has one, then applies them all in one store transaction.
+// Items matching the stored config are reported unchanged and add no
+// version. If any item fails, nothing is updated and the error carries the
+// per-item results.
+func (s *GameDNAServiceServer) BatchUpdateGameDNA(ctx context.Context, req *pb.BatchUpdateGameDNARequest) (*pb.BatchGameDNAResponse, error) {
+	if err := s.checkBatchSize(len(req.Requests)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchItemResult, len(req.Requests))
+	set := storage.SaveSet{}
+	byID := make(map[string]*pb.BatchItemResult)
+	for i, item := range req.Requests {
+		results[i] = &pb.BatchItemResult{Index: int32(i)}
+		dna, err := s.batchUpdateTarget(ctx, item)
+		if err == nil && byID[item.Id] != nil {
+			err = status.Errorf(codes.InvalidArgument, "config %s is updated more than once in the batch", item.Id)
+		}
+		if err != nil {
+			failBatchItem(results[i], err)
+			continue
+		}
+		byID[item.Id] = results[i]
+		report, err := s.validateBatchItem(ctx, dna, results[i])
+		if err != nil {
+			return nil, err
+		}
+		set.Updates = append(set.Updates, dna)
+		set.UpdateReports = append(set.UpdateReports, report)
+	}
+	if rejected := rejectedBatch(results); rejected != nil {
+		return nil, rejected
+	}
+
+	saved, err := s.store.SaveSet(ctx, set)
+	if err != nil {
+		s.logger.Error("Failed to batch update game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to batch update game DNA: %w", err)
+	}
+	for _, dna := range saved.Updates {
+		byID[dna.Id].Status = batchUpdated
+		byID[dna.Id].GameDna = dna
+		s.changed(ctx, events.TypeUpdated, dna.Id, dna)
+		s.runPostHooks(ctx, hooks.PostUpdate, dna)
+	}
+	for _, dna := range saved.Unchanged {
+		byID[dna.Id].Status = batchUnchanged
+		byID[dna.Id].GameDna = dna
+	}
+
+	return &pb.BatchGameDNAResponse{
+		Results: results,
+		Message: fmt.Sprintf("%d configs updated, %d unchanged", len(saved.Updates), len(saved.Unchanged)),
+	}, nil
+}
+
+// checkBatchSize rejects empty batches and ones over MaxBatchSize.
+func (s *GameDNAServiceServer) checkBatchSize(n int) error {
+	if n == 0 {
+		return status.Error(codes.InvalidArgument, "requests must contain at least one item")
+	}
+	if max := s.opts.MaxBatchSize; max > 0 && n > max {
+		return status.Errorf(codes.InvalidArgument, "a batch may contain at most %d items, got %d", max, n)
+	}
+	return nil
+}
+
+// batchUpdateTarget returns the config an update item would store: its
+// game_dna, or the stored config with the masked fields replaced, after the
+// pre_update hooks, under the item's ID and keeping the stored slug when it
+// has none.
+func (s *GameDNAServiceServer) batchUpdateTarget(ctx context.Context, item *pb.UpdateGameDNARequest) (*pb.GameDNA, error) {
+	if item.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	current, err := s.store.Read(ctx, item.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	if current.IsLocked {
+		return nil, fmt.Errorf("config %s: %w", item.Id, storage.ErrLocked)
+	}
+
+	dna := item.GameDna
+	if paths := item.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		fields, err := updateMaskFields(paths)
+		if err != nil {
+			return nil, err
+		}
+		dna = mergeFields(current, item.GetGameDna(), fields)
+	}
+	if dna == nil {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+	}
+	dna, err = s.runPreHooks(ctx, hooks.PreUpdate, item.Id, dna)
+	if err != nil {
+		return nil, err
+	}
+	dna = models.Clone(dna)
+	dna.Id = item.Id
+	if err := checkSlug(dna); err != nil {
+		return nil, err
+	}
+	if err := checkEnvironment(dna); err != nil {
+		return nil, err
+	}
+	if dna.Slug == "" {
+		dna.Slug = current.Slug
+	}
+	return dna, nil
+}
+
+// validateBatchItem validates dna and stamps its checksum, recording the
+// report on result. Findings only mark the item invalid; the error is for
+// failures of validation itself.
+func (s *GameDNAServiceServer) validateBatchItem(ctx context.Context, dna *pb.GameDNA, result *pb.BatchItemResult) (*pb.ValidationResponse, error) {
+	report, err := s.validate(ctx, dna)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	result.Validation = report
+	if !report.IsValid {
+		result.Status = batchInvalid
+		result.Error = fmt.Sprintf("validation failed: %d errors", len(report.Errors))
+		return report, nil
+	}
+
+	checksum, err := s.rust.CalculateChecksum(dna)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	dna.Checksum = checksum
+	return report, nil
+}
+
+// failBatchItem marks result failed with err's message.
+func failBatchItem(result *pb.BatchItemResult, err error) {
+	result.Status = batchFailed
+	result.Error = status.Convert(err).Message()
+}
+
+// rejectedBatch returns nil when every item passed. Otherwise it marks the
+// passing items not applied and returns an InvalidArgument error with a
+// field violation per finding, as "requests[i].field", and the results
+// attached.
+func rejectedBatch(results []*pb.BatchItemResult) error {
+	rejected := 0
+	br := &errdetails.BadRequest{}
+	for _, result := range results {
+		switch result.Status {
+		case batchInvalid:
+			for _, e := range result.Validation.Errors {
+				br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       fmt.Sprintf("requests[%d].%s", result.Index, e.Field),
+					Description: e.Message,
+				})
+			}
+		case batchFailed:
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("requests[%d]", result.Index),
+				Description: result.Error,
+			})
+		default:
+			continue
+		}
+		rejected++
+	}
+	if rejected == 0 {
+		return nil
+	}
+	for _, result := range results {
+		if result.Status == "" {
+			result.Status = batchNotApplied
+		}
+	}
+
+	msg := fmt.Sprintf("batch rejected: %d of %d items failed", rejected, len(results))
+	st := status.New(codes.InvalidArgument, msg)
+	if withDetails, err := st.WithDetails(br, &pb.BatchGameDNAResponse{Results: results, Message: msg}); err == nil {
+		return withDetails.Err()
+	}
+	return st.Err()
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BlameGameDNA reports, for every field with a value, the version that last
+// changed it and who wrote that version.
+func (s *GameDNAServiceServer) BlameGameDNA(ctx context.Context, req *pb.BlameGameDNARequest) (*pb.BlameGameDNAResponse, error) {
+	versions, err := s.store.GetVersionHistory(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to get version history", zap.Error(err))
+		return nil, fmt.Errorf("failed to get version history: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no versions for config: %s", req.ConfigId)
+	}
+
+	versions = append([]*storage.VersionInfo(nil), versions...)
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].VersionNum < versions[j].VersionNum
+	})
+	lastChanged := blame(versions)
+	latest := versions[len(versions)-1]
+	resp := &pb.BlameGameDNAResponse{
+		ConfigId:   req.ConfigId,
+		VersionNum: latest.VersionNum,
+	}
+	// Comparing against an empty config lists every field that has a value.
+	for _, c := range diff.Compare(nil, latest.Data) {
+		v := lastChanged[c.Field]
+		if v == nil {
+			v = versions[0]
+		}
+		resp.Fields = append(resp.Fields, &pb.FieldBlame{
+			Field:      c.Field,
+			Section:    c.Section,
+			Value:      c.New,
+			VersionNum: v.VersionNum,
+			ChangedBy:  v.CreatedBy,
+			ChangedAt:  models.FormatTime(v.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// blame maps each field to the last version that changed it. versions must be
+// oldest first. Versions written before changed fields were recorded fall back
+// to diffing against their predecessor.
+func blame(versions []*storage.VersionInfo) map[string]*storage.VersionInfo {
+	lastChanged := make(map[string]*storage.VersionInfo)
+	var prev *pb.GameDNA
+	for _, v := range versions {
+		changed := v.ChangedFields
+		if changed == nil {
+			changed = diff.ChangedFields(prev, v.Data)
+		}
+		for _, field := range changed {
+			lastChanged[field] = v
+		}
+		prev = v.Data
+	}
+	return lastChanged
+}
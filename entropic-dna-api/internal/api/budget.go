@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/budget"
+	"github.com/entropic-engine/entropic-dna-api/internal/schema"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EstimatePerformanceBudget estimates a config's cost on each platform it
+// targets with the cost model. The config is estimated as the engine would
+// run it: with the values it inherits and the engine defaults filled in.
+func (s *GameDNAServiceServer) EstimatePerformanceBudget(ctx context.Context, req *pb.EstimatePerformanceBudgetRequest) (*pb.EstimatePerformanceBudgetResponse, error) {
+	dna := req.GameDna
+	if dna == nil {
+		if req.Id == "" {
+			return nil, status.Error(codes.InvalidArgument, "either id or game_dna must be provided")
+		}
+		stored, err := s.readConfig(ctx, req.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read game DNA: %w", err)
+		}
+		dna = stored
+	}
+	dna, err := s.effective(ctx, dna)
+	if err != nil {
+		return nil, err
+	}
+	defaults := schema.LatestDefaults()
+	dna = defaults.Materialize(dna)
+
+	model := s.opts.CostModel
+	if model == nil {
+		model = budget.DefaultModel
+	}
+	resp := &pb.EstimatePerformanceBudgetResponse{
+		Feasible:        true,
+		Model:           model.Name(),
+		DefaultsVersion: defaults.Version,
+	}
+	for _, platform := range dna.TargetPlatforms {
+		e := model.Estimate(dna, platform)
+		resp.Budgets = append(resp.Budgets, &pb.PerformanceBudget{
+			Platform:       e.Platform,
+			MemoryMb:       e.MemoryMB,
+			MemoryBudgetMb: e.MemoryBudgetMB,
+			CpuMs:          e.CPUMs,
+			CpuBudgetMs:    e.CPUBudgetMs,
+			DrawCalls:      e.DrawCalls,
+			DrawCallBudget: e.DrawCallBudget,
+			OverBudget:     e.Over,
+			Feasible:       e.Feasible(),
+		})
+		resp.Feasible = resp.Feasible && e.Feasible()
+	}
+	s.logger.Debug("Estimated performance budget",
+		zap.String("id", dna.Id),
+		zap.String("model", resp.Model),
+		zap.Bool("feasible", resp.Feasible))
+	return resp, nil
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Export archive formats.
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatZip    = "zip"
+)
+
+// Content types of the export formats.
+const (
+	ndjsonContentType = "application/x-ndjson"
+	zipContentType    = "application/zip"
+)
+
+// Import result statuses, in addition to the bulk update and sheet import ones.
+const importStatusSkipped = "skipped"
+
+// exportChunkSize is the size of the chunks an export is streamed in.
+const exportChunkSize = 64 << 10
+
+// maxImportArchiveBytes bounds a single import upload, and what a zip upload
+// decompresses to across all of its files.
+const maxImportArchiveBytes = 256 << 20
+
+// maxImportArchiveEntries bounds the number of files in a zip upload.
+const maxImportArchiveEntries = 100000
+
+// exportEntryPrefix is the directory holding one file per config in a zip export.
+const exportEntryPrefix = "configs/"
+
+// ExportGameDNA streams the configs matching the request as NDJSON, one
+// GameDNAExport per line, or as a zip archive with one JSON file per config.
+func (s *GameDNAServiceServer) ExportGameDNA(req *pb.ExportGameDNARequest, stream pb.GameDNAService_ExportGameDNAServer) error {
+	ctx := stream.Context()
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = exportFormatNDJSON
+	}
+	var contentType string
+	switch format {
+	case exportFormatNDJSON:
+		contentType = ndjsonContentType
+	case exportFormatZip:
+		contentType = zipContentType
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported export format %q: want ndjson or zip", req.Format)
+	}
+
+	ids := make(map[string]bool, len(req.Ids))
+	for _, id := range req.Ids {
+		ids[id] = true
+	}
+	// Collect matches up front: reading history from inside Each could
+	// deadlock stores that hold a lock while iterating.
+	var matched []*pb.GameDNA
+	err := s.store.Each(ctx, storage.ListFilters{
+		Tags:       req.Tags,
+		Genre:      req.Genre,
+		NameFilter: req.NameFilter,
+	}, func(dna *pb.GameDNA) error {
+		if len(ids) == 0 || ids[dna.Id] {
+			matched = append(matched, models.Clone(dna))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to list configs", zap.Error(err))
+		return fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	out := bufio.NewWriterSize(&exportChunkWriter{stream: stream, contentType: contentType}, exportChunkSize)
+	var zw *zip.Writer
+	if format == exportFormatZip {
+		zw = zip.NewWriter(out)
+	}
+	for _, dna := range matched {
+		record := &pb.GameDNAExport{Config: dna}
+		if req.IncludeHistory {
+			versions, err := s.store.GetVersionHistory(ctx, dna.Id)
+			if err != nil {
+				s.logger.Error("Failed to get version history", zap.String("id", dna.Id), zap.Error(err))
+				return fmt.Errorf("failed to get version history of %s: %w", dna.Id, err)
+			}
+			for _, v := range versions {
+				record.Versions = append(record.Versions, versionInfoProto(v))
+			}
+		}
+		line, err := protojson.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode config %s: %w", dna.Id, err)
+		}
+
+		if zw != nil {
+			f, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     exportEntryPrefix + dna.Id + ".json",
+				Method:   zip.Deflate,
+				Modified: models.TimeFromProto(dna.UpdateTime),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+			_, err = f.Write(line)
+			if err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+			continue
+		}
+		line = append(line, '\n')
+		if _, err := out.Write(line); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return nil
+}
+
+// exportChunkWriter sends everything written to it as HttpBody chunks.
+type exportChunkWriter struct {
+	stream      pb.GameDNAService_ExportGameDNAServer
+	contentType string
+}
+
+func (w *exportChunkWriter) Write(p []byte) (int, error) {
+	chunk := &httpbody.HttpBody{
+		ContentType: w.contentType,
+		Data:        append([]byte(nil), p...),
+	}
+	if err := w.stream.Send(chunk); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ImportGameDNA restores configs from an ExportGameDNA archive. New configs
+// keep their IDs and, when the archive has it, their version history. Existing
+// configs are skipped, or with overwrite updated to the exported content as a
+// new version. Configs are restored as exported, without validation.
+func (s *GameDNAServiceServer) ImportGameDNA(stream pb.GameDNAService_ImportGameDNAServer) error {
+	ctx := stream.Context()
+
+	var opts *pb.ImportGameDNARequest
+	var data []byte
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if opts == nil {
+			opts = req
+		}
+		if len(data)+len(req.Data) > maxImportArchiveBytes {
+			return status.Errorf(codes.InvalidArgument, "archive exceeds the %d byte limit", maxImportArchiveBytes)
+		}
+		data = append(data, req.Data...)
+	}
+	if opts == nil {
+		opts = &pb.ImportGameDNARequest{}
+	}
+
+	records, err := readExport(opts.Format, data)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &pb.ImportGameDNAResponse{Counts: make(map[string]int32)}
+	for _, record := range records {
+		result := s.importOne(ctx, record, opts.Overwrite, opts.DryRun)
+		resp.Results = append(resp.Results, result)
+		resp.Counts[result.Status]++
+	}
+	resp.Message = fmt.Sprintf("Processed %d configs", len(records))
+
+	return stream.SendAndClose(resp)
+}
+
+// importOne restores a single exported config and reports the outcome.
+func (s *GameDNAServiceServer) importOne(ctx context.Context, record *pb.GameDNAExport, overwrite, dryRun bool) *pb.ImportGameDNAResult {
+	dna := record.Config
+	result := &pb.ImportGameDNAResult{
+		ConfigId: dna.Id,
+		Name:     dna.Name,
+	}
+	failed := func(err error) *pb.ImportGameDNAResult {
+		s.logger.Warn("Import failed for config", zap.String("id", dna.Id), zap.Error(err))
+		result.Status = bulkStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	var existing *pb.GameDNA
+	if dna.Id != "" {
+		current, err := s.store.Read(ctx, dna.Id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+		case err != nil:
+			return failed(err)
+		default:
+			existing = current
+		}
+	}
+
+	if existing == nil {
+		versions, err := importVersions(record.Versions)
+		if err != nil {
+			return failed(err)
+		}
+		result.Versions = int32(len(versions))
+		if len(versions) == 0 {
+			result.Versions = 1
+		}
+		if dryRun {
+			result.Status = importStatusWouldCreate
+			return result
+		}
+		created, err := s.store.ImportConfig(ctx, dna, versions)
+		if err != nil {
+			return failed(err)
+		}
+		result.ConfigId = created.Id
+		result.Status = importStatusCreated
+		s.changed(ctx, events.TypeCreated, created.Id, created)
+		return result
+	}
+
+	switch {
+	case !overwrite:
+		result.Status = importStatusSkipped
+		return result
+	case existing.IsLocked:
+		result.Status = bulkStatusLocked
+		return result
+	case unchangedChecksum(existing, dna):
+		result.Status = bulkStatusUnchanged
+		return result
+	case dryRun:
+		result.Status = bulkStatusWouldUpdate
+		return result
+	}
+
+	updated, err := s.store.Update(ctx, dna)
+	switch {
+	case errors.Is(err, storage.ErrNotModified):
+		result.Status = bulkStatusUnchanged
+	case errors.Is(err, storage.ErrLocked):
+		result.Status = bulkStatusLocked
+	case err != nil:
+		return failed(err)
+	default:
+		result.Status = bulkStatusUpdated
+		s.changed(ctx, events.TypeUpdated, updated.Id, updated)
+	}
+	return result
+}
+
+// unchangedChecksum reports whether an exported config matches the stored one.
+func unchangedChecksum(stored, exported *pb.GameDNA) bool {
+	return exported.Checksum != "" && stored.Checksum == exported.Checksum
+}
+
+// importVersions converts an exported history for the store, rejecting
+// versions that are out of order or carry no data.
+func importVersions(exported []*pb.VersionInfo) ([]*storage.VersionInfo, error) {
+	var versions []*storage.VersionInfo
+	var last int64
+	for _, v := range exported {
+		if v.VersionNum <= last {
+			return nil, fmt.Errorf("version %d is out of order", v.VersionNum)
+		}
+		if v.Data == nil {
+			return nil, fmt.Errorf("version %d has no data", v.VersionNum)
+		}
+		createdAt := models.TimeFromProto(v.CreateTime)
+		if v.CreateTime == nil {
+			t, err := models.ParseTime(v.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("version %d: %w", v.VersionNum, err)
+			}
+			createdAt = t
+		}
+		versions = append(versions, &storage.VersionInfo{
+			VersionNum:    v.VersionNum,
+			Checksum:      v.Checksum,
+			CreatedAt:     createdAt,
+			CreatedBy:     v.CreatedBy,
+			Data:          v.Data,
+			Validation:    v.Validation,
+			ChangedFields: v.ChangedFields,
+			Changes:       fieldChangesFromProto(v.Changes),
+		})
+		last = v.VersionNum
+	}
+	return versions, nil
+}
+
+// readExport decodes an ExportGameDNA archive. An empty format is detected
+// from the data: zip archives start with "PK".
+func readExport(format string, data []byte) ([]*pb.GameDNAExport, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = exportFormatNDJSON
+		if bytes.HasPrefix(data, []byte("PK")) {
+			format = exportFormatZip
+		}
+	}
+
+	var records []*pb.GameDNAExport
+	decode := func(where string, b []byte) error {
+		record := &pb.GameDNAExport{}
+		// Archives from newer servers may carry fields this one doesn't know.
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, record); err != nil {
+			return fmt.Errorf("%s: %v", where, err)
+		}
+		if record.Config == nil {
+			return fmt.Errorf("%s: missing config", where)
+		}
+		records = append(records, record)
+		return nil
+	}
+
+	switch format {
+	case exportFormatNDJSON:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, exportChunkSize), maxImportArchiveBytes)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			if err := decode(fmt.Sprintf("line %d", line), scanner.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read NDJSON: %v", err)
+		}
+	case exportFormatZip:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip archive: %v", err)
+		}
+		if len(zr.File) > maxImportArchiveEntries {
+			return nil, fmt.Errorf("zip archive has %d files; the limit is %d", len(zr.File), maxImportArchiveEntries)
+		}
+		// Reject archives that declare too much before reading any file, and
+		// still count what the files decompress to as they are read.
+		var declared uint64
+		for _, f := range zr.File {
+			declared += f.UncompressedSize64
+			if declared > maxImportArchiveBytes {
+				return nil, fmt.Errorf("zip archive decompresses to more than %d bytes", maxImportArchiveBytes)
+			}
+		}
+		remaining := int64(maxImportArchiveBytes)
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", f.Name, err)
+			}
+			b, err := io.ReadAll(io.LimitReader(rc, remaining+1))
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", f.Name, err)
+			}
+			if remaining -= int64(len(b)); remaining < 0 {
+				return nil, fmt.Errorf("zip archive decompresses to more than %d bytes", maxImportArchiveBytes)
+			}
+			if err := decode(f.Name, b); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported import format %q: want ndjson or zip", format)
+	}
+	return records, nil
+}
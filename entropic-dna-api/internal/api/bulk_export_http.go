@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	bulkExportPath = "/api/v1/game-dna/bulk-export"
+	bulkImportPath = "/api/v1/game-dna/bulk-import"
+)
+
+// serveBulkExport serves ExportGameDNA as a raw NDJSON or zip download. The
+// generated gateway handler can't, as it appends a newline to every streamed
+// chunk. Query parameters are ExportGameDNARequest fields.
+func serveBulkExport(mux *runtime.ServeMux, client pb.GameDNAServiceClient, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/entropic.dna.v1.GameDNAService/ExportGameDNA", runtime.WithHTTPPathPattern(bulkExportPath))
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+
+		req := &pb.ExportGameDNARequest{}
+		if err := runtime.PopulateQueryParameters(req, r.URL.Query(), utilities.NewDoubleArray(nil)); err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Errorf(codes.InvalidArgument, "%v", err))
+			return
+		}
+		stream, err := client.ExportGameDNA(ctx, req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+
+		// Errors surface on the first chunk, while a proper error response can
+		// still be sent.
+		chunk, err := stream.Recv()
+		if err != nil && err != io.EOF {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		contentType, ext := ndjsonContentType, exportFormatNDJSON
+		if strings.EqualFold(req.Format, exportFormatZip) {
+			contentType, ext = zipContentType, exportFormatZip
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="game-dna-export.`+ext+`"`)
+		w.WriteHeader(http.StatusOK)
+
+		for err == nil {
+			if _, werr := w.Write(chunk.GetData()); werr != nil {
+				return
+			}
+			chunk, err = stream.Recv()
+		}
+		if err != io.EOF {
+			// Abort the connection so the client sees a truncated download
+			// rather than a complete-looking archive.
+			logger.Error("Bulk export failed mid-stream", zap.Error(err))
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// serveBulkImport streams a raw NDJSON or zip request body to ImportGameDNA.
+// Query parameters are ImportGameDNARequest options; without a format, the
+// Content-Type picks one before falling back to detection.
+func serveBulkImport(mux *runtime.ServeMux, client pb.GameDNAServiceClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/entropic.dna.v1.GameDNAService/ImportGameDNA", runtime.WithHTTPPathPattern(bulkImportPath))
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+
+		msg := &pb.ImportGameDNARequest{}
+		if err := runtime.PopulateQueryParameters(msg, r.URL.Query(), utilities.NewDoubleArray(nil)); err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Errorf(codes.InvalidArgument, "%v", err))
+			return
+		}
+		if msg.Format == "" {
+			switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+			case zipContentType:
+				msg.Format = exportFormatZip
+			case ndjsonContentType:
+				msg.Format = exportFormatNDJSON
+			}
+		}
+
+		stream, err := client.ImportGameDNA(ctx)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		buf := make([]byte, exportChunkSize)
+		for {
+			n, rerr := r.Body.Read(buf)
+			if n > 0 {
+				msg.Data = buf[:n]
+				// A send error means the server ended the call; CloseAndRecv
+				// returns its status.
+				if stream.Send(msg) != nil {
+					break
+				}
+				msg = &pb.ImportGameDNARequest{}
+			}
+			if errors.Is(rerr, io.EOF) {
+				break
+			}
+			if rerr != nil {
+				runtime.HTTPError(ctx, mux, outbound, w, r, status.Errorf(codes.InvalidArgument, "failed to read request body: %v", rerr))
+				return
+			}
+		}
+
+		resp, err := stream.CloseAndRecv()
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, r, resp, mux.GetForwardResponseOptions()...)
+	})
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Bulk update result statuses.
+const (
+	bulkStatusUpdated     = "updated"
+	bulkStatusWouldUpdate = "would_update"
+	bulkStatusUnchanged   = "unchanged"
+	bulkStatusInvalid     = "invalid"
+	bulkStatusLocked      = "locked"
+	bulkStatusFailed      = "failed"
+)
+
+// bulkUpdatePageSize bounds how many configs are loaded per List call.
+const bulkUpdatePageSize = 100
+
+// serverManagedFields may not appear in an update mask.
+var serverManagedFields = map[protoreflect.Name]bool{
+	"id":                      true,
+	"created_at":              true,
+	"last_modified":           true,
+	"create_time":             true,
+	"update_time":             true,
+	"created_by":              true,
+	"checksum":                true,
+	"is_locked":               true,
+	"published_rules_version": true,
+}
+
+// BulkUpdateField copies the masked fields of req.Values onto every config
+// matching the filter. Each config is validated on its own; invalid or locked
+// configs are reported and skipped rather than failing the whole run.
+func (s *GameDNAServiceServer) BulkUpdateField(req *pb.BulkUpdateFieldRequest, stream pb.GameDNAService_BulkUpdateFieldServer) error {
+	ctx := stream.Context()
+	paths := req.GetUpdateMask().GetPaths()
+
+	fields, err := updateMaskFields(paths)
+	if err != nil {
+		return err
+	}
+	if req.Values == nil {
+		req.Values = &pb.GameDNA{}
+	}
+
+	// Collect matches up front so writes can't shift later pages.
+	filters := storage.ListFilters{
+		Tags:       req.Tags,
+		Genre:      req.Genre,
+		NameFilter: req.NameFilter,
+	}
+	var matched []*pb.GameDNA
+	for page := int32(1); ; page++ {
+		items, total, err := s.store.List(ctx, filters, storage.Pagination{Page: page, PageSize: bulkUpdatePageSize})
+		if err != nil {
+			s.logger.Error("Failed to list configs", zap.Error(err))
+			return fmt.Errorf("failed to list configs: %w", err)
+		}
+		matched = append(matched, items...)
+		if len(items) == 0 || page*bulkUpdatePageSize >= total {
+			break
+		}
+	}
+
+	for _, current := range matched {
+		result := s.bulkUpdateOne(ctx, current, req.Values, fields, req.DryRun)
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bulkUpdateOne applies the change to a single config and reports the outcome.
+func (s *GameDNAServiceServer) bulkUpdateOne(ctx context.Context, current, values *pb.GameDNA, fields []protoreflect.FieldDescriptor, dryRun bool) *pb.BulkUpdateFieldResult {
+	result := &pb.BulkUpdateFieldResult{
+		ConfigId: current.Id,
+		Name:     current.Name,
+	}
+	if current.IsLocked {
+		result.Status = bulkStatusLocked
+		return result
+	}
+
+	changed := mergeFields(current, values, fields)
+
+	validationResp, err := s.validate(ctx, changed)
+	if err != nil {
+		result.Status = bulkStatusFailed
+		result.Error = fmt.Sprintf("validation error: %v", err)
+		return result
+	}
+	result.Validation = validationResp
+	if !validationResp.IsValid {
+		result.Status = bulkStatusInvalid
+		return result
+	}
+
+	checksum, err := s.rust.CalculateChecksum(changed)
+	if err != nil {
+		result.Status = bulkStatusFailed
+		result.Error = fmt.Sprintf("failed to calculate checksum: %v", err)
+		return result
+	}
+	if checksum == current.Checksum {
+		result.Status = bulkStatusUnchanged
+		return result
+	}
+	if dryRun {
+		result.Status = bulkStatusWouldUpdate
+		return result
+	}
+	changed.Checksum = checksum
+
+	updated, err := s.store.Update(ctx, changed, storage.WithValidation(validationResp))
+	switch {
+	case errors.Is(err, storage.ErrNotModified):
+		result.Status = bulkStatusUnchanged
+	case err != nil:
+		s.logger.Warn("Bulk update failed for config", zap.String("id", current.Id), zap.Error(err))
+		result.Status = bulkStatusFailed
+		result.Error = err.Error()
+	default:
+		result.Status = bulkStatusUpdated
+		s.changed(ctx, events.TypeUpdated, updated.Id, updated)
+	}
+	return result
+}
+
+// updateMaskFields resolves mask paths to GameDNA fields, rejecting unknown,
+// nested and server-managed paths.
+func updateMaskFields(paths []string) ([]protoreflect.FieldDescriptor, error) {
+	if len(paths) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update_mask must name at least one field")
+	}
+
+	all := (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields()
+	var fields []protoreflect.FieldDescriptor
+	for _, path := range paths {
+		fd := all.ByName(protoreflect.Name(path))
+		if fd == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown field in update_mask: %s", path)
+		}
+		if serverManagedFields[fd.Name()] {
+			return nil, status.Errorf(codes.InvalidArgument, "field %s is managed by the server and cannot be updated", path)
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+// mergeFields returns a copy of current with fields taken from values. Fields
+// unset in values are cleared.
+func mergeFields(current, values *pb.GameDNA, fields []protoreflect.FieldDescriptor) *pb.GameDNA {
+	merged := proto.Clone(current).(*pb.GameDNA)
+	src := proto.Clone(values).ProtoReflect()
+	dst := merged.ProtoReflect()
+	for _, fd := range fields {
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,419 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Canary defaults for what StartCanaryRequest leaves at zero.
+const (
+	defaultCanaryPercent              = 5
+	defaultCanaryDuration             = time.Hour
+	defaultCanaryMaxErrorRateIncrease = 0.01
+	defaultCanaryMinSessions          = 100
+)
+
+// Canary finish reasons recorded for automatic decisions.
+const (
+	canaryReasonPromoted = "canary finished within its error thresholds"
+	canaryReasonStarved  = "canary ended before enough sessions were reported"
+	canaryReasonChanged  = "published config changed during the canary"
+)
+
+// StartCanary serves a candidate to a share of a published config's readers,
+// picked by bucket key, until the canary ends or crosses an error threshold.
+// The candidate is gated like a publish.
+func (s *GameDNAServiceServer) StartCanary(ctx context.Context, req *pb.StartCanaryRequest) (*pb.CanaryResponse, error) {
+	if req.GameDna == nil {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+	}
+	percent := req.Percent
+	if percent == 0 {
+		percent = defaultCanaryPercent
+	}
+	if percent < 1 || percent > 99 {
+		return nil, status.Error(codes.InvalidArgument, "percent must be between 1 and 99")
+	}
+	if req.DurationSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "duration_seconds cannot be negative")
+	}
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if duration == 0 {
+		duration = defaultCanaryDuration
+	}
+	if req.MaxErrorRate < 0 || req.MaxErrorRate > 1 {
+		return nil, status.Error(codes.InvalidArgument, "max_error_rate must be between 0 and 1")
+	}
+	increase := req.MaxErrorRateIncrease
+	switch {
+	case increase == 0:
+		increase = defaultCanaryMaxErrorRateIncrease
+	case increase < 0:
+		increase = 0
+	case increase > 1:
+		return nil, status.Error(codes.InvalidArgument, "max_error_rate_increase may be at most 1")
+	}
+	if req.MinSessions < 0 {
+		return nil, status.Error(codes.InvalidArgument, "min_sessions cannot be negative")
+	}
+	minSessions := req.MinSessions
+	if minSessions == 0 {
+		minSessions = defaultCanaryMinSessions
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for canary", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	if !current.IsLocked {
+		return nil, status.Errorf(codes.FailedPrecondition, "config %s must be published to start a canary", current.Id)
+	}
+
+	candidate, err := s.runPreHooks(ctx, hooks.PrePublish, current.Id, proto.Clone(req.GameDna).(*pb.GameDNA))
+	if err != nil {
+		return nil, err
+	}
+	// The candidate replaces the published content, so it keeps its identity
+	candidate.Id = current.Id
+	candidate.Slug = current.Slug
+	if candidate.Version == "" {
+		candidate.Version = current.Version
+	}
+	candidate.CreatedBy = actor
+
+	validationResp, err := s.validate(ctx, candidate)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := s.checkPublishable(current.Id, validationResp); err != nil {
+		return nil, err
+	}
+	checksum, err := s.rust.CalculateChecksum(candidate)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	if checksum == current.Checksum {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is the same as the published content")
+	}
+	candidate.Checksum = checksum
+	candidate.IsLocked = true
+	candidate.PublishedRulesVersion = validationResp.GetRulesVersion()
+
+	now := models.Now()
+	models.SetCreated(candidate, models.TimeFromProto(current.CreateTime))
+	models.SetModified(candidate, now)
+	canary, err := s.store.StartCanary(ctx, &storage.Canary{
+		ConfigID:             current.Id,
+		Candidate:            candidate,
+		Checksum:             checksum,
+		BaseChecksum:         current.Checksum,
+		Percent:              percent,
+		MaxErrorRate:         req.MaxErrorRate,
+		MaxErrorRateIncrease: increase,
+		MinSessions:          minSessions,
+		State:                storage.CanaryRunning,
+		StartedBy:            actor,
+		CreatedAt:            now,
+		EndsAt:               now.Add(duration),
+	})
+	if err != nil {
+		s.logger.Error("Failed to start canary", zap.Error(err))
+		return nil, fmt.Errorf("failed to start canary: %w", err)
+	}
+
+	return &pb.CanaryResponse{
+		Canary:     canaryProto(canary),
+		Validation: validationResp,
+		Message:    "Canary started",
+	}, nil
+}
+
+// GetCanary returns a config's latest canary.
+func (s *GameDNAServiceServer) GetCanary(ctx context.Context, req *pb.GetCanaryRequest) (*pb.CanaryResponse, error) {
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	canary, err := s.store.GetCanary(ctx, current.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get canary: %w", err)
+	}
+	return &pb.CanaryResponse{
+		Canary:  canaryProto(canary),
+		Message: "Canary retrieved successfully",
+	}, nil
+}
+
+// PromoteCanary publishes a running canary's candidate now, whatever its
+// error rates.
+func (s *GameDNAServiceServer) PromoteCanary(ctx context.Context, req *pb.PromoteCanaryRequest) (*pb.CanaryResponse, error) {
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	canary, err := s.store.GetCanary(ctx, current.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get canary: %w", err)
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "promoted by " + actor
+	}
+	return s.promoteCanary(ctx, canary, actor, reason)
+}
+
+// RollbackCanary stops a running canary, leaving the published config as it
+// is.
+func (s *GameDNAServiceServer) RollbackCanary(ctx context.Context, req *pb.RollbackCanaryRequest) (*pb.CanaryResponse, error) {
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "rolled back by " + actor
+	}
+	canary, err := s.rollbackCanary(ctx, current.Id, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CanaryResponse{
+		Canary:  canaryProto(canary),
+		Message: "Canary rolled back",
+	}, nil
+}
+
+// maxFeedbackSessions bounds the sessions one feedback report may count, so
+// a single caller can't outvote every other game server.
+const maxFeedbackSessions = 10000
+
+// ReportConfigFeedback adds a game server's session counts to the running
+// canary of a published config, and rolls the canary back as soon as the
+// candidate crosses an error threshold. Reports for configs without a running
+// canary, or for content neither side of it serves, are dropped.
+func (s *GameDNAServiceServer) ReportConfigFeedback(ctx context.Context, req *pb.ReportConfigFeedbackRequest) (*pb.ReportConfigFeedbackResponse, error) {
+	if req.Slug == "" {
+		return nil, status.Error(codes.InvalidArgument, "slug is required")
+	}
+	if req.Checksum == "" {
+		return nil, status.Error(codes.InvalidArgument, "checksum is required")
+	}
+	if req.Sessions <= 0 || req.Sessions > maxFeedbackSessions {
+		return nil, status.Errorf(codes.InvalidArgument, "sessions must be between 1 and %d", maxFeedbackSessions)
+	}
+	if req.Errors < 0 || req.Errors > req.Sessions {
+		return nil, status.Error(codes.InvalidArgument, "errors must be between 0 and sessions")
+	}
+
+	dna, err := s.readPublished(ctx, req.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read published config: %w", err)
+	}
+	canary, err := s.store.RecordCanaryFeedback(ctx, dna.Id, req.Checksum, req.Sessions, req.Errors)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return &pb.ReportConfigFeedbackResponse{Message: "No canary is running; feedback dropped"}, nil
+	case errors.Is(err, storage.ErrConflict):
+		return &pb.ReportConfigFeedbackResponse{Message: "Checksum is not served by the running canary; feedback dropped"}, nil
+	case err != nil:
+		s.logger.Error("Failed to record canary feedback", zap.Error(err))
+		return nil, fmt.Errorf("failed to record canary feedback: %w", err)
+	}
+
+	// Only rollbacks are decided here; promotion waits for the canary to end
+	if rollback, reason := canaryVerdict(canary, models.Now()); rollback {
+		if _, err := s.rollbackCanary(ctx, canary.ConfigID, "system", reason); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			s.logger.Warn("Failed to roll back canary", zap.String("id", canary.ConfigID), zap.Error(err))
+		}
+	}
+	return &pb.ReportConfigFeedbackResponse{Recorded: true, Message: "Feedback recorded"}, nil
+}
+
+// FinishCanaries promotes or rolls back every running canary that has ended
+// or crossed an error threshold, and returns how many it finished. The server
+// calls it periodically; a canary that fails to finish is retried on the next
+// call.
+func (s *GameDNAServiceServer) FinishCanaries(ctx context.Context) (int, error) {
+	canaries, err := s.store.ListRunningCanaries(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list canaries: %w", err)
+	}
+
+	now := models.Now()
+	finished := 0
+	for _, canary := range canaries {
+		rollback, reason := canaryVerdict(canary, now)
+		switch {
+		case rollback:
+			_, err = s.rollbackCanary(ctx, canary.ConfigID, "system", reason)
+		case !now.Before(canary.EndsAt):
+			_, err = s.promoteCanary(ctx, canary, "system", canaryReasonPromoted)
+			// Content that can no longer be promoted as it is never will be
+			if errors.Is(err, storage.ErrNotLocked) || errors.Is(err, storage.ErrConflict) {
+				_, err = s.rollbackCanary(ctx, canary.ConfigID, "system", canaryReasonChanged)
+			} else if status.Code(err) == codes.FailedPrecondition {
+				_, err = s.rollbackCanary(ctx, canary.ConfigID, "system", status.Convert(err).Message())
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			s.logger.Warn("Failed to finish canary", zap.String("id", canary.ConfigID), zap.Error(err))
+			continue
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// canaryVerdict reports whether a running canary should be rolled back, and
+// why. Thresholds are judged once the candidate has MinSessions sessions,
+// the increase over the published content once that side has as many too.
+// A canary that ends short of MinSessions is rolled back as well.
+func canaryVerdict(c *storage.Canary, now time.Time) (bool, string) {
+	candidate, stable := c.CandidateFeedback, c.StableFeedback
+	if candidate.Sessions >= c.MinSessions {
+		if c.MaxErrorRate > 0 && candidate.ErrorRate() > c.MaxErrorRate {
+			return true, fmt.Sprintf("candidate error rate %.4f exceeds %.4f", candidate.ErrorRate(), c.MaxErrorRate)
+		}
+		if c.MaxErrorRateIncrease > 0 && stable.Sessions >= c.MinSessions &&
+			candidate.ErrorRate()-stable.ErrorRate() > c.MaxErrorRateIncrease {
+			return true, fmt.Sprintf("candidate error rate %.4f exceeds the published %.4f by more than %.4f",
+				candidate.ErrorRate(), stable.ErrorRate(), c.MaxErrorRateIncrease)
+		}
+		return false, ""
+	}
+	if !now.Before(c.EndsAt) {
+		return true, canaryReasonStarved
+	}
+	return false, ""
+}
+
+// promoteCanary validates a canary's candidate against the current rules and
+// publishes it in place of the config's content.
+func (s *GameDNAServiceServer) promoteCanary(ctx context.Context, canary *storage.Canary, actor, reason string) (*pb.CanaryResponse, error) {
+	validationResp, err := s.validate(ctx, canary.Candidate)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := s.checkPublishable(canary.ConfigID, validationResp); err != nil {
+		return nil, err
+	}
+	checksum, err := s.rust.CalculateChecksum(canary.Candidate)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	promoted, finished, entry, err := s.store.PromoteCanary(ctx, canary.ConfigID, actor, reason, checksum, validationResp)
+	if err != nil {
+		s.logger.Error("Failed to promote canary", zap.Error(err))
+		return nil, fmt.Errorf("failed to promote canary: %w", err)
+	}
+
+	s.logger.Info("Canary promoted", zap.String("id", promoted.Id), zap.String("actor", actor), zap.String("checksum", promoted.Checksum))
+	s.changed(ctx, events.TypePublished, promoted.Id, promoted)
+	s.runPostHooks(ctx, hooks.PostPublish, promoted)
+
+	return &pb.CanaryResponse{
+		Canary:     canaryProto(finished),
+		GameDna:    promoted,
+		Validation: validationResp,
+		AuditEntry: auditEntryProto(entry),
+		Message:    "Canary promoted and published",
+	}, nil
+}
+
+func (s *GameDNAServiceServer) rollbackCanary(ctx context.Context, configID, actor, reason string) (*storage.Canary, error) {
+	canary, err := s.store.RollbackCanary(ctx, configID, actor, reason)
+	if err != nil {
+		s.logger.Error("Failed to roll back canary", zap.Error(err))
+		return nil, fmt.Errorf("failed to roll back canary: %w", err)
+	}
+	s.logger.Info("Canary rolled back", zap.String("id", configID), zap.String("actor", actor), zap.String("reason", reason))
+	return canary, nil
+}
+
+// canaryContent returns the content a reader with bucketKey is served
+// instead of the published dna, or nil when it is served dna. Keys are
+// hashed with the config ID, so a reader lands in the canaries of different
+// configs independently.
+func (s *GameDNAServiceServer) canaryContent(ctx context.Context, dna *pb.GameDNA, bucketKey string) (*pb.GameDNA, error) {
+	if bucketKey == "" {
+		return nil, nil
+	}
+	canary, err := s.store.GetCanary(ctx, dna.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if canary.State != storage.CanaryRunning || canary.BaseChecksum != dna.Checksum || !inCanary(dna.Id, bucketKey, canary.Percent) {
+		return nil, nil
+	}
+	return canary.Candidate, nil
+}
+
+// inCanary reports whether bucketKey falls in the first percent of the
+// config's buckets.
+func inCanary(configID, bucketKey string, percent int32) bool {
+	h := fnv.New32a()
+	h.Write([]byte(configID + "/" + bucketKey))
+	return int32(h.Sum32()%100) < percent
+}
+
+func canaryProto(c *storage.Canary) *pb.Canary {
+	return &pb.Canary{
+		ConfigId:             c.ConfigID,
+		Candidate:            c.Candidate,
+		Checksum:             c.Checksum,
+		BaseChecksum:         c.BaseChecksum,
+		Percent:              c.Percent,
+		MaxErrorRate:         c.MaxErrorRate,
+		MaxErrorRateIncrease: c.MaxErrorRateIncrease,
+		MinSessions:          c.MinSessions,
+		State:                c.State,
+		StartedBy:            c.StartedBy,
+		CreateTime:           models.TimestampProto(c.CreatedAt),
+		EndTime:              models.TimestampProto(c.EndsAt),
+		FinishedBy:           c.FinishedBy,
+		FinishTime:           models.TimestampProto(c.FinishedAt),
+		Reason:               c.Reason,
+		CandidateFeedback:    canaryFeedbackProto(c.CandidateFeedback),
+		StableFeedback:       canaryFeedbackProto(c.StableFeedback),
+	}
+}
+
+func canaryFeedbackProto(a storage.CanaryArm) *pb.CanaryFeedback {
+	return &pb.CanaryFeedback{Sessions: a.Sessions, Errors: a.Errors, ErrorRate: a.ErrorRate()}
+}
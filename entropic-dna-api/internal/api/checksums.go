@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/integrity"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StartChecksumMigration starts a background job that recomputes every stored
+// checksum, or resumes a stopped one.
+func (s *GameDNAServiceServer) StartChecksumMigration(ctx context.Context, req *pb.StartChecksumMigrationRequest) (*pb.ChecksumMigrationResponse, error) {
+	if s.opts.Checksums == nil {
+		return nil, status.Error(codes.FailedPrecondition, "checksum migrations are disabled")
+	}
+
+	var (
+		job *storage.ChecksumJob
+		err error
+		msg string
+	)
+	if req.ResumeId != "" {
+		job, err = s.opts.Checksums.Resume(ctx, req.ResumeId)
+		msg = "Checksum migration resumed"
+	} else {
+		job, err = s.opts.Checksums.Start(ctx, integrity.Options{
+			DryRun:        req.DryRun,
+			BatchSize:     int(req.BatchSize),
+			RatePerSecond: int(req.MaxConfigsPerSecond),
+			Actor:         req.Actor,
+		})
+		msg = "Checksum migration started"
+	}
+	if err != nil {
+		return nil, checksumJobError(err)
+	}
+
+	s.logger.Info(msg, zap.String("id", job.ID), zap.Bool("dry_run", job.DryRun), zap.String("actor", job.StartedBy))
+	return &pb.ChecksumMigrationResponse{Migration: checksumJobProto(job), Message: msg}, nil
+}
+
+// GetChecksumMigration reports a checksum migration's progress.
+func (s *GameDNAServiceServer) GetChecksumMigration(ctx context.Context, req *pb.GetChecksumMigrationRequest) (*pb.ChecksumMigrationResponse, error) {
+	if s.opts.Checksums == nil {
+		return nil, status.Error(codes.FailedPrecondition, "checksum migrations are disabled")
+	}
+	job, err := s.opts.Checksums.Get(ctx, req.Id)
+	if err != nil {
+		return nil, checksumJobError(err)
+	}
+	return &pb.ChecksumMigrationResponse{Migration: checksumJobProto(job)}, nil
+}
+
+// CancelChecksumMigration stops a running checksum migration. Cancelling a
+// job that isn't running is a no-op.
+func (s *GameDNAServiceServer) CancelChecksumMigration(ctx context.Context, req *pb.CancelChecksumMigrationRequest) (*pb.ChecksumMigrationResponse, error) {
+	if s.opts.Checksums == nil {
+		return nil, status.Error(codes.FailedPrecondition, "checksum migrations are disabled")
+	}
+	job, err := s.opts.Checksums.Cancel(ctx, req.Id)
+	if err != nil {
+		return nil, checksumJobError(err)
+	}
+
+	s.logger.Info("Checksum migration cancelled", zap.String("id", job.ID))
+	return &pb.ChecksumMigrationResponse{Migration: checksumJobProto(job), Message: "Checksum migration " + job.State}, nil
+}
+
+// checksumJobError maps integrity errors to gRPC status errors; storage
+// errors are left to statusError.
+func checksumJobError(err error) error {
+	switch {
+	case errors.Is(err, integrity.ErrInvalidOptions):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, integrity.ErrBusy), errors.Is(err, integrity.ErrFinished):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+func checksumJobProto(job *storage.ChecksumJob) *pb.ChecksumMigration {
+	out := &pb.ChecksumMigration{
+		Id:                  job.ID,
+		State:               job.State,
+		DryRun:              job.DryRun,
+		BatchSize:           int32(job.BatchSize),
+		MaxConfigsPerSecond: int32(job.RatePerSecond),
+		ConfigsScanned:      job.ConfigsScanned,
+		VersionsScanned:     job.VersionsScanned,
+		ConfigMismatches:    job.ConfigMismatches,
+		VersionMismatches:   job.VersionMismatches,
+		Fixed:               job.Fixed,
+		Error:               job.Error,
+		StartedBy:           job.StartedBy,
+		CreatedAt:           models.TimestampProto(job.CreatedAt),
+		UpdatedAt:           models.TimestampProto(job.UpdatedAt),
+		FinishedAt:          models.TimestampProto(job.FinishedAt),
+	}
+	for _, m := range job.Mismatches {
+		out.Mismatches = append(out.Mismatches, &pb.ChecksumMismatch{
+			ConfigId: m.ConfigID,
+			Version:  int32(m.VersionNum),
+			Stored:   m.Stored,
+			Computed: m.Computed,
+		})
+	}
+	return out
+}
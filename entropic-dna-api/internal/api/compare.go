@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CompareGameDNA compares two distinct configs field by field. Unless the
+// request asks for raw values, each is compared with the values it inherits
+// filled in, so variants of one base differ only where they really do.
+func (s *GameDNAServiceServer) CompareGameDNA(ctx context.Context, req *pb.CompareGameDNARequest) (*pb.CompareGameDNAResponse, error) {
+	read := func(ref string) (*pb.GameDNA, error) {
+		dna, err := s.readConfig(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read game DNA %s: %w", ref, err)
+		}
+		if req.Raw {
+			return dna, nil
+		}
+		return s.effective(ctx, dna)
+	}
+	left, err := read(req.LeftId)
+	if err != nil {
+		return nil, err
+	}
+	right, err := read(req.RightId)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diff.CompareConfigs(left, right)
+	title := fmt.Sprintf("%s vs %s", left.GetName(), right.GetName())
+	rendered, contentType, err := diff.RenderComparison(req.Format, title, left.GetName(), right.GetName(), changes)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &pb.CompareGameDNAResponse{
+		LeftId:          left.Id,
+		RightId:         right.Id,
+		DifferingFields: int32(len(changes)),
+		Rendered:        rendered,
+		ContentType:     contentType,
+	}
+	for _, c := range changes {
+		if n := len(resp.Sections); n == 0 || resp.Sections[n-1].Section != c.Section {
+			resp.Sections = append(resp.Sections, &pb.SectionChanges{Section: c.Section})
+		}
+		section := resp.Sections[len(resp.Sections)-1]
+		section.Changes = append(section.Changes, &pb.FieldChange{
+			Field:    c.Field,
+			Section:  c.Section,
+			OldValue: c.Old,
+			NewValue: c.New,
+			Risky:    c.Risky,
+		})
+	}
+	return resp, nil
+}
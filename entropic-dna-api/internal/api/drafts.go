@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SaveDraft saves the caller's private draft of a config. Editors can save as
+// often as they like: drafts write no version and send no change event until
+// they are promoted.
+func (s *GameDNAServiceServer) SaveDraft(ctx context.Context, req *pb.SaveDraftRequest) (*pb.DraftResponse, error) {
+	owner, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	if req.GameDna == nil {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+	}
+	if err := checkSlug(req.GameDna); err != nil {
+		return nil, err
+	}
+	if err := checkEnvironment(req.GameDna); err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	// The base stays that of the first save, so promoting sees every change
+	// made to the config while the draft was open.
+	base := current.Checksum
+	if existing, err := s.store.GetDraft(ctx, current.Id, owner); err == nil {
+		base = existing.BaseChecksum
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read draft: %w", err)
+	}
+
+	data := models.Clone(req.GameDna)
+	data.Id = current.Id
+	draft, err := s.store.SaveDraft(ctx, &storage.Draft{
+		ConfigID:     current.Id,
+		Owner:        owner,
+		Data:         data,
+		BaseChecksum: base,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save draft", zap.Error(err))
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	s.logger.Debug("Draft saved", zap.String("config_id", draft.ConfigID), zap.String("owner", owner))
+	return s.draftResponse(ctx, draft, current, "Draft saved")
+}
+
+// GetDraft returns the caller's draft of a config.
+func (s *GameDNAServiceServer) GetDraft(ctx context.Context, req *pb.GetDraftRequest) (*pb.DraftResponse, error) {
+	owner, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	draft, err := s.store.GetDraft(ctx, current.Id, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read draft: %w", err)
+	}
+	return s.draftResponse(ctx, draft, current, "")
+}
+
+// DiscardDraft throws away the caller's draft of a config.
+func (s *GameDNAServiceServer) DiscardDraft(ctx context.Context, req *pb.DiscardDraftRequest) (*pb.DiscardDraftResponse, error) {
+	owner, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	if err := s.store.DeleteDraft(ctx, current.Id, owner); err != nil {
+		return nil, fmt.Errorf("failed to discard draft: %w", err)
+	}
+
+	return &pb.DiscardDraftResponse{Message: "Draft discarded"}, nil
+}
+
+// PromoteDraft updates the config with the caller's draft, writing one
+// version and sending one change event, then discards the draft. A draft
+// whose config changed since it was started is only promoted with force.
+func (s *GameDNAServiceServer) PromoteDraft(ctx context.Context, req *pb.PromoteDraftRequest) (*pb.GameDNAResponse, error) {
+	owner, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	draft, err := s.store.GetDraft(ctx, current.Id, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read draft: %w", err)
+	}
+	if draft.BaseChecksum != current.Checksum && !req.Force {
+		return nil, status.Error(codes.Aborted, "the config changed since the draft was started; review the changes and promote with force")
+	}
+
+	resp, err := s.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: current.Id, GameDna: draft.Data})
+	if err != nil {
+		// The draft is kept, so a failed validation can be fixed and retried.
+		return nil, err
+	}
+	if err := s.store.DeleteDraft(ctx, current.Id, owner); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Warn("Failed to discard promoted draft", zap.String("config_id", current.Id), zap.Error(err))
+	}
+
+	resp.Message = "Draft promoted"
+	if resp.NotModified {
+		resp.Message = "Draft matched the config; no version recorded"
+	}
+	return resp, nil
+}
+
+// callerName returns who a call acts as: the authenticated principal, or when
+// authentication is off, actor. A principal with the act_as scope, such as a
+// service account working for a user, acts as actor when it names one.
+func callerName(ctx context.Context, actor string) (string, error) {
+	if p, ok := auth.FromContext(ctx); ok && p.Name != "" {
+		if actor != "" && p.ActsForUsers() {
+			return actor, nil
+		}
+		return p.Name, nil
+	}
+	if actor == "" {
+		return "", status.Error(codes.InvalidArgument, "actor is required")
+	}
+	return actor, nil
+}
+
+// actorName is callerName for changes that name no one without
+// authentication, which are recorded as made by "system".
+func actorName(ctx context.Context, actor string) string {
+	name, err := callerName(ctx, actor)
+	if err != nil {
+		return "system"
+	}
+	return name
+}
+
+// draftResponse describes draft with how it validates now and whether
+// current moved on since it was started.
+func (s *GameDNAServiceServer) draftResponse(ctx context.Context, draft *storage.Draft, current *pb.GameDNA, msg string) (*pb.DraftResponse, error) {
+	validation, err := s.validate(ctx, draft.Data)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	pbDraft := draftProto(draft)
+	pbDraft.Stale = draft.BaseChecksum != current.Checksum
+	return &pb.DraftResponse{
+		Draft:      pbDraft,
+		Validation: validation,
+		Message:    msg,
+	}, nil
+}
+
+func draftProto(draft *storage.Draft) *pb.Draft {
+	return &pb.Draft{
+		ConfigId:     draft.ConfigID,
+		Owner:        draft.Owner,
+		GameDna:      draft.Data,
+		BaseChecksum: draft.BaseChecksum,
+		CreateTime:   models.TimestampProto(draft.CreatedAt),
+		UpdateTime:   models.TimestampProto(draft.UpdatedAt),
+	}
+}
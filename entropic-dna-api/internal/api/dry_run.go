@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// dryRunCreate returns the response of a dry-run create: dna, validated and
+// checksummed, with the fields the store would fill in on create. No ID is
+// assigned, as none would be kept.
+func (s *GameDNAServiceServer) dryRunCreate(ctx context.Context, dna *pb.GameDNA, validation *pb.ValidationResponse) (*pb.GameDNAResponse, error) {
+	preview := models.Clone(dna)
+	if err := models.NormalizeTimestamps(preview); err != nil {
+		return nil, err
+	}
+	now := models.Now()
+	if preview.CreateTime == nil {
+		models.SetCreated(preview, now)
+	}
+	if preview.UpdateTime == nil {
+		models.SetModified(preview, now)
+	}
+	if preview.Version == "" {
+		preview.Version = "0.1.0"
+	}
+
+	msg := "Dry run: Game DNA would be created"
+	taken, err := s.store.ReadBySlug(ctx, preview.Slug)
+	switch {
+	case err == nil && taken.Id != preview.Id:
+		msg = fmt.Sprintf("Dry run: Game DNA would be created; slug %s is taken, so a numeric suffix would be added", preview.Slug)
+	case err != nil && !errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("failed to check slug: %w", err)
+	}
+
+	return &pb.GameDNAResponse{
+		GameDna:    preview,
+		Message:    msg,
+		DryRun:     true,
+		Validation: validation,
+	}, nil
+}
+
+// dryRunUpdate returns the response of a dry-run update, refusing it as the
+// store would refuse the update.
+func (s *GameDNAServiceServer) dryRunUpdate(ctx context.Context, dna *pb.GameDNA, validation *pb.ValidationResponse) (*pb.GameDNAResponse, error) {
+	current, err := s.store.Read(ctx, dna.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update game DNA: %w", err)
+	}
+	if current.IsLocked {
+		return nil, fmt.Errorf("failed to update game DNA: config %s: %w", dna.Id, storage.ErrLocked)
+	}
+	other, err := s.store.ReadBySlug(ctx, dna.Slug)
+	switch {
+	case err == nil && other.Id != dna.Id:
+		return nil, fmt.Errorf("failed to update game DNA: slug %s is already used by config %s: %w", dna.Slug, other.Id, storage.ErrConflict)
+	case err != nil && !errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("failed to check slug: %w", err)
+	}
+
+	resp := &pb.GameDNAResponse{
+		DryRun:     true,
+		Validation: validation,
+	}
+	if current.Checksum == dna.Checksum {
+		resp.GameDna = current
+		resp.Message = "Dry run: Game DNA unchanged"
+		resp.NotModified = true
+		return resp, nil
+	}
+	preview := models.Clone(dna)
+	models.SetCreated(preview, models.TimeFromProto(current.CreateTime))
+	models.SetModified(preview, models.Now())
+	resp.GameDna = preview
+	resp.Message = "Dry run: Game DNA would be updated"
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/editing"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StartEditing registers an advisory editing session so other clients can
+// warn that someone else has the config open. Sessions never block writes.
+func (s *GameDNAServiceServer) StartEditing(ctx context.Context, req *pb.StartEditingRequest) (*pb.EditingSessionResponse, error) {
+	if req.Actor == "" {
+		return nil, status.Error(codes.InvalidArgument, "actor is required")
+	}
+	dna, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	session := s.opts.Editing.Start(dna.Id, req.Actor)
+	return s.editingSessionResponse(session), nil
+}
+
+// HeartbeatEditing keeps an editing session alive for another TTL.
+func (s *GameDNAServiceServer) HeartbeatEditing(ctx context.Context, req *pb.HeartbeatEditingRequest) (*pb.EditingSessionResponse, error) {
+	configID, err := s.editingConfigID(ctx, req.ConfigId)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.opts.Editing.Heartbeat(configID, req.SessionId)
+	if errors.Is(err, editing.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.NotFound, "editing session %s not found or expired", req.SessionId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to heartbeat editing session: %w", err)
+	}
+	return s.editingSessionResponse(session), nil
+}
+
+// StopEditing ends an editing session. Stopping an expired session succeeds.
+func (s *GameDNAServiceServer) StopEditing(ctx context.Context, req *pb.StopEditingRequest) (*pb.StopEditingResponse, error) {
+	configID, err := s.editingConfigID(ctx, req.ConfigId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.opts.Editing.Stop(configID, req.SessionId)
+	return &pb.StopEditingResponse{Message: "Editing session stopped"}, nil
+}
+
+// editingConfigID resolves a slug to a config ID. IDs pass through unread so
+// sessions on a deleted config can still be stopped.
+func (s *GameDNAServiceServer) editingConfigID(ctx context.Context, ref string) (string, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		return ref, nil
+	}
+	dna, err := s.readConfig(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	return dna.Id, nil
+}
+
+// editingSessionResponse pairs a session with the other sessions on its config.
+func (s *GameDNAServiceServer) editingSessionResponse(session editing.Session) *pb.EditingSessionResponse {
+	var others []editing.Session
+	for _, other := range s.opts.Editing.Active(session.ConfigID) {
+		if other.ID != session.ID {
+			others = append(others, other)
+		}
+	}
+	return &pb.EditingSessionResponse{
+		Session:      editingSessionToProto(session),
+		OtherEditors: editingSessionsToProto(others),
+	}
+}
+
+func editingSessionToProto(session editing.Session) *pb.EditingSession {
+	return &pb.EditingSession{
+		SessionId:  session.ID,
+		ConfigId:   session.ConfigID,
+		Actor:      session.Actor,
+		StartedAt:  session.StartedAt.Format(time.RFC3339),
+		LastSeenAt: session.LastSeen.Format(time.RFC3339),
+		ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+func editingSessionsToProto(sessions []editing.Session) []*pb.EditingSession {
+	if len(sessions) == 0 {
+		return nil
+	}
+	out := make([]*pb.EditingSession, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, editingSessionToProto(session))
+	}
+	return out
+}
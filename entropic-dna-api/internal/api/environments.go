@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errPromotionTargetFound stops the scan for a promotion target.
+var errPromotionTargetFound = errors.New("promotion target found")
+
+// PromoteGameDNA copies a published version of a config to the config
+// serving the next environment and publishes it there, recording who
+// approved the promotion in the target's audit log. The target is the config
+// promoted from this one before; the first promotion creates it.
+func (s *GameDNAServiceServer) PromoteGameDNA(ctx context.Context, req *pb.PromoteGameDNARequest) (*pb.PromoteGameDNAResponse, error) {
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	if req.ApprovedBy == "" {
+		return nil, status.Error(codes.InvalidArgument, "approved_by is required")
+	}
+	if req.ApprovedBy == actor {
+		return nil, status.Error(codes.InvalidArgument, "approved_by must be someone other than the caller")
+	}
+	if req.VersionNum < 0 {
+		return nil, status.Error(codes.InvalidArgument, "version_num must not be negative")
+	}
+
+	source, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	sourceEnv := storage.EnvironmentOf(source)
+	targetEnv := storage.NextEnvironment(sourceEnv)
+	if req.TargetEnvironment != "" {
+		if err := storage.ValidateEnvironment(req.TargetEnvironment); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if req.TargetEnvironment != targetEnv {
+			return nil, status.Errorf(codes.FailedPrecondition, "config %s is in %s; it can only be promoted to the next environment", source.Id, sourceEnv)
+		}
+	}
+	if targetEnv == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "config %s is in %s, the last environment", source.Id, sourceEnv)
+	}
+
+	version, err := s.promotableVersion(ctx, source, req.VersionNum)
+	if err != nil {
+		return nil, err
+	}
+	// The target stands alone: the source's parent serves the source's
+	// environment, so the values it inherits are copied instead
+	content, err := s.effective(ctx, models.Clone(version.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve inherited values of %s: %w", source.Id, err)
+	}
+	content = models.Clone(content)
+	models.ClearServerFields(content)
+	content.ParentId = ""
+	content.Environment = targetEnv
+	content.PromotedFrom = source.Id
+
+	target, err := s.promotionTarget(ctx, source.Id, targetEnv)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		content.Id = target.Id
+		content.Slug = target.Slug
+		content.CreatedBy = target.CreatedBy
+	} else {
+		content.Slug = promotedSlug(source.Slug, sourceEnv, targetEnv)
+	}
+
+	validationResp, err := s.validate(ctx, content)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := s.checkPublishable(source.Id, validationResp); err != nil {
+		return nil, err
+	}
+
+	created := target == nil
+	if created {
+		if target, err = s.createPromotionTarget(ctx, content, actor); err != nil {
+			return nil, err
+		}
+		content.Id = target.Id
+		content.Slug = target.Slug
+		content.CreatedBy = target.CreatedBy
+	}
+
+	checksum, err := s.rust.CalculateChecksum(content)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	reason := req.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("promoted from %s config %s version %d", sourceEnv, source.Slug, version.VersionNum)
+	}
+	promoted, entry, err := s.store.PromoteVersion(ctx, target.Id, content, actor, req.ApprovedBy, reason, checksum, validationResp)
+	if err != nil {
+		s.logger.Error("Failed to promote game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to promote game DNA: %w", err)
+	}
+
+	s.changed(ctx, events.TypePublished, promoted.Id, promoted)
+	s.runPostHooks(ctx, hooks.PostPublish, promoted)
+
+	return &pb.PromoteGameDNAResponse{
+		GameDna:           promoted,
+		SourceEnvironment: sourceEnv,
+		TargetEnvironment: targetEnv,
+		SourceVersionNum:  version.VersionNum,
+		Created:           created,
+		Validation:        validationResp,
+		AuditEntry:        auditEntryProto(entry),
+		Message:           fmt.Sprintf("Game DNA promoted from %s to %s", sourceEnv, targetEnv),
+	}, nil
+}
+
+// promotableVersion returns the published version of source to promote:
+// versionNum, or the current content when it is 0.
+func (s *GameDNAServiceServer) promotableVersion(ctx context.Context, source *pb.GameDNA, versionNum int64) (*storage.VersionInfo, error) {
+	if versionNum == 0 && !source.IsLocked {
+		return nil, status.Errorf(codes.FailedPrecondition, "config %s is not published; publish it or name a published version_num", source.Id)
+	}
+	versions, err := s.store.GetVersionHistory(ctx, source.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version history: %w", err)
+	}
+	var found *storage.VersionInfo
+	for _, v := range versions {
+		// Publishing writes a version, so the latest is the current content
+		if (versionNum == 0 && (found == nil || v.VersionNum > found.VersionNum)) || v.VersionNum == versionNum {
+			found = v
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("version %d of config %s: %w", versionNum, source.Id, storage.ErrNotFound)
+	}
+	if !found.Data.GetIsLocked() {
+		return nil, status.Errorf(codes.FailedPrecondition, "version %d of config %s was not published", found.VersionNum, source.Id)
+	}
+	return found, nil
+}
+
+// promotionTarget returns the config in env promoted from sourceID, or nil
+// when there is none yet.
+func (s *GameDNAServiceServer) promotionTarget(ctx context.Context, sourceID, env string) (*pb.GameDNA, error) {
+	// Promotions are rare enough that a scan beats keeping promoted_from in
+	// a column every write path would have to maintain
+	var target *pb.GameDNA
+	err := s.store.Each(ctx, storage.ListFilters{Environment: env}, func(dna *pb.GameDNA) error {
+		if dna.PromotedFrom == sourceID {
+			target = dna
+			return errPromotionTargetFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPromotionTargetFound) {
+		return nil, fmt.Errorf("failed to find promotion target: %w", err)
+	}
+	return target, nil
+}
+
+// createPromotionTarget creates the config a first promotion publishes
+// content to, unpublished until the promotion replaces its content.
+func (s *GameDNAServiceServer) createPromotionTarget(ctx context.Context, content *pb.GameDNA, actor string) (*pb.GameDNA, error) {
+	dna := models.Clone(content)
+	dna.CreatedBy = actor
+	checksum, err := s.rust.CalculateChecksum(dna)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	dna.Checksum = checksum
+
+	created, err := s.store.Create(ctx, dna)
+	if err != nil {
+		s.logger.Error("Failed to create promotion target", zap.Error(err))
+		return nil, fmt.Errorf("failed to create promotion target: %w", err)
+	}
+	s.changed(ctx, events.TypeCreated, created.Id, created)
+	return created, nil
+}
+
+// promotedSlug returns the slug for the config a first promotion creates:
+// the source's slug with its environment suffix, if any, swapped for the
+// target's, e.g. "arena-shooter-dev" becomes "arena-shooter-staging".
+func promotedSlug(slug, sourceEnv, targetEnv string) string {
+	base := strings.TrimSuffix(slug, "-"+sourceEnv)
+	return storage.Slugify(base + "-" + targetEnv)
+}
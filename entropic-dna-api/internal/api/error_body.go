@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/msgpack"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// errorObject is one problem with a request, shaped after a JSON:API error
+// object so clients can act on it without parsing the message.
+type errorObject struct {
+	// Status is the HTTP status, as a string as in JSON:API.
+	Status string `json:"status"`
+	// Code is machine-readable: the validator's code for a validation
+	// finding, e.g. "FPS_BELOW_PROFILE_MINIMUM", otherwise the gRPC code,
+	// e.g. "NOT_FOUND".
+	Code   string       `json:"code"`
+	Title  string       `json:"title"`
+	Detail string       `json:"detail"`
+	Source *errorSource `json:"source,omitempty"`
+	Links  *errorLinks  `json:"links,omitempty"`
+}
+
+type errorSource struct {
+	// Field is the proto name of the field at fault, e.g. "target_fps".
+	Field string `json:"field"`
+}
+
+type errorLinks struct {
+	// About documents the error's code.
+	About string `json:"about"`
+}
+
+// fieldError is a problem with one field, from a validation report or a
+// BadRequest detail.
+type fieldError struct {
+	Field       string
+	Description string
+	// Code is the validator's code; empty for BadRequest violations.
+	Code string
+}
+
+// fieldErrors returns the field errors st carries. A validation report is
+// preferred to the BadRequest detail sent with it, as it has the codes. A
+// report without errors was rejected for its warnings, by strict validation.
+func fieldErrors(st *status.Status) []fieldError {
+	var fromReport, fromBadRequest []fieldError
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *pb.ValidationResponse:
+			for _, e := range d.Errors {
+				fromReport = append(fromReport, fieldError{Field: e.Field, Description: e.Message, Code: e.Code})
+			}
+			if len(d.Errors) == 0 {
+				for _, w := range d.Warnings {
+					fromReport = append(fromReport, fieldError{Field: w.Field, Description: w.Message, Code: w.Code})
+				}
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				fromBadRequest = append(fromBadRequest, fieldError{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	if len(fromReport) > 0 {
+		return fromReport
+	}
+	return fromBadRequest
+}
+
+// errorObjects describes st as one error object per field error, or a single
+// one for the status itself when there are none.
+func errorObjects(st *status.Status, docsURL string) []errorObject {
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+	code := strings.ToUpper(strings.ReplaceAll(codeSlug(st.Code().String()), "-", "_"))
+	var links *errorLinks
+	if docsURL != "" {
+		links = &errorLinks{About: problemType(docsURL, st.Code().String())}
+	}
+	object := func(code, detail string) errorObject {
+		return errorObject{
+			Status: strconv.Itoa(httpStatus),
+			Code:   code,
+			Title:  http.StatusText(httpStatus),
+			Detail: detail,
+			Links:  links,
+		}
+	}
+
+	fields := fieldErrors(st)
+	if len(fields) == 0 {
+		return []errorObject{object(code, st.Message())}
+	}
+	objects := make([]errorObject, 0, len(fields))
+	for _, f := range fields {
+		o := object(code, f.Description)
+		if f.Code != "" {
+			o.Code = f.Code
+		}
+		if f.Field != "" {
+			o.Source = &errorSource{Field: f.Field}
+		}
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// errorRequestID returns the request ID of a failed request: the one the
+// middleware settled on, or the one the gRPC server sent back.
+func errorRequestID(r *http.Request, st *status.Status) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RequestInfo); ok {
+			return info.GetRequestId()
+		}
+	}
+	return ""
+}
+
+// errorBodyMarshaler adds error objects, the request ID and the trace ID to
+// the JSON and MessagePack error bodies grpc-gateway writes, keeping its
+// code, message and details for clients that read them:
+//
+//	{"code": 3, "message": "...", "details": [...],
+//	 "errors": [{"status": "400", "code": "...", "title": "Bad Request", "detail": "...", "source": {"field": "target_fps"}}],
+//	 "request_id": "...", "trace_id": "..."}
+//
+// It only marshals the error; the default handler still sets the status and
+// headers.
+type errorBodyMarshaler struct {
+	runtime.Marshaler
+	st      *status.Status
+	r       *http.Request
+	docsURL string
+}
+
+func (m *errorBodyMarshaler) Marshal(v interface{}) ([]byte, error) {
+	// MessagePack bodies are built as JSON and converted at the end.
+	inner := m.Marshaler
+	mp, isMsgpack := inner.(*msgpackMarshaler)
+	if isMsgpack {
+		inner = mp.json
+	}
+	buf, err := inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &body); err != nil {
+		// Not JSON, such as a binary protobuf status; leave it as it is
+		return buf, nil
+	}
+	add := func(key string, value interface{}) error {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		body[key] = raw
+		return nil
+	}
+	if err := add("errors", errorObjects(m.st, m.docsURL)); err != nil {
+		return nil, err
+	}
+	if id := errorRequestID(m.r, m.st); id != "" {
+		if err := add("request_id", id); err != nil {
+			return nil, err
+		}
+	}
+	if traceID := tracing.TraceID(m.r.Header.Get(tracing.TraceparentHeader)); traceID != "" {
+		if err := add("trace_id", traceID); err != nil {
+			return nil, err
+		}
+	}
+	out, err := json.Marshal(body)
+	if err != nil || !isMsgpack {
+		return out, err
+	}
+	return msgpack.FromJSON(out)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError translates storage errors into gRPC status errors, so the REST
+// gateway answers "config not found" with 404 rather than 500. Errors that
+// already carry a status, and errors it does not recognise, are returned as is.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var code codes.Code
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, storage.ErrLocked), errors.Is(err, storage.ErrNotLocked):
+		code = codes.FailedPrecondition
+	case errors.Is(err, storage.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, storage.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, storage.ErrUnavailable):
+		code = codes.Unavailable
+	default:
+		return err
+	}
+	return status.Error(code, err.Error())
+}
+
+// UnaryErrorInterceptor maps the errors of unary handlers with statusError.
+func UnaryErrorInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, statusError(err)
+}
+
+// StreamErrorInterceptor maps the errors of streaming handlers with statusError.
+func StreamErrorInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return statusError(handler(srv, ss))
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/eventsource"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errEventLogOff rejects event log reads when history is kept as snapshots.
+var errEventLogOff = status.Error(codes.FailedPrecondition, "version history is not event sourced; set database.history to events")
+
+// GetEventLog lists the mutations of a config recorded in the event log.
+// Deleted configs keep their log, so the ID is used as is when no config has
+// it.
+func (s *GameDNAServiceServer) GetEventLog(ctx context.Context, req *pb.GetEventLogRequest) (*pb.EventLogResponse, error) {
+	if s.opts.EventLog == nil {
+		return nil, errEventLogOff
+	}
+	events, err := s.configEvents(ctx, req.ConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("failed to read event log: config %s: %w", req.ConfigId, storage.ErrNotFound)
+	}
+
+	resp := &pb.EventLogResponse{}
+	for _, e := range events {
+		entry := &pb.EventLogEntry{
+			Seq:        e.Seq,
+			Type:       e.Type,
+			VersionNum: e.VersionNum,
+			Checksum:   e.Checksum,
+			Actor:      e.Actor,
+			CreateTime: models.TimestampProto(e.CreatedAt),
+			Full:       e.Full,
+			SizeBytes:  e.Size,
+		}
+		if !e.Full {
+			entry.ChangedFields = e.Fields
+		}
+		resp.Events = append(resp.Events, entry)
+	}
+	return resp, nil
+}
+
+// configEvents returns the event log of the config ref names by ID or slug,
+// or of the deleted config whose ID it is.
+func (s *GameDNAServiceServer) configEvents(ctx context.Context, ref string) ([]*storage.Event, error) {
+	id := ref
+	dna, err := s.readConfig(ctx, ref)
+	switch {
+	case err == nil:
+		id = dna.Id
+	case !errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	events, err := s.opts.EventLog.ListEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, nil
+}
+
+// readAsOf rebuilds the config ref names as it was at t, and the version it
+// was at, from its event log.
+func (s *GameDNAServiceServer) readAsOf(ctx context.Context, ref string, t time.Time) (*pb.GameDNA, int64, error) {
+	if s.opts.EventLog == nil {
+		return nil, 0, errEventLogOff
+	}
+	events, err := s.configEvents(ctx, ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	dna, version := eventsource.StateAt(events, t)
+	if dna == nil {
+		return nil, 0, fmt.Errorf("failed to read game DNA: config %s did not exist at %s: %w", ref, t.Format(time.RFC3339), storage.ErrNotFound)
+	}
+	return dna, version, nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	eventsPath        = "/api/v1/events"
+	sseHeartbeatEvery = 15 * time.Second
+)
+
+// serveEvents streams config change events as server-sent events for browser
+// clients that cannot use gRPC streaming. Supported query parameters:
+// config_id, slug, tag and type (both repeatable). Clients resume with the Last-Event-ID header
+// (or last_event_id query parameter for EventSource polyfills).
+func serveEvents(broker *events.Broker, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+		var afterSeq int64
+		if lastID != "" {
+			seq, err := strconv.ParseInt(lastID, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			afterSeq = seq
+		}
+
+		filter := events.Filter{
+			ConfigID: r.URL.Query().Get("config_id"),
+			Slug:     r.URL.Query().Get("slug"),
+			Tags:     r.URL.Query()["tag"],
+			Types:    r.URL.Query()["type"],
+		}
+
+		replay, live, cancel := broker.Subscribe(afterSeq, filter)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		// Ask browsers to wait a few seconds before reconnecting.
+		fmt.Fprint(w, "retry: 3000\n\n")
+		flusher.Flush()
+
+		sent := afterSeq
+		write := func(ev *pb.ConfigEvent) bool {
+			data, err := protojson.Marshal(ev)
+			if err != nil {
+				logger.Error("Failed to marshal event", zap.Error(err))
+				return false
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Sequence, ev.Type, data); err != nil {
+				return false
+			}
+			flusher.Flush()
+			sent = ev.Sequence
+			return true
+		}
+
+		for _, ev := range replay {
+			if !write(ev) {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(sseHeartbeatEvery)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case ev, ok := <-live:
+				if !ok {
+					// Dropped for falling behind; the client reconnects with Last-Event-ID.
+					return
+				}
+				if ev.Sequence <= sent {
+					continue
+				}
+				if !write(ev) {
+					return
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/faults"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetFaults lists the active fault injection rules.
+func (s *GameDNAServiceServer) GetFaults(ctx context.Context, req *pb.GetFaultsRequest) (*pb.FaultsResponse, error) {
+	if s.opts.Faults == nil {
+		return nil, status.Error(codes.FailedPrecondition, "fault injection is disabled")
+	}
+	return s.faultsResponse(), nil
+}
+
+// SetFaults replaces the active fault injection rules, so resilience tests can
+// slow down or fail storage, validation and event publishing per method.
+func (s *GameDNAServiceServer) SetFaults(ctx context.Context, req *pb.SetFaultsRequest) (*pb.FaultsResponse, error) {
+	if s.opts.Faults == nil {
+		return nil, status.Error(codes.FailedPrecondition, "fault injection is disabled")
+	}
+
+	rules := make([]faults.Rule, 0, len(req.Rules))
+	for _, r := range req.Rules {
+		rules = append(rules, faults.Rule{
+			Target:    r.Target,
+			Latency:   time.Duration(r.LatencyMs) * time.Millisecond,
+			ErrorRate: r.ErrorRate,
+			Message:   r.Message,
+		})
+	}
+	if err := s.opts.Faults.Set(rules); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.logger.Warn("Fault injection rules changed", zap.Int("rules", len(rules)))
+	return s.faultsResponse(), nil
+}
+
+func (s *GameDNAServiceServer) faultsResponse() *pb.FaultsResponse {
+	resp := &pb.FaultsResponse{}
+	for _, r := range s.opts.Faults.Rules() {
+		resp.Rules = append(resp.Rules, &pb.FaultRule{
+			Target:    r.Target,
+			LatencyMs: r.Latency.Milliseconds(),
+			ErrorRate: r.ErrorRate,
+			Message:   r.Message,
+		})
+	}
+	resp.Message = fmt.Sprintf("%d fault rules active", len(resp.Rules))
+	return resp
+}
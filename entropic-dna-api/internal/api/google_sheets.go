@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/sheets"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultSheetName is the tab used when a request names none.
+const defaultSheetName = "Configs"
+
+// ExportToGoogleSheet overwrites a sheet with the configs matching the filter,
+// one row per config, so designers can review and edit them in place.
+func (s *GameDNAServiceServer) ExportToGoogleSheet(ctx context.Context, req *pb.ExportToGoogleSheetRequest) (*pb.ExportToGoogleSheetResponse, error) {
+	if err := s.checkSheetsRequest(req.SpreadsheetId); err != nil {
+		return nil, err
+	}
+	sheet := req.Sheet
+	if sheet == "" {
+		sheet = defaultSheetName
+	}
+
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = sheets.ExportFields()
+	}
+	hasID := false
+	for _, field := range fields {
+		if err := sheets.CheckField(field); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		hasID = hasID || field == "id"
+	}
+	// Pulling the sheet back matches rows to configs by id.
+	if !hasID {
+		fields = append([]string{"id"}, fields...)
+	}
+
+	filters := storage.ListFilters{
+		Tags:       req.Tags,
+		Genre:      req.Genre,
+		NameFilter: req.NameFilter,
+	}
+	rows := [][]string{fields}
+	err := s.store.Each(ctx, filters, func(dna *pb.GameDNA) error {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			value, err := sheets.FormatField(dna, field)
+			if err != nil {
+				return fmt.Errorf("failed to format %s: %w", field, err)
+			}
+			row[i] = value
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to read configs for export", zap.Error(err))
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	if err := s.opts.Sheets.WriteRange(ctx, req.SpreadsheetId, sheet, rows); err != nil {
+		s.logger.Error("Failed to write Google Sheet", zap.Error(err))
+		return nil, status.Errorf(codes.Unavailable, "failed to write Google Sheet: %v", err)
+	}
+
+	exported := int32(len(rows) - 1)
+	return &pb.ExportToGoogleSheetResponse{
+		Exported: exported,
+		Sheet:    sheet,
+		Message:  fmt.Sprintf("Exported %d configs to %s", exported, sheet),
+	}, nil
+}
+
+// PullFromGoogleSheet reads edited values back from a sheet and runs them
+// through the same per-row validation as ImportSheet. Without apply it only
+// previews the changes.
+func (s *GameDNAServiceServer) PullFromGoogleSheet(ctx context.Context, req *pb.PullFromGoogleSheetRequest) (*pb.ImportSheetResponse, error) {
+	if err := s.checkSheetsRequest(req.SpreadsheetId); err != nil {
+		return nil, err
+	}
+	sheet := req.Sheet
+	if sheet == "" {
+		sheet = defaultSheetName
+	}
+
+	rows, err := s.opts.Sheets.ReadRange(ctx, req.SpreadsheetId, sheet)
+	if err != nil {
+		s.logger.Error("Failed to read Google Sheet", zap.Error(err))
+		return nil, status.Errorf(codes.Unavailable, "failed to read Google Sheet: %v", err)
+	}
+
+	return s.importRows(ctx, rows, req.Project, nil, req.Actor, !req.Apply)
+}
+
+func (s *GameDNAServiceServer) checkSheetsRequest(spreadsheetID string) error {
+	if s.opts.Sheets == nil {
+		return status.Error(codes.FailedPrecondition, "Google Sheets integration is not configured; set GOOGLE_SHEETS_CREDENTIALS_FILE")
+	}
+	if spreadsheetID == "" {
+		return status.Error(codes.InvalidArgument, "spreadsheet_id is required")
+	}
+	return nil
+}
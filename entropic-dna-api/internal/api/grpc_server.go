@@ -2,44 +2,164 @@ package api
 
 import (
     "context"
+    "errors"
     "fmt"
+    "net/http"
+    "sync/atomic"
+    "time"
 
     pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+    "github.com/entropic-engine/entropic-dna-api/internal/budget"
+    "github.com/entropic-engine/entropic-dna-api/internal/diff"
+    "github.com/entropic-engine/entropic-dna-api/internal/editing"
+    "github.com/entropic-engine/entropic-dna-api/internal/events"
+    "github.com/entropic-engine/entropic-dna-api/internal/faults"
     "github.com/entropic-engine/entropic-dna-api/internal/ffi"
+    "github.com/entropic-engine/entropic-dna-api/internal/hooks"
+    "github.com/entropic-engine/entropic-dna-api/internal/integrity"
+    "github.com/entropic-engine/entropic-dna-api/internal/models"
+    "github.com/entropic-engine/entropic-dna-api/internal/schema"
+    "github.com/entropic-engine/entropic-dna-api/internal/sheets"
     "github.com/entropic-engine/entropic-dna-api/internal/storage"
+    "github.com/entropic-engine/entropic-dna-api/internal/tokens"
+    "github.com/entropic-engine/entropic-dna-api/internal/tracing"
+    "github.com/entropic-engine/entropic-dna-api/internal/webhooks"
+    "github.com/google/uuid"
     "go.uber.org/zap"
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
 )
 
+// ServerOptions controls optional service behaviour.
+type ServerOptions struct {
+    // PublishMaxWarnings rejects publishes with more validation warnings than this. -1 disables the check.
+    PublishMaxWarnings int
+    // Events receives a notification for every successful change. May be nil.
+    Events *events.Broker
+    // EventLog holds every config's mutations when version history is event
+    // sourced. Nil disables ListConfigEvents and reads as of a past time.
+    EventLog storage.EventLog
+    // Watch serves WatchGameDNA. Nil disables it, as under data residency,
+    // where events are not tenant-aware.
+    Watch *events.Broker
+    // Editing tracks advisory editing sessions. May be nil.
+    Editing *editing.Registry
+    // List bounds ListGameDNA and SearchGameDNA requests. Zero fields are not enforced.
+    List ListLimits
+    // Sheets enables the Google Sheets export and pull. May be nil.
+    Sheets *sheets.GoogleClient
+    // Faults enables the fault injection admin RPCs. Nil outside test environments.
+    Faults *faults.Injector
+    // Tokens enables read-only API tokens, which the published-config reads
+    // then require. May be nil.
+    Tokens *tokens.Authenticator
+    // Journal records recent requests for LookupRequest. May be nil.
+    Journal *tracing.Journal
+    // WriteStrictness is the strictness of creates and updates that don't
+    // set one: StrictnessLenient or StrictnessStrict. Empty means lenient.
+    WriteStrictness string
+    // StrictEnvironments are the environments whose configs are created and
+    // updated strictly unless the request sets a strictness.
+    StrictEnvironments []string
+    // MaterializeDefaults fills engine defaults into unset fields on reads
+    // that don't ask for raw or materialized values.
+    MaterializeDefaults bool
+    // Checksums runs checksum migrations. May be nil.
+    Checksums *integrity.Checksums
+    // MaxWaiverDuration caps how far ahead a validation waiver may expire. Zero allows any expiry.
+    MaxWaiverDuration time.Duration
+    // MaxUnlockWindow caps how long a temporary unlock may last. Zero allows any length.
+    MaxUnlockWindow time.Duration
+    // MaxWait caps how long GetGameDNA holds a request waiting for a change.
+    // Zero uses defaultMaxWait.
+    MaxWait time.Duration
+    // MaxBatchSize caps the items of one batch create or update. Zero allows any number.
+    MaxBatchSize int
+    // MaxHistoryDepth and MaxHistoryAge are the version retention
+    // PruneVersionHistory enforces: the newest versions kept per config, and
+    // how long versions are kept. Zero keeps any number, or any age.
+    MaxHistoryDepth int
+    MaxHistoryAge   time.Duration
+    // Hooks runs lifecycle hooks around creates, updates and publishes. May be nil.
+    Hooks *hooks.Registry
+    // Webhooks sends lifecycle events to registered endpoints. Nil disables
+    // the webhook RPCs.
+    Webhooks *webhooks.Sender
+    // Reload rereads the server configuration, applies the settings that can
+    // change at runtime and returns the ones that changed. Nil disables
+    // ReloadConfig.
+    Reload func() ([]string, error)
+    // CostModel estimates performance budgets. Nil uses budget.DefaultModel.
+    CostModel budget.Model
+    // IdempotencyKeyTTL is how long the response of a create, clone or
+    // publish made with an idempotency key is replayed to retries. Zero
+    // ignores idempotency keys.
+    IdempotencyKeyTTL time.Duration
+}
+
 // GameDNAServiceServer implements the gRPC service.
 type GameDNAServiceServer struct {
     pb.UnimplementedGameDNAServiceServer
     store  storage.Store
     rust   *ffi.RustFFI
+    opts   ServerOptions
     logger *zap.Logger
+
+    // strictness replaces the strictness options once SetWriteStrictness
+    // is called
+    strictness atomic.Pointer[strictnessSettings]
 }
 
 // NewGameDNAServiceServer creates a new gRPC service server.
-func NewGameDNAServiceServer(store storage.Store, rust *ffi.RustFFI, logger *zap.Logger) *GameDNAServiceServer {
+func NewGameDNAServiceServer(store storage.Store, rust *ffi.RustFFI, opts ServerOptions, logger *zap.Logger) *GameDNAServiceServer {
     return &GameDNAServiceServer{
         store:  store,
         rust:   rust,
+        opts:   opts,
         logger: logger,
     }
 }
 
-// CreateGameDNA creates a new game configuration.
-func (s *GameDNAServiceServer) CreateGameDNA(ctx context.Context, req *pb.CreateGameDNARequest) (*pb.GameDNAResponse, error) {
-    s.logger.Info("Creating game DNA", zap.String("name", req.GameDna.Name))
+// createGameDNA creates a new game configuration. A dry run validates and
+// checksums it and returns what would be created, storing nothing.
+func (s *GameDNAServiceServer) createGameDNA(ctx context.Context, req *pb.CreateGameDNARequest) (*pb.GameDNAResponse, error) {
+    dna, err := s.runPreHooks(ctx, hooks.PreCreate, "", req.GameDna)
+    if err != nil {
+        return nil, err
+    }
+    req.GameDna = dna
+
+    if err := checkSlug(req.GameDna); err != nil {
+        return nil, err
+    }
+    if err := checkEnvironment(req.GameDna); err != nil {
+        return nil, err
+    }
+    if err := checkTimestamps(req.GameDna); err != nil {
+        return nil, err
+    }
+    if req.GameDna.Slug == "" {
+        req.GameDna.Slug = storage.Slugify(req.GameDna.Name)
+    }
+    strictness, err := s.writeStrictness(req.Strictness, req.GameDna)
+    if err != nil {
+        return nil, err
+    }
 
     // Validate the configuration
-    validationResp, err := s.rust.ValidateGameDNA(req.GameDna)
+    validationResp, err := s.validate(ctx, req.GameDna)
     if err != nil {
         s.logger.Error("Validation error", zap.Error(err))
         return nil, fmt.Errorf("validation error: %w", err)
     }
     if !validationResp.IsValid {
         s.logger.Warn("Validation failed for create", zap.Int("errors", len(validationResp.Errors)))
-        return nil, fmt.Errorf("validation failed: %d errors", len(validationResp.Errors))
+        return nil, validationFailedError(fmt.Sprintf("validation failed: %d errors", len(validationResp.Errors)), validationResp)
+    }
+    if err := checkStrictness(strictness, validationResp); err != nil {
+        s.logger.Warn("Strict validation failed for create", zap.Int("warnings", len(validationResp.Warnings)))
+        return nil, err
     }
 
     // Calculate checksum
@@ -49,51 +169,165 @@ func (s *GameDNAServiceServer) CreateGameDNA(ctx context.Context, req *pb.Create
         return nil, fmt.Errorf("failed to calculate checksum: %w", err)
     }
     req.GameDna.Checksum = checksum
+    if req.DryRun {
+        return s.dryRunCreate(ctx, req.GameDna, validationResp)
+    }
 
     // Store the configuration
-    created, err := s.store.Create(ctx, req.GameDna)
+    created, err := s.store.Create(ctx, req.GameDna, storage.WithValidation(validationResp))
     if err != nil {
         s.logger.Error("Failed to create game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to create game DNA: %w", err)
     }
 
-    s.logger.Info("Game DNA created", zap.String("id", created.Id))
+    s.changed(ctx, events.TypeCreated, created.Id, created)
+    s.runPostHooks(ctx, hooks.PostCreate, created)
 
     return &pb.GameDNAResponse{
-        GameDna: created,
-        Message: "Game DNA created successfully",
+        GameDna:    created,
+        Message:    "Game DNA created successfully",
+        Validation: validationResp,
     }, nil
 }
 
-// GetGameDNA retrieves a game configuration by ID.
-func (s *GameDNAServiceServer) GetGameDNA(ctx context.Context, req *pb.GetGameDNARequest) (*pb.GameDNAResponse, error) {
-    s.logger.Info("Getting game DNA", zap.String("id", req.Id))
+// readConfig loads a config by ID, or by slug when ref is not a UUID.
+func (s *GameDNAServiceServer) readConfig(ctx context.Context, ref string) (*pb.GameDNA, error) {
+    if _, err := uuid.Parse(ref); err == nil {
+        return s.store.Read(ctx, ref)
+    }
+    return s.store.ReadBySlug(ctx, ref)
+}
+
+// checkSlug validates a client-supplied slug. An empty slug is allowed and
+// means "generate from the name" on create and "keep the current one" on update.
+func checkSlug(dna *pb.GameDNA) error {
+    if dna.GetSlug() == "" {
+        return nil
+    }
+    if err := storage.ValidateSlug(dna.Slug); err != nil {
+        return status.Error(codes.InvalidArgument, err.Error())
+    }
+    return nil
+}
+
+// checkEnvironment validates a client-supplied environment. An empty one
+// means dev.
+func checkEnvironment(dna *pb.GameDNA) error {
+    if dna.GetEnvironment() == "" {
+        return nil
+    }
+    if err := storage.ValidateEnvironment(dna.Environment); err != nil {
+        return status.Error(codes.InvalidArgument, err.Error())
+    }
+    return nil
+}
 
-    dna, err := s.store.Read(ctx, req.Id)
+// GetGameDNA retrieves a game configuration by ID or slug, with the values it
+// inherits filled in, or the version of it a release tag names, or the config
+// as it was at a past time. Versions hold a config's own values only. With a
+// wait checksum it long-polls for the next change.
+func (s *GameDNAServiceServer) GetGameDNA(ctx context.Context, req *pb.GetGameDNARequest) (*pb.GameDNAResponse, error) {
+    defaults, err := s.defaultsFor(req.Defaults)
     if err != nil {
+        return nil, err
+    }
+
+    if req.WaitChecksum != "" && (req.ReleaseTag != "" || req.AsOf != nil) {
+        return nil, status.Error(codes.InvalidArgument, "wait_checksum waits for the current config; it cannot be combined with release_tag or as_of")
+    }
+    if req.AsOf != nil {
+        if req.ReleaseTag != "" {
+            return nil, status.Error(codes.InvalidArgument, "release_tag and as_of cannot be combined")
+        }
+        if err := req.AsOf.CheckValid(); err != nil {
+            return nil, status.Errorf(codes.InvalidArgument, "invalid as_of: %v", err)
+        }
+        at := req.AsOf.AsTime()
+        dna, version, err := s.readAsOf(ctx, req.Id, at)
+        if err != nil {
+            return nil, err
+        }
+        return &pb.GameDNAResponse{
+            GameDna:         materialize(defaults, dna),
+            Message:         fmt.Sprintf("Game DNA retrieved as of %s", at.Format(time.RFC3339)),
+            DefaultsVersion: defaultsVersion(defaults),
+            VersionNum:      version,
+        }, nil
+    }
+
+    if req.ReleaseTag != "" {
+        version, err := s.readTaggedVersion(ctx, req.Id, req.ReleaseTag)
+        if err != nil {
+            s.logger.Error("Failed to read tagged game DNA", zap.Error(err))
+            return nil, fmt.Errorf("failed to read game DNA: %w", err)
+        }
+        return &pb.GameDNAResponse{
+            GameDna:         materialize(defaults, version.Data),
+            Message:         fmt.Sprintf("Game DNA retrieved at release tag %s", req.ReleaseTag),
+            DefaultsVersion: defaultsVersion(defaults),
+            VersionNum:      version.VersionNum,
+        }, nil
+    }
+
+    var stored *pb.GameDNA
+    unchanged := false
+    if req.WaitChecksum != "" {
+        if stored, unchanged, err = s.waitForChange(ctx, req.Id, req.WaitChecksum, time.Duration(req.WaitSeconds)*time.Second); err != nil {
+            return nil, err
+        }
+    } else if stored, err = s.readConfig(ctx, req.Id); err != nil {
         s.logger.Error("Failed to read game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to read game DNA: %w", err)
     }
+    dna, err := s.effective(ctx, stored)
+    if err != nil {
+        s.logger.Error("Failed to resolve inherited values", zap.Error(err))
+        return nil, fmt.Errorf("failed to resolve inherited values of %s: %w", stored.Id, err)
+    }
 
+    message := "Game DNA retrieved successfully"
+    if unchanged {
+        message = "Game DNA unchanged"
+    }
     return &pb.GameDNAResponse{
-        GameDna: dna,
-        Message: "Game DNA retrieved successfully",
+        GameDna:         materialize(defaults, dna),
+        Message:         message,
+        NotModified:     unchanged,
+        ActiveEditors:   editingSessionsToProto(s.opts.Editing.Active(dna.Id)),
+        DefaultsVersion: defaultsVersion(defaults),
     }, nil
 }
 
 // ListGameDNA lists all game configurations with filtering and pagination.
 func (s *GameDNAServiceServer) ListGameDNA(ctx context.Context, req *pb.ListGameDNARequest) (*pb.ListGameDNAResponse, error) {
-    s.logger.Info("Listing game DNAs", zap.Int32("page", req.Page))
+    if err := s.opts.List.check(req); err != nil {
+        s.logger.Warn("Rejected list request", zap.Error(err))
+        return nil, err
+    }
+    defaults, err := s.defaultsFor(req.Defaults)
+    if err != nil {
+        return nil, err
+    }
 
+    if req.Environment != "" {
+        if err := storage.ValidateEnvironment(req.Environment); err != nil {
+            return nil, status.Error(codes.InvalidArgument, err.Error())
+        }
+    }
     filters := storage.ListFilters{
-        Tags:       req.Tags,
-        Genre:      req.Genre,
-        NameFilter: req.NameFilter,
+        Tags:        req.Tags,
+        Genre:       req.Genre,
+        NameFilter:  req.NameFilter,
+        Environment: req.Environment,
     }
 
+    pageSize := s.opts.List.pageSize(req.PageSize)
+    if req.PageToken != "" {
+        return s.listAfter(ctx, req.PageToken, filters, pageSize, defaults)
+    }
     pagination := storage.Pagination{
         Page:     req.Page,
-        PageSize: req.PageSize,
+        PageSize: pageSize,
     }
 
     items, total, err := s.store.List(ctx, filters, pagination)
@@ -102,15 +336,20 @@ func (s *GameDNAServiceServer) ListGameDNA(ctx context.Context, req *pb.ListGame
         return nil, fmt.Errorf("failed to list game DNAs: %w", err)
     }
 
-    pageSize := req.PageSize
-    if pageSize == 0 {
-        pageSize = 10
-    }
     page := req.Page
     if page == 0 {
         page = 1
     }
     totalPages := (total + pageSize - 1) / pageSize
+    // The first page starts the cursor; later pages by number keep paging
+    // by number, as the client asked
+    var nextPageToken string
+    if page == 1 && total > pageSize && len(items) > 0 {
+        nextPageToken = lastItemToken(items, filters)
+    }
+    for i, item := range items {
+        items[i] = materialize(defaults, item)
+    }
 
     return &pb.ListGameDNAResponse{
         Items: items,
@@ -120,25 +359,110 @@ func (s *GameDNAServiceServer) ListGameDNA(ctx context.Context, req *pb.ListGame
             Total:      total,
             TotalPages: totalPages,
         },
+        DefaultsVersion: defaultsVersion(defaults),
+        NextPageToken:   nextPageToken,
     }, nil
 }
 
-// UpdateGameDNA updates an existing game configuration.
+// listAfter serves a ListGameDNA page continuing from a page token.
+func (s *GameDNAServiceServer) listAfter(ctx context.Context, token string, filters storage.ListFilters, pageSize int32, defaults *schema.DefaultsTable) (*pb.ListGameDNAResponse, error) {
+    after, err := decodePageToken(token, filters)
+    if err != nil {
+        return nil, err
+    }
+    items, next, err := s.store.ListAfter(ctx, filters, after, pageSize)
+    if err != nil {
+        s.logger.Error("Failed to list game DNAs", zap.Error(err))
+        return nil, fmt.Errorf("failed to list game DNAs: %w", err)
+    }
+
+    var nextPageToken string
+    if next != nil {
+        nextPageToken = encodePageToken(*next, filters)
+    }
+    for i, item := range items {
+        items[i] = materialize(defaults, item)
+    }
+
+    return &pb.ListGameDNAResponse{
+        Items:           items,
+        Pagination:      &pb.PaginationInfo{PageSize: pageSize},
+        DefaultsVersion: defaultsVersion(defaults),
+        NextPageToken:   nextPageToken,
+    }, nil
+}
+
+// checkTimestamps fills in create_time and update_time from the legacy RFC3339
+// strings older clients send, rejecting malformed ones.
+func checkTimestamps(dna *pb.GameDNA) error {
+    if err := models.NormalizeTimestamps(dna); err != nil {
+        return status.Error(codes.InvalidArgument, err.Error())
+    }
+    return nil
+}
+
+// UpdateGameDNA updates an existing game configuration. A dry run validates
+// and checksums it and returns what would be saved, writing no version.
 func (s *GameDNAServiceServer) UpdateGameDNA(ctx context.Context, req *pb.UpdateGameDNARequest) (*pb.GameDNAResponse, error) {
-    s.logger.Info("Updating game DNA", zap.String("id", req.Id))
+    if len(req.GetUpdateMask().GetPaths()) > 0 {
+        // Partial update: merge the masked fields onto the stored config
+        fields, err := updateMaskFields(req.UpdateMask.Paths)
+        if err != nil {
+            return nil, err
+        }
+        current, err := s.store.Read(ctx, req.Id)
+        if err != nil {
+            s.logger.Error("Failed to update game DNA", zap.Error(err))
+            return nil, fmt.Errorf("failed to update game DNA: %w", err)
+        }
+        req.GameDna = mergeFields(current, req.GetGameDna(), fields)
+    }
+    if req.GameDna == nil {
+        return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+    }
+
+    dna, err := s.runPreHooks(ctx, hooks.PreUpdate, req.Id, req.GameDna)
+    if err != nil {
+        return nil, err
+    }
+    req.GameDna = dna
 
     // Ensure ID matches
     req.GameDna.Id = req.Id
 
+    if err := checkSlug(req.GameDna); err != nil {
+        return nil, err
+    }
+    if err := checkEnvironment(req.GameDna); err != nil {
+        return nil, err
+    }
+    if req.GameDna.Slug == "" {
+        // Keep the current slug so the checksum matches unchanged content
+        current, err := s.store.Read(ctx, req.Id)
+        if err != nil {
+            s.logger.Error("Failed to update game DNA", zap.Error(err))
+            return nil, fmt.Errorf("failed to update game DNA: %w", err)
+        }
+        req.GameDna.Slug = current.Slug
+    }
+    strictness, err := s.writeStrictness(req.Strictness, req.GameDna)
+    if err != nil {
+        return nil, err
+    }
+
     // Validate the configuration
-    validationResp, err := s.rust.ValidateGameDNA(req.GameDna)
+    validationResp, err := s.validate(ctx, req.GameDna)
     if err != nil {
         s.logger.Error("Validation error", zap.Error(err))
         return nil, fmt.Errorf("validation error: %w", err)
     }
     if !validationResp.IsValid {
         s.logger.Warn("Validation failed for update", zap.Int("errors", len(validationResp.Errors)))
-        return nil, fmt.Errorf("validation failed: %d errors", len(validationResp.Errors))
+        return nil, validationFailedError(fmt.Sprintf("validation failed: %d errors", len(validationResp.Errors)), validationResp)
+    }
+    if err := checkStrictness(strictness, validationResp); err != nil {
+        s.logger.Warn("Strict validation failed for update", zap.Int("warnings", len(validationResp.Warnings)))
+        return nil, err
     }
 
     // Calculate new checksum
@@ -148,33 +472,68 @@ func (s *GameDNAServiceServer) UpdateGameDNA(ctx context.Context, req *pb.Update
         return nil, fmt.Errorf("failed to calculate checksum: %w", err)
     }
     req.GameDna.Checksum = checksum
+    if req.DryRun {
+        return s.dryRunUpdate(ctx, req.GameDna, validationResp)
+    }
 
     // Update the configuration
-    updated, err := s.store.Update(ctx, req.GameDna)
+    updated, err := s.store.Update(ctx, req.GameDna, storage.WithValidation(validationResp))
+    if errors.Is(err, storage.ErrNotModified) {
+        // Retried or autosaved updates with identical content don't add versions
+        return &pb.GameDNAResponse{
+            GameDna:     updated,
+            Message:     "Game DNA unchanged",
+            NotModified: true,
+            Validation:  validationResp,
+        }, nil
+    }
     if err != nil {
         s.logger.Error("Failed to update game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to update game DNA: %w", err)
     }
 
-    s.logger.Info("Game DNA updated", zap.String("id", updated.Id))
+    s.changed(ctx, events.TypeUpdated, updated.Id, updated)
+    s.runPostHooks(ctx, hooks.PostUpdate, updated)
 
     return &pb.GameDNAResponse{
-        GameDna: updated,
-        Message: "Game DNA updated successfully",
+        GameDna:    updated,
+        Message:    "Game DNA updated successfully",
+        Validation: validationResp,
     }, nil
 }
 
 // DeleteGameDNA deletes a game configuration.
 func (s *GameDNAServiceServer) DeleteGameDNA(ctx context.Context, req *pb.DeleteGameDNARequest) (*pb.DeleteGameDNAResponse, error) {
-    s.logger.Info("Deleting game DNA", zap.String("id", req.Id))
+    // Best-effort read so the delete event still says which config it was
+    last, _ := s.store.Read(ctx, req.Id)
+
+    // Configs inheriting from this one would lose their values
+    if last != nil {
+        children, err := s.childCount(ctx, last.Id)
+        if err != nil {
+            s.logger.Error("Failed to check for inheriting configs", zap.Error(err))
+            return nil, fmt.Errorf("failed to check for inheriting configs: %w", err)
+        }
+        if children > 0 {
+            return nil, status.Errorf(codes.FailedPrecondition, "config %s is the parent of %d configs; delete them or change their parent_id first", last.Id, children)
+        }
+    }
 
     err := s.store.Delete(ctx, req.Id)
+    if err != nil && req.Idempotent && errors.Is(err, storage.ErrNotFound) {
+        setHTTPStatus(ctx, http.StatusNoContent)
+        return &pb.DeleteGameDNAResponse{
+            Success:        true,
+            Message:        "Game DNA already deleted",
+            AlreadyDeleted: true,
+        }, nil
+    }
     if err != nil {
         s.logger.Error("Failed to delete game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to delete game DNA: %w", err)
     }
 
-    s.logger.Info("Game DNA deleted", zap.String("id", req.Id))
+    s.changed(ctx, events.TypeDeleted, req.Id, last)
 
     return &pb.DeleteGameDNAResponse{
         Success: true,
@@ -200,88 +559,193 @@ func (s *GameDNAServiceServer) ValidateGameDNA(ctx context.Context, req *pb.Vali
         return nil, fmt.Errorf("either id or game_dna must be provided")
     }
 
-    name := dna.GetName()
-    if name == "" {
-        name = dna.GetId()
-    }
-    s.logger.Info("Validating game DNA", zap.String("name", name), zap.String("id", dna.GetId()))
-
-    validationResp, err := s.rust.ValidateGameDNA(dna)
+    validationResp, err := s.validate(ctx, dna)
     if err != nil {
         s.logger.Error("Validation error", zap.Error(err))
         return nil, fmt.Errorf("validation error: %w", err)
     }
 
-    s.logger.Info("Validation complete",
-        zap.Bool("valid", validationResp.IsValid),
-        zap.Int("errors", len(validationResp.Errors)),
-        zap.Int("warnings", len(validationResp.Warnings)),
-    )
-
     return validationResp, nil
 }
 
-// PublishGameDNA locks a game configuration and creates an immutable snapshot.
-func (s *GameDNAServiceServer) PublishGameDNA(ctx context.Context, req *pb.PublishGameDNARequest) (*pb.PublishedGameDNAResponse, error) {
-    s.logger.Info("Publishing game DNA", zap.String("id", req.Id))
+// publishGameDNA re-validates a game configuration against the current rules,
+// then locks it and creates an immutable snapshot carrying the validation report.
+func (s *GameDNAServiceServer) publishGameDNA(ctx context.Context, req *pb.PublishGameDNARequest) (*pb.PublishedGameDNAResponse, error) {
+    current, err := s.readConfig(ctx, req.Id)
+    if err != nil {
+        s.logger.Error("Failed to read game DNA for publish", zap.Error(err))
+        return nil, fmt.Errorf("failed to read game DNA: %w", err)
+    }
+
+    // Publish hooks may veto the publish, but not change what is published
+    if _, err := s.runPreHooks(ctx, hooks.PrePublish, current.Id, current); err != nil {
+        return nil, err
+    }
 
-    published, err := s.store.PublishVersion(ctx, req.Id, "system")
+    // Rules may have tightened since the config was saved, so validate again.
+    validationResp, err := s.validate(ctx, current)
+    if err != nil {
+        s.logger.Error("Validation error", zap.Error(err))
+        return nil, fmt.Errorf("validation error: %w", err)
+    }
+    if err := s.checkPublishable(current.Id, validationResp); err != nil {
+        return nil, err
+    }
+
+    // Seal the checksum of the content as stored, after the store's defaults
+    checksum, err := s.rust.CalculateChecksum(current)
+    if err != nil {
+        s.logger.Error("Failed to calculate checksum", zap.Error(err))
+        return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+    }
+
+    // The store refuses the publish if the config moved on from what was validated
+    published, err := s.store.PublishVersion(ctx, current.Id, actorName(ctx, req.Actor), current.Checksum, checksum, validationResp)
+    if errors.Is(err, storage.ErrConflict) {
+        return nil, status.Error(codes.Aborted, "the config changed while it was being validated; publish again")
+    }
     if err != nil {
         s.logger.Error("Failed to publish game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to publish game DNA: %w", err)
     }
 
-    s.logger.Info("Game DNA published", zap.String("id", published.Id), zap.String("checksum", published.Checksum))
+    s.changed(ctx, events.TypePublished, published.Id, published)
+    s.runPostHooks(ctx, hooks.PostPublish, published)
 
     return &pb.PublishedGameDNAResponse{
-        GameDna:  published,
-        Checksum: published.Checksum,
-        Message:  "Game DNA published and locked successfully",
+        GameDna:    published,
+        Checksum:   published.Checksum,
+        Message:    "Game DNA published and locked successfully",
+        Validation: validationResp,
     }, nil
 }
 
-// GetVersionHistory retrieves the version history for a game configuration.
+// checkPublishable rejects content whose validation report has errors, or
+// more warnings than publishing allows.
+func (s *GameDNAServiceServer) checkPublishable(id string, validationResp *pb.ValidationResponse) error {
+    if !validationResp.IsValid {
+        s.logger.Warn("Publish rejected: validation errors", zap.String("id", id), zap.Int("errors", len(validationResp.Errors)))
+        return validationStatusError(codes.FailedPrecondition, fmt.Sprintf("cannot publish: %d validation errors", len(validationResp.Errors)), validationResp)
+    }
+    if max := s.opts.PublishMaxWarnings; max >= 0 && len(validationResp.Warnings) > max {
+        s.logger.Warn("Publish rejected: too many warnings", zap.String("id", id), zap.Int("warnings", len(validationResp.Warnings)), zap.Int("max", max))
+        return status.Errorf(codes.FailedPrecondition, "cannot publish: %d validation warnings exceed the limit of %d", len(validationResp.Warnings), max)
+    }
+    return nil
+}
+
+// GetVersionHistory retrieves the version history for a game configuration,
+// newest first, a page at a time when the request sets a page size.
 func (s *GameDNAServiceServer) GetVersionHistory(ctx context.Context, req *pb.GetVersionHistoryRequest) (*pb.VersionHistoryResponse, error) {
-    s.logger.Info("Getting version history", zap.String("config_id", req.ConfigId))
+    withoutData := req.IncludeData != nil && !*req.IncludeData
+    versions, nextPageToken, err := s.versionPage(ctx, req.ConfigId, req.PageSize, req.PageToken, withoutData)
+    if err != nil {
+        return nil, err
+    }
+    tags, err := s.releaseTagsByVersion(ctx, req.ConfigId)
+    if err != nil {
+        s.logger.Error("Failed to list release tags", zap.Error(err))
+        return nil, fmt.Errorf("failed to list release tags: %w", err)
+    }
+
+    var pbVersions []*pb.VersionInfo
+    for _, v := range versions {
+        pbVersion := versionInfoProto(v)
+        pbVersion.ReleaseTags = tags[v.VersionNum]
+        pbVersions = append(pbVersions, pbVersion)
+    }
+
+    return &pb.VersionHistoryResponse{
+        Versions:      pbVersions,
+        NextPageToken: nextPageToken,
+    }, nil
+}
 
+// versionInfoProto converts a stored version for the API.
+func versionInfoProto(v *storage.VersionInfo) *pb.VersionInfo {
+    return &pb.VersionInfo{
+        VersionNum:    v.VersionNum,
+        Checksum:      v.Checksum,
+        CreatedAt:     models.FormatTime(v.CreatedAt),
+        CreateTime:    models.TimestampProto(v.CreatedAt),
+        CreatedBy:     v.CreatedBy,
+        Data:          v.Data,
+        Validation:    v.Validation,
+        ChangedFields: v.ChangedFields,
+        Changes:       versionChangesProto(v.Changes),
+        Size:          v.Size,
+    }
+}
+
+// DiffVersions compares two versions of a configuration.
+func (s *GameDNAServiceServer) DiffVersions(ctx context.Context, req *pb.DiffVersionsRequest) (*pb.DiffVersionsResponse, error) {
     versions, err := s.store.GetVersionHistory(ctx, req.ConfigId)
     if err != nil {
         s.logger.Error("Failed to get version history", zap.Error(err))
         return nil, fmt.Errorf("failed to get version history: %w", err)
     }
 
-    var pbVersions []*pb.VersionInfo
+    byNum := make(map[int64]*storage.VersionInfo, len(versions))
+    var latest int64
     for _, v := range versions {
-        pbVersions = append(pbVersions, &pb.VersionInfo{
-            VersionNum: v.VersionNum,
-            Checksum:   v.Checksum,
-            CreatedAt:  v.CreatedAt,
-            CreatedBy:  v.CreatedBy,
-            Data:       v.Data,
-        })
+        byNum[v.VersionNum] = v
+        if v.VersionNum > latest {
+            latest = v.VersionNum
+        }
     }
 
-    s.logger.Info("Version history retrieved", zap.Int("count", len(pbVersions)))
+    to := req.ToVersion
+    if to == 0 {
+        to = latest
+    }
+    from := req.FromVersion
+    if from == 0 {
+        from = to - 1
+    }
+    fromVersion, ok := byNum[from]
+    if !ok {
+        return nil, status.Errorf(codes.NotFound, "version not found: %d", from)
+    }
+    toVersion, ok := byNum[to]
+    if !ok {
+        return nil, status.Errorf(codes.NotFound, "version not found: %d", to)
+    }
 
-    return &pb.VersionHistoryResponse{
-        Versions: pbVersions,
-    }, nil
+    changes := diff.Compare(fromVersion.Data, toVersion.Data)
+    title := fmt.Sprintf("%s: version %d → %d", toVersion.Data.GetName(), from, to)
+    rendered, contentType, err := diff.Render(req.Format, title, changes)
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+
+    resp := &pb.DiffVersionsResponse{
+        FromVersion: from,
+        ToVersion:   to,
+        Rendered:    rendered,
+        ContentType: contentType,
+    }
+    for _, c := range changes {
+        resp.Changes = append(resp.Changes, &pb.FieldChange{
+            Field:    c.Field,
+            Section:  c.Section,
+            OldValue: c.Old,
+            NewValue: c.New,
+            Risky:    c.Risky,
+        })
+    }
+
+    return resp, nil
 }
 
 // RollbackToVersion rolls back a game configuration to a previous version.
 func (s *GameDNAServiceServer) RollbackToVersion(ctx context.Context, req *pb.RollbackToVersionRequest) (*pb.GameDNAResponse, error) {
-    s.logger.Info("Rolling back to version",
-        zap.String("config_id", req.ConfigId),
-        zap.Int64("version", req.VersionNum),
-    )
-
-    rolled, err := s.store.RollbackToVersion(ctx, req.ConfigId, req.VersionNum, "system")
+    rolled, err := s.store.RollbackToVersion(ctx, req.ConfigId, req.VersionNum, actorName(ctx, req.Actor))
     if err != nil {
         s.logger.Error("Failed to rollback version", zap.Error(err))
         return nil, fmt.Errorf("failed to rollback version: %w", err)
     }
 
-    s.logger.Info("Rolled back successfully", zap.String("id", rolled.Id))
+    s.changed(ctx, events.TypeRolledBack, rolled.Id, rolled)
 
     return &pb.GameDNAResponse{
         GameDna: rolled,
@@ -289,23 +753,128 @@ func (s *GameDNAServiceServer) RollbackToVersion(ctx context.Context, req *pb.Ro
     }, nil
 }
 
-// CloneGameDNA creates a copy of an existing game configuration.
-func (s *GameDNAServiceServer) CloneGameDNA(ctx context.Context, req *pb.CloneGameDNARequest) (*pb.GameDNAResponse, error) {
-    s.logger.Info("Cloning game DNA",
-        zap.String("id", req.Id),
-        zap.String("new_name", req.NewName),
-    )
-
-    cloned, err := s.store.Clone(ctx, req.Id, req.NewName, "system")
+// cloneGameDNA creates a copy of an existing game configuration.
+func (s *GameDNAServiceServer) cloneGameDNA(ctx context.Context, req *pb.CloneGameDNARequest) (*pb.GameDNAResponse, error) {
+    cloned, err := s.store.Clone(ctx, req.Id, req.NewName, actorName(ctx, req.Actor))
     if err != nil {
         s.logger.Error("Failed to clone game DNA", zap.Error(err))
         return nil, fmt.Errorf("failed to clone game DNA: %w", err)
     }
 
-    s.logger.Info("Game DNA cloned", zap.String("original_id", req.Id), zap.String("cloned_id", cloned.Id))
+    s.changed(ctx, events.TypeCloned, cloned.Id, cloned)
 
     return &pb.GameDNAResponse{
         GameDna: cloned,
         Message: "Game DNA cloned successfully",
     }, nil
 }
+
+// SaveSet validates and applies a group of creates and updates atomically.
+func (s *GameDNAServiceServer) SaveSet(ctx context.Context, req *pb.SaveSetRequest) (*pb.SaveSetResponse, error) {
+    if len(req.Creates) == 0 && len(req.Updates) == 0 {
+        return nil, fmt.Errorf("save set must contain at least one create or update")
+    }
+
+    for _, dna := range req.Updates {
+        if dna.GetId() == "" {
+            return nil, fmt.Errorf("save set update is missing an id")
+        }
+    }
+
+    // Validate every entry before touching storage so the set is all-or-nothing.
+    set := storage.SaveSet{
+        Creates: req.Creates,
+        Updates: req.Updates,
+    }
+    for _, dna := range req.Creates {
+        report, err := s.validateForSaveSet(ctx, dna)
+        if err != nil {
+            return nil, err
+        }
+        set.CreateReports = append(set.CreateReports, report)
+    }
+    for _, dna := range req.Updates {
+        report, err := s.validateForSaveSet(ctx, dna)
+        if err != nil {
+            return nil, err
+        }
+        set.UpdateReports = append(set.UpdateReports, report)
+    }
+
+    saved, err := s.store.SaveSet(ctx, set)
+    if err != nil {
+        s.logger.Error("Failed to save config set", zap.Error(err))
+        return nil, fmt.Errorf("failed to save config set: %w", err)
+    }
+
+    for _, dna := range saved.Creates {
+        s.changed(ctx, events.TypeCreated, dna.Id, dna)
+    }
+    for _, dna := range saved.Updates {
+        s.changed(ctx, events.TypeUpdated, dna.Id, dna)
+    }
+
+    return &pb.SaveSetResponse{
+        Created:           saved.Creates,
+        Updated:           saved.Updates,
+        Unchanged:         saved.Unchanged,
+        Message:           "Config set saved successfully",
+        CreateValidations: set.CreateReports,
+        UpdateValidations: set.UpdateReports,
+    }, nil
+}
+
+// validateForSaveSet validates one save set entry and stamps its checksum.
+func (s *GameDNAServiceServer) validateForSaveSet(ctx context.Context, dna *pb.GameDNA) (*pb.ValidationResponse, error) {
+    if err := checkSlug(dna); err != nil {
+        return nil, err
+    }
+    if err := checkEnvironment(dna); err != nil {
+        return nil, err
+    }
+
+    validationResp, err := s.validate(ctx, dna)
+    if err != nil {
+        s.logger.Error("Validation error", zap.Error(err))
+        return nil, fmt.Errorf("validation error: %w", err)
+    }
+    if !validationResp.IsValid {
+        s.logger.Warn("Validation failed for save set",
+            zap.String("name", dna.GetName()),
+            zap.Int("errors", len(validationResp.Errors)),
+        )
+        return nil, validationFailedError(fmt.Sprintf("validation failed for %q: %d errors", dna.GetName(), len(validationResp.Errors)), validationResp)
+    }
+
+    checksum, err := s.rust.CalculateChecksum(dna)
+    if err != nil {
+        s.logger.Error("Failed to calculate checksum", zap.Error(err))
+        return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+    }
+    dna.Checksum = checksum
+
+    return validationResp, nil
+}
+
+// validationFailedError reports a failed validation as InvalidArgument.
+func validationFailedError(msg string, resp *pb.ValidationResponse) error {
+    return validationStatusError(codes.InvalidArgument, msg, resp)
+}
+
+// validationStatusError builds a status with one field violation per validation
+// error, so REST clients can surface them per field. The report itself goes
+// with it, for the validator's codes.
+func validationStatusError(code codes.Code, msg string, resp *pb.ValidationResponse) error {
+    st := status.New(code, msg)
+    br := &errdetails.BadRequest{}
+    for _, e := range resp.Errors {
+        br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+            Field:       e.Field,
+            Description: e.Message,
+        })
+    }
+    if withDetails, err := st.WithDetails(br, resp); err == nil {
+        return withDetails.Err()
+    }
+    return st.Err()
+}
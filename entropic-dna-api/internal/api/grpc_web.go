@@ -0,0 +1,349 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebPath prefixes the methods served over gRPC-Web, as in
+// "/entropic.dna.v1.GameDNAService/GetGameDNA".
+var grpcWebPath = "/" + pb.GameDNAService_ServiceDesc.ServiceName + "/"
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	// grpcWebMaxRequest bounds a request body, as the gRPC server bounds a
+	// message.
+	grpcWebMaxRequest = 4 << 20
+	// grpcWebTrailerFlag marks the frame holding the trailers.
+	grpcWebTrailerFlag = 0x80
+)
+
+// grpcWebSkipHeaders are the request headers that describe the HTTP exchange
+// rather than the call, so aren't passed on as metadata.
+var grpcWebSkipHeaders = map[string]bool{
+	"accept": true, "accept-encoding": true, "accept-language": true, "connection": true,
+	"content-length": true, "content-type": true, "cookie": true, "grpc-timeout": true,
+	"host": true, "origin": true, "referer": true, "te": true, "user-agent": true,
+	"x-grpc-web": true, "x-user-agent": true,
+	// The caller's address is set from the connection, as the REST gateway
+	// sets it, so a caller can't pick the address it is rate limited by.
+	"x-forwarded-for": true, "x-real-ip": true, "forwarded": true,
+}
+
+// grpcWebExposedHeaders are the response headers browsers let clients read:
+// the status, when a call fails before any message, and the request ID.
+var grpcWebExposedHeaders = "grpc-status, grpc-message, grpc-status-details-bin, " + strings.ToLower(RequestIDHeader) + ", " + idempotentReplayedMetadata
+
+// serveGRPCWeb answers gRPC-Web calls, from browsers and from clients such as
+// Unity's that can't speak HTTP/2 gRPC, by making them on conn. They pass
+// through the gRPC server's authentication, rate limits and validation like
+// any other call. Client-streaming methods, which gRPC-Web can't carry, are
+// Unimplemented. Cross-origin calls are allowed from origins, where "*" is
+// any origin.
+func serveGRPCWeb(conn *grpc.ClientConn, origins []string) http.Handler {
+	streams := map[string]grpc.StreamDesc{}
+	for _, m := range pb.GameDNAService_ServiceDesc.Methods {
+		streams[m.MethodName] = grpc.StreamDesc{StreamName: m.MethodName}
+	}
+	for _, s := range pb.GameDNAService_ServiceDesc.Streams {
+		streams[s.StreamName] = s
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r) {
+			if !slices.Contains(origins, "*") && !slices.Contains(origins, origin) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", grpcWebExposedHeaders)
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+				w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		contentType := r.Header.Get("Content-Type")
+		text := strings.HasPrefix(contentType, grpcWebTextContentType)
+		if !text && !strings.HasPrefix(contentType, grpcWebContentType) {
+			http.Error(w, "want a content type of application/grpc-web or application/grpc-web-text", http.StatusUnsupportedMediaType)
+			return
+		}
+		var body io.Reader = http.MaxBytesReader(w, r.Body, grpcWebMaxRequest)
+		var out io.Writer = w
+		if text {
+			body = base64.NewDecoder(base64.StdEncoding, body)
+			out = grpcWebTextWriter{w}
+			w.Header().Set("Content-Type", grpcWebTextContentType+"+proto")
+		} else {
+			w.Header().Set("Content-Type", grpcWebContentType+"+proto")
+		}
+
+		method := strings.TrimPrefix(r.URL.Path, grpcWebPath)
+		desc, ok := streams[method]
+		if !ok {
+			writeGRPCWebTrailers(out, status.New(codes.Unimplemented, fmt.Sprintf("unknown method %s", method)), nil)
+			return
+		}
+		if desc.ClientStreams {
+			writeGRPCWebTrailers(out, status.New(codes.Unimplemented, "gRPC-Web can't stream requests; use the REST gateway or gRPC"), nil)
+			return
+		}
+		req, err := readGRPCWebFrame(body)
+		if err != nil {
+			writeGRPCWebTrailers(out, status.Convert(err), nil)
+			return
+		}
+
+		ctx := metadata.NewOutgoingContext(r.Context(), grpcWebMetadata(r))
+		if timeout, ok := grpcWebTimeout(r.Header.Get("Grpc-Timeout")); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		desc.ServerStreams = true
+		stream, err := conn.NewStream(ctx, &desc, grpcWebPath+method, grpc.ForceCodec(rawCodec{}))
+		if err == nil {
+			err = stream.SendMsg(req)
+		}
+		if err == nil {
+			err = stream.CloseSend()
+		}
+		if err != nil {
+			writeGRPCWebTrailers(out, status.Convert(err), nil)
+			return
+		}
+
+		flusher, _ := w.(http.Flusher)
+		wroteHeader := false
+		for {
+			var msg rawMessage
+			err := stream.RecvMsg(&msg)
+			if !wroteHeader {
+				if header, _ := stream.Header(); header != nil {
+					for key, vals := range header {
+						if key == "content-type" {
+							continue
+						}
+						for _, v := range vals {
+							w.Header().Add(key, v)
+						}
+					}
+				}
+				wroteHeader = true
+			}
+			if err == io.EOF {
+				writeGRPCWebTrailers(out, status.New(codes.OK, ""), stream.Trailer())
+				return
+			}
+			if err != nil {
+				writeGRPCWebTrailers(out, status.Convert(err), stream.Trailer())
+				return
+			}
+			if _, err := out.Write(grpcWebFrame(0, msg)); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// sameOrigin reports whether origin is the gateway's own, which browsers
+// send on POSTs without needing CORS.
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// readGRPCWebFrame reads the single, uncompressed message a gRPC-Web request
+// carries.
+func readGRPCWebFrame(body io.Reader) (rawMessage, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "request holds no message")
+	}
+	if prefix[0] != 0 {
+		return nil, status.Error(codes.Unimplemented, "compressed gRPC-Web requests aren't supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > grpcWebMaxRequest {
+		return nil, status.Errorf(codes.ResourceExhausted, "request is larger than %d bytes", grpcWebMaxRequest)
+	}
+	msg := make(rawMessage, size)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, status.Errorf(codes.ResourceExhausted, "request is larger than %d bytes", grpcWebMaxRequest)
+		}
+		return nil, status.Error(codes.InvalidArgument, "request message is truncated")
+	}
+	return msg, nil
+}
+
+// grpcWebFrame prefixes data with its flags and length.
+func grpcWebFrame(flags byte, data []byte) []byte {
+	frame := make([]byte, 5+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[5:], data)
+	return frame
+}
+
+// writeGRPCWebTrailers ends a response with st and trailer, as gRPC-Web
+// carries trailers: in a last frame of HTTP/1-style header lines.
+func writeGRPCWebTrailers(out io.Writer, st *status.Status, trailer metadata.MD) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "grpc-status: %d\r\n", st.Code())
+	if st.Message() != "" {
+		fmt.Fprintf(&b, "grpc-message: %s\r\n", percentEncode(st.Message()))
+	}
+	if len(st.Details()) > 0 {
+		if details, err := proto.Marshal(st.Proto()); err == nil {
+			fmt.Fprintf(&b, "grpc-status-details-bin: %s\r\n", base64.RawStdEncoding.EncodeToString(details))
+		}
+	}
+	for key, vals := range trailer {
+		if key == "content-type" {
+			continue
+		}
+		for _, v := range vals {
+			if strings.HasSuffix(key, "-bin") {
+				v = base64.RawStdEncoding.EncodeToString([]byte(v))
+			}
+			fmt.Fprintf(&b, "%s: %s\r\n", key, v)
+		}
+	}
+	_, _ = out.Write(grpcWebFrame(grpcWebTrailerFlag, []byte(b.String())))
+}
+
+// percentEncode encodes a grpc-message as the gRPC protocol does: bytes
+// outside printable ASCII, and '%', as %XX.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// grpcWebMetadata passes a request's headers on as the call's metadata, as
+// gRPC-Web clients send metadata, less those about the HTTP exchange. The
+// gRPC server sees the call come from the gateway, so x-forwarded-for is set
+// to the address the request came from, which the rate limiter, audit log
+// and request logs take as the caller's.
+func grpcWebMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Set("x-forwarded-for", ip)
+	}
+	for key, vals := range r.Header {
+		key = strings.ToLower(key)
+		if grpcWebSkipHeaders[key] || strings.HasPrefix(key, "access-control-") || strings.HasPrefix(key, "sec-") {
+			continue
+		}
+		if strings.HasSuffix(key, "-bin") {
+			for _, v := range vals {
+				if decoded, err := base64.StdEncoding.DecodeString(v); err == nil {
+					md.Append(key, string(decoded))
+				} else if decoded, err := base64.RawStdEncoding.DecodeString(v); err == nil {
+					md.Append(key, string(decoded))
+				}
+			}
+			continue
+		}
+		md.Append(key, vals...)
+	}
+	return md
+}
+
+// grpcWebTimeout parses a grpc-timeout header, such as "500m" or "10S".
+func grpcWebTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	unit := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}[v[len(v)-1]]
+	if unit == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// grpcWebTextWriter base64-encodes each frame on its own, as
+// application/grpc-web-text clients decode them.
+type grpcWebTextWriter struct {
+	w io.Writer
+}
+
+func (t grpcWebTextWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(t.w, base64.StdEncoding.EncodeToString(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rawMessage is an encoded protobuf message, passed through unread.
+type rawMessage []byte
+
+// rawCodec passes messages between gRPC-Web and gRPC without decoding them.
+// It is named "proto" so the server decodes them as it would any call's.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec can't marshal %T", v)
+	}
+	return msg, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("rawCodec can't unmarshal into %T", v)
+	}
+	*msg = append((*msg)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
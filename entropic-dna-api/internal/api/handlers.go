@@ -2,3 +2,94 @@ package api
 
 // This file is reserved for custom HTTP handlers/middleware that complement grpc-gateway.
 // The REST API surface is primarily generated from protobuf annotations.
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// RequestIDHeader carries the request ID on REST requests and responses.
+	RequestIDHeader = "X-Request-Id"
+	// RequestIDMetadata carries the request ID on gRPC calls. The REST gateway
+	// forwards RequestIDHeader as this key.
+	RequestIDMetadata = "x-request-id"
+	// httpStatusMetadata carries a REST status override from a handler to the gateway.
+	httpStatusMetadata = "x-http-code"
+	healthPath         = "/healthz"
+)
+
+// setHTTPStatus asks the REST gateway to answer with code instead of 200 OK.
+// It has no effect on gRPC clients.
+func setHTTPStatus(ctx context.Context, code int) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(httpStatusMetadata, strconv.Itoa(code)))
+}
+
+// forwardHTTPStatus applies a status set with setHTTPStatus to the REST response.
+func forwardHTTPStatus(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	vals := md.HeaderMD.Get(httpStatusMetadata)
+	if len(vals) == 0 {
+		return nil
+	}
+	code, err := strconv.Atoi(vals[0])
+	if err != nil {
+		return err
+	}
+	w.Header().Del(runtime.MetadataHeaderPrefix + httpStatusMetadata)
+	if code == http.StatusNoContent {
+		w.Header().Del("Content-Type")
+	}
+	w.WriteHeader(code)
+	return nil
+}
+
+// dropNoContentBody discards the body the gateway marshals after a handler
+// set 204 No Content with setHTTPStatus, which must have none.
+func dropNoContentBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&noContentWriter{ResponseWriter: w}, r)
+	})
+}
+
+// noContentWriter drops writes once the status is 204. It flushes through,
+// so event streams still reach the client as they are written.
+type noContentWriter struct {
+	http.ResponseWriter
+	noContent bool
+}
+
+func (w *noContentWriter) WriteHeader(code int) {
+	w.noContent = code == http.StatusNoContent
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *noContentWriter) Write(p []byte) (int, error) {
+	if w.noContent {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *noContentWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// serveHealth reports that the REST gateway is accepting requests. Whether
+// the server can serve them is up to /readyz.
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
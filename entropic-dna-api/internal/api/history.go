@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// versionPageToken is what a GetVersionHistory page_token carries: the
+// config it pages and the oldest version already returned. Like list tokens
+// it is opaque base64 JSON.
+type versionPageToken struct {
+	ConfigID string `json:"c"`
+	Before   int64  `json:"v"`
+}
+
+// encodeVersionPageToken returns the token continuing below version before.
+func encodeVersionPageToken(configID string, before int64) string {
+	data, _ := json.Marshal(versionPageToken{ConfigID: configID, Before: before})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeVersionPageToken returns the version token continues below,
+// rejecting tokens that are malformed or were issued for another config.
+func decodeVersionPageToken(token, configID string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	var t versionPageToken
+	if err == nil {
+		err = json.Unmarshal(data, &t)
+	}
+	if err != nil || t.Before <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	if t.ConfigID != configID {
+		return 0, status.Error(codes.InvalidArgument, "page_token was issued for a different config_id")
+	}
+	return t.Before, nil
+}
+
+// versionPage returns a page of configID's versions, newest first, and the
+// token of the next page, if there is one. A pageSize of 0 returns every
+// version after pageToken.
+func (s *GameDNAServiceServer) versionPage(ctx context.Context, configID string, pageSize int32, pageToken string, withoutData bool) ([]*storage.VersionInfo, string, error) {
+	page := storage.VersionPage{WithoutData: withoutData}
+	if pageToken != "" {
+		before, err := decodeVersionPageToken(pageToken, configID)
+		if err != nil {
+			return nil, "", err
+		}
+		page.Before = before
+	}
+	if pageSize > 0 {
+		// One more than asked tells whether another page follows
+		page.Limit = int(pageSize) + 1
+	}
+	versions, err := s.store.ListVersions(ctx, configID, page)
+	if err != nil {
+		s.logger.Error("Failed to get version history", zap.Error(err))
+		return nil, "", fmt.Errorf("failed to get version history: %w", err)
+	}
+	if pageSize <= 0 || len(versions) <= int(pageSize) {
+		return versions, "", nil
+	}
+	versions = versions[:pageSize]
+	return versions, encodeVersionPageToken(configID, versions[len(versions)-1].VersionNum), nil
+}
+
+// ListVersionMetadata lists a config's versions without reading their data,
+// newest first, a page at a time when the request sets a page size.
+func (s *GameDNAServiceServer) ListVersionMetadata(ctx context.Context, req *pb.ListVersionMetadataRequest) (*pb.ListVersionMetadataResponse, error) {
+	versions, nextPageToken, err := s.versionPage(ctx, req.ConfigId, req.PageSize, req.PageToken, true)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.releaseTagsByVersion(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to list release tags", zap.Error(err))
+		return nil, fmt.Errorf("failed to list release tags: %w", err)
+	}
+
+	resp := &pb.ListVersionMetadataResponse{NextPageToken: nextPageToken}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, &pb.VersionMetadata{
+			VersionNum:    v.VersionNum,
+			Checksum:      v.Checksum,
+			CreateTime:    models.TimestampProto(v.CreatedAt),
+			CreatedBy:     v.CreatedBy,
+			Size:          v.Size,
+			ChangedFields: v.ChangedFields,
+			Changes:       versionChangesProto(v.Changes),
+			ReleaseTags:   tags[v.VersionNum],
+		})
+	}
+	return resp, nil
+}
+
+// GetVersion returns one version of a config with its data.
+func (s *GameDNAServiceServer) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	v, err := s.store.GetVersion(ctx, req.ConfigId, req.VersionNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+	tags, err := s.releaseTagsByVersion(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to list release tags", zap.Error(err))
+		return nil, fmt.Errorf("failed to list release tags: %w", err)
+	}
+
+	version := versionInfoProto(v)
+	version.ReleaseTags = tags[v.VersionNum]
+	return &pb.GetVersionResponse{Version: version}, nil
+}
+
+// PruneVersionHistory deletes the versions the retention options let go:
+// those past the newest MaxHistoryDepth of a config and those older than
+// MaxHistoryAge. Writes already trim to the depth, but configs that are no
+// longer written only lose versions here. Each config keeps its newest
+// version, and versions pinned by a snapshot or named by a release tag. The
+// server calls it periodically.
+func (s *GameDNAServiceServer) PruneVersionHistory(ctx context.Context) (int64, error) {
+	retention := storage.HistoryRetention{MaxVersions: s.opts.MaxHistoryDepth}
+	if s.opts.MaxHistoryAge > 0 {
+		retention.CreatedBefore = models.Now().Add(-s.opts.MaxHistoryAge)
+	}
+	if retention.MaxVersions <= 0 && retention.CreatedBefore.IsZero() {
+		return 0, nil
+	}
+	pruned, err := s.store.PruneVersionHistory(ctx, retention)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune version history: %w", err)
+	}
+	return pruned, nil
+}
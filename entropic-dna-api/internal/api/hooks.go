@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hookActor names the authenticated caller for lifecycle hooks, or "".
+func hookActor(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.Name
+	}
+	return ""
+}
+
+// runPreHooks runs the pre hooks of stage on dna and returns the config to
+// carry on with. A rejection is FailedPrecondition.
+func (s *GameDNAServiceServer) runPreHooks(ctx context.Context, stage hooks.Stage, id string, dna *pb.GameDNA) (*pb.GameDNA, error) {
+	out, err := s.opts.Hooks.RunPre(ctx, stage, id, hookActor(ctx), dna)
+	if err != nil {
+		s.logger.Warn("Change rejected by lifecycle hook", zap.String("stage", string(stage)), zap.String("id", id), zap.Error(err))
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return out, nil
+}
+
+// runPostHooks starts the post hooks of stage for a change that was made.
+func (s *GameDNAServiceServer) runPostHooks(ctx context.Context, stage hooks.Stage, dna *pb.GameDNA) {
+	s.opts.Hooks.RunPost(stage, dna.Id, hookActor(ctx), dna)
+}
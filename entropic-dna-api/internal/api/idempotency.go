@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// IdempotencyKeyHeader carries a client's idempotency key on REST
+	// requests.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyKeyMetadata carries it on gRPC calls. The REST gateway
+	// forwards IdempotencyKeyHeader as this key.
+	IdempotencyKeyMetadata = "idempotency-key"
+	// idempotentReplayedMetadata marks a response replayed for a retry. The
+	// REST gateway sends it as Grpc-Metadata-Idempotent-Replayed.
+	idempotentReplayedMetadata = "idempotent-replayed"
+	// maxIdempotencyKeyLength bounds a key, room enough for a UUID or a CI
+	// job's name and attempt.
+	maxIdempotencyKeyLength = 255
+	// idempotencyClaimLease is how long a call in progress holds its key. A
+	// retry within it is turned away; after it, as when the server running
+	// the call died, the retry runs.
+	idempotencyClaimLease = 2 * time.Minute
+)
+
+// CreateGameDNA creates a game configuration, at most once per idempotency
+// key: a retry carrying the key gets the first call's response instead of a
+// second config.
+func (s *GameDNAServiceServer) CreateGameDNA(ctx context.Context, req *pb.CreateGameDNARequest) (*pb.GameDNAResponse, error) {
+	resp, err := s.idempotent(ctx, "CreateGameDNA", req, &pb.GameDNAResponse{}, func() (proto.Message, error) {
+		return s.createGameDNA(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.GameDNAResponse), nil
+}
+
+// CloneGameDNA copies a game configuration, at most once per idempotency key.
+func (s *GameDNAServiceServer) CloneGameDNA(ctx context.Context, req *pb.CloneGameDNARequest) (*pb.GameDNAResponse, error) {
+	resp, err := s.idempotent(ctx, "CloneGameDNA", req, &pb.GameDNAResponse{}, func() (proto.Message, error) {
+		return s.cloneGameDNA(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.GameDNAResponse), nil
+}
+
+// PublishGameDNA re-validates and locks a game configuration, at most once
+// per idempotency key.
+func (s *GameDNAServiceServer) PublishGameDNA(ctx context.Context, req *pb.PublishGameDNARequest) (*pb.PublishedGameDNAResponse, error) {
+	resp, err := s.idempotent(ctx, "PublishGameDNA", req, &pb.PublishedGameDNAResponse{}, func() (proto.Message, error) {
+		return s.publishGameDNA(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.PublishedGameDNAResponse), nil
+}
+
+// idempotent runs call, the handler of method for req, unless the call
+// carries an idempotency key a call of the same caller already used. A retry
+// of a call that succeeded gets its response decoded into replay; of one in
+// progress, Aborted; of one that failed, a fresh run. A key reused for
+// another request is rejected.
+func (s *GameDNAServiceServer) idempotent(ctx context.Context, method string, req proto.Message, replay proto.Message, call func() (proto.Message, error)) (proto.Message, error) {
+	key := idempotencyKey(ctx)
+	if key == "" || s.opts.IdempotencyKeyTTL <= 0 {
+		return call()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+	}
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	now := models.Now()
+	claim := &storage.IdempotencyKey{
+		Scope:       idempotencyScope(ctx, method),
+		Key:         key,
+		RequestHash: hex.EncodeToString(sum[:]),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyClaimLease),
+	}
+
+	existing, err := s.store.ClaimIdempotencyKey(ctx, claim)
+	switch {
+	case errors.Is(err, storage.ErrConflict):
+		if existing.RequestHash != claim.RequestHash {
+			return nil, status.Error(codes.InvalidArgument, "idempotency key was already used for a different request")
+		}
+		if existing.Response == nil {
+			return nil, status.Error(codes.Aborted, "a request with this idempotency key is in progress; retry later")
+		}
+		if err := proto.Unmarshal(existing.Response, replay); err != nil {
+			return nil, fmt.Errorf("failed to decode replayed response: %w", err)
+		}
+		s.logger.Debug("Replayed idempotent request", zap.String("method", method), zap.String("idempotency_key", key))
+		_ = grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedMetadata, "true"))
+		return replay, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	resp, callErr := call()
+	var response []byte
+	if callErr == nil {
+		if response, err = proto.Marshal(resp); err != nil {
+			s.logger.Warn("Failed to encode idempotent response", zap.String("idempotency_key", key), zap.Error(err))
+		}
+	}
+	// The claim is released when the call failed, so a retry runs it again.
+	// The response is saved even if the client gave up on the call, as after
+	// a gateway timeout, since that is when its retry most needs it. Failing
+	// to save it only turns retries away until the claim lapses.
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := s.store.CompleteIdempotencyKey(saveCtx, claim.Scope, key, response, models.Now().Add(s.opts.IdempotencyKeyTTL)); err != nil {
+		s.logger.Warn("Failed to save idempotent response", zap.String("idempotency_key", key), zap.Error(err))
+	}
+	return resp, callErr
+}
+
+// idempotencyKey returns the key a call carries, or "".
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(IdempotencyKeyMetadata); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// idempotencyScope keeps the keys of each caller and method apart. The
+// caller is hashed, so erasing a principal needn't reach the records.
+func idempotencyScope(ctx context.Context, method string) string {
+	sum := sha256.Sum256([]byte(hookActor(ctx)))
+	return method + ":" + hex.EncodeToString(sum[:16])
+}
+
+// PruneIdempotencyKeys deletes the idempotency records that have lapsed.
+func (s *GameDNAServiceServer) PruneIdempotencyKeys(ctx context.Context) (int64, error) {
+	pruned, err := s.store.PruneIdempotencyKeys(ctx, models.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune idempotency keys: %w", err)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/sheets"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Import row statuses, in addition to the bulk update ones.
+const (
+	importStatusCreated     = "created"
+	importStatusWouldCreate = "would_create"
+)
+
+// maxImportRows bounds a single import so one upload can't monopolise the store.
+const maxImportRows = 5000
+
+// ImportSheet creates or updates one config per data row of a CSV or XLSX
+// sheet. Rows with an id or slug matching an existing config update it; other
+// rows create a new config. Each row is validated on its own, and empty cells
+// leave the field unchanged.
+func (s *GameDNAServiceServer) ImportSheet(ctx context.Context, req *pb.ImportSheetRequest) (*pb.ImportSheetResponse, error) {
+	// The header row comes on top of the data rows
+	rows, err := sheets.Read(req.Format, req.Data, maxImportRows+1)
+	if errors.Is(err, sheets.ErrTooManyRows) {
+		return nil, status.Errorf(codes.InvalidArgument, "sheet has more than %d data rows", maxImportRows)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.importRows(ctx, rows, req.Project, req.Columns, req.Actor, req.DryRun)
+}
+
+// importRows imports sheet rows using the project's saved mapping overlaid
+// with overrides. The first row is the header.
+func (s *GameDNAServiceServer) importRows(ctx context.Context, rows [][]string, project string, overrides map[string]string, actor string, dryRun bool) (*pb.ImportSheetResponse, error) {
+	if len(rows) < 2 {
+		return nil, status.Error(codes.InvalidArgument, "sheet needs a header row and at least one data row")
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, status.Errorf(codes.InvalidArgument, "sheet has %d data rows; the limit is %d", len(rows)-1, maxImportRows)
+	}
+
+	mapping := make(map[string]string)
+	if project != "" {
+		saved, err := s.store.GetImportMapping(ctx, project)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			if len(overrides) == 0 {
+				return nil, status.Errorf(codes.NotFound, "no import mapping saved for project %s", project)
+			}
+		case err != nil:
+			s.logger.Error("Failed to read import mapping", zap.Error(err))
+			return nil, fmt.Errorf("failed to read import mapping: %w", err)
+		default:
+			for header, path := range saved.Columns {
+				mapping[normalizeHeader(header)] = path
+			}
+		}
+	}
+	for header, path := range overrides {
+		mapping[normalizeHeader(header)] = path
+	}
+
+	columns, ignored, err := importColumns(rows[0], mapping)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &pb.ImportSheetResponse{Counts: make(map[string]int32)}
+	for i, row := range rows[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		result := s.importRow(ctx, row, columns, actor, dryRun)
+		result.Row = int32(i + 2)
+		resp.Rows = append(resp.Rows, result)
+		resp.Counts[result.Status]++
+	}
+
+	resp.Message = fmt.Sprintf("Imported %d rows", len(resp.Rows))
+	if len(ignored) > 0 {
+		resp.Message += fmt.Sprintf("; ignored unmapped columns: %s", strings.Join(ignored, ", "))
+	}
+
+	return resp, nil
+}
+
+// importColumns maps header positions to field paths. Headers without a
+// mapping are used as-is when they name a GameDNA field, and ignored otherwise.
+func importColumns(header []string, mapping map[string]string) (map[int]string, []string, error) {
+	for _, path := range mapping {
+		if err := sheets.CheckField(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	columns := make(map[int]string)
+	used := make(map[string]string)
+	var ignored []string
+	for i, h := range header {
+		key := normalizeHeader(h)
+		if key == "" {
+			continue
+		}
+		path, ok := mapping[key]
+		if !ok {
+			if sheets.CheckField(key) != nil {
+				ignored = append(ignored, strings.TrimSpace(h))
+				continue
+			}
+			path = key
+		}
+		if prev, dup := used[path]; dup {
+			return nil, nil, fmt.Errorf("columns %q and %q both map to %s", prev, strings.TrimSpace(h), path)
+		}
+		used[path] = strings.TrimSpace(h)
+		columns[i] = path
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("no sheet columns map to GameDNA fields")
+	}
+	return columns, ignored, nil
+}
+
+// importRow creates or updates the config described by one sheet row.
+func (s *GameDNAServiceServer) importRow(ctx context.Context, row []string, columns map[int]string, actor string, dryRun bool) *pb.ImportRowResult {
+	result := &pb.ImportRowResult{}
+	values := make(map[string]string)
+	for i, path := range columns {
+		if i < len(row) && strings.TrimSpace(row[i]) != "" {
+			values[path] = strings.TrimSpace(row[i])
+		}
+	}
+
+	current, err := s.importTarget(ctx, values)
+	if err != nil {
+		result.Status = bulkStatusInvalid
+		result.Error = err.Error()
+		return result
+	}
+
+	dna := &pb.GameDNA{}
+	if current != nil {
+		result.ConfigId = current.Id
+		dna = proto.Clone(current).(*pb.GameDNA)
+	}
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if path == "id" {
+			continue
+		}
+		if err := sheets.SetField(dna, path, values[path]); err != nil {
+			result.Status = bulkStatusInvalid
+			result.Error = err.Error()
+			return result
+		}
+	}
+	result.Name = dna.Name
+	if err := checkSlug(dna); err != nil {
+		result.Status = bulkStatusInvalid
+		result.Error = status.Convert(err).Message()
+		return result
+	}
+	if err := checkEnvironment(dna); err != nil {
+		result.Status = bulkStatusInvalid
+		result.Error = status.Convert(err).Message()
+		return result
+	}
+	if actor != "" {
+		dna.CreatedBy = actor
+	}
+	if current == nil && dna.Slug == "" {
+		dna.Slug = storage.Slugify(dna.Name)
+	}
+	result.Changes = fieldChangesToProto(diff.Compare(current, dna))
+	// Locked configs are reported as such even when the row matches them,
+	// as UpdateGameDNA checks the lock before the content.
+	if current != nil && current.IsLocked {
+		result.Status = bulkStatusLocked
+		return result
+	}
+	if current != nil && len(result.Changes) == 0 {
+		result.Status = bulkStatusUnchanged
+		return result
+	}
+
+	validationResp, err := s.validate(ctx, dna)
+	if err != nil {
+		result.Status = bulkStatusFailed
+		result.Error = fmt.Sprintf("validation error: %v", err)
+		return result
+	}
+	result.Validation = validationResp
+	if !validationResp.IsValid {
+		result.Status = bulkStatusInvalid
+		return result
+	}
+
+	checksum, err := s.rust.CalculateChecksum(dna)
+	if err != nil {
+		result.Status = bulkStatusFailed
+		result.Error = fmt.Sprintf("failed to calculate checksum: %v", err)
+		return result
+	}
+	if dryRun {
+		result.Status = bulkStatusWouldUpdate
+		if current == nil {
+			result.Status = importStatusWouldCreate
+		}
+		return result
+	}
+	dna.Checksum = checksum
+
+	if current == nil {
+		created, err := s.store.Create(ctx, dna, storage.WithValidation(validationResp))
+		if err != nil {
+			s.logger.Warn("Import create failed", zap.String("name", dna.Name), zap.Error(err))
+			result.Status = bulkStatusFailed
+			result.Error = err.Error()
+			return result
+		}
+		result.ConfigId = created.Id
+		result.Status = importStatusCreated
+		s.changed(ctx, events.TypeCreated, created.Id, created)
+		return result
+	}
+
+	updated, err := s.store.Update(ctx, dna, storage.WithValidation(validationResp))
+	switch {
+	case errors.Is(err, storage.ErrNotModified):
+		result.Status = bulkStatusUnchanged
+	case err != nil:
+		s.logger.Warn("Import update failed", zap.String("id", current.Id), zap.Error(err))
+		result.Status = bulkStatusFailed
+		result.Error = err.Error()
+	default:
+		result.Status = bulkStatusUpdated
+		s.changed(ctx, events.TypeUpdated, updated.Id, updated)
+	}
+	return result
+}
+
+// importTarget finds the config a row refers to by id or slug. It returns nil
+// when the row describes a new config.
+func (s *GameDNAServiceServer) importTarget(ctx context.Context, values map[string]string) (*pb.GameDNA, error) {
+	if id := values["id"]; id != "" {
+		current, err := s.store.Read(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("config %s not found", id)
+		}
+		return current, nil
+	}
+	if slug := values["slug"]; slug != "" {
+		if current, err := s.store.ReadBySlug(ctx, slug); err == nil {
+			return current, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveImportMapping stores the column mapping used for a project's sheets.
+func (s *GameDNAServiceServer) SaveImportMapping(ctx context.Context, req *pb.SaveImportMappingRequest) (*pb.ImportMappingResponse, error) {
+	if req.Project == "" {
+		return nil, status.Error(codes.InvalidArgument, "project is required")
+	}
+	if len(req.Columns) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "columns must map at least one header")
+	}
+	for header, path := range req.Columns {
+		if strings.TrimSpace(header) == "" {
+			return nil, status.Error(codes.InvalidArgument, "column headers cannot be empty")
+		}
+		if err := sheets.CheckField(path); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	saved, err := s.store.SaveImportMapping(ctx, &storage.ImportMapping{
+		Project:   req.Project,
+		Columns:   req.Columns,
+		UpdatedBy: req.Actor,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save import mapping", zap.Error(err))
+		return nil, fmt.Errorf("failed to save import mapping: %w", err)
+	}
+
+	return &pb.ImportMappingResponse{
+		Mapping: importMappingToProto(saved),
+		Message: "Import mapping saved",
+	}, nil
+}
+
+// GetImportMapping returns a project's saved import mapping.
+func (s *GameDNAServiceServer) GetImportMapping(ctx context.Context, req *pb.GetImportMappingRequest) (*pb.ImportMappingResponse, error) {
+	mapping, err := s.store.GetImportMapping(ctx, req.Project)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "no import mapping saved for project %s", req.Project)
+	}
+	if err != nil {
+		s.logger.Error("Failed to read import mapping", zap.Error(err))
+		return nil, fmt.Errorf("failed to read import mapping: %w", err)
+	}
+
+	return &pb.ImportMappingResponse{
+		Mapping: importMappingToProto(mapping),
+		Message: "Import mapping retrieved",
+	}, nil
+}
+
+func importMappingToProto(m *storage.ImportMapping) *pb.ImportMapping {
+	return &pb.ImportMapping{
+		Project:   m.Project,
+		Columns:   m.Columns,
+		UpdatedAt: m.UpdatedAt,
+		UpdatedBy: m.UpdatedBy,
+	}
+}
+
+func normalizeHeader(h string) string {
+	return strings.ToLower(strings.TrimSpace(h))
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
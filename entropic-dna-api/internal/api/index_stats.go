@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"go.uber.org/zap"
+)
+
+// GetIndexStats reports how often each storage index has been used, so
+// operators can spot unused indexes and queries that miss them.
+func (s *GameDNAServiceServer) GetIndexStats(ctx context.Context, req *pb.GetIndexStatsRequest) (*pb.GetIndexStatsResponse, error) {
+	stats, err := s.store.IndexStats(ctx)
+	if err != nil {
+		s.logger.Error("Failed to read index stats", zap.Error(err))
+		return nil, fmt.Errorf("failed to read index stats: %w", err)
+	}
+
+	resp := &pb.GetIndexStatsResponse{
+		Message: fmt.Sprintf("%d indexes", len(stats)),
+	}
+	if len(stats) == 0 {
+		resp.Message = "storage backend has no indexes"
+	}
+	for _, st := range stats {
+		resp.Indexes = append(resp.Indexes, &pb.IndexStat{
+			Table:         st.Table,
+			Index:         st.Index,
+			Scans:         st.Scans,
+			TuplesRead:    st.TuplesRead,
+			TuplesFetched: st.TuplesFetched,
+			SizeBytes:     st.SizeBytes,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListLimits keeps a single List call from scanning the whole table.
+type ListLimits struct {
+	// DefaultPageSize is used when a request leaves page_size unset; 0 uses
+	// storage.DefaultPageSize.
+	DefaultPageSize int32
+	// MaxPageSize is the largest page_size accepted.
+	MaxPageSize int32
+	// MaxOffset is the deepest row offset, (page-1)*page_size, accepted.
+	MaxOffset int64
+	// MinNameFilterLength is the shortest name_filter accepted, not counting
+	// wildcard characters.
+	MinNameFilterLength int
+}
+
+// nameFilterWildcards match any text in a name filter. % and _ are LIKE
+// wildcards in the Postgres store.
+const nameFilterWildcards = "%_*"
+
+// pageSize returns requested, or the default page size when it is unset.
+func (l ListLimits) pageSize(requested int32) int32 {
+	if requested != 0 {
+		return requested
+	}
+	if l.DefaultPageSize > 0 {
+		return l.DefaultPageSize
+	}
+	return storage.DefaultPageSize
+}
+
+// check rejects list requests outside the limits.
+func (l ListLimits) check(req *pb.ListGameDNARequest) error {
+	if err := l.checkPage(req.Page, req.PageSize); err != nil {
+		return err
+	}
+	if req.PageToken != "" && req.Page != 0 {
+		return status.Error(codes.InvalidArgument, "page and page_token cannot be combined")
+	}
+
+	if req.NameFilter != "" && l.MinNameFilterLength > 0 {
+		literal := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(nameFilterWildcards, r) {
+				return -1
+			}
+			return r
+		}, strings.TrimSpace(req.NameFilter))
+		if n := utf8.RuneCountInString(literal); n < l.MinNameFilterLength {
+			return status.Errorf(codes.InvalidArgument,
+				"name_filter must contain at least %d characters other than wildcards, got %d", l.MinNameFilterLength, n)
+		}
+	}
+
+	return nil
+}
+
+// checkPage rejects pages outside the limits, for List and Search.
+func (l ListLimits) checkPage(requestedPage, requestedPageSize int32) error {
+	if requestedPage < 0 {
+		return status.Error(codes.InvalidArgument, "page cannot be negative")
+	}
+	if requestedPageSize < 0 {
+		return status.Error(codes.InvalidArgument, "page_size cannot be negative")
+	}
+	if l.MaxPageSize > 0 && requestedPageSize > l.MaxPageSize {
+		return status.Errorf(codes.InvalidArgument, "page_size %d exceeds the maximum of %d", requestedPageSize, l.MaxPageSize)
+	}
+
+	page, pageSize := int64(requestedPage), int64(l.pageSize(requestedPageSize))
+	if page == 0 {
+		page = 1
+	}
+	if offset := (page - 1) * pageSize; l.MaxOffset > 0 && offset > l.MaxOffset {
+		return status.Errorf(codes.InvalidArgument,
+			"page %d is too deep: offset %d exceeds the maximum of %d; narrow the filters instead of paging further",
+			requestedPage, offset, l.MaxOffset)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// changed announces a successful change to config id: it publishes the change
+// event, queues it for webhooks and records the change against the request
+// in the journal.
+func (s *GameDNAServiceServer) changed(ctx context.Context, eventType, id string, dna *pb.GameDNA) {
+	s.opts.Events.Publish(eventType, id, dna)
+	s.enqueueWebhooks(ctx, eventType, id, dna)
+	checksum := ""
+	if dna != nil && eventType != events.TypeDeleted {
+		checksum = dna.Checksum
+	}
+	s.opts.Journal.NoteChange(requestIDFromContext(ctx), eventType, id, checksum)
+}
+
+// requestIDFromContext returns the request ID of a gRPC call, or "".
+func requestIDFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(RequestIDMetadata); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// LookupRequest returns what a recent request did: its outcome, the log lines
+// written for it, the changes it made and the versions it wrote.
+func (s *GameDNAServiceServer) LookupRequest(ctx context.Context, req *pb.LookupRequestRequest) (*pb.LookupRequestResponse, error) {
+	if s.opts.Journal == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the request journal is disabled")
+	}
+	if req.RequestId == "" {
+		return nil, status.Error(codes.InvalidArgument, "request_id is required")
+	}
+	entry, ok := s.opts.Journal.Lookup(req.RequestId)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "request %s not found; this instance keeps its last %d requests", req.RequestId, s.opts.Journal.Size())
+	}
+
+	resp := &pb.LookupRequestResponse{
+		RequestId:  entry.RequestID,
+		TraceId:    entry.TraceID,
+		Method:     entry.Method,
+		Code:       entry.Code,
+		Error:      entry.Error,
+		StartTime:  models.TimestampProto(entry.Start),
+		DurationMs: entry.Duration.Milliseconds(),
+		Truncated:  entry.Truncated,
+	}
+	for _, line := range entry.Logs {
+		resp.Logs = append(resp.Logs, &pb.RequestLogLine{
+			Time:    models.TimestampProto(line.Time),
+			Level:   line.Level,
+			Message: line.Message,
+			Fields:  line.Fields,
+		})
+	}
+	for _, c := range entry.Changes {
+		resp.AuditEntries = append(resp.AuditEntries, &pb.RequestAuditEntry{
+			Time:     models.TimestampProto(c.Time),
+			Type:     c.Type,
+			ConfigId: c.ConfigID,
+			Checksum: c.Checksum,
+		})
+	}
+
+	resp.Versions = s.writtenVersions(ctx, entry)
+	return resp, nil
+}
+
+// writtenVersions finds the versions entry's changes wrote: those with the
+// written checksum, stored since the request started. Configs deleted since
+// have no history left and are skipped.
+func (s *GameDNAServiceServer) writtenVersions(ctx context.Context, entry tracing.Entry) []*pb.VersionInfo {
+	written := make(map[string]map[string]bool)
+	var order []string
+	for _, c := range entry.Changes {
+		if c.Checksum == "" {
+			continue
+		}
+		if written[c.ConfigID] == nil {
+			written[c.ConfigID] = make(map[string]bool)
+			order = append(order, c.ConfigID)
+		}
+		written[c.ConfigID][c.Checksum] = true
+	}
+
+	// Version times are stored to the second.
+	since := entry.Start.Truncate(time.Second)
+	var out []*pb.VersionInfo
+	for _, id := range order {
+		history, err := s.store.GetVersionHistory(ctx, id)
+		if err != nil {
+			s.logger.Debug("No version history for changed config", zap.String("config_id", id), zap.Error(err))
+			continue
+		}
+		for _, v := range history {
+			if written[id][v.Checksum] && !v.CreatedAt.Before(since) {
+				out = append(out, versionInfoProto(v))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/schema"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Paths of the documents describing the REST API.
+const (
+	openAPIPath       = "/openapi.json"
+	gameDNASchemaPath = "/schema/gamedna.json"
+)
+
+// schemaRefPrefix starts references to the OpenAPI document's schemas.
+const schemaRefPrefix = "#/components/schemas/"
+
+// pathParam matches a variable of an HTTP rule's path template, as in
+// "{id}" or "{name=projects/*}".
+var pathParam = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// openAPIDocument describes the REST routes of the gRPC service's HTTP
+// annotations as an OpenAPI 3.1 document. Routes the gateway serves by hand,
+// such as bulk export and the event stream, aren't in it.
+func openAPIDocument() schema.Object {
+	sd := pb.File_entropic_dna_v1_service_proto.Services().ByName("GameDNAService")
+	b := schema.NewBuilder(schemaRefPrefix)
+	paths := schema.Object{}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		rules := append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...)
+		for n, r := range rules {
+			verb, template := httpPattern(r)
+			if verb == "" {
+				continue
+			}
+			op := operation(b, md, r)
+			op["operationId"] = string(md.Name())
+			if n > 0 {
+				op["operationId"] = string(md.Name()) + strings.Repeat("_", n)
+			}
+			op["tags"] = []string{string(sd.Name())}
+			path := pathParam.ReplaceAllString(template, "{$1}")
+			item, _ := paths[path].(schema.Object)
+			if item == nil {
+				item = schema.Object{}
+				paths[path] = item
+			}
+			item[verb] = op
+		}
+	}
+
+	// Error bodies are a google.rpc.Status, with the errors the gateway
+	// adds to it.
+	b.Message((&statuspb.Status{}).ProtoReflect().Descriptor())
+	defs := b.Definitions()
+	errorSchema := defs["google.rpc.Status"].(schema.Object)
+	errorSchema["properties"].(schema.Object)["errors"] = schema.Object{
+		"type": "array",
+		"items": schema.Object{
+			"type": "object",
+			"properties": schema.Object{
+				"status": schema.Object{"type": "string"},
+				"code":   schema.Object{"type": "string"},
+				"title":  schema.Object{"type": "string"},
+				"detail": schema.Object{"type": "string"},
+				"source": schema.Object{"type": "object", "properties": schema.Object{"field": schema.Object{"type": "string"}}},
+				"links":  schema.Object{"type": "object", "properties": schema.Object{"about": schema.Object{"type": "string"}}},
+			},
+		},
+	}
+
+	return schema.Object{
+		"openapi":           "3.1.0",
+		"jsonSchemaDialect": schema.JSONSchemaDialect,
+		"info": schema.Object{
+			"title":   "Entropic DNA API",
+			"version": "v1",
+		},
+		"tags":  []schema.Object{{"name": string(sd.Name())}},
+		"paths": paths,
+		// Credentials are needed only with authentication on, hence the
+		// empty alternative.
+		"security": []schema.Object{{"apiKey": []string{}}, {"bearer": []string{}}, {}},
+		"components": schema.Object{
+			"schemas": defs,
+			"securitySchemes": schema.Object{
+				"apiKey": schema.Object{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+				"bearer": schema.Object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// httpPattern returns the lowercase HTTP method and path template of r.
+func httpPattern(r *annotations.HttpRule) (string, string) {
+	switch p := r.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return "get", p.Get
+	case *annotations.HttpRule_Put:
+		return "put", p.Put
+	case *annotations.HttpRule_Post:
+		return "post", p.Post
+	case *annotations.HttpRule_Delete:
+		return "delete", p.Delete
+	case *annotations.HttpRule_Patch:
+		return "patch", p.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToLower(p.Custom.GetKind()), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// operation describes calling md through r: the path and query parameters,
+// the body, and the response.
+func operation(b *schema.Builder, md protoreflect.MethodDescriptor, r *annotations.HttpRule) schema.Object {
+	_, template := httpPattern(r)
+	in := md.Input()
+	var params []schema.Object
+	inPath := map[protoreflect.Name]bool{}
+	for _, m := range pathParam.FindAllStringSubmatch(template, -1) {
+		fd := fieldByPath(in, m[1])
+		if fd == nil {
+			continue
+		}
+		if !strings.Contains(m[1], ".") {
+			inPath[fd.Name()] = true
+		}
+		params = append(params, schema.Object{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   b.Field(fd),
+		})
+	}
+
+	op := schema.Object{}
+	switch r.Body {
+	case "":
+		params = append(params, queryParams(b, in, "", inPath, map[protoreflect.FullName]bool{})...)
+	case "*":
+		op["requestBody"] = jsonContent(b.MessageFields(in, inPath), true)
+	default:
+		fd := in.Fields().ByName(protoreflect.Name(r.Body))
+		if fd != nil {
+			op["requestBody"] = jsonContent(b.Field(fd), true)
+			inPath[fd.Name()] = true
+		}
+		params = append(params, queryParams(b, in, "", inPath, map[protoreflect.FullName]bool{})...)
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	var resp schema.Object
+	switch {
+	case md.Output().FullName() == "google.api.HttpBody":
+		resp = schema.Object{
+			"description": "The body, in the content type the service picks",
+			"content":     schema.Object{"*/*": schema.Object{"schema": schema.Object{"type": "string", "format": "binary"}}},
+		}
+	case md.IsStreamingServer():
+		// The gateway writes one JSON object per line, holding a result or
+		// the error that ended the stream.
+		resp = jsonContent(schema.Object{
+			"type": "object",
+			"properties": schema.Object{
+				"result": b.Message(md.Output()),
+				"error":  schema.Object{"$ref": schemaRefPrefix + "google.rpc.Status"},
+			},
+		}, false)
+		resp["description"] = "A stream of newline-delimited results"
+	case r.ResponseBody != "":
+		resp = jsonContent(b.Field(md.Output().Fields().ByName(protoreflect.Name(r.ResponseBody))), false)
+		resp["description"] = "OK"
+	default:
+		resp = jsonContent(b.Message(md.Output()), false)
+		resp["description"] = "OK"
+	}
+	errResp := jsonContent(schema.Object{"$ref": schemaRefPrefix + "google.rpc.Status"}, false)
+	errResp["description"] = "An error"
+	op["responses"] = schema.Object{"200": resp, "default": errResp}
+	return op
+}
+
+// queryParams lists the fields of md a route without a body takes from the
+// query string: scalars and lists of them, and the fields of messages as
+// "parent.child", except the fields in omit.
+func queryParams(b *schema.Builder, md protoreflect.MessageDescriptor, prefix string, omit map[protoreflect.Name]bool, visiting map[protoreflect.FullName]bool) []schema.Object {
+	if visiting[md.FullName()] {
+		return nil
+	}
+	visiting[md.FullName()] = true
+	defer delete(visiting, md.FullName())
+
+	var params []schema.Object
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if omit[fd.Name()] || fd.IsMap() {
+			continue
+		}
+		name := prefix + fd.JSONName()
+		s := b.Field(fd)
+		if fd.Message() != nil {
+			if fd.IsList() {
+				continue
+			}
+			// Well-known types take a string, other messages their fields.
+			if _, isRef := s["$ref"]; isRef {
+				params = append(params, queryParams(b, fd.Message(), name+".", nil, visiting)...)
+				continue
+			}
+		}
+		params = append(params, schema.Object{"name": name, "in": "query", "schema": s})
+	}
+	return params
+}
+
+// fieldByPath finds the field a path variable such as "config_id" or
+// "game_dna.id" names, or returns nil.
+func fieldByPath(md protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil
+		}
+		if fd = md.Fields().ByName(protoreflect.Name(name)); fd == nil {
+			return nil
+		}
+		md = fd.Message()
+	}
+	return fd
+}
+
+// jsonContent returns a request or response body of schema s.
+func jsonContent(s schema.Object, required bool) schema.Object {
+	body := schema.Object{"content": schema.Object{"application/json": schema.Object{"schema": s}}}
+	if required {
+		body["required"] = true
+	}
+	return body
+}
+
+// serveDocument serves a JSON document encoded once, answering conditional
+// requests by its ETag.
+func serveDocument(doc schema.Object, contentType string) (http.Handler, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}), nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransferOwnership moves every config and webhook owned by a departing
+// principal to a successor so nothing is left without a responsible owner.
+// Each moved config's audit log records the transfer.
+func (s *GameDNAServiceServer) TransferOwnership(ctx context.Context, req *pb.TransferOwnershipRequest) (*pb.TransferOwnershipResponse, error) {
+	if req.FromActor == "" || req.ToActor == "" {
+		return nil, status.Error(codes.InvalidArgument, "from_actor and to_actor are required")
+	}
+	if req.FromActor == req.ToActor {
+		return nil, status.Error(codes.InvalidArgument, "from_actor and to_actor must differ")
+	}
+
+	moved, err := s.store.TransferOwnership(ctx, req.FromActor, req.ToActor, actorName(ctx, req.Actor), req.DryRun)
+	if err != nil {
+		s.logger.Error("Failed to transfer ownership", zap.Error(err))
+		return nil, fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+
+	resp := &pb.TransferOwnershipResponse{Configs: moved.Configs}
+	for _, webhook := range moved.Webhooks {
+		resp.Webhooks = append(resp.Webhooks, webhookProto(webhook))
+	}
+	if req.DryRun {
+		resp.Message = fmt.Sprintf("%d configs and %d webhooks would be transferred from %s to %s", len(moved.Configs), len(moved.Webhooks), req.FromActor, req.ToActor)
+		return resp, nil
+	}
+
+	for _, dna := range moved.Configs {
+		s.changed(ctx, events.TypeUpdated, dna.Id, dna)
+	}
+
+	resp.Transferred = int32(len(moved.Configs))
+	resp.Message = fmt.Sprintf("Transferred %d configs and %d webhooks from %s to %s", len(moved.Configs), len(moved.Webhooks), req.FromActor, req.ToActor)
+	return resp, nil
+}
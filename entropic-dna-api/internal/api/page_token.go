@@ -0,0 +1,66 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pageToken is what a ListGameDNA page_token carries. Clients treat it as
+// opaque; it is base64 JSON so it survives URLs and logs.
+type pageToken struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	// Filters fingerprints the filters the token was issued for, as the
+	// position means nothing in a different result set.
+	Filters string `json:"f"`
+}
+
+// filtersFingerprint identifies a set of list filters.
+func filtersFingerprint(filters storage.ListFilters) string {
+	key := fmt.Sprintf("%q|%q|%q", filters.Tags, filters.Genre, filters.NameFilter)
+	// Added later; left out when unset so earlier tokens stay valid
+	if filters.Environment != "" {
+		key += fmt.Sprintf("|%q", filters.Environment)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodePageToken returns the token continuing after cursor.
+func encodePageToken(cursor storage.ListCursor, filters storage.ListFilters) string {
+	data, _ := json.Marshal(pageToken{CreatedAt: cursor.CreatedAt, ID: cursor.ID, Filters: filtersFingerprint(filters)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// lastItemToken returns the token continuing after the last of items.
+func lastItemToken(items []*pb.GameDNA, filters storage.ListFilters) string {
+	last := items[len(items)-1]
+	return encodePageToken(storage.ListCursor{CreatedAt: models.TimeFromProto(last.CreateTime), ID: last.Id}, filters)
+}
+
+// decodePageToken returns the cursor in token, rejecting tokens that are
+// malformed or were issued for other filters.
+func decodePageToken(token string, filters storage.ListFilters) (*storage.ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	var t pageToken
+	if err == nil {
+		err = json.Unmarshal(data, &t)
+	}
+	if err != nil || t.ID == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	if t.Filters != filtersFingerprint(filters) {
+		return nil, status.Error(codes.InvalidArgument, "page_token was issued for different filters; pass the same tags, genre, name_filter and environment as on the first page")
+	}
+	return &storage.ListCursor{CreatedAt: t.CreatedAt, ID: t.ID}, nil
+}
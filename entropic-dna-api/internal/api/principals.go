@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportPrincipalData reports every record naming a principal, for data
+// subject access requests.
+func (s *GameDNAServiceServer) ExportPrincipalData(ctx context.Context, req *pb.ExportPrincipalDataRequest) (*pb.PrincipalDataReport, error) {
+	if req.Principal == "" {
+		return nil, status.Error(codes.InvalidArgument, "principal is required")
+	}
+
+	found, err := s.store.FindPrincipalData(ctx, req.Principal)
+	if err != nil {
+		s.logger.Error("Failed to find principal data", zap.Error(err))
+		return nil, fmt.Errorf("failed to find principal data: %w", err)
+	}
+	return principalDataReport(req.Principal, found), nil
+}
+
+// AnonymizePrincipal replaces a principal with a pseudonym in every record
+// naming them and deletes the drafts they own, for erasure requests. The
+// history is kept; only who is named in it changes.
+func (s *GameDNAServiceServer) AnonymizePrincipal(ctx context.Context, req *pb.AnonymizePrincipalRequest) (*pb.AnonymizePrincipalResponse, error) {
+	if req.Principal == "" {
+		return nil, status.Error(codes.InvalidArgument, "principal is required")
+	}
+	pseudonym := req.Pseudonym
+	if pseudonym == "" {
+		pseudonym = "anonymized-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	}
+	if pseudonym == req.Principal {
+		return nil, status.Error(codes.InvalidArgument, "pseudonym must differ from principal")
+	}
+
+	if req.DryRun {
+		found, err := s.store.FindPrincipalData(ctx, req.Principal)
+		if err != nil {
+			s.logger.Error("Failed to find principal data", zap.Error(err))
+			return nil, fmt.Errorf("failed to find principal data: %w", err)
+		}
+		return &pb.AnonymizePrincipalResponse{
+			Report:    principalDataReport(req.Principal, found),
+			Pseudonym: pseudonym,
+			Message:   fmt.Sprintf("%d records would be anonymized", found.Total()),
+		}, nil
+	}
+
+	found, err := s.store.AnonymizePrincipal(ctx, req.Principal, pseudonym)
+	if err != nil {
+		s.logger.Error("Failed to anonymize principal", zap.Error(err))
+		return nil, fmt.Errorf("failed to anonymize principal: %w", err)
+	}
+
+	// The principal's name is deliberately left out of the log from here on.
+	s.logger.Info("Principal anonymized", zap.String("pseudonym", pseudonym), zap.Int("records", found.Total()))
+	for _, dna := range found.Configs {
+		renamed := models.Clone(dna)
+		renamed.CreatedBy = pseudonym
+		s.changed(ctx, events.TypeUpdated, renamed.Id, renamed)
+	}
+
+	return &pb.AnonymizePrincipalResponse{
+		Report:    principalDataReport(req.Principal, found),
+		Pseudonym: pseudonym,
+		Message:   fmt.Sprintf("Anonymized %d records", found.Total()),
+	}, nil
+}
+
+// principalDataReport converts the records found for principal for the API.
+func principalDataReport(principal string, found *storage.PrincipalData) *pb.PrincipalDataReport {
+	now := models.Now()
+	report := &pb.PrincipalDataReport{
+		Principal: principal,
+		Configs:   found.Configs,
+		Total:     int32(found.Total()),
+	}
+	for _, pv := range found.Versions {
+		report.Versions = append(report.Versions, &pb.PrincipalVersion{ConfigId: pv.ConfigID, Version: versionInfoProto(pv.Version)})
+	}
+	for _, entry := range found.AuditEntries {
+		report.AuditEntries = append(report.AuditEntries, auditEntryProto(entry))
+	}
+	for _, draft := range found.Drafts {
+		report.Drafts = append(report.Drafts, draftProto(draft))
+	}
+	for _, w := range found.Waivers {
+		report.Waivers = append(report.Waivers, waiverProto(w, now))
+	}
+	for _, u := range found.Unlocks {
+		report.Unlocks = append(report.Unlocks, temporaryUnlockProto(u))
+	}
+	for _, snapshot := range found.Snapshots {
+		report.Snapshots = append(report.Snapshots, snapshotToProto(snapshot))
+	}
+	for _, mapping := range found.ImportMappings {
+		report.ImportMappings = append(report.ImportMappings, importMappingToProto(mapping))
+	}
+	for _, token := range found.APITokens {
+		report.ApiTokens = append(report.ApiTokens, apiTokenProto(token))
+	}
+	for _, template := range found.Templates {
+		report.Templates = append(report.Templates, templateProto(template))
+	}
+	for _, job := range found.ChecksumJobs {
+		report.ChecksumMigrations = append(report.ChecksumMigrations, checksumJobProto(job))
+	}
+	for _, canary := range found.Canaries {
+		report.Canaries = append(report.Canaries, canaryProto(canary))
+	}
+	for _, tag := range found.ReleaseTags {
+		report.ReleaseTags = append(report.ReleaseTags, releaseTagProto(tag))
+	}
+	for _, webhook := range found.Webhooks {
+		report.Webhooks = append(report.Webhooks, webhookProto(webhook))
+	}
+	return report
+}
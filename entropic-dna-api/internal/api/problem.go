@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemDetails is an RFC 7807 problem document extended with gRPC context.
+type problemDetails struct {
+	Type            string           `json:"type"`
+	Title           string           `json:"title"`
+	Status          int              `json:"status"`
+	Detail          string           `json:"detail,omitempty"`
+	Instance        string           `json:"instance,omitempty"`
+	Code            string           `json:"code"`
+	RequestID       string           `json:"request_id,omitempty"`
+	TraceID         string           `json:"trace_id,omitempty"`
+	FieldViolations []fieldViolation `json:"field_violations,omitempty"`
+}
+
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+	// Code is the validator's code for validation findings.
+	Code string `json:"code,omitempty"`
+}
+
+// writeProblem renders err as application/problem+json.
+func writeProblem(w http.ResponseWriter, r *http.Request, err error, docsURL string) {
+	st := status.Convert(err)
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+
+	problem := problemDetails{
+		Type:      problemType(docsURL, st.Code().String()),
+		Title:     http.StatusText(httpStatus),
+		Status:    httpStatus,
+		Detail:    st.Message(),
+		Instance:  r.URL.Path,
+		Code:      st.Code().String(),
+		RequestID: errorRequestID(r, st),
+		TraceID:   tracing.TraceID(r.Header.Get(tracing.TraceparentHeader)),
+	}
+	for _, f := range fieldErrors(st) {
+		problem.FieldViolations = append(problem.FieldViolations, fieldViolation{
+			Field:       f.Field,
+			Description: f.Description,
+			Code:        f.Code,
+		})
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// problemType links a gRPC code to its documentation, e.g. <docsURL>#invalid-argument.
+func problemType(docsURL, code string) string {
+	if docsURL == "" {
+		return "about:blank"
+	}
+	return docsURL + "#" + codeSlug(code)
+}
+
+// codeSlug turns a gRPC code name into an anchor, e.g. "InvalidArgument" into
+// "invalid-argument".
+func codeSlug(code string) string {
+	var slug strings.Builder
+	for i, c := range code {
+		if i > 0 && c >= 'A' && c <= 'Z' {
+			slug.WriteByte('-')
+		}
+		slug.WriteRune(c)
+	}
+	return strings.ToLower(slug.String())
+}
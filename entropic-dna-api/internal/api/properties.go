@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/ffi"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/properties"
+	"github.com/entropic-engine/entropic-dna-api/internal/sheets"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serverProperties are the custom properties the server reads itself. They
+// are never reported as undeclared.
+var serverProperties = []string{ffi.ProjectProperty, ffi.PEGIProperty}
+
+// ListPropertySchemas lists the custom properties a project declares.
+func (s *GameDNAServiceServer) ListPropertySchemas(ctx context.Context, req *pb.ListPropertySchemasRequest) (*pb.ListPropertySchemasResponse, error) {
+	schemas, err := s.store.ListPropertySchemas(ctx, req.Project)
+	if err != nil {
+		s.logger.Error("Failed to list property schemas", zap.Error(err))
+		return nil, fmt.Errorf("failed to list property schemas: %w", err)
+	}
+	resp := &pb.ListPropertySchemasResponse{}
+	for _, schema := range schemas {
+		resp.Schemas = append(resp.Schemas, propertySchemaProto(schema))
+	}
+	return resp, nil
+}
+
+// GetPropertySchema retrieves one of a project's property schemas.
+func (s *GameDNAServiceServer) GetPropertySchema(ctx context.Context, req *pb.GetPropertySchemaRequest) (*pb.PropertySchemaResponse, error) {
+	schema, err := s.store.GetPropertySchema(ctx, req.Project, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read property schema: %w", err)
+	}
+	return &pb.PropertySchemaResponse{Schema: propertySchemaProto(schema)}, nil
+}
+
+// SavePropertySchema declares a custom property of a project's configs, or
+// replaces its schema. Stored configs aren't revalidated; they are checked
+// against it the next time they are written or validated.
+func (s *GameDNAServiceServer) SavePropertySchema(ctx context.Context, req *pb.SavePropertySchemaRequest) (*pb.PropertySchemaResponse, error) {
+	if req.Name == ffi.ProjectProperty {
+		return nil, status.Errorf(codes.InvalidArgument, "%s names the project and cannot be declared", ffi.ProjectProperty)
+	}
+	if strings.ContainsFunc(req.Name, func(r rune) bool { return unicode.IsSpace(r) || r == '/' }) {
+		return nil, status.Errorf(codes.InvalidArgument, "property name %q must not contain spaces or slashes", req.Name)
+	}
+	if err := properties.CheckSchema(req.Type, req.AllowedValues); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.store.SavePropertySchema(ctx, &storage.PropertySchema{
+		Project:       req.Project,
+		Name:          req.Name,
+		Type:          req.Type,
+		AllowedValues: req.AllowedValues,
+		Required:      req.Required,
+		Description:   req.Description,
+		UpdatedBy:     actor,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save property schema", zap.Error(err))
+		return nil, fmt.Errorf("failed to save property schema: %w", err)
+	}
+
+	return &pb.PropertySchemaResponse{
+		Schema:  propertySchemaProto(saved),
+		Message: "Property schema saved successfully",
+	}, nil
+}
+
+// DeletePropertySchema deletes a property schema. Configs setting the
+// property keep it, reported as undeclared if the project declares others.
+func (s *GameDNAServiceServer) DeletePropertySchema(ctx context.Context, req *pb.DeletePropertySchemaRequest) (*pb.DeletePropertySchemaResponse, error) {
+	if err := s.store.DeletePropertySchema(ctx, req.Project, req.Name); err != nil {
+		s.logger.Error("Failed to delete property schema", zap.Error(err))
+		return nil, fmt.Errorf("failed to delete property schema: %w", err)
+	}
+
+	return &pb.DeletePropertySchemaResponse{
+		Success: true,
+		Message: "Property schema deleted successfully",
+	}, nil
+}
+
+// propertySchemas returns the property schemas of dna's project, none when
+// it names no project.
+func (s *GameDNAServiceServer) propertySchemas(ctx context.Context, dna *pb.GameDNA) ([]*storage.PropertySchema, error) {
+	project := dna.GetCustomProperties()[ffi.ProjectProperty]
+	if project == "" {
+		return nil, nil
+	}
+	schemas, err := s.store.ListPropertySchemas(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list property schemas of project %s: %w", project, err)
+	}
+	return schemas, nil
+}
+
+// coerceProperties puts the values dna sets for declared properties in the
+// canonical form of their type. Values that aren't of their type are left
+// for checkProperties to report.
+func coerceProperties(schemas []*storage.PropertySchema, dna *pb.GameDNA) {
+	for _, schema := range schemas {
+		value, ok := dna.CustomProperties[schema.Name]
+		if !ok || value == "" {
+			continue
+		}
+		if coerced, err := properties.Coerce(schema.Type, schema.AllowedValues, value); err == nil {
+			dna.CustomProperties[schema.Name] = coerced
+		}
+	}
+}
+
+// checkProperties adds an error to resp for each required property dna
+// leaves unset or empty and each value not of its property's type, and a
+// warning for each key the project doesn't declare, naming the declared one
+// it likely misspells.
+func checkProperties(schemas []*storage.PropertySchema, dna *pb.GameDNA, resp *pb.ValidationResponse) {
+	if len(schemas) == 0 {
+		return
+	}
+	project := dna.CustomProperties[ffi.ProjectProperty]
+	names := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		names = append(names, schema.Name)
+		field := sheets.CustomPropertyPrefix + schema.Name
+		details := fmt.Sprintf("Set %s to a %s value", schema.Name, schema.Type)
+		if schema.Type == properties.TypeEnum {
+			details = fmt.Sprintf("Set %s to one of %s", schema.Name, strings.Join(schema.AllowedValues, ", "))
+		}
+		value := dna.CustomProperties[schema.Name]
+		if value == "" {
+			if schema.Required {
+				resp.IsValid = false
+				resp.Errors = append(resp.Errors, &pb.ValidationError{
+					Code:    "PROPERTY_REQUIRED",
+					Field:   field,
+					Message: fmt.Sprintf("Project %s requires custom property %s", project, schema.Name),
+					Details: details,
+				})
+			}
+			continue
+		}
+		if _, err := properties.Coerce(schema.Type, schema.AllowedValues, value); err != nil {
+			resp.IsValid = false
+			resp.Errors = append(resp.Errors, &pb.ValidationError{
+				Code:    "PROPERTY_TYPE_MISMATCH",
+				Field:   field,
+				Message: fmt.Sprintf("Custom property %s: %v", schema.Name, err),
+				Details: details,
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(dna.CustomProperties))
+	for key := range dna.CustomProperties {
+		if !slices.Contains(names, key) && !slices.Contains(serverProperties, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		suggestion := fmt.Sprintf("Declare %s for project %s, or remove it", key, project)
+		if closest := properties.Closest(key, names); closest != "" {
+			suggestion = fmt.Sprintf("Did you mean %s?", closest)
+		}
+		resp.Warnings = append(resp.Warnings, &pb.ValidationWarning{
+			Code:       "PROPERTY_UNDECLARED",
+			Field:      sheets.CustomPropertyPrefix + key,
+			Message:    fmt.Sprintf("Project %s does not declare custom property %s", project, key),
+			Suggestion: suggestion,
+		})
+	}
+}
+
+func propertySchemaProto(schema *storage.PropertySchema) *pb.PropertySchema {
+	return &pb.PropertySchema{
+		Project:       schema.Project,
+		Name:          schema.Name,
+		Type:          schema.Type,
+		AllowedValues: schema.AllowedValues,
+		Required:      schema.Required,
+		Description:   schema.Description,
+		UpdatedBy:     schema.UpdatedBy,
+		UpdateTime:    models.TimestampProto(schema.UpdatedAt),
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/replicas"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// publishedToken returns the API token of a published-config read. With API
+// tokens enabled these reads require one, unless the caller authenticated
+// with an API key or JWT; otherwise the token is nil.
+func (s *GameDNAServiceServer) publishedToken(ctx context.Context) (*storage.APIToken, error) {
+	if s.opts.Tokens == nil {
+		return nil, nil
+	}
+	if _, ok := auth.FromContext(ctx); ok {
+		return nil, nil
+	}
+	token, ok := tokens.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "an API token is required")
+	}
+	return token, nil
+}
+
+// readPublished loads the published config with slug. Drafts are NotFound, so
+// callers can't tell them from configs that don't exist. Published configs
+// rarely change, so the read may be served by a read replica.
+func (s *GameDNAServiceServer) readPublished(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	dna, err := s.store.ReadBySlug(replicas.AllowStale(ctx), slug)
+	if err != nil {
+		return nil, err
+	}
+	if !dna.IsLocked {
+		return nil, fmt.Errorf("config %s is not published: %w", slug, storage.ErrNotFound)
+	}
+	return dna, nil
+}
+
+// GetPublishedConfig returns a published config by slug.
+func (s *GameDNAServiceServer) GetPublishedConfig(ctx context.Context, req *pb.GetPublishedConfigRequest) (*pb.PublishedGameDNAResponse, error) {
+	if req.Slug == "" {
+		return nil, status.Error(codes.InvalidArgument, "slug is required")
+	}
+	token, err := s.publishedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := s.defaultsFor(req.Defaults)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil && !tokens.Allows(token, req.Slug) {
+		return nil, status.Errorf(codes.PermissionDenied, "API token may not read %s", req.Slug)
+	}
+
+	dna, err := s.readPublished(ctx, req.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read published config: %w", err)
+	}
+	candidate, err := s.canaryContent(ctx, dna, req.BucketKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read canary: %w", err)
+	}
+	if candidate != nil {
+		dna = candidate
+	}
+	return &pb.PublishedGameDNAResponse{
+		GameDna:         materialize(defaults, dna),
+		Checksum:        dna.Checksum,
+		Message:         "Published config retrieved successfully",
+		DefaultsVersion: defaultsVersion(defaults),
+		Canary:          candidate != nil,
+	}, nil
+}
+
+// SyncPublishedConfigs returns the published configs whose checksum differs
+// from the caller's copy, so game clients can refresh in one call. With a
+// bucket key, configs the key is in the canary of are compared and returned
+// as served.
+func (s *GameDNAServiceServer) SyncPublishedConfigs(ctx context.Context, req *pb.SyncPublishedConfigsRequest) (*pb.SyncPublishedConfigsResponse, error) {
+	token, err := s.publishedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := s.defaultsFor(req.Defaults)
+	if err != nil {
+		return nil, err
+	}
+	slugs := req.Slugs
+	if len(slugs) == 0 && token != nil {
+		slugs = token.Slugs
+	}
+	if len(slugs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "slugs is required")
+	}
+
+	resp := &pb.SyncPublishedConfigsResponse{DefaultsVersion: defaultsVersion(defaults)}
+	for _, slug := range slugs {
+		// Slugs the token doesn't allow are reported like unpublished ones.
+		if token != nil && !tokens.Allows(token, slug) {
+			resp.Missing = append(resp.Missing, slug)
+			continue
+		}
+		dna, err := s.readPublished(ctx, slug)
+		if errors.Is(err, storage.ErrNotFound) {
+			resp.Missing = append(resp.Missing, slug)
+			continue
+		}
+		if err != nil {
+			s.logger.Error("Failed to read published config", zap.String("slug", slug), zap.Error(err))
+			return nil, fmt.Errorf("failed to read published config %s: %w", slug, err)
+		}
+		candidate, err := s.canaryContent(ctx, dna, req.BucketKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read canary of %s: %w", slug, err)
+		}
+		if candidate != nil {
+			dna = candidate
+		}
+		if dna.Checksum != "" && req.Checksums[slug] == dna.Checksum {
+			continue
+		}
+		resp.Changed = append(resp.Changed, materialize(defaults, dna))
+	}
+	return resp, nil
+}
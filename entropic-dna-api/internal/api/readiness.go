@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	readyPath = "/readyz"
+	// ReadinessTimeout bounds one run of the readiness checks.
+	ReadinessTimeout = 5 * time.Second
+)
+
+// ReadinessCheck checks one dependency the server needs to serve requests,
+// such as the database.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// readinessResult is what one check reported.
+type readinessResult struct {
+	name string
+	err  error
+}
+
+// runReadinessChecks runs every check within ReadinessTimeout, in order.
+func runReadinessChecks(ctx context.Context, checks []ReadinessCheck) []readinessResult {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+	defer cancel()
+	results := make([]readinessResult, len(checks))
+	for i, c := range checks {
+		results[i] = readinessResult{name: c.Name, err: c.Check(ctx)}
+	}
+	return results
+}
+
+// CheckReadiness runs checks and returns their failures, each prefixed with
+// the check's name, or nil when every check passed.
+func CheckReadiness(ctx context.Context, checks []ReadinessCheck) error {
+	var errs []error
+	for _, r := range runReadinessChecks(ctx, checks) {
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// grpcReadiness checks the gateway can reach the gRPC server and that the
+// server is not shutting down.
+func grpcReadiness(conn *grpc.ClientConn) ReadinessCheck {
+	client := healthpb.NewHealthClient(conn)
+	return ReadinessCheck{Name: "grpc", Check: func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		return nil
+	}}
+}
+
+// serveReadiness runs checks and answers 200 when all pass or 503 when any
+// fails, with one line per check.
+func serveReadiness(checks []ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body strings.Builder
+		code := http.StatusOK
+		for _, result := range runReadinessChecks(r.Context(), checks) {
+			if result.err != nil {
+				code = http.StatusServiceUnavailable
+				fmt.Fprintf(&body, "%s: %v\n", result.name, result.err)
+				continue
+			}
+			fmt.Fprintf(&body, "%s: ok\n", result.name)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(body.String()))
+	}
+}
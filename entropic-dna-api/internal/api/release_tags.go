@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// releaseTagPattern is what a release tag may look like: short, URL-safe and
+// free of anything that reads as a path or query.
+var releaseTagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// TagVersion names a version of a config with a release tag. A tag names one
+// version at a time; moving it to another needs move.
+func (s *GameDNAServiceServer) TagVersion(ctx context.Context, req *pb.TagVersionRequest) (*pb.TagVersionResponse, error) {
+	if !releaseTagPattern.MatchString(req.Tag) {
+		return nil, status.Error(codes.InvalidArgument, "tag must be 1 to 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	if req.VersionNum <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "version_num is required")
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	dna, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for tagging", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	tag, previous, err := s.store.TagVersion(ctx, &storage.ReleaseTag{
+		ConfigID:   dna.Id,
+		Tag:        req.Tag,
+		VersionNum: req.VersionNum,
+		CreatedBy:  actor,
+	}, req.Move)
+	if err != nil {
+		s.logger.Error("Failed to tag version", zap.Error(err))
+		return nil, fmt.Errorf("failed to tag version: %w", err)
+	}
+
+	msg := fmt.Sprintf("Tagged version %d as %s", tag.VersionNum, tag.Tag)
+	switch {
+	case previous == tag.VersionNum:
+		previous = 0
+		msg = fmt.Sprintf("Version %d is already tagged %s", tag.VersionNum, tag.Tag)
+	case previous != 0:
+		msg = fmt.Sprintf("Moved %s from version %d to version %d", tag.Tag, previous, tag.VersionNum)
+	}
+	return &pb.TagVersionResponse{
+		ReleaseTag:         releaseTagProto(tag),
+		PreviousVersionNum: previous,
+		Message:            msg,
+	}, nil
+}
+
+// readTaggedVersion returns the version of config ref that tag names.
+func (s *GameDNAServiceServer) readTaggedVersion(ctx context.Context, ref, tag string) (*storage.VersionInfo, error) {
+	dna, err := s.readConfig(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetTaggedVersion(ctx, dna.Id, tag)
+}
+
+// releaseTagsByVersion groups a config's release tags by the version they
+// name.
+func (s *GameDNAServiceServer) releaseTagsByVersion(ctx context.Context, configID string) (map[int64][]string, error) {
+	tags, err := s.store.ListReleaseTags(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64][]string)
+	for _, t := range tags {
+		byVersion[t.VersionNum] = append(byVersion[t.VersionNum], t.Tag)
+	}
+	return byVersion, nil
+}
+
+// releaseTagProto converts a stored release tag for the API.
+func releaseTagProto(t *storage.ReleaseTag) *pb.ReleaseTag {
+	return &pb.ReleaseTag{
+		ConfigId:   t.ConfigID,
+		Tag:        t.Tag,
+		VersionNum: t.VersionNum,
+		CreatedBy:  t.CreatedBy,
+		CreateTime: models.TimestampProto(t.CreatedAt),
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReloadConfig rereads the server configuration and applies the settings that
+// can change at runtime, as SIGHUP does. An invalid configuration changes
+// nothing.
+func (s *GameDNAServiceServer) ReloadConfig(ctx context.Context, req *pb.ReloadConfigRequest) (*pb.ReloadConfigResponse, error) {
+	if s.opts.Reload == nil {
+		return nil, status.Error(codes.FailedPrecondition, "config reload is not available")
+	}
+	changed, err := s.opts.Reload()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	resp := &pb.ReloadConfigResponse{Changed: changed, Message: "configuration unchanged"}
+	if len(changed) > 0 {
+		resp.Message = fmt.Sprintf("reloaded %s", strings.Join(changed, ", "))
+	}
+	return resp, nil
+}
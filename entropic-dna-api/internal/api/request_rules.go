@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messageRules are the field rules of a message type, and the message fields
+// whose types have rules of their own.
+type messageRules struct {
+	fields []fieldRule
+	nested []protoreflect.FieldDescriptor
+}
+
+type fieldRule struct {
+	field protoreflect.FieldDescriptor
+	rules *pb.FieldRules
+}
+
+// rulesByMessage caches messageRules by message full name.
+var rulesByMessage sync.Map
+
+// rulesFor returns the rules of md, or nil when neither it nor any message it
+// holds has any. visiting holds the messages being resolved, so recursive
+// types such as google.protobuf.Value end; only results resolved from the top
+// are cached, as the others may have been cut short by it.
+func rulesFor(md protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) *messageRules {
+	if cached, ok := rulesByMessage.Load(md.FullName()); ok {
+		return cached.(*messageRules)
+	}
+	if visiting[md.FullName()] {
+		return nil
+	}
+	top := len(visiting) == 0
+	visiting[md.FullName()] = true
+	defer delete(visiting, md.FullName())
+
+	var mr messageRules
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if rules, ok := proto.GetExtension(fd.Options(), pb.E_Rules).(*pb.FieldRules); ok && rules != nil {
+			mr.fields = append(mr.fields, fieldRule{field: fd, rules: rules})
+		}
+		if fd.Message() != nil && !fd.IsMap() && rulesFor(fd.Message(), visiting) != nil {
+			mr.nested = append(mr.nested, fd)
+		}
+	}
+	var result *messageRules
+	if len(mr.fields) > 0 || len(mr.nested) > 0 {
+		result = &mr
+	}
+	if top {
+		rulesByMessage.Store(md.FullName(), result)
+	}
+	return result
+}
+
+// checkRequestRules checks msg against the field rules in its proto
+// definition, returning an InvalidArgument status with one BadRequest field
+// violation per broken rule, or nil when it keeps them all.
+func checkRequestRules(msg proto.Message) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	checkMessageRules(msg.ProtoReflect(), "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	msgText := violations[0].Field + ": " + violations[0].Description
+	if len(violations) > 1 {
+		msgText = fmt.Sprintf("%s (and %d more)", msgText, len(violations)-1)
+	}
+	st := status.New(codes.InvalidArgument, "invalid request: "+msgText)
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		return withDetails.Err()
+	}
+	return st.Err()
+}
+
+func checkMessageRules(m protoreflect.Message, prefix string, violations *[]*errdetails.BadRequest_FieldViolation) {
+	mr := rulesFor(m.Descriptor(), map[protoreflect.FullName]bool{})
+	if mr == nil {
+		return
+	}
+	for _, fr := range mr.fields {
+		if desc := ruleViolation(m, fr.field, fr.rules); desc != "" {
+			*violations = append(*violations, &errdetails.BadRequest_FieldViolation{
+				Field:       prefix + string(fr.field.Name()),
+				Description: desc,
+			})
+		}
+	}
+	for _, fd := range mr.nested {
+		if !m.Has(fd) {
+			continue
+		}
+		name := prefix + string(fd.Name())
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				checkMessageRules(list.Get(i).Message(), name+"["+strconv.Itoa(i)+"].", violations)
+			}
+			continue
+		}
+		checkMessageRules(m.Get(fd).Message(), name+".", violations)
+	}
+}
+
+// ruleViolation describes how fd of m breaks rules, or returns "".
+func ruleViolation(m protoreflect.Message, fd protoreflect.FieldDescriptor, rules *pb.FieldRules) string {
+	if rules.Required && !m.Has(fd) {
+		return "is required"
+	}
+	if fd.IsList() || fd.IsMap() {
+		return ""
+	}
+
+	v := m.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n := v.Int()
+		if rules.Gte != nil && n < rules.GetGte() {
+			if rules.GetGte() == 0 {
+				return "must not be negative"
+			}
+			return fmt.Sprintf("must be at least %d, got %d", rules.GetGte(), n)
+		}
+		if rules.Lte != nil && n > rules.GetLte() {
+			return fmt.Sprintf("must be at most %d, got %d", rules.GetLte(), n)
+		}
+	case protoreflect.StringKind:
+		if rules.MaxLen > 0 {
+			if n := utf8.RuneCountInString(v.String()); uint64(n) > rules.MaxLen {
+				return fmt.Sprintf("must be at most %d characters, got %d", rules.MaxLen, n)
+			}
+		}
+	}
+	return ""
+}
+
+// UnaryValidationInterceptor rejects requests that break the field rules of
+// their proto definitions before the handler sees them.
+func UnaryValidationInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if msg, ok := req.(proto.Message); ok {
+		if err := checkRequestRules(msg); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+// StreamValidationInterceptor checks each message a streaming handler
+// receives against the field rules of its proto definition.
+func StreamValidationInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingStream{ServerStream: ss})
+}
+
+// validatingStream fails RecvMsg for messages that break their field rules.
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		return checkRequestRules(msg)
+	}
+	return nil
+}
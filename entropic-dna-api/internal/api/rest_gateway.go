@@ -2,39 +2,161 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net/http"
+	"strings"
 
 	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/compression"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/schema"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// protobufContentType selects binary protobuf request and response bodies.
+const protobufContentType = "application/x-protobuf"
+
+// GatewayOptions controls optional REST gateway behaviour.
+type GatewayOptions struct {
+	// ProblemJSON switches error bodies to RFC 7807 application/problem+json.
+	ProblemJSON bool
+	// ErrorDocsURL is the base for problem "type" links and the "about" links
+	// of error objects. Empty uses about:blank, and leaves out the links.
+	ErrorDocsURL string
+	// Events, when set, enables the server-sent events endpoint.
+	Events *events.Broker
+	// Middleware, when set, wraps every request, including health, metrics
+	// and events.
+	Middleware func(http.Handler) http.Handler
+	// EventsMiddleware, when set, wraps the events endpoint inside Middleware,
+	// to check credentials and limits there. Gateway routes are checked by the
+	// gRPC server.
+	EventsMiddleware func(http.Handler) http.Handler
+	// MetricsMiddleware, when set, wraps the metrics endpoint inside
+	// Middleware, as EventsMiddleware does the events endpoint.
+	MetricsMiddleware func(http.Handler) http.Handler
+	// Readiness are checked by /readyz, after the gateway's own check that it
+	// can reach the gRPC server.
+	Readiness []ReadinessCheck
+	// APIExplorer, when set, serves Swagger UI at /docs, loading it from
+	// APIExplorerAssets, the base URL of a swagger-ui-dist release.
+	APIExplorer       bool
+	APIExplorerAssets string
+	// GRPCWeb, when set, serves the gRPC service to gRPC-Web clients under
+	// /entropic.dna.v1.GameDNAService/, allowing cross-origin calls from
+	// GRPCWebOrigins ("*" for any).
+	GRPCWeb        bool
+	GRPCWebOrigins []string
+}
+
 // RESTGateway provides an HTTP server that proxies to the gRPC server.
 type RESTGateway struct {
 	server *http.Server
+	conn   *grpc.ClientConn
 	logger *zap.Logger
+	opts   GatewayOptions
 }
 
 // NewRESTGateway creates a new REST gateway.
-func NewRESTGateway(ctx context.Context, grpcAddr string, httpAddr string, logger *zap.Logger) (*RESTGateway, error) {
+func NewRESTGateway(ctx context.Context, grpcAddr string, httpAddr string, gwOpts GatewayOptions, logger *zap.Logger) (*RESTGateway, error) {
+	g := &RESTGateway{logger: logger, opts: gwOpts}
+	mp := newMsgpackMarshaler()
+
 	mux := runtime.NewServeMux(
-		runtime.WithErrorHandler(customHTTPError),
+		runtime.WithErrorHandler(g.customHTTPError),
+		runtime.WithForwardResponseOption(forwardHTTPStatus),
+		// Clients sending "Accept: application/x-protobuf" get binary responses,
+		// skipping the JSON encoding of large List and export payloads.
+		runtime.WithMarshalerOption(protobufContentType, &runtime.ProtoMarshaller{}),
+		// MessagePack keeps the JSON field names and values, about a quarter
+		// smaller, for game clients that have no protobuf descriptors.
+		runtime.WithMarshalerOption(msgpackContentType, mp),
+		runtime.WithMarshalerOption(xMsgpackContentType, mp),
+		runtime.WithIncomingHeaderMatcher(forwardHeaders),
 	)
 
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	// The hop to the gRPC server is recorded as a client span of the request's,
+	// and its responses come uncompressed, to be compressed for the client.
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor, compression.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(tracing.StreamClientInterceptor, compression.StreamClientInterceptor),
+	}
 	if err := pb.RegisterGameDNAServiceHandlerFromEndpoint(ctx, mux, grpcAddr, opts); err != nil {
 		return nil, fmt.Errorf("failed to register gateway: %w", err)
 	}
+	// Bulk export and import stream raw bodies, which the generated handlers
+	// can't, so they call the service through a connection of their own.
+	conn, err := grpc.DialContext(ctx, grpcAddr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial gRPC server: %w", err)
+	}
+	g.conn = conn
+	client := pb.NewGameDNAServiceClient(conn)
+
+	// The API's descriptions come from the compiled-in proto descriptors, so
+	// they always match the routes served.
+	openAPI, err := serveDocument(openAPIDocument(), "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	gameDNASchema, err := serveDocument(schema.GameDNAJSONSchema(), "application/schema+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode GameDNA schema: %w", err)
+	}
+
+	root := http.NewServeMux()
+	root.Handle("/", dropNoContentBody(mux))
+	root.Handle(bulkExportPath, serveBulkExport(mux, client, logger))
+	root.Handle(bulkImportPath, serveBulkImport(mux, client))
+	vars := expvar.Handler()
+	if gwOpts.MetricsMiddleware != nil {
+		vars = gwOpts.MetricsMiddleware(vars)
+	}
+	root.Handle(metrics.Path, vars)
+	root.HandleFunc(healthPath, serveHealth)
+	root.Handle(openAPIPath, openAPI)
+	root.Handle(gameDNASchemaPath, gameDNASchema)
+	if gwOpts.APIExplorer {
+		explorer, err := serveAPIExplorer(gwOpts.APIExplorerAssets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render API explorer: %w", err)
+		}
+		root.Handle(apiExplorerPath, explorer)
+	}
+	if gwOpts.GRPCWeb {
+		root.Handle(grpcWebPath, serveGRPCWeb(conn, gwOpts.GRPCWebOrigins))
+	}
+	root.Handle(readyPath, serveReadiness(append([]ReadinessCheck{grpcReadiness(conn)}, gwOpts.Readiness...)))
+	if gwOpts.Events != nil {
+		var stream http.Handler = serveEvents(gwOpts.Events, logger)
+		if gwOpts.EventsMiddleware != nil {
+			stream = gwOpts.EventsMiddleware(stream)
+		}
+		root.Handle(eventsPath, stream)
+	}
 
-	srv := &http.Server{
+	var handler http.Handler = root
+	if gwOpts.Middleware != nil {
+		handler = gwOpts.Middleware(root)
+	}
+	g.server = &http.Server{
 		Addr:    httpAddr,
-		Handler: requestLoggingMiddleware(logger, mux),
+		Handler: handler,
 	}
 
-	return &RESTGateway{server: srv, logger: logger}, nil
+	return g, nil
 }
 
 // Start starts the HTTP server.
@@ -43,24 +165,58 @@ func (g *RESTGateway) Start() error {
 	return g.server.ListenAndServe()
 }
 
+// Handler returns the gateway's HTTP handler, for serving it without Start,
+// as tests do.
+func (g *RESTGateway) Handler() http.Handler {
+	return g.server.Handler
+}
+
 // Shutdown gracefully shuts down the HTTP server.
 func (g *RESTGateway) Shutdown(ctx context.Context) error {
 	g.logger.Info("Shutting down REST gateway")
+	defer g.conn.Close()
 	return g.server.Shutdown(ctx)
 }
 
-func requestLoggingMiddleware(logger *zap.Logger, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logger.Info("HTTP request",
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-			zap.String("remote", r.RemoteAddr),
-		)
-		next.ServeHTTP(w, r)
-	})
+// forwardHeaders passes the request ID, trace context, tenant, API key and
+// idempotency key on to the gRPC server, so both sides log the same IDs,
+// storage is routed for the tenant, the caller is authenticated and retries
+// are recognised.
+func forwardHeaders(key string) (string, bool) {
+	switch {
+	case strings.EqualFold(key, RequestIDHeader):
+		return RequestIDMetadata, true
+	case strings.EqualFold(key, tracing.TraceparentHeader):
+		return tracing.TraceparentHeader, true
+	case strings.EqualFold(key, residency.TenantHeader):
+		return residency.TenantMetadata, true
+	case strings.EqualFold(key, auth.APIKeyHeader):
+		return auth.APIKeyMetadata, true
+	case strings.EqualFold(key, IdempotencyKeyHeader):
+		return IdempotencyKeyMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
 }
 
-func customHTTPError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
-	// Default grpc-gateway error handler already maps gRPC codes.
+func (g *RESTGateway) customHTTPError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	setRetryAfter(w, err)
+	if g.opts.ProblemJSON {
+		writeProblem(w, r, err, g.opts.ErrorDocsURL)
+		return
+	}
+	// The default handler maps the code to a status and sets the headers; the
+	// marshaler adds the error objects to its body.
+	marshaler = &errorBodyMarshaler{Marshaler: marshaler, st: status.Convert(err), r: r, docsURL: g.opts.ErrorDocsURL}
 	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
 }
+
+// setRetryAfter sets Retry-After from the RetryInfo of a status, as sent with
+// rate-limited calls.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			w.Header().Set("Retry-After", ratelimit.RetryAfter(info.RetryDelay.AsDuration()))
+			return
+		}
+	}
+}
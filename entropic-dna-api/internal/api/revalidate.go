@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+)
+
+// revalidatePageSize bounds how many published configs are loaded per List call.
+const revalidatePageSize = 100
+
+// RevalidateAgainstLatest re-runs the current rules over every published config
+// and reports the ones that no longer pass, so rule upgrades come with a
+// migration list.
+func (s *GameDNAServiceServer) RevalidateAgainstLatest(ctx context.Context, req *pb.RevalidateAgainstLatestRequest) (*pb.RevalidateAgainstLatestResponse, error) {
+	resp := &pb.RevalidateAgainstLatestResponse{
+		CurrentRulesVersion: s.rust.RulesVersion(),
+	}
+
+	filters := storage.ListFilters{PublishedOnly: true}
+	for page := int32(1); ; page++ {
+		items, total, err := s.store.List(ctx, filters, storage.Pagination{Page: page, PageSize: revalidatePageSize})
+		if err != nil {
+			s.logger.Error("Failed to list published configs", zap.Error(err))
+			return nil, fmt.Errorf("failed to list published configs: %w", err)
+		}
+
+		for _, dna := range items {
+			validationResp, err := s.validate(ctx, dna)
+			if err != nil {
+				s.logger.Error("Validation error", zap.String("id", dna.Id), zap.Error(err))
+				return nil, fmt.Errorf("validation error for %s: %w", dna.Id, err)
+			}
+			resp.CurrentRulesVersion = validationResp.RulesVersion
+			resp.Checked++
+			if !validationResp.IsValid {
+				resp.Failing++
+			} else if !req.IncludePassing {
+				continue
+			}
+
+			resp.Results = append(resp.Results, &pb.RevalidationResult{
+				ConfigId:              dna.Id,
+				Name:                  dna.Name,
+				PublishedRulesVersion: dna.PublishedRulesVersion,
+				Validation:            validationResp,
+			})
+		}
+
+		if len(items) == 0 || page*revalidatePageSize >= total {
+			break
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/ffi"
+	"github.com/entropic-engine/entropic-dna-api/internal/schema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values of the defaults request field.
+const (
+	DefaultsRaw          = "raw"
+	DefaultsMaterialized = "materialized"
+)
+
+// defaultsFor returns the defaults table a read asking for mode fills in, or
+// nil when it returns raw values. An empty mode uses the server default.
+func (s *GameDNAServiceServer) defaultsFor(mode string) (*schema.DefaultsTable, error) {
+	switch mode {
+	case "":
+		if !s.opts.MaterializeDefaults {
+			return nil, nil
+		}
+	case DefaultsRaw:
+		return nil, nil
+	case DefaultsMaterialized:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "defaults must be %q or %q", DefaultsRaw, DefaultsMaterialized)
+	}
+	t := schema.LatestDefaults()
+	return &t, nil
+}
+
+// materialize fills t's defaults into dna, when t is set.
+func materialize(t *schema.DefaultsTable, dna *pb.GameDNA) *pb.GameDNA {
+	if t == nil {
+		return dna
+	}
+	return t.Materialize(dna)
+}
+
+// defaultsVersion returns the version of t, or 0 for raw values.
+func defaultsVersion(t *schema.DefaultsTable) int32 {
+	if t == nil {
+		return 0
+	}
+	return t.Version
+}
+
+// GetSchema describes GameDNA's fields and a version of the engine defaults.
+func (s *GameDNAServiceServer) GetSchema(ctx context.Context, req *pb.GetSchemaRequest) (*pb.GetSchemaResponse, error) {
+	latest := schema.LatestDefaults()
+	t := latest
+	if req.DefaultsVersion != 0 {
+		var ok bool
+		if t, ok = schema.Defaults(req.DefaultsVersion); !ok {
+			return nil, status.Errorf(codes.NotFound, "defaults version %d not found; the latest is %d", req.DefaultsVersion, latest.Version)
+		}
+	}
+
+	resp := &pb.GetSchemaResponse{
+		DefaultsVersion:       t.Version,
+		LatestDefaultsVersion: latest.Version,
+	}
+	for _, f := range t.Fields() {
+		resp.Fields = append(resp.Fields, &pb.SchemaField{
+			Name:         f.Name,
+			JsonName:     f.JSONName,
+			Type:         f.Type,
+			HasDefault:   f.HasDefault,
+			DefaultValue: f.Default,
+		})
+	}
+	return resp, nil
+}
+
+// ListFieldEnums lists the known values of GameDNA's enum-like string fields,
+// as the rules engine defines them.
+func (s *GameDNAServiceServer) ListFieldEnums(ctx context.Context, req *pb.ListFieldEnumsRequest) (*pb.ListFieldEnumsResponse, error) {
+	enums := s.rust.FieldEnums()
+	resp := &pb.ListFieldEnumsResponse{RulesVersion: s.rust.RulesVersion()}
+	for _, name := range req.Fields {
+		if !slices.ContainsFunc(enums, func(e ffi.FieldEnum) bool { return e.Field == name }) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not an enum-like field", name)
+		}
+	}
+	for _, e := range enums {
+		if len(req.Fields) > 0 && !slices.Contains(req.Fields, e.Field) {
+			continue
+		}
+		out := &pb.FieldEnum{Field: e.Field, AllowsCustom: e.AllowsCustom}
+		for _, v := range e.Values {
+			out.Values = append(out.Values, &pb.EnumValue{Value: v.Value, Description: v.Description})
+		}
+		resp.Enums = append(resp.Enums, out)
+	}
+	return resp, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// comparisonPattern matches "target_fps >= 60"; spaces are optional.
+	comparisonPattern = regexp.MustCompile(`^\s*([a-z_]+)\s*(>=|<=|!=|==|=|<|>)\s*(\S+)\s*$`)
+	// betweenPattern matches "max_players between 8 and 32".
+	betweenPattern = regexp.MustCompile(`(?i)^\s*([a-z_]+)\s+between\s+(\S+)\s+and\s+(\S+)\s*$`)
+)
+
+// SearchGameDNA finds configs by the words of their name, tags and custom
+// properties and by comparisons of their numeric fields.
+func (s *GameDNAServiceServer) SearchGameDNA(ctx context.Context, req *pb.SearchGameDNARequest) (*pb.ListGameDNAResponse, error) {
+	if err := s.opts.List.checkPage(req.Page, req.PageSize); err != nil {
+		s.logger.Warn("Rejected search request", zap.Error(err))
+		return nil, err
+	}
+	defaults, err := s.defaultsFor(req.Defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	query := storage.SearchQuery{Text: req.Q}
+	for _, where := range req.Where {
+		pred, err := parsePredicate(where)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "where %q: %v", where, err)
+		}
+		query.Predicates = append(query.Predicates, pred)
+	}
+	if err := query.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	pageSize := s.opts.List.pageSize(req.PageSize)
+	items, total, err := s.store.Search(ctx, query, storage.Pagination{Page: req.Page, PageSize: pageSize})
+	if err != nil {
+		s.logger.Error("Failed to search game DNAs", zap.Error(err))
+		return nil, fmt.Errorf("failed to search game DNAs: %w", err)
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	for i, item := range items {
+		items[i] = materialize(defaults, item)
+	}
+
+	return &pb.ListGameDNAResponse{
+		Items: items,
+		Pagination: &pb.PaginationInfo{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: (total + pageSize - 1) / pageSize,
+		},
+		DefaultsVersion: defaultsVersion(defaults),
+	}, nil
+}
+
+// parsePredicate reads a comparison such as "target_fps >= 60" or
+// "max_players between 8 and 32".
+func parsePredicate(text string) (storage.NumericPredicate, error) {
+	if m := betweenPattern.FindStringSubmatch(text); m != nil {
+		lower, err := parseNumber(m[2])
+		if err != nil {
+			return storage.NumericPredicate{}, err
+		}
+		upper, err := parseNumber(m[3])
+		if err != nil {
+			return storage.NumericPredicate{}, err
+		}
+		return storage.NumericPredicate{Field: m[1], Op: storage.OpBetween, Value: lower, Upper: upper}, nil
+	}
+	if m := comparisonPattern.FindStringSubmatch(text); m != nil {
+		value, err := parseNumber(m[3])
+		if err != nil {
+			return storage.NumericPredicate{}, err
+		}
+		op := m[2]
+		if op == "==" {
+			op = storage.OpEq
+		}
+		return storage.NumericPredicate{Field: m[1], Op: op, Value: value}, nil
+	}
+	return storage.NumericPredicate{}, fmt.Errorf("want a comparison such as %q or %q", "target_fps >= 60", "max_players between 8 and 32")
+}
+
+func parseNumber(text string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s is not a number", text)
+	}
+	return v, nil
+}
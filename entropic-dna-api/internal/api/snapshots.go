@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreateSnapshot captures a named set of config versions.
+func (s *GameDNAServiceServer) CreateSnapshot(ctx context.Context, req *pb.CreateSnapshotRequest) (*pb.SnapshotResponse, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("snapshot name is required")
+	}
+	if len(req.Entries) == 0 {
+		return nil, fmt.Errorf("snapshot must contain at least one config")
+	}
+
+	snapshot := &storage.Snapshot{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   actorName(ctx, req.Actor),
+	}
+	seen := make(map[string]bool)
+	for _, entry := range req.Entries {
+		if entry.ConfigId == "" {
+			return nil, fmt.Errorf("snapshot entry is missing a config_id")
+		}
+		if seen[entry.ConfigId] {
+			return nil, fmt.Errorf("config %s appears more than once in snapshot", entry.ConfigId)
+		}
+		seen[entry.ConfigId] = true
+		snapshot.Entries = append(snapshot.Entries, storage.SnapshotEntry{
+			ConfigID:   entry.ConfigId,
+			VersionNum: entry.VersionNum,
+		})
+	}
+
+	created, err := s.store.CreateSnapshot(ctx, snapshot)
+	if err != nil {
+		s.logger.Error("Failed to create snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	return &pb.SnapshotResponse{
+		Snapshot: snapshotToProto(created),
+		Message:  "Snapshot created successfully",
+	}, nil
+}
+
+// GetSnapshot retrieves a snapshot by name.
+func (s *GameDNAServiceServer) GetSnapshot(ctx context.Context, req *pb.GetSnapshotRequest) (*pb.SnapshotResponse, error) {
+	snapshot, err := s.store.GetSnapshot(ctx, req.Name, false)
+	if err != nil {
+		s.logger.Error("Failed to get snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	return &pb.SnapshotResponse{
+		Snapshot: snapshotToProto(snapshot),
+		Message:  "Snapshot retrieved successfully",
+	}, nil
+}
+
+// ListSnapshots lists all snapshots.
+func (s *GameDNAServiceServer) ListSnapshots(ctx context.Context, req *pb.ListSnapshotsRequest) (*pb.ListSnapshotsResponse, error) {
+	snapshots, err := s.store.ListSnapshots(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list snapshots", zap.Error(err))
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	resp := &pb.ListSnapshotsResponse{}
+	for _, snapshot := range snapshots {
+		resp.Snapshots = append(resp.Snapshots, snapshotToProto(snapshot))
+	}
+
+	return resp, nil
+}
+
+// RestoreSnapshot rolls every config in a snapshot back to its captured version.
+func (s *GameDNAServiceServer) RestoreSnapshot(ctx context.Context, req *pb.RestoreSnapshotRequest) (*pb.RestoreSnapshotResponse, error) {
+	restored, err := s.store.RestoreSnapshot(ctx, req.Name, actorName(ctx, req.Actor))
+	if err != nil {
+		s.logger.Error("Failed to restore snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	for _, dna := range restored {
+		s.changed(ctx, events.TypeRolledBack, dna.Id, dna)
+	}
+
+	return &pb.RestoreSnapshotResponse{
+		Restored: restored,
+		Message:  fmt.Sprintf("Restored %d configs from snapshot %s", len(restored), req.Name),
+	}, nil
+}
+
+// ExportSnapshot returns a snapshot with the full config data of every entry,
+// plus the saved import mappings and property schemas of any requested
+// projects. Projects without a saved mapping export only their schemas.
+func (s *GameDNAServiceServer) ExportSnapshot(ctx context.Context, req *pb.ExportSnapshotRequest) (*pb.SnapshotResponse, error) {
+	snapshot, err := s.store.GetSnapshot(ctx, req.Name, true)
+	if err != nil {
+		s.logger.Error("Failed to export snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to export snapshot: %w", err)
+	}
+
+	resp := &pb.SnapshotResponse{
+		Snapshot: snapshotToProto(snapshot),
+		Message:  "Snapshot exported successfully",
+	}
+	seen := make(map[string]bool)
+	for _, project := range req.Projects {
+		if project == "" || seen[project] {
+			continue
+		}
+		seen[project] = true
+		mapping, err := s.store.GetImportMapping(ctx, project)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+		case err != nil:
+			s.logger.Error("Failed to read import mapping for export", zap.String("project", project), zap.Error(err))
+			return nil, fmt.Errorf("failed to export import mapping for project %s: %w", project, err)
+		default:
+			resp.ImportMappings = append(resp.ImportMappings, importMappingToProto(mapping))
+		}
+
+		schemas, err := s.store.ListPropertySchemas(ctx, project)
+		if err != nil {
+			s.logger.Error("Failed to list property schemas for export", zap.String("project", project), zap.Error(err))
+			return nil, fmt.Errorf("failed to export property schemas for project %s: %w", project, err)
+		}
+		for _, schema := range schemas {
+			resp.PropertySchemas = append(resp.PropertySchemas, propertySchemaProto(schema))
+		}
+	}
+
+	return resp, nil
+}
+
+// ImportSnapshot recreates an exported snapshot on this deployment. The
+// import mappings and property schemas are saved first, then every entry's
+// config is imported like ImportGameDNA would, and the snapshot is created
+// pinning the resulting versions. When any config fails to import, is
+// skipped or is locked, the snapshot is not created and the results say
+// which.
+func (s *GameDNAServiceServer) ImportSnapshot(ctx context.Context, req *pb.ImportSnapshotRequest) (*pb.ImportSnapshotResponse, error) {
+	snapshot := req.Export.GetSnapshot()
+	if snapshot.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "export carries no snapshot name")
+	}
+	if len(snapshot.Entries) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "snapshot must contain at least one config")
+	}
+	for _, entry := range snapshot.Entries {
+		if entry.Data == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "snapshot entry %s carries no config data; export it with ExportSnapshot", entry.ConfigId)
+		}
+	}
+	if _, err := s.store.GetSnapshot(ctx, snapshot.Name, false); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "snapshot %s already exists", snapshot.Name)
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Error("Failed to check snapshot for import", zap.Error(err))
+		return nil, fmt.Errorf("failed to import snapshot: %w", err)
+	}
+	actor := actorName(ctx, req.Actor)
+
+	for _, mapping := range req.Export.ImportMappings {
+		if _, err := s.SaveImportMapping(ctx, &pb.SaveImportMappingRequest{
+			Project: mapping.Project,
+			Columns: mapping.Columns,
+			Actor:   actor,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, schema := range req.Export.PropertySchemas {
+		if schema.Project == "" || schema.Name == "" {
+			return nil, status.Error(codes.InvalidArgument, "property schemas need a project and a name")
+		}
+		if _, err := s.SavePropertySchema(ctx, &pb.SavePropertySchemaRequest{
+			Project:       schema.Project,
+			Name:          schema.Name,
+			Type:          schema.Type,
+			AllowedValues: schema.AllowedValues,
+			Required:      schema.Required,
+			Description:   schema.Description,
+			Actor:         actor,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &pb.ImportSnapshotResponse{}
+	created := &storage.Snapshot{
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		CreatedBy:   actor,
+	}
+	for _, entry := range snapshot.Entries {
+		dna := proto.Clone(entry.Data).(*pb.GameDNA)
+		if dna.Id == "" {
+			dna.Id = entry.ConfigId
+		}
+		result := s.importOne(ctx, &pb.GameDNAExport{Config: dna}, req.Overwrite, false)
+		resp.Results = append(resp.Results, result)
+		switch result.Status {
+		case importStatusCreated, bulkStatusUpdated, bulkStatusUnchanged:
+			created.Entries = append(created.Entries, storage.SnapshotEntry{ConfigID: result.ConfigId})
+		}
+	}
+	if len(created.Entries) < len(snapshot.Entries) {
+		resp.Message = fmt.Sprintf("Imported %d of %d configs; snapshot %s not created", len(created.Entries), len(snapshot.Entries), snapshot.Name)
+		return resp, nil
+	}
+
+	stored, err := s.store.CreateSnapshot(ctx, created)
+	if err != nil {
+		s.logger.Error("Failed to create imported snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	resp.Snapshot = snapshotToProto(stored)
+	resp.Message = fmt.Sprintf("Imported snapshot %s with %d configs", snapshot.Name, len(created.Entries))
+	return resp, nil
+}
+
+func snapshotToProto(snapshot *storage.Snapshot) *pb.Snapshot {
+	out := &pb.Snapshot{
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		CreatedAt:   snapshot.CreatedAt,
+		CreatedBy:   snapshot.CreatedBy,
+	}
+	for _, entry := range snapshot.Entries {
+		out.Entries = append(out.Entries, &pb.SnapshotEntry{
+			ConfigId:   entry.ConfigID,
+			VersionNum: entry.VersionNum,
+			Data:       entry.Data,
+		})
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values of the strictness request field and server setting: which
+// validation findings reject a create or update.
+const (
+	// StrictnessLenient rejects configs with validation errors only.
+	StrictnessLenient = "lenient"
+	// StrictnessStrict rejects configs with validation warnings too.
+	StrictnessStrict = "strict"
+)
+
+// ValidateStrictness checks that strictness names a known level. Empty is
+// allowed and means the server default.
+func ValidateStrictness(strictness string) error {
+	switch strictness {
+	case "", StrictnessLenient, StrictnessStrict:
+		return nil
+	}
+	return fmt.Errorf("strictness must be %q or %q: %q", StrictnessLenient, StrictnessStrict, strictness)
+}
+
+// strictnessSettings are the server's strictness options.
+type strictnessSettings struct {
+	write        string
+	environments []string
+}
+
+// SetWriteStrictness replaces the WriteStrictness and StrictEnvironments
+// options for writes from now on, as when the configuration is reloaded.
+func (s *GameDNAServiceServer) SetWriteStrictness(strictness string, environments []string) error {
+	if err := ValidateStrictness(strictness); err != nil {
+		return err
+	}
+	s.strictness.Store(&strictnessSettings{write: strictness, environments: slices.Clone(environments)})
+	return nil
+}
+
+func (s *GameDNAServiceServer) strictnessSettings() *strictnessSettings {
+	if settings := s.strictness.Load(); settings != nil {
+		return settings
+	}
+	return &strictnessSettings{write: s.opts.WriteStrictness, environments: s.opts.StrictEnvironments}
+}
+
+// writeStrictness returns the strictness a write of dna runs at: requested,
+// or else strict for configs serving one of the strict environments, or
+// else the server default.
+func (s *GameDNAServiceServer) writeStrictness(requested string, dna *pb.GameDNA) (string, error) {
+	if err := ValidateStrictness(requested); err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	settings := s.strictnessSettings()
+	switch {
+	case requested != "":
+		return requested, nil
+	case slices.Contains(settings.environments, storage.EnvironmentOf(dna)):
+		return StrictnessStrict, nil
+	case settings.write != "":
+		return settings.write, nil
+	}
+	return StrictnessLenient, nil
+}
+
+// checkStrictness rejects a write whose validation report has warnings when
+// strictness is strict, with one field violation per warning.
+func checkStrictness(strictness string, resp *pb.ValidationResponse) error {
+	if strictness != StrictnessStrict || len(resp.Warnings) == 0 {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("validation failed: %d warnings rejected by strict validation", len(resp.Warnings)))
+	br := &errdetails.BadRequest{}
+	for _, w := range resp.Warnings {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       w.Field,
+			Description: w.Message,
+		})
+	}
+	if withDetails, err := st.WithDetails(br, resp); err == nil {
+		return withDetails.Err()
+	}
+	return st.Err()
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/entropic-engine/entropic-dna-api/internal/templates"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListTemplates lists the built-in presets followed by the user-defined
+// templates.
+func (s *GameDNAServiceServer) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
+	stored, err := s.store.ListTemplates(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list templates", zap.Error(err))
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	matches := func(dna *pb.GameDNA) bool {
+		return req.Genre == "" || strings.EqualFold(dna.GetGenre(), req.Genre)
+	}
+	resp := &pb.ListTemplatesResponse{}
+	for _, b := range templates.List() {
+		if matches(b.Data) {
+			resp.Templates = append(resp.Templates, builtinTemplateProto(b))
+		}
+	}
+	for _, t := range stored {
+		if matches(t.Data) {
+			resp.Templates = append(resp.Templates, templateProto(t))
+		}
+	}
+	return resp, nil
+}
+
+// GetTemplate retrieves a built-in or user-defined template by name.
+func (s *GameDNAServiceServer) GetTemplate(ctx context.Context, req *pb.GetTemplateRequest) (*pb.TemplateResponse, error) {
+	template, err := s.readTemplate(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TemplateResponse{Template: template}, nil
+}
+
+// SaveTemplate creates or replaces a user-defined template. Only the config
+// content is kept; server-managed fields, the name and the slug are cleared.
+func (s *GameDNAServiceServer) SaveTemplate(ctx context.Context, req *pb.SaveTemplateRequest) (*pb.TemplateResponse, error) {
+	if err := checkTemplateName(req.Name); err != nil {
+		return nil, err
+	}
+	if req.GameDna == nil {
+		return nil, status.Error(codes.InvalidArgument, "game_dna is required")
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	data := models.Clone(req.GameDna)
+	models.ClearServerFields(data)
+	data.Name = ""
+	data.Slug = ""
+
+	saved, err := s.store.SaveTemplate(ctx, &storage.Template{
+		Name:        req.Name,
+		Description: req.Description,
+		Data:        data,
+		CreatedBy:   actor,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save template", zap.Error(err))
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+
+	return &pb.TemplateResponse{
+		Template: templateProto(saved),
+		Message:  "Template saved successfully",
+	}, nil
+}
+
+// DeleteTemplate deletes a user-defined template. Configs created from it
+// are unaffected.
+func (s *GameDNAServiceServer) DeleteTemplate(ctx context.Context, req *pb.DeleteTemplateRequest) (*pb.DeleteTemplateResponse, error) {
+	if templates.IsBuiltin(req.Name) {
+		return nil, status.Errorf(codes.FailedPrecondition, "template %s is built in and cannot be deleted", req.Name)
+	}
+	if err := s.store.DeleteTemplate(ctx, req.Name); err != nil {
+		s.logger.Error("Failed to delete template", zap.Error(err))
+		return nil, fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return &pb.DeleteTemplateResponse{
+		Success: true,
+		Message: "Template deleted successfully",
+	}, nil
+}
+
+// CreateFromTemplate creates a config from a template's content, with the
+// masked fields of req.Overrides in place of the template's. The result is
+// validated and stored like any other new config.
+func (s *GameDNAServiceServer) CreateFromTemplate(ctx context.Context, req *pb.CreateFromTemplateRequest) (*pb.GameDNAResponse, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.readTemplate(ctx, req.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	dna := template.GameDna
+	if paths := req.GetUpdateMask().GetPaths(); len(paths) > 0 {
+		fields, err := updateMaskFields(paths)
+		if err != nil {
+			return nil, err
+		}
+		dna = mergeFields(dna, req.GetOverrides(), fields)
+	} else if req.Overrides != nil {
+		return nil, status.Error(codes.InvalidArgument, "update_mask must name the fields to take from overrides")
+	}
+	dna.Name = req.Name
+	dna.Slug = req.Slug
+	dna.CreatedBy = actor
+
+	resp, err := s.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+	if err != nil {
+		return nil, err
+	}
+	resp.Message = fmt.Sprintf("Game DNA created from template %s", template.Name)
+	return resp, nil
+}
+
+// readTemplate returns the built-in or stored template with the name.
+func (s *GameDNAServiceServer) readTemplate(ctx context.Context, name string) (*pb.Template, error) {
+	if b, ok := templates.Lookup(name); ok {
+		return builtinTemplateProto(b), nil
+	}
+	template, err := s.store.GetTemplate(ctx, name)
+	if err != nil {
+		s.logger.Error("Failed to read template", zap.Error(err))
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return templateProto(template), nil
+}
+
+// checkTemplateName rejects names that aren't slug-shaped or that a built-in
+// preset reserves.
+func checkTemplateName(name string) error {
+	if err := storage.ValidateSlug(name); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid template name: %v", err)
+	}
+	if templates.IsBuiltin(name) {
+		return status.Errorf(codes.InvalidArgument, "template name %s is reserved by a built-in template", name)
+	}
+	return nil
+}
+
+func builtinTemplateProto(b templates.Builtin) *pb.Template {
+	return &pb.Template{
+		Name:        b.Name,
+		Description: b.Description,
+		GameDna:     b.Data,
+		Builtin:     true,
+	}
+}
+
+func templateProto(t *storage.Template) *pb.Template {
+	return &pb.Template{
+		Name:        t.Name,
+		Description: t.Description,
+		GameDna:     t.Data,
+		CreatedBy:   t.CreatedBy,
+		CreateTime:  models.TimestampProto(t.CreatedAt),
+		UpdateTime:  models.TimestampProto(t.UpdatedAt),
+	}
+}
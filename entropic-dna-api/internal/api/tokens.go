@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAPIToken issues a read-only token for a set of published configs.
+// The secret is only returned here.
+func (s *GameDNAServiceServer) CreateAPIToken(ctx context.Context, req *pb.CreateAPITokenRequest) (*pb.APITokenResponse, error) {
+	if s.opts.Tokens == nil {
+		return nil, status.Error(codes.FailedPrecondition, "API tokens are disabled")
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if len(req.Slugs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one slug is required")
+	}
+	for _, slug := range req.Slugs {
+		if err := storage.ValidateSlug(slug); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	secret, hash, err := tokens.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.store.SaveAPIToken(ctx, &storage.APIToken{
+		ID:         uuid.New().String(),
+		Name:       req.Name,
+		Slugs:      req.Slugs,
+		SecretHash: hash,
+		CreatedAt:  models.Now(),
+		CreatedBy:  req.Actor,
+		Tenant:     residency.TenantFromContext(ctx),
+	})
+	if err != nil {
+		s.logger.Error("Failed to save API token", zap.Error(err))
+		return nil, fmt.Errorf("failed to save API token: %w", err)
+	}
+
+	s.logger.Info("API token created", zap.String("id", token.ID), zap.Strings("slugs", token.Slugs))
+	return &pb.APITokenResponse{
+		Token:   apiTokenProto(token),
+		Secret:  secret,
+		Message: "API token created; store the secret now, it cannot be retrieved later",
+	}, nil
+}
+
+// ListAPITokens lists API tokens, without their secrets.
+func (s *GameDNAServiceServer) ListAPITokens(ctx context.Context, req *pb.ListAPITokensRequest) (*pb.ListAPITokensResponse, error) {
+	if s.opts.Tokens == nil {
+		return nil, status.Error(codes.FailedPrecondition, "API tokens are disabled")
+	}
+	list, err := s.store.ListAPITokens(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list API tokens", zap.Error(err))
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	resp := &pb.ListAPITokensResponse{}
+	for _, token := range list {
+		resp.Tokens = append(resp.Tokens, apiTokenProto(token))
+	}
+	return resp, nil
+}
+
+// RevokeAPIToken revokes an API token. Revoking twice is a no-op.
+func (s *GameDNAServiceServer) RevokeAPIToken(ctx context.Context, req *pb.RevokeAPITokenRequest) (*pb.APITokenResponse, error) {
+	if s.opts.Tokens == nil {
+		return nil, status.Error(codes.FailedPrecondition, "API tokens are disabled")
+	}
+	token, err := s.store.GetAPIToken(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API token: %w", err)
+	}
+	if token.RevokedAt.IsZero() {
+		token.RevokedAt = models.Now()
+		if token, err = s.store.SaveAPIToken(ctx, token); err != nil {
+			s.logger.Error("Failed to revoke API token", zap.Error(err))
+			return nil, fmt.Errorf("failed to revoke API token: %w", err)
+		}
+		s.opts.Tokens.Forget()
+		s.logger.Warn("API token revoked", zap.String("id", token.ID))
+	}
+	return &pb.APITokenResponse{
+		Token:   apiTokenProto(token),
+		Message: "API token revoked",
+	}, nil
+}
+
+// RotateAPIToken issues a new secret for an API token. The replaced secret
+// keeps working for grace_seconds, so shipped builds can be updated first.
+func (s *GameDNAServiceServer) RotateAPIToken(ctx context.Context, req *pb.RotateAPITokenRequest) (*pb.APITokenResponse, error) {
+	if s.opts.Tokens == nil {
+		return nil, status.Error(codes.FailedPrecondition, "API tokens are disabled")
+	}
+	if req.GraceSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "grace_seconds must not be negative")
+	}
+	token, err := s.store.GetAPIToken(ctx, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API token: %w", err)
+	}
+	if !token.RevokedAt.IsZero() {
+		return nil, status.Errorf(codes.FailedPrecondition, "API token %s is revoked", token.ID)
+	}
+
+	secret, hash, err := tokens.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := models.Now()
+	token.PreviousHash, token.PreviousExpiresAt = "", time.Time{}
+	if req.GraceSeconds > 0 {
+		token.PreviousHash = token.SecretHash
+		token.PreviousExpiresAt = now.Add(time.Duration(req.GraceSeconds) * time.Second)
+	}
+	token.SecretHash = hash
+	token.RotatedAt = now
+	if token, err = s.store.SaveAPIToken(ctx, token); err != nil {
+		s.logger.Error("Failed to rotate API token", zap.Error(err))
+		return nil, fmt.Errorf("failed to rotate API token: %w", err)
+	}
+	s.opts.Tokens.Forget()
+
+	s.logger.Info("API token rotated", zap.String("id", token.ID), zap.Int64("grace_seconds", req.GraceSeconds))
+	return &pb.APITokenResponse{
+		Token:   apiTokenProto(token),
+		Secret:  secret,
+		Message: "API token rotated; store the secret now, it cannot be retrieved later",
+	}, nil
+}
+
+func apiTokenProto(t *storage.APIToken) *pb.APIToken {
+	return &pb.APIToken{
+		Id:                       t.ID,
+		Name:                     t.Name,
+		Slugs:                    t.Slugs,
+		CreatedBy:                t.CreatedBy,
+		CreateTime:               models.TimestampProto(t.CreatedAt),
+		RotateTime:               models.TimestampProto(t.RotatedAt),
+		RevokeTime:               models.TimestampProto(t.RevokedAt),
+		PreviousSecretExpireTime: models.TimestampProto(t.PreviousExpiresAt),
+		Revoked:                  !t.RevokedAt.IsZero(),
+	}
+}
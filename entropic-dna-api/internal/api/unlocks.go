@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultUnlockWindow is how long a temporary unlock lasts when the request
+// doesn't say.
+const defaultUnlockWindow = 30 * time.Minute
+
+// Relock reasons recorded in the audit log.
+const (
+	relockReasonFinished = "temporary unlock finished"
+	relockReasonExpired  = "temporary unlock expired"
+)
+
+// RequestTemporaryUnlock unlocks a published config for a bounded window, for
+// hotfixes. When the window closes, or FinishTemporaryUnlock is called, the
+// config is published again with its edits.
+func (s *GameDNAServiceServer) RequestTemporaryUnlock(ctx context.Context, req *pb.RequestTemporaryUnlockRequest) (*pb.TemporaryUnlockResponse, error) {
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	if req.DurationSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "duration_seconds cannot be negative")
+	}
+	window := time.Duration(req.DurationSeconds) * time.Second
+	max := s.opts.MaxUnlockWindow
+	switch {
+	case window == 0 && max > 0 && max < defaultUnlockWindow:
+		window = max
+	case window == 0:
+		window = defaultUnlockWindow
+	case max > 0 && window > max:
+		return nil, status.Errorf(codes.InvalidArgument, "duration_seconds may be at most %d", int64(max/time.Second))
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for temporary unlock", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	expires := models.Now().Add(window)
+	unlocked, entry, err := s.store.UnlockTemporarily(ctx, current.Id, actor, req.Reason, expires)
+	if err != nil {
+		s.logger.Error("Failed to temporarily unlock game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to temporarily unlock game DNA: %w", err)
+	}
+
+	s.changed(ctx, events.TypeUnpublished, unlocked.Id, unlocked)
+
+	return &pb.TemporaryUnlockResponse{
+		GameDna: unlocked,
+		Unlock: temporaryUnlockProto(&storage.TemporaryUnlock{
+			ConfigID:  unlocked.Id,
+			Actor:     actor,
+			Reason:    req.Reason,
+			CreatedAt: entry.CreatedAt,
+			ExpiresAt: expires,
+		}),
+		AuditEntry: auditEntryProto(entry),
+		Message:    "Game DNA temporarily unlocked",
+	}, nil
+}
+
+// FinishTemporaryUnlock closes a config's temporary unlock early and publishes
+// it again now.
+func (s *GameDNAServiceServer) FinishTemporaryUnlock(ctx context.Context, req *pb.FinishTemporaryUnlockRequest) (*pb.TemporaryUnlockResponse, error) {
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.Id)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for relock", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	return s.relock(ctx, current, actor, relockReasonFinished)
+}
+
+// ListTemporaryUnlocks lists the open temporary unlock windows.
+func (s *GameDNAServiceServer) ListTemporaryUnlocks(ctx context.Context, req *pb.ListTemporaryUnlocksRequest) (*pb.ListTemporaryUnlocksResponse, error) {
+	unlocks, err := s.store.ListTemporaryUnlocks(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list temporary unlocks", zap.Error(err))
+		return nil, fmt.Errorf("failed to list temporary unlocks: %w", err)
+	}
+
+	resp := &pb.ListTemporaryUnlocksResponse{}
+	for _, u := range unlocks {
+		resp.Unlocks = append(resp.Unlocks, temporaryUnlockProto(u))
+	}
+	return resp, nil
+}
+
+// RelockExpired publishes again every config whose temporary unlock window
+// has closed, and returns how many it relocked. The server calls it
+// periodically; a config that fails to relock is retried on the next call.
+func (s *GameDNAServiceServer) RelockExpired(ctx context.Context) (int, error) {
+	unlocks, err := s.store.ListTemporaryUnlocks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list temporary unlocks: %w", err)
+	}
+
+	now := models.Now()
+	relocked := 0
+	for _, u := range unlocks {
+		if u.ExpiresAt.After(now) {
+			break
+		}
+		current, err := s.store.Read(ctx, u.ConfigID)
+		if err != nil {
+			s.logger.Warn("Failed to read expired temporary unlock", zap.String("id", u.ConfigID), zap.Error(err))
+			continue
+		}
+		if _, err := s.relock(ctx, current, "system", relockReasonExpired); err != nil {
+			s.logger.Warn("Failed to relock expired temporary unlock", zap.String("id", u.ConfigID), zap.Error(err))
+			continue
+		}
+		relocked++
+	}
+	return relocked, nil
+}
+
+// relock validates current and publishes it again, closing its temporary
+// unlock. Unlike PublishGameDNA it doesn't reject findings: the config was
+// published before the window, and leaving it unlocked would be worse.
+func (s *GameDNAServiceServer) relock(ctx context.Context, current *pb.GameDNA, actor, reason string) (*pb.TemporaryUnlockResponse, error) {
+	validationResp, err := s.validate(ctx, current)
+	if err != nil {
+		s.logger.Error("Validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	checksum, err := s.rust.CalculateChecksum(current)
+	if err != nil {
+		s.logger.Error("Failed to calculate checksum", zap.Error(err))
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	relocked, entry, err := s.store.RelockVersion(ctx, current.Id, actor, reason, checksum, validationResp)
+	if err != nil {
+		s.logger.Error("Failed to relock game DNA", zap.Error(err))
+		return nil, fmt.Errorf("failed to relock game DNA: %w", err)
+	}
+
+	if !validationResp.IsValid || len(validationResp.Warnings) > 0 {
+		s.logger.Warn("Game DNA relocked with validation findings",
+			zap.String("id", relocked.Id),
+			zap.Int("errors", len(validationResp.Errors)),
+			zap.Int("warnings", len(validationResp.Warnings)),
+		)
+	}
+	s.logger.Info("Game DNA relocked", zap.String("id", relocked.Id), zap.String("actor", actor), zap.String("reason", reason))
+	s.changed(ctx, events.TypePublished, relocked.Id, relocked)
+
+	return &pb.TemporaryUnlockResponse{
+		GameDna:    relocked,
+		AuditEntry: auditEntryProto(entry),
+		Validation: validationResp,
+		Message:    "Game DNA relocked and published",
+	}, nil
+}
+
+func temporaryUnlockProto(u *storage.TemporaryUnlock) *pb.TemporaryUnlock {
+	return &pb.TemporaryUnlock{
+		ConfigId:   u.ConfigID,
+		Actor:      u.Actor,
+		Reason:     u.Reason,
+		CreateTime: models.TimestampProto(u.CreatedAt),
+		ExpireTime: models.TimestampProto(u.ExpiresAt),
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+)
+
+// validate runs the FFI validator on dna, recording the call as a span of
+// the request's trace, and checks its custom properties against its
+// project's schemas. A config with a parent is validated with the values it
+// inherits filled in. Values of declared properties are put in canonical
+// form in dna itself, so that writes store them that way. Findings waived
+// for a stored config are moved out of the errors and warnings.
+func (s *GameDNAServiceServer) validate(ctx context.Context, dna *pb.GameDNA) (_ *pb.ValidationResponse, err error) {
+	own := dna
+	dna, err = s.effective(ctx, dna)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := s.propertySchemas(ctx, dna)
+	if err != nil {
+		return nil, err
+	}
+	coerceProperties(schemas, own)
+	if dna != own {
+		coerceProperties(schemas, dna)
+	}
+
+	_, span := tracing.Start(ctx, "ffi.ValidateGameDNA", tracing.SpanKindInternal)
+	defer func() { span.Finish(err) }()
+
+	resp, err := s.rust.ValidateGameDNA(dna)
+	if err != nil {
+		return nil, err
+	}
+	checkProperties(schemas, dna, resp)
+	if dna.GetId() != "" {
+		if err := s.applyWaivers(ctx, dna.Id, resp); err != nil {
+			return nil, err
+		}
+	}
+	span.SetAttribute("validation.valid", resp.IsValid)
+	span.SetAttribute("validation.errors", int64(len(resp.Errors)))
+	span.SetAttribute("validation.rules_version", resp.RulesVersion)
+	return resp, nil
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateValidationWaiver records a reviewed waiver for one validation finding
+// on a config. Until it expires, the finding is reported under waived rather
+// than as an error or warning.
+func (s *GameDNAServiceServer) CreateValidationWaiver(ctx context.Context, req *pb.CreateValidationWaiverRequest) (*pb.ValidationWaiverResponse, error) {
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+	if req.Justification == "" {
+		return nil, status.Error(codes.InvalidArgument, "justification is required")
+	}
+	if req.ExpireTime == nil {
+		return nil, status.Error(codes.InvalidArgument, "expire_time is required")
+	}
+	now := models.Now()
+	expires := req.ExpireTime.AsTime()
+	if !expires.After(now) {
+		return nil, status.Error(codes.InvalidArgument, "expire_time must be in the future")
+	}
+	if max := s.opts.MaxWaiverDuration; max > 0 && expires.Sub(now) > max {
+		return nil, status.Errorf(codes.InvalidArgument, "expire_time may be at most %s away", max)
+	}
+	approver, err := callerName(ctx, req.Approver)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		s.logger.Error("Failed to read game DNA for waiver", zap.Error(err))
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+
+	waiver, err := s.store.SaveWaiver(ctx, &storage.Waiver{
+		ID:            uuid.New().String(),
+		ConfigID:      current.Id,
+		Code:          req.Code,
+		Field:         req.Field,
+		Justification: req.Justification,
+		Approver:      approver,
+		CreatedAt:     now,
+		ExpiresAt:     expires,
+	})
+	if err != nil {
+		s.logger.Error("Failed to save waiver", zap.Error(err))
+		return nil, fmt.Errorf("failed to save waiver: %w", err)
+	}
+
+	return &pb.ValidationWaiverResponse{
+		Waiver:  waiverProto(waiver, now),
+		Message: "Validation waiver created",
+	}, nil
+}
+
+// ListValidationWaivers lists a config's waivers, by default only those
+// still applied.
+func (s *GameDNAServiceServer) ListValidationWaivers(ctx context.Context, req *pb.ListValidationWaiversRequest) (*pb.ListValidationWaiversResponse, error) {
+	configID := req.ConfigId
+	// Waivers outlive their config, so only slugs need the config to exist.
+	if current, err := s.readConfig(ctx, req.ConfigId); err == nil {
+		configID = current.Id
+	}
+
+	waivers, err := s.store.ListWaivers(ctx, configID)
+	if err != nil {
+		s.logger.Error("Failed to list waivers", zap.Error(err))
+		return nil, fmt.Errorf("failed to list waivers: %w", err)
+	}
+
+	now := models.Now()
+	resp := &pb.ListValidationWaiversResponse{}
+	for _, w := range waivers {
+		if req.IncludeInactive || w.Active(now) {
+			resp.Waivers = append(resp.Waivers, waiverProto(w, now))
+		}
+	}
+	return resp, nil
+}
+
+// RevokeValidationWaiver withdraws a waiver, so its finding is reported again.
+// The waiver is kept, with who revoked it and when.
+func (s *GameDNAServiceServer) RevokeValidationWaiver(ctx context.Context, req *pb.RevokeValidationWaiverRequest) (*pb.ValidationWaiverResponse, error) {
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.readConfig(ctx, req.ConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	waivers, err := s.store.ListWaivers(ctx, current.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waivers: %w", err)
+	}
+
+	var waiver *storage.Waiver
+	for _, w := range waivers {
+		if w.ID == req.Id {
+			waiver = w
+		}
+	}
+	if waiver == nil {
+		return nil, status.Errorf(codes.NotFound, "waiver %s not found on config %s", req.Id, current.Id)
+	}
+	now := models.Now()
+	if !waiver.RevokedAt.IsZero() {
+		return &pb.ValidationWaiverResponse{Waiver: waiverProto(waiver, now), Message: "Validation waiver already revoked"}, nil
+	}
+
+	waiver.RevokedAt = now
+	waiver.RevokedBy = actor
+	if waiver, err = s.store.SaveWaiver(ctx, waiver); err != nil {
+		s.logger.Error("Failed to revoke waiver", zap.Error(err))
+		return nil, fmt.Errorf("failed to revoke waiver: %w", err)
+	}
+
+	return &pb.ValidationWaiverResponse{
+		Waiver:  waiverProto(waiver, now),
+		Message: "Validation waiver revoked",
+	}, nil
+}
+
+// applyWaivers moves the findings in resp that an active waiver of configID
+// covers into resp.Waived. Expired waivers no longer match, so their findings
+// are reported again.
+func (s *GameDNAServiceServer) applyWaivers(ctx context.Context, configID string, resp *pb.ValidationResponse) error {
+	waivers, err := s.store.ListWaivers(ctx, configID)
+	if err != nil || len(waivers) == 0 {
+		return err
+	}
+
+	now := models.Now()
+	find := func(code, field string) *storage.Waiver {
+		for _, w := range waivers {
+			if w.Code == code && w.Field == field && w.Active(now) {
+				return w
+			}
+		}
+		return nil
+	}
+	waived := func(w *storage.Waiver, message string, isError bool) *pb.WaivedFinding {
+		return &pb.WaivedFinding{
+			Code:       w.Code,
+			Field:      w.Field,
+			Message:    message,
+			IsError:    isError,
+			WaiverId:   w.ID,
+			ExpireTime: models.TimestampProto(w.ExpiresAt),
+		}
+	}
+
+	errs := resp.Errors[:0]
+	for _, e := range resp.Errors {
+		if w := find(e.Code, e.Field); w != nil {
+			resp.Waived = append(resp.Waived, waived(w, e.Message, true))
+		} else {
+			errs = append(errs, e)
+		}
+	}
+	resp.Errors = errs
+	warnings := resp.Warnings[:0]
+	for _, warning := range resp.Warnings {
+		if w := find(warning.Code, warning.Field); w != nil {
+			resp.Waived = append(resp.Waived, waived(w, warning.Message, false))
+		} else {
+			warnings = append(warnings, warning)
+		}
+	}
+	resp.Warnings = warnings
+	if len(resp.Errors) == 0 {
+		resp.IsValid = true
+	}
+	return nil
+}
+
+func waiverProto(w *storage.Waiver, now time.Time) *pb.ValidationWaiver {
+	return &pb.ValidationWaiver{
+		Id:            w.ID,
+		ConfigId:      w.ConfigID,
+		Code:          w.Code,
+		Field:         w.Field,
+		Justification: w.Justification,
+		Approver:      w.Approver,
+		CreateTime:    models.TimestampProto(w.CreatedAt),
+		ExpireTime:    models.TimestampProto(w.ExpiresAt),
+		RevokeTime:    models.TimestampProto(w.RevokedAt),
+		RevokedBy:     w.RevokedBy,
+		Active:        w.Active(now),
+	}
+}
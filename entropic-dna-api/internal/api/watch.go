@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchGameDNA streams change events matching the request until the client
+// goes away. Response headers are sent once the watch is registered. A
+// watcher that falls behind is ended with Unavailable and resumes with
+// after_sequence.
+func (s *GameDNAServiceServer) WatchGameDNA(req *pb.WatchGameDNARequest, stream pb.GameDNAService_WatchGameDNAServer) error {
+	if s.opts.Watch == nil {
+		return status.Error(codes.FailedPrecondition, "change events are disabled")
+	}
+	for _, t := range req.Types {
+		if !slices.Contains(events.Types, t) {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", t)
+		}
+	}
+	if req.AfterSequence < 0 {
+		return status.Error(codes.InvalidArgument, "after_sequence must not be negative")
+	}
+
+	ctx := stream.Context()
+	filter := events.Filter{Tags: req.Tags, Types: req.Types}
+	if req.ConfigId != "" {
+		filter.ConfigID = req.ConfigId
+		// Slugs are resolved up front, as delete events carry no config.
+		if _, err := uuid.Parse(req.ConfigId); err != nil {
+			dna, err := s.store.ReadBySlug(ctx, req.ConfigId)
+			if err != nil {
+				return fmt.Errorf("failed to read game DNA: %w", err)
+			}
+			filter.ConfigID = dna.Id
+		}
+	}
+
+	after := req.AfterSequence
+	if after == 0 {
+		after = math.MaxInt64
+	}
+	replay, live, cancel := s.opts.Watch.Subscribe(after, filter)
+	defer cancel()
+	// Headers tell the client the watch is registered.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	sent := req.AfterSequence
+	for _, ev := range replay {
+		if err := stream.Send(ev); err != nil {
+			return err
+		}
+		sent = ev.Sequence
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-live:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "watch fell behind; resume with after_sequence %d", sent)
+			}
+			if ev.Sequence <= sent {
+				continue
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+			sent = ev.Sequence
+		}
+	}
+}
+
+// defaultMaxWait caps long polls when the server sets no MaxWait.
+const defaultMaxWait = time.Minute
+
+// waitForChange reads the config ref names by ID or slug once its checksum
+// differs from checksum, or when wait runs out, capped by the server's
+// MaxWait. It reports whether the wait ran out with the config unchanged.
+// Changes are noticed through the change events, so other instances' writes
+// are seen as soon as their events are relayed.
+func (s *GameDNAServiceServer) waitForChange(ctx context.Context, ref, checksum string, wait time.Duration) (*pb.GameDNA, bool, error) {
+	if s.opts.Watch == nil {
+		return nil, false, status.Error(codes.FailedPrecondition, "change events are disabled")
+	}
+	max := s.opts.MaxWait
+	if max <= 0 {
+		max = defaultMaxWait
+	}
+	if wait <= 0 || wait > max {
+		wait = max
+	}
+
+	dna, err := s.readConfig(ctx, ref)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read game DNA: %w", err)
+	}
+	// The config is read again once subscribed, so no change slips between.
+	subscribe := func() (<-chan *pb.ConfigEvent, func()) {
+		_, live, cancel := s.opts.Watch.Subscribe(math.MaxInt64, events.Filter{ConfigID: dna.Id})
+		return live, cancel
+	}
+	live, cancel := subscribe()
+	defer func() { cancel() }()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		if dna, err = s.readConfig(ctx, dna.Id); err != nil {
+			return nil, false, fmt.Errorf("failed to read game DNA: %w", err)
+		}
+		if dna.Checksum != checksum {
+			return dna, false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+			return dna, true, nil
+		case _, ok := <-live:
+			if !ok {
+				// Fell behind; the read above catches up
+				cancel()
+				live, cancel = subscribe()
+			}
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/entropic-engine/entropic-dna-api/internal/webhooks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// webhookBatchSize is how many deliveries one DeliverWebhooks call claims.
+	webhookBatchSize = 64
+	// defaultDeliveryLimit and maxDeliveryLimit bound ListWebhookDeliveries.
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// CreateWebhook registers an endpoint to be sent lifecycle events. The
+// signing secret is only returned here.
+func (s *GameDNAServiceServer) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.WebhookResponse, error) {
+	if s.opts.Webhooks == nil {
+		return nil, status.Error(codes.FailedPrecondition, "webhooks are disabled")
+	}
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "url %q is not an http or https URL", req.Url)
+	}
+	for _, t := range req.EventTypes {
+		if !slices.Contains(events.Types, t) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %q; want one of %v", t, events.Types)
+		}
+	}
+	actor, err := callerName(ctx, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := s.store.SaveWebhook(ctx, &storage.Webhook{
+		ID:         uuid.New().String(),
+		URL:        req.Url,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		CreatedBy:  actor,
+		CreatedAt:  models.Now(),
+	})
+	if err != nil {
+		s.logger.Error("Failed to save webhook", zap.Error(err))
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	s.logger.Info("Webhook created", zap.String("id", webhook.ID), zap.String("url", webhook.URL), zap.Strings("event_types", webhook.EventTypes))
+	return &pb.WebhookResponse{
+		Webhook: webhookProto(webhook),
+		Secret:  secret,
+		Message: "Webhook created; store the secret now, it cannot be retrieved later",
+	}, nil
+}
+
+// ListWebhooks lists webhooks, without their secrets.
+func (s *GameDNAServiceServer) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	if s.opts.Webhooks == nil {
+		return nil, status.Error(codes.FailedPrecondition, "webhooks are disabled")
+	}
+	list, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list webhooks", zap.Error(err))
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	resp := &pb.ListWebhooksResponse{}
+	for _, webhook := range list {
+		resp.Webhooks = append(resp.Webhooks, webhookProto(webhook))
+	}
+	return resp, nil
+}
+
+// DeleteWebhook deletes a webhook with its deliveries, pending ones included.
+func (s *GameDNAServiceServer) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	if s.opts.Webhooks == nil {
+		return nil, status.Error(codes.FailedPrecondition, "webhooks are disabled")
+	}
+	if err := s.store.DeleteWebhook(ctx, req.Id); err != nil {
+		s.logger.Error("Failed to delete webhook", zap.Error(err))
+		return nil, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	s.logger.Info("Webhook deleted", zap.String("id", req.Id))
+	return &pb.DeleteWebhookResponse{
+		Success: true,
+		Message: "Webhook deleted",
+	}, nil
+}
+
+// ListWebhookDeliveries lists the recent deliveries to a webhook, newest
+// first. Finished deliveries are kept for the configured retention.
+func (s *GameDNAServiceServer) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	if s.opts.Webhooks == nil {
+		return nil, status.Error(codes.FailedPrecondition, "webhooks are disabled")
+	}
+	switch req.State {
+	case "", storage.WebhookDeliveryPending, storage.WebhookDeliverySucceeded, storage.WebhookDeliveryFailed:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown state %q; want pending, succeeded or failed", req.State)
+	}
+	limit := int(req.Limit)
+	switch {
+	case limit < 0:
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	case limit == 0:
+		limit = defaultDeliveryLimit
+	case limit > maxDeliveryLimit:
+		limit = maxDeliveryLimit
+	}
+	if _, err := s.store.GetWebhook(ctx, req.WebhookId); err != nil {
+		return nil, fmt.Errorf("failed to read webhook: %w", err)
+	}
+
+	list, err := s.store.ListWebhookDeliveries(ctx, req.WebhookId, req.State, limit)
+	if err != nil {
+		s.logger.Error("Failed to list webhook deliveries", zap.Error(err))
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	resp := &pb.ListWebhookDeliveriesResponse{}
+	for _, d := range list {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryProto(d))
+	}
+	return resp, nil
+}
+
+// enqueueWebhooks queues a delivery of a change event to every webhook that
+// wants it. The change is already made, so failures are only logged.
+func (s *GameDNAServiceServer) enqueueWebhooks(ctx context.Context, eventType, id string, dna *pb.GameDNA) {
+	if s.opts.Webhooks == nil {
+		return
+	}
+	list, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to list webhooks", zap.String("id", id), zap.Error(err))
+		return
+	}
+	now := models.Now()
+	var payload []byte
+	for _, webhook := range list {
+		if len(webhook.EventTypes) > 0 && !slices.Contains(webhook.EventTypes, eventType) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = webhooks.Payload(eventType, id, dna, now); err != nil {
+				s.logger.Warn("Failed to encode webhook payload", zap.String("id", id), zap.Error(err))
+				return
+			}
+		}
+		_, err := s.store.SaveWebhookDelivery(ctx, &storage.WebhookDelivery{
+			ID:            uuid.New().String(),
+			WebhookID:     webhook.ID,
+			EventType:     eventType,
+			ConfigID:      id,
+			Payload:       payload,
+			State:         storage.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			s.logger.Warn("Failed to queue webhook delivery", zap.String("webhook", webhook.ID), zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// DeliverWebhooks sends a batch of due webhook deliveries and returns how
+// many succeeded. Deliveries to one webhook are sent in order, different
+// webhooks at once. A failed attempt is retried with backoff until the
+// delivery runs out of attempts. The server calls it periodically; finished
+// deliveries past their retention are pruned on the way.
+func (s *GameDNAServiceServer) DeliverWebhooks(ctx context.Context) (int, error) {
+	sender := s.opts.Webhooks
+	if sender == nil {
+		return 0, nil
+	}
+	now := models.Now()
+	if _, err := s.store.PruneWebhookDeliveries(ctx, now.Add(-sender.Retention())); err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	// The lease outlasts a batch, so deliveries claimed by a server that
+	// stops mid-batch are only delayed
+	lease := time.Duration(webhookBatchSize)*sender.Timeout() + time.Minute
+	claimed, err := s.store.ClaimWebhookDeliveries(ctx, now, webhookBatchSize, lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	if len(claimed) == 0 {
+		return 0, nil
+	}
+	list, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	byWebhook := make(map[string][]*storage.WebhookDelivery)
+	for _, d := range claimed {
+		byWebhook[d.WebhookID] = append(byWebhook[d.WebhookID], d)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	delivered := 0
+	for _, webhook := range list {
+		queue := byWebhook[webhook.ID]
+		if len(queue) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(webhook *storage.Webhook, queue []*storage.WebhookDelivery) {
+			defer wg.Done()
+			for _, d := range queue {
+				if s.attemptDelivery(ctx, webhook, d) {
+					mu.Lock()
+					delivered++
+					mu.Unlock()
+				}
+			}
+		}(webhook, queue)
+	}
+	wg.Wait()
+	return delivered, nil
+}
+
+// attemptDelivery sends d once and records how it went, reporting whether
+// it succeeded.
+func (s *GameDNAServiceServer) attemptDelivery(ctx context.Context, webhook *storage.Webhook, d *storage.WebhookDelivery) bool {
+	sender := s.opts.Webhooks
+	code, sendErr := sender.Send(ctx, webhook, d)
+	now := models.Now()
+	d.Attempts++
+	d.LastStatus = code
+	d.UpdatedAt = now
+	outcome := "succeeded"
+	switch {
+	case sendErr == nil:
+		d.State = storage.WebhookDeliverySucceeded
+		d.LastError = ""
+		d.DeliveredAt = now
+	case d.Attempts >= sender.MaxAttempts():
+		outcome = "failed"
+		d.State = storage.WebhookDeliveryFailed
+		d.LastError = sendErr.Error()
+		s.logger.Warn("Webhook delivery failed", zap.String("webhook", webhook.ID), zap.String("delivery", d.ID), zap.Int("attempts", d.Attempts), zap.Error(sendErr))
+	default:
+		outcome = "retried"
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = now.Add(webhooks.Backoff(d.Attempts))
+	}
+	metrics.ObserveWebhookDelivery(outcome)
+
+	// A webhook deleted meanwhile took the delivery with it
+	if _, err := s.store.SaveWebhookDelivery(ctx, d); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Warn("Failed to record webhook delivery", zap.String("delivery", d.ID), zap.Error(err))
+	}
+	return sendErr == nil
+}
+
+func webhookProto(w *storage.Webhook) *pb.Webhook {
+	return &pb.Webhook{
+		Id:         w.ID,
+		Url:        w.URL,
+		EventTypes: w.EventTypes,
+		CreatedBy:  w.CreatedBy,
+		CreateTime: models.TimestampProto(w.CreatedAt),
+	}
+}
+
+func webhookDeliveryProto(d *storage.WebhookDelivery) *pb.WebhookDelivery {
+	resp := &pb.WebhookDelivery{
+		Id:          d.ID,
+		WebhookId:   d.WebhookID,
+		EventType:   d.EventType,
+		ConfigId:    d.ConfigID,
+		State:       d.State,
+		Attempts:    int32(d.Attempts),
+		LastStatus:  int32(d.LastStatus),
+		LastError:   d.LastError,
+		CreateTime:  models.TimestampProto(d.CreatedAt),
+		UpdateTime:  models.TimestampProto(d.UpdatedAt),
+		DeliverTime: models.TimestampProto(d.DeliveredAt),
+	}
+	if d.State == storage.WebhookDeliveryPending {
+		resp.NextAttemptTime = models.TimestampProto(d.NextAttemptAt)
+	}
+	return resp
+}
@@ -0,0 +1,231 @@
+// Package archive keeps the data of old config versions in S3-compatible
+// object storage: Amazon S3, Google Cloud Storage through its XML API with
+// HMAC keys, or a self-hosted service such as MinIO. Requests are signed with
+// AWS Signature Version 4, which all of them accept.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxObjectSize bounds an object read back, well above any config.
+const maxObjectSize = 64 << 20
+
+// Options configures a Bucket.
+type Options struct {
+	// Endpoint is the S3 API's base URL, such as https://s3.amazonaws.com
+	// or https://storage.googleapis.com.
+	Endpoint string
+	// Region requests are signed for. GCS accepts "auto".
+	Region string
+	Bucket string
+	// Prefix starts every object key, so deployments can share a bucket.
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// Timeout bounds each request; 0 means 30 seconds.
+	Timeout time.Duration
+}
+
+// Bucket reads and writes objects of one bucket, addressed by path, which
+// every S3-compatible service serves.
+type Bucket struct {
+	endpoint *url.URL
+	opts     Options
+	client   *http.Client
+	now      func() time.Time
+}
+
+// New returns a Bucket, rejecting options that can't make a request.
+func New(opts Options) (*Bucket, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(opts.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("archive endpoint %q is not an http or https URL", opts.Endpoint)
+	}
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("archive bucket is required")
+	}
+	if opts.Region == "" {
+		return nil, fmt.Errorf("archive region is required")
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, fmt.Errorf("archive access key ID and secret access key are required")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	return &Bucket{
+		endpoint: endpoint,
+		opts:     opts,
+		client:   &http.Client{Timeout: opts.Timeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Put stores data under key, replacing any object there.
+func (b *Bucket) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return b.failure(resp, "put", key)
+	}
+	return nil
+}
+
+// Get returns the object under key.
+func (b *Bucket) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, b.failure(resp, "get", key)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived object %s: %w", key, err)
+	}
+	if len(data) > maxObjectSize {
+		return nil, fmt.Errorf("archived object %s is larger than %d bytes", key, maxObjectSize)
+	}
+	return data, nil
+}
+
+// Delete removes the object under key. Deleting a missing object succeeds,
+// as S3 reports it.
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return b.failure(resp, "delete", key)
+	}
+	return nil
+}
+
+// do sends a signed request for the object under key.
+func (b *Bucket) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	key = b.opts.Prefix + key
+	u := *b.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + b.opts.Bucket + "/" + key
+	u.RawPath = escapePath(u.Path)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build archive request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	b.sign(req, body)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s archived object %s: %w", strings.ToLower(method), key, err)
+	}
+	return resp, nil
+}
+
+// failure describes an error response, by the code and message S3 puts in
+// its body when there is one.
+func (b *Bucket) failure(resp *http.Response, op, key string) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &body) == nil && body.Code != "" {
+		return fmt.Errorf("failed to %s archived object %s%s: %s: %s", op, b.opts.Prefix, key, body.Code, body.Message)
+	}
+	return fmt.Errorf("failed to %s archived object %s%s: %s", op, b.opts.Prefix, key, resp.Status)
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host and
+// every header req has so far.
+func (b *Bucket) sign(req *http.Request, body []byte) {
+	now := b.now().UTC()
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + b.opts.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+b.opts.SecretAccessKey), date)
+	key = hmacSHA256(key, b.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath percent-encodes everything in path but unreserved characters
+// and slashes, as Signature Version 4 expects of S3 object paths.
+func escapePath(path string) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,423 @@
+// Package auth authenticates callers by API key or JWT bearer token and
+// enforces the scope each RPC requires. Keys are configured, not stored, and
+// JWTs are issued by an identity provider sharing the signing secret.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Scopes a key or token may grant. Admin grants every scope but ScopeActAs;
+// the others grant only themselves. ScopeActAs guards no method: it lets a
+// service account record its changes as made by the user it acts for.
+const (
+	ScopeRead    = "read"
+	ScopeWrite   = "write"
+	ScopePublish = "publish"
+	ScopeAdmin   = "admin"
+	ScopeActAs   = "act_as"
+)
+
+// Scopes lists every scope.
+var Scopes = []string{ScopeRead, ScopeWrite, ScopePublish, ScopeAdmin, ScopeActAs}
+
+const (
+	// APIKeyHeader carries an API key on REST requests.
+	APIKeyHeader = "X-API-Key"
+	// APIKeyMetadata carries an API key on gRPC calls.
+	APIKeyMetadata = "x-api-key"
+	// authorizationMetadata carries "Bearer <jwt>". The REST gateway forwards
+	// the Authorization header under this key.
+	authorizationMetadata = "authorization"
+)
+
+// servicePrefix starts the full name of every GameDNAService method. Calls to
+// other services, such as health checks and reflection, need no credentials.
+const servicePrefix = "/entropic.dna.v1.GameDNAService/"
+
+// MethodScopes maps each GameDNAService method to the scope it requires.
+// Methods missing here require ScopeAdmin.
+var MethodScopes = map[string]string{
+	"GetGameDNA":                ScopeRead,
+	"GetEffectiveGameDNA":       ScopeRead,
+	"ListGameDNA":               ScopeRead,
+	"SearchGameDNA":             ScopeRead,
+	"ValidateGameDNA":           ScopeRead,
+	"GetSchema":                 ScopeRead,
+	"ListFieldEnums":            ScopeRead,
+	"EstimatePerformanceBudget": ScopeRead,
+	"GetVersionHistory":         ScopeRead,
+	"ListVersionMetadata":       ScopeRead,
+	"GetVersion":                ScopeRead,
+	"DiffVersions":              ScopeRead,
+	"CompareGameDNA":            ScopeRead,
+	"BlameGameDNA":              ScopeRead,
+	"WatchGameDNA":              ScopeRead,
+	"GetSnapshot":               ScopeRead,
+	"ListSnapshots":             ScopeRead,
+	"ExportSnapshot":            ScopeRead,
+	"ExportGameDNA":             ScopeRead,
+	"ExportToGoogleSheet":       ScopeRead,
+	"GetImportMapping":          ScopeRead,
+	"GetDraft":                  ScopeRead,
+	"GetAuditLog":               ScopeRead,
+	"ListValidationWaivers":     ScopeRead,
+	"ListTemplates":             ScopeRead,
+	"GetTemplate":               ScopeRead,
+	"ListPropertySchemas":       ScopeRead,
+	"GetPropertySchema":         ScopeRead,
+	"GetEventLog":               ScopeRead,
+	"ListTemporaryUnlocks":      ScopeRead,
+	"GetCanary":                 ScopeRead,
+	"GetPublishedConfig":        ScopeRead,
+	"SyncPublishedConfigs":      ScopeRead,
+
+	"CreateGameDNA":           ScopeWrite,
+	"UpdateGameDNA":           ScopeWrite,
+	"DeleteGameDNA":           ScopeWrite,
+	"RollbackToVersion":       ScopeWrite,
+	"CloneGameDNA":            ScopeWrite,
+	"SaveSet":                 ScopeWrite,
+	"BatchCreateGameDNA":      ScopeWrite,
+	"BatchUpdateGameDNA":      ScopeWrite,
+	"BulkUpdateField":         ScopeWrite,
+	"RevalidateAgainstLatest": ScopeWrite,
+	"CreateSnapshot":          ScopeWrite,
+	"RestoreSnapshot":         ScopeWrite,
+	"ImportSnapshot":          ScopeWrite,
+	"ImportSheet":             ScopeWrite,
+	"ImportGameDNA":           ScopeWrite,
+	"ApplyGameDNA":            ScopeWrite,
+	"PullFromGoogleSheet":     ScopeWrite,
+	"SaveImportMapping":       ScopeWrite,
+	"StartEditing":            ScopeWrite,
+	"HeartbeatEditing":        ScopeWrite,
+	"StopEditing":             ScopeWrite,
+	"SaveDraft":               ScopeWrite,
+	"DiscardDraft":            ScopeWrite,
+	"PromoteDraft":            ScopeWrite,
+	"SaveTemplate":            ScopeWrite,
+	"DeleteTemplate":          ScopeWrite,
+	"CreateFromTemplate":      ScopeWrite,
+	"SavePropertySchema":      ScopeWrite,
+	"DeletePropertySchema":    ScopeWrite,
+	"ReportConfigFeedback":    ScopeWrite,
+
+	"PublishGameDNA":         ScopePublish,
+	"CreateValidationWaiver": ScopePublish,
+	"RevokeValidationWaiver": ScopePublish,
+	"RequestTemporaryUnlock": ScopePublish,
+	"FinishTemporaryUnlock":  ScopePublish,
+	"StartCanary":            ScopePublish,
+	"PromoteCanary":          ScopePublish,
+	"PromoteGameDNA":         ScopePublish,
+	"RollbackCanary":         ScopePublish,
+	"TagVersion":             ScopePublish,
+
+	"CreateAPIToken":    ScopeAdmin,
+	"ListAPITokens":     ScopeAdmin,
+	"RevokeAPIToken":    ScopeAdmin,
+	"RotateAPIToken":    ScopeAdmin,
+	"TransferOwnership": ScopeAdmin,
+	"UnpublishGameDNA":  ScopeAdmin,
+	"GetIndexStats":     ScopeAdmin,
+	"LookupRequest":     ScopeAdmin,
+	"GetFaults":         ScopeAdmin,
+	"SetFaults":         ScopeAdmin,
+	"ReloadConfig":      ScopeAdmin,
+
+	"StartChecksumMigration":  ScopeAdmin,
+	"GetChecksumMigration":    ScopeAdmin,
+	"CancelChecksumMigration": ScopeAdmin,
+
+	"ExportPrincipalData": ScopeAdmin,
+	"AnonymizePrincipal":  ScopeAdmin,
+}
+
+// RequiredScope returns the scope a call of the full gRPC method name needs,
+// or "" when it needs no credentials.
+func RequiredScope(method string) string {
+	name, ok := strings.CutPrefix(method, servicePrefix)
+	if !ok {
+		return ""
+	}
+	if scope, ok := MethodScopes[name]; ok {
+		return scope
+	}
+	return ScopeAdmin
+}
+
+// Errors returned by Authenticate.
+var (
+	ErrNoCredentials = errors.New("an API key or bearer token is required")
+	ErrInvalid       = errors.New("invalid API key or bearer token")
+)
+
+// Principal is who a call authenticated as.
+type Principal struct {
+	Name   string // the API key's name, or the JWT subject
+	Scopes []string
+	Tenant string // the tenant the principal acts for under data residency, or ""
+}
+
+// Has reports whether p was granted scope.
+func (p *Principal) Has(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// ActsForUsers reports whether p was granted ScopeActAs. Admin doesn't imply
+// it, so an admin's changes stay attributed to the admin.
+func (p *Principal) ActsForUsers() bool {
+	return slices.Contains(p.Scopes, ScopeActAs)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns ctx carrying the principal the call authenticated as.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal the call authenticated as, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Key is a configured API key.
+type Key struct {
+	Name      string
+	KeySHA256 string // hex SHA-256 of the key, so the key itself isn't kept in config
+	Scopes    []string
+	Tenant    string // tenant the key acts for under data residency
+}
+
+// JWT configures bearer token verification.
+type JWT struct {
+	Secret      string // HS256 signing secret; empty disables bearer tokens
+	Issuer      string // required "iss" claim; empty accepts any
+	Audience    string // required "aud" claim; empty accepts any
+	ScopeClaim  string // claim holding the scopes; empty uses "scope"
+	TenantClaim string // claim holding the tenant under data residency; empty uses "tenant"
+}
+
+// Authenticator checks credentials against the configured keys and JWT
+// settings.
+type Authenticator struct {
+	creds atomic.Pointer[credentials]
+}
+
+// credentials are the keys and JWT settings an Authenticator checks against,
+// replaced as a whole by Reload.
+type credentials struct {
+	keys map[string]*Principal // by key hash
+	jwt  JWT
+}
+
+// New creates an authenticator for keys and jwt.
+func New(keys []Key, jwt JWT) (*Authenticator, error) {
+	c, err := newCredentials(keys, jwt)
+	if err != nil {
+		return nil, err
+	}
+	a := &Authenticator{}
+	a.creds.Store(c)
+	return a, nil
+}
+
+// Reload replaces the keys and JWT settings. Calls already authenticated
+// keep their principal; when keys or jwt are invalid, the old ones stay.
+func (a *Authenticator) Reload(keys []Key, jwt JWT) error {
+	c, err := newCredentials(keys, jwt)
+	if err != nil {
+		return err
+	}
+	a.creds.Store(c)
+	return nil
+}
+
+func newCredentials(keys []Key, jwt JWT) (*credentials, error) {
+	c := &credentials{
+		keys: make(map[string]*Principal, len(keys)),
+		jwt:  jwt,
+	}
+	if c.jwt.ScopeClaim == "" {
+		c.jwt.ScopeClaim = "scope"
+	}
+	if c.jwt.TenantClaim == "" {
+		c.jwt.TenantClaim = "tenant"
+	}
+	for _, k := range keys {
+		hash := strings.ToLower(k.KeySHA256)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("API key %s: key_sha256 must be a hex SHA-256", k.Name)
+		}
+		if len(k.Scopes) == 0 {
+			return nil, fmt.Errorf("API key %s has no scopes", k.Name)
+		}
+		for _, scope := range k.Scopes {
+			if !slices.Contains(Scopes, scope) {
+				return nil, fmt.Errorf("API key %s: unknown scope %q", k.Name, scope)
+			}
+		}
+		if _, ok := c.keys[hash]; ok {
+			return nil, fmt.Errorf("API key %s duplicates another key", k.Name)
+		}
+		c.keys[hash] = &Principal{Name: k.Name, Scopes: k.Scopes, Tenant: k.Tenant}
+	}
+	return c, nil
+}
+
+// HashKey returns the hex SHA-256 of key, as configured in key_sha256.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the principal for an API key or a JWT bearer token.
+// An API key takes precedence when both are sent.
+func (a *Authenticator) Authenticate(apiKey, bearer string) (*Principal, error) {
+	c := a.creds.Load()
+	switch {
+	case apiKey != "":
+		p, ok := c.keys[HashKey(apiKey)]
+		if !ok {
+			return nil, ErrInvalid
+		}
+		return p, nil
+	case bearer != "":
+		if c.jwt.Secret == "" {
+			return nil, ErrInvalid
+		}
+		return c.verifyJWT(bearer)
+	}
+	return nil, ErrNoCredentials
+}
+
+// authorize authenticates a call of method, checks it has the scope the
+// method requires and binds it to the principal's tenant. Calls already
+// authenticated with an API token were limited to published reads, and
+// bound to the token's tenant, by the tokens interceptor and pass.
+func (a *Authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	scope := RequiredScope(method)
+	if scope == "" {
+		return ctx, nil
+	}
+	if _, ok := tokens.FromContext(ctx); ok {
+		return ctx, nil
+	}
+	p, err := a.Authenticate(Credentials(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !p.Has(scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s requires the %s scope", strings.TrimPrefix(method, servicePrefix), scope)
+	}
+	ctx, err = residency.Bind(WithPrincipal(ctx, p), residency.RequestedTenant(ctx), p.Tenant)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return ctx, nil
+}
+
+// Credentials returns the API key and bearer token sent with a call.
+func Credentials(ctx context.Context) (apiKey, bearer string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if vals := md.Get(APIKeyMetadata); len(vals) > 0 {
+		apiKey = vals[0]
+	}
+	for _, value := range md.Get(authorizationMetadata) {
+		if token := bearerToken(value); token != "" {
+			bearer = token
+		}
+	}
+	return apiKey, bearer
+}
+
+// RequestCredentials returns the API key and bearer token sent with a REST
+// request.
+func RequestCredentials(r *http.Request) (apiKey, bearer string) {
+	return r.Header.Get(APIKeyHeader), bearerToken(r.Header.Get("Authorization"))
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" value, or "".
+func bearerToken(value string) string {
+	scheme, token, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// UnaryInterceptor rejects calls without credentials granting the scope their
+// method requires.
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls.
+func (a *Authenticator) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+}
+
+// principalStream overrides a stream's context with one carrying the
+// principal.
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+// Require wraps a REST handler that doesn't go through the gRPC server, so
+// the interceptors can't see it, and admits only requests whose credentials
+// grant scope, bound to the principal's tenant as the interceptors do. Gateway routes need no wrapping: the interceptors check them
+// when the gateway calls the gRPC server.
+func (a *Authenticator) Require(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(RequestCredentials(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !p.Has(scope) {
+			http.Error(w, fmt.Sprintf("%s requires the %s scope", r.URL.Path, scope), http.StatusForbidden)
+			return
+		}
+		ctx, err := residency.Bind(WithPrincipal(r.Context(), p), r.Header.Get(residency.TenantHeader), p.Tenant)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
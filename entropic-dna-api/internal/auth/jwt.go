@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far exp and nbf may be off between the issuer and us.
+const clockSkew = 30 * time.Second
+
+// verifyJWT checks an HS256 token's signature and registered claims and
+// returns its principal. Tokens must carry exp.
+func (c *credentials) verifyJWT(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	mac := hmac.New(sha256.New, []byte(c.jwt.Secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalid
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalid
+	}
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: token has no exp", ErrInvalid)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalid)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: token not yet valid", ErrInvalid)
+	}
+	if c.jwt.Issuer != "" && claims["iss"] != c.jwt.Issuer {
+		return nil, fmt.Errorf("%w: wrong issuer", ErrInvalid)
+	}
+	if c.jwt.Audience != "" && !hasAudience(claims["aud"], c.jwt.Audience) {
+		return nil, fmt.Errorf("%w: wrong audience", ErrInvalid)
+	}
+
+	sub, _ := claims["sub"].(string)
+	tenant, _ := claims[c.jwt.TenantClaim].(string)
+	return &Principal{Name: sub, Scopes: scopesOf(claims[c.jwt.ScopeClaim]), Tenant: tenant}, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// hasAudience reports whether an aud claim, a string or a list of them,
+// names audience.
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopesOf reads a scope claim: a space-separated string, as in OAuth 2.0,
+// or a list of strings.
+func scopesOf(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []interface{}:
+		var scopes []string
+		for _, s := range claim {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package budget estimates what a config costs to run on each platform it
+// targets: memory, CPU time per frame and draw calls, against the platform's
+// budgets. The estimates are rough, meant to tell designers early that a
+// config can't be feasible, not to replace profiling.
+package budget
+
+import (
+	"math"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+)
+
+// Resources an estimate may go over budget on.
+const (
+	ResourceMemory    = "memory"
+	ResourceCPU       = "cpu"
+	ResourceDrawCalls = "draw_calls"
+)
+
+// Estimate is a config's cost on one platform and the platform's budgets.
+type Estimate struct {
+	Platform       string
+	MemoryMB       float64
+	MemoryBudgetMB float64
+	// CPUMs is the time a frame takes; the budget is the frame time at the
+	// config's target FPS.
+	CPUMs          float64
+	CPUBudgetMs    float64
+	DrawCalls      uint32
+	DrawCallBudget uint32
+	// Over lists the resources over budget, in the order above.
+	Over []string
+}
+
+// Feasible reports whether e is within every budget.
+func (e Estimate) Feasible() bool {
+	return len(e.Over) == 0
+}
+
+// Model estimates costs. Embedders replace DefaultModel with their own, such
+// as one fitted to their engine's profiling data.
+type Model interface {
+	// Name identifies the model and its version in responses.
+	Name() string
+	// Estimate returns dna's cost on platform. Unset fields have been filled
+	// with the engine defaults.
+	Estimate(dna *pb.GameDNA, platform string) Estimate
+}
+
+// Coefficients are one platform's costs and budgets.
+type Coefficients struct {
+	BaseMemoryMB      float64 // engine, assets and everything not per entity
+	MemoryPerEntityKB float64
+	MemoryPerNPCKB    float64 // on top of the NPC's entity
+	MemoryBudgetMB    float64
+
+	BaseCPUMs      float64 // per frame
+	CPUPerEntityUs float64 // per frame
+	CPUPerNPCUs    float64 // per frame, for AI on top of the NPC's entity
+
+	BaseDrawCalls      float64
+	DrawCallsPerEntity float64 // per visible entity
+	DrawCallBudget     uint32
+	// FullViewDistance is the draw distance at which every entity is
+	// visible; shorter ones draw proportionally fewer.
+	FullViewDistance float64
+}
+
+// CoefficientModel estimates costs linearly from per-platform coefficients.
+type CoefficientModel struct {
+	Version string
+	// Platforms holds the coefficients by lowercased platform name.
+	Platforms map[string]Coefficients
+	// Default is used for platforms missing from Platforms.
+	Default Coefficients
+}
+
+// DefaultModel is used unless the server is given another model. Its
+// coefficients are ballpark figures for current hardware of each class.
+var DefaultModel = &CoefficientModel{
+	Version: "coefficients/1",
+	Platforms: map[string]Coefficients{
+		"pc": {
+			BaseMemoryMB: 2048, MemoryPerEntityKB: 64, MemoryPerNPCKB: 256, MemoryBudgetMB: 8192,
+			BaseCPUMs: 2, CPUPerEntityUs: 0.5, CPUPerNPCUs: 5,
+			BaseDrawCalls: 200, DrawCallsPerEntity: 0.25, DrawCallBudget: 5000, FullViewDistance: 1000,
+		},
+		"console": {
+			BaseMemoryMB: 2048, MemoryPerEntityKB: 64, MemoryPerNPCKB: 256, MemoryBudgetMB: 12288,
+			BaseCPUMs: 2.5, CPUPerEntityUs: 0.6, CPUPerNPCUs: 6,
+			BaseDrawCalls: 200, DrawCallsPerEntity: 0.25, DrawCallBudget: 4000, FullViewDistance: 1000,
+		},
+		"mobile": {
+			BaseMemoryMB: 512, MemoryPerEntityKB: 32, MemoryPerNPCKB: 128, MemoryBudgetMB: 2048,
+			BaseCPUMs: 4, CPUPerEntityUs: 2, CPUPerNPCUs: 20,
+			BaseDrawCalls: 50, DrawCallsPerEntity: 0.5, DrawCallBudget: 500, FullViewDistance: 500,
+		},
+		// VR renders each frame twice, once per eye.
+		"vr": {
+			BaseMemoryMB: 1536, MemoryPerEntityKB: 64, MemoryPerNPCKB: 256, MemoryBudgetMB: 6144,
+			BaseCPUMs: 3, CPUPerEntityUs: 0.8, CPUPerNPCUs: 8,
+			BaseDrawCalls: 200, DrawCallsPerEntity: 1, DrawCallBudget: 2000, FullViewDistance: 500,
+		},
+		"web": {
+			BaseMemoryMB: 256, MemoryPerEntityKB: 32, MemoryPerNPCKB: 128, MemoryBudgetMB: 1024,
+			BaseCPUMs: 5, CPUPerEntityUs: 3, CPUPerNPCUs: 30,
+			BaseDrawCalls: 50, DrawCallsPerEntity: 0.5, DrawCallBudget: 300, FullViewDistance: 500,
+		},
+	},
+	Default: Coefficients{
+		BaseMemoryMB: 512, MemoryPerEntityKB: 64, MemoryPerNPCKB: 256, MemoryBudgetMB: 2048,
+		BaseCPUMs: 4, CPUPerEntityUs: 2, CPUPerNPCUs: 20,
+		BaseDrawCalls: 100, DrawCallsPerEntity: 0.5, DrawCallBudget: 1000, FullViewDistance: 500,
+	},
+}
+
+// Name implements Model.
+func (m *CoefficientModel) Name() string {
+	return m.Version
+}
+
+// Estimate implements Model.
+func (m *CoefficientModel) Estimate(dna *pb.GameDNA, platform string) Estimate {
+	c, ok := m.Platforms[strings.ToLower(platform)]
+	if !ok {
+		c = m.Default
+	}
+	entities := float64(dna.MaxEntities)
+	npcs := float64(dna.MaxNpcCount)
+
+	visible := entities
+	if c.FullViewDistance > 0 && float64(dna.MaxDrawDistance) < c.FullViewDistance {
+		visible = entities * float64(dna.MaxDrawDistance) / c.FullViewDistance
+	}
+	e := Estimate{
+		Platform:       platform,
+		MemoryMB:       c.BaseMemoryMB + (entities*c.MemoryPerEntityKB+npcs*c.MemoryPerNPCKB)/1024,
+		MemoryBudgetMB: c.MemoryBudgetMB,
+		CPUMs:          c.BaseCPUMs + (entities*c.CPUPerEntityUs+npcs*c.CPUPerNPCUs)/1000,
+		DrawCalls:      uint32(math.Ceil(c.BaseDrawCalls + visible*c.DrawCallsPerEntity)),
+		DrawCallBudget: c.DrawCallBudget,
+	}
+	if dna.TargetFps > 0 {
+		e.CPUBudgetMs = 1000 / float64(dna.TargetFps)
+	}
+	if e.MemoryMB > e.MemoryBudgetMB {
+		e.Over = append(e.Over, ResourceMemory)
+	}
+	if e.CPUMs > e.CPUBudgetMs {
+		e.Over = append(e.Over, ResourceCPU)
+	}
+	if e.DrawCalls > e.DrawCallBudget {
+		e.Over = append(e.Over, ResourceDrawCalls)
+	}
+	return e
+}
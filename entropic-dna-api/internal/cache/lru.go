@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LRU is a Backend held in process memory, for single instances or when no
+// Redis is at hand. It keeps at most a fixed number of entries, evicting the
+// least recently used. Counters are kept apart and never evicted.
+type LRU struct {
+	max int
+	now func() time.Time
+
+	mu       sync.Mutex
+	order    *list.List
+	entries  map[string]*list.Element
+	counters map[string]int64
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU creates an LRU holding at most max entries.
+func NewLRU(max int) *LRU {
+	return &LRU{
+		max:      max,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		counters: make(map[string]int64),
+	}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n, ok := l.counters[key]; ok {
+		return []byte(strconv.FormatInt(n, 10)), true, nil
+	}
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*lruEntry)
+	if !l.now().Before(entry.expires) {
+		l.remove(el)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expires := l.now().Add(ttl)
+	if el, ok := l.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(el)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.order.Len() > l.max {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+func (l *LRU) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := l.entries[key]; ok {
+			l.remove(el)
+		}
+		delete(l.counters, key)
+	}
+	return nil
+}
+
+func (l *LRU) Incr(_ context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counters[key]++
+	return l.counters[key], nil
+}
+
+// Len returns the number of entries held, expired or not.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) Close() error { return nil }
+
+func (l *LRU) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.entries, el.Value.(*lruEntry).key)
+}
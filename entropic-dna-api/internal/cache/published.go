@@ -0,0 +1,298 @@
+// Package cache keeps configs close to the API so hot reads skip the
+// database: published configs in memory, so the burst of game-client reads
+// after a deploy is served without a database round trip per config, and
+// recent reads and lists in Redis or an in-process LRU.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+// ChecksumFunc computes the content checksum of a config.
+type ChecksumFunc func(*pb.GameDNA) (string, error)
+
+// WarmResult summarises one warm-up pass.
+type WarmResult struct {
+	// Loaded is the number of published configs now cached.
+	Loaded int
+	// Mismatched lists configs whose stored checksum doesn't match their
+	// content. They are left out of the cache and read from the store.
+	Mismatched []string
+}
+
+// PublishedStore is a storage.Store that serves Read and ReadBySlug for
+// published (locked) configs from memory. Published content only changes
+// through deletes, rollbacks and snapshot restores, which evict the affected
+// configs. Other instances' writes are picked up by the next Warm.
+type PublishedStore struct {
+	storage.Store
+	checksum ChecksumFunc
+
+	mu     sync.RWMutex
+	byID   map[string]*pb.GameDNA
+	bySlug map[string]string
+	// gen counts evictions so a read-through racing one doesn't re-add stale data.
+	gen uint64
+	// Evictions during a Warm scan, dropped from its result before the swap.
+	warming    bool
+	evicted    map[string]struct{}
+	evictedAll bool
+
+	// warmMu serialises Warm calls.
+	warmMu sync.Mutex
+}
+
+// NewPublishedStore wraps next with an empty published-config cache.
+// Configs are only cached when checksum confirms their stored checksum.
+func NewPublishedStore(next storage.Store, checksum ChecksumFunc) *PublishedStore {
+	return &PublishedStore{
+		Store:    next,
+		checksum: checksum,
+		byID:     make(map[string]*pb.GameDNA),
+		bySlug:   make(map[string]string),
+	}
+}
+
+// Warm replaces the cache with every published config whose checksum
+// verifies. It runs at startup and then on a schedule.
+func (c *PublishedStore) Warm(ctx context.Context) (WarmResult, error) {
+	c.warmMu.Lock()
+	defer c.warmMu.Unlock()
+
+	var result WarmResult
+	byID := make(map[string]*pb.GameDNA)
+	bySlug := make(map[string]string)
+
+	c.mu.Lock()
+	c.warming = true
+	c.evicted = make(map[string]struct{})
+	c.evictedAll = false
+	c.mu.Unlock()
+
+	err := c.Store.Each(ctx, storage.ListFilters{PublishedOnly: true}, func(dna *pb.GameDNA) error {
+		if !c.verified(dna) {
+			result.Mismatched = append(result.Mismatched, dna.Id)
+			return nil
+		}
+		dna = proto.Clone(dna).(*pb.GameDNA)
+		byID[dna.Id] = dna
+		if dna.Slug != "" {
+			bySlug[dna.Slug] = dna.Id
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warming = false
+	if err != nil {
+		return result, fmt.Errorf("failed to load published configs: %w", err)
+	}
+	// Configs changed during the scan may have been loaded stale; reads fill them in again.
+	if c.evictedAll {
+		byID = make(map[string]*pb.GameDNA)
+		bySlug = make(map[string]string)
+	}
+	for id := range c.evicted {
+		if dna, ok := byID[id]; ok {
+			delete(bySlug, dna.Slug)
+			delete(byID, id)
+		}
+	}
+	c.byID = byID
+	c.bySlug = bySlug
+	c.gen++
+	result.Loaded = len(byID)
+	metrics.SetCacheEntries(result.Loaded)
+	return result, nil
+}
+
+// Read serves published configs from the cache and reads through otherwise.
+func (c *PublishedStore) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	c.mu.RLock()
+	dna, ok := c.byID[id]
+	gen := c.gen
+	c.mu.RUnlock()
+	metrics.ObserveCacheRead(ok)
+	if ok {
+		return proto.Clone(dna).(*pb.GameDNA), nil
+	}
+
+	dna, err := c.Store.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.add(dna, gen)
+	return dna, nil
+}
+
+// ReadBySlug serves published configs from the cache and reads through otherwise.
+func (c *PublishedStore) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	c.mu.RLock()
+	dna, ok := c.byID[c.bySlug[slug]]
+	gen := c.gen
+	c.mu.RUnlock()
+	metrics.ObserveCacheRead(ok)
+	if ok {
+		return proto.Clone(dna).(*pb.GameDNA), nil
+	}
+
+	dna, err := c.Store.ReadBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	c.add(dna, gen)
+	return dna, nil
+}
+
+func (c *PublishedStore) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	defer c.evict(dna.Id)
+	return c.Store.Update(ctx, dna, opts...)
+}
+
+func (c *PublishedStore) Delete(ctx context.Context, id string) error {
+	defer c.evict(id)
+	return c.Store.Delete(ctx, id)
+}
+
+func (c *PublishedStore) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	defer c.evict(configID)
+	return c.Store.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (c *PublishedStore) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	defer c.evict(configID)
+	return c.Store.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (c *PublishedStore) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.evict(configID)
+	return c.Store.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (c *PublishedStore) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.evict(configID)
+	return c.Store.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (c *PublishedStore) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.evict(configID)
+	return c.Store.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (c *PublishedStore) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	defer c.evict(configID)
+	return c.Store.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (c *PublishedStore) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.evict(configID)
+	return c.Store.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (c *PublishedStore) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	defer c.evictAll()
+	return c.Store.SaveSet(ctx, set)
+}
+
+func (c *PublishedStore) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	defer c.evictAll()
+	return c.Store.ImportConfig(ctx, dna, versions)
+}
+
+func (c *PublishedStore) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	defer c.evictAll()
+	return c.Store.RestoreSnapshot(ctx, name, actor)
+}
+
+func (c *PublishedStore) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	if !dryRun {
+		defer c.evictAll()
+	}
+	return c.Store.TransferOwnership(ctx, from, to, actor, dryRun)
+}
+
+func (c *PublishedStore) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	defer c.evictAll()
+	return c.Store.AnonymizePrincipal(ctx, principal, pseudonym)
+}
+
+func (c *PublishedStore) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (int64, error) {
+	fixed, err := c.Store.FixChecksums(ctx, fixes)
+	for _, fix := range fixes {
+		c.evict(fix.ConfigID)
+	}
+	return fixed, err
+}
+
+// Len returns the number of cached configs.
+func (c *PublishedStore) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.byID)
+}
+
+// add caches dna if it is published, verified and nothing was evicted since
+// the read started at gen.
+func (c *PublishedStore) add(dna *pb.GameDNA, gen uint64) {
+	if !dna.IsLocked || !c.verified(dna) {
+		return
+	}
+	dna = proto.Clone(dna).(*pb.GameDNA)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return
+	}
+	c.byID[dna.Id] = dna
+	if dna.Slug != "" {
+		c.bySlug[dna.Slug] = dna.Id
+	}
+	metrics.SetCacheEntries(len(c.byID))
+}
+
+func (c *PublishedStore) verified(dna *pb.GameDNA) bool {
+	sum, err := c.checksum(dna)
+	return err == nil && sum == dna.Checksum
+}
+
+func (c *PublishedStore) evict(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if c.warming {
+		c.evicted[id] = struct{}{}
+	}
+	if dna, ok := c.byID[id]; ok {
+		delete(c.bySlug, dna.Slug)
+		delete(c.byID, id)
+	}
+	metrics.SetCacheEntries(len(c.byID))
+}
+
+// Evict drops config id from the cache, for changes made through other
+// instances sharing the store.
+func (c *PublishedStore) Evict(id string) {
+	c.evict(id)
+}
+
+func (c *PublishedStore) evictAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if c.warming {
+		c.evictedAll = true
+	}
+	c.byID = make(map[string]*pb.GameDNA)
+	c.bySlug = make(map[string]string)
+	metrics.SetCacheEntries(0)
+}
@@ -0,0 +1,332 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Backend holds the entries of a ReadStore. Implementations must be safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the value of key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are not an error.
+	Delete(ctx context.Context, keys ...string) error
+	// Incr adds one to the counter under key, starting from 0, and returns
+	// it. Counters never expire.
+	Incr(ctx context.Context, key string) (int64, error)
+	Close() error
+}
+
+// ReadOptions tune a ReadStore.
+type ReadOptions struct {
+	// Prefix starts every key, so deployments can share a Redis.
+	Prefix string
+	// ReadTTL is how long a config read is cached.
+	ReadTTL time.Duration
+	// ListTTL is how long a List page is cached; 0 leaves List uncached.
+	ListTTL time.Duration
+}
+
+// listGenKey holds the list generation. Every write bumps it, so cached
+// pages are never served after a write; they expire unread.
+const listGenKey = "list-gen"
+
+// ReadStore is a storage.Store that serves Read, ReadBySlug and List from a
+// Backend and reads through to the store on a miss. Writes made through it
+// delete the configs they touch and retire every cached List page. Writes
+// made elsewhere are seen once entries expire, or, with a shared Redis,
+// immediately when the other instance also caches through it.
+//
+// A read racing a write may cache the config as it was before the write,
+// until ReadTTL. Backend errors are counted and the store answers instead.
+type ReadStore struct {
+	storage.Store
+	backend Backend
+	opts    ReadOptions
+	logger  *zap.Logger
+}
+
+// NewReadStore caches reads of next in backend.
+func NewReadStore(next storage.Store, backend Backend, opts ReadOptions, logger *zap.Logger) *ReadStore {
+	return &ReadStore{Store: next, backend: backend, opts: opts, logger: logger}
+}
+
+func (c *ReadStore) idKey(id string) string     { return c.opts.Prefix + "dna:" + id }
+func (c *ReadStore) slugKey(slug string) string { return c.opts.Prefix + "slug:" + slug }
+
+func (c *ReadStore) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	if dna, ok := c.cached(ctx, id); ok {
+		metrics.ObserveReadCache("read", true)
+		return dna, nil
+	}
+	metrics.ObserveReadCache("read", false)
+	dna, err := c.Store.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.put(ctx, dna)
+	return dna, nil
+}
+
+// ReadBySlug maps the slug to an ID, then reads the config by ID. A mapping
+// left behind by a slug change is noticed because the config no longer
+// carries the slug.
+func (c *ReadStore) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	id, ok, err := c.backend.Get(ctx, c.slugKey(slug))
+	c.backendError("get", err)
+	if ok {
+		if dna, ok := c.cached(ctx, string(id)); ok && dna.Slug == slug {
+			metrics.ObserveReadCache("read", true)
+			return dna, nil
+		}
+	}
+	metrics.ObserveReadCache("read", false)
+	dna, err := c.Store.ReadBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	c.put(ctx, dna)
+	return dna, nil
+}
+
+func (c *ReadStore) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	if c.opts.ListTTL <= 0 {
+		return c.Store.List(ctx, filters, pagination)
+	}
+	key, ok := c.listKey(ctx, filters, pagination)
+	if ok {
+		data, found, err := c.backend.Get(ctx, key)
+		c.backendError("get", err)
+		if found {
+			page := &pb.ListGameDNAResponse{}
+			if err := proto.Unmarshal(data, page); err == nil {
+				metrics.ObserveReadCache("list", true)
+				return page.Items, page.Pagination.GetTotal(), nil
+			}
+		}
+	}
+	metrics.ObserveReadCache("list", false)
+	items, total, err := c.Store.List(ctx, filters, pagination)
+	if err != nil || !ok {
+		return items, total, err
+	}
+	data, err := proto.Marshal(&pb.ListGameDNAResponse{Items: items, Pagination: &pb.PaginationInfo{Total: total}})
+	if err == nil {
+		c.backendError("set", c.backend.Set(ctx, key, data, c.opts.ListTTL))
+	}
+	return items, total, nil
+}
+
+// listKey names the cached page for a List call in the current list
+// generation. It returns false when the generation can't be read, as the
+// page might then be from before a write.
+func (c *ReadStore) listKey(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) (string, bool) {
+	gen, found, err := c.backend.Get(ctx, c.opts.Prefix+listGenKey)
+	c.backendError("get", err)
+	if err != nil {
+		return "", false
+	}
+	if !found {
+		gen = []byte("0")
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v|%#v", filters, pagination)))
+	return c.opts.Prefix + "list:" + string(gen) + ":" + hex.EncodeToString(sum[:16]), true
+}
+
+// cached returns the cached config id, if any.
+func (c *ReadStore) cached(ctx context.Context, id string) (*pb.GameDNA, bool) {
+	data, found, err := c.backend.Get(ctx, c.idKey(id))
+	c.backendError("get", err)
+	if !found {
+		return nil, false
+	}
+	dna := &pb.GameDNA{}
+	if err := proto.Unmarshal(data, dna); err != nil {
+		return nil, false
+	}
+	return dna, true
+}
+
+// put caches dna by ID and maps its slug to the ID.
+func (c *ReadStore) put(ctx context.Context, dna *pb.GameDNA) {
+	data, err := proto.Marshal(dna)
+	if err != nil {
+		return
+	}
+	c.backendError("set", c.backend.Set(ctx, c.idKey(dna.Id), data, c.opts.ReadTTL))
+	if dna.Slug != "" {
+		c.backendError("set", c.backend.Set(ctx, c.slugKey(dna.Slug), []byte(dna.Id), c.opts.ReadTTL))
+	}
+}
+
+// invalidate deletes the cached configs ids and retires the cached List
+// pages. It runs with its own context, as a write whose caller has gone
+// away has still been made.
+func (c *ReadStore) invalidate(ids ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), invalidateTimeout)
+	defer cancel()
+	if len(ids) > 0 {
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = c.idKey(id)
+		}
+		if err := c.backend.Delete(ctx, keys...); err != nil {
+			metrics.ObserveReadCacheError()
+			c.logger.Warn("Failed to evict configs from the read cache; they may be served stale until they expire",
+				zap.Strings("config_ids", ids), zap.Error(err))
+		}
+	}
+	if _, err := c.backend.Incr(ctx, c.opts.Prefix+listGenKey); err != nil {
+		metrics.ObserveReadCacheError()
+		c.logger.Warn("Failed to retire cached list pages; they may be served stale until they expire", zap.Error(err))
+	}
+}
+
+// invalidateTimeout bounds evicting after a write.
+const invalidateTimeout = 2 * time.Second
+
+// Evict drops config id from the cache, for changes made through other
+// instances sharing the store.
+func (c *ReadStore) Evict(id string) {
+	c.invalidate(id)
+}
+
+func (c *ReadStore) backendError(op string, err error) {
+	if err == nil {
+		return
+	}
+	metrics.ObserveReadCacheError()
+	c.logger.Debug("Read cache "+op+" failed", zap.Error(err))
+}
+
+func (c *ReadStore) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	created, err := c.Store.Create(ctx, dna, opts...)
+	c.invalidate(configIDs(created)...)
+	return created, err
+}
+
+func (c *ReadStore) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	defer c.invalidate(dna.Id)
+	return c.Store.Update(ctx, dna, opts...)
+}
+
+func (c *ReadStore) Delete(ctx context.Context, id string) error {
+	defer c.invalidate(id)
+	return c.Store.Delete(ctx, id)
+}
+
+func (c *ReadStore) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	defer c.invalidate(configID)
+	return c.Store.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (c *ReadStore) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	defer c.invalidate(configID)
+	return c.Store.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (c *ReadStore) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.invalidate(configID)
+	return c.Store.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (c *ReadStore) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.invalidate(configID)
+	return c.Store.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (c *ReadStore) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.invalidate(configID)
+	return c.Store.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (c *ReadStore) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	defer c.invalidate(configID)
+	return c.Store.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (c *ReadStore) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	defer c.invalidate(configID)
+	return c.Store.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (c *ReadStore) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+	clone, err := c.Store.Clone(ctx, id, newName, actor)
+	c.invalidate(configIDs(clone)...)
+	return clone, err
+}
+
+func (c *ReadStore) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	saved, err := c.Store.SaveSet(ctx, set)
+	ids := configIDs(set.Updates...)
+	if saved != nil {
+		ids = append(ids, configIDs(saved.Creates...)...)
+	}
+	c.invalidate(ids...)
+	return saved, err
+}
+
+func (c *ReadStore) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	defer c.invalidate(dna.Id)
+	return c.Store.ImportConfig(ctx, dna, versions)
+}
+
+func (c *ReadStore) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	restored, err := c.Store.RestoreSnapshot(ctx, name, actor)
+	c.invalidate(configIDs(restored...)...)
+	return restored, err
+}
+
+func (c *ReadStore) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	moved, err := c.Store.TransferOwnership(ctx, from, to, actor, dryRun)
+	if moved != nil && !dryRun {
+		c.invalidate(configIDs(moved.Configs...)...)
+	}
+	return moved, err
+}
+
+func (c *ReadStore) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	data, err := c.Store.AnonymizePrincipal(ctx, principal, pseudonym)
+	var ids []string
+	if data != nil {
+		ids = configIDs(data.Configs...)
+		for _, v := range data.Versions {
+			ids = append(ids, v.ConfigID)
+		}
+	}
+	c.invalidate(ids...)
+	return data, err
+}
+
+func (c *ReadStore) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (int64, error) {
+	fixed, err := c.Store.FixChecksums(ctx, fixes)
+	ids := make([]string, len(fixes))
+	for i, fix := range fixes {
+		ids[i] = fix.ConfigID
+	}
+	c.invalidate(ids...)
+	return fixed, err
+}
+
+// configIDs returns the IDs of configs, skipping nil ones.
+func configIDs(configs ...*pb.GameDNA) []string {
+	ids := make([]string, 0, len(configs))
+	for _, dna := range configs {
+		if dna != nil && dna.Id != "" {
+			ids = append(ids, dna.Id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,91 @@
+// Package compression compresses large responses: gRPC ones with a
+// grpc-encoding the client accepts, REST ones by Accept-Encoding. Version
+// history and export responses run to megabytes of JSON that compress about
+// tenfold; small responses are left alone, where compressing costs more than
+// it saves.
+package compression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Algorithms a Policy may use.
+const (
+	Zstd = "zstd"
+	Gzip = "gzip"
+)
+
+// DefaultAlgorithms are used when Options leaves them unset, most preferred
+// first.
+var DefaultAlgorithms = []string{Zstd, Gzip}
+
+// Options configures a Policy.
+type Options struct {
+	// MinSize is the smallest response, in bytes, that is compressed.
+	MinSize int
+	// Algorithms are the ones offered, most preferred first. The first one a
+	// client accepts is used.
+	Algorithms []string
+}
+
+// Policy decides whether and how each response is compressed.
+type Policy struct {
+	minSize    int
+	algorithms []string
+}
+
+// New creates a Policy, rejecting unknown algorithms.
+func New(opts Options) (*Policy, error) {
+	if opts.MinSize < 0 {
+		return nil, fmt.Errorf("compression min size cannot be negative")
+	}
+	algorithms := opts.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultAlgorithms
+	}
+	seen := make(map[string]bool)
+	for _, name := range algorithms {
+		if name != Zstd && name != Gzip {
+			return nil, fmt.Errorf("unknown compression algorithm %q: want %s or %s", name, Zstd, Gzip)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("compression algorithm %s is listed more than once", name)
+		}
+		seen[name] = true
+	}
+	return &Policy{minSize: opts.MinSize, algorithms: algorithms}, nil
+}
+
+// choose returns the most preferred algorithm accepts allows, or "" for none.
+func (p *Policy) choose(accepts func(name string) bool) string {
+	for _, name := range p.algorithms {
+		if accepts(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// acceptEncoding returns whether an Accept-Encoding header allows name, by
+// name or "*", with a non-zero quality.
+func acceptEncoding(header string) func(name string) bool {
+	qualities := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		allowed := true
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			allowed = strings.Trim(strings.TrimSpace(q), "0.") != ""
+		}
+		if coding != "" {
+			qualities[coding] = allowed
+		}
+	}
+	return func(name string) bool {
+		if allowed, ok := qualities[name]; ok {
+			return allowed
+		}
+		return qualities["*"]
+	}
+}
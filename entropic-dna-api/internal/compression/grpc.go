@@ -0,0 +1,138 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // registers gzip
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// uncompressedMetadata asks the server for uncompressed responses. The REST
+// gateway sends it, as it would only decompress them again before compressing
+// for its own client.
+const uncompressedMetadata = "x-uncompressed-responses"
+
+// maxDecodedSize bounds a zstd message decompressed, so a small request can't
+// expand to exhaust memory. gRPC's own message size limit is far lower.
+const maxDecodedSize = 64 << 20
+
+// zstdEncoder and zstdDecoder compress whole messages, which is safe from any
+// number of goroutines.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedSize))
+)
+
+func init() {
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+// zstdCompressor is the gRPC zstd compressor. Importing this package
+// registers it, so clients built with it accept zstd responses.
+type zstdCompressor struct{}
+
+func (zstdCompressor) Name() string {
+	return Zstd
+}
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdMessageWriter{w: w}, nil
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out, err := zstdDecoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
+// zstdMessageWriter collects a message and compresses it on Close.
+type zstdMessageWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (z *zstdMessageWriter) Write(p []byte) (int, error) {
+	return z.buf.Write(p)
+}
+
+func (z *zstdMessageWriter) Close() error {
+	_, err := z.w.Write(zstdEncoder.EncodeAll(z.buf.Bytes(), nil))
+	return err
+}
+
+// UnaryInterceptor compresses responses of at least the minimum size with
+// the preferred algorithm the client accepts, and sends smaller ones
+// uncompressed, whatever the request was sent with.
+func (p *Policy) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		size := 0
+		if msg, ok := resp.(proto.Message); ok {
+			size = proto.Size(msg)
+		}
+		p.setSendCompressor(ctx, size)
+	}
+	return resp, err
+}
+
+// StreamInterceptor decides on a stream's compression by its first message,
+// as it can't change once the headers are sent: export chunks are compressed,
+// small change events are not.
+func (p *Policy) StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &compressedStream{ServerStream: ss, policy: p})
+}
+
+type compressedStream struct {
+	grpc.ServerStream
+	policy  *Policy
+	decided bool
+}
+
+func (s *compressedStream) SendMsg(m interface{}) error {
+	if !s.decided {
+		s.decided = true
+		size := 0
+		if msg, ok := m.(proto.Message); ok {
+			size = proto.Size(msg)
+		}
+		s.policy.setSendCompressor(s.Context(), size)
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// setSendCompressor picks the compression of a call's responses. Errors mean
+// the headers are out already, and the responses go as they would have.
+func (p *Policy) setSendCompressor(ctx context.Context, size int) {
+	name := encoding.Identity
+	if size >= p.minSize && len(metadata.ValueFromIncomingContext(ctx, uncompressedMetadata)) == 0 {
+		advertised, _ := grpc.ClientSupportedCompressors(ctx)
+		if chosen := p.choose(func(name string) bool { return slices.Contains(advertised, name) }); chosen != "" {
+			name = chosen
+		}
+	}
+	_ = grpc.SetSendCompressor(ctx, name)
+}
+
+// UnaryClientInterceptor asks the server for uncompressed responses, for
+// clients on the same host as it, such as the REST gateway.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, uncompressedMetadata, "1"), method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streams.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(metadata.AppendToOutgoingContext(ctx, uncompressedMetadata, "1"), desc, cc, method, opts...)
+}
@@ -0,0 +1,194 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// incompressibleTypes are content types compressed already.
+var incompressibleTypes = map[string]bool{
+	"application/zip":  true,
+	"application/gzip": true,
+	"application/zstd": true,
+}
+
+// Encoders are reused across responses, as zstd's in particular allocate
+// their window up front.
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	zstdWriters = sync.Pool{New: func() interface{} {
+		// One goroutine per response encodes synchronously, so flushes of
+		// event streams go out at once.
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// flushWriteCloser is a streaming encoder.
+type flushWriteCloser interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newEncoder returns an encoder for name writing to w, and a func returning
+// it to its pool once closed.
+func newEncoder(name string, w io.Writer) (flushWriteCloser, func()) {
+	if name == Zstd {
+		enc := zstdWriters.Get().(*zstd.Encoder)
+		enc.Reset(w)
+		return enc, func() { zstdWriters.Put(enc) }
+	}
+	enc := gzipWriters.Get().(*gzip.Writer)
+	enc.Reset(w)
+	return enc, func() { gzipWriters.Put(enc) }
+}
+
+// Handler compresses responses of next by the request's Accept-Encoding.
+// Bodies are held until they reach the minimum size, and sent uncompressed if
+// they end first; a flush, as event streams make, compresses from then on.
+// Responses that set a Content-Encoding or are compressed archives already
+// pass through.
+func (p *Policy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		name := p.choose(acceptEncoding(r.Header.Get("Accept-Encoding")))
+		if name == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, name: name, minSize: p.minSize}
+		next.ServeHTTP(cw, r)
+		// Not deferred: a handler that panics, as an aborted download does,
+		// must not have its body ended as if it were complete.
+		cw.close()
+	})
+}
+
+// compressWriter buffers a response until it knows whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	name    string
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     flushWriteCloser
+	release func()
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	// Informational responses go out as they are; the final one waits.
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if !w.compressible() {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, p...)
+			if len(w.buf) >= w.minSize {
+				w.decide(true)
+				if err := w.flushBuffer(); err != nil {
+					return 0, err
+				}
+			}
+			return len(p), nil
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.compressible())
+		_ = w.flushBuffer()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection's writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether the response as its headers stand may be
+// compressed.
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return !incompressibleTypes[mediaType]
+}
+
+// decide sends the headers, compressed or not.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.name)
+		w.enc, w.release = newEncoder(w.name, w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// flushBuffer writes what was held back while deciding.
+func (w *compressWriter) flushBuffer() error {
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close ends the response: one under the minimum size is sent as it is, and
+// a compressed one gets its final frame.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+		_ = w.flushBuffer()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.release()
+	}
+}
@@ -0,0 +1,189 @@
+// Package diff compares GameDNA configs field by field and renders the result
+// for humans.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Change is one field whose value differs between two configs.
+type Change struct {
+	Field   string `json:"field"`
+	Section string `json:"section"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Risky   bool   `json:"risky,omitempty"`
+}
+
+// ignoredFields change on every write and carry no review value.
+var ignoredFields = map[protoreflect.Name]bool{
+	"id":            true,
+	"created_at":    true,
+	"last_modified": true,
+	"create_time":   true,
+	"update_time":   true,
+	"created_by":    true,
+	"checksum":      true,
+}
+
+// identityFields tell two configs apart rather than describe the game, so
+// comparing different configs skips them.
+var identityFields = map[protoreflect.Name]bool{
+	"name":                    true,
+	"slug":                    true,
+	"version":                 true,
+	"is_locked":               true,
+	"published_rules_version": true,
+	"parent_id":               true,
+	"environment":             true,
+	"promoted_from":           true,
+}
+
+// riskyFields affect players, certification or revenue and deserve a closer look.
+var riskyFields = map[protoreflect.Name]bool{
+	"is_locked":        true,
+	"target_platforms": true,
+	"max_players":      true,
+	"is_competitive":   true,
+	"monetization":     true,
+	"target_audience":  true,
+	"esrb_rating":      true,
+	"target_fps":       true,
+	"max_entities":     true,
+	"persistent_world": true,
+}
+
+// Sections in display order, matching the grouping in messages.proto.
+var sections = []string{
+	"Basic metadata",
+	"Core configuration",
+	"Gameplay",
+	"Monetization and business",
+	"Performance",
+	"World simulation",
+	"AI and NPCs",
+	"Narrative",
+	"Tags and custom properties",
+}
+
+// sectionOf maps a GameDNA field number to its section.
+func sectionOf(num protoreflect.FieldNumber) string {
+	switch {
+	case num <= 8, num == 39, num == 40:
+		return sections[0]
+	case num <= 13:
+		return sections[1]
+	case num <= 18:
+		return sections[2]
+	case num <= 21:
+		return sections[3]
+	case num <= 25:
+		return sections[4]
+	case num <= 30:
+		return sections[5]
+	case num <= 33:
+		return sections[6]
+	case num <= 36:
+		return sections[7]
+	default:
+		return sections[8]
+	}
+}
+
+// Compare returns the fields that differ from old to new, in section order.
+func Compare(old, new *pb.GameDNA) []Change {
+	return compare(old, new, nil)
+}
+
+// CompareConfigs returns the fields that differ between two distinct
+// configs, such as the variants of a game for two platforms, in section
+// order. Old is left's value and New right's. Fields identifying the configs,
+// like name and slug, are skipped.
+func CompareConfigs(left, right *pb.GameDNA) []Change {
+	return compare(left, right, identityFields)
+}
+
+func compare(old, new *pb.GameDNA, skip map[protoreflect.Name]bool) []Change {
+	oldMsg := old.ProtoReflect()
+	newMsg := new.ProtoReflect()
+	fields := oldMsg.Descriptor().Fields()
+
+	var changes []Change
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if ignoredFields[fd.Name()] || skip[fd.Name()] {
+			continue
+		}
+		before := formatValue(fd, oldMsg.Get(fd))
+		after := formatValue(fd, newMsg.Get(fd))
+		if before == after {
+			continue
+		}
+		changes = append(changes, Change{
+			Field:   string(fd.Name()),
+			Section: sectionOf(fd.Number()),
+			Old:     before,
+			New:     after,
+			Risky:   riskyFields[fd.Name()],
+		})
+	}
+
+	order := make(map[string]int, len(sections))
+	for i, name := range sections {
+		order[name] = i
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return order[changes[i].Section] < order[changes[j].Section]
+	})
+	return changes
+}
+
+// Changes is Compare, but never nil, so callers can tell "nothing changed"
+// from "not recorded" as with ChangedFields.
+func Changes(old, new *pb.GameDNA) []Change {
+	changes := Compare(old, new)
+	if changes == nil {
+		changes = []Change{}
+	}
+	return changes
+}
+
+// ChangedFields returns the names of the fields that differ from old to new,
+// in section order. A nil old compares against an empty config. The result is
+// never nil, so callers can tell "nothing changed" from "not recorded".
+func ChangedFields(old, new *pb.GameDNA) []string {
+	changes := Compare(old, new)
+	fields := make([]string, 0, len(changes))
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	return fields
+}
+
+// formatValue renders a field value as a stable, human-readable string.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		items := make([]string, list.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(list.Get(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case fd.IsMap():
+		var items []string
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			items = append(items, fmt.Sprintf("%v=%v", k.Interface(), mv.Interface()))
+			return true
+		})
+		sort.Strings(items)
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// labels name a rendering's value columns and what its changes are.
+type labels struct {
+	old, new string
+	changed  string // e.g. "changed", as in "2 risky field(s) changed"
+	none     string // shown when there are no changes
+}
+
+var versionLabels = labels{old: "Before", new: "After", changed: "changed", none: "No changes."}
+
+// Render formats changes for review tools, chat notifications and commit
+// bodies. FormatJSON renders nothing since callers return the structured
+// changes instead. It returns the rendered text and its content type.
+func Render(format, title string, changes []Change) (string, string, error) {
+	return render(format, title, versionLabels, changes)
+}
+
+// RenderComparison is Render for the changes between two configs, from
+// CompareConfigs, with columns named after them.
+func RenderComparison(format, title, left, right string, changes []Change) (string, string, error) {
+	return render(format, title, labels{old: left, new: right, changed: "differ", none: "No differences."}, changes)
+}
+
+func render(format, title string, l labels, changes []Change) (string, string, error) {
+	switch format {
+	case "", FormatJSON:
+		return "", "application/json", nil
+	case FormatMarkdown:
+		return renderMarkdown(title, l, changes), "text/markdown; charset=utf-8", nil
+	case FormatHTML:
+		return renderHTML(title, l, changes), "text/html; charset=utf-8", nil
+	default:
+		return "", "", fmt.Errorf("unsupported diff format %q (want json, markdown or html)", format)
+	}
+}
+
+func renderMarkdown(title string, l labels, changes []Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", title)
+	if len(changes) == 0 {
+		b.WriteString(l.none + "\n")
+		return b.String()
+	}
+	if n := countRisky(changes); n > 0 {
+		fmt.Fprintf(&b, "> **%d risky field(s) %s.** Review them carefully.\n\n", n, l.changed)
+	}
+
+	section := ""
+	for _, c := range changes {
+		if c.Section != section {
+			if section != "" {
+				b.WriteString("\n")
+			}
+			section = c.Section
+			fmt.Fprintf(&b, "#### %s\n\n| Field | %s | %s |\n|---|---|---|\n", section, markdownHeader(l.old), markdownHeader(l.new))
+		}
+		field := "`" + c.Field + "`"
+		if c.Risky {
+			field = "⚠️ **" + field + "**"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", field, markdownCell(c.Old), markdownCell(c.New))
+	}
+	return b.String()
+}
+
+func renderHTML(title string, l labels, changes []Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h3>%s</h3>\n", html.EscapeString(title))
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(l.none))
+		return b.String()
+	}
+	if n := countRisky(changes); n > 0 {
+		fmt.Fprintf(&b, "<p class=\"diff-warning\"><strong>%d risky field(s) %s.</strong> Review them carefully.</p>\n", n, l.changed)
+	}
+
+	section := ""
+	for _, c := range changes {
+		if c.Section != section {
+			if section != "" {
+				b.WriteString("</tbody></table>\n")
+			}
+			section = c.Section
+			fmt.Fprintf(&b, "<h4>%s</h4>\n<table class=\"diff\"><thead><tr><th>Field</th><th>%s</th><th>%s</th></tr></thead><tbody>\n",
+				html.EscapeString(section), html.EscapeString(l.old), html.EscapeString(l.new))
+		}
+		class := ""
+		if c.Risky {
+			class = ` class="risky"`
+		}
+		fmt.Fprintf(&b, "<tr%s><td><code>%s</code></td><td><del>%s</del></td><td><ins>%s</ins></td></tr>\n",
+			class, html.EscapeString(c.Field), html.EscapeString(c.Old), html.EscapeString(c.New))
+	}
+	b.WriteString("</tbody></table>\n")
+	return b.String()
+}
+
+func countRisky(changes []Change) int {
+	n := 0
+	for _, c := range changes {
+		if c.Risky {
+			n++
+		}
+	}
+	return n
+}
+
+// markdownHeader keeps a column name from breaking the table layout.
+func markdownHeader(v string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(v, "|", "\\|"), "\n", " ")
+}
+
+// markdownCell keeps a value from breaking the table layout.
+func markdownCell(v string) string {
+	if v == "" {
+		return "_empty_"
+	}
+	v = strings.ReplaceAll(v, "|", "\\|")
+	v = strings.ReplaceAll(v, "\n", " ")
+	return "`" + v + "`"
+}
@@ -0,0 +1,358 @@
+package dnactl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// importChunkSize is how much of an archive each ImportGameDNA message
+// carries.
+const importChunkSize = 1 << 20
+
+// stringList is a flag that may be repeated or given comma-separated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func createCommand() *command {
+	var file string
+	return &command{
+		usage:   "create -f FILE",
+		summary: "Create a config from a YAML or JSON file",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&file, "f", "", "config file, YAML or JSON; - reads stdin")
+		},
+		run: func(ctx context.Context, c *client, _ []string) error {
+			dna, err := readGameDNA(file, c.io.Stdin)
+			if err != nil {
+				return err
+			}
+			resp, err := c.svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+			if err != nil {
+				return fmt.Errorf("create failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func getCommand() *command {
+	var releaseTag, defaults string
+	return &command{
+		usage:   "get ID|SLUG",
+		summary: "Show a config, or the version a release tag names",
+		args:    [2]int{1, 1},
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&releaseTag, "release-tag", "", "read the version this release tag names")
+			fs.StringVar(&defaults, "defaults", "", "raw or materialized; empty uses the server default")
+		},
+		run: func(ctx context.Context, c *client, args []string) error {
+			resp, err := c.svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: args[0], ReleaseTag: releaseTag, Defaults: defaults})
+			if err != nil {
+				return fmt.Errorf("get failed: %w", err)
+			}
+			if c.opts.output == outputJSON {
+				return c.printJSON(resp)
+			}
+			return printDetail(c.io.Stdout, resp.GameDna, resp.VersionNum)
+		},
+	}
+}
+
+func listCommand() *command {
+	var tags stringList
+	var genre, name, env string
+	var pageSize int
+	var all bool
+	return &command{
+		usage:   "list [flags]",
+		summary: "List configs",
+		flags: func(fs *flag.FlagSet) {
+			fs.Var(&tags, "tag", "only configs with this tag; repeatable")
+			fs.StringVar(&genre, "genre", "", "only configs of this genre")
+			fs.StringVar(&name, "name", "", "only configs whose name contains this")
+			fs.StringVar(&env, "env", "", "only configs serving this environment: dev, staging or prod")
+			fs.IntVar(&pageSize, "page-size", 0, "configs per page; 0 uses the server default")
+			fs.BoolVar(&all, "all", false, "follow next_page_token through every page")
+		},
+		run: func(ctx context.Context, c *client, _ []string) error {
+			req := &pb.ListGameDNARequest{Tags: tags, Genre: genre, NameFilter: name, Environment: env, PageSize: int32(pageSize)}
+			var items []*pb.GameDNA
+			var last *pb.ListGameDNAResponse
+			for {
+				resp, err := c.svc.ListGameDNA(ctx, req)
+				if err != nil {
+					return fmt.Errorf("list failed: %w", err)
+				}
+				items = append(items, resp.Items...)
+				last = resp
+				if !all || resp.NextPageToken == "" {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+			if c.opts.output == outputJSON {
+				last.Items = items
+				if all {
+					last.NextPageToken = ""
+				}
+				return c.printJSON(last)
+			}
+			if err := printConfigTable(c.io.Stdout, items); err != nil {
+				return err
+			}
+			if last.NextPageToken != "" {
+				fmt.Fprintf(c.io.Stderr, "More configs exist; pass -all to list every page.\n")
+			}
+			return nil
+		},
+	}
+}
+
+func updateCommand() *command {
+	var file string
+	var fields stringList
+	return &command{
+		usage:   "update ID -f FILE",
+		summary: "Replace a config, or some of its fields, from a YAML or JSON file",
+		args:    [2]int{1, 1},
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&file, "f", "", "config file, YAML or JSON; - reads stdin")
+			fs.Var(&fields, "field", "only replace this top-level field; repeatable")
+		},
+		run: func(ctx context.Context, c *client, args []string) error {
+			dna, err := readGameDNA(file, c.io.Stdin)
+			if err != nil {
+				return err
+			}
+			req := &pb.UpdateGameDNARequest{Id: args[0], GameDna: dna}
+			if len(fields) > 0 {
+				req.UpdateMask = &fieldmaskpb.FieldMask{Paths: fields}
+			}
+			resp, err := c.svc.UpdateGameDNA(ctx, req)
+			if err != nil {
+				return fmt.Errorf("update failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func publishCommand() *command {
+	return &command{
+		usage:   "publish ID",
+		summary: "Validate and publish a config",
+		args:    [2]int{1, 1},
+		run: func(ctx context.Context, c *client, args []string) error {
+			resp, err := c.svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: args[0]})
+			if err != nil {
+				return fmt.Errorf("publish failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func promoteCommand() *command {
+	var env, approvedBy, reason string
+	var version int64
+	return &command{
+		usage:   "promote ID -approved-by NAME",
+		summary: "Publish a config's published content in the next environment",
+		args:    [2]int{1, 1},
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&env, "env", "", "environment to promote to; default the next one")
+			fs.Int64Var(&version, "version", 0, "published version to promote; 0 for the current content")
+			fs.StringVar(&approvedBy, "approved-by", "", "who approved the promotion")
+			fs.StringVar(&reason, "reason", "", "why it is promoted")
+		},
+		run: func(ctx context.Context, c *client, args []string) error {
+			if approvedBy == "" {
+				return errors.New("-approved-by is required")
+			}
+			resp, err := c.svc.PromoteGameDNA(ctx, &pb.PromoteGameDNARequest{
+				Id:                args[0],
+				TargetEnvironment: env,
+				VersionNum:        version,
+				ApprovedBy:        approvedBy,
+				Reason:            reason,
+			})
+			if err != nil {
+				return fmt.Errorf("promote failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func rollbackCommand() *command {
+	return &command{
+		usage:   "rollback ID VERSION",
+		summary: "Roll a config back to an earlier version",
+		args:    [2]int{2, 2},
+		run: func(ctx context.Context, c *client, args []string) error {
+			version, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || version <= 0 {
+				return fmt.Errorf("invalid version %q", args[1])
+			}
+			resp, err := c.svc.RollbackToVersion(ctx, &pb.RollbackToVersionRequest{ConfigId: args[0], VersionNum: version})
+			if err != nil {
+				return fmt.Errorf("rollback failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func cloneCommand() *command {
+	var name string
+	return &command{
+		usage:   "clone ID -name NAME",
+		summary: "Copy a config under a new name",
+		args:    [2]int{1, 1},
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&name, "name", "", "name of the copy")
+		},
+		run: func(ctx context.Context, c *client, args []string) error {
+			if name == "" {
+				return errors.New("-name is required")
+			}
+			resp, err := c.svc.CloneGameDNA(ctx, &pb.CloneGameDNARequest{Id: args[0], NewName: name})
+			if err != nil {
+				return fmt.Errorf("clone failed: %w", err)
+			}
+			return c.printConfig(resp, resp.GameDna, resp.Message)
+		},
+	}
+}
+
+func exportCommand() *command {
+	var out, format, genre, name string
+	var tags stringList
+	var history bool
+	return &command{
+		usage:   "export [flags] [ID...]",
+		summary: "Export configs as an NDJSON or zip archive",
+		args:    [2]int{0, -1},
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&out, "out", "-", "file to write the archive to; - writes stdout")
+			fs.StringVar(&format, "format", "ndjson", "ndjson or zip")
+			fs.Var(&tags, "tag", "only configs with this tag; repeatable")
+			fs.StringVar(&genre, "genre", "", "only configs of this genre")
+			fs.StringVar(&name, "name", "", "only configs whose name contains this")
+			fs.BoolVar(&history, "history", false, "include every stored version")
+		},
+		run: func(ctx context.Context, c *client, args []string) error {
+			stream, err := c.svc.ExportGameDNA(ctx, &pb.ExportGameDNARequest{
+				Ids:            args,
+				Tags:           tags,
+				Genre:          genre,
+				NameFilter:     name,
+				Format:         format,
+				IncludeHistory: history,
+			})
+			if err != nil {
+				return fmt.Errorf("export failed: %w", err)
+			}
+			w := c.io.Stdout
+			var f *os.File
+			if out != "-" {
+				if f, err = os.Create(out); err != nil {
+					return fmt.Errorf("failed to create %s: %w", out, err)
+				}
+				defer f.Close()
+				w = f
+			}
+			var written int
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("export failed: %w", err)
+				}
+				n, err := w.Write(chunk.Data)
+				written += n
+				if err != nil {
+					return fmt.Errorf("failed to write archive: %w", err)
+				}
+			}
+			if f != nil {
+				if err := f.Sync(); err != nil {
+					return fmt.Errorf("failed to write %s: %w", out, err)
+				}
+				fmt.Fprintf(c.io.Stderr, "Wrote %d bytes to %s\n", written, out)
+			}
+			return nil
+		},
+	}
+}
+
+func importCommand() *command {
+	var file, format string
+	var overwrite, dryRun bool
+	return &command{
+		usage:   "import -f FILE [flags]",
+		summary: "Restore configs from an export archive",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&file, "f", "", "archive written by export; - reads stdin")
+			fs.StringVar(&format, "format", "", "ndjson or zip; detected when empty")
+			fs.BoolVar(&overwrite, "overwrite", false, "replace configs that already exist")
+			fs.BoolVar(&dryRun, "dry-run", false, "report what would happen without writing")
+		},
+		run: func(ctx context.Context, c *client, _ []string) error {
+			data, err := readInput(file, c.io.Stdin)
+			if err != nil {
+				return err
+			}
+			stream, err := c.svc.ImportGameDNA(ctx)
+			if err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+			first := true
+			for first || len(data) > 0 {
+				n := min(len(data), importChunkSize)
+				req := &pb.ImportGameDNARequest{Data: data[:n]}
+				if first {
+					req.Format, req.Overwrite, req.DryRun = format, overwrite, dryRun
+					first = false
+				}
+				if err := stream.Send(req); err != nil {
+					break // the server's reason arrives with CloseAndRecv
+				}
+				data = data[n:]
+			}
+			resp, err := stream.CloseAndRecv()
+			if err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+			if c.opts.output == outputJSON {
+				return c.printJSON(resp)
+			}
+			if err := printImportTable(c.io.Stdout, resp.Results); err != nil {
+				return err
+			}
+			fmt.Fprintln(c.io.Stderr, resp.Message)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,223 @@
+// Package dnactl is the command-line client behind cmd/dnactl. It talks to
+// the gRPC API so operators don't have to hand-write grpcurl invocations.
+package dnactl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	sdk "github.com/entropic-engine/entropic-dna-api/pkg/client"
+)
+
+// Defaults for the global options; each can also come from the environment.
+const (
+	defaultAddr    = "localhost:50051"
+	defaultTimeout = 30 * time.Second
+)
+
+// Environment variables read for the global options.
+const (
+	envAddr   = "DNACTL_ADDR"
+	envAPIKey = "DNACTL_API_KEY"
+	envToken  = "DNACTL_TOKEN"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// errUsage reports a bad command line; the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+// IO is where a command reads input and writes output.
+type IO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// options are the flags every command accepts.
+type options struct {
+	addr    string
+	apiKey  string
+	token   string
+	timeout time.Duration
+	output  string
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.addr, "addr", envOr(envAddr, defaultAddr), "gRPC address of the API ($"+envAddr+")")
+	fs.StringVar(&o.apiKey, "api-key", os.Getenv(envAPIKey), "API key ($"+envAPIKey+")")
+	fs.StringVar(&o.token, "token", os.Getenv(envToken), "bearer token ($"+envToken+")")
+	fs.DurationVar(&o.timeout, "timeout", defaultTimeout, "deadline for the whole command")
+	fs.StringVar(&o.output, "o", outputTable, "output format: table or json")
+}
+
+// command is one dnactl subcommand.
+type command struct {
+	usage   string
+	summary string
+	// flags registers the command's own flags
+	flags func(fs *flag.FlagSet)
+	// args is how many positional arguments it takes: [min, max], max -1 for
+	// any number
+	args [2]int
+	run  func(ctx context.Context, c *client, args []string) error
+}
+
+var commands = map[string]*command{
+	"create":   createCommand(),
+	"get":      getCommand(),
+	"list":     listCommand(),
+	"update":   updateCommand(),
+	"publish":  publishCommand(),
+	"promote":  promoteCommand(),
+	"rollback": rollbackCommand(),
+	"clone":    cloneCommand(),
+	"export":   exportCommand(),
+	"import":   importCommand(),
+}
+
+// Run runs the command line args (without the program name) and returns the
+// process exit code.
+func Run(ctx context.Context, args []string, stdio IO) int {
+	if err := run(ctx, args, stdio); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stdio.Stderr, "Error: %v\n", err)
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func run(ctx context.Context, args []string, stdio IO) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdio.Stderr)
+		if len(args) == 0 {
+			return errUsage
+		}
+		return nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stdio.Stderr, "dnactl: unknown command %q\n\n", args[0])
+		printUsage(stdio.Stderr)
+		return errUsage
+	}
+
+	var opts options
+	fs := flag.NewFlagSet("dnactl "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stdio.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stdio.Stderr, "Usage: dnactl %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if len(positional) < cmd.args[0] || (cmd.args[1] >= 0 && len(positional) > cmd.args[1]) {
+		fs.Usage()
+		return errUsage
+	}
+	if opts.output != outputTable && opts.output != outputJSON {
+		fmt.Fprintf(stdio.Stderr, "dnactl: unknown output format %q; want table or json\n", opts.output)
+		return errUsage
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+	c, err := dial(ctx, opts, stdio)
+	if err != nil {
+		return err
+	}
+	defer c.sdk.Close()
+	return cmd.run(ctx, c, positional)
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional
+// arguments as in "dnactl get my-config -o json", and returns the
+// positional arguments. "--" ends the flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// flag.Parse consumed a "--" terminator if rest is shorter than
+		// what followed the last flag; everything left is positional
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "dnactl manages game configurations through the Entropic DNA gRPC API.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage: dnactl <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"dnactl <command> -h\" for the command's flags.")
+}
+
+// client is a connection to the API and the options it was made with.
+type client struct {
+	sdk  *sdk.Client
+	svc  pb.GameDNAServiceClient
+	opts options
+	io   IO
+}
+
+func dial(ctx context.Context, opts options, stdio IO) (*client, error) {
+	c, err := sdk.Dial(ctx, opts.addr, sdk.Options{APIKey: opts.apiKey, Token: opts.token})
+	if err != nil {
+		return nil, err
+	}
+	return &client{sdk: c, svc: c.Service(), opts: opts, io: stdio}, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,156 @@
+package dnactl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// readInput reads path, or stdin for "-".
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	switch path {
+	case "":
+		return nil, errors.New("-f is required")
+	case "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// readGameDNA reads a config from a YAML or JSON file. Field names may be
+// the proto names (target_fps) or their JSON names (targetFps), as in the
+// REST API.
+func readGameDNA(path string, stdin io.Reader) (*pb.GameDNA, error) {
+	data, err := readInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	// YAML is a superset of JSON, so one decoder reads both
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("failed to parse %s: want a mapping of config fields", path)
+	}
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var dna pb.GameDNA
+	if err := protojson.Unmarshal(jsonData, &dna); err != nil {
+		return nil, fmt.Errorf("invalid config in %s: %w", path, err)
+	}
+	return &dna, nil
+}
+
+// printJSON writes msg as indented protojson, as the REST API would.
+func (c *client) printJSON(msg proto.Message) error {
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	_, err = fmt.Fprintf(c.io.Stdout, "%s\n", data)
+	return err
+}
+
+// printConfig writes the response of a call returning one config: the whole
+// response as JSON, or the config as a table row followed by the message.
+func (c *client) printConfig(resp proto.Message, dna *pb.GameDNA, msg string) error {
+	if c.opts.output == outputJSON {
+		return c.printJSON(resp)
+	}
+	if err := printConfigTable(c.io.Stdout, []*pb.GameDNA{dna}); err != nil {
+		return err
+	}
+	if msg != "" {
+		fmt.Fprintln(c.io.Stderr, msg)
+	}
+	return nil
+}
+
+func printConfigTable(w io.Writer, items []*pb.GameDNA) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSLUG\tNAME\tGENRE\tPUBLISHED\tUPDATED")
+	for _, dna := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n",
+			dna.Id, dna.Slug, dna.Name, dna.Genre, dna.IsLocked, models.FormatTime(models.TimeFromProto(dna.UpdateTime)))
+	}
+	return tw.Flush()
+}
+
+// printDetail writes the fields of one config as name/value lines, in
+// declaration order and formatted as in JSON. version is the version read, 0
+// for the current one.
+func printDetail(w io.Writer, dna *pb.GameDNA, version int64) error {
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(dna)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	// Numbers stay as written, not as float64
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values map[string]interface{}
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if version != 0 {
+		fmt.Fprintf(tw, "version\t%d\n", version)
+	}
+	fields := dna.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		value, ok := values[fd.JSONName()]
+		if !ok || value == nil {
+			continue
+		}
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case []interface{}:
+			parts := make([]string, len(v))
+			for j, item := range v {
+				parts[j] = fmt.Sprint(item)
+			}
+			text = strings.Join(parts, ", ")
+		case map[string]interface{}:
+			encoded, _ := json.Marshal(v)
+			text = string(encoded)
+		default:
+			text = fmt.Sprint(v)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", fd.Name(), text)
+	}
+	return tw.Flush()
+}
+
+func printImportTable(w io.Writer, results []*pb.ImportGameDNAResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tVERSIONS\tERROR")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.ConfigId, r.Name, r.Status, r.Versions, r.Error)
+	}
+	return tw.Flush()
+}
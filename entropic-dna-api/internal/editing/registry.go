@@ -0,0 +1,147 @@
+// Package editing tracks who currently has a config open for editing. It is
+// advisory only: sessions never block writes.
+package editing
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTTL is how long a session stays active without a heartbeat.
+const DefaultTTL = 60 * time.Second
+
+// ErrSessionNotFound is returned for unknown or expired sessions.
+var ErrSessionNotFound = errors.New("editing session not found")
+
+// Session is one actor editing one config.
+type Session struct {
+	ID        string
+	ConfigID  string
+	Actor     string
+	StartedAt time.Time
+	LastSeen  time.Time
+	ExpiresAt time.Time
+}
+
+// Registry holds editing sessions in memory. Sessions are per server
+// instance. A nil *Registry is valid and tracks nothing.
+type Registry struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	sessions map[string]*Session // by session ID
+}
+
+// NewRegistry creates a registry whose sessions expire ttl after their last
+// heartbeat. A non-positive ttl uses DefaultTTL.
+func NewRegistry(ttl time.Duration) *Registry {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Registry{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// TTL returns the session lifetime without heartbeats.
+func (r *Registry) TTL() time.Duration {
+	if r == nil {
+		return DefaultTTL
+	}
+	return r.ttl
+}
+
+// Start opens a session for actor on configID.
+func (r *Registry) Start(configID, actor string) Session {
+	if r == nil {
+		now := time.Now()
+		return Session{ID: uuid.New().String(), ConfigID: configID, Actor: actor, StartedAt: now, LastSeen: now, ExpiresAt: now.Add(DefaultTTL)}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked()
+	now := r.now()
+	s := &Session{
+		ID:        uuid.New().String(),
+		ConfigID:  configID,
+		Actor:     actor,
+		StartedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(r.ttl),
+	}
+	r.sessions[s.ID] = s
+	return *s
+}
+
+// Heartbeat extends a session on configID.
+func (r *Registry) Heartbeat(configID, sessionID string) (Session, error) {
+	if r == nil {
+		return Session{}, ErrSessionNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked()
+	s, ok := r.sessions[sessionID]
+	if !ok || s.ConfigID != configID {
+		return Session{}, ErrSessionNotFound
+	}
+	s.LastSeen = r.now()
+	s.ExpiresAt = s.LastSeen.Add(r.ttl)
+	return *s, nil
+}
+
+// Stop ends a session. Stopping an unknown session is not an error.
+func (r *Registry) Stop(configID, sessionID string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[sessionID]; ok && s.ConfigID == configID {
+		delete(r.sessions, sessionID)
+	}
+}
+
+// Active lists live sessions on configID, oldest first.
+func (r *Registry) Active(configID string) []Session {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked()
+	var active []Session
+	for _, s := range r.sessions {
+		if s.ConfigID == configID {
+			active = append(active, *s)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+	return active
+}
+
+// expireLocked drops sessions past their expiry. Callers must hold r.mu.
+func (r *Registry) expireLocked() {
+	now := r.now()
+	for id, s := range r.sessions {
+		if now.After(s.ExpiresAt) {
+			delete(r.sessions, id)
+		}
+	}
+}
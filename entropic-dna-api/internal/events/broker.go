@@ -0,0 +1,255 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/faults"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"google.golang.org/protobuf/proto"
+)
+
+// Event types published for config changes.
+const (
+	TypeCreated     = "created"
+	TypeUpdated     = "updated"
+	TypeDeleted     = "deleted"
+	TypePublished   = "published"
+	TypeUnpublished = "unpublished"
+	TypeRolledBack  = "rolled_back"
+	TypeCloned      = "cloned"
+)
+
+const (
+	defaultBacklog    = 1024
+	subscriberBufSize = 64
+)
+
+// Types lists every event type.
+var Types = []string{TypeCreated, TypeUpdated, TypeDeleted, TypePublished, TypeUnpublished, TypeRolledBack, TypeCloned}
+
+// Filter selects which events a subscriber receives. Zero values match everything.
+type Filter struct {
+	ConfigID string
+	Slug     string
+	Tags     []string // event config must carry all of these tags
+	Types    []string // event type must be one of these
+}
+
+// Matches reports whether the event passes the filter.
+func (f Filter) Matches(ev *pb.ConfigEvent) bool {
+	if f.ConfigID != "" && ev.ConfigId != f.ConfigID {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, ev.Type) {
+		return false
+	}
+	if f.Slug != "" && ev.GetGameDna().GetSlug() != f.Slug {
+		return false
+	}
+	for _, want := range f.Tags {
+		found := false
+		for _, tag := range ev.GetGameDna().GetTags() {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan *pb.ConfigEvent
+}
+
+// Broker fans config change events out to in-process subscribers and keeps a
+// bounded backlog so reconnecting clients can resume from a sequence number.
+// A nil *Broker is valid and drops all events.
+type Broker struct {
+	mu      sync.Mutex
+	seq     int64
+	backlog []*pb.ConfigEvent
+	limit   int
+	subs    map[*subscriber]struct{}
+	faults  *faults.Injector
+	journal *Journal // nil unless embedded
+	relay   Relay
+}
+
+// Relay announces the events this instance publishes to the other instances
+// sharing its database, which Deliver them to their own subscribers.
+type Relay interface {
+	Announce(eventType string, configID string)
+}
+
+// NewBroker creates a broker retaining up to backlog events for resume.
+func NewBroker(backlog int) *Broker {
+	if backlog <= 0 {
+		backlog = defaultBacklog
+	}
+	return &Broker{
+		limit: backlog,
+		subs:  make(map[*subscriber]struct{}),
+	}
+}
+
+// NewEmbeddedBroker creates a broker that journals events in dir, so the
+// sequence and resume backlog survive restarts. Call Close to release the
+// journal.
+func NewEmbeddedBroker(backlog int, dir string, sync bool) (*Broker, error) {
+	journal, events, err := OpenJournal(dir, sync)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewBroker(backlog)
+	b.journal = journal
+	if len(events) > 0 {
+		b.seq = events[len(events)-1].Sequence
+	}
+	if len(events) > b.limit {
+		events = events[len(events)-b.limit:]
+		if err := journal.Compact(events); err != nil {
+			journal.Close()
+			return nil, err
+		}
+	}
+	b.backlog = events
+	return b, nil
+}
+
+// Close releases the broker's journal, if it has one.
+func (b *Broker) Close() error {
+	if b == nil || b.journal == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.journal.Close()
+}
+
+// SetFaults makes the broker inject the "events.Publish" faults of inj. An
+// injected error drops the event, as a lost notification would.
+func (b *Broker) SetFaults(inj *faults.Injector) {
+	b.faults = inj
+}
+
+// SetRelay makes the broker announce every published event through r. Call
+// it before the broker is shared.
+func (b *Broker) SetRelay(r Relay) {
+	b.relay = r
+}
+
+// Publish assigns the next sequence number to an event for dna and delivers it.
+// Subscribers that cannot keep up are disconnected rather than blocking writers.
+func (b *Broker) Publish(eventType string, configID string, dna *pb.GameDNA) {
+	if b == nil {
+		return
+	}
+	if err := b.faults.Inject(context.Background(), "events.Publish"); err != nil {
+		return
+	}
+	b.Deliver(eventType, configID, dna)
+	if b.relay != nil {
+		b.relay.Announce(eventType, configID)
+	}
+}
+
+// Deliver is Publish for an event announced by another instance: it is
+// delivered locally and not relayed again.
+func (b *Broker) Deliver(eventType string, configID string, dna *pb.GameDNA) {
+	if b == nil {
+		return
+	}
+
+	// Snapshot the config so later in-place edits by the store don't leak into the backlog.
+	if dna != nil {
+		dna = proto.Clone(dna).(*pb.GameDNA)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	ev := &pb.ConfigEvent{
+		Sequence:   b.seq,
+		Type:       eventType,
+		ConfigId:   configID,
+		GameDna:    dna,
+		OccurredAt: time.Now().Format(time.RFC3339),
+	}
+
+	b.backlog = append(b.backlog, ev)
+	if len(b.backlog) > b.limit {
+		b.backlog = b.backlog[len(b.backlog)-b.limit:]
+	}
+	if b.journal != nil {
+		b.appendJournal(ev)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Matches(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// appendJournal persists ev, compacting the journal down to the backlog once
+// it holds twice as many events. A failed write still delivers the event
+// live; it is only lost to resumes after a restart. Callers must hold b.mu.
+func (b *Broker) appendJournal(ev *pb.ConfigEvent) {
+	err := b.journal.Append(ev)
+	if err == nil && b.journal.Len() >= 2*b.limit {
+		err = b.journal.Compact(b.backlog)
+	}
+	metrics.ObserveEventJournal(err)
+}
+
+// Subscribe registers a subscriber and returns the retained events after
+// afterSeq that match filter, followed by a channel of live events. The channel
+// is closed if the subscriber falls behind; call cancel when done.
+func (b *Broker) Subscribe(afterSeq int64, filter Filter) ([]*pb.ConfigEvent, <-chan *pb.ConfigEvent, func()) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan *pb.ConfigEvent, subscriberBufSize),
+	}
+	if b == nil {
+		return nil, sub.ch, func() {}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []*pb.ConfigEvent
+	for _, ev := range b.backlog {
+		if ev.Sequence > afterSeq && filter.Matches(ev) {
+			replay = append(replay, ev)
+		}
+	}
+	b.subs[sub] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+
+	return replay, sub.ch, cancel
+}
@@ -0,0 +1,163 @@
+package events
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// journalFile is the journal's name inside its directory.
+const journalFile = "events.log"
+
+// maxRecordSize bounds a single journal record, so a corrupt length prefix
+// can't trigger a huge allocation.
+const maxRecordSize = 16 << 20
+
+// Journal is an append-only file of change events. It backs the embedded
+// events mode: the broker's sequence and resume backlog survive restarts
+// without an external message broker. Each record is a 4-byte big-endian
+// length followed by the binary ConfigEvent.
+type Journal struct {
+	path  string
+	file  *os.File
+	sync  bool
+	count int // records in the file
+}
+
+// OpenJournal opens the journal in dir, creating both if needed, and returns
+// the events it holds, oldest first. A record cut short by a crash is dropped.
+// With sync, every append is flushed to disk before Publish returns.
+func OpenJournal(dir string, sync bool) (*Journal, []*pb.ConfigEvent, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create events directory: %w", err)
+	}
+	path := filepath.Join(dir, journalFile)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open events journal: %w", err)
+	}
+
+	events, valid, err := readJournal(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	// Drop a torn tail so new records follow the last complete one.
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to truncate events journal: %w", err)
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to seek events journal: %w", err)
+	}
+
+	return &Journal{path: path, file: file, sync: sync, count: len(events)}, events, nil
+}
+
+// readJournal decodes records from the start of file, returning them and the
+// offset just past the last complete one.
+func readJournal(file *os.File) ([]*pb.ConfigEvent, int64, error) {
+	r := bufio.NewReader(file)
+	var events []*pb.ConfigEvent
+	var offset int64
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return events, offset, nil
+			}
+			return nil, 0, fmt.Errorf("failed to read events journal: %w", err)
+		}
+		size := binary.BigEndian.Uint32(header[:])
+		if size > maxRecordSize {
+			return events, offset, nil
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return events, offset, nil
+			}
+			return nil, 0, fmt.Errorf("failed to read events journal: %w", err)
+		}
+		ev := &pb.ConfigEvent{}
+		if err := proto.Unmarshal(data, ev); err != nil {
+			return events, offset, nil
+		}
+		events = append(events, ev)
+		offset += int64(len(header)) + int64(size)
+	}
+}
+
+// Append writes ev to the end of the journal.
+func (j *Journal) Append(ev *pb.ConfigEvent) error {
+	record, err := encodeRecord(nil, ev)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(record); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	if j.sync {
+		if err := j.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync events journal: %w", err)
+		}
+	}
+	j.count++
+	return nil
+}
+
+// Len returns the number of records in the journal.
+func (j *Journal) Len() int {
+	return j.count
+}
+
+// Compact replaces the journal's contents with events. The new file is
+// written beside the old one and renamed over it, so a crash leaves one or
+// the other intact.
+func (j *Journal) Compact(events []*pb.ConfigEvent) error {
+	var buf []byte
+	for _, ev := range events {
+		var err error
+		if buf, err = encodeRecord(buf, ev); err != nil {
+			return err
+		}
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o644); err != nil {
+		return fmt.Errorf("failed to write compacted events journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to replace events journal: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen events journal: %w", err)
+	}
+	j.file.Close()
+	j.file = file
+	j.count = len(events)
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+func encodeRecord(buf []byte, ev *pb.ConfigEvent) ([]byte, error) {
+	data, err := proto.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	return append(buf, data...), nil
+}
@@ -0,0 +1,469 @@
+// Package eventsource keeps configs' version history as an append-only log
+// of the mutations that wrote each version. Each event holds only the fields
+// its mutation changed, so the log stays small for large configs whose
+// versions differ in a few fields, and any past version, or the config as it
+// was at any moment, can be rebuilt exactly by replaying it.
+//
+// The store it wraps still materializes each config's current state and
+// keeps its recent versions in full; with the log holding the rest, history
+// retention can be set low without losing history.
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+)
+
+// syncBatch is how many versions or config IDs are read at a time.
+const syncBatch = 100
+
+// Store is a storage.Store that appends an event to its log for each
+// version written through it, and serves versions the store pruned from the
+// log. Events are appended after the write, from the versions the store
+// recorded; a failed append is logged and made up for on the config's next
+// write, so the log never fails a write.
+type Store struct {
+	storage.Store
+	log    storage.EventLog
+	logger *zap.Logger
+
+	mu sync.Mutex // serializes syncs, so versions are appended in order
+}
+
+// NewStore keeps the version history of next in log.
+func NewStore(next storage.Store, log storage.EventLog, logger *zap.Logger) *Store {
+	return &Store{Store: next, log: log, logger: logger}
+}
+
+// sync appends the versions of configID the log lacks. hint is the type of
+// the newest one, when the write knows better than the versions tell.
+func (s *Store) sync(ctx context.Context, configID string, hint string) {
+	if configID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.syncLocked(ctx, configID, hint); err != nil {
+		s.logger.Warn("Failed to append to the event log", zap.String("config_id", configID), zap.Error(err))
+	}
+}
+
+func (s *Store) syncLocked(ctx context.Context, configID string, hint string) error {
+	events, err := s.log.ListEvents(ctx, configID)
+	if err != nil {
+		return err
+	}
+	events = Current(events)
+	var last int64
+	if len(events) > 0 {
+		last = events[len(events)-1].VersionNum
+	}
+	state := Replay(events)
+
+	pending, err := s.versionsAfter(ctx, configID, last)
+	if err != nil {
+		return err
+	}
+	for i, v := range pending {
+		event := &storage.Event{
+			ConfigID:   configID,
+			Type:       eventType(state, v, hint, i == len(pending)-1),
+			VersionNum: v.VersionNum,
+			Checksum:   v.Checksum,
+			Actor:      v.CreatedBy,
+			CreatedAt:  v.CreatedAt,
+			Validation: v.Validation,
+		}
+		// Versions pruned before they were logged leave a gap only a full
+		// config can bridge.
+		if state == nil || v.VersionNum != last+1 {
+			event.Full, event.Data = true, models.Clone(v.Data)
+		} else {
+			event.Fields, event.Data = Diff(state, v.Data)
+		}
+		if _, err := s.log.AppendEvent(ctx, event); err != nil {
+			return err
+		}
+		state, last = v.Data, v.VersionNum
+	}
+	return nil
+}
+
+// versionsAfter returns the versions of configID newer than after, oldest
+// first. A config deleted meanwhile has none.
+func (s *Store) versionsAfter(ctx context.Context, configID string, after int64) ([]*storage.VersionInfo, error) {
+	var newer []*storage.VersionInfo
+	page := storage.VersionPage{Limit: syncBatch}
+	for {
+		versions, err := s.Store.ListVersions(ctx, configID, page)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			if v.VersionNum <= after {
+				return reversed(newer), nil
+			}
+			newer = append(newer, v)
+		}
+		if len(versions) < syncBatch {
+			return reversed(newer), nil
+		}
+		page.Before = versions[len(versions)-1].VersionNum
+	}
+}
+
+func reversed(versions []*storage.VersionInfo) []*storage.VersionInfo {
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions
+}
+
+// eventType names the mutation that wrote v over state.
+func eventType(state *pb.GameDNA, v *storage.VersionInfo, hint string, newest bool) string {
+	switch {
+	case v.VersionNum == 1:
+		return storage.EventConfigCreated
+	case newest && hint != "":
+		return hint
+	case state != nil && !state.IsLocked && v.Data.IsLocked:
+		return storage.EventPublished
+	case state != nil && state.IsLocked && !v.Data.IsLocked:
+		return storage.EventUnpublished
+	}
+	return storage.EventFieldChanged
+}
+
+func (s *Store) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	created, err := s.Store.Create(ctx, dna, opts...)
+	if err == nil {
+		s.sync(ctx, created.Id, "")
+	}
+	return created, err
+}
+
+func (s *Store) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	updated, err := s.Store.Update(ctx, dna, opts...)
+	if err == nil {
+		s.sync(ctx, dna.Id, "")
+	}
+	return updated, err
+}
+
+// Delete logs the config's last versions, then its deletion. The log of a
+// deleted config is kept.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	s.sync(ctx, id, "")
+	if err := s.Store.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.log.AppendEvent(ctx, &storage.Event{
+		ConfigID:  id,
+		Type:      storage.EventConfigDeleted,
+		CreatedAt: models.Now(),
+		Data:      &pb.GameDNA{},
+	})
+	if err != nil {
+		s.logger.Warn("Failed to append to the event log", zap.String("config_id", id), zap.Error(err))
+	}
+	return nil
+}
+
+// RollbackToVersion rolls back to versions the store pruned too, by writing
+// their content rebuilt from the log as an update.
+func (s *Store) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	rolled, err := s.Store.RollbackToVersion(ctx, configID, versionNum, actor)
+	if errors.Is(err, storage.ErrNotFound) {
+		rolled, err = s.rollbackToLogged(ctx, configID, versionNum, actor)
+	}
+	if err == nil {
+		s.sync(ctx, configID, storage.EventRolledBack)
+	}
+	return rolled, err
+}
+
+func (s *Store) rollbackToLogged(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	current, err := s.Store.Read(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.loggedVersion(ctx, configID, versionNum)
+	if err != nil {
+		return nil, err
+	}
+	content := target.Data
+	// As the stores do, keep the current slug if the old one predates slugs
+	if content.Slug == "" {
+		content.Slug = current.Slug
+	}
+	if actor != "" {
+		content.CreatedBy = actor
+	}
+	rolled, err := s.Store.Update(ctx, content, storage.WithValidation(target.Validation))
+	if errors.Is(err, storage.ErrNotModified) {
+		return rolled, nil
+	}
+	return rolled, err
+}
+
+func (s *Store) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	published, err := s.Store.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return published, err
+}
+
+func (s *Store) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	unpublished, entry, err := s.Store.UnpublishVersion(ctx, configID, actor, reason)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return unpublished, entry, err
+}
+
+func (s *Store) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	unlocked, entry, err := s.Store.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return unlocked, entry, err
+}
+
+func (s *Store) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	relocked, entry, err := s.Store.RelockVersion(ctx, configID, actor, reason, checksum, report)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return relocked, entry, err
+}
+
+func (s *Store) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	promoted, canary, entry, err := s.Store.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return promoted, canary, entry, err
+}
+
+func (s *Store) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	promoted, entry, err := s.Store.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+	if err == nil {
+		s.sync(ctx, configID, "")
+	}
+	return promoted, entry, err
+}
+
+func (s *Store) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+	clone, err := s.Store.Clone(ctx, id, newName, actor)
+	if err == nil {
+		s.sync(ctx, clone.Id, "")
+	}
+	return clone, err
+}
+
+func (s *Store) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	saved, err := s.Store.SaveSet(ctx, set)
+	if err == nil {
+		for _, dna := range saved.Creates {
+			s.sync(ctx, dna.Id, "")
+		}
+		for _, dna := range saved.Updates {
+			s.sync(ctx, dna.Id, "")
+		}
+	}
+	return saved, err
+}
+
+func (s *Store) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	imported, err := s.Store.ImportConfig(ctx, dna, versions)
+	if err == nil {
+		s.sync(ctx, imported.Id, "")
+	}
+	return imported, err
+}
+
+func (s *Store) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	restored, err := s.Store.RestoreSnapshot(ctx, name, actor)
+	if err == nil {
+		for _, dna := range restored {
+			s.sync(ctx, dna.Id, "")
+		}
+	}
+	return restored, err
+}
+
+func (s *Store) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	moved, err := s.Store.TransferOwnership(ctx, from, to, actor, dryRun)
+	if err == nil && !dryRun {
+		for _, dna := range moved.Configs {
+			s.sync(ctx, dna.Id, "")
+		}
+	}
+	return moved, err
+}
+
+// AnonymizePrincipal renames the principal in the log too.
+func (s *Store) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	data, err := s.Store.AnonymizePrincipal(ctx, principal, pseudonym)
+	if err != nil {
+		return data, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.log.AnonymizeEvents(ctx, principal, pseudonym); err != nil {
+		return data, fmt.Errorf("failed to anonymize the event log: %w", err)
+	}
+	return data, nil
+}
+
+// PruneVersionHistory logs every config's versions before the store prunes
+// them.
+func (s *Store) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (int64, error) {
+	after := ""
+	for {
+		ids, err := s.Store.ConfigIDsAfter(ctx, after, syncBatch)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list configs to log: %w", err)
+		}
+		for _, id := range ids {
+			s.sync(ctx, id, "")
+		}
+		if len(ids) < syncBatch {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+	return s.Store.PruneVersionHistory(ctx, retention)
+}
+
+// GetVersionHistory adds the versions the store pruned, rebuilt from the
+// log.
+func (s *Store) GetVersionHistory(ctx context.Context, configID string) ([]*storage.VersionInfo, error) {
+	stored, err := s.Store.GetVersionHistory(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withLogged(ctx, configID, stored, false)
+}
+
+// ListVersions adds the versions the store pruned, rebuilt from the log, to
+// the page. Their Size is that of their event.
+func (s *Store) ListVersions(ctx context.Context, configID string, page storage.VersionPage) ([]*storage.VersionInfo, error) {
+	stored, err := s.Store.ListVersions(ctx, configID, storage.VersionPage{WithoutData: page.WithoutData})
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.withLogged(ctx, configID, reversed(stored), page.WithoutData)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*storage.VersionInfo
+	for i := len(versions) - 1; i >= 0; i-- {
+		if page.Limit > 0 && len(result) == page.Limit {
+			break
+		}
+		if page.Before > 0 && versions[i].VersionNum >= page.Before {
+			continue
+		}
+		result = append(result, versions[i])
+	}
+	return result, nil
+}
+
+// GetVersion rebuilds versions the store pruned from the log.
+func (s *Store) GetVersion(ctx context.Context, configID string, versionNum int64) (*storage.VersionInfo, error) {
+	v, err := s.Store.GetVersion(ctx, configID, versionNum)
+	if errors.Is(err, storage.ErrNotFound) {
+		if _, readErr := s.Store.Read(ctx, configID); readErr == nil {
+			return s.loggedVersion(ctx, configID, versionNum)
+		}
+	}
+	return v, err
+}
+
+// loggedVersion rebuilds a version of a config that exists from the log.
+func (s *Store) loggedVersion(ctx context.Context, configID string, versionNum int64) (*storage.VersionInfo, error) {
+	versions, err := s.logged(ctx, configID, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.VersionNum == versionNum {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("version %d: %w", versionNum, storage.ErrNotFound)
+}
+
+// withLogged merges stored, oldest first, with the versions of the config's
+// log the store no longer has.
+func (s *Store) withLogged(ctx context.Context, configID string, stored []*storage.VersionInfo, withoutData bool) ([]*storage.VersionInfo, error) {
+	logged, err := s.logged(ctx, configID, withoutData)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[int64]bool, len(stored))
+	for _, v := range stored {
+		have[v.VersionNum] = true
+	}
+	versions := append([]*storage.VersionInfo(nil), stored...)
+	for _, v := range logged {
+		if !have[v.VersionNum] {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].VersionNum < versions[j].VersionNum })
+	return versions, nil
+}
+
+// logged rebuilds every version in the log of the config under configID,
+// oldest first.
+func (s *Store) logged(ctx context.Context, configID string, withoutData bool) ([]*storage.VersionInfo, error) {
+	events, err := s.log.ListEvents(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the event log: %w", err)
+	}
+	var versions []*storage.VersionInfo
+	var state *pb.GameDNA
+	for _, event := range Current(events) {
+		prev := state
+		state = step(state, event)
+		if state == nil || event.VersionNum == 0 {
+			continue
+		}
+		v := &storage.VersionInfo{
+			VersionNum: event.VersionNum,
+			Checksum:   event.Checksum,
+			CreatedAt:  event.CreatedAt,
+			CreatedBy:  event.Actor,
+			Validation: event.Validation,
+			Size:       event.Size,
+		}
+		if prev != nil {
+			v.ChangedFields = diff.ChangedFields(prev, state)
+			v.Changes = diff.Changes(prev, state)
+		}
+		if !withoutData {
+			v.Data = state
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
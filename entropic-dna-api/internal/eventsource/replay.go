@@ -0,0 +1,113 @@
+package eventsource
+
+import (
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+// Diff returns the GameDNA fields that differ from old to new, by proto
+// name, and a config holding new's values of them. Unlike diff.ChangedFields
+// it skips nothing, so applying the result to old gives back new exactly.
+func Diff(old, new *pb.GameDNA) ([]string, *pb.GameDNA) {
+	oldMsg := old.ProtoReflect()
+	newMsg := new.ProtoReflect()
+	delta := &pb.GameDNA{}
+	deltaMsg := delta.ProtoReflect()
+	fields := newMsg.Descriptor().Fields()
+
+	changed := []string{}
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		before, after := &pb.GameDNA{}, &pb.GameDNA{}
+		if oldMsg.Has(fd) {
+			before.ProtoReflect().Set(fd, oldMsg.Get(fd))
+		}
+		if newMsg.Has(fd) {
+			after.ProtoReflect().Set(fd, newMsg.Get(fd))
+		}
+		if proto.Equal(before, after) {
+			continue
+		}
+		changed = append(changed, string(fd.Name()))
+		if newMsg.Has(fd) {
+			deltaMsg.Set(fd, newMsg.Get(fd))
+		}
+	}
+	return changed, models.Clone(delta)
+}
+
+// apply sets the fields event lists on state to their values in the event,
+// clearing those it leaves unset.
+func apply(state *pb.GameDNA, event *storage.Event) {
+	stateMsg := state.ProtoReflect()
+	data := models.Clone(event.Data).ProtoReflect()
+	fields := stateMsg.Descriptor().Fields()
+	for _, name := range event.Fields {
+		fd := fields.ByTextName(name)
+		if fd == nil {
+			continue
+		}
+		if data.Has(fd) {
+			stateMsg.Set(fd, data.Get(fd))
+		} else {
+			stateMsg.Clear(fd)
+		}
+	}
+}
+
+// Current returns the events since the config's last deletion, those of the
+// config that exists now or last existed under its ID.
+func Current(events []*storage.Event) []*storage.Event {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == storage.EventConfigDeleted {
+			return events[i+1:]
+		}
+	}
+	return events
+}
+
+// Replay applies events in order and returns the config they leave, or nil
+// when they leave none: no event rebuilt a full config, or the config was
+// deleted.
+func Replay(events []*storage.Event) *pb.GameDNA {
+	var state *pb.GameDNA
+	for _, event := range events {
+		state = step(state, event)
+	}
+	return state
+}
+
+// step returns state after event. It never modifies state.
+func step(state *pb.GameDNA, event *storage.Event) *pb.GameDNA {
+	switch {
+	case event.Type == storage.EventConfigDeleted:
+		return nil
+	case event.Full:
+		return models.Clone(event.Data)
+	case state == nil:
+		// A change to a config the log never held in full can't be applied
+		return nil
+	}
+	next := models.Clone(state)
+	apply(next, event)
+	return next
+}
+
+// StateAt returns the config as it was at t, and the version it was at,
+// from the config's events. It returns nil when the config didn't exist at
+// t.
+func StateAt(events []*storage.Event, t time.Time) (*pb.GameDNA, int64) {
+	until := 0
+	for until < len(events) && !events[until].CreatedAt.After(t) {
+		until++
+	}
+	events = Current(events[:until])
+	if len(events) == 0 {
+		return nil, 0
+	}
+	return Replay(events), events[len(events)-1].VersionNum
+}
@@ -0,0 +1,142 @@
+// Package faults injects latency and errors into storage, validation and event
+// publishing so resilience and degradation paths can be exercised on purpose.
+// It is for test environments only and stays off unless explicitly enabled.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Components faults can target. A rule's target is "<component>.<Method>",
+// e.g. "storage.Update", or "<component>.*" for every method.
+const (
+	ComponentStorage = "storage"
+	ComponentFFI     = "ffi"
+	ComponentEvents  = "events"
+)
+
+// ErrInjected is wrapped by every injected error.
+var ErrInjected = errors.New("injected fault")
+
+// Rule describes the fault injected into calls to one target.
+type Rule struct {
+	Target string
+	// Latency is added before the call runs.
+	Latency time.Duration
+	// ErrorRate is the fraction of calls, from 0 to 1, that fail. Values
+	// between 0 and 1 give partial failures.
+	ErrorRate float64
+	// Message describes injected errors; empty uses a generic one.
+	Message string
+}
+
+// Injector holds the active rules. A nil *Injector is valid and never injects.
+type Injector struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// NewInjector creates an injector with no rules.
+func NewInjector() *Injector {
+	return &Injector{rules: make(map[string]Rule)}
+}
+
+// Set replaces the active rules. An empty list clears them.
+func (i *Injector) Set(rules []Rule) error {
+	if i == nil {
+		return fmt.Errorf("fault injection is disabled")
+	}
+	next := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		if err := checkTarget(r.Target); err != nil {
+			return err
+		}
+		if r.ErrorRate < 0 || r.ErrorRate > 1 {
+			return fmt.Errorf("%s: error rate must be between 0 and 1", r.Target)
+		}
+		if r.Latency < 0 {
+			return fmt.Errorf("%s: latency cannot be negative", r.Target)
+		}
+		if _, dup := next[r.Target]; dup {
+			return fmt.Errorf("%s: duplicate rule", r.Target)
+		}
+		next[r.Target] = r
+	}
+
+	i.mu.Lock()
+	i.rules = next
+	i.mu.Unlock()
+	return nil
+}
+
+// Rules returns the active rules sorted by target.
+func (i *Injector) Rules() []Rule {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	rules := make([]Rule, 0, len(i.rules))
+	for _, r := range i.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(a, b int) bool { return rules[a].Target < rules[b].Target })
+	return rules
+}
+
+// Inject applies the rule for target, if any: it waits out the latency and
+// then fails the call at the rule's error rate. A rule for the exact method
+// takes precedence over the component's wildcard rule.
+func (i *Injector) Inject(ctx context.Context, target string) error {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	r, ok := i.rules[target]
+	if !ok {
+		component, _, _ := strings.Cut(target, ".")
+		r, ok = i.rules[component+".*"]
+	}
+	i.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if r.Latency > 0 {
+		timer := time.NewTimer(r.Latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	if r.ErrorRate > 0 && rand.Float64() < r.ErrorRate {
+		msg := r.Message
+		if msg == "" {
+			msg = "call failed"
+		}
+		return fmt.Errorf("%s: %s: %w", target, msg, ErrInjected)
+	}
+	return nil
+}
+
+func checkTarget(target string) error {
+	component, method, ok := strings.Cut(target, ".")
+	if !ok || method == "" {
+		return fmt.Errorf("invalid fault target %q: want <component>.<Method> or <component>.*", target)
+	}
+	switch component {
+	case ComponentStorage, ComponentFFI, ComponentEvents:
+		return nil
+	}
+	return fmt.Errorf("invalid fault target %q: component must be %s, %s or %s", target, ComponentStorage, ComponentFFI, ComponentEvents)
+}
@@ -0,0 +1,557 @@
+package faults
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// store wraps a storage.Store, injecting faults before each call.
+type store struct {
+	next storage.Store
+	inj  *Injector
+}
+
+// WrapStore returns a store that injects the "storage.<Method>" faults of inj
+// before delegating to next.
+func WrapStore(next storage.Store, inj *Injector) storage.Store {
+	return &store{next: next, inj: inj}
+}
+
+func (s *store) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.Create"); err != nil {
+		return nil, err
+	}
+	return s.next.Create(ctx, dna, opts...)
+}
+
+func (s *store) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.Read"); err != nil {
+		return nil, err
+	}
+	return s.next.Read(ctx, id)
+}
+
+func (s *store) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.ReadBySlug"); err != nil {
+		return nil, err
+	}
+	return s.next.ReadBySlug(ctx, slug)
+}
+
+func (s *store) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.Update"); err != nil {
+		return nil, err
+	}
+	return s.next.Update(ctx, dna, opts...)
+}
+
+func (s *store) Delete(ctx context.Context, id string) error {
+	if err := s.inj.Inject(ctx, "storage.Delete"); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id)
+}
+
+func (s *store) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	if err := s.inj.Inject(ctx, "storage.List"); err != nil {
+		return nil, 0, err
+	}
+	return s.next.List(ctx, filters, pagination)
+}
+
+func (s *store) ListAfter(ctx context.Context, filters storage.ListFilters, after *storage.ListCursor, limit int32) ([]*pb.GameDNA, *storage.ListCursor, error) {
+	if err := s.inj.Inject(ctx, "storage.ListAfter"); err != nil {
+		return nil, nil, err
+	}
+	return s.next.ListAfter(ctx, filters, after, limit)
+}
+
+func (s *store) Each(ctx context.Context, filters storage.ListFilters, fn func(*pb.GameDNA) error) error {
+	if err := s.inj.Inject(ctx, "storage.Each"); err != nil {
+		return err
+	}
+	return s.next.Each(ctx, filters, fn)
+}
+
+func (s *store) Search(ctx context.Context, query storage.SearchQuery, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	if err := s.inj.Inject(ctx, "storage.Search"); err != nil {
+		return nil, 0, err
+	}
+	return s.next.Search(ctx, query, pagination)
+}
+
+func (s *store) GetVersionHistory(ctx context.Context, configID string) ([]*storage.VersionInfo, error) {
+	if err := s.inj.Inject(ctx, "storage.GetVersionHistory"); err != nil {
+		return nil, err
+	}
+	return s.next.GetVersionHistory(ctx, configID)
+}
+
+func (s *store) ListVersions(ctx context.Context, configID string, page storage.VersionPage) ([]*storage.VersionInfo, error) {
+	if err := s.inj.Inject(ctx, "storage.ListVersions"); err != nil {
+		return nil, err
+	}
+	return s.next.ListVersions(ctx, configID, page)
+}
+
+func (s *store) GetVersion(ctx context.Context, configID string, versionNum int64) (*storage.VersionInfo, error) {
+	if err := s.inj.Inject(ctx, "storage.GetVersion"); err != nil {
+		return nil, err
+	}
+	return s.next.GetVersion(ctx, configID, versionNum)
+}
+
+func (s *store) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (int64, error) {
+	if err := s.inj.Inject(ctx, "storage.PruneVersionHistory"); err != nil {
+		return 0, err
+	}
+	return s.next.PruneVersionHistory(ctx, retention)
+}
+
+func (s *store) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.RollbackToVersion"); err != nil {
+		return nil, err
+	}
+	return s.next.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (s *store) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.PublishVersion"); err != nil {
+		return nil, err
+	}
+	return s.next.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (s *store) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.UnpublishVersion"); err != nil {
+		return nil, nil, err
+	}
+	return s.next.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (s *store) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.UnlockTemporarily"); err != nil {
+		return nil, nil, err
+	}
+	return s.next.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (s *store) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.RelockVersion"); err != nil {
+		return nil, nil, err
+	}
+	return s.next.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) ListTemporaryUnlocks(ctx context.Context) ([]*storage.TemporaryUnlock, error) {
+	if err := s.inj.Inject(ctx, "storage.ListTemporaryUnlocks"); err != nil {
+		return nil, err
+	}
+	return s.next.ListTemporaryUnlocks(ctx)
+}
+
+func (s *store) StartCanary(ctx context.Context, canary *storage.Canary) (*storage.Canary, error) {
+	if err := s.inj.Inject(ctx, "storage.StartCanary"); err != nil {
+		return nil, err
+	}
+	return s.next.StartCanary(ctx, canary)
+}
+
+func (s *store) GetCanary(ctx context.Context, configID string) (*storage.Canary, error) {
+	if err := s.inj.Inject(ctx, "storage.GetCanary"); err != nil {
+		return nil, err
+	}
+	return s.next.GetCanary(ctx, configID)
+}
+
+func (s *store) ListRunningCanaries(ctx context.Context) ([]*storage.Canary, error) {
+	if err := s.inj.Inject(ctx, "storage.ListRunningCanaries"); err != nil {
+		return nil, err
+	}
+	return s.next.ListRunningCanaries(ctx)
+}
+
+func (s *store) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*storage.Canary, error) {
+	if err := s.inj.Inject(ctx, "storage.RecordCanaryFeedback"); err != nil {
+		return nil, err
+	}
+	return s.next.RecordCanaryFeedback(ctx, configID, checksum, sessions, errors)
+}
+
+func (s *store) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.PromoteCanary"); err != nil {
+		return nil, nil, nil, err
+	}
+	return s.next.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.PromoteVersion"); err != nil {
+		return nil, nil, err
+	}
+	return s.next.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (s *store) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*storage.Canary, error) {
+	if err := s.inj.Inject(ctx, "storage.RollbackCanary"); err != nil {
+		return nil, err
+	}
+	return s.next.RollbackCanary(ctx, configID, actor, reason)
+}
+
+func (s *store) TagVersion(ctx context.Context, tag *storage.ReleaseTag, move bool) (*storage.ReleaseTag, int64, error) {
+	if err := s.inj.Inject(ctx, "storage.TagVersion"); err != nil {
+		return nil, 0, err
+	}
+	return s.next.TagVersion(ctx, tag, move)
+}
+
+func (s *store) GetTaggedVersion(ctx context.Context, configID string, tag string) (*storage.VersionInfo, error) {
+	if err := s.inj.Inject(ctx, "storage.GetTaggedVersion"); err != nil {
+		return nil, err
+	}
+	return s.next.GetTaggedVersion(ctx, configID, tag)
+}
+
+func (s *store) ListReleaseTags(ctx context.Context, configID string) ([]*storage.ReleaseTag, error) {
+	if err := s.inj.Inject(ctx, "storage.ListReleaseTags"); err != nil {
+		return nil, err
+	}
+	return s.next.ListReleaseTags(ctx, configID)
+}
+
+func (s *store) ListAuditEntries(ctx context.Context, configID string) ([]*storage.AuditEntry, error) {
+	if err := s.inj.Inject(ctx, "storage.ListAuditEntries"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAuditEntries(ctx, configID)
+}
+
+func (s *store) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.Clone"); err != nil {
+		return nil, err
+	}
+	return s.next.Clone(ctx, id, newName, actor)
+}
+
+func (s *store) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveSet"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveSet(ctx, set)
+}
+
+func (s *store) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.ImportConfig"); err != nil {
+		return nil, err
+	}
+	return s.next.ImportConfig(ctx, dna, versions)
+}
+
+func (s *store) CreateSnapshot(ctx context.Context, snapshot *storage.Snapshot) (*storage.Snapshot, error) {
+	if err := s.inj.Inject(ctx, "storage.CreateSnapshot"); err != nil {
+		return nil, err
+	}
+	return s.next.CreateSnapshot(ctx, snapshot)
+}
+
+func (s *store) GetSnapshot(ctx context.Context, name string, withData bool) (*storage.Snapshot, error) {
+	if err := s.inj.Inject(ctx, "storage.GetSnapshot"); err != nil {
+		return nil, err
+	}
+	return s.next.GetSnapshot(ctx, name, withData)
+}
+
+func (s *store) ListSnapshots(ctx context.Context) ([]*storage.Snapshot, error) {
+	if err := s.inj.Inject(ctx, "storage.ListSnapshots"); err != nil {
+		return nil, err
+	}
+	return s.next.ListSnapshots(ctx)
+}
+
+func (s *store) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	if err := s.inj.Inject(ctx, "storage.RestoreSnapshot"); err != nil {
+		return nil, err
+	}
+	return s.next.RestoreSnapshot(ctx, name, actor)
+}
+
+func (s *store) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	if err := s.inj.Inject(ctx, "storage.TransferOwnership"); err != nil {
+		return nil, err
+	}
+	return s.next.TransferOwnership(ctx, from, to, actor, dryRun)
+}
+
+func (s *store) FindPrincipalData(ctx context.Context, principal string) (*storage.PrincipalData, error) {
+	if err := s.inj.Inject(ctx, "storage.FindPrincipalData"); err != nil {
+		return nil, err
+	}
+	return s.next.FindPrincipalData(ctx, principal)
+}
+
+func (s *store) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	if err := s.inj.Inject(ctx, "storage.AnonymizePrincipal"); err != nil {
+		return nil, err
+	}
+	return s.next.AnonymizePrincipal(ctx, principal, pseudonym)
+}
+
+func (s *store) SaveImportMapping(ctx context.Context, mapping *storage.ImportMapping) (*storage.ImportMapping, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveImportMapping"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveImportMapping(ctx, mapping)
+}
+
+func (s *store) GetImportMapping(ctx context.Context, project string) (*storage.ImportMapping, error) {
+	if err := s.inj.Inject(ctx, "storage.GetImportMapping"); err != nil {
+		return nil, err
+	}
+	return s.next.GetImportMapping(ctx, project)
+}
+
+func (s *store) SaveAPIToken(ctx context.Context, token *storage.APIToken) (*storage.APIToken, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveAPIToken"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveAPIToken(ctx, token)
+}
+
+func (s *store) GetAPIToken(ctx context.Context, id string) (*storage.APIToken, error) {
+	if err := s.inj.Inject(ctx, "storage.GetAPIToken"); err != nil {
+		return nil, err
+	}
+	return s.next.GetAPIToken(ctx, id)
+}
+
+func (s *store) FindAPIToken(ctx context.Context, hash string) (*storage.APIToken, error) {
+	if err := s.inj.Inject(ctx, "storage.FindAPIToken"); err != nil {
+		return nil, err
+	}
+	return s.next.FindAPIToken(ctx, hash)
+}
+
+func (s *store) ListAPITokens(ctx context.Context) ([]*storage.APIToken, error) {
+	if err := s.inj.Inject(ctx, "storage.ListAPITokens"); err != nil {
+		return nil, err
+	}
+	return s.next.ListAPITokens(ctx)
+}
+
+func (s *store) SaveWaiver(ctx context.Context, waiver *storage.Waiver) (*storage.Waiver, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveWaiver"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveWaiver(ctx, waiver)
+}
+
+func (s *store) ListWaivers(ctx context.Context, configID string) ([]*storage.Waiver, error) {
+	if err := s.inj.Inject(ctx, "storage.ListWaivers"); err != nil {
+		return nil, err
+	}
+	return s.next.ListWaivers(ctx, configID)
+}
+
+func (s *store) SaveTemplate(ctx context.Context, template *storage.Template) (*storage.Template, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveTemplate"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveTemplate(ctx, template)
+}
+
+func (s *store) GetTemplate(ctx context.Context, name string) (*storage.Template, error) {
+	if err := s.inj.Inject(ctx, "storage.GetTemplate"); err != nil {
+		return nil, err
+	}
+	return s.next.GetTemplate(ctx, name)
+}
+
+func (s *store) ListTemplates(ctx context.Context) ([]*storage.Template, error) {
+	if err := s.inj.Inject(ctx, "storage.ListTemplates"); err != nil {
+		return nil, err
+	}
+	return s.next.ListTemplates(ctx)
+}
+
+func (s *store) DeleteTemplate(ctx context.Context, name string) error {
+	if err := s.inj.Inject(ctx, "storage.DeleteTemplate"); err != nil {
+		return err
+	}
+	return s.next.DeleteTemplate(ctx, name)
+}
+
+func (s *store) SavePropertySchema(ctx context.Context, schema *storage.PropertySchema) (*storage.PropertySchema, error) {
+	if err := s.inj.Inject(ctx, "storage.SavePropertySchema"); err != nil {
+		return nil, err
+	}
+	return s.next.SavePropertySchema(ctx, schema)
+}
+
+func (s *store) GetPropertySchema(ctx context.Context, project, name string) (*storage.PropertySchema, error) {
+	if err := s.inj.Inject(ctx, "storage.GetPropertySchema"); err != nil {
+		return nil, err
+	}
+	return s.next.GetPropertySchema(ctx, project, name)
+}
+
+func (s *store) ListPropertySchemas(ctx context.Context, project string) ([]*storage.PropertySchema, error) {
+	if err := s.inj.Inject(ctx, "storage.ListPropertySchemas"); err != nil {
+		return nil, err
+	}
+	return s.next.ListPropertySchemas(ctx, project)
+}
+
+func (s *store) DeletePropertySchema(ctx context.Context, project, name string) error {
+	if err := s.inj.Inject(ctx, "storage.DeletePropertySchema"); err != nil {
+		return err
+	}
+	return s.next.DeletePropertySchema(ctx, project, name)
+}
+
+func (s *store) SaveWebhook(ctx context.Context, webhook *storage.Webhook) (*storage.Webhook, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveWebhook"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveWebhook(ctx, webhook)
+}
+
+func (s *store) GetWebhook(ctx context.Context, id string) (*storage.Webhook, error) {
+	if err := s.inj.Inject(ctx, "storage.GetWebhook"); err != nil {
+		return nil, err
+	}
+	return s.next.GetWebhook(ctx, id)
+}
+
+func (s *store) ListWebhooks(ctx context.Context) ([]*storage.Webhook, error) {
+	if err := s.inj.Inject(ctx, "storage.ListWebhooks"); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhooks(ctx)
+}
+
+func (s *store) DeleteWebhook(ctx context.Context, id string) error {
+	if err := s.inj.Inject(ctx, "storage.DeleteWebhook"); err != nil {
+		return err
+	}
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *store) SaveWebhookDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (*storage.WebhookDelivery, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveWebhookDelivery"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveWebhookDelivery(ctx, delivery)
+}
+
+func (s *store) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*storage.WebhookDelivery, error) {
+	if err := s.inj.Inject(ctx, "storage.ClaimWebhookDeliveries"); err != nil {
+		return nil, err
+	}
+	return s.next.ClaimWebhookDeliveries(ctx, now, limit, lease)
+}
+
+func (s *store) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*storage.WebhookDelivery, error) {
+	if err := s.inj.Inject(ctx, "storage.ListWebhookDeliveries"); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhookDeliveries(ctx, webhookID, state, limit)
+}
+
+func (s *store) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	if err := s.inj.Inject(ctx, "storage.PruneWebhookDeliveries"); err != nil {
+		return 0, err
+	}
+	return s.next.PruneWebhookDeliveries(ctx, finishedBefore)
+}
+
+func (s *store) ClaimIdempotencyKey(ctx context.Context, key *storage.IdempotencyKey) (*storage.IdempotencyKey, error) {
+	if err := s.inj.Inject(ctx, "storage.ClaimIdempotencyKey"); err != nil {
+		return nil, err
+	}
+	return s.next.ClaimIdempotencyKey(ctx, key)
+}
+
+func (s *store) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	if err := s.inj.Inject(ctx, "storage.CompleteIdempotencyKey"); err != nil {
+		return err
+	}
+	return s.next.CompleteIdempotencyKey(ctx, scope, key, response, expiresAt)
+}
+
+func (s *store) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	if err := s.inj.Inject(ctx, "storage.PruneIdempotencyKeys"); err != nil {
+		return 0, err
+	}
+	return s.next.PruneIdempotencyKeys(ctx, expiredBefore)
+}
+
+func (s *store) ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+	if err := s.inj.Inject(ctx, "storage.ConfigIDsAfter"); err != nil {
+		return nil, err
+	}
+	return s.next.ConfigIDsAfter(ctx, after, limit)
+}
+
+func (s *store) SaveDraft(ctx context.Context, draft *storage.Draft) (*storage.Draft, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveDraft"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveDraft(ctx, draft)
+}
+
+func (s *store) GetDraft(ctx context.Context, configID, owner string) (*storage.Draft, error) {
+	if err := s.inj.Inject(ctx, "storage.GetDraft"); err != nil {
+		return nil, err
+	}
+	return s.next.GetDraft(ctx, configID, owner)
+}
+
+func (s *store) DeleteDraft(ctx context.Context, configID, owner string) error {
+	if err := s.inj.Inject(ctx, "storage.DeleteDraft"); err != nil {
+		return err
+	}
+	return s.next.DeleteDraft(ctx, configID, owner)
+}
+
+func (s *store) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (int64, error) {
+	if err := s.inj.Inject(ctx, "storage.FixChecksums"); err != nil {
+		return 0, err
+	}
+	return s.next.FixChecksums(ctx, fixes)
+}
+
+func (s *store) SaveChecksumJob(ctx context.Context, job *storage.ChecksumJob) (*storage.ChecksumJob, error) {
+	if err := s.inj.Inject(ctx, "storage.SaveChecksumJob"); err != nil {
+		return nil, err
+	}
+	return s.next.SaveChecksumJob(ctx, job)
+}
+
+func (s *store) GetChecksumJob(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	if err := s.inj.Inject(ctx, "storage.GetChecksumJob"); err != nil {
+		return nil, err
+	}
+	return s.next.GetChecksumJob(ctx, id)
+}
+
+func (s *store) IndexStats(ctx context.Context) ([]storage.IndexStat, error) {
+	if err := s.inj.Inject(ctx, "storage.IndexStats"); err != nil {
+		return nil, err
+	}
+	return s.next.IndexStats(ctx)
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	if err := s.inj.Inject(ctx, "storage.Ping"); err != nil {
+		return err
+	}
+	return s.next.Ping(ctx)
+}
+
+func (s *store) Close() {
+	s.next.Close()
+}
@@ -0,0 +1,206 @@
+package ffi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// PEGIProperty is the custom property holding a config's PEGI rating, which
+// the compliance rules check against its ESRB rating.
+const PEGIProperty = "pegi_rating"
+
+// ComplianceRating is what configs with one ESRB rating may combine it with.
+// Values compare ignoring case. Empty lists allow anything.
+type ComplianceRating struct {
+	ESRB                  string
+	PEGI                  []string // PEGI ratings equivalent to it
+	ForbiddenMonetization []string
+	ForbiddenAudiences    []string // target_audience values
+	ForbiddenTones        []string
+}
+
+// ComplianceRules cross-check a config's age rating with its monetization,
+// target audience, tone and PEGI rating. Ratings go from the youngest
+// audience to the oldest, so remediation can name the lowest rating that
+// allows a value. Configs whose ESRB rating isn't listed, such as RP, aren't
+// checked.
+type ComplianceRules struct {
+	Version string
+	Ratings []ComplianceRating
+}
+
+// DefaultComplianceRules are the rules checked when compliance is enabled
+// without a file of its own.
+var DefaultComplianceRules = ComplianceRules{
+	Version: "compliance/1",
+	Ratings: []ComplianceRating{
+		{
+			ESRB:                  "E",
+			PEGI:                  []string{"3", "7"},
+			ForbiddenMonetization: []string{"Gambling", "RealMoneyGambling", "Lootbox"},
+			ForbiddenAudiences:    []string{"Adults", "Adults only", "Mature"},
+			ForbiddenTones:        []string{"Gore", "Horror", "Mature"},
+		},
+		{
+			ESRB:                  "E10+",
+			PEGI:                  []string{"7", "12"},
+			ForbiddenMonetization: []string{"Gambling", "RealMoneyGambling"},
+			ForbiddenAudiences:    []string{"Adults", "Adults only", "Mature"},
+			ForbiddenTones:        []string{"Gore", "Mature"},
+		},
+		{
+			ESRB:                  "T",
+			PEGI:                  []string{"12", "16"},
+			ForbiddenMonetization: []string{"RealMoneyGambling"},
+			ForbiddenAudiences:    []string{"Kids", "Children", "Adults only"},
+			ForbiddenTones:        []string{"Gore"},
+		},
+		{
+			ESRB:                  "M",
+			PEGI:                  []string{"16", "18"},
+			ForbiddenMonetization: []string{"RealMoneyGambling"},
+			ForbiddenAudiences:    []string{"Kids", "Children", "Everyone", "Family", "Adults only"},
+		},
+		{
+			ESRB:               "AO",
+			PEGI:               []string{"18"},
+			ForbiddenAudiences: []string{"Kids", "Children", "Everyone", "Family", "Teens"},
+		},
+	},
+}
+
+// SetComplianceRules checks configs against rules from now on. Call it before
+// the binding is shared.
+func (r *RustFFI) SetComplianceRules(rules ComplianceRules) error {
+	if rules.Version == "" {
+		return fmt.Errorf("compliance rules need a version")
+	}
+	if len(rules.Ratings) == 0 {
+		return fmt.Errorf("compliance rules need at least one rating")
+	}
+	seen := make(map[string]bool)
+	for _, rating := range rules.Ratings {
+		if rating.ESRB == "" {
+			return fmt.Errorf("compliance ratings need an esrb rating")
+		}
+		key := strings.ToLower(rating.ESRB)
+		if seen[key] {
+			return fmt.Errorf("compliance rating %s is listed more than once", rating.ESRB)
+		}
+		seen[key] = true
+	}
+	r.compliance = &rules
+	return nil
+}
+
+// complianceFile is the YAML form of ComplianceRules:
+//
+//	version: studio-compliance/1
+//	ratings:
+//	  - esrb: E
+//	    pegi: ["3", "7"]
+//	    forbidden_monetization: [Gambling]
+//	    forbidden_audiences: [Adults]
+//	    forbidden_tones: [Gore]
+type complianceFile struct {
+	Version string `yaml:"version"`
+	Ratings []struct {
+		ESRB                  string   `yaml:"esrb"`
+		PEGI                  []string `yaml:"pegi"`
+		ForbiddenMonetization []string `yaml:"forbidden_monetization"`
+		ForbiddenAudiences    []string `yaml:"forbidden_audiences"`
+		ForbiddenTones        []string `yaml:"forbidden_tones"`
+	} `yaml:"ratings"`
+}
+
+// ParseComplianceRules parses a YAML compliance file. The result still has
+// to be passed to SetComplianceRules, which checks it.
+func ParseComplianceRules(data []byte) (ComplianceRules, error) {
+	var file complianceFile
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return ComplianceRules{}, fmt.Errorf("invalid compliance file: %w", err)
+	}
+	rules := ComplianceRules{Version: file.Version}
+	for _, r := range file.Ratings {
+		rules.Ratings = append(rules.Ratings, ComplianceRating{
+			ESRB:                  r.ESRB,
+			PEGI:                  r.PEGI,
+			ForbiddenMonetization: r.ForbiddenMonetization,
+			ForbiddenAudiences:    r.ForbiddenAudiences,
+			ForbiddenTones:        r.ForbiddenTones,
+		})
+	}
+	return rules, nil
+}
+
+// containsFold reports whether values holds value, ignoring case.
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// checkCompliance adds an error to resp for each value dna combines with its
+// ESRB rating against rules, with the ratings that would allow it.
+func checkCompliance(rules *ComplianceRules, dna *pb.GameDNA, resp *pb.ValidationResponse) {
+	if rules == nil || dna.EsrbRating == "" {
+		return
+	}
+	at := slices.IndexFunc(rules.Ratings, func(r ComplianceRating) bool { return strings.EqualFold(r.ESRB, dna.EsrbRating) })
+	if at < 0 {
+		return
+	}
+	rating := rules.Ratings[at]
+
+	// allowedFrom names the lowest rating above dna's that doesn't forbid
+	// value, or "" when none does.
+	allowedFrom := func(value string, forbidden func(ComplianceRating) []string) string {
+		for _, r := range rules.Ratings[at+1:] {
+			if !containsFold(forbidden(r), value) {
+				return r.ESRB
+			}
+		}
+		return ""
+	}
+	for _, check := range []struct {
+		code, field, label string
+		value              string
+		forbidden          func(ComplianceRating) []string
+	}{
+		{"RATING_MONETIZATION_CONFLICT", "monetization", "monetization", dna.Monetization,
+			func(r ComplianceRating) []string { return r.ForbiddenMonetization }},
+		{"RATING_AUDIENCE_CONFLICT", "target_audience", "target audience", dna.TargetAudience,
+			func(r ComplianceRating) []string { return r.ForbiddenAudiences }},
+		{"RATING_TONE_CONFLICT", "tone", "tone", dna.Tone,
+			func(r ComplianceRating) []string { return r.ForbiddenTones }},
+	} {
+		if check.value == "" || !containsFold(check.forbidden(rating), check.value) {
+			continue
+		}
+		details := fmt.Sprintf("Choose another %s; no rating allows %s", check.label, check.value)
+		if above := allowedFrom(check.value, check.forbidden); above != "" {
+			details = fmt.Sprintf("Rate the game %s or higher, or choose another %s", above, check.label)
+		}
+		resp.IsValid = false
+		resp.Errors = append(resp.Errors, &pb.ValidationError{
+			Code:    check.code,
+			Field:   check.field,
+			Message: fmt.Sprintf("ESRB %s cannot be combined with %s %s", rating.ESRB, check.label, check.value),
+			Details: details,
+		})
+	}
+
+	if pegi := dna.CustomProperties[PEGIProperty]; pegi != "" && len(rating.PEGI) > 0 && !containsFold(rating.PEGI, pegi) {
+		resp.IsValid = false
+		resp.Errors = append(resp.Errors, &pb.ValidationError{
+			Code:    "RATING_PEGI_MISMATCH",
+			Field:   "custom_properties." + PEGIProperty,
+			Message: fmt.Sprintf("PEGI %s does not match ESRB %s", pegi, rating.ESRB),
+			Details: fmt.Sprintf("ESRB %s corresponds to PEGI %s; change one of the ratings", rating.ESRB, strings.Join(rating.PEGI, " or ")),
+		})
+	}
+}
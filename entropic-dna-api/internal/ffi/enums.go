@@ -0,0 +1,90 @@
+package ffi
+
+// EnumValue is one value a string field is known to take.
+type EnumValue struct {
+	Value       string
+	Description string
+}
+
+// FieldEnum lists the known values of a string field.
+type FieldEnum struct {
+	Field  string // proto field name
+	Values []EnumValue
+	// AllowsCustom means the engine also accepts other values, as custom
+	// ones, so editors should offer a free-text option besides the list.
+	AllowsCustom bool
+}
+
+// fieldEnums mirror the enums of entropic-dna-core's schema module, with the
+// names they serialize as. Every engine enum has a Custom variant.
+var fieldEnums = []FieldEnum{
+	{Field: "genre", AllowsCustom: true, Values: []EnumValue{
+		{"FPS", "First person shooter"},
+		{"RPG", "Role playing game"},
+		{"TPS", "Third person shooter"},
+		{"Strategy", "Strategy game"},
+		{"Casual", "Casual game"},
+		{"Horror", "Horror game"},
+		{"Racing", "Racing game"},
+		{"Simulation", "Simulation game"},
+		{"Puzzle", "Puzzle game"},
+		{"Educational", "Educational game"},
+	}},
+	{Field: "camera", AllowsCustom: true, Values: []EnumValue{
+		{"Perspective2D", "2D perspective"},
+		{"Perspective2_5D", "2.5D perspective (2D with depth)"},
+		{"Perspective3D", "3D perspective"},
+		{"Isometric", "Isometric view"},
+		{"VR", "Virtual reality"},
+	}},
+	{Field: "tone", AllowsCustom: true, Values: []EnumValue{
+		{"Realistic", "Realistic tone"},
+		{"Arcade", "Arcade-style tone"},
+		{"Cinematic", "Cinematic presentation"},
+		{"Stylized", "Stylized visuals"},
+		{"Minimalist", "Minimalist design"},
+	}},
+	{Field: "world_scale", AllowsCustom: true, Values: []EnumValue{
+		{"TinyLevel", "Small enclosed level"},
+		{"SmallLevel", "Small level"},
+		{"MediumLevel", "Medium-sized level"},
+		{"LargeLevel", "Large level"},
+		{"OpenWorld", "Open world environment"},
+		{"Planet", "Planet-scale world"},
+		{"Galaxy", "Galaxy-scale world"},
+	}},
+	{Field: "physics_profile", AllowsCustom: true, Values: []EnumValue{
+		{"Arcade", "Arcade-style physics (fast, forgiving)"},
+		{"SemiRealistic", "Semi-realistic physics"},
+		{"Realistic", "Realistic physics simulation"},
+	}},
+	{Field: "difficulty", AllowsCustom: true, Values: []EnumValue{
+		{"Easy", "Easy difficulty setting"},
+		{"Medium", "Medium difficulty setting"},
+		{"Hard", "Hard difficulty setting"},
+		{"Dynamic", "Dynamic/adaptive difficulty"},
+	}},
+	{Field: "monetization", AllowsCustom: true, Values: []EnumValue{
+		{"FreeToPlay", "Free to play with optional purchases"},
+		{"PremiumBuy", "Premium purchase required"},
+		{"Subscription", "Subscription-based access"},
+		{"OneTimePay", "One-time purchase"},
+		{"Hybrid", "Hybrid monetization model"},
+	}},
+	// The engine keeps the rating as free text; these are the ESRB's own.
+	{Field: "esrb_rating", AllowsCustom: true, Values: []EnumValue{
+		{"E", "Everyone"},
+		{"E10+", "Everyone 10+"},
+		{"T", "Teen"},
+		{"M", "Mature 17+"},
+		{"AO", "Adults Only 18+"},
+		{"RP", "Rating Pending"},
+	}},
+}
+
+// FieldEnums returns the known values of GameDNA's enum-like string fields,
+// in schema order.
+func (r *RustFFI) FieldEnums() []FieldEnum {
+	// TODO: Read the enums from the Rust library once it is loaded
+	return fieldEnums
+}
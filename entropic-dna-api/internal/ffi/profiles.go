@@ -0,0 +1,156 @@
+package ffi
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProjectProperty is the custom property naming a config's project, whose
+// profile overrides apply to it.
+const ProjectProperty = "project"
+
+// DefaultProfile is the profile of genres without one of their own.
+const DefaultProfile = "default"
+
+// Profile holds the thresholds and required fields a genre is validated
+// against. Zero limits are not checked.
+type Profile struct {
+	Name           string
+	MinFPS         uint32   // target_fps below this is an error
+	MaxEntities    uint32   // max_entities above this is a warning
+	MaxNPCCount    uint32   // max_npc_count above this is a warning
+	MaxPlayers     uint32   // max_players above this is a warning
+	RequiredFields []string // proto field names that must be set
+}
+
+// profiles are the built-in profiles, by lowercased genre.
+var profiles = map[string]Profile{
+	DefaultProfile: {Name: DefaultProfile, MinFPS: 1},
+	"fps":          {Name: "fps", MinFPS: 30, MaxEntities: 2000, MaxNPCCount: 200, MaxPlayers: 128},
+	"rpg":          {Name: "rpg", MinFPS: 30, MaxEntities: 20000, MaxNPCCount: 5000},
+	// Strategy games simulate whole armies at lower frame rates.
+	"rts":    {Name: "rts", MinFPS: 20, MaxEntities: 200000, MaxNPCCount: 50000, RequiredFields: []string{"camera"}},
+	"puzzle": {Name: "puzzle", MinFPS: 1, MaxEntities: 1000, MaxNPCCount: 50, MaxPlayers: 8},
+	// Below 72 FPS head tracking lags enough to cause motion sickness.
+	"vr": {Name: "vr", MinFPS: 72, MaxEntities: 5000, MaxNPCCount: 500, MaxPlayers: 16, RequiredFields: []string{"camera"}},
+}
+
+// ProfileOverride adjusts the profile of one project's configs. Zero fields
+// keep the profile's values.
+type ProfileOverride struct {
+	Profile        string // Use this built-in profile instead of the genre's
+	MinFPS         uint32
+	MaxEntities    uint32
+	MaxNPCCount    uint32
+	MaxPlayers     uint32
+	RequiredFields []string // Replaces the profile's when set
+}
+
+// SetProfileOverrides applies overrides, by project, to configs whose
+// "project" custom property names that project. Call it before the binding
+// is shared.
+func (r *RustFFI) SetProfileOverrides(overrides map[string]ProfileOverride) error {
+	fields := (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields()
+	for project, o := range overrides {
+		if o.Profile != "" {
+			if _, ok := profiles[strings.ToLower(o.Profile)]; !ok {
+				return fmt.Errorf("project %s: unknown validation profile %q", project, o.Profile)
+			}
+		}
+		for _, name := range o.RequiredFields {
+			if fields.ByName(protoreflect.Name(name)) == nil {
+				return fmt.Errorf("project %s: unknown required field %q", project, name)
+			}
+		}
+	}
+	r.overrides = overrides
+	return nil
+}
+
+// profile returns the profile dna is validated against: its genre's, or the
+// one its project picks, with the project's overrides applied.
+func (r *RustFFI) profile(dna *pb.GameDNA) Profile {
+	override, ok := r.overrides[dna.CustomProperties[ProjectProperty]]
+	name := strings.ToLower(dna.Genre)
+	if ok && override.Profile != "" {
+		name = strings.ToLower(override.Profile)
+	}
+	p, found := profiles[name]
+	if !found {
+		p = profiles[DefaultProfile]
+	}
+	if !ok {
+		return p
+	}
+
+	if override.MinFPS != 0 {
+		p.MinFPS = override.MinFPS
+	}
+	if override.MaxEntities != 0 {
+		p.MaxEntities = override.MaxEntities
+	}
+	if override.MaxNPCCount != 0 {
+		p.MaxNPCCount = override.MaxNPCCount
+	}
+	if override.MaxPlayers != 0 {
+		p.MaxPlayers = override.MaxPlayers
+	}
+	if len(override.RequiredFields) > 0 {
+		p.RequiredFields = override.RequiredFields
+	}
+	return p
+}
+
+// checkProfile adds p's errors and warnings for dna to resp.
+func checkProfile(p Profile, dna *pb.GameDNA, resp *pb.ValidationResponse) {
+	resp.Profile = p.Name
+
+	msg := dna.ProtoReflect()
+	for _, name := range p.RequiredFields {
+		field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if field == nil || msg.Has(field) {
+			continue
+		}
+		resp.IsValid = false
+		resp.Errors = append(resp.Errors, &pb.ValidationError{
+			Code:    "MISSING_REQUIRED_FIELD",
+			Field:   name,
+			Message: fmt.Sprintf("%s is required by the %s profile", name, p.Name),
+			Details: fmt.Sprintf("The %s validation profile requires: %s", p.Name, strings.Join(p.RequiredFields, ", ")),
+		})
+	}
+
+	// Out-of-range frame rates are reported by the basic FPS check.
+	if dna.TargetFps > 0 && dna.TargetFps <= 1000 && dna.TargetFps < p.MinFPS {
+		resp.IsValid = false
+		resp.Errors = append(resp.Errors, &pb.ValidationError{
+			Code:    "FPS_BELOW_PROFILE_MINIMUM",
+			Field:   "target_fps",
+			Message: fmt.Sprintf("Target FPS must be at least %d for the %s profile", p.MinFPS, p.Name),
+			Details: fmt.Sprintf("Current value: %d", dna.TargetFps),
+		})
+	}
+
+	for _, limit := range []struct {
+		field string
+		value uint32
+		max   uint32
+	}{
+		{"max_entities", dna.MaxEntities, p.MaxEntities},
+		{"max_npc_count", dna.MaxNpcCount, p.MaxNPCCount},
+		{"max_players", dna.MaxPlayers, p.MaxPlayers},
+	} {
+		if limit.max == 0 || limit.value <= limit.max {
+			continue
+		}
+		resp.Warnings = append(resp.Warnings, &pb.ValidationWarning{
+			Code:       "ABOVE_PROFILE_LIMIT",
+			Field:      limit.field,
+			Message:    fmt.Sprintf("%s of %d is above the %s profile's limit of %d", limit.field, limit.value, p.Name, limit.max),
+			Suggestion: fmt.Sprintf("Lower %s, or raise the limit for the project in validation.projects", limit.field),
+		})
+	}
+}
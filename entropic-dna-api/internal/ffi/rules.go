@@ -0,0 +1,478 @@
+package ffi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule severities.
+const (
+	SeverityError      = "error"      // fails validation
+	SeverityWarning    = "warning"    // reported, counts against publish_max_warnings
+	SeveritySuggestion = "suggestion" // reported as a suggestion string only
+)
+
+// Rule is one validation check. A config that doesn't satisfy Check gets a
+// finding of the rule's severity, coded with its ID.
+type Rule struct {
+	ID       string
+	Severity string
+	// Field is the path the finding is reported against: a proto field
+	// name, or custom_properties.<key>. Suggestions have none.
+	Field   string
+	Message string
+	// Hint becomes an error's details or a warning's suggestion. {path}
+	// placeholders are replaced with the config's value at path.
+	Hint string
+	// Check reports whether dna satisfies the rule.
+	Check func(dna *pb.GameDNA) bool
+}
+
+// RuleSet is a named set of rules checked after the built-in ones. Reports
+// made with it carry "<BasicRulesVersion>+<Version>" as their rules version.
+type RuleSet struct {
+	Version string
+	Rules   []Rule
+}
+
+// builtinRules are the checks every config gets, before its profile's.
+var builtinRules = []Rule{
+	{
+		ID:       "EMPTY_NAME",
+		Severity: SeverityError,
+		Field:    "name",
+		Message:  "Game name cannot be empty",
+		Hint:     "The game name field is required and must contain at least one character",
+		Check:    func(dna *pb.GameDNA) bool { return dna.Name != "" },
+	},
+	{
+		ID:       "NO_PLATFORMS",
+		Severity: SeverityError,
+		Field:    "target_platforms",
+		Message:  "At least one target platform must be specified",
+		Hint:     "Valid platforms include PC, Console, Mobile, VR, Web",
+		Check:    func(dna *pb.GameDNA) bool { return len(dna.TargetPlatforms) > 0 },
+	},
+	{
+		ID:       "INVALID_FPS",
+		Severity: SeverityError,
+		Field:    "target_fps",
+		Message:  "Target FPS must be between 1 and 1000",
+		Hint:     "Current value: {target_fps}",
+		Check:    func(dna *pb.GameDNA) bool { return dna.TargetFps > 0 && dna.TargetFps <= 1000 },
+	},
+	{
+		ID:       "INVALID_TIME_SCALE",
+		Severity: SeverityError,
+		Field:    "time_scale",
+		Message:  "Time scale must be positive and reasonable",
+		Hint:     "Current value: {time_scale}",
+		Check:    func(dna *pb.GameDNA) bool { return dna.TimeScale > 0 && dna.TimeScale <= 1000 },
+	},
+	{
+		ID:       "MISSING_GENRE",
+		Severity: SeverityWarning,
+		Field:    "genre",
+		Message:  "No genre specified",
+		Hint:     "Consider specifying a genre (FPS, RPG, Strategy, etc.)",
+		Check:    func(dna *pb.GameDNA) bool { return dna.Genre != "" },
+	},
+	{
+		ID:       "MULTIPLAYER_NOT_CONFIGURED",
+		Severity: SeverityWarning,
+		Field:    "max_players",
+		Message:  "Multiplayer capacity set but no competitive or coop mode enabled",
+		Hint:     "Set is_competitive or supports_coop to true",
+		Check: func(dna *pb.GameDNA) bool {
+			return dna.MaxPlayers <= 1 || dna.IsCompetitive || dna.SupportsCoop
+		},
+	},
+	{
+		ID:       "CAMERA_FOR_GENRE",
+		Severity: SeveritySuggestion,
+		Message:  "Consider specifying a camera mode that matches your genre",
+		Check:    func(dna *pb.GameDNA) bool { return dna.Genre == "" || dna.Camera != "" },
+	},
+	{
+		ID:       "AI_WITHOUT_NPCS",
+		Severity: SeveritySuggestion,
+		Message:  "AI is enabled but NPC count is 0 - consider setting npc_count > 0",
+		Check:    func(dna *pb.GameDNA) bool { return !dna.AiEnabled || dna.NpcCount > 0 },
+	},
+}
+
+// SetRuleSet adds set's rules to the built-in ones. Rule IDs must be unique
+// and their fields must exist. Call it before the binding is shared.
+func (r *RustFFI) SetRuleSet(set RuleSet) error {
+	if len(set.Rules) > 0 && set.Version == "" {
+		return fmt.Errorf("rule set needs a version")
+	}
+	ids := make(map[string]bool)
+	for _, rule := range builtinRules {
+		ids[rule.ID] = true
+	}
+	for _, rule := range set.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule needs an id")
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("rule %s is defined more than once", rule.ID)
+		}
+		ids[rule.ID] = true
+		switch rule.Severity {
+		case SeverityError, SeverityWarning, SeveritySuggestion:
+		default:
+			return fmt.Errorf("rule %s: unknown severity %q", rule.ID, rule.Severity)
+		}
+		if rule.Field != "" {
+			if _, err := resolveField(rule.Field); err != nil {
+				return fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+		if rule.Message == "" {
+			return fmt.Errorf("rule %s needs a message", rule.ID)
+		}
+		if rule.Check == nil {
+			return fmt.Errorf("rule %s needs a check", rule.ID)
+		}
+	}
+	r.rules = set
+	return nil
+}
+
+// RulesVersion identifies the rules configs are validated against: the
+// built-in ones, then the rule set's and the compliance rules' when set.
+func (r *RustFFI) RulesVersion() string {
+	version := BasicRulesVersion
+	if len(r.rules.Rules) > 0 {
+		version += "+" + r.rules.Version
+	}
+	if r.compliance != nil {
+		version += "+" + r.compliance.Version
+	}
+	return version
+}
+
+// checkRules adds the findings of rules for dna to resp.
+func checkRules(rules []Rule, dna *pb.GameDNA, resp *pb.ValidationResponse) {
+	for _, rule := range rules {
+		if rule.Check(dna) {
+			continue
+		}
+		switch rule.Severity {
+		case SeverityError:
+			resp.IsValid = false
+			resp.Errors = append(resp.Errors, &pb.ValidationError{
+				Code:    rule.ID,
+				Field:   rule.Field,
+				Message: rule.Message,
+				Details: expandHint(rule.Hint, dna),
+			})
+		case SeverityWarning:
+			resp.Warnings = append(resp.Warnings, &pb.ValidationWarning{
+				Code:       rule.ID,
+				Field:      rule.Field,
+				Message:    rule.Message,
+				Suggestion: expandHint(rule.Hint, dna),
+			})
+		default:
+			resp.Suggestions = append(resp.Suggestions, rule.Message)
+		}
+	}
+}
+
+var hintPlaceholder = regexp.MustCompile(`\{([a-z0-9_.]+)\}`)
+
+// expandHint replaces the {path} placeholders of hint with dna's values.
+// Unknown paths are left as they are.
+func expandHint(hint string, dna *pb.GameDNA) string {
+	return hintPlaceholder.ReplaceAllStringFunc(hint, func(placeholder string) string {
+		path, err := resolveField(strings.Trim(placeholder, "{}"))
+		if err != nil {
+			return placeholder
+		}
+		value, _ := path.value(dna)
+		return formatFieldValue(value)
+	})
+}
+
+// fieldPath is a resolved rule field: a GameDNA field, and for map fields
+// the key within it.
+type fieldPath struct {
+	field protoreflect.FieldDescriptor
+	key   string
+}
+
+// resolveField resolves a proto field name, or <map field>.<key>.
+func resolveField(path string) (fieldPath, error) {
+	name, key, keyed := strings.Cut(path, ".")
+	field := (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(name))
+	switch {
+	case field == nil:
+		return fieldPath{}, fmt.Errorf("unknown field %q", path)
+	case field.IsMap() && !keyed:
+		return fieldPath{}, fmt.Errorf("field %s needs a key, as in %s.<key>", name, name)
+	case field.IsMap():
+		return fieldPath{field: field, key: key}, nil
+	case keyed:
+		return fieldPath{}, fmt.Errorf("field %s has no keys", name)
+	case field.Kind() == protoreflect.MessageKind && !field.IsList():
+		return fieldPath{}, fmt.Errorf("field %s is not a scalar or list", name)
+	}
+	return fieldPath{field: field}, nil
+}
+
+// value returns dna's value at p, and whether it's set. Lists are returned
+// whole; map entries that are missing are unset.
+func (p fieldPath) value(dna *pb.GameDNA) (interface{}, bool) {
+	msg := dna.ProtoReflect()
+	switch {
+	case p.field.IsMap():
+		v := msg.Get(p.field).Map().Get(protoreflect.ValueOfString(p.key).MapKey())
+		if !v.IsValid() {
+			return "", false
+		}
+		return v.String(), true
+	case p.field.IsList():
+		list := msg.Get(p.field).List()
+		values := make([]string, list.Len())
+		for i := range values {
+			values[i] = list.Get(i).String()
+		}
+		return values, list.Len() > 0
+	}
+	return msg.Get(p.field).Interface(), msg.Has(p.field)
+}
+
+// formatFieldValue renders a field value for hints and comparisons, with
+// floats in fixed-point notation.
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case float32, float64:
+		return fmt.Sprintf("%f", v)
+	case []string:
+		return strings.Join(v, ", ")
+	case protoreflect.EnumNumber:
+		return fmt.Sprint(int32(v))
+	}
+	return fmt.Sprint(value)
+}
+
+// ruleFile is the YAML form of a RuleSet:
+//
+//	version: studio/1
+//	rules:
+//	  - id: LOOTBOX_NEEDS_ESRB
+//	    severity: error
+//	    field: esrb_rating
+//	    message: Configs with loot boxes must have an ESRB rating
+//	    when:
+//	      - {field: monetization, equals: Lootbox}
+//	    require:
+//	      - {field: esrb_rating, set: true}
+type ruleFile struct {
+	Version string `yaml:"version"`
+	Rules   []struct {
+		ID       string          `yaml:"id"`
+		Severity string          `yaml:"severity"`
+		Field    string          `yaml:"field"`
+		Message  string          `yaml:"message"`
+		Hint     string          `yaml:"hint"`
+		When     []ruleCondition `yaml:"when"`    // the rule applies when all hold
+		Require  []ruleCondition `yaml:"require"` // and is satisfied when all hold
+	} `yaml:"rules"`
+}
+
+// ruleCondition tests one field. Exactly one test is given. String
+// comparisons ignore case; list fields pass equals and in when any element
+// does.
+type ruleCondition struct {
+	Field     string   `yaml:"field"`
+	Equals    *string  `yaml:"equals"`
+	NotEquals *string  `yaml:"not_equals"`
+	In        []string `yaml:"in"`
+	Set       *bool    `yaml:"set"`
+	Min       *float64 `yaml:"min"`
+	Max       *float64 `yaml:"max"`
+	Matches   *string  `yaml:"matches"`
+}
+
+// ParseRuleSet parses a YAML rules file. The result still has to be passed
+// to SetRuleSet, which checks it.
+func ParseRuleSet(data []byte) (RuleSet, error) {
+	var file ruleFile
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return RuleSet{}, fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	set := RuleSet{Version: file.Version}
+	for _, r := range file.Rules {
+		if len(r.Require) == 0 {
+			return RuleSet{}, fmt.Errorf("rule %s needs at least one require condition", r.ID)
+		}
+		when, err := compileConditions(r.When)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		require, err := compileConditions(r.Require)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		set.Rules = append(set.Rules, Rule{
+			ID:       r.ID,
+			Severity: strings.ToLower(r.Severity),
+			Field:    r.Field,
+			Message:  r.Message,
+			Hint:     r.Hint,
+			Check: func(dna *pb.GameDNA) bool {
+				return !when(dna) || require(dna)
+			},
+		})
+	}
+	return set, nil
+}
+
+// compileConditions returns a predicate that holds when all conds do.
+func compileConditions(conds []ruleCondition) (func(*pb.GameDNA) bool, error) {
+	tests := make([]func(*pb.GameDNA) bool, 0, len(conds))
+	for _, c := range conds {
+		test, err := c.compile()
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, test)
+	}
+	return func(dna *pb.GameDNA) bool {
+		for _, test := range tests {
+			if !test(dna) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (c ruleCondition) compile() (func(*pb.GameDNA) bool, error) {
+	path, err := resolveField(c.Field)
+	if err != nil {
+		return nil, err
+	}
+	given := 0
+	for _, set := range []bool{c.Equals != nil, c.NotEquals != nil, c.In != nil, c.Set != nil, c.Min != nil, c.Max != nil, c.Matches != nil} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, fmt.Errorf("condition on %s must have exactly one of equals, not_equals, in, set, min, max or matches", c.Field)
+	}
+
+	// anyValue reports whether match holds for the value at path, or for any
+	// element of a list.
+	anyValue := func(dna *pb.GameDNA, match func(string) bool) bool {
+		value, _ := path.value(dna)
+		if list, ok := value.([]string); ok {
+			for _, v := range list {
+				if match(v) {
+					return true
+				}
+			}
+			return false
+		}
+		return match(formatFieldValue(value))
+	}
+	numeric := func() error {
+		switch path.field.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+			protoreflect.Sint32Kind, protoreflect.Sint64Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+			if !path.field.IsList() && !path.field.IsMap() {
+				return nil
+			}
+		}
+		return fmt.Errorf("min and max need a numeric field, not %s", c.Field)
+	}
+	number := func(dna *pb.GameDNA) float64 {
+		value, _ := path.value(dna)
+		switch v := value.(type) {
+		case int32:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case uint32:
+			return float64(v)
+		case uint64:
+			return float64(v)
+		case float32:
+			return float64(v)
+		case float64:
+			return v
+		}
+		return 0
+	}
+
+	switch {
+	case c.Equals != nil:
+		want := *c.Equals
+		return func(dna *pb.GameDNA) bool {
+			return anyValue(dna, func(v string) bool { return valuesEqual(v, want) })
+		}, nil
+	case c.NotEquals != nil:
+		want := *c.NotEquals
+		return func(dna *pb.GameDNA) bool {
+			return !anyValue(dna, func(v string) bool { return valuesEqual(v, want) })
+		}, nil
+	case c.In != nil:
+		return func(dna *pb.GameDNA) bool {
+			return anyValue(dna, func(v string) bool {
+				for _, want := range c.In {
+					if valuesEqual(v, want) {
+						return true
+					}
+				}
+				return false
+			})
+		}, nil
+	case c.Set != nil:
+		want := *c.Set
+		return func(dna *pb.GameDNA) bool {
+			_, set := path.value(dna)
+			return set == want
+		}, nil
+	case c.Min != nil:
+		if err := numeric(); err != nil {
+			return nil, err
+		}
+		min := *c.Min
+		return func(dna *pb.GameDNA) bool { return number(dna) >= min }, nil
+	case c.Max != nil:
+		if err := numeric(); err != nil {
+			return nil, err
+		}
+		max := *c.Max
+		return func(dna *pb.GameDNA) bool { return number(dna) <= max }, nil
+	}
+	re, err := regexp.Compile(*c.Matches)
+	if err != nil {
+		return nil, fmt.Errorf("condition on %s: invalid pattern: %w", c.Field, err)
+	}
+	return func(dna *pb.GameDNA) bool { return anyValue(dna, re.MatchString) }, nil
+}
+
+// valuesEqual compares a field value with a rule's, ignoring case, and
+// numerically when both are numbers, so "1" equals a time_scale of 1.
+func valuesEqual(value, want string) bool {
+	a, aErr := strconv.ParseFloat(value, 64)
+	b, bErr := strconv.ParseFloat(want, 64)
+	if aErr == nil && bErr == nil {
+		return a == b
+	}
+	return strings.EqualFold(value, want)
+}
@@ -1,17 +1,50 @@
 package ffi
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sync"
+	"time"
 
 	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/faults"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"google.golang.org/protobuf/proto"
 )
 
+// BasicRulesVersion identifies the Go fallback rule set. Bump it whenever
+// the built-in rules or profiles change so stored reports can be told apart.
+const BasicRulesVersion = "go-basic/2"
+
+// Validation engines reported in metrics. EngineGoFallback means Rust was
+// enabled but the Go rules answered instead.
+const (
+	EngineRust       = "rust"
+	EngineGo         = "go"
+	EngineGoFallback = "go_fallback"
+)
+
+// checksumBuffers holds encoding buffers reused by basicChecksum.
+var checksumBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
 // RustFFI provides an interface to the Rust Game DNA validation engine.
 // This is a stub implementation until the Rust library is compiled and available.
 type RustFFI struct {
 	enabled bool
 	libPath string
+	faults  *faults.Injector
+
+	overrides  map[string]ProfileOverride // validation profile overrides by project
+	rules      RuleSet                    // rules checked after the built-in ones
+	compliance *ComplianceRules           // nil unless compliance is checked
 }
 
 // NewRustFFI creates a new Rust FFI binding.
@@ -29,101 +62,56 @@ func NewRustFFI(libPath string, enabled bool) (*RustFFI, error) {
 	}, nil
 }
 
+// SetFaults makes the binding inject the "ffi.<Method>" faults of inj.
+func (r *RustFFI) SetFaults(inj *faults.Injector) {
+	r.faults = inj
+}
+
 // ValidateGameDNA validates a GameDNA configuration using the Rust engine.
 func (r *RustFFI) ValidateGameDNA(dna *pb.GameDNA) (*pb.ValidationResponse, error) {
+	if err := r.faults.Inject(context.Background(), "ffi.ValidateGameDNA"); err != nil {
+		return nil, err
+	}
+	start := time.Now()
 	if !r.enabled {
-		return r.basicValidation(dna), nil
+		resp := r.basicValidation(dna)
+		metrics.ObserveValidation(EngineGo, resp, time.Since(start))
+		return resp, nil
 	}
 
 	// TODO: Call Rust FFI function
 	// For now, fallback to basic validation
 
-	return r.basicValidation(dna), nil
+	resp := r.basicValidation(dna)
+	metrics.ObserveValidation(EngineGoFallback, resp, time.Since(start))
+	return resp, nil
 }
 
 // basicValidation provides basic Go-side validation as a fallback.
 func (r *RustFFI) basicValidation(dna *pb.GameDNA) *pb.ValidationResponse {
 	resp := &pb.ValidationResponse{
-		IsValid:     true,
-		Errors:      []*pb.ValidationError{},
-		Warnings:    []*pb.ValidationWarning{},
-		Suggestions: []string{},
-	}
-
-	// Basic field validation
-	if dna.Name == "" {
-		resp.IsValid = false
-		resp.Errors = append(resp.Errors, &pb.ValidationError{
-			Code:    "EMPTY_NAME",
-			Field:   "name",
-			Message: "Game name cannot be empty",
-			Details: "The game name field is required and must contain at least one character",
-		})
-	}
-
-	if len(dna.TargetPlatforms) == 0 {
-		resp.IsValid = false
-		resp.Errors = append(resp.Errors, &pb.ValidationError{
-			Code:    "NO_PLATFORMS",
-			Field:   "target_platforms",
-			Message: "At least one target platform must be specified",
-			Details: "Valid platforms include PC, Console, Mobile, VR, Web",
-		})
-	}
-
-	if dna.TargetFps == 0 || dna.TargetFps > 1000 {
-		resp.IsValid = false
-		resp.Errors = append(resp.Errors, &pb.ValidationError{
-			Code:    "INVALID_FPS",
-			Field:   "target_fps",
-			Message: "Target FPS must be between 1 and 1000",
-			Details: fmt.Sprintf("Current value: %d", dna.TargetFps),
-		})
+		IsValid:      true,
+		Errors:       []*pb.ValidationError{},
+		Warnings:     []*pb.ValidationWarning{},
+		Suggestions:  []string{},
+		RulesVersion: r.RulesVersion(),
 	}
 
-	if dna.TimeScale <= 0 || dna.TimeScale > 1000 {
-		resp.IsValid = false
-		resp.Errors = append(resp.Errors, &pb.ValidationError{
-			Code:    "INVALID_TIME_SCALE",
-			Field:   "time_scale",
-			Message: "Time scale must be positive and reasonable",
-			Details: fmt.Sprintf("Current value: %f", dna.TimeScale),
-		})
-	}
-
-	// Warnings
-	if dna.Genre == "" {
-		resp.Warnings = append(resp.Warnings, &pb.ValidationWarning{
-			Code:       "MISSING_GENRE",
-			Field:      "genre",
-			Message:    "No genre specified",
-			Suggestion: "Consider specifying a genre (FPS, RPG, Strategy, etc.)",
-		})
-	}
-
-	if dna.MaxPlayers > 1 && !dna.IsCompetitive && !dna.SupportsCoop {
-		resp.Warnings = append(resp.Warnings, &pb.ValidationWarning{
-			Code:       "MULTIPLAYER_NOT_CONFIGURED",
-			Field:      "max_players",
-			Message:    "Multiplayer capacity set but no competitive or coop mode enabled",
-			Suggestion: "Set is_competitive or supports_coop to true",
-		})
-	}
-
-	// Suggestions
-	if dna.Genre != "" && dna.Camera == "" {
-		resp.Suggestions = append(resp.Suggestions, "Consider specifying a camera mode that matches your genre")
-	}
-
-	if dna.AiEnabled && dna.NpcCount == 0 {
-		resp.Suggestions = append(resp.Suggestions, "AI is enabled but NPC count is 0 - consider setting npc_count > 0")
-	}
+	// Built-in rules, the genre profile's thresholds and required fields,
+	// then any configured rules and the age rating's compliance
+	checkRules(builtinRules, dna, resp)
+	checkProfile(r.profile(dna), dna, resp)
+	checkRules(r.rules.Rules, dna, resp)
+	checkCompliance(r.compliance, dna, resp)
 
 	return resp
 }
 
 // CalculateChecksum generates a checksum for a GameDNA configuration.
 func (r *RustFFI) CalculateChecksum(dna *pb.GameDNA) (string, error) {
+	if err := r.faults.Inject(context.Background(), "ffi.CalculateChecksum"); err != nil {
+		return "", err
+	}
 	if !r.enabled {
 		return r.basicChecksum(dna)
 	}
@@ -132,17 +120,36 @@ func (r *RustFFI) CalculateChecksum(dna *pb.GameDNA) (string, error) {
 	return r.basicChecksum(dna)
 }
 
-// basicChecksum provides basic Go-side checksum calculation.
+// basicChecksum provides basic Go-side checksum calculation. It hashes the
+// deterministic encoding of the config content, ignoring server-managed
+// metadata, so identical payloads always produce the same checksum.
 func (r *RustFFI) basicChecksum(dna *pb.GameDNA) (string, error) {
-	// Simple JSON-based checksum for now
-	// In production, this should use the Rust deterministic serialization
-	jsonData, err := json.Marshal(dna)
+	content := models.Clone(dna)
+	models.ClearServerFields(content)
+
+	// Bulk paths checksum thousands of configs; reuse the encoding buffers.
+	buf := checksumBuffers.Get().(*[]byte)
+	defer checksumBuffers.Put(buf)
+
+	data, err := proto.MarshalOptions{Deterministic: true}.MarshalAppend((*buf)[:0], content)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal DNA for checksum: %w", err)
 	}
+	*buf = data
 
-	// Use a simple hash (in production, use SHA-256 from Rust)
-	return fmt.Sprintf("%x", len(jsonData)), nil
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Check reports whether the validation engine can serve requests, for
+// readiness checks. The Go rules are always available, so until the Rust
+// library is loaded it only fails when an "ffi.Check" fault is injected.
+func (r *RustFFI) Check(ctx context.Context) error {
+	if err := r.faults.Inject(ctx, "ffi.Check"); err != nil {
+		return err
+	}
+	// TODO: Check the Rust library is loaded
+	return nil
 }
 
 // Close closes the FFI binding and releases resources.
@@ -0,0 +1,254 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"go.uber.org/zap"
+)
+
+// Stage names a point in a config's lifecycle that hooks can run at.
+type Stage string
+
+// Lifecycle stages. Pre hooks run before the change is validated and
+// stored; post hooks run after it succeeded.
+const (
+	PreCreate   Stage = "pre_create"
+	PostCreate  Stage = "post_create"
+	PreUpdate   Stage = "pre_update"
+	PostUpdate  Stage = "post_update"
+	PrePublish  Stage = "pre_publish"
+	PostPublish Stage = "post_publish"
+)
+
+// Stages lists every stage in lifecycle order.
+var Stages = []Stage{PreCreate, PostCreate, PreUpdate, PostUpdate, PrePublish, PostPublish}
+
+// ParseStage returns the stage named name.
+func ParseStage(name string) (Stage, error) {
+	for _, stage := range Stages {
+		if string(stage) == name {
+			return stage, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hook stage %q", name)
+}
+
+// Pre reports whether hooks at the stage run before the change.
+func (s Stage) Pre() bool {
+	return strings.HasPrefix(string(s), "pre_")
+}
+
+// FailurePolicy says what a failing or timed-out pre hook does to the change.
+type FailurePolicy string
+
+const (
+	// FailClosed rejects the change.
+	FailClosed FailurePolicy = "fail"
+	// FailOpen logs the failure and carries on without the hook's result.
+	FailOpen FailurePolicy = "ignore"
+)
+
+// ParseFailurePolicy returns the policy named name.
+func ParseFailurePolicy(name string) (FailurePolicy, error) {
+	switch policy := FailurePolicy(name); policy {
+	case FailClosed, FailOpen:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown hook failure policy %q: want %s or %s", name, FailClosed, FailOpen)
+}
+
+// Event is what a hook is called with.
+type Event struct {
+	Stage   Stage
+	ID      string // Empty at pre_create
+	Actor   string // Authenticated caller, if any
+	GameDNA *pb.GameDNA
+}
+
+// Hook runs at the stages it is registered for. At pre_create and
+// pre_update it may return a changed config to carry on with, or nil to keep
+// the one it was given; everywhere else its result is ignored. An error
+// rejects the change if the hook's policy is FailClosed.
+type Hook interface {
+	Run(ctx context.Context, event Event) (*pb.GameDNA, error)
+}
+
+// HookFunc adapts a function to Hook.
+type HookFunc func(ctx context.Context, event Event) (*pb.GameDNA, error)
+
+// Run calls f.
+func (f HookFunc) Run(ctx context.Context, event Event) (*pb.GameDNA, error) {
+	return f(ctx, event)
+}
+
+// Options control one registered hook. Zero values use the registry's.
+type Options struct {
+	Timeout time.Duration
+	Policy  FailurePolicy
+}
+
+// RejectedError is returned when a pre hook rejects a change.
+type RejectedError struct {
+	Hook  string
+	Stage Stage
+	Err   error
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s hook %s: %v", e.Stage, e.Hook, e.Err)
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+type registered struct {
+	name string
+	hook Hook
+	opts Options
+}
+
+// Registry holds the hooks of each stage and runs them in registration
+// order. A nil *Registry runs nothing.
+type Registry struct {
+	defaults Options
+	logger   *zap.Logger
+
+	mu    sync.RWMutex
+	hooks map[Stage][]registered
+	names map[string]bool
+	wg    sync.WaitGroup
+}
+
+// NewRegistry creates an empty registry whose hooks run with defaults unless
+// registered with their own options.
+func NewRegistry(defaults Options, logger *zap.Logger) *Registry {
+	if defaults.Policy == "" {
+		defaults.Policy = FailClosed
+	}
+	return &Registry{
+		defaults: defaults,
+		logger:   logger,
+		hooks:    make(map[Stage][]registered),
+		names:    make(map[string]bool),
+	}
+}
+
+// Register adds hook at stages under a name unique within the registry,
+// used in logs and errors.
+func (r *Registry) Register(name string, hook Hook, opts Options, stages ...Stage) error {
+	if name == "" {
+		return errors.New("hook name is required")
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf("hook %s has no stages", name)
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = r.defaults.Timeout
+	}
+	if opts.Policy == "" {
+		opts.Policy = r.defaults.Policy
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		return fmt.Errorf("hook %s is registered more than once", name)
+	}
+	r.names[name] = true
+	for _, stage := range stages {
+		r.hooks[stage] = append(r.hooks[stage], registered{name: name, hook: hook, opts: opts})
+	}
+	return nil
+}
+
+func (r *Registry) at(stage Stage) []registered {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hooks[stage]
+}
+
+// RunPre runs the pre hooks of stage in order, each given the config the one
+// before returned, and returns the config to carry on with. Hooks are given
+// copies, so dna is never changed in place.
+func (r *Registry) RunPre(ctx context.Context, stage Stage, id, actor string, dna *pb.GameDNA) (*pb.GameDNA, error) {
+	for _, h := range r.at(stage) {
+		changed, err := r.run(ctx, h, Event{Stage: stage, ID: id, Actor: actor, GameDNA: models.Clone(dna)})
+		if err != nil {
+			if h.opts.Policy == FailOpen {
+				r.logger.Warn("Lifecycle hook failed; ignored",
+					zap.String("hook", h.name), zap.String("stage", string(stage)), zap.Error(err))
+				continue
+			}
+			return nil, &RejectedError{Hook: h.name, Stage: stage, Err: err}
+		}
+		if changed != nil {
+			dna = changed
+		}
+	}
+	return dna, nil
+}
+
+// RunPost runs the post hooks of stage in the background. Failures are
+// logged; the change has already been made.
+func (r *Registry) RunPost(stage Stage, id, actor string, dna *pb.GameDNA) {
+	for _, h := range r.at(stage) {
+		event := Event{Stage: stage, ID: id, Actor: actor, GameDNA: models.Clone(dna)}
+		r.wg.Add(1)
+		go func(h registered) {
+			defer r.wg.Done()
+			if _, err := r.run(context.Background(), h, event); err != nil {
+				r.logger.Warn("Lifecycle hook failed",
+					zap.String("hook", h.name), zap.String("stage", string(stage)), zap.String("id", id), zap.Error(err))
+			}
+		}(h)
+	}
+}
+
+// Wait blocks until the post hooks already started have finished.
+func (r *Registry) Wait() {
+	if r != nil {
+		r.wg.Wait()
+	}
+}
+
+func (r *Registry) run(ctx context.Context, h registered, event Event) (dna *pb.GameDNA, err error) {
+	if h.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.opts.Timeout)
+		defer cancel()
+	}
+
+	// A hook that ignores its context still can't hold up the change
+	// beyond its timeout.
+	type result struct {
+		dna *pb.GameDNA
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{err: fmt.Errorf("hook panicked: %v", p)}
+			}
+		}()
+		dna, err := h.hook.Run(ctx, event)
+		done <- result{dna, err}
+	}()
+	select {
+	case res := <-done:
+		return res.dna, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("hook did not finish: %w", ctx.Err())
+	}
+}
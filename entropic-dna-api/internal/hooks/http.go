@@ -0,0 +1,99 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxHTTPResponse bounds how much of a hook's response is read.
+const maxHTTPResponse = 1 << 20
+
+// HTTPHook posts each event as JSON to an external service:
+//
+//	{"stage": "pre_update", "id": "...", "actor": "...", "gameDna": {...}}
+//
+// A 2xx response accepts the change. Its body may be empty, or an object
+// whose "gameDna" replaces the config at pre_create and pre_update. Any other
+// status fails the hook, with the body's "error" as the reason if it has one.
+type HTTPHook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPHook creates a hook that posts to url with headers, e.g. a shared
+// secret the service checks. Timeouts come from the registry.
+func NewHTTPHook(url string, headers map[string]string) *HTTPHook {
+	return &HTTPHook{url: url, headers: headers, client: &http.Client{}}
+}
+
+type httpHookRequest struct {
+	Stage   Stage           `json:"stage"`
+	ID      string          `json:"id,omitempty"`
+	Actor   string          `json:"actor,omitempty"`
+	GameDNA json.RawMessage `json:"gameDna"`
+}
+
+type httpHookResponse struct {
+	GameDNA json.RawMessage `json:"gameDna"`
+	Error   string          `json:"error"`
+}
+
+// Run posts event and reads back the hook's answer.
+func (h *HTTPHook) Run(ctx context.Context, event Event) (*pb.GameDNA, error) {
+	dna, err := protojson.Marshal(event.GameDNA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	body, err := json.Marshal(httpHookRequest{Stage: event.Stage, ID: event.ID, Actor: event.Actor, GameDNA: dna})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range h.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook response: %w", err)
+	}
+
+	var answer httpHookResponse
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &answer); err != nil && resp.StatusCode < 300 {
+			return nil, fmt.Errorf("invalid hook response: %w", err)
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if answer.Error != "" {
+			return nil, fmt.Errorf("%s", answer.Error)
+		}
+		return nil, fmt.Errorf("hook returned %s", resp.Status)
+	}
+	if len(answer.GameDNA) == 0 || string(answer.GameDNA) == "null" {
+		return nil, nil
+	}
+	changed := &pb.GameDNA{}
+	if err := protojson.Unmarshal(answer.GameDNA, changed); err != nil {
+		return nil, fmt.Errorf("invalid gameDna in hook response: %w", err)
+	}
+	return changed, nil
+}
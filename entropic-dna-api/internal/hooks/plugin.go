@@ -0,0 +1,32 @@
+package hooks
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// PluginRegister is the signature of the Register function a Go plugin
+// exports. It adds the plugin's hooks to the registry it is given.
+type PluginRegister = func(r *Registry) error
+
+// LoadPlugin opens a Go plugin built with -buildmode=plugin against the same
+// server version, and calls its Register function. Plugins need a cgo build
+// on Linux, FreeBSD or macOS.
+func LoadPlugin(path string, r *Registry) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open hook plugin %s: %w", path, err)
+	}
+	sym, err := p.Lookup("Register")
+	if err != nil {
+		return fmt.Errorf("hook plugin %s: %w", path, err)
+	}
+	register, ok := sym.(PluginRegister)
+	if !ok {
+		return fmt.Errorf("hook plugin %s: Register is %T, want func(*hooks.Registry) error", path, sym)
+	}
+	if err := register(r); err != nil {
+		return fmt.Errorf("hook plugin %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+// Package integrity runs background jobs that check stored data against the
+// content it covers. The checksum job recomputes the canonical checksum of
+// every config and version, reports mismatches and, unless it is a dry run,
+// fixes them in batches at a bounded rate. Progress is stored after every
+// batch, so a job stopped by a restart or a cancel can be resumed.
+package integrity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Job limits and defaults.
+const (
+	DefaultBatchSize     = 500
+	MaxBatchSize         = 5000
+	DefaultRatePerSecond = 200
+	// maxRecordedMismatches bounds the mismatches kept on a job; the counts
+	// cover all of them.
+	maxRecordedMismatches = 1000
+	// staleAfter is how long a running job may go without saving progress
+	// before it is presumed dead, say with its instance, and may be resumed.
+	staleAfter = 2 * time.Minute
+)
+
+var (
+	// ErrInvalidOptions indicates a job's options are out of range.
+	ErrInvalidOptions = errors.New("invalid checksum job options")
+	// ErrBusy indicates a job is already running.
+	ErrBusy = errors.New("a checksum job is already running")
+	// ErrFinished indicates the job completed and has nothing left to resume.
+	ErrFinished = errors.New("checksum job already completed")
+)
+
+// ChecksumFunc computes the canonical checksum of a config's content.
+type ChecksumFunc func(*pb.GameDNA) (string, error)
+
+// Options configures a new checksum job.
+type Options struct {
+	DryRun        bool
+	BatchSize     int // configs per batch; 0 uses DefaultBatchSize
+	RatePerSecond int // configs checked per second at most; 0 uses DefaultRatePerSecond
+	Actor         string
+}
+
+// Checksums runs checksum jobs, one at a time per instance.
+type Checksums struct {
+	store    storage.Store
+	checksum ChecksumFunc
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	running string             // ID of the job running here, if any
+	cancel  context.CancelFunc // stops it
+}
+
+// NewChecksums creates a checksum job runner over store.
+func NewChecksums(store storage.Store, checksum ChecksumFunc, logger *zap.Logger) *Checksums {
+	return &Checksums{store: store, checksum: checksum, logger: logger}
+}
+
+// Start saves a new job and runs it in the background. Under data residency
+// it covers the region of ctx's tenant.
+func (c *Checksums) Start(ctx context.Context, opts Options) (*storage.ChecksumJob, error) {
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.RatePerSecond == 0 {
+		opts.RatePerSecond = DefaultRatePerSecond
+	}
+	if opts.BatchSize < 0 || opts.BatchSize > MaxBatchSize {
+		return nil, fmt.Errorf("%w: batch size must be between 1 and %d", ErrInvalidOptions, MaxBatchSize)
+	}
+	if opts.RatePerSecond < 0 {
+		return nil, fmt.Errorf("%w: rate cannot be negative", ErrInvalidOptions)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running != "" {
+		return nil, fmt.Errorf("job %s: %w", c.running, ErrBusy)
+	}
+	job, err := c.store.SaveChecksumJob(ctx, &storage.ChecksumJob{
+		ID:            uuid.New().String(),
+		State:         storage.ChecksumJobRunning,
+		DryRun:        opts.DryRun,
+		BatchSize:     opts.BatchSize,
+		RatePerSecond: opts.RatePerSecond,
+		StartedBy:     opts.Actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.launch(ctx, job)
+	return job, nil
+}
+
+// Resume continues a cancelled or failed job, or a running one whose
+// instance stopped saving progress, from where it stopped.
+func (c *Checksums) Resume(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running != "" {
+		return nil, fmt.Errorf("job %s: %w", c.running, ErrBusy)
+	}
+	job, err := c.store.GetChecksumJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch job.State {
+	case storage.ChecksumJobCompleted:
+		return nil, fmt.Errorf("job %s: %w", id, ErrFinished)
+	case storage.ChecksumJobRunning:
+		if time.Since(job.UpdatedAt) < staleAfter {
+			return nil, fmt.Errorf("job %s is running on another instance: %w", id, ErrBusy)
+		}
+	}
+	job.State = storage.ChecksumJobRunning
+	job.Error = ""
+	job.FinishedAt = time.Time{}
+	if job, err = c.store.SaveChecksumJob(ctx, job); err != nil {
+		return nil, err
+	}
+	c.launch(ctx, job)
+	return job, nil
+}
+
+// Cancel stops a running job. Jobs running on other instances see the cancel
+// before their next batch.
+func (c *Checksums) Cancel(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	c.mu.Lock()
+	local := c.running == id
+	if local {
+		c.cancel()
+	}
+	c.mu.Unlock()
+	if local {
+		// Let it stop before recording the cancel, so its last progress
+		// save can't overwrite the state.
+		c.Wait()
+	}
+
+	job, err := c.store.GetChecksumJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != storage.ChecksumJobRunning {
+		return job, nil
+	}
+	job.State = storage.ChecksumJobCancelled
+	job.FinishedAt = time.Now()
+	return c.store.SaveChecksumJob(ctx, job)
+}
+
+// Get returns a job's progress.
+func (c *Checksums) Get(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	return c.store.GetChecksumJob(ctx, id)
+}
+
+// Wait blocks until no job runs on this instance, for tests and shutdown.
+func (c *Checksums) Wait() {
+	for {
+		c.mu.Lock()
+		running := c.running
+		c.mu.Unlock()
+		if running == "" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Stop stops the job running on this instance on shutdown. The job stays
+// running in storage and can be resumed once it goes stale.
+func (c *Checksums) Stop() {
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.mu.Unlock()
+	c.Wait()
+}
+
+// launch runs job in the background. The caller holds c.mu.
+func (c *Checksums) launch(ctx context.Context, saved *storage.ChecksumJob) {
+	// The runner updates its own copy; saved goes back to the caller.
+	job := *saved
+	job.Mismatches = append([]storage.ChecksumMismatch(nil), saved.Mismatches...)
+	// The job outlives the request that started it but keeps its tenant.
+	runCtx, cancel := context.WithCancel(residency.WithTenant(context.Background(), residency.TenantFromContext(ctx)))
+	c.running, c.cancel = job.ID, cancel
+	go func() {
+		defer func() {
+			cancel()
+			c.mu.Lock()
+			c.running, c.cancel = "", nil
+			c.mu.Unlock()
+		}()
+		c.run(runCtx, &job)
+	}()
+}
+
+// run works through job's remaining configs batch by batch.
+func (c *Checksums) run(ctx context.Context, job *storage.ChecksumJob) {
+	log := c.logger.With(zap.String("job_id", job.ID), zap.Bool("dry_run", job.DryRun))
+	log.Info("Checksum job running", zap.String("after", job.Cursor))
+	for {
+		start := time.Now()
+		ids, err := c.store.ConfigIDsAfter(ctx, job.Cursor, job.BatchSize)
+		if err == nil && len(ids) > 0 {
+			err = c.batch(ctx, job, ids)
+		}
+		if ctx.Err() != nil {
+			// Stopped or cancelled: the last saved batch is where it resumes.
+			log.Info("Checksum job stopped", zap.String("after", job.Cursor))
+			return
+		}
+		if err != nil {
+			log.Error("Checksum job failed", zap.Error(err))
+			c.finish(ctx, job, storage.ChecksumJobFailed, err.Error())
+			return
+		}
+		if len(ids) == 0 {
+			log.Info("Checksum job completed",
+				zap.Int64("configs", job.ConfigsScanned),
+				zap.Int64("versions", job.VersionsScanned),
+				zap.Int64("mismatches", job.ConfigMismatches+job.VersionMismatches),
+				zap.Int64("fixed", job.Fixed))
+			c.finish(ctx, job, storage.ChecksumJobCompleted, "")
+			return
+		}
+
+		// A cancel from another instance shows up as the stored state.
+		stored, err := c.store.GetChecksumJob(ctx, job.ID)
+		if err == nil && stored.State != storage.ChecksumJobRunning {
+			log.Info("Checksum job cancelled", zap.String("after", job.Cursor))
+			return
+		}
+		if _, err := c.store.SaveChecksumJob(ctx, job); err != nil {
+			log.Warn("Failed to save checksum job progress", zap.Error(err))
+		}
+
+		wait := time.Until(start.Add(time.Duration(len(ids)) * time.Second / time.Duration(job.RatePerSecond)))
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+	}
+}
+
+// batch checks the configs with ids and their versions, fixes mismatches
+// unless the job is a dry run, and advances the job's cursor.
+func (c *Checksums) batch(ctx context.Context, job *storage.ChecksumJob, ids []string) error {
+	var found []storage.ChecksumMismatch
+	for _, id := range ids {
+		dna, err := c.store.Read(ctx, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue // deleted since it was listed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read config %s: %w", id, err)
+		}
+		job.ConfigsScanned++
+		sum, err := c.checksum(dna)
+		if err != nil {
+			return fmt.Errorf("failed to checksum config %s: %w", id, err)
+		}
+		if sum != dna.Checksum {
+			job.ConfigMismatches++
+			found = append(found, storage.ChecksumMismatch{ConfigID: id, Stored: dna.Checksum, Computed: sum})
+		}
+
+		versions, err := c.store.GetVersionHistory(ctx, id)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("failed to read versions of config %s: %w", id, err)
+		}
+		for _, v := range versions {
+			if v.Data == nil {
+				continue
+			}
+			job.VersionsScanned++
+			sum, err := c.checksum(v.Data)
+			if err != nil {
+				return fmt.Errorf("failed to checksum version %d of config %s: %w", v.VersionNum, id, err)
+			}
+			if sum != v.Checksum {
+				job.VersionMismatches++
+				found = append(found, storage.ChecksumMismatch{ConfigID: id, VersionNum: v.VersionNum, Stored: v.Checksum, Computed: sum})
+			}
+		}
+	}
+
+	if !job.DryRun && len(found) > 0 {
+		fixed, err := c.store.FixChecksums(ctx, found)
+		if err != nil {
+			return err
+		}
+		job.Fixed += fixed
+	}
+	for _, m := range found {
+		if len(job.Mismatches) >= maxRecordedMismatches {
+			break
+		}
+		job.Mismatches = append(job.Mismatches, m)
+	}
+	job.Cursor = ids[len(ids)-1]
+	return nil
+}
+
+// finish records the job's final state.
+func (c *Checksums) finish(ctx context.Context, job *storage.ChecksumJob, state, message string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	job.State = state
+	job.Error = message
+	job.FinishedAt = time.Now()
+	if _, err := c.store.SaveChecksumJob(ctx, job); err != nil {
+		c.logger.Error("Failed to save checksum job", zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
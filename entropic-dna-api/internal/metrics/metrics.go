@@ -0,0 +1,302 @@
+// Package metrics exposes process counters through expvar. They are served as
+// JSON at /debug/vars on the REST gateway.
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// Path is where the REST gateway serves the expvar JSON document.
+const Path = "/debug/vars"
+
+var (
+	validation = expvar.NewMap("validation")
+
+	validationRuns       = new(expvar.Map).Init() // per engine
+	validationOutcomes   = new(expvar.Map).Init() // valid / invalid
+	validationErrors     = new(expvar.Map).Init() // per error code
+	validationWarnings   = new(expvar.Map).Init() // per warning code
+	validationDurationUS = new(expvar.Map).Init() // cumulative microseconds per engine
+)
+
+func init() {
+	validation.Set("runs", validationRuns)
+	validation.Set("outcomes", validationOutcomes)
+	validation.Set("errors_by_code", validationErrors)
+	validation.Set("warnings_by_code", validationWarnings)
+	validation.Set("duration_us_total", validationDurationUS)
+}
+
+// ObserveValidation records one validation run by engine. Divide
+// duration_us_total by runs for the mean latency of an engine.
+func ObserveValidation(engine string, resp *pb.ValidationResponse, elapsed time.Duration) {
+	validationRuns.Add(engine, 1)
+	validationDurationUS.Add(engine, elapsed.Microseconds())
+
+	if resp == nil {
+		return
+	}
+	if resp.IsValid {
+		validationOutcomes.Add("valid", 1)
+	} else {
+		validationOutcomes.Add("invalid", 1)
+	}
+	for _, e := range resp.Errors {
+		validationErrors.Add(e.Code, 1)
+	}
+	for _, w := range resp.Warnings {
+		validationWarnings.Add(w.Code, 1)
+	}
+}
+
+var (
+	publishedCache        = expvar.NewMap("published_cache")
+	publishedCacheEntries = new(expvar.Int)
+)
+
+func init() {
+	publishedCache.Set("entries", publishedCacheEntries)
+}
+
+// ObserveCacheRead records a published-config cache lookup.
+func ObserveCacheRead(hit bool) {
+	if hit {
+		publishedCache.Add("hits", 1)
+	} else {
+		publishedCache.Add("misses", 1)
+	}
+}
+
+// SetCacheEntries records how many configs the published-config cache holds.
+func SetCacheEntries(n int) {
+	publishedCacheEntries.Set(int64(n))
+}
+
+var readCache = expvar.NewMap("read_cache")
+
+// ObserveReadCache records a read cache lookup by kind (read or list).
+func ObserveReadCache(kind string, hit bool) {
+	if hit {
+		readCache.Add(kind+"_hits", 1)
+	} else {
+		readCache.Add(kind+"_misses", 1)
+	}
+}
+
+// ObserveReadCacheError records a failed call to the read cache backend.
+// The store answers instead, so errors only cost latency.
+func ObserveReadCacheError() {
+	readCache.Add("errors", 1)
+}
+
+var eventJournal = expvar.NewMap("event_journal")
+
+// ObserveEventJournal records one write to the embedded events journal.
+func ObserveEventJournal(err error) {
+	if err != nil {
+		eventJournal.Add("errors", 1)
+	} else {
+		eventJournal.Add("writes", 1)
+	}
+}
+
+var panics = expvar.NewMap("panics")
+
+// ObservePanic records a panic recovered from a handler, by transport (grpc
+// or http).
+func ObservePanic(transport string) {
+	panics.Add(transport, 1)
+}
+
+var (
+	rpcs          = expvar.NewMap("rpcs")
+	rpcCalls      = new(expvar.Map).Init() // per method
+	rpcCodes      = new(expvar.Map).Init() // per status code
+	rpcErrors     = new(expvar.Map).Init() // per method, calls not OK
+	rpcDurationUS = new(expvar.Map).Init() // cumulative microseconds per method
+)
+
+func init() {
+	rpcs.Set("calls", rpcCalls)
+	rpcs.Set("codes", rpcCodes)
+	rpcs.Set("errors", rpcErrors)
+	rpcs.Set("duration_us_total", rpcDurationUS)
+}
+
+// ObserveRPC records one finished gRPC call by method, such as
+// "entropic.dna.v1.GameDNAService/GetGameDNA", and status code, such as
+// "NotFound" or "OK". Streams are timed until they end. Divide duration_us_total by calls for the mean
+// latency of a method.
+func ObserveRPC(method, code string, elapsed time.Duration) {
+	rpcCalls.Add(method, 1)
+	rpcCodes.Add(code, 1)
+	if code != "OK" {
+		rpcErrors.Add(method, 1)
+	}
+	rpcDurationUS.Add(method, elapsed.Microseconds())
+}
+
+var rateLimited = expvar.NewMap("rate_limited")
+
+// ObserveRateLimited records a call rejected by the rate limiter, by method.
+func ObserveRateLimited(method string) {
+	rateLimited.Add(method, 1)
+}
+
+var webhookDeliveries = expvar.NewMap("webhook_deliveries")
+
+// ObserveWebhookDelivery records one webhook delivery attempt by outcome:
+// succeeded, retried or failed (out of attempts).
+func ObserveWebhookDelivery(outcome string) {
+	webhookDeliveries.Add(outcome, 1)
+}
+
+var (
+	replicaReads       = expvar.NewMap("replica_reads")
+	replicaHedgeBudget = new(expvar.Int)
+)
+
+func init() {
+	replicaReads.Set("hedge_budget_us", replicaHedgeBudget)
+}
+
+// ObserveReplicaRead records one read sent to the read replicas: whether it
+// was hedged, whether the hedge answered first, and whether the replicas
+// failed so the primary served it. Divide hedged by reads for the hedge rate.
+func ObserveReplicaRead(hedged, hedgeWon, primary bool) {
+	replicaReads.Add("reads", 1)
+	if hedged {
+		replicaReads.Add("hedged", 1)
+	}
+	if hedgeWon {
+		replicaReads.Add("hedge_wins", 1)
+	}
+	if primary {
+		replicaReads.Add("primary_fallbacks", 1)
+	}
+}
+
+// SetHedgeBudget records how long a replica read currently waits before it
+// is hedged.
+func SetHedgeBudget(d time.Duration) {
+	replicaHedgeBudget.Set(d.Microseconds())
+}
+
+var (
+	database             = expvar.NewMap("database")
+	databaseBreakersOpen = new(expvar.Int)
+)
+
+func init() {
+	database.Set("breakers_open", databaseBreakersOpen)
+}
+
+// ObserveDatabaseRetry records a database call retried after a transient
+// failure.
+func ObserveDatabaseRetry() {
+	database.Add("retries", 1)
+}
+
+// ObserveDatabaseRejected records a database call failed fast because the
+// circuit breaker was open.
+func ObserveDatabaseRejected() {
+	database.Add("rejected", 1)
+}
+
+// ObserveBreaker records a database circuit breaker opening or closing.
+// breakers_open counts those open now, one per database.
+func ObserveBreaker(open bool) {
+	if open {
+		database.Add("breaker_opens", 1)
+		databaseBreakersOpen.Add(1)
+	} else {
+		databaseBreakersOpen.Add(-1)
+	}
+}
+
+// latencyBucketsMS bound the storage latency histogram's buckets, in
+// milliseconds. Each counts the calls at or under its bound, and le_inf every
+// call, so a percentile is the first bucket holding that share of le_inf.
+var latencyBucketsMS = []int64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+var (
+	storageStats       = expvar.NewMap("storage")
+	storageCalls       = new(expvar.Map).Init() // per method
+	storageErrors      = new(expvar.Map).Init() // per method
+	storageDurationUS  = new(expvar.Map).Init() // cumulative microseconds per method
+	storageLatencyMS   = new(expvar.Map).Init() // per method, a histogram
+	storageQueries     = new(expvar.Map).Init() // SQL statements per method
+	storageRows        = new(expvar.Map).Init() // rows read or written per method
+	storageSlowQueries = new(expvar.Map).Init() // statements over the slow query threshold per method
+
+	storageLatencyMu sync.Mutex // guards adding a method's histogram
+)
+
+func init() {
+	storageStats.Set("calls", storageCalls)
+	storageStats.Set("errors", storageErrors)
+	storageStats.Set("duration_us_total", storageDurationUS)
+	storageStats.Set("latency_ms", storageLatencyMS)
+	storageStats.Set("queries", storageQueries)
+	storageStats.Set("rows", storageRows)
+	storageStats.Set("slow_queries", storageSlowQueries)
+}
+
+// observeCall names method in ctx for the statements it runs and returns a
+// func recording the call when it returns err.
+func observeCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	return storage.WithMethod(ctx, method), func(err error) {
+		ObserveStorageCall(method, time.Since(start), err)
+	}
+}
+
+// ObserveStorageCall records one call to a Store method, such as "List".
+// Divide duration_us_total by calls for the mean latency of a method, and
+// read its tail from latency_ms.
+func ObserveStorageCall(method string, elapsed time.Duration, err error) {
+	storageCalls.Add(method, 1)
+	if err != nil {
+		storageErrors.Add(method, 1)
+	}
+	storageDurationUS.Add(method, elapsed.Microseconds())
+
+	hist, _ := storageLatencyMS.Get(method).(*expvar.Map)
+	if hist == nil {
+		storageLatencyMu.Lock()
+		if hist, _ = storageLatencyMS.Get(method).(*expvar.Map); hist == nil {
+			hist = new(expvar.Map).Init()
+			storageLatencyMS.Set(method, hist)
+		}
+		storageLatencyMu.Unlock()
+	}
+	ms := elapsed.Milliseconds()
+	for _, bound := range latencyBucketsMS {
+		if ms <= bound {
+			hist.Add("le_"+strconv.FormatInt(bound, 10), 1)
+		}
+	}
+	hist.Add("le_inf", 1)
+}
+
+// ObserveQuery records one SQL statement a Store method ran, and whether it
+// took over the slow query threshold. Statements run outside a method
+// WrapStore names, such as by migrations, are counted as "other".
+func ObserveQuery(q storage.QueryStats, slow bool) {
+	method := q.Method
+	if method == "" {
+		method = "other"
+	}
+	storageQueries.Add(method, 1)
+	storageRows.Add(method, q.Rows)
+	if slow {
+		storageSlowQueries.Add(method, 1)
+	}
+}
@@ -0,0 +1,482 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// store wraps a storage.Store, timing each call by method.
+type store struct {
+	next storage.Store
+}
+
+// WrapStore returns a store that records the latency and outcome of each
+// call to next under "storage" in the expvar document. It names the method in
+// the call's context with storage.WithMethod, so the statements a
+// PostgresStore runs for it are counted against it.
+func WrapStore(next storage.Store) storage.Store {
+	return &store{next: next}
+}
+
+func (s *store) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "Create")
+	defer func() { done(err) }()
+	return s.next.Create(ctx, dna, opts...)
+}
+
+func (s *store) Read(ctx context.Context, id string) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "Read")
+	defer func() { done(err) }()
+	return s.next.Read(ctx, id)
+}
+
+func (s *store) ReadBySlug(ctx context.Context, slug string) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "ReadBySlug")
+	defer func() { done(err) }()
+	return s.next.ReadBySlug(ctx, slug)
+}
+
+func (s *store) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "Update")
+	defer func() { done(err) }()
+	return s.next.Update(ctx, dna, opts...)
+}
+
+func (s *store) Delete(ctx context.Context, id string) (err error) {
+	ctx, done := observeCall(ctx, "Delete")
+	defer func() { done(err) }()
+	return s.next.Delete(ctx, id)
+}
+
+func (s *store) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) (_ []*pb.GameDNA, _ int32, err error) {
+	ctx, done := observeCall(ctx, "List")
+	defer func() { done(err) }()
+	return s.next.List(ctx, filters, pagination)
+}
+
+func (s *store) ListAfter(ctx context.Context, filters storage.ListFilters, after *storage.ListCursor, limit int32) (_ []*pb.GameDNA, _ *storage.ListCursor, err error) {
+	ctx, done := observeCall(ctx, "ListAfter")
+	defer func() { done(err) }()
+	return s.next.ListAfter(ctx, filters, after, limit)
+}
+
+func (s *store) Each(ctx context.Context, filters storage.ListFilters, fn func(*pb.GameDNA) error) (err error) {
+	ctx, done := observeCall(ctx, "Each")
+	defer func() { done(err) }()
+	return s.next.Each(ctx, filters, fn)
+}
+
+func (s *store) Search(ctx context.Context, query storage.SearchQuery, pagination storage.Pagination) (_ []*pb.GameDNA, _ int32, err error) {
+	ctx, done := observeCall(ctx, "Search")
+	defer func() { done(err) }()
+	return s.next.Search(ctx, query, pagination)
+}
+
+func (s *store) GetVersionHistory(ctx context.Context, configID string) (_ []*storage.VersionInfo, err error) {
+	ctx, done := observeCall(ctx, "GetVersionHistory")
+	defer func() { done(err) }()
+	return s.next.GetVersionHistory(ctx, configID)
+}
+
+func (s *store) ListVersions(ctx context.Context, configID string, page storage.VersionPage) (_ []*storage.VersionInfo, err error) {
+	ctx, done := observeCall(ctx, "ListVersions")
+	defer func() { done(err) }()
+	return s.next.ListVersions(ctx, configID, page)
+}
+
+func (s *store) GetVersion(ctx context.Context, configID string, versionNum int64) (_ *storage.VersionInfo, err error) {
+	ctx, done := observeCall(ctx, "GetVersion")
+	defer func() { done(err) }()
+	return s.next.GetVersion(ctx, configID, versionNum)
+}
+
+func (s *store) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (_ int64, err error) {
+	ctx, done := observeCall(ctx, "PruneVersionHistory")
+	defer func() { done(err) }()
+	return s.next.PruneVersionHistory(ctx, retention)
+}
+
+func (s *store) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "RollbackToVersion")
+	defer func() { done(err) }()
+	return s.next.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (s *store) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "PublishVersion")
+	defer func() { done(err) }()
+	return s.next.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (s *store) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "UnpublishVersion")
+	defer func() { done(err) }()
+	return s.next.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (s *store) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "UnlockTemporarily")
+	defer func() { done(err) }()
+	return s.next.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (s *store) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "RelockVersion")
+	defer func() { done(err) }()
+	return s.next.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) ListTemporaryUnlocks(ctx context.Context) (_ []*storage.TemporaryUnlock, err error) {
+	ctx, done := observeCall(ctx, "ListTemporaryUnlocks")
+	defer func() { done(err) }()
+	return s.next.ListTemporaryUnlocks(ctx)
+}
+
+func (s *store) StartCanary(ctx context.Context, canary *storage.Canary) (_ *storage.Canary, err error) {
+	ctx, done := observeCall(ctx, "StartCanary")
+	defer func() { done(err) }()
+	return s.next.StartCanary(ctx, canary)
+}
+
+func (s *store) GetCanary(ctx context.Context, configID string) (_ *storage.Canary, err error) {
+	ctx, done := observeCall(ctx, "GetCanary")
+	defer func() { done(err) }()
+	return s.next.GetCanary(ctx, configID)
+}
+
+func (s *store) ListRunningCanaries(ctx context.Context) (_ []*storage.Canary, err error) {
+	ctx, done := observeCall(ctx, "ListRunningCanaries")
+	defer func() { done(err) }()
+	return s.next.ListRunningCanaries(ctx)
+}
+
+func (s *store) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (_ *storage.Canary, err error) {
+	ctx, done := observeCall(ctx, "RecordCanaryFeedback")
+	defer func() { done(err) }()
+	return s.next.RecordCanaryFeedback(ctx, configID, checksum, sessions, errors)
+}
+
+func (s *store) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.Canary, _ *storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "PromoteCanary")
+	defer func() { done(err) }()
+	return s.next.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "PromoteVersion")
+	defer func() { done(err) }()
+	return s.next.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (s *store) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (_ *storage.Canary, err error) {
+	ctx, done := observeCall(ctx, "RollbackCanary")
+	defer func() { done(err) }()
+	return s.next.RollbackCanary(ctx, configID, actor, reason)
+}
+
+func (s *store) TagVersion(ctx context.Context, tag *storage.ReleaseTag, move bool) (_ *storage.ReleaseTag, _ int64, err error) {
+	ctx, done := observeCall(ctx, "TagVersion")
+	defer func() { done(err) }()
+	return s.next.TagVersion(ctx, tag, move)
+}
+
+func (s *store) GetTaggedVersion(ctx context.Context, configID string, tag string) (_ *storage.VersionInfo, err error) {
+	ctx, done := observeCall(ctx, "GetTaggedVersion")
+	defer func() { done(err) }()
+	return s.next.GetTaggedVersion(ctx, configID, tag)
+}
+
+func (s *store) ListReleaseTags(ctx context.Context, configID string) (_ []*storage.ReleaseTag, err error) {
+	ctx, done := observeCall(ctx, "ListReleaseTags")
+	defer func() { done(err) }()
+	return s.next.ListReleaseTags(ctx, configID)
+}
+
+func (s *store) ListAuditEntries(ctx context.Context, configID string) (_ []*storage.AuditEntry, err error) {
+	ctx, done := observeCall(ctx, "ListAuditEntries")
+	defer func() { done(err) }()
+	return s.next.ListAuditEntries(ctx, configID)
+}
+
+func (s *store) Clone(ctx context.Context, id string, newName string, actor string) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "Clone")
+	defer func() { done(err) }()
+	return s.next.Clone(ctx, id, newName, actor)
+}
+
+func (s *store) SaveSet(ctx context.Context, set storage.SaveSet) (_ *storage.SaveSet, err error) {
+	ctx, done := observeCall(ctx, "SaveSet")
+	defer func() { done(err) }()
+	return s.next.SaveSet(ctx, set)
+}
+
+func (s *store) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (_ *pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "ImportConfig")
+	defer func() { done(err) }()
+	return s.next.ImportConfig(ctx, dna, versions)
+}
+
+func (s *store) CreateSnapshot(ctx context.Context, snapshot *storage.Snapshot) (_ *storage.Snapshot, err error) {
+	ctx, done := observeCall(ctx, "CreateSnapshot")
+	defer func() { done(err) }()
+	return s.next.CreateSnapshot(ctx, snapshot)
+}
+
+func (s *store) GetSnapshot(ctx context.Context, name string, withData bool) (_ *storage.Snapshot, err error) {
+	ctx, done := observeCall(ctx, "GetSnapshot")
+	defer func() { done(err) }()
+	return s.next.GetSnapshot(ctx, name, withData)
+}
+
+func (s *store) ListSnapshots(ctx context.Context) (_ []*storage.Snapshot, err error) {
+	ctx, done := observeCall(ctx, "ListSnapshots")
+	defer func() { done(err) }()
+	return s.next.ListSnapshots(ctx)
+}
+
+func (s *store) RestoreSnapshot(ctx context.Context, name string, actor string) (_ []*pb.GameDNA, err error) {
+	ctx, done := observeCall(ctx, "RestoreSnapshot")
+	defer func() { done(err) }()
+	return s.next.RestoreSnapshot(ctx, name, actor)
+}
+
+func (s *store) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (_ *storage.OwnershipTransfer, err error) {
+	ctx, done := observeCall(ctx, "TransferOwnership")
+	defer func() { done(err) }()
+	return s.next.TransferOwnership(ctx, from, to, actor, dryRun)
+}
+
+func (s *store) FindPrincipalData(ctx context.Context, principal string) (_ *storage.PrincipalData, err error) {
+	ctx, done := observeCall(ctx, "FindPrincipalData")
+	defer func() { done(err) }()
+	return s.next.FindPrincipalData(ctx, principal)
+}
+
+func (s *store) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (_ *storage.PrincipalData, err error) {
+	ctx, done := observeCall(ctx, "AnonymizePrincipal")
+	defer func() { done(err) }()
+	return s.next.AnonymizePrincipal(ctx, principal, pseudonym)
+}
+
+func (s *store) SaveImportMapping(ctx context.Context, mapping *storage.ImportMapping) (_ *storage.ImportMapping, err error) {
+	ctx, done := observeCall(ctx, "SaveImportMapping")
+	defer func() { done(err) }()
+	return s.next.SaveImportMapping(ctx, mapping)
+}
+
+func (s *store) GetImportMapping(ctx context.Context, project string) (_ *storage.ImportMapping, err error) {
+	ctx, done := observeCall(ctx, "GetImportMapping")
+	defer func() { done(err) }()
+	return s.next.GetImportMapping(ctx, project)
+}
+
+func (s *store) SaveAPIToken(ctx context.Context, token *storage.APIToken) (_ *storage.APIToken, err error) {
+	ctx, done := observeCall(ctx, "SaveAPIToken")
+	defer func() { done(err) }()
+	return s.next.SaveAPIToken(ctx, token)
+}
+
+func (s *store) GetAPIToken(ctx context.Context, id string) (_ *storage.APIToken, err error) {
+	ctx, done := observeCall(ctx, "GetAPIToken")
+	defer func() { done(err) }()
+	return s.next.GetAPIToken(ctx, id)
+}
+
+func (s *store) FindAPIToken(ctx context.Context, hash string) (_ *storage.APIToken, err error) {
+	ctx, done := observeCall(ctx, "FindAPIToken")
+	defer func() { done(err) }()
+	return s.next.FindAPIToken(ctx, hash)
+}
+
+func (s *store) ListAPITokens(ctx context.Context) (_ []*storage.APIToken, err error) {
+	ctx, done := observeCall(ctx, "ListAPITokens")
+	defer func() { done(err) }()
+	return s.next.ListAPITokens(ctx)
+}
+
+func (s *store) SaveWaiver(ctx context.Context, waiver *storage.Waiver) (_ *storage.Waiver, err error) {
+	ctx, done := observeCall(ctx, "SaveWaiver")
+	defer func() { done(err) }()
+	return s.next.SaveWaiver(ctx, waiver)
+}
+
+func (s *store) ListWaivers(ctx context.Context, configID string) (_ []*storage.Waiver, err error) {
+	ctx, done := observeCall(ctx, "ListWaivers")
+	defer func() { done(err) }()
+	return s.next.ListWaivers(ctx, configID)
+}
+
+func (s *store) SaveTemplate(ctx context.Context, template *storage.Template) (_ *storage.Template, err error) {
+	ctx, done := observeCall(ctx, "SaveTemplate")
+	defer func() { done(err) }()
+	return s.next.SaveTemplate(ctx, template)
+}
+
+func (s *store) GetTemplate(ctx context.Context, name string) (_ *storage.Template, err error) {
+	ctx, done := observeCall(ctx, "GetTemplate")
+	defer func() { done(err) }()
+	return s.next.GetTemplate(ctx, name)
+}
+
+func (s *store) ListTemplates(ctx context.Context) (_ []*storage.Template, err error) {
+	ctx, done := observeCall(ctx, "ListTemplates")
+	defer func() { done(err) }()
+	return s.next.ListTemplates(ctx)
+}
+
+func (s *store) DeleteTemplate(ctx context.Context, name string) (err error) {
+	ctx, done := observeCall(ctx, "DeleteTemplate")
+	defer func() { done(err) }()
+	return s.next.DeleteTemplate(ctx, name)
+}
+
+func (s *store) SavePropertySchema(ctx context.Context, schema *storage.PropertySchema) (_ *storage.PropertySchema, err error) {
+	ctx, done := observeCall(ctx, "SavePropertySchema")
+	defer func() { done(err) }()
+	return s.next.SavePropertySchema(ctx, schema)
+}
+
+func (s *store) GetPropertySchema(ctx context.Context, project, name string) (_ *storage.PropertySchema, err error) {
+	ctx, done := observeCall(ctx, "GetPropertySchema")
+	defer func() { done(err) }()
+	return s.next.GetPropertySchema(ctx, project, name)
+}
+
+func (s *store) ListPropertySchemas(ctx context.Context, project string) (_ []*storage.PropertySchema, err error) {
+	ctx, done := observeCall(ctx, "ListPropertySchemas")
+	defer func() { done(err) }()
+	return s.next.ListPropertySchemas(ctx, project)
+}
+
+func (s *store) DeletePropertySchema(ctx context.Context, project, name string) (err error) {
+	ctx, done := observeCall(ctx, "DeletePropertySchema")
+	defer func() { done(err) }()
+	return s.next.DeletePropertySchema(ctx, project, name)
+}
+
+func (s *store) SaveWebhook(ctx context.Context, webhook *storage.Webhook) (_ *storage.Webhook, err error) {
+	ctx, done := observeCall(ctx, "SaveWebhook")
+	defer func() { done(err) }()
+	return s.next.SaveWebhook(ctx, webhook)
+}
+
+func (s *store) GetWebhook(ctx context.Context, id string) (_ *storage.Webhook, err error) {
+	ctx, done := observeCall(ctx, "GetWebhook")
+	defer func() { done(err) }()
+	return s.next.GetWebhook(ctx, id)
+}
+
+func (s *store) ListWebhooks(ctx context.Context) (_ []*storage.Webhook, err error) {
+	ctx, done := observeCall(ctx, "ListWebhooks")
+	defer func() { done(err) }()
+	return s.next.ListWebhooks(ctx)
+}
+
+func (s *store) DeleteWebhook(ctx context.Context, id string) (err error) {
+	ctx, done := observeCall(ctx, "DeleteWebhook")
+	defer func() { done(err) }()
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *store) SaveWebhookDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (_ *storage.WebhookDelivery, err error) {
+	ctx, done := observeCall(ctx, "SaveWebhookDelivery")
+	defer func() { done(err) }()
+	return s.next.SaveWebhookDelivery(ctx, delivery)
+}
+
+func (s *store) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) (_ []*storage.WebhookDelivery, err error) {
+	ctx, done := observeCall(ctx, "ClaimWebhookDeliveries")
+	defer func() { done(err) }()
+	return s.next.ClaimWebhookDeliveries(ctx, now, limit, lease)
+}
+
+func (s *store) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) (_ []*storage.WebhookDelivery, err error) {
+	ctx, done := observeCall(ctx, "ListWebhookDeliveries")
+	defer func() { done(err) }()
+	return s.next.ListWebhookDeliveries(ctx, webhookID, state, limit)
+}
+
+func (s *store) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (_ int64, err error) {
+	ctx, done := observeCall(ctx, "PruneWebhookDeliveries")
+	defer func() { done(err) }()
+	return s.next.PruneWebhookDeliveries(ctx, finishedBefore)
+}
+
+func (s *store) ClaimIdempotencyKey(ctx context.Context, key *storage.IdempotencyKey) (_ *storage.IdempotencyKey, err error) {
+	ctx, done := observeCall(ctx, "ClaimIdempotencyKey")
+	defer func() { done(err) }()
+	return s.next.ClaimIdempotencyKey(ctx, key)
+}
+
+func (s *store) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) (err error) {
+	ctx, done := observeCall(ctx, "CompleteIdempotencyKey")
+	defer func() { done(err) }()
+	return s.next.CompleteIdempotencyKey(ctx, scope, key, response, expiresAt)
+}
+
+func (s *store) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (_ int64, err error) {
+	ctx, done := observeCall(ctx, "PruneIdempotencyKeys")
+	defer func() { done(err) }()
+	return s.next.PruneIdempotencyKeys(ctx, expiredBefore)
+}
+
+func (s *store) ConfigIDsAfter(ctx context.Context, after string, limit int) (_ []string, err error) {
+	ctx, done := observeCall(ctx, "ConfigIDsAfter")
+	defer func() { done(err) }()
+	return s.next.ConfigIDsAfter(ctx, after, limit)
+}
+
+func (s *store) SaveDraft(ctx context.Context, draft *storage.Draft) (_ *storage.Draft, err error) {
+	ctx, done := observeCall(ctx, "SaveDraft")
+	defer func() { done(err) }()
+	return s.next.SaveDraft(ctx, draft)
+}
+
+func (s *store) GetDraft(ctx context.Context, configID, owner string) (_ *storage.Draft, err error) {
+	ctx, done := observeCall(ctx, "GetDraft")
+	defer func() { done(err) }()
+	return s.next.GetDraft(ctx, configID, owner)
+}
+
+func (s *store) DeleteDraft(ctx context.Context, configID, owner string) (err error) {
+	ctx, done := observeCall(ctx, "DeleteDraft")
+	defer func() { done(err) }()
+	return s.next.DeleteDraft(ctx, configID, owner)
+}
+
+func (s *store) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (_ int64, err error) {
+	ctx, done := observeCall(ctx, "FixChecksums")
+	defer func() { done(err) }()
+	return s.next.FixChecksums(ctx, fixes)
+}
+
+func (s *store) SaveChecksumJob(ctx context.Context, job *storage.ChecksumJob) (_ *storage.ChecksumJob, err error) {
+	ctx, done := observeCall(ctx, "SaveChecksumJob")
+	defer func() { done(err) }()
+	return s.next.SaveChecksumJob(ctx, job)
+}
+
+func (s *store) GetChecksumJob(ctx context.Context, id string) (_ *storage.ChecksumJob, err error) {
+	ctx, done := observeCall(ctx, "GetChecksumJob")
+	defer func() { done(err) }()
+	return s.next.GetChecksumJob(ctx, id)
+}
+
+func (s *store) IndexStats(ctx context.Context) (_ []storage.IndexStat, err error) {
+	ctx, done := observeCall(ctx, "IndexStats")
+	defer func() { done(err) }()
+	return s.next.IndexStats(ctx)
+}
+
+func (s *store) Ping(ctx context.Context) (err error) {
+	ctx, done := observeCall(ctx, "Ping")
+	defer func() { done(err) }()
+	return s.next.Ping(ctx)
+}
+
+func (s *store) Close() {
+	s.next.Close()
+}
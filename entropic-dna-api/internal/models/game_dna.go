@@ -1,25 +1,28 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
 )
 
-// GameDNA represents a game configuration in the database
+// GameDNA represents a game configuration in the database: the indexed
+// columns of game_dna_configs plus the full config as JSON in Data.
 type GameDNA struct {
-	ID             string
-	Name           string
-	Version        string
-	CreatedAt      time.Time
-	LastModified   time.Time
-	CreatedBy      string
-	Checksum       string
-	IsLocked       bool
-	Data           string // JSON representation
-	Tags           []string
-	Genre          string
-	TargetPlatforms []string
+	ID           string
+	Name         string
+	Version      string
+	Slug         string
+	CreatedAt    time.Time
+	LastModified time.Time
+	CreatedBy    string
+	Checksum     string
+	IsLocked     bool
+	Tags         []string
+	Data         []byte // JSON representation of the full config
 }
 
 // GameDNAVersion represents a version snapshot in version history
@@ -27,52 +30,104 @@ type GameDNAVersion struct {
 	ID         int64
 	ConfigID   string
 	VersionNum int64
-	Data       string // JSON representation
+	Data       []byte // JSON representation
 	Checksum   string
 	CreatedAt  time.Time
 	CreatedBy  string
 }
 
-// ToProto converts the model to protobuf representation
+// ToProto converts the model to protobuf representation. Every field comes
+// from Data; the columns take precedence where both hold a value.
 func (g *GameDNA) ToProto() (*pb.GameDNA, error) {
-	return &pb.GameDNA{
-		Id:           g.ID,
-		Name:         g.Name,
-		Version:      g.Version,
-		CreatedAt:    g.CreatedAt.Format(time.RFC3339),
-		LastModified: g.LastModified.Format(time.RFC3339),
-		CreatedBy:    g.CreatedBy,
-		Checksum:     g.Checksum,
-		IsLocked:     g.IsLocked,
-		Genre:        g.Genre,
-		Tags:         g.Tags,
-		TargetPlatforms: g.TargetPlatforms,
-	}, nil
+	dna := &pb.GameDNA{}
+	if len(g.Data) > 0 {
+		var err error
+		if dna, err = Unmarshal(g.Data); err != nil {
+			return nil, err
+		}
+	}
+	dna.Id = g.ID
+	dna.Name = g.Name
+	dna.Version = g.Version
+	dna.Slug = g.Slug
+	dna.CreatedBy = g.CreatedBy
+	dna.Checksum = g.Checksum
+	dna.IsLocked = g.IsLocked
+	dna.Tags = g.Tags
+	SetCreated(dna, g.CreatedAt)
+	SetModified(dna, g.LastModified)
+	return dna, nil
 }
 
-// FromProto creates a model from protobuf representation
-func FromProto(pb *pb.GameDNA) (*GameDNA, error) {
-	createdAt, _ := time.Parse(time.RFC3339, pb.CreatedAt)
-	lastModified, _ := time.Parse(time.RFC3339, pb.LastModified)
-	
-	if createdAt.IsZero() {
-		createdAt = time.Now()
+// FromProto creates a model from protobuf representation. Malformed
+// timestamps are an error; missing ones stay zero.
+func FromProto(dna *pb.GameDNA) (*GameDNA, error) {
+	dna = Clone(dna)
+	if err := NormalizeTimestamps(dna); err != nil {
+		return nil, err
 	}
-	if lastModified.IsZero() {
-		lastModified = time.Now()
+	data, err := Marshal(dna)
+	if err != nil {
+		return nil, err
 	}
 
 	return &GameDNA{
-		ID:              pb.Id,
-		Name:            pb.Name,
-		Version:         pb.Version,
-		CreatedAt:       createdAt,
-		LastModified:    lastModified,
-		CreatedBy:       pb.CreatedBy,
-		Checksum:        pb.Checksum,
-		IsLocked:        pb.IsLocked,
-		Genre:           pb.Genre,
-		Tags:            pb.Tags,
-		TargetPlatforms: pb.TargetPlatforms,
+		ID:           dna.Id,
+		Name:         dna.Name,
+		Version:      dna.Version,
+		Slug:         dna.Slug,
+		CreatedAt:    TimeFromProto(dna.CreateTime),
+		LastModified: TimeFromProto(dna.UpdateTime),
+		CreatedBy:    dna.CreatedBy,
+		Checksum:     dna.Checksum,
+		IsLocked:     dna.IsLocked,
+		Tags:         dna.Tags,
+		Data:         data,
 	}, nil
 }
+
+// Marshal encodes a config as stored in the data columns.
+func Marshal(dna *pb.GameDNA) ([]byte, error) {
+	data, err := json.Marshal(dna)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal game DNA: %w", err)
+	}
+	return data, nil
+}
+
+// Unmarshal decodes a stored config. Rows written before create_time and
+// update_time existed only carry the RFC3339 strings; they are filled in.
+func Unmarshal(data []byte) (*pb.GameDNA, error) {
+	var dna pb.GameDNA
+	if err := json.Unmarshal(data, &dna); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game DNA: %w", err)
+	}
+	if err := NormalizeTimestamps(&dna); err != nil {
+		return nil, fmt.Errorf("config %s: %w", dna.Id, err)
+	}
+	return &dna, nil
+}
+
+// Clone returns a deep copy of dna, or nil for nil.
+func Clone(dna *pb.GameDNA) *pb.GameDNA {
+	if dna == nil {
+		return nil
+	}
+	return proto.Clone(dna).(*pb.GameDNA)
+}
+
+// ClearServerFields resets the fields the server manages: the ID, timestamps,
+// owner, checksum, publish state and promotion source. What remains is the
+// config content.
+func ClearServerFields(dna *pb.GameDNA) {
+	dna.Id = ""
+	dna.CreatedAt = ""
+	dna.LastModified = ""
+	dna.CreateTime = nil
+	dna.UpdateTime = nil
+	dna.CreatedBy = ""
+	dna.Checksum = ""
+	dna.IsLocked = false
+	dna.PublishedRulesVersion = ""
+	dna.PromotedFrom = ""
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Config timestamps are carried as google.protobuf.Timestamp (create_time and
+// update_time). The RFC3339 created_at and last_modified strings predate them
+// and are kept as exact copies so older clients keep working.
+
+// Now returns the current UTC time, truncated to whole seconds so the RFC3339
+// copies represent it exactly.
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// FormatTime renders t as RFC3339 in UTC. The zero time renders as "".
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseTime parses an RFC3339 timestamp. "" parses as the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: want RFC3339", s)
+	}
+	return t.UTC(), nil
+}
+
+// TimestampProto converts t, returning nil for the zero time.
+func TimestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// TimeFromProto converts ts, returning the zero time for nil.
+func TimeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// SetCreated stamps dna's creation time in both representations.
+func SetCreated(dna *pb.GameDNA, t time.Time) {
+	dna.CreateTime = TimestampProto(t)
+	dna.CreatedAt = FormatTime(t)
+}
+
+// SetModified stamps dna's modification time in both representations.
+func SetModified(dna *pb.GameDNA, t time.Time) {
+	dna.UpdateTime = TimestampProto(t)
+	dna.LastModified = FormatTime(t)
+}
+
+// CreatedTime returns when dna was created, preferring create_time over the
+// legacy string. A malformed string is an error, never a silent "now".
+func CreatedTime(dna *pb.GameDNA) (time.Time, error) {
+	if dna.CreateTime != nil {
+		return dna.CreateTime.AsTime(), nil
+	}
+	t, err := ParseTime(dna.CreatedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("created_at: %w", err)
+	}
+	return t, nil
+}
+
+// ModifiedTime returns when dna was last modified, preferring update_time over
+// the legacy string.
+func ModifiedTime(dna *pb.GameDNA) (time.Time, error) {
+	if dna.UpdateTime != nil {
+		return dna.UpdateTime.AsTime(), nil
+	}
+	t, err := ParseTime(dna.LastModified)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("last_modified: %w", err)
+	}
+	return t, nil
+}
+
+// NormalizeTimestamps is the compatibility shim between the two
+// representations. Configs from older clients, or stored before the Timestamp
+// fields existed, only carry the strings; it fills in the Timestamps from them
+// and rewrites the strings from the Timestamps so both always agree.
+func NormalizeTimestamps(dna *pb.GameDNA) error {
+	created, err := CreatedTime(dna)
+	if err != nil {
+		return err
+	}
+	modified, err := ModifiedTime(dna)
+	if err != nil {
+		return err
+	}
+	SetCreated(dna, created)
+	SetModified(dna, modified)
+	return nil
+}
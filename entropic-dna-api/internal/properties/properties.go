@@ -0,0 +1,130 @@
+// Package properties checks custom property values against the types a
+// project declares for them, and puts valid values in canonical form so that
+// "TRUE", "yes" and "true" are stored, searched and diffed alike.
+package properties
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Property types.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeEnum   = "enum"
+)
+
+// Types lists the property types.
+var Types = []string{TypeString, TypeInt, TypeFloat, TypeBool, TypeEnum}
+
+// CheckSchema checks that a property of typ can have allowed as its allowed
+// values: enums need at least one, distinct ignoring case, and other types
+// none.
+func CheckSchema(typ string, allowed []string) error {
+	if !slices.Contains(Types, typ) {
+		return fmt.Errorf("unknown property type %q; want one of %v", typ, Types)
+	}
+	if typ != TypeEnum {
+		if len(allowed) > 0 {
+			return fmt.Errorf("only enum properties have allowed values")
+		}
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("enum properties need allowed values")
+	}
+	seen := make(map[string]bool, len(allowed))
+	for _, v := range allowed {
+		if strings.TrimSpace(v) == "" {
+			return fmt.Errorf("allowed values must not be empty")
+		}
+		key := strings.ToLower(v)
+		if seen[key] {
+			return fmt.Errorf("allowed value %q is listed more than once", v)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Coerce returns value in the canonical form of typ: integers and floats in
+// plain decimal, booleans as "true" or "false", and enum values spelled as
+// the allowed value they match ignoring case. Strings are returned as is. It
+// fails when value is not of typ.
+func Coerce(typ string, allowed []string, value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	switch typ {
+	case TypeString:
+		return value, nil
+	case TypeInt:
+		n, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case TypeFloat:
+		f, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case TypeBool:
+		// The spellings the sheet importer accepts for boolean fields
+		switch strings.ToLower(trimmed) {
+		case "1", "true", "yes", "y", "x":
+			return "true", nil
+		case "0", "false", "no", "n":
+			return "false", nil
+		}
+		return "", fmt.Errorf("%q is not a boolean", value)
+	case TypeEnum:
+		for _, v := range allowed {
+			if strings.EqualFold(v, trimmed) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+	return "", fmt.Errorf("unknown property type %q", typ)
+}
+
+// Closest returns the name in names most likely meant by a misspelled key,
+// or "" when none is close: at most two edits away, ignoring case, and fewer
+// edits than the key is long.
+func Closest(key string, names []string) string {
+	best, bestDist := "", 3
+	for _, name := range names {
+		d := distance(strings.ToLower(key), strings.ToLower(name))
+		if d < bestDist && d < len(key) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b, in bytes.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,339 @@
+// Package ratelimit caps how fast each caller may call the API with token
+// buckets. A caller is the API key or JWT subject it authenticates as, or its
+// IP address when it sends no valid credentials, so a runaway CI job is
+// throttled without slowing everyone else down.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// servicePrefix starts the full name of every GameDNAService method. Calls to
+// other services, such as health checks and reflection, are never limited.
+const servicePrefix = "/entropic.dna.v1.GameDNAService/"
+
+// forwardedForMetadata carries the REST client's address on calls the
+// gateway makes for it.
+const forwardedForMetadata = "x-forwarded-for"
+
+// sweepInterval is how often buckets that have refilled are dropped.
+const sweepInterval = time.Minute
+
+// Limit is a token bucket: Burst calls at once, refilled at Rate calls per
+// second. A zero Rate is no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) validate(name string) error {
+	if l.Rate < 0 {
+		return fmt.Errorf("%s rate cannot be negative", name)
+	}
+	if l.Rate > 0 && l.Burst < 1 {
+		return fmt.Errorf("%s burst must be at least 1", name)
+	}
+	return nil
+}
+
+// Config sets the limits. A call draws on every limit that applies to it.
+type Config struct {
+	// Default limits every call by one caller.
+	Default Limit
+	// Writes limits calls by one caller to methods needing the write,
+	// publish or admin scope.
+	Writes Limit
+	// Methods limits calls by one caller to a method, by RPC name.
+	Methods map[string]Limit
+	// Keys replaces Default for callers authenticated as the API key name
+	// or JWT subject.
+	Keys map[string]Limit
+}
+
+// Limiter enforces a Config.
+type Limiter struct {
+	authn *auth.Authenticator
+	now   func() time.Time
+
+	mu        sync.Mutex
+	cfg       Config
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// New checks cfg and creates a limiter. authn identifies callers by their
+// credentials; when nil, every caller is identified by IP address.
+func New(cfg Config, authn *auth.Authenticator) (*Limiter, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		cfg:     cfg,
+		authn:   authn,
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}, nil
+}
+
+// SetConfig checks cfg and enforces it from now on. Every caller starts
+// again with full buckets. When cfg is invalid, the old limits stay.
+func (l *Limiter) SetConfig(cfg Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.buckets = make(map[bucketKey]*bucket)
+	return nil
+}
+
+func (cfg Config) validate() error {
+	if err := cfg.Default.validate("default"); err != nil {
+		return err
+	}
+	if err := cfg.Writes.validate("writes"); err != nil {
+		return err
+	}
+	methods := make(map[string]bool)
+	for _, m := range pb.GameDNAService_ServiceDesc.Methods {
+		methods[m.MethodName] = true
+	}
+	for _, s := range pb.GameDNAService_ServiceDesc.Streams {
+		methods[s.StreamName] = true
+	}
+	for method, limit := range cfg.Methods {
+		if !methods[method] {
+			return fmt.Errorf("unknown method %q", method)
+		}
+		if err := limit.validate(method); err != nil {
+			return err
+		}
+	}
+	for name, limit := range cfg.Keys {
+		if err := limit.validate("key " + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bucketKey names one caller's bucket for one limit: "" for the caller's
+// limit, "writes", or a method name.
+type bucketKey struct {
+	caller string
+	limit  string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// refill adds the tokens earned since the bucket was last used.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+}
+
+// wait is how long until the bucket holds a token.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// allow takes a token for a call of method, which is "" for REST endpoints
+// outside the gRPC service, from each of caller's buckets that applies. When
+// any is empty, none is taken and allow returns how long until the call
+// could go through.
+func (l *Limiter) allow(caller, principal, method string, write bool) (bool, time.Duration) {
+	type applied struct {
+		name  string
+		limit Limit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	callerLimit := l.cfg.Default
+	if limit, ok := l.cfg.Keys[principal]; ok && principal != "" {
+		callerLimit = limit
+	}
+	limits := []applied{{"", callerLimit}}
+	if write {
+		limits = append(limits, applied{"writes", l.cfg.Writes})
+	}
+	if limit, ok := l.cfg.Methods[method]; ok {
+		limits = append(limits, applied{method, limit})
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	var taken []*bucket
+	var wait time.Duration
+	for _, a := range limits {
+		if a.limit.Rate == 0 {
+			continue
+		}
+		key := bucketKey{caller: caller, limit: a.name}
+		b := l.buckets[key]
+		if b == nil {
+			b = &bucket{tokens: float64(a.limit.Burst), last: now, limit: a.limit}
+			l.buckets[key] = b
+		}
+		b.refill(now)
+		if w := b.wait(); w > wait {
+			wait = w
+		}
+		taken = append(taken, b)
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	return true, 0
+}
+
+// sweep drops buckets that have refilled, as a fresh bucket would be full
+// anyway, so callers that went quiet don't hold memory.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// identify returns the caller's bucket name and, when its credentials are
+// valid, the principal it authenticates as. Invalid credentials count as
+// none, so a caller can't dodge its limit by sending made-up keys.
+func (l *Limiter) identify(apiKey, bearer, ip string) (caller, principal string) {
+	if l.authn != nil && (apiKey != "" || bearer != "") {
+		if p, err := l.authn.Authenticate(apiKey, bearer); err == nil {
+			return "principal:" + p.Name, p.Name
+		}
+	}
+	return "ip:" + ip, ""
+}
+
+// limit admits a call of fullMethod or returns a ResourceExhausted status
+// carrying a RetryInfo.
+func (l *Limiter) limit(ctx context.Context, fullMethod string) error {
+	method, ok := strings.CutPrefix(fullMethod, servicePrefix)
+	if !ok {
+		return nil
+	}
+	apiKey, bearer := auth.Credentials(ctx)
+	caller, principal := l.identify(apiKey, bearer, callIP(ctx))
+	write := auth.RequiredScope(fullMethod) != auth.ScopeRead
+	allowed, wait := l.allow(caller, principal, method, write)
+	if allowed {
+		return nil
+	}
+	metrics.ObserveRateLimited(method)
+	st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for %s; retry in %s", method, wait.Round(time.Millisecond))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// callIP returns the IP address a call came from. Calls from a loopback
+// address, as the REST gateway's are, are attributed to the last address in
+// x-forwarded-for, which the gateway sets to its client's.
+func callIP(ctx context.Context) string {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	ip := addrIP(pr.Addr.String())
+	if parsed := net.ParseIP(ip); parsed == nil || !parsed.IsLoopback() {
+		return ip
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get(forwardedForMetadata); len(vals) > 0 {
+		forwarded := strings.Split(vals[len(vals)-1], ",")
+		if last := strings.TrimSpace(forwarded[len(forwarded)-1]); last != "" {
+			return last
+		}
+	}
+	return ip
+}
+
+// addrIP strips the port from a host:port address.
+func addrIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// UnaryInterceptor rejects calls over their caller's limits.
+func (l *Limiter) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.limit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls. A stream counts
+// as one call however many messages it carries.
+func (l *Limiter) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.limit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// Handler wraps a REST handler that doesn't go through the gRPC server, so
+// the interceptors can't see it, and answers requests over the caller's
+// limit with 429 and Retry-After. Gateway routes need no wrapping: the
+// interceptors limit them when the gateway calls the gRPC server.
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, bearer := auth.RequestCredentials(r)
+		caller, principal := l.identify(apiKey, bearer, addrIP(r.RemoteAddr))
+		allowed, wait := l.allow(caller, principal, "", false)
+		if !allowed {
+			metrics.ObserveRateLimited(r.URL.Path)
+			w.Header().Set("Retry-After", RetryAfter(wait))
+			http.Error(w, fmt.Sprintf("rate limit exceeded; retry in %s", wait.Round(time.Millisecond)), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RetryAfter formats wait as a Retry-After header value: whole seconds,
+// rounded up so a client that honours it is admitted.
+func RetryAfter(wait time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10)
+}
@@ -0,0 +1,66 @@
+package replicas
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// windowSize is how many recent read latencies the budget is taken from.
+	windowSize = 512
+	// minSamples is how many reads are timed before the percentile is
+	// trusted; until then the budget is the maximum delay.
+	minSamples = 20
+)
+
+// budget tracks recent replica read latencies and derives from them how
+// long a read waits before it is hedged.
+type budget struct {
+	percentile float64
+	min, max   time.Duration
+
+	mu      sync.Mutex
+	samples [windowSize]time.Duration
+	n       int // samples recorded, up to windowSize
+	next    int // where the next sample goes
+}
+
+func newBudget(percentile float64, minDelay, maxDelay time.Duration) *budget {
+	return &budget{percentile: percentile, min: minDelay, max: maxDelay}
+}
+
+// observe records the latency of a read.
+func (b *budget) observe(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.samples[b.next] = d
+	b.next = (b.next + 1) % windowSize
+	if b.n < windowSize {
+		b.n++
+	}
+}
+
+// delay returns the percentile of the recent latencies, clamped to the
+// configured bounds.
+func (b *budget) delay() time.Duration {
+	b.mu.Lock()
+	if b.n < minSamples {
+		b.mu.Unlock()
+		return b.max
+	}
+	sorted := make([]time.Duration, b.n)
+	copy(sorted, b.samples[:b.n])
+	b.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d := sorted[int(math.Ceil(b.percentile/100*float64(len(sorted))))-1]
+	if d < b.min {
+		return b.min
+	}
+	if d > b.max {
+		return b.max
+	}
+	return d
+}
@@ -0,0 +1,166 @@
+// Package replicas serves published-config reads from PostgreSQL read
+// replicas, hedging reads that a replica is slow to answer, as it is during
+// a garbage collection pause, with a second replica.
+package replicas
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+type staleKey struct{}
+
+// AllowStale returns ctx whose reads may be served by a replica. Replicas lag
+// behind the primary, so only reads that can live with a slightly old answer
+// should use it.
+func AllowStale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleKey{}, true)
+}
+
+func staleAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(staleKey{}).(bool)
+	return allowed
+}
+
+// Options tune hedging.
+type Options struct {
+	// HedgePercentile is the percentile of recent replica read latencies a
+	// read may take before it is also sent to a second replica. 0 disables
+	// hedging.
+	HedgePercentile float64
+	// MinDelay and MaxDelay bound the wait before hedging. MaxDelay is also
+	// the wait until enough reads have been timed.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// Store is a storage.Store that sends Read and ReadBySlug to the replicas in
+// turn when the context allows stale reads, and everything else to the
+// primary. A replica read that fails, or finds nothing because the replica
+// hasn't caught up yet, is retried on the primary.
+type Store struct {
+	storage.Store // the primary
+
+	replicas []storage.Store
+	opts     Options
+	budget   *budget
+	next     atomic.Uint64
+}
+
+// NewStore routes stale-tolerant reads of primary to replicas, which must not
+// be empty.
+func NewStore(primary storage.Store, replicas []storage.Store, opts Options) *Store {
+	return &Store{
+		Store:    primary,
+		replicas: replicas,
+		opts:     opts,
+		budget:   newBudget(opts.HedgePercentile, opts.MinDelay, opts.MaxDelay),
+	}
+}
+
+func (s *Store) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	if !staleAllowed(ctx) {
+		return s.Store.Read(ctx, id)
+	}
+	return s.read(ctx, func(ctx context.Context, store storage.Store) (*pb.GameDNA, error) {
+		return store.Read(ctx, id)
+	})
+}
+
+func (s *Store) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	if !staleAllowed(ctx) {
+		return s.Store.ReadBySlug(ctx, slug)
+	}
+	return s.read(ctx, func(ctx context.Context, store storage.Store) (*pb.GameDNA, error) {
+		return store.ReadBySlug(ctx, slug)
+	})
+}
+
+// Close closes the replicas and the primary.
+func (s *Store) Close() {
+	for _, replica := range s.replicas {
+		replica.Close()
+	}
+	s.Store.Close()
+}
+
+type readFunc func(ctx context.Context, store storage.Store) (*pb.GameDNA, error)
+
+type readResult struct {
+	dna     *pb.GameDNA
+	err     error
+	hedge   bool
+	primary bool
+}
+
+// read runs fn on the next replica. If it hasn't answered within the hedge
+// budget, fn runs on a second replica too, or on the primary when there is
+// only one, and the first success wins. When every attempt fails, fn runs on
+// the primary.
+func (s *Store) read(ctx context.Context, fn readFunc) (*pb.GameDNA, error) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	results := make(chan readResult, 2)
+	launch := func(store storage.Store, hedge bool) {
+		go func() {
+			dna, err := fn(attemptCtx, store)
+			results <- readResult{dna: dna, err: err, hedge: hedge, primary: store == s.Store}
+		}()
+	}
+
+	first := int(s.next.Add(1) % uint64(len(s.replicas)))
+	launch(s.replicas[first], false)
+	pending := 1
+
+	var hedgeTimer <-chan time.Time
+	if s.opts.HedgePercentile > 0 {
+		delay := s.budget.delay()
+		metrics.SetHedgeBudget(delay)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	hedged := false
+	for pending > 0 {
+		select {
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			hedged = true
+			second := s.Store
+			if len(s.replicas) > 1 {
+				second = s.replicas[(first+1)%len(s.replicas)]
+			}
+			launch(second, true)
+			pending++
+		case r := <-results:
+			pending--
+			// The primary's answer stands even when it is an error.
+			if r.err != nil && !r.primary {
+				continue
+			}
+			if r.err != nil {
+				metrics.ObserveReplicaRead(hedged, r.hedge, false)
+				return nil, r.err
+			}
+			// A hedged read is timed from its start, not the hedge's: that
+			// is a lower bound on the slow replica's latency, and timing the
+			// hedge alone would shrink the budget with every hedge.
+			s.budget.observe(time.Since(start))
+			metrics.ObserveReplicaRead(hedged, r.hedge, false)
+			return r.dna, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	metrics.ObserveReplicaRead(hedged, false, true)
+	return fn(ctx, s.Store)
+}
@@ -0,0 +1,671 @@
+package residency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// Router is a storage.Store that sends each call to the store of the region
+// the calling tenant is homed in. Calls without a tenant, for unknown tenants
+// or for tenants homed in a region this deployment doesn't serve fail with
+// storage.ErrForbidden, so no call ever reaches another region's data.
+type Router struct {
+	stores  map[string]storage.Store // region -> store
+	tenants map[string]string        // tenant -> region
+}
+
+// NewRouter creates a router over per-region stores. tenants maps each tenant
+// to its home region, which need not be one of stores.
+func NewRouter(stores map[string]storage.Store, tenants map[string]string) *Router {
+	return &Router{stores: stores, tenants: tenants}
+}
+
+// Region returns the home region of tenant, or "" if it is unknown.
+func (r *Router) Region(tenant string) string {
+	return r.tenants[tenant]
+}
+
+// storeFor picks the store for the tenant of ctx.
+func (r *Router) storeFor(ctx context.Context) (storage.Store, error) {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return nil, fmt.Errorf("the caller's credentials name no tenant: %w", storage.ErrForbidden)
+	}
+	region, ok := r.tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("tenant %s has no residency region: %w", tenant, storage.ErrForbidden)
+	}
+	store, ok := r.stores[region]
+	if !ok {
+		return nil, fmt.Errorf("tenant %s is homed in region %s, which this deployment does not serve: %w", tenant, region, storage.ErrForbidden)
+	}
+	return store, nil
+}
+
+func (r *Router) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Create(ctx, dna, opts...)
+}
+
+func (r *Router) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Read(ctx, id)
+}
+
+func (r *Router) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ReadBySlug(ctx, slug)
+}
+
+func (r *Router) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Update(ctx, dna, opts...)
+}
+
+func (r *Router) Delete(ctx context.Context, id string) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, id)
+}
+
+func (r *Router) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return store.List(ctx, filters, pagination)
+}
+
+func (r *Router) ListAfter(ctx context.Context, filters storage.ListFilters, after *storage.ListCursor, limit int32) ([]*pb.GameDNA, *storage.ListCursor, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.ListAfter(ctx, filters, after, limit)
+}
+
+func (r *Router) Each(ctx context.Context, filters storage.ListFilters, fn func(*pb.GameDNA) error) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Each(ctx, filters, fn)
+}
+
+func (r *Router) Search(ctx context.Context, query storage.SearchQuery, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return store.Search(ctx, query, pagination)
+}
+
+func (r *Router) GetVersionHistory(ctx context.Context, configID string) ([]*storage.VersionInfo, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetVersionHistory(ctx, configID)
+}
+
+func (r *Router) ListVersions(ctx context.Context, configID string, page storage.VersionPage) ([]*storage.VersionInfo, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListVersions(ctx, configID, page)
+}
+
+func (r *Router) GetVersion(ctx context.Context, configID string, versionNum int64) (*storage.VersionInfo, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetVersion(ctx, configID, versionNum)
+}
+
+func (r *Router) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (int64, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.PruneVersionHistory(ctx, retention)
+}
+
+func (r *Router) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (r *Router) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (r *Router) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (r *Router) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (r *Router) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (r *Router) ListTemporaryUnlocks(ctx context.Context) ([]*storage.TemporaryUnlock, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListTemporaryUnlocks(ctx)
+}
+
+func (r *Router) StartCanary(ctx context.Context, canary *storage.Canary) (*storage.Canary, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.StartCanary(ctx, canary)
+}
+
+func (r *Router) GetCanary(ctx context.Context, configID string) (*storage.Canary, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetCanary(ctx, configID)
+}
+
+func (r *Router) ListRunningCanaries(ctx context.Context) ([]*storage.Canary, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListRunningCanaries(ctx)
+}
+
+func (r *Router) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*storage.Canary, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.RecordCanaryFeedback(ctx, configID, checksum, sessions, errors)
+}
+
+func (r *Router) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return store.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (r *Router) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (r *Router) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*storage.Canary, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.RollbackCanary(ctx, configID, actor, reason)
+}
+
+func (r *Router) TagVersion(ctx context.Context, tag *storage.ReleaseTag, move bool) (*storage.ReleaseTag, int64, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return store.TagVersion(ctx, tag, move)
+}
+
+func (r *Router) GetTaggedVersion(ctx context.Context, configID string, tag string) (*storage.VersionInfo, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetTaggedVersion(ctx, configID, tag)
+}
+
+func (r *Router) ListReleaseTags(ctx context.Context, configID string) ([]*storage.ReleaseTag, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListReleaseTags(ctx, configID)
+}
+
+func (r *Router) ListAuditEntries(ctx context.Context, configID string) ([]*storage.AuditEntry, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListAuditEntries(ctx, configID)
+}
+
+func (r *Router) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Clone(ctx, id, newName, actor)
+}
+
+func (r *Router) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveSet(ctx, set)
+}
+
+func (r *Router) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ImportConfig(ctx, dna, versions)
+}
+
+func (r *Router) CreateSnapshot(ctx context.Context, snapshot *storage.Snapshot) (*storage.Snapshot, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.CreateSnapshot(ctx, snapshot)
+}
+
+func (r *Router) GetSnapshot(ctx context.Context, name string, withData bool) (*storage.Snapshot, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetSnapshot(ctx, name, withData)
+}
+
+func (r *Router) ListSnapshots(ctx context.Context) ([]*storage.Snapshot, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListSnapshots(ctx)
+}
+
+func (r *Router) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.RestoreSnapshot(ctx, name, actor)
+}
+
+func (r *Router) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.TransferOwnership(ctx, from, to, actor, dryRun)
+}
+
+func (r *Router) FindPrincipalData(ctx context.Context, principal string) (*storage.PrincipalData, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.FindPrincipalData(ctx, principal)
+}
+
+func (r *Router) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.AnonymizePrincipal(ctx, principal, pseudonym)
+}
+
+func (r *Router) SaveImportMapping(ctx context.Context, mapping *storage.ImportMapping) (*storage.ImportMapping, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveImportMapping(ctx, mapping)
+}
+
+func (r *Router) GetImportMapping(ctx context.Context, project string) (*storage.ImportMapping, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetImportMapping(ctx, project)
+}
+
+func (r *Router) SaveAPIToken(ctx context.Context, token *storage.APIToken) (*storage.APIToken, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveAPIToken(ctx, token)
+}
+
+func (r *Router) GetAPIToken(ctx context.Context, id string) (*storage.APIToken, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetAPIToken(ctx, id)
+}
+
+func (r *Router) FindAPIToken(ctx context.Context, hash string) (*storage.APIToken, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.FindAPIToken(ctx, hash)
+}
+
+func (r *Router) ListAPITokens(ctx context.Context) ([]*storage.APIToken, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListAPITokens(ctx)
+}
+
+func (r *Router) SaveDraft(ctx context.Context, draft *storage.Draft) (*storage.Draft, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveDraft(ctx, draft)
+}
+
+func (r *Router) GetDraft(ctx context.Context, configID, owner string) (*storage.Draft, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetDraft(ctx, configID, owner)
+}
+
+func (r *Router) DeleteDraft(ctx context.Context, configID, owner string) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.DeleteDraft(ctx, configID, owner)
+}
+
+func (r *Router) SaveWaiver(ctx context.Context, waiver *storage.Waiver) (*storage.Waiver, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveWaiver(ctx, waiver)
+}
+
+func (r *Router) ListWaivers(ctx context.Context, configID string) ([]*storage.Waiver, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListWaivers(ctx, configID)
+}
+
+func (r *Router) SaveTemplate(ctx context.Context, template *storage.Template) (*storage.Template, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveTemplate(ctx, template)
+}
+
+func (r *Router) GetTemplate(ctx context.Context, name string) (*storage.Template, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetTemplate(ctx, name)
+}
+
+func (r *Router) ListTemplates(ctx context.Context) ([]*storage.Template, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListTemplates(ctx)
+}
+
+func (r *Router) DeleteTemplate(ctx context.Context, name string) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.DeleteTemplate(ctx, name)
+}
+
+func (r *Router) SavePropertySchema(ctx context.Context, schema *storage.PropertySchema) (*storage.PropertySchema, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SavePropertySchema(ctx, schema)
+}
+
+func (r *Router) GetPropertySchema(ctx context.Context, project, name string) (*storage.PropertySchema, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetPropertySchema(ctx, project, name)
+}
+
+func (r *Router) ListPropertySchemas(ctx context.Context, project string) ([]*storage.PropertySchema, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListPropertySchemas(ctx, project)
+}
+
+func (r *Router) DeletePropertySchema(ctx context.Context, project, name string) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.DeletePropertySchema(ctx, project, name)
+}
+
+func (r *Router) SaveWebhook(ctx context.Context, webhook *storage.Webhook) (*storage.Webhook, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveWebhook(ctx, webhook)
+}
+
+func (r *Router) GetWebhook(ctx context.Context, id string) (*storage.Webhook, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetWebhook(ctx, id)
+}
+
+func (r *Router) ListWebhooks(ctx context.Context) ([]*storage.Webhook, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListWebhooks(ctx)
+}
+
+func (r *Router) DeleteWebhook(ctx context.Context, id string) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.DeleteWebhook(ctx, id)
+}
+
+func (r *Router) SaveWebhookDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (*storage.WebhookDelivery, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveWebhookDelivery(ctx, delivery)
+}
+
+func (r *Router) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*storage.WebhookDelivery, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ClaimWebhookDeliveries(ctx, now, limit, lease)
+}
+
+func (r *Router) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*storage.WebhookDelivery, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListWebhookDeliveries(ctx, webhookID, state, limit)
+}
+
+func (r *Router) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.PruneWebhookDeliveries(ctx, finishedBefore)
+}
+
+func (r *Router) ClaimIdempotencyKey(ctx context.Context, key *storage.IdempotencyKey) (*storage.IdempotencyKey, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ClaimIdempotencyKey(ctx, key)
+}
+
+func (r *Router) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return err
+	}
+	return store.CompleteIdempotencyKey(ctx, scope, key, response, expiresAt)
+}
+
+func (r *Router) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.PruneIdempotencyKeys(ctx, expiredBefore)
+}
+
+func (r *Router) ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.ConfigIDsAfter(ctx, after, limit)
+}
+
+func (r *Router) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (int64, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.FixChecksums(ctx, fixes)
+}
+
+func (r *Router) SaveChecksumJob(ctx context.Context, job *storage.ChecksumJob) (*storage.ChecksumJob, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.SaveChecksumJob(ctx, job)
+}
+
+func (r *Router) GetChecksumJob(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetChecksumJob(ctx, id)
+}
+
+func (r *Router) IndexStats(ctx context.Context) ([]storage.IndexStat, error) {
+	store, err := r.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.IndexStats(ctx)
+}
+
+// Ping pings every regional store, whatever the tenant of ctx, as readiness
+// needs them all.
+func (r *Router) Ping(ctx context.Context) error {
+	regions := make([]string, 0, len(r.stores))
+	for region := range r.stores {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		if err := r.stores[region].Ping(ctx); err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+	}
+	return nil
+}
+
+// Close closes every regional store.
+func (r *Router) Close() {
+	for _, store := range r.stores {
+		store.Close()
+	}
+}
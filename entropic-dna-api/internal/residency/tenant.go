@@ -0,0 +1,63 @@
+// Package residency keeps each tenant's configs in the database of the region
+// the tenant is homed in.
+package residency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TenantHeader names the tenant of a REST request.
+	TenantHeader = "X-Tenant-Id"
+	// TenantMetadata names the tenant of a gRPC call. The REST gateway
+	// forwards TenantHeader as this key.
+	TenantMetadata = "x-tenant-id"
+)
+
+// ErrTenantMismatch indicates a call naming a tenant other than the one its
+// credentials belong to.
+var ErrTenantMismatch = errors.New("the tenant does not match the caller's credentials")
+
+type tenantKey struct{}
+
+// WithTenant returns ctx acting for tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with WithTenant or Bind, or "" if
+// there is none. The tenant a call names in its metadata is never used
+// until the call's credentials confirm it.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// RequestedTenant returns the tenant an incoming gRPC call names in its
+// x-tenant-id metadata, or "". Callers may name any tenant, so it only
+// serves to find the store holding the call's credentials.
+func RequestedTenant(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(TenantMetadata); len(vals) > 0 {
+			return vals[0]
+		}
+	}
+	return ""
+}
+
+// Bind returns ctx acting for tenant, the tenant of the credentials the call
+// authenticated with. A call that names another tenant, including one whose
+// credentials have none, fails with ErrTenantMismatch.
+func Bind(ctx context.Context, requested, tenant string) (context.Context, error) {
+	if requested != "" && requested != tenant {
+		return nil, fmt.Errorf("%s %q: %w", TenantHeader, requested, ErrTenantMismatch)
+	}
+	if tenant == "" {
+		return ctx, nil
+	}
+	return WithTenant(ctx, tenant), nil
+}
@@ -0,0 +1,116 @@
+// Package resilience keeps the service answering cleanly while its database
+// restarts or fails over. Calls failing transiently are retried with backoff,
+// and a circuit breaker fails calls fast with storage.ErrUnavailable once the
+// database keeps failing, until a call or health check gets through again.
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Options tune retries and the circuit breaker.
+type Options struct {
+	// MaxRetries is how many times a call failing transiently is retried.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// one after up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// FailureThreshold is how many calls in a row may lose the database
+	// before the breaker opens. 0 never opens it.
+	FailureThreshold int
+	// Cooldown is how long an open breaker fails calls fast before it lets
+	// one through to try the database.
+	Cooldown time.Duration
+	// HealthInterval is how often the database is pinged. A failed ping
+	// opens the breaker and a successful one closes it. 0 disables pinging.
+	HealthInterval time.Duration
+}
+
+// breaker counts calls that lost the database in a row and, past the
+// threshold, stays open for the cooldown. After it, one trial call at a time
+// goes through; the breaker closes once one reaches the database.
+type breaker struct {
+	opts   Options
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	open     bool
+	failures int
+	openedAt time.Time
+	trial    bool // a trial call is running
+}
+
+// allow reports whether a call may go to the database, and whether it is
+// the trial call of an open breaker.
+func (b *breaker) allow() (ok, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true, false
+	}
+	if b.trial || time.Since(b.openedAt) < b.opts.Cooldown {
+		return false, false
+	}
+	b.trial = true
+	return true, true
+}
+
+// record notes how an allowed call went: whether it lost the database.
+func (b *breaker) record(trial, lost bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		b.trial = false
+	}
+	if !lost {
+		b.failures = 0
+		b.closeLocked()
+		return
+	}
+	b.failures++
+	switch {
+	case b.open && trial:
+		b.openedAt = time.Now()
+	case !b.open && b.opts.FailureThreshold > 0 && b.failures >= b.opts.FailureThreshold:
+		b.openLocked("calls kept losing the database")
+	}
+}
+
+// probe notes the outcome of a health check ping.
+func (b *breaker) probe(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.closeLocked()
+		return
+	}
+	if !b.open {
+		b.logger.Warn("Database health check failed", zap.Error(err))
+		b.openLocked("health check failed")
+	}
+}
+
+func (b *breaker) openLocked(reason string) {
+	b.open = true
+	b.openedAt = time.Now()
+	metrics.ObserveBreaker(true)
+	b.logger.Warn("Database circuit breaker opened; failing calls fast",
+		zap.String("reason", reason),
+		zap.Duration("cooldown", b.opts.Cooldown))
+}
+
+func (b *breaker) closeLocked() {
+	if !b.open {
+		return
+	}
+	b.open = false
+	metrics.ObserveBreaker(false)
+	b.logger.Info("Database reachable again; circuit breaker closed",
+		zap.Duration("open_for", time.Since(b.openedAt)))
+}
@@ -0,0 +1,609 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Whether a call may be retried after losing the database. A read may; a
+// write may have been committed before the connection dropped, so it is
+// retried only when PostgreSQL reports it rolled back, after a serialization
+// failure or deadlock.
+const (
+	read  = true
+	write = false
+)
+
+// store wraps a storage.Store, retrying its transient failures and failing
+// calls fast while the breaker is open.
+type store struct {
+	next storage.Store
+	opts Options
+	b    *breaker
+	stop context.CancelFunc
+}
+
+// WrapStore returns a store that retries calls to next failing transiently
+// and, once next keeps losing its database, fails calls fast with
+// storage.ErrUnavailable until it is reachable again. Closing the store stops
+// its health checks.
+func WrapStore(next storage.Store, opts Options, logger *zap.Logger) storage.Store {
+	ctx, stop := context.WithCancel(context.Background())
+	s := &store{next: next, opts: opts, b: &breaker{opts: opts, logger: logger}, stop: stop}
+	if opts.HealthInterval > 0 {
+		go s.monitor(ctx)
+	}
+	return s
+}
+
+// monitor pings the database every HealthInterval until ctx is done.
+func (s *store) monitor(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, s.opts.HealthInterval)
+		err := s.next.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		s.b.probe(err)
+	}
+}
+
+// do runs fn unless the breaker is open, retrying it with backoff while it
+// fails transiently. A call that still loses the database, or keeps being
+// rolled back, fails with storage.ErrUnavailable; other errors are returned
+// as they are.
+func (s *store) do(ctx context.Context, retryLost bool, fn func() error) error {
+	wait := s.opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		ok, trial := s.b.allow()
+		if !ok {
+			metrics.ObserveDatabaseRejected()
+			return fmt.Errorf("%w: database circuit breaker is open", storage.ErrUnavailable)
+		}
+		err := fn()
+		lost := storage.Disconnected(err)
+		s.b.record(trial, lost)
+
+		aborted := storage.Aborted(err)
+		if !lost && !aborted {
+			return err
+		}
+		if attempt >= s.opts.MaxRetries || (lost && !retryLost) || ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", storage.ErrUnavailable, err)
+		}
+		metrics.ObserveDatabaseRetry()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", storage.ErrUnavailable, err)
+		case <-timer.C:
+		}
+		wait = min(wait*2, s.opts.MaxBackoff)
+	}
+}
+
+// call runs fn through do, returning its result.
+func call[T any](s *store, ctx context.Context, retryLost bool, fn func() (T, error)) (T, error) {
+	var v T
+	err := s.do(ctx, retryLost, func() (err error) {
+		v, err = fn()
+		return err
+	})
+	return v, err
+}
+
+// call2 is call for methods with two results.
+func call2[T, U any](s *store, ctx context.Context, retryLost bool, fn func() (T, U, error)) (T, U, error) {
+	var (
+		v T
+		w U
+	)
+	err := s.do(ctx, retryLost, func() (err error) {
+		v, w, err = fn()
+		return err
+	})
+	return v, w, err
+}
+
+// call3 is call for methods with three results.
+func call3[T, U, V any](s *store, ctx context.Context, retryLost bool, fn func() (T, U, V, error)) (T, U, V, error) {
+	var (
+		v T
+		w U
+		x V
+	)
+	err := s.do(ctx, retryLost, func() (err error) {
+		v, w, x, err = fn()
+		return err
+	})
+	return v, w, x, err
+}
+
+func (s *store) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.Create(ctx, dna, opts...)
+	})
+}
+
+func (s *store) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	return call(s, ctx, read, func() (*pb.GameDNA, error) {
+		return s.next.Read(ctx, id)
+	})
+}
+
+func (s *store) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	return call(s, ctx, read, func() (*pb.GameDNA, error) {
+		return s.next.ReadBySlug(ctx, slug)
+	})
+}
+
+func (s *store) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.Update(ctx, dna, opts...)
+	})
+}
+
+func (s *store) Delete(ctx context.Context, id string) error {
+	return s.do(ctx, write, func() error {
+		return s.next.Delete(ctx, id)
+	})
+}
+
+func (s *store) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	return call2(s, ctx, read, func() ([]*pb.GameDNA, int32, error) {
+		return s.next.List(ctx, filters, pagination)
+	})
+}
+
+func (s *store) ListAfter(ctx context.Context, filters storage.ListFilters, after *storage.ListCursor, limit int32) ([]*pb.GameDNA, *storage.ListCursor, error) {
+	return call2(s, ctx, read, func() ([]*pb.GameDNA, *storage.ListCursor, error) {
+		return s.next.ListAfter(ctx, filters, after, limit)
+	})
+}
+
+// Each is not retried after losing the database, as fn may have been given
+// some of the configs already. An error from fn is returned as it is, never
+// taken for a lost database.
+func (s *store) Each(ctx context.Context, filters storage.ListFilters, fn func(*pb.GameDNA) error) error {
+	var fnErr error
+	err := s.do(ctx, write, func() error {
+		err := s.next.Each(ctx, filters, func(dna *pb.GameDNA) error {
+			fnErr = fn(dna)
+			return fnErr
+		})
+		if fnErr != nil {
+			return nil
+		}
+		return err
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+func (s *store) Search(ctx context.Context, query storage.SearchQuery, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	return call2(s, ctx, read, func() ([]*pb.GameDNA, int32, error) {
+		return s.next.Search(ctx, query, pagination)
+	})
+}
+
+func (s *store) GetVersionHistory(ctx context.Context, configID string) ([]*storage.VersionInfo, error) {
+	return call(s, ctx, read, func() ([]*storage.VersionInfo, error) {
+		return s.next.GetVersionHistory(ctx, configID)
+	})
+}
+
+func (s *store) ListVersions(ctx context.Context, configID string, page storage.VersionPage) ([]*storage.VersionInfo, error) {
+	return call(s, ctx, read, func() ([]*storage.VersionInfo, error) {
+		return s.next.ListVersions(ctx, configID, page)
+	})
+}
+
+func (s *store) GetVersion(ctx context.Context, configID string, versionNum int64) (*storage.VersionInfo, error) {
+	return call(s, ctx, read, func() (*storage.VersionInfo, error) {
+		return s.next.GetVersion(ctx, configID, versionNum)
+	})
+}
+
+func (s *store) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (int64, error) {
+	return call(s, ctx, write, func() (int64, error) {
+		return s.next.PruneVersionHistory(ctx, retention)
+	})
+}
+
+func (s *store) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.RollbackToVersion(ctx, configID, versionNum, actor)
+	})
+}
+
+func (s *store) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+	})
+}
+
+func (s *store) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *storage.AuditEntry, error) {
+	return call2(s, ctx, write, func() (*pb.GameDNA, *storage.AuditEntry, error) {
+		return s.next.UnpublishVersion(ctx, configID, actor, reason)
+	})
+}
+
+func (s *store) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *storage.AuditEntry, error) {
+	return call2(s, ctx, write, func() (*pb.GameDNA, *storage.AuditEntry, error) {
+		return s.next.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+	})
+}
+
+func (s *store) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	return call2(s, ctx, write, func() (*pb.GameDNA, *storage.AuditEntry, error) {
+		return s.next.RelockVersion(ctx, configID, actor, reason, checksum, report)
+	})
+}
+
+func (s *store) ListTemporaryUnlocks(ctx context.Context) ([]*storage.TemporaryUnlock, error) {
+	return call(s, ctx, read, func() ([]*storage.TemporaryUnlock, error) {
+		return s.next.ListTemporaryUnlocks(ctx)
+	})
+}
+
+func (s *store) StartCanary(ctx context.Context, canary *storage.Canary) (*storage.Canary, error) {
+	return call(s, ctx, write, func() (*storage.Canary, error) {
+		return s.next.StartCanary(ctx, canary)
+	})
+}
+
+func (s *store) GetCanary(ctx context.Context, configID string) (*storage.Canary, error) {
+	return call(s, ctx, read, func() (*storage.Canary, error) {
+		return s.next.GetCanary(ctx, configID)
+	})
+}
+
+func (s *store) ListRunningCanaries(ctx context.Context) ([]*storage.Canary, error) {
+	return call(s, ctx, read, func() ([]*storage.Canary, error) {
+		return s.next.ListRunningCanaries(ctx)
+	})
+}
+
+func (s *store) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*storage.Canary, error) {
+	return call(s, ctx, write, func() (*storage.Canary, error) {
+		return s.next.RecordCanaryFeedback(ctx, configID, checksum, sessions, errors)
+	})
+}
+
+func (s *store) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+	return call3(s, ctx, write, func() (*pb.GameDNA, *storage.Canary, *storage.AuditEntry, error) {
+		return s.next.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+	})
+}
+
+func (s *store) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *storage.AuditEntry, error) {
+	return call2(s, ctx, write, func() (*pb.GameDNA, *storage.AuditEntry, error) {
+		return s.next.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+	})
+}
+
+func (s *store) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*storage.Canary, error) {
+	return call(s, ctx, write, func() (*storage.Canary, error) {
+		return s.next.RollbackCanary(ctx, configID, actor, reason)
+	})
+}
+
+func (s *store) TagVersion(ctx context.Context, tag *storage.ReleaseTag, move bool) (*storage.ReleaseTag, int64, error) {
+	return call2(s, ctx, write, func() (*storage.ReleaseTag, int64, error) {
+		return s.next.TagVersion(ctx, tag, move)
+	})
+}
+
+func (s *store) GetTaggedVersion(ctx context.Context, configID string, tag string) (*storage.VersionInfo, error) {
+	return call(s, ctx, read, func() (*storage.VersionInfo, error) {
+		return s.next.GetTaggedVersion(ctx, configID, tag)
+	})
+}
+
+func (s *store) ListReleaseTags(ctx context.Context, configID string) ([]*storage.ReleaseTag, error) {
+	return call(s, ctx, read, func() ([]*storage.ReleaseTag, error) {
+		return s.next.ListReleaseTags(ctx, configID)
+	})
+}
+
+func (s *store) ListAuditEntries(ctx context.Context, configID string) ([]*storage.AuditEntry, error) {
+	return call(s, ctx, read, func() ([]*storage.AuditEntry, error) {
+		return s.next.ListAuditEntries(ctx, configID)
+	})
+}
+
+func (s *store) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.Clone(ctx, id, newName, actor)
+	})
+}
+
+func (s *store) SaveSet(ctx context.Context, set storage.SaveSet) (*storage.SaveSet, error) {
+	return call(s, ctx, write, func() (*storage.SaveSet, error) {
+		return s.next.SaveSet(ctx, set)
+	})
+}
+
+func (s *store) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (*pb.GameDNA, error) {
+	return call(s, ctx, write, func() (*pb.GameDNA, error) {
+		return s.next.ImportConfig(ctx, dna, versions)
+	})
+}
+
+func (s *store) CreateSnapshot(ctx context.Context, snapshot *storage.Snapshot) (*storage.Snapshot, error) {
+	return call(s, ctx, write, func() (*storage.Snapshot, error) {
+		return s.next.CreateSnapshot(ctx, snapshot)
+	})
+}
+
+func (s *store) GetSnapshot(ctx context.Context, name string, withData bool) (*storage.Snapshot, error) {
+	return call(s, ctx, read, func() (*storage.Snapshot, error) {
+		return s.next.GetSnapshot(ctx, name, withData)
+	})
+}
+
+func (s *store) ListSnapshots(ctx context.Context) ([]*storage.Snapshot, error) {
+	return call(s, ctx, read, func() ([]*storage.Snapshot, error) {
+		return s.next.ListSnapshots(ctx)
+	})
+}
+
+func (s *store) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+	return call(s, ctx, write, func() ([]*pb.GameDNA, error) {
+		return s.next.RestoreSnapshot(ctx, name, actor)
+	})
+}
+
+func (s *store) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*storage.OwnershipTransfer, error) {
+	return call(s, ctx, write, func() (*storage.OwnershipTransfer, error) {
+		return s.next.TransferOwnership(ctx, from, to, actor, dryRun)
+	})
+}
+
+func (s *store) FindPrincipalData(ctx context.Context, principal string) (*storage.PrincipalData, error) {
+	return call(s, ctx, read, func() (*storage.PrincipalData, error) {
+		return s.next.FindPrincipalData(ctx, principal)
+	})
+}
+
+func (s *store) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*storage.PrincipalData, error) {
+	return call(s, ctx, write, func() (*storage.PrincipalData, error) {
+		return s.next.AnonymizePrincipal(ctx, principal, pseudonym)
+	})
+}
+
+func (s *store) SaveImportMapping(ctx context.Context, mapping *storage.ImportMapping) (*storage.ImportMapping, error) {
+	return call(s, ctx, write, func() (*storage.ImportMapping, error) {
+		return s.next.SaveImportMapping(ctx, mapping)
+	})
+}
+
+func (s *store) GetImportMapping(ctx context.Context, project string) (*storage.ImportMapping, error) {
+	return call(s, ctx, read, func() (*storage.ImportMapping, error) {
+		return s.next.GetImportMapping(ctx, project)
+	})
+}
+
+func (s *store) SaveAPIToken(ctx context.Context, token *storage.APIToken) (*storage.APIToken, error) {
+	return call(s, ctx, write, func() (*storage.APIToken, error) {
+		return s.next.SaveAPIToken(ctx, token)
+	})
+}
+
+func (s *store) GetAPIToken(ctx context.Context, id string) (*storage.APIToken, error) {
+	return call(s, ctx, read, func() (*storage.APIToken, error) {
+		return s.next.GetAPIToken(ctx, id)
+	})
+}
+
+func (s *store) FindAPIToken(ctx context.Context, hash string) (*storage.APIToken, error) {
+	return call(s, ctx, read, func() (*storage.APIToken, error) {
+		return s.next.FindAPIToken(ctx, hash)
+	})
+}
+
+func (s *store) ListAPITokens(ctx context.Context) ([]*storage.APIToken, error) {
+	return call(s, ctx, read, func() ([]*storage.APIToken, error) {
+		return s.next.ListAPITokens(ctx)
+	})
+}
+
+func (s *store) SaveWaiver(ctx context.Context, waiver *storage.Waiver) (*storage.Waiver, error) {
+	return call(s, ctx, write, func() (*storage.Waiver, error) {
+		return s.next.SaveWaiver(ctx, waiver)
+	})
+}
+
+func (s *store) ListWaivers(ctx context.Context, configID string) ([]*storage.Waiver, error) {
+	return call(s, ctx, read, func() ([]*storage.Waiver, error) {
+		return s.next.ListWaivers(ctx, configID)
+	})
+}
+
+func (s *store) SaveTemplate(ctx context.Context, template *storage.Template) (*storage.Template, error) {
+	return call(s, ctx, write, func() (*storage.Template, error) {
+		return s.next.SaveTemplate(ctx, template)
+	})
+}
+
+func (s *store) GetTemplate(ctx context.Context, name string) (*storage.Template, error) {
+	return call(s, ctx, read, func() (*storage.Template, error) {
+		return s.next.GetTemplate(ctx, name)
+	})
+}
+
+func (s *store) ListTemplates(ctx context.Context) ([]*storage.Template, error) {
+	return call(s, ctx, read, func() ([]*storage.Template, error) {
+		return s.next.ListTemplates(ctx)
+	})
+}
+
+func (s *store) DeleteTemplate(ctx context.Context, name string) error {
+	return s.do(ctx, write, func() error {
+		return s.next.DeleteTemplate(ctx, name)
+	})
+}
+
+func (s *store) SavePropertySchema(ctx context.Context, schema *storage.PropertySchema) (*storage.PropertySchema, error) {
+	return call(s, ctx, write, func() (*storage.PropertySchema, error) {
+		return s.next.SavePropertySchema(ctx, schema)
+	})
+}
+
+func (s *store) GetPropertySchema(ctx context.Context, project, name string) (*storage.PropertySchema, error) {
+	return call(s, ctx, read, func() (*storage.PropertySchema, error) {
+		return s.next.GetPropertySchema(ctx, project, name)
+	})
+}
+
+func (s *store) ListPropertySchemas(ctx context.Context, project string) ([]*storage.PropertySchema, error) {
+	return call(s, ctx, read, func() ([]*storage.PropertySchema, error) {
+		return s.next.ListPropertySchemas(ctx, project)
+	})
+}
+
+func (s *store) DeletePropertySchema(ctx context.Context, project, name string) error {
+	return s.do(ctx, write, func() error {
+		return s.next.DeletePropertySchema(ctx, project, name)
+	})
+}
+
+func (s *store) SaveWebhook(ctx context.Context, webhook *storage.Webhook) (*storage.Webhook, error) {
+	return call(s, ctx, write, func() (*storage.Webhook, error) {
+		return s.next.SaveWebhook(ctx, webhook)
+	})
+}
+
+func (s *store) GetWebhook(ctx context.Context, id string) (*storage.Webhook, error) {
+	return call(s, ctx, read, func() (*storage.Webhook, error) {
+		return s.next.GetWebhook(ctx, id)
+	})
+}
+
+func (s *store) ListWebhooks(ctx context.Context) ([]*storage.Webhook, error) {
+	return call(s, ctx, read, func() ([]*storage.Webhook, error) {
+		return s.next.ListWebhooks(ctx)
+	})
+}
+
+func (s *store) DeleteWebhook(ctx context.Context, id string) error {
+	return s.do(ctx, write, func() error {
+		return s.next.DeleteWebhook(ctx, id)
+	})
+}
+
+func (s *store) SaveWebhookDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (*storage.WebhookDelivery, error) {
+	return call(s, ctx, write, func() (*storage.WebhookDelivery, error) {
+		return s.next.SaveWebhookDelivery(ctx, delivery)
+	})
+}
+
+func (s *store) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*storage.WebhookDelivery, error) {
+	return call(s, ctx, write, func() ([]*storage.WebhookDelivery, error) {
+		return s.next.ClaimWebhookDeliveries(ctx, now, limit, lease)
+	})
+}
+
+func (s *store) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*storage.WebhookDelivery, error) {
+	return call(s, ctx, read, func() ([]*storage.WebhookDelivery, error) {
+		return s.next.ListWebhookDeliveries(ctx, webhookID, state, limit)
+	})
+}
+
+func (s *store) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	return call(s, ctx, write, func() (int64, error) {
+		return s.next.PruneWebhookDeliveries(ctx, finishedBefore)
+	})
+}
+
+func (s *store) ClaimIdempotencyKey(ctx context.Context, key *storage.IdempotencyKey) (*storage.IdempotencyKey, error) {
+	return call(s, ctx, write, func() (*storage.IdempotencyKey, error) {
+		return s.next.ClaimIdempotencyKey(ctx, key)
+	})
+}
+
+func (s *store) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	return s.do(ctx, write, func() error {
+		return s.next.CompleteIdempotencyKey(ctx, scope, key, response, expiresAt)
+	})
+}
+
+func (s *store) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	return call(s, ctx, write, func() (int64, error) {
+		return s.next.PruneIdempotencyKeys(ctx, expiredBefore)
+	})
+}
+
+func (s *store) ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+	return call(s, ctx, read, func() ([]string, error) {
+		return s.next.ConfigIDsAfter(ctx, after, limit)
+	})
+}
+
+func (s *store) SaveDraft(ctx context.Context, draft *storage.Draft) (*storage.Draft, error) {
+	return call(s, ctx, write, func() (*storage.Draft, error) {
+		return s.next.SaveDraft(ctx, draft)
+	})
+}
+
+func (s *store) GetDraft(ctx context.Context, configID, owner string) (*storage.Draft, error) {
+	return call(s, ctx, read, func() (*storage.Draft, error) {
+		return s.next.GetDraft(ctx, configID, owner)
+	})
+}
+
+func (s *store) DeleteDraft(ctx context.Context, configID, owner string) error {
+	return s.do(ctx, write, func() error {
+		return s.next.DeleteDraft(ctx, configID, owner)
+	})
+}
+
+func (s *store) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (int64, error) {
+	return call(s, ctx, write, func() (int64, error) {
+		return s.next.FixChecksums(ctx, fixes)
+	})
+}
+
+func (s *store) SaveChecksumJob(ctx context.Context, job *storage.ChecksumJob) (*storage.ChecksumJob, error) {
+	return call(s, ctx, write, func() (*storage.ChecksumJob, error) {
+		return s.next.SaveChecksumJob(ctx, job)
+	})
+}
+
+func (s *store) GetChecksumJob(ctx context.Context, id string) (*storage.ChecksumJob, error) {
+	return call(s, ctx, read, func() (*storage.ChecksumJob, error) {
+		return s.next.GetChecksumJob(ctx, id)
+	})
+}
+
+func (s *store) IndexStats(ctx context.Context) ([]storage.IndexStat, error) {
+	return call(s, ctx, read, func() ([]storage.IndexStat, error) {
+		return s.next.IndexStats(ctx)
+	})
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	return s.next.Ping(ctx)
+}
+
+func (s *store) Close() {
+	s.stop()
+	s.next.Close()
+}
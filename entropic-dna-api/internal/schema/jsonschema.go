@@ -0,0 +1,227 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// JSONSchemaDialect is the JSON Schema draft the schemas are written in, the
+// one OpenAPI 3.1 uses.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Object is a JSON object of a schema or an OpenAPI document.
+type Object = map[string]interface{}
+
+// Builder writes JSON Schemas of proto messages as the REST gateway encodes
+// them with protojson: lowerCamelCase field names, 64-bit integers as
+// strings, enums by name and well-known types in their JSON forms. Message
+// and enum types become definitions referred to by full name, so recursive
+// types end.
+type Builder struct {
+	refPrefix string
+	defs      Object
+}
+
+// NewBuilder returns a Builder whose references start with refPrefix, such as
+// "#/$defs/" or "#/components/schemas/".
+func NewBuilder(refPrefix string) *Builder {
+	return &Builder{refPrefix: refPrefix, defs: Object{}}
+}
+
+// Definitions returns the schemas of every message and enum type referred to
+// so far, by full name.
+func (b *Builder) Definitions() Object {
+	return b.defs
+}
+
+// Message returns the schema of a message of md: a well-known type's JSON
+// form, or a reference to its definition.
+func (b *Builder) Message(md protoreflect.MessageDescriptor) Object {
+	if s := wellKnown(b, md); s != nil {
+		return s
+	}
+	name := string(md.FullName())
+	if _, ok := b.defs[name]; !ok {
+		// The placeholder stops recursion before the definition is done.
+		b.defs[name] = Object{}
+		b.defs[name] = b.MessageFields(md, nil)
+	}
+	return Object{"$ref": b.refPrefix + name}
+}
+
+// MessageFields returns an object schema of md's fields, leaving out those
+// named in omit, such as the ones a REST route takes from the path.
+func (b *Builder) MessageFields(md protoreflect.MessageDescriptor, omit map[protoreflect.Name]bool) Object {
+	props := Object{}
+	var required []string
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if omit[fd.Name()] {
+			continue
+		}
+		if rules := fieldRules(fd); rules != nil && rules.Required {
+			required = append(required, fd.JSONName())
+		}
+		props[fd.JSONName()] = b.Field(fd)
+	}
+	s := Object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// Field returns the schema of fd's value: a list, a map or a single value,
+// with the constraints of its field rules.
+func (b *Builder) Field(fd protoreflect.FieldDescriptor) Object {
+	var s Object
+	switch {
+	case fd.IsMap():
+		s = Object{"type": "object", "additionalProperties": b.value(fd.MapValue())}
+	case fd.IsList():
+		s = Object{"type": "array", "items": b.value(fd)}
+	default:
+		s = b.value(fd)
+	}
+	if rules := fieldRules(fd); rules != nil {
+		applyRules(s, fd, rules)
+	}
+	return s
+}
+
+// value returns the schema of one value of fd, ignoring its cardinality.
+func (b *Builder) value(fd protoreflect.FieldDescriptor) Object {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return Object{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return Object{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return Object{"type": "integer", "minimum": 0, "maximum": uint64(math.MaxUint32)}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson writes 64-bit integers as strings and reads either.
+		return Object{"type": []string{"string", "integer"}, "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return Object{"type": []string{"string", "integer"}, "format": "uint64"}
+	case protoreflect.FloatKind:
+		return Object{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return Object{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return Object{"type": "string"}
+	case protoreflect.BytesKind:
+		return Object{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.EnumKind:
+		return b.enum(fd.Enum())
+	}
+	return b.Message(fd.Message())
+}
+
+// enum returns a reference to the definition of ed, which lists its value
+// names.
+func (b *Builder) enum(ed protoreflect.EnumDescriptor) Object {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		return Object{"type": "null"}
+	}
+	name := string(ed.FullName())
+	if _, ok := b.defs[name]; !ok {
+		values := ed.Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		b.defs[name] = Object{"type": "string", "enum": names}
+	}
+	return Object{"$ref": b.refPrefix + name}
+}
+
+// wellKnown returns the schema of a well-known type's JSON form, or nil for
+// other messages.
+func wellKnown(b *Builder, md protoreflect.MessageDescriptor) Object {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return Object{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return Object{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]{1,9})?s$`}
+	case "google.protobuf.FieldMask":
+		return Object{"type": "string", "description": "Comma-separated lowerCamelCase field paths"}
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		return Object{"type": "object"}
+	case "google.protobuf.ListValue":
+		return Object{"type": "array"}
+	case "google.protobuf.Value":
+		return Object{}
+	case "google.protobuf.Any":
+		return Object{
+			"type":       "object",
+			"properties": Object{"@type": Object{"type": "string"}},
+			"required":   []string{"@type"},
+		}
+	case "google.protobuf.BoolValue", "google.protobuf.BytesValue", "google.protobuf.StringValue",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value", "google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value", "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return Object{"anyOf": []Object{b.value(md.Fields().ByName("value")), {"type": "null"}}}
+	}
+	return nil
+}
+
+// fieldRules returns the rules of fd's proto definition, or nil.
+func fieldRules(fd protoreflect.FieldDescriptor) *pb.FieldRules {
+	rules, _ := proto.GetExtension(fd.Options(), pb.E_Rules).(*pb.FieldRules)
+	return rules
+}
+
+// applyRules adds the constraints of a field's rules to its schema s, as far
+// as JSON Schema can express them. Whether the field must be present is up
+// to the message's schema.
+func applyRules(s Object, fd protoreflect.FieldDescriptor, rules *pb.FieldRules) {
+	if rules.Required {
+		switch {
+		case fd.IsList():
+			s["minItems"] = 1
+		case fd.IsMap():
+			s["minProperties"] = 1
+		case fd.Kind() == protoreflect.StringKind:
+			s["minLength"] = 1
+		}
+	}
+	if fd.IsList() || fd.IsMap() {
+		return
+	}
+	if rules.Gte != nil {
+		s["minimum"] = rules.GetGte()
+	}
+	if rules.Lte != nil {
+		s["maximum"] = rules.GetLte()
+	}
+	if rules.MaxLen > 0 && fd.Kind() == protoreflect.StringKind {
+		s["maxLength"] = rules.MaxLen
+	}
+}
+
+// GameDNAJSONSchema returns a standalone JSON Schema of a GameDNA config as
+// the REST API takes and returns it, with the latest engine defaults as its
+// fields' defaults.
+func GameDNAJSONSchema() Object {
+	b := NewBuilder("#/$defs/")
+	md := (&pb.GameDNA{}).ProtoReflect().Descriptor()
+	s := b.MessageFields(md, nil)
+	props := s["properties"].(Object)
+	for _, f := range LatestDefaults().Fields() {
+		var v interface{}
+		if f.HasDefault && json.Unmarshal([]byte(f.Default), &v) == nil {
+			props[f.JSONName].(Object)["default"] = v
+		}
+	}
+	s["$schema"] = JSONSchemaDialect
+	s["title"] = string(md.FullName())
+	if defs := b.Definitions(); len(defs) > 0 {
+		s["$defs"] = defs
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+// Package schema describes GameDNA's fields and the values the engine uses
+// for the ones a config leaves unset. proto3 can't tell an unset field from
+// one set to its zero value, so the zero value means "unset" throughout.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultsTable is one version of the engine defaults.
+type DefaultsTable struct {
+	Version int32
+	Values  *pb.GameDNA
+}
+
+// defaultsTables holds every version of the defaults, oldest first. They
+// mirror GameDNABuilder::new in entropic-dna-core. Never edit a published
+// table: append a new version, so runtimes and editors pinned to an old one
+// keep their meaning of "unset".
+var defaultsTables = []DefaultsTable{
+	{Version: 1, Values: &pb.GameDNA{
+		Genre:           "FPS",
+		Camera:          "Perspective3D",
+		Tone:            "Realistic",
+		WorldScale:      "MediumLevel",
+		TargetPlatforms: []string{"PC"},
+		PhysicsProfile:  "SemiRealistic",
+		MaxPlayers:      1,
+		Difficulty:      "Medium",
+		Monetization:    "PremiumBuy",
+		TargetAudience:  "General Audience",
+		TargetFps:       60,
+		MaxDrawDistance: 1000,
+		MaxEntities:     10000,
+		MaxNpcCount:     1000,
+		TimeScale:       1,
+	}},
+}
+
+// defaultedFields are the fields the engine has a default for. Those missing
+// from a table default to their zero value, such as false for feature flags.
+// esrb_rating has no default.
+var defaultedFields = map[protoreflect.Name]bool{
+	"genre": true, "camera": true, "tone": true, "world_scale": true,
+	"target_platforms": true, "physics_profile": true, "max_players": true,
+	"is_competitive": true, "supports_coop": true, "difficulty": true,
+	"monetization": true, "target_audience": true, "target_fps": true,
+	"max_draw_distance": true, "max_entities": true, "max_npc_count": true,
+	"time_scale": true, "weather_enabled": true, "seasons_enabled": true,
+	"day_night_cycle": true, "persistent_world": true, "npc_count": true,
+	"ai_enabled": true, "ai_difficulty_scaling": true, "has_campaign": true,
+	"has_side_quests": true, "dynamic_quests": true,
+}
+
+// LatestDefaults returns the current defaults table.
+func LatestDefaults() DefaultsTable {
+	return defaultsTables[len(defaultsTables)-1]
+}
+
+// Defaults returns the defaults table with version.
+func Defaults(version int32) (DefaultsTable, bool) {
+	for _, t := range defaultsTables {
+		if t.Version == version {
+			return t, true
+		}
+	}
+	return DefaultsTable{}, false
+}
+
+// Materialize returns a copy of dna with t's defaults filled into its unset
+// fields. dna itself, which may be shared with a cache, is not modified.
+func (t DefaultsTable) Materialize(dna *pb.GameDNA) *pb.GameDNA {
+	out := proto.Clone(dna).(*pb.GameDNA)
+	m := out.ProtoReflect()
+	t.Values.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if m.Has(fd) {
+			return true
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for i := 0; i < v.List().Len(); i++ {
+				list.Append(v.List().Get(i))
+			}
+			return true
+		}
+		m.Set(fd, v)
+		return true
+	})
+	return out
+}
+
+// Field describes one GameDNA field.
+type Field struct {
+	Name     string // proto name, as used in field masks and blame
+	JSONName string
+	Type     string // e.g. "string", "uint32", "repeated string"
+	// HasDefault reports whether the engine has a default for the field.
+	HasDefault bool
+	// Default is the JSON encoding of the default, when HasDefault.
+	Default string
+}
+
+// Fields describes every GameDNA field, in field number order, with its
+// default in t.
+func (t DefaultsTable) Fields() []Field {
+	values := t.Values.ProtoReflect()
+	fds := values.Descriptor().Fields()
+	out := make([]Field, 0, fds.Len())
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		f := Field{
+			Name:       string(fd.Name()),
+			JSONName:   fd.JSONName(),
+			Type:       typeName(fd),
+			HasDefault: defaultedFields[fd.Name()],
+		}
+		if f.HasDefault {
+			f.Default = jsonValue(fd, values.Get(fd))
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func typeName(fd protoreflect.FieldDescriptor) string {
+	scalar := func(fd protoreflect.FieldDescriptor) string {
+		if fd.Kind() == protoreflect.MessageKind {
+			return string(fd.Message().FullName())
+		}
+		return fd.Kind().String()
+	}
+	switch {
+	case fd.IsMap():
+		return fmt.Sprintf("map<%s, %s>", scalar(fd.MapKey()), scalar(fd.MapValue()))
+	case fd.IsList():
+		return "repeated " + scalar(fd)
+	}
+	return scalar(fd)
+}
+
+// jsonValue encodes a scalar or list of scalars as JSON.
+func jsonValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	var x interface{} = v.Interface()
+	if fd.IsList() {
+		items := make([]interface{}, 0, v.List().Len())
+		for i := 0; i < v.List().Len(); i++ {
+			items = append(items, v.List().Get(i).Interface())
+		}
+		x = items
+	}
+	b, _ := json.Marshal(x)
+	return string(b)
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"google.golang.org/grpc"
+)
+
+// adminMethods returns the names of the methods the admin listener serves:
+// those requiring the admin scope, and extra.
+func adminMethods(extra []string) (map[string]bool, error) {
+	desc := pb.GameDNAService_ServiceDesc
+	var known []string
+	for _, m := range desc.Methods {
+		known = append(known, m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		known = append(known, s.StreamName)
+	}
+	admin := make(map[string]bool)
+	for _, name := range known {
+		if auth.RequiredScope("/"+desc.ServiceName+"/"+name) == auth.ScopeAdmin {
+			admin[name] = true
+		}
+	}
+	for _, name := range extra {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown admin method %q", name)
+		}
+		admin[name] = true
+	}
+	return admin, nil
+}
+
+// serviceDesc returns the service's description keeping only the methods for
+// which keep returns true. Servers registered with it answer the others with
+// Unimplemented, as if they didn't exist.
+func serviceDesc(keep func(name string) bool) *grpc.ServiceDesc {
+	desc := pb.GameDNAService_ServiceDesc
+	desc.Methods = slices.DeleteFunc(slices.Clone(desc.Methods), func(m grpc.MethodDesc) bool {
+		return !keep(m.MethodName)
+	})
+	desc.Streams = slices.DeleteFunc(slices.Clone(desc.Streams), func(s grpc.StreamDesc) bool {
+		return !keep(s.StreamName)
+	})
+	return &desc
+}
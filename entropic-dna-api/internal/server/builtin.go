@@ -0,0 +1,701 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/compression"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Names of the built-in middleware.
+const (
+	MiddlewareRecovery  = "recovery"
+	MiddlewareRequestID = "request_id"
+	MiddlewareTracing   = "tracing"
+	MiddlewareLogging   = "logging"
+	MiddlewareMetrics   = "metrics"
+	MiddlewareErrors    = "errors"
+
+	MiddlewareCompression = "compression"
+	MiddlewareRateLimit   = "rate_limit"
+	MiddlewareTokens      = "tokens"
+	MiddlewareAuth        = "auth"
+	MiddlewareValidation  = "validation"
+)
+
+// DefaultRegistry returns a registry holding the built-in middleware, built
+// from opts: CrashReporter receives crash reports from recovery, Journal
+// records requests and Tracer exports spans for tracing, and Compression,
+// RateLimit, Tokens and Auth back the middleware of those names. Any may be
+// nil, leaving its middleware a no-op.
+func DefaultRegistry(logger *zap.Logger, opts Options) *Registry {
+	r := NewRegistry()
+	for _, m := range []Middleware{
+		Recovery(logger, opts.CrashReporter),
+		RequestID(),
+		Tracing(opts.Journal, opts.Tracer),
+		Logging(logger),
+		Metrics(),
+		Errors(),
+		Compression(opts.Compression),
+		RateLimit(opts.RateLimit),
+		Tokens(opts.Tokens),
+		Auth(opts.Auth),
+		Validation(),
+	} {
+		// Built-in names are distinct, so Register cannot fail here.
+		_ = r.Register(m)
+	}
+	return r
+}
+
+// Recovery turns a panic in a handler into an Internal error (HTTP 500) that
+// carries the request ID, instead of crashing the process or dropping the
+// connection. Each panic is logged with its stack and a summary of the
+// request, counted in the panics metric and passed to reporter if set.
+func Recovery(logger *zap.Logger, reporter CrashReporter) Middleware {
+	recovered := func(report CrashReport) {
+		report.Stack = string(debug.Stack())
+		report.Time = time.Now()
+		metrics.ObservePanic(report.Transport)
+		logger.Error("Recovered from panic",
+			zap.String("request_id", report.RequestID),
+			zap.String("trace_id", report.TraceID),
+			zap.String("transport", report.Transport),
+			zap.String("method", report.Method),
+			zap.String("peer", report.Peer),
+			zap.String("request", report.Request),
+			zap.String("panic", report.Panic),
+			zap.String("stack", report.Stack),
+		)
+		if reporter != nil {
+			reporter.Report(report)
+		}
+	}
+	grpcReport := func(ctx context.Context, id, traceID, method string, req interface{}, p interface{}) error {
+		report := CrashReport{
+			RequestID: id,
+			TraceID:   traceID,
+			Transport: "grpc",
+			Method:    method,
+			Request:   requestSummary(req),
+			Panic:     fmt.Sprint(p),
+		}
+		if pr, ok := peer.FromContext(ctx); ok {
+			report.Peer = pr.Addr.String()
+		}
+		recovered(report)
+		return internalError(id, traceID)
+	}
+
+	return Middleware{
+		Name: MiddlewareRecovery,
+		// Each handler settles the request and trace IDs before calling the
+		// next, so the crash report and the other middleware agree on them.
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+			ctx, id := ensureGRPCRequestID(ctx)
+			ctx, traceID := ensureGRPCTrace(ctx)
+			defer func() {
+				if p := recover(); p != nil {
+					resp, err = nil, grpcReport(ctx, id, traceID, info.FullMethod, req, p)
+				}
+			}()
+			return handler(ctx, req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+			ctx, id := ensureGRPCRequestID(ss.Context())
+			ctx, traceID := ensureGRPCTrace(ctx)
+			defer func() {
+				if p := recover(); p != nil {
+					err = grpcReport(ctx, id, traceID, info.FullMethod, nil, p)
+				}
+			}()
+			return handler(srv, &requestIDStream{ServerStream: ss, ctx: ctx})
+		},
+		HTTP: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id := ensureHTTPRequestID(r)
+				r, traceID := ensureHTTPTrace(r)
+				defer func() {
+					p := recover()
+					if p == nil {
+						return
+					}
+					if p == http.ErrAbortHandler {
+						panic(p)
+					}
+					w.Header().Set(api.RequestIDHeader, id)
+					w.Header().Set(tracing.TraceIDHeader, traceID)
+					recovered(CrashReport{
+						RequestID: id,
+						TraceID:   traceID,
+						Transport: "http",
+						Method:    r.Method + " " + r.URL.Path,
+						Peer:      r.RemoteAddr,
+						Request:   fmt.Sprintf("%d bytes, %s", r.ContentLength, r.UserAgent()),
+						Panic:     fmt.Sprint(p),
+					})
+					http.Error(w, fmt.Sprintf("internal error (request %s)", id), http.StatusInternalServerError)
+				}()
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+// internalError is the status returned for a recovered panic. The request ID
+// is in the message and, for clients that read details, a RequestInfo.
+func internalError(id, traceID string) error {
+	return withRequestInfo(status.New(codes.Internal, fmt.Sprintf("internal error (request %s)", id)), id, traceID)
+}
+
+// withRequestInfo returns st as an error carrying a RequestInfo detail with
+// the request ID and, as its serving data, the trace ID.
+func withRequestInfo(st *status.Status, id, traceID string) error {
+	info := &errdetails.RequestInfo{RequestId: id}
+	if traceID != "" {
+		info.ServingData = "trace_id=" + traceID
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// requestSummary describes a gRPC request by type and size, never content.
+func requestSummary(req interface{}) string {
+	if req == nil {
+		return "stream"
+	}
+	if msg, ok := req.(proto.Message); ok {
+		return fmt.Sprintf("%s, %d bytes", msg.ProtoReflect().Descriptor().FullName(), proto.Size(msg))
+	}
+	return fmt.Sprintf("%T", req)
+}
+
+// ensureGRPCRequestID returns ctx with a request ID in its incoming metadata,
+// generating one when the client sent none, and the ID.
+func ensureGRPCRequestID(ctx context.Context) (context.Context, string) {
+	if id := grpcRequestID(ctx); id != "" {
+		return ctx, id
+	}
+	id := uuid.New().String()
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Set(api.RequestIDMetadata, id)
+	return metadata.NewIncomingContext(ctx, md), id
+}
+
+// ensureHTTPRequestID returns r's request ID, generating and setting one when
+// the client sent none.
+func ensureHTTPRequestID(r *http.Request) string {
+	id := r.Header.Get(api.RequestIDHeader)
+	if id == "" {
+		id = uuid.New().String()
+		r.Header.Set(api.RequestIDHeader, id)
+	}
+	return id
+}
+
+// grpcRequestID returns the request ID sent with a gRPC call, or "".
+func grpcRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(api.RequestIDMetadata); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// ensureGRPCTrace returns ctx with a valid traceparent in its incoming
+// metadata, starting a new trace when the client sent none, and the trace ID.
+func ensureGRPCTrace(ctx context.Context) (context.Context, string) {
+	if id := grpcTraceID(ctx); id != "" {
+		return ctx, id
+	}
+	id := tracing.NewTraceID()
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Set(tracing.TraceparentHeader, tracing.Traceparent(id))
+	return metadata.NewIncomingContext(context.WithValue(ctx, localTraceKey{}, id), md), id
+}
+
+// ensureHTTPTrace returns r and its trace ID, starting a new trace when r
+// carries no valid traceparent.
+func ensureHTTPTrace(r *http.Request) (*http.Request, string) {
+	if id := tracing.TraceID(r.Header.Get(tracing.TraceparentHeader)); id != "" {
+		return r, id
+	}
+	id := tracing.NewTraceID()
+	r.Header.Set(tracing.TraceparentHeader, tracing.Traceparent(id))
+	return r.WithContext(context.WithValue(r.Context(), localTraceKey{}, id)), id
+}
+
+// localTraceKey marks the ID of a trace this server started, whose
+// traceparent names a parent span that was never recorded.
+type localTraceKey struct{}
+
+// startServerSpan starts a server span continuing traceparent, as the root
+// of its trace when this server started the trace itself.
+func startServerSpan(ctx context.Context, tracer *tracing.Tracer, name, traceparent string) (context.Context, *tracing.Span) {
+	ctx, span := tracer.StartRemote(ctx, name, tracing.SpanKindServer, traceparent)
+	if span != nil && ctx.Value(localTraceKey{}) == span.TraceID {
+		span.ParentSpanID = ""
+	}
+	return ctx, span
+}
+
+// grpcTraceID returns the trace ID of a gRPC call's traceparent, or "".
+func grpcTraceID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(tracing.TraceparentHeader); len(vals) > 0 {
+		return tracing.TraceID(vals[0])
+	}
+	return ""
+}
+
+// RequestID ensures every request carries a request ID, generating one when
+// the client did not send it. REST responses echo it in X-Request-Id and gRPC
+// responses in the x-request-id header.
+func RequestID() Middleware {
+	withID := func(ctx context.Context) context.Context {
+		ctx, id := ensureGRPCRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(api.RequestIDMetadata, id))
+		return ctx
+	}
+	return Middleware{
+		Name: MiddlewareRequestID,
+		Unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(withID(ctx), req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &requestIDStream{ServerStream: ss, ctx: withID(ss.Context())})
+		},
+		HTTP: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(api.RequestIDHeader, ensureHTTPRequestID(r))
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+// requestIDStream overrides a stream's context with one carrying the request ID.
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDStream) Context() context.Context {
+	return s.ctx
+}
+
+// Tracing ties each request to a W3C trace, continuing the caller's trace
+// when it sends a traceparent. The trace ID is returned in the x-trace-id
+// header (X-Trace-Id on REST), and in the trailer with the request ID. Error
+// statuses carry both IDs in a RequestInfo detail. Calls are recorded in
+// journal, if set, for LookupRequest, and as server spans by tracer, if set.
+func Tracing(journal *tracing.Journal, tracer *tracing.Tracer) Middleware {
+	begin := func(ctx context.Context, method string) (context.Context, *tracing.Span, string, string) {
+		ctx, span := startServerSpan(ctx, tracer, strings.TrimPrefix(method, "/"), incomingTraceparent(ctx))
+		if span != nil {
+			// Callees, and the journal, see the trace continue under this call's span.
+			md, _ := metadata.FromIncomingContext(ctx)
+			md = md.Copy()
+			md.Set(tracing.TraceparentHeader, span.Traceparent())
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+		ctx, traceID := ensureGRPCTrace(ctx)
+		id := grpcRequestID(ctx)
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", method)
+		if id != "" {
+			span.SetAttribute("request.id", id)
+		}
+		journal.Begin(id, traceID, method)
+		return ctx, span, id, traceID
+	}
+	trailer := func(id, traceID string) metadata.MD {
+		md := metadata.Pairs(tracing.TraceIDMetadata, traceID)
+		if id != "" {
+			md.Set(api.RequestIDMetadata, id)
+		}
+		return md
+	}
+	end := func(span *tracing.Span, id, traceID string, start time.Time, err error) error {
+		st, ok := status.FromError(err)
+		journal.End(id, st.Code().String(), st.Message(), time.Since(start))
+		span.SetAttribute("rpc.grpc.status_code", int64(st.Code()))
+		if serverFault(st.Code()) {
+			span.Finish(err)
+		} else {
+			span.Finish(nil)
+		}
+		// Errors not yet mapped to a status, when errors runs outside this
+		// middleware, are left for it.
+		if err == nil || !ok {
+			return err
+		}
+		return withRequestInfo(st, id, traceID)
+	}
+	return Middleware{
+		Name: MiddlewareTracing,
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			ctx, span, id, traceID := begin(ctx, info.FullMethod)
+			_ = grpc.SetHeader(ctx, metadata.Pairs(tracing.TraceIDMetadata, traceID))
+			resp, err := handler(ctx, req)
+			_ = grpc.SetTrailer(ctx, trailer(id, traceID))
+			return resp, end(span, id, traceID, start, err)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			ctx, span, id, traceID := begin(ss.Context(), info.FullMethod)
+			_ = ss.SetHeader(metadata.Pairs(tracing.TraceIDMetadata, traceID))
+			err := handler(srv, &requestIDStream{ServerStream: ss, ctx: ctx})
+			ss.SetTrailer(trailer(id, traceID))
+			return end(span, id, traceID, start, err)
+		},
+		HTTP: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx, span := startServerSpan(r.Context(), tracer, "HTTP "+r.Method, r.Header.Get(tracing.TraceparentHeader))
+				if span == nil {
+					r, traceID := ensureHTTPTrace(r)
+					w.Header().Set(tracing.TraceIDHeader, traceID)
+					next.ServeHTTP(w, r)
+					return
+				}
+				span.SetAttribute("http.request.method", r.Method)
+				span.SetAttribute("url.path", r.URL.Path)
+				if id := r.Header.Get(api.RequestIDHeader); id != "" {
+					span.SetAttribute("request.id", id)
+				}
+				r = r.WithContext(ctx)
+				r.Header.Set(tracing.TraceparentHeader, span.Traceparent())
+				w.Header().Set(tracing.TraceIDHeader, span.TraceID)
+
+				sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+				next.ServeHTTP(sw, r)
+				span.SetAttribute("http.response.status_code", int64(sw.status))
+				if sw.status >= 500 {
+					span.Finish(fmt.Errorf("HTTP %d", sw.status))
+				} else {
+					span.Finish(nil)
+				}
+			})
+		},
+	}
+}
+
+// incomingTraceparent returns the traceparent a gRPC caller sent, or "".
+func incomingTraceparent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get(tracing.TraceparentHeader); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// serverFault reports whether a status code means the server failed, rather
+// than the caller's request, so that its span is marked as an error.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// statusWriter records the status code of a response. It flushes through,
+// so event streams still reach the client as they are written.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Request logs are sampled: each second, the first requestLogFirst are
+// logged, then every requestLogThereafter-th.
+const (
+	requestLogFirst      = 100
+	requestLogThereafter = 100
+)
+
+// Logging logs each call with its method, status code, duration, actor,
+// config ID and request size. Calls are logged at debug level and sampled,
+// except those failing with a server fault, which are always logged as
+// warnings. REST requests are logged at debug level too, since they reach the
+// service through gRPC as well.
+func Logging(logger *zap.Logger) Middleware {
+	sampled := logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, time.Second, requestLogFirst, requestLogThereafter)
+	}))
+	log := func(ctx context.Context, msg, method string, call *callLog, req, resp interface{}, start time.Time, err error) {
+		code := status.Code(err)
+		fields := []zap.Field{
+			zap.String("request_id", grpcRequestID(ctx)),
+			zap.String("trace_id", grpcTraceID(ctx)),
+			zap.String("method", method),
+			zap.String("code", code.String()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("actor", call.actor),
+		}
+		if id := configIDOf(req, resp); id != "" {
+			fields = append(fields, zap.String("config_id", id))
+		}
+		if msg, ok := req.(proto.Message); ok {
+			fields = append(fields, zap.Int("request_bytes", proto.Size(msg)))
+		}
+		if serverFault(code) {
+			logger.Warn(msg, append(fields, zap.Error(err))...)
+			return
+		}
+		sampled.Debug(msg, fields...)
+	}
+	return Middleware{
+		Name: MiddlewareLogging,
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			call := &callLog{}
+			resp, err := handler(context.WithValue(ctx, callLogKey{}, call), req)
+			log(ctx, "gRPC request", info.FullMethod, call, req, resp, start, err)
+			return resp, err
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			call := &callLog{}
+			ctx := context.WithValue(ss.Context(), callLogKey{}, call)
+			err := handler(srv, &requestIDStream{ServerStream: ss, ctx: ctx})
+			log(ss.Context(), "gRPC stream", info.FullMethod, call, nil, nil, start, err)
+			return err
+		},
+		HTTP: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sampled.Debug("HTTP request",
+					zap.String("request_id", r.Header.Get(api.RequestIDHeader)),
+					zap.String("trace_id", tracing.TraceID(r.Header.Get(tracing.TraceparentHeader))),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("remote", r.RemoteAddr),
+					zap.Int64("request_bytes", r.ContentLength),
+				)
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+// callLog collects what the logging middleware learns of a call only once
+// inner interceptors have run, such as who made it.
+type callLog struct {
+	actor string
+}
+
+type callLogKey struct{}
+
+// noteActor records the principal authentication settled in the call's log.
+// Authentication runs inside logging, so the logging middleware can't see the
+// principal in the context it passes on.
+func noteActor(ctx context.Context) {
+	call, ok := ctx.Value(callLogKey{}).(*callLog)
+	if !ok {
+		return
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		call.actor = p.Name
+	}
+}
+
+// configIDOf returns the ID of the config a call is about: the request's
+// config_id or id, or else the ID of the config in the response, as for a
+// create. It is "" for calls about no one config.
+func configIDOf(req, resp interface{}) string {
+	for _, m := range []interface{}{req, resp} {
+		msg, ok := m.(proto.Message)
+		if !ok || msg == nil {
+			continue
+		}
+		r := msg.ProtoReflect()
+		if !r.IsValid() {
+			continue
+		}
+		if id := stringField(r, "config_id"); id != "" {
+			return id
+		}
+		if id := stringField(r, "id"); id != "" {
+			return id
+		}
+		fd := r.Descriptor().Fields().ByName("game_dna")
+		if fd != nil && fd.Message() != nil && fd.Cardinality() != protoreflect.Repeated && r.Has(fd) {
+			if id := stringField(r.Get(fd).Message(), "id"); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// stringField returns the value of m's singular string field name, or "".
+func stringField(m protoreflect.Message, name protoreflect.Name) string {
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.Cardinality() == protoreflect.Repeated {
+		return ""
+	}
+	return m.Get(fd).String()
+}
+
+// Metrics counts gRPC calls by method and status code and times them, under
+// rpcs in /debug/vars. REST requests are counted as the calls they make.
+// Placed outside errors, it sees the codes callers get.
+func Metrics() Middleware {
+	observe := func(method string, start time.Time, err error) {
+		metrics.ObserveRPC(strings.TrimPrefix(method, "/"), status.Code(err).String(), time.Since(start))
+	}
+	return Middleware{
+		Name: MiddlewareMetrics,
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			observe(info.FullMethod, start, err)
+			return resp, err
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			observe(info.FullMethod, start, err)
+			return err
+		},
+	}
+}
+
+// Errors maps storage errors to gRPC status codes; see api.UnaryErrorInterceptor.
+func Errors() Middleware {
+	return Middleware{
+		Name:   MiddlewareErrors,
+		Unary:  api.UnaryErrorInterceptor,
+		Stream: api.StreamErrorInterceptor,
+	}
+}
+
+// Compression compresses large responses; see compression.Policy. Placed
+// inside logging and metrics, they see the responses as handlers wrote them.
+// A nil policy compresses nothing.
+func Compression(policy *compression.Policy) Middleware {
+	if policy == nil {
+		return Middleware{Name: MiddlewareCompression}
+	}
+	return Middleware{
+		Name:   MiddlewareCompression,
+		Unary:  policy.UnaryInterceptor,
+		Stream: policy.StreamInterceptor,
+		HTTP:   policy.Handler,
+	}
+}
+
+// RateLimit limits each caller's calls; see ratelimit.Limiter. REST routes
+// are limited as the calls they make, and the events and metrics endpoints
+// directly.
+// Placed before tokens and auth, floods are turned away cheaply. A nil
+// limiter limits nothing.
+func RateLimit(limiter *ratelimit.Limiter) Middleware {
+	if limiter == nil {
+		return Middleware{Name: MiddlewareRateLimit}
+	}
+	return Middleware{
+		Name:    MiddlewareRateLimit,
+		Unary:   limiter.UnaryInterceptor,
+		Stream:  limiter.StreamInterceptor,
+		Events:  limiter.Handler,
+		Metrics: limiter.Handler,
+	}
+}
+
+// Tokens scopes calls carrying an API token; see tokens.Authenticator.
+// Placed before auth, calls made with an API token pass it. A nil
+// authenticator scopes nothing.
+func Tokens(authn *tokens.Authenticator) Middleware {
+	if authn == nil {
+		return Middleware{Name: MiddlewareTokens}
+	}
+	return Middleware{
+		Name:   MiddlewareTokens,
+		Unary:  authn.UnaryInterceptor,
+		Stream: authn.StreamInterceptor,
+	}
+}
+
+// Auth requires an API key or JWT granting each method's scope; see
+// auth.Authenticator. The events endpoint requires the read scope, and the
+// metrics endpoint the admin scope. A nil authenticator lets every call
+// through.
+func Auth(authn *auth.Authenticator) Middleware {
+	if authn == nil {
+		return Middleware{Name: MiddlewareAuth}
+	}
+	return Middleware{
+		Name:   MiddlewareAuth,
+		Unary:  authn.UnaryInterceptor,
+		Stream: authn.StreamInterceptor,
+		Events: func(next http.Handler) http.Handler {
+			return authn.Require(auth.ScopeRead, next)
+		},
+		Metrics: func(next http.Handler) http.Handler {
+			return authn.Require(auth.ScopeAdmin, next)
+		},
+	}
+}
+
+// Validation rejects requests that break the field rules in the protos
+// before they reach a handler; see api.UnaryValidationInterceptor. Placed
+// last, callers learn a request is malformed only once they may make it.
+// Just before the check, the principal authentication settled is noted for
+// the logging middleware.
+func Validation() Middleware {
+	return Middleware{
+		Name: MiddlewareValidation,
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			noteActor(ctx)
+			return api.UnaryValidationInterceptor(ctx, req, info, handler)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			noteActor(ss.Context())
+			return api.StreamValidationInterceptor(srv, ss, info, handler)
+		},
+	}
+}
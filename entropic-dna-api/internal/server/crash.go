@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CrashReport describes a panic recovered from a handler.
+type CrashReport struct {
+	RequestID string
+	TraceID   string
+	Transport string // grpc or http
+	Method    string // full gRPC method, or HTTP method and path
+	Peer      string
+	Request   string // short summary of the request; never its body
+	Panic     string
+	Stack     string
+	Time      time.Time
+}
+
+// CrashReporter forwards crash reports to an external service. Report must
+// not block the handler that panicked.
+type CrashReporter interface {
+	Report(report CrashReport)
+}
+
+// sentryTimeout bounds a single report upload.
+const sentryTimeout = 5 * time.Second
+
+// SentryReporter sends crash reports to Sentry's store endpoint, one event per
+// report, in the background.
+type SentryReporter struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+	logger   *zap.Logger
+	wg       sync.WaitGroup
+}
+
+// NewSentryReporter creates a reporter for a Sentry DSN of the form
+// https://<key>@<host>/<project>.
+func NewSentryReporter(dsn string, logger *zap.Logger) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	project := path.Base(u.Path)
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || project == "." || project == "/" {
+		return nil, fmt.Errorf("invalid Sentry DSN: want https://<key>@<host>/<project>")
+	}
+	prefix := strings.TrimSuffix(path.Dir(u.Path), "/")
+
+	return &SentryReporter{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=entropic-dna-api/1.0, sentry_key=%s", u.User.Username()),
+		client:   &http.Client{Timeout: sentryTimeout},
+		logger:   logger,
+	}, nil
+}
+
+// Report uploads report in the background. Failures are logged, not retried.
+func (s *SentryReporter) Report(report CrashReport) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.send(report); err != nil {
+			s.logger.Warn("Failed to send crash report to Sentry",
+				zap.String("request_id", report.RequestID), zap.Error(err))
+		}
+	}()
+}
+
+// Close waits for reports still being uploaded.
+func (s *SentryReporter) Close() {
+	s.wg.Wait()
+}
+
+func (s *SentryReporter) send(report CrashReport) error {
+	body, err := json.Marshal(sentryEvent(report))
+	if err != nil {
+		return fmt.Errorf("failed to encode crash report: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sentryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sentryEvent renders report in Sentry's event format.
+func sentryEvent(report CrashReport) map[string]interface{} {
+	return map[string]interface{}{
+		"event_id":    strings.ReplaceAll(uuid.New().String(), "-", ""),
+		"timestamp":   report.Time.UTC().Format(time.RFC3339),
+		"level":       "fatal",
+		"platform":    "go",
+		"logger":      "entropic-dna-api",
+		"transaction": report.Method,
+		"exception": map[string]interface{}{
+			"values": []map[string]string{{"type": "panic", "value": report.Panic}},
+		},
+		"tags": map[string]string{
+			"transport":  report.Transport,
+			"request_id": report.RequestID,
+			"trace_id":   report.TraceID,
+		},
+		"extra": map[string]string{
+			"peer":    report.Peer,
+			"request": report.Request,
+			"stack":   report.Stack,
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"google.golang.org/grpc"
+)
+
+// Middleware is one cross-cutting concern, such as recovery or logging,
+// applied to the gRPC server, the REST gateway or both. Hooks that don't apply
+// to a transport are left nil.
+type Middleware struct {
+	Name   string
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+	HTTP   func(http.Handler) http.Handler
+	// Events wraps only the gateway's events endpoint, which answers without
+	// calling the gRPC server. Access control sets it, as the gRPC server
+	// checks every other REST route itself.
+	Events func(http.Handler) http.Handler
+	// Metrics is Events for the gateway's metrics endpoint.
+	Metrics func(http.Handler) http.Handler
+}
+
+// Registry holds the middleware available to a server by name. The config's
+// server.middleware list picks which of them run, and in what order.
+type Registry struct {
+	byName map[string]Middleware
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]Middleware)}
+}
+
+// Register adds m to the registry. Names must be unique.
+func (r *Registry) Register(m Middleware) error {
+	if m.Name == "" {
+		return fmt.Errorf("middleware name cannot be empty")
+	}
+	if _, ok := r.byName[m.Name]; ok {
+		return fmt.Errorf("middleware %s is already registered", m.Name)
+	}
+	r.byName[m.Name] = m
+	return nil
+}
+
+// Chain resolves names, in order, into a chain. Unknown names are an error.
+func (r *Registry) Chain(names []string) (*Chain, error) {
+	chain := &Chain{}
+	for _, name := range names {
+		m, ok := r.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		chain.middleware = append(chain.middleware, m)
+	}
+	return chain, nil
+}
+
+// Chain is an ordered list of middleware. The first wraps all the others, so
+// it sees every request first and every response last.
+type Chain struct {
+	middleware []Middleware
+}
+
+// Names returns the names of the chain's middleware, in order.
+func (c *Chain) Names() []string {
+	names := make([]string, 0, len(c.middleware))
+	for _, m := range c.middleware {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// ServerOptions returns the chain's gRPC interceptors as server options.
+func (c *Chain) ServerOptions() []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	for _, m := range c.middleware {
+		if m.Unary != nil {
+			unary = append(unary, m.Unary)
+		}
+		if m.Stream != nil {
+			stream = append(stream, m.Stream)
+		}
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// Handler wraps next in the chain's HTTP middleware.
+func (c *Chain) Handler(next http.Handler) http.Handler {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		if wrap := c.middleware[i].HTTP; wrap != nil {
+			next = wrap(next)
+		}
+	}
+	return next
+}
+
+// EventsHandler wraps next, the events endpoint, in the chain's Events hooks.
+func (c *Chain) EventsHandler(next http.Handler) http.Handler {
+	return c.wrap(next, func(m Middleware) func(http.Handler) http.Handler { return m.Events })
+}
+
+// MetricsHandler wraps next, the metrics endpoint, in the chain's Metrics
+// hooks.
+func (c *Chain) MetricsHandler(next http.Handler) http.Handler {
+	return c.wrap(next, func(m Middleware) func(http.Handler) http.Handler { return m.Metrics })
+}
+
+// wrap wraps next in the hook of each middleware that sets it.
+func (c *Chain) wrap(next http.Handler, hook func(Middleware) func(http.Handler) http.Handler) http.Handler {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		if wrap := hook(c.middleware[i]); wrap != nil {
+			next = wrap(next)
+		}
+	}
+	return next
+}
+
+// Has reports whether the chain runs the middleware named name.
+func (c *Chain) Has(name string) bool {
+	for _, m := range c.middleware {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace returns a copy of the chain with the middleware named name swapped
+// for m, and those named in drop left out.
+func (c *Chain) Replace(name string, m Middleware, drop ...string) *Chain {
+	out := &Chain{}
+	for _, cur := range c.middleware {
+		switch {
+		case slices.Contains(drop, cur.Name):
+		case cur.Name == name:
+			out.middleware = append(out.middleware, m)
+		default:
+			out.middleware = append(out.middleware, cur)
+		}
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+// Package server assembles the gRPC server and REST gateway around the
+// service, with the configured middleware chain shared by both.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/compression"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configures a Server.
+type Options struct {
+	GRPCAddr string
+	HTTPAddr string
+	// Gateway configures the REST gateway. Its Middleware, EventsMiddleware
+	// and MetricsMiddleware are set from the chain.
+	Gateway api.GatewayOptions
+	// Middleware names the middleware to run, in order. Nothing else runs
+	// around the handlers, so access control runs only when named here.
+	// New fails when Tokens, Auth, RateLimit or Compression is set but its
+	// middleware is not named.
+	Middleware []string
+	// Registry holds the middleware Middleware may name. Nil uses
+	// DefaultRegistry, built from the fields below.
+	Registry *Registry
+	// CrashReporter receives recovered panics. May be nil.
+	CrashReporter CrashReporter
+	// Journal records requests for the tracing middleware. May be nil.
+	Journal *tracing.Journal
+	// Tracer records spans for the tracing middleware. May be nil.
+	Tracer *tracing.Tracer
+	// Tokens scopes calls carrying an API token, for the tokens middleware.
+	// May be nil.
+	Tokens *tokens.Authenticator
+	// Auth requires an API key or JWT granting each method's scope, for the
+	// auth middleware. May be nil.
+	Auth *auth.Authenticator
+	// RateLimit limits each caller's calls, for the rate_limit middleware.
+	// May be nil.
+	RateLimit *ratelimit.Limiter
+	// Compression compresses large responses on every listener and the REST
+	// gateway, for the compression middleware. May be nil.
+	Compression *compression.Policy
+	// Readiness are the checks behind /readyz and the service's gRPC health
+	// status.
+	Readiness []api.ReadinessCheck
+	// Reflection registers the gRPC reflection service on the listeners.
+	Reflection bool
+
+	// AdminAddr, when set, moves the methods requiring the admin scope, and
+	// those in AdminMethods, to a gRPC listener of their own. GRPCAddr and the
+	// REST gateway answer them with Unimplemented.
+	AdminAddr    string
+	AdminMethods []string
+	// AdminAuth authenticates calls on AdminAddr, taking the place of the
+	// auth middleware, while rate_limit and tokens are left out. Required
+	// with AdminAddr.
+	AdminAuth *auth.Authenticator
+}
+
+// readinessInterval is how often the service's gRPC health status is
+// refreshed from the readiness checks.
+const readinessInterval = 10 * time.Second
+
+// Server runs the gRPC server and the REST gateway in front of it.
+type Server struct {
+	grpc    *grpc.Server
+	admin   *grpc.Server // nil without an admin listener
+	health  *health.Server
+	gateway *api.RESTGateway
+	opts    Options
+	logger  *zap.Logger
+
+	stopReadiness context.CancelFunc
+}
+
+// New builds the servers for svc. Nothing listens until Start.
+func New(svc pb.GameDNAServiceServer, opts Options, logger *zap.Logger) (*Server, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = DefaultRegistry(logger, opts)
+	}
+	chain, err := registry.Chain(opts.Middleware)
+	if err != nil {
+		return nil, fmt.Errorf("invalid middleware: %w", err)
+	}
+	// Nothing runs around the handlers but the chain, so a configured
+	// policy its middleware is missing from would silently not apply.
+	for _, m := range []struct {
+		option     string
+		name       string
+		configured bool
+	}{
+		{"Compression", MiddlewareCompression, opts.Compression != nil},
+		{"RateLimit", MiddlewareRateLimit, opts.RateLimit != nil},
+		{"Tokens", MiddlewareTokens, opts.Tokens != nil},
+		{"Auth", MiddlewareAuth, opts.Auth != nil},
+	} {
+		if m.configured && !chain.Has(m.name) {
+			return nil, fmt.Errorf("%s is set but the %s middleware is not in the chain", m.option, m.name)
+		}
+	}
+	logger.Info("Middleware configured", zap.Strings("middleware", chain.Names()))
+	if !chain.Has(MiddlewareRecovery) {
+		logger.Warn("Middleware has no recovery; a panicking handler will crash the server")
+	}
+
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	// The server as a whole ("") is serving until shutdown; the service only
+	// once its readiness checks pass.
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.GameDNAService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if opts.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	var adminServer *grpc.Server
+	if opts.AdminAddr == "" {
+		pb.RegisterGameDNAServiceServer(grpcServer, svc)
+	} else {
+		if opts.AdminAuth == nil {
+			return nil, fmt.Errorf("an admin listener needs admin authentication")
+		}
+		admin, err := adminMethods(opts.AdminMethods)
+		if err != nil {
+			return nil, err
+		}
+		grpcServer.RegisterService(serviceDesc(func(name string) bool { return !admin[name] }), svc)
+
+		// Admin calls are logged, traced and recovered like any other, but
+		// only admin keys are accepted and callers are not rate limited.
+		if !chain.Has(MiddlewareAuth) {
+			return nil, fmt.Errorf("an admin listener needs the %s middleware", MiddlewareAuth)
+		}
+		adminChain := chain.Replace(MiddlewareAuth, Auth(opts.AdminAuth), MiddlewareRateLimit, MiddlewareTokens)
+		adminServer = grpc.NewServer(adminChain.ServerOptions()...)
+		adminServer.RegisterService(serviceDesc(func(name string) bool { return admin[name] }), svc)
+		healthpb.RegisterHealthServer(adminServer, healthServer)
+		if opts.Reflection {
+			reflection.Register(adminServer)
+		}
+		logger.Info("Admin methods served on the admin listener only", zap.Int("methods", len(admin)))
+	}
+
+	gwOpts := opts.Gateway
+	gwOpts.Middleware = chain.Handler
+	gwOpts.EventsMiddleware = chain.EventsHandler
+	gwOpts.MetricsMiddleware = chain.MetricsHandler
+	gwOpts.Readiness = opts.Readiness
+	gateway, err := api.NewRESTGateway(context.Background(), opts.GRPCAddr, opts.HTTPAddr, gwOpts, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create REST gateway: %w", err)
+	}
+
+	return &Server{
+		grpc:    grpcServer,
+		admin:   adminServer,
+		health:  healthServer,
+		gateway: gateway,
+		opts:    opts,
+		logger:  logger,
+	}, nil
+}
+
+// Start listens on the gRPC and admin addresses and serves the servers in
+// the background.
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", s.opts.GRPCAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.opts.GRPCAddr, err)
+	}
+	if s.admin != nil {
+		adminLis, err := net.Listen("tcp", s.opts.AdminAddr)
+		if err != nil {
+			lis.Close()
+			return fmt.Errorf("failed to listen on %s: %w", s.opts.AdminAddr, err)
+		}
+		go func() {
+			s.logger.Info("Admin gRPC server listening", zap.String("addr", s.opts.AdminAddr))
+			if err := s.admin.Serve(adminLis); err != nil {
+				s.logger.Error("Admin gRPC server error", zap.Error(err))
+			}
+		}()
+	}
+
+	go func() {
+		s.logger.Info("gRPC server listening", zap.String("addr", s.opts.GRPCAddr))
+		if err := s.grpc.Serve(lis); err != nil {
+			s.logger.Error("gRPC server error", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		s.logger.Info("REST gateway listening", zap.String("addr", s.opts.HTTPAddr))
+		if err := s.gateway.Start(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("REST gateway error", zap.Error(err))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopReadiness = cancel
+	go s.watchReadiness(ctx)
+	return nil
+}
+
+// watchReadiness sets the service's gRPC health status from the readiness
+// checks until ctx is done, logging each change.
+func (s *Server) watchReadiness(ctx context.Context) {
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	checked, ready := false, false
+	for {
+		err := api.CheckReadiness(ctx, s.opts.Readiness)
+		if ctx.Err() != nil {
+			return
+		}
+		if !checked || ready != (err == nil) {
+			if err != nil {
+				s.logger.Warn("Not ready", zap.Error(err))
+			} else {
+				s.logger.Info("Ready")
+			}
+		}
+		checked, ready = true, err == nil
+		servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			servingStatus = healthpb.HealthCheckResponse_SERVING
+		}
+		s.health.SetServingStatus(pb.GameDNAService_ServiceDesc.ServiceName, servingStatus)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports NOT_SERVING to health checks, then drains the REST gateway
+// within ctx and the gRPC servers.
+func (s *Server) Shutdown(ctx context.Context) {
+	if s.stopReadiness != nil {
+		s.stopReadiness()
+	}
+	s.health.Shutdown()
+
+	if err := s.gateway.Shutdown(ctx); err != nil {
+		s.logger.Error("Error shutting down REST gateway", zap.Error(err))
+	}
+
+	s.grpc.GracefulStop()
+	if s.admin != nil {
+		s.admin.GracefulStop()
+	}
+}
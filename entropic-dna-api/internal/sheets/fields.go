@@ -0,0 +1,229 @@
+package sheets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CustomPropertyPrefix maps a column to a single custom property, e.g.
+// "custom_properties.rarity".
+const CustomPropertyPrefix = "custom_properties."
+
+// KeyFields identify an existing config rather than set a value.
+var KeyFields = map[string]bool{
+	"id":   true,
+	"slug": true,
+}
+
+// managedFields are set by the server and cannot be imported.
+var managedFields = map[protoreflect.Name]bool{
+	"created_at":              true,
+	"last_modified":           true,
+	"create_time":             true,
+	"update_time":             true,
+	"created_by":              true,
+	"checksum":                true,
+	"is_locked":               true,
+	"published_rules_version": true,
+}
+
+var gameDNAFields = (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields()
+
+// IsField reports whether name is a GameDNA field name.
+func IsField(name string) bool {
+	return gameDNAFields.ByName(protoreflect.Name(name)) != nil
+}
+
+// CheckField reports whether path can be mapped from a sheet column.
+func CheckField(path string) error {
+	if key, ok := strings.CutPrefix(path, CustomPropertyPrefix); ok {
+		if key == "" {
+			return fmt.Errorf("field %s: missing custom property key", path)
+		}
+		return nil
+	}
+	fd := gameDNAFields.ByName(protoreflect.Name(path))
+	if fd == nil {
+		return fmt.Errorf("unknown field: %s", path)
+	}
+	if managedFields[fd.Name()] {
+		return fmt.Errorf("field %s is managed by the server and cannot be imported", path)
+	}
+	return nil
+}
+
+// SetField parses value and assigns it to the field named by path. Lists take
+// values separated by ";" or ","; custom_properties takes "key=value" pairs
+// separated by ";".
+func SetField(dna *pb.GameDNA, path, value string) error {
+	value = strings.TrimSpace(value)
+	if key, ok := strings.CutPrefix(path, CustomPropertyPrefix); ok {
+		if dna.CustomProperties == nil {
+			dna.CustomProperties = make(map[string]string)
+		}
+		dna.CustomProperties[key] = value
+		return nil
+	}
+
+	fd := gameDNAFields.ByName(protoreflect.Name(path))
+	if fd == nil {
+		return fmt.Errorf("unknown field: %s", path)
+	}
+	msg := dna.ProtoReflect()
+
+	switch {
+	case fd.IsMap():
+		m := msg.Mutable(fd).Map()
+		m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			m.Clear(k)
+			return true
+		})
+		for _, pair := range splitList(value, ";") {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("field %s: expected key=value, got %q", path, pair)
+			}
+			m.Set(protoreflect.ValueOfString(strings.TrimSpace(k)).MapKey(), protoreflect.ValueOfString(strings.TrimSpace(v)))
+		}
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		list.Truncate(0)
+		for _, item := range splitList(value, ";,") {
+			v, err := parseScalar(fd, item)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", path, err)
+			}
+			list.Append(v)
+		}
+	default:
+		v, err := parseScalar(fd, value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
+		}
+		msg.Set(fd, v)
+	}
+	return nil
+}
+
+// splitList splits s on any of seps, dropping empty items.
+func splitList(s, seps string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(seps, r) }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		switch strings.ToLower(s) {
+		case "1", "true", "yes", "y", "x":
+			return protoreflect.ValueOfBool(true), nil
+		case "", "0", "false", "no", "n":
+			return protoreflect.ValueOfBool(false), nil
+		}
+		return protoreflect.Value{}, fmt.Errorf("invalid boolean %q", s)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := parseInteger(s, 0, math.MaxUint32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := parseInteger(s, math.MinInt32, math.MaxInt32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid number %q", s)
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid number %q", s)
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field type %s", fd.Kind())
+	}
+}
+
+// parseInteger accepts whole numbers, including spreadsheet renderings such
+// as "60.0", within [min, max].
+func parseInteger(s string, min, max float64) (int64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, fmt.Errorf("invalid whole number %q", s)
+	}
+	if f < min || f > max {
+		return 0, fmt.Errorf("%s is out of range", s)
+	}
+	return int64(f), nil
+}
+
+// ExportFields lists the columns written when exporting configs: id first,
+// then every importable field in declaration order.
+func ExportFields() []string {
+	fields := []string{"id"}
+	for i := 0; i < gameDNAFields.Len(); i++ {
+		fd := gameDNAFields.Get(i)
+		if fd.Name() == "id" || managedFields[fd.Name()] {
+			continue
+		}
+		fields = append(fields, string(fd.Name()))
+	}
+	return fields
+}
+
+// FormatField renders the field named by path in the form SetField parses.
+func FormatField(dna *pb.GameDNA, path string) (string, error) {
+	if key, ok := strings.CutPrefix(path, CustomPropertyPrefix); ok {
+		return dna.GetCustomProperties()[key], nil
+	}
+
+	fd := gameDNAFields.ByName(protoreflect.Name(path))
+	if fd == nil {
+		return "", fmt.Errorf("unknown field: %s", path)
+	}
+	v := dna.ProtoReflect().Get(fd)
+
+	switch {
+	case fd.IsMap():
+		var pairs []string
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			pairs = append(pairs, k.String()+"="+mv.String())
+			return true
+		})
+		sort.Strings(pairs)
+		return strings.Join(pairs, "; "), nil
+	case fd.IsList():
+		list := v.List()
+		items := make([]string, list.Len())
+		for i := range items {
+			items[i] = formatScalar(fd, list.Get(i))
+		}
+		return strings.Join(items, "; "), nil
+	default:
+		return formatScalar(fd, v), nil
+	}
+}
+
+func formatScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
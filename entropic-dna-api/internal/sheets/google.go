@@ -0,0 +1,234 @@
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSheetsEndpoint is the Google Sheets API base URL.
+const DefaultSheetsEndpoint = "https://sheets.googleapis.com"
+
+const (
+	sheetsScope      = "https://www.googleapis.com/auth/spreadsheets"
+	defaultTokenURI  = "https://oauth2.googleapis.com/token"
+	jwtBearerGrant   = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	tokenLifetime    = time.Hour
+	tokenRefreshSkew = time.Minute
+)
+
+// serviceAccount is the subset of a Google service-account key file we use.
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// GoogleClient reads and writes Google Sheets ranges as a service account.
+// Share a spreadsheet with the account's email to give it access.
+type GoogleClient struct {
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+	endpoint string
+	http     *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewGoogleClient parses a service-account key file. An empty endpoint uses
+// DefaultSheetsEndpoint.
+func NewGoogleClient(credentialsJSON []byte, endpoint string) (*GoogleClient, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(credentialsJSON, &sa); err != nil {
+		return nil, fmt.Errorf("failed to parse service account credentials: %w", err)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("service account credentials need client_email and private_key")
+	}
+
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account private key is not an RSA key")
+	}
+
+	if sa.TokenURI == "" {
+		sa.TokenURI = defaultTokenURI
+	}
+	if endpoint == "" {
+		endpoint = DefaultSheetsEndpoint
+	}
+	return &GoogleClient{
+		email:    sa.ClientEmail,
+		key:      key,
+		tokenURI: sa.TokenURI,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Email returns the service account address spreadsheets must be shared with.
+func (c *GoogleClient) Email() string {
+	return c.email
+}
+
+// ReadRange returns the formatted cell values of rng, e.g. "Configs".
+func (c *GoogleClient) ReadRange(ctx context.Context, spreadsheetID, rng string) ([][]string, error) {
+	var resp struct {
+		Values [][]string `json:"values"`
+	}
+	path := c.valuesPath(spreadsheetID, rng) + "?valueRenderOption=FORMATTED_VALUE"
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rng, err)
+	}
+	return resp.Values, nil
+}
+
+// WriteRange replaces the contents of rng with rows, starting at its top-left cell.
+func (c *GoogleClient) WriteRange(ctx context.Context, spreadsheetID, rng string, rows [][]string) error {
+	if err := c.do(ctx, http.MethodPost, c.valuesPath(spreadsheetID, rng)+":clear", struct{}{}, nil); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", rng, err)
+	}
+
+	body := map[string]interface{}{
+		"range":          rng,
+		"majorDimension": "ROWS",
+		"values":         rows,
+	}
+	if err := c.do(ctx, http.MethodPut, c.valuesPath(spreadsheetID, rng)+"?valueInputOption=RAW", body, nil); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rng, err)
+	}
+	return nil
+}
+
+func (c *GoogleClient) valuesPath(spreadsheetID, rng string) string {
+	return "/v4/spreadsheets/" + url.PathEscape(spreadsheetID) + "/values/" + url.PathEscape(rng)
+}
+
+func (c *GoogleClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sheets API returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns a cached OAuth token, exchanging a freshly signed JWT
+// assertion when it is about to expire.
+func (c *GoogleClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenRefreshSkew).Before(c.expiry) {
+		return c.token, nil
+	}
+
+	assertion, err := c.signAssertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{"grant_type": {jwtBearerGrant}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+	c.token = tok.AccessToken
+	c.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// signAssertion builds the RS256-signed JWT used for the service-account grant.
+func (c *GoogleClient) signAssertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   c.email,
+		"scope": sheetsScope,
+		"aud":   c.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenLifetime).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,246 @@
+// Package sheets reads spreadsheet data (CSV and XLSX) and maps cells onto
+// GameDNA fields, so balance data kept in spreadsheets can be imported
+// without transcribing values by hand.
+package sheets
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported sheet formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// maxColumns is the widest sheet Excel allows, column XFD.
+const maxColumns = 16384
+
+// ErrTooManyRows is returned by Read for a sheet longer than its row limit.
+var ErrTooManyRows = errors.New("sheet has too many rows")
+
+// Read parses a sheet into rows of cells. An empty format is detected from
+// the data: XLSX files are zip archives, anything else is read as CSV.
+// Reading stops with ErrTooManyRows once more than maxRows rows are read,
+// the header included; 0 reads any number.
+func Read(format string, data []byte, maxRows int) ([][]string, error) {
+	if format == "" {
+		format = FormatCSV
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			format = FormatXLSX
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return readCSV(data, maxRows)
+	case FormatXLSX:
+		return readXLSX(data, maxRows)
+	default:
+		return nil, fmt.Errorf("unsupported sheet format %q (want csv or xlsx)", format)
+	}
+}
+
+// tooManyRows reports whether rows has passed maxRows.
+func tooManyRows(rows [][]string, maxRows int) error {
+	if maxRows > 0 && len(rows) > maxRows {
+		return fmt.Errorf("%w: the limit is %d", ErrTooManyRows, maxRows)
+	}
+	return nil
+}
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		rows = append(rows, row)
+		if err := tooManyRows(rows, maxRows); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string       `xml:"r,attr"`
+		Type   string       `xml:"t,attr"`
+		Value  string       `xml:"v"`
+		Inline xlsxRichText `xml:"is"`
+	} `xml:"c"`
+}
+
+// readXLSX reads the cell values of the first worksheet. Formulas yield their
+// cached results; styles and number formats are ignored. Rows are decoded
+// one at a time, so reading stops as soon as the sheet passes maxRows.
+func readXLSX(data []byte, maxRows int) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	var sheetNames []string
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if path.Dir(f.Name) == "xl/worksheets" && strings.HasSuffix(f.Name, ".xml") {
+			sheetNames = append(sheetNames, f.Name)
+		}
+	}
+	if len(sheetNames) == 0 {
+		return nil, fmt.Errorf("XLSX has no worksheets")
+	}
+	sort.Slice(sheetNames, func(i, j int) bool { return sheetNumber(sheetNames[i]) < sheetNumber(sheetNames[j]) })
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, fmt.Errorf("failed to read XLSX shared strings: %w", err)
+		}
+	}
+
+	rc, err := files[sheetNames[0]].Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX worksheet: %w", err)
+	}
+	defer rc.Close()
+	dec := xml.NewDecoder(io.LimitReader(rc, 64<<20))
+
+	var rows [][]string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read XLSX worksheet: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("failed to read XLSX worksheet: %w", err)
+		}
+		cells, err := xlsxCells(row, shared)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, cells)
+		if err := tooManyRows(rows, maxRows); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// xlsxCells returns the values of a worksheet row, placed by column.
+func xlsxCells(row xlsxRow, shared xlsxSharedStrings) ([]string, error) {
+	var cells []string
+	for i, c := range row.Cells {
+		col := i
+		if c.Ref != "" {
+			var err error
+			if col, err = columnIndex(c.Ref); err != nil {
+				return nil, err
+			}
+		}
+		if col >= maxColumns {
+			return nil, fmt.Errorf("cell %s: sheets are at most %d columns wide", c.Ref, maxColumns)
+		}
+		for len(cells) <= col {
+			cells = append(cells, "")
+		}
+
+		switch c.Type {
+		case "s":
+			idx, err := strconv.Atoi(c.Value)
+			if err != nil || idx < 0 || idx >= len(shared.Items) {
+				return nil, fmt.Errorf("cell %s: invalid shared string index %q", c.Ref, c.Value)
+			}
+			cells[col] = shared.Items[idx].String()
+		case "inlineStr":
+			cells[col] = c.Inline.String()
+		case "b":
+			cells[col] = strconv.FormatBool(c.Value == "1")
+		default:
+			cells[col] = c.Value
+		}
+	}
+	return cells, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v)
+}
+
+// sheetNumber extracts N from "xl/worksheets/sheetN.xml".
+func sheetNumber(name string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "sheet"), ".xml"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return n
+}
+
+// columnIndex converts a cell reference such as "C12" to a zero-based column.
+// Columns past XFD, the last Excel allows, are rejected.
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > maxColumns {
+			return 0, fmt.Errorf("invalid cell reference %q: sheets are at most %d columns wide", ref, maxColumns)
+		}
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+)
+
+// archiveFetches bounds the archived versions one read fetches at once.
+const archiveFetches = 8
+
+// ObjectStore keeps the data of archived versions, such as an S3 bucket.
+type ObjectStore interface {
+	// Put stores data under key, replacing any object there.
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the object under key; a missing one is no error.
+	Delete(ctx context.Context, key string) error
+}
+
+// SetArchive keeps the data of versions ArchiveVersions moves out in objects,
+// and reads archived versions back from it. Call it before the store is
+// shared.
+func (p *PostgresStore) SetArchive(objects ObjectStore) {
+	p.archive = objects
+}
+
+// archiveKey names the object holding a version's data. A config recreated
+// under a deleted one's ID reuses its keys, which no row points at anymore.
+func archiveKey(configID string, versionNum int64) string {
+	return fmt.Sprintf("versions/%s/%d.json", configID, versionNum)
+}
+
+// ArchiveVersions moves the data of up to limit versions that retention lets
+// go, oldest first, to the store's archive. Each version keeps its row, with
+// everything but the data, and the key of the object now holding it; reads
+// of the version fetch it back. Like PruneVersionHistory, it keeps each
+// config's newest version and the versions snapshots pin or release tags name
+// in the database. It returns how many versions it moved.
+func (p *PostgresStore) ArchiveVersions(ctx context.Context, retention HistoryRetention, limit int) (int64, error) {
+	if p.archive == nil {
+		return 0, errors.New("no archive is configured")
+	}
+	if retention.MaxVersions <= 0 && retention.CreatedBefore.IsZero() {
+		return 0, nil
+	}
+	createdBefore := sql.NullTime{Time: retention.CreatedBefore, Valid: !retention.CreatedBefore.IsZero()}
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT v.config_id, v.version_num, v.data::text
+		FROM game_dna_versions v
+		JOIN (
+			SELECT config_id, version_num,
+			       ROW_NUMBER() OVER (PARTITION BY config_id ORDER BY version_num DESC) AS age
+			FROM game_dna_versions
+		) r ON v.config_id = r.config_id AND v.version_num = r.version_num
+		WHERE v.data IS NOT NULL
+		  AND r.age > 1
+		  AND (($1::int > 0 AND r.age > $1) OR ($2::timestamptz IS NOT NULL AND v.created_at < $2))
+		  AND NOT EXISTS (
+		      SELECT 1 FROM game_dna_snapshot_entries e
+		      WHERE e.config_id = v.config_id AND e.version_num = v.version_num
+		  )
+		  AND NOT EXISTS (
+		      SELECT 1 FROM game_dna_release_tags t
+		      WHERE t.config_id = v.config_id AND t.version_num = v.version_num
+		  )
+		ORDER BY v.created_at, v.config_id, v.version_num
+		LIMIT $3
+	`, retention.MaxVersions, createdBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find versions to archive: %w", err)
+	}
+	type candidate struct {
+		configID   string
+		versionNum int64
+		data       string
+	}
+	var candidates []candidate
+	err = eachRow(rows, func() error {
+		var c candidate
+		if err := rows.Scan(&c.configID, &c.versionNum, &c.data); err != nil {
+			return fmt.Errorf("failed to scan version to archive: %w", err)
+		}
+		candidates = append(candidates, c)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The data column is archived as stored, so codecs' compression and
+	// encryption carry over to the object.
+	var archived int64
+	for _, c := range candidates {
+		key := archiveKey(c.configID, c.versionNum)
+		if err := p.archive.Put(ctx, key, []byte(c.data)); err != nil {
+			return archived, err
+		}
+		// A version whose data was rewritten meanwhile, as by
+		// AnonymizePrincipal, is left for the next sweep.
+		result, err := p.db.ExecContext(ctx, `
+			UPDATE game_dna_versions
+			SET data = NULL, archive_key = $3, archived_size = octet_length(data::text)
+			WHERE config_id = $1 AND version_num = $2 AND data = $4::jsonb
+		`, c.configID, c.versionNum, key, c.data)
+		if err != nil {
+			return archived, fmt.Errorf("failed to archive version %d of %s: %w", c.versionNum, c.configID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return archived, err
+		}
+		archived += n
+	}
+	return archived, nil
+}
+
+// versionData decodes a version's data column, or fetches the data from the
+// archive when the column was archived, and returns the data's stored size.
+func (p *PostgresStore) versionData(ctx context.Context, configID string, versionNum int64, data sql.NullString, archiveKey sql.NullString) (*pb.GameDNA, int64, error) {
+	if data.Valid || !archiveKey.Valid {
+		dna, err := p.unmarshal([]byte(data.String))
+		return dna, int64(len(data.String)), err
+	}
+	if p.archive == nil {
+		return nil, 0, fmt.Errorf("version %d of %s is archived, but no archive is configured", versionNum, configID)
+	}
+	stored, err := p.archive.Get(ctx, archiveKey.String)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read archived version %d of %s: %w", versionNum, configID, err)
+	}
+	dna, err := p.unmarshal(stored)
+	return dna, int64(len(stored)), err
+}
+
+// rehydrate fetches the data of the archived versions among versions of
+// configID, a few at a time.
+func (p *PostgresStore) rehydrate(ctx context.Context, configID string, versions []*VersionInfo) error {
+	errs := make([]error, len(versions))
+	sem := make(chan struct{}, archiveFetches)
+	var wg sync.WaitGroup
+	for i, v := range versions {
+		if v.Data != nil || v.ArchiveKey == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, v *VersionInfo) {
+			defer func() { <-sem; wg.Done() }()
+			key := sql.NullString{String: v.ArchiveKey, Valid: true}
+			v.Data, v.Size, errs[i] = p.versionData(ctx, configID, v.VersionNum, sql.NullString{}, key)
+		}(i, v)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Names of the built-in codecs.
+const (
+	CodecGzip   = "gzip"
+	CodecAESGCM = "aes-gcm"
+)
+
+// Codec transforms stored config data, for example to compress or encrypt
+// it. Decode reverses Encode.
+type Codec interface {
+	Name() string
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+// CodecChain runs codecs in order on write and in reverse on read, such as
+// gzip then aes-gcm to compress before encrypting.
+type CodecChain struct {
+	codecs []Codec
+}
+
+// NewCodecChain chains codecs, applied in the order given.
+func NewCodecChain(codecs ...Codec) *CodecChain {
+	return &CodecChain{codecs: codecs}
+}
+
+// NewCodecs builds the chain named by names, in order. key is the aes-gcm
+// key: 16, 24 or 32 bytes.
+func NewCodecs(names []string, key []byte) (*CodecChain, error) {
+	codecs := make([]Codec, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("codec %s is listed more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case CodecGzip:
+			codecs = append(codecs, GzipCodec{})
+		case CodecAESGCM:
+			c, err := NewAESGCMCodec(key)
+			if err != nil {
+				return nil, err
+			}
+			codecs = append(codecs, c)
+		default:
+			return nil, fmt.Errorf("unknown codec %q: want %s or %s", name, CodecGzip, CodecAESGCM)
+		}
+	}
+	return NewCodecChain(codecs...), nil
+}
+
+// Names returns the chain's codecs, in the order they encode.
+func (c *CodecChain) Names() []string {
+	names := make([]string, len(c.codecs))
+	for i, codec := range c.codecs {
+		names[i] = codec.Name()
+	}
+	return names
+}
+
+// Encode runs data through every codec in order.
+func (c *CodecChain) Encode(data []byte) ([]byte, error) {
+	for _, codec := range c.codecs {
+		var err error
+		if data, err = codec.Encode(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", codec.Name(), err)
+		}
+	}
+	return data, nil
+}
+
+// Decode reverses the codecs names, as recorded when data was encoded. The
+// chain must hold each of them, though not necessarily in the same order, so
+// data written before a codec was added or reordered stays readable.
+func (c *CodecChain) Decode(names []string, data []byte) ([]byte, error) {
+	for i := len(names) - 1; i >= 0; i-- {
+		codec := c.codec(names[i])
+		if codec == nil {
+			return nil, fmt.Errorf("data was encoded with codec %s, which is not configured", names[i])
+		}
+		var err error
+		if data, err = codec.Decode(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", codec.Name(), err)
+		}
+	}
+	return data, nil
+}
+
+func (c *CodecChain) codec(name string) Codec {
+	if c == nil {
+		return nil
+	}
+	for _, codec := range c.codecs {
+		if codec.Name() == name {
+			return codec
+		}
+	}
+	return nil
+}
+
+// GzipCodec compresses data with gzip.
+type GzipCodec struct{}
+
+// Name implements Codec.
+func (GzipCodec) Name() string { return CodecGzip }
+
+// Encode implements Codec.
+func (GzipCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements Codec.
+func (GzipCodec) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// AESGCMCodec encrypts data with AES-GCM. Each encoding has a random nonce,
+// stored ahead of the ciphertext.
+type AESGCMCodec struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCodec creates an AES-GCM codec; key must be 16, 24 or 32 bytes.
+func NewAESGCMCodec(key []byte) (*AESGCMCodec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s key: want 16, 24 or 32 bytes, got %d", CodecAESGCM, len(key))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMCodec{aead: aead}, nil
+}
+
+// Name implements Codec.
+func (c *AESGCMCodec) Name() string { return CodecAESGCM }
+
+// Encode implements Codec.
+func (c *AESGCMCodec) Encode(data []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decode implements Codec. It fails if data was altered or encrypted with
+// another key.
+func (c *AESGCMCodec) Decode(data []byte) ([]byte, error) {
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("ciphertext too short")
+	}
+	return c.aead.Open(nil, data[:n], data[n:], nil)
+}
+
+// encodedData is how encoded config data is kept in a JSONB column. Fields
+// that queries filter on stay readable beside the payload.
+type encodedData struct {
+	Codecs      []string `json:"$codecs"`
+	Payload     []byte   `json:"$payload"`
+	Genre       string   `json:"genre,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+}
+
+// encodeData wraps a config's JSON for a data column, encoded by c. With no
+// codecs the JSON is stored as is.
+func (c *CodecChain) encodeData(data []byte, genre, environment string) ([]byte, error) {
+	if c == nil || len(c.codecs) == 0 {
+		return data, nil
+	}
+	payload, err := c.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config data: %w", err)
+	}
+	return json.Marshal(encodedData{Codecs: c.Names(), Payload: payload, Genre: genre, Environment: environment})
+}
+
+// decodeData returns the config JSON held in a data column, whether it was
+// stored as is or encoded.
+func (c *CodecChain) decodeData(data []byte) ([]byte, error) {
+	// Plain config JSON has no "$codecs" member, so it is read unchanged.
+	var enc encodedData
+	if !bytes.Contains(data, []byte(`"$codecs"`)) || json.Unmarshal(data, &enc) != nil || len(enc.Codecs) == 0 {
+		return data, nil
+	}
+	plain, err := c.Decode(enc.Codecs, enc.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config data: %w", err)
+	}
+	return plain, nil
+}
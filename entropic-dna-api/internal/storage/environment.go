@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+)
+
+// Environments a config can serve, in the order configs are promoted
+// through them.
+const (
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
+)
+
+// Environments lists every environment in promotion order.
+var Environments = []string{EnvironmentDev, EnvironmentStaging, EnvironmentProd}
+
+// EnvironmentOf returns the environment dna serves. Configs that don't name
+// one serve dev.
+func EnvironmentOf(dna *pb.GameDNA) string {
+	if dna.GetEnvironment() == "" {
+		return EnvironmentDev
+	}
+	return dna.Environment
+}
+
+// NextEnvironment returns the environment configs in env are promoted to,
+// or "" when env is the last.
+func NextEnvironment(env string) string {
+	for i, e := range Environments {
+		if e == env && i+1 < len(Environments) {
+			return Environments[i+1]
+		}
+	}
+	return ""
+}
+
+// ValidateEnvironment checks that env names a known environment.
+func ValidateEnvironment(env string) error {
+	for _, e := range Environments {
+		if e == env {
+			return nil
+		}
+	}
+	return fmt.Errorf("environment must be one of %s: %q", strings.Join(Environments, ", "), env)
+}
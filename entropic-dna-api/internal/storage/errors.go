@@ -7,6 +7,14 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrLocked indicates the config is locked (immutable).
 	ErrLocked = errors.New("locked")
+	// ErrNotLocked indicates the config is not locked, so it cannot be unpublished.
+	ErrNotLocked = errors.New("not locked")
 	// ErrConflict indicates a constraint violation (e.g., unique name+version).
 	ErrConflict = errors.New("conflict")
+	// ErrNotModified indicates an update matched the stored checksum, so nothing was written.
+	ErrNotModified = errors.New("not modified")
+	// ErrForbidden indicates the caller may not reach the data from this deployment.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnavailable indicates the database cannot be reached, such as while it restarts.
+	ErrUnavailable = errors.New("unavailable")
 )
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// Event types of the event log.
+const (
+	EventConfigCreated = "ConfigCreated"
+	EventFieldChanged  = "FieldChanged"
+	EventPublished     = "Published"
+	EventUnpublished   = "Unpublished"
+	EventRolledBack    = "RolledBack"
+	EventConfigDeleted = "ConfigDeleted"
+)
+
+// Event is one mutation of a config, as kept in its append-only event log.
+// Each versioned event holds only what changed since the previous one, so
+// replaying a config's events in order rebuilds any of its versions.
+type Event struct {
+	Seq        int64 // position in the log, set on append
+	ConfigID   string
+	Type       string
+	VersionNum int64 // version the mutation wrote; 0 for EventConfigDeleted
+	Checksum   string
+	Actor      string
+	CreatedAt  time.Time
+	// Full marks events whose Data is the whole config, such as the first
+	// event of a config. The others hold the GameDNA fields listed in Fields,
+	// by proto name; a listed field unset in Data was cleared.
+	Full       bool
+	Fields     []string
+	Data       *pb.GameDNA
+	Validation *pb.ValidationResponse // report recorded with the version, if any
+	// Size is how many bytes Data takes in the log. Set by ListEvents only.
+	Size int64
+}
+
+// EventLog is an append-only log of config mutations. Events outlive their
+// config, so the log of a deleted config can still be read.
+type EventLog interface {
+	// AppendEvent adds event to the log and sets its Seq. A versioned event
+	// the log already has, with the same version number and creation time,
+	// is dropped and reported with false, so that appending the same version
+	// twice is harmless.
+	AppendEvent(ctx context.Context, event *Event) (bool, error)
+	// ListEvents returns the events of a config, oldest first.
+	ListEvents(ctx context.Context, configID string) ([]*Event, error)
+	// AnonymizeEvents replaces principal with pseudonym as the actor of
+	// events and as the created_by their data sets, as AnonymizePrincipal
+	// does for versions.
+	AnonymizeEvents(ctx context.Context, principal string, pseudonym string) error
+}
+
+// AppendEvent adds an event to the in-memory log.
+func (m *MemoryStore) AppendEvent(ctx context.Context, event *Event) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.VersionNum > 0 {
+		for _, e := range m.events[event.ConfigID] {
+			if e.VersionNum == event.VersionNum && e.CreatedAt.Equal(event.CreatedAt) {
+				return false, nil
+			}
+		}
+	}
+	m.eventSeq++
+	event.Seq = m.eventSeq
+	saved := copyEvent(event)
+	if data, err := models.Marshal(saved.Data); err == nil {
+		saved.Size = int64(len(data))
+	}
+	m.events[event.ConfigID] = append(m.events[event.ConfigID], saved)
+	return true, nil
+}
+
+// ListEvents returns the events of a config from the in-memory log.
+func (m *MemoryStore) ListEvents(ctx context.Context, configID string) ([]*Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]*Event, 0, len(m.events[configID]))
+	for _, e := range m.events[configID] {
+		events = append(events, copyEvent(e))
+	}
+	return events, nil
+}
+
+// AnonymizeEvents renames principal in the in-memory log.
+func (m *MemoryStore) AnonymizeEvents(ctx context.Context, principal string, pseudonym string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, events := range m.events {
+		for i, e := range events {
+			if e.Actor != principal && e.Data.GetCreatedBy() != principal {
+				continue
+			}
+			renamed := copyEvent(e)
+			if renamed.Actor == principal {
+				renamed.Actor = pseudonym
+			}
+			if renamed.Data.GetCreatedBy() == principal {
+				renamed.Data.CreatedBy = pseudonym
+			}
+			events[i] = renamed
+		}
+	}
+	return nil
+}
+
+func copyEvent(src *Event) *Event {
+	dst := *src
+	dst.Fields = append([]string(nil), src.Fields...)
+	dst.Data = models.Clone(src.Data)
+	return &dst
+}
+
+// eventColumns lists the columns scanEvent reads, in order.
+const eventColumns = `seq, config_id, type, version_num, checksum, actor, created_at, full_data, fields, data, validation, octet_length(data::text)`
+
+// AppendEvent adds an event to the game_dna_events table. Its data is
+// encoded with the store's codecs, like config data.
+func (p *PostgresStore) AppendEvent(ctx context.Context, event *Event) (bool, error) {
+	data, err := p.marshal(event.Data)
+	if err != nil {
+		return false, err
+	}
+	validation, err := marshalValidation(event.Validation)
+	if err != nil {
+		return false, err
+	}
+
+	err = p.db.QueryRowContext(ctx, `
+		INSERT INTO game_dna_events (config_id, type, version_num, checksum, actor, created_at, full_data, fields, data, validation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (config_id, version_num, created_at) WHERE version_num > 0 DO NOTHING
+		RETURNING seq
+	`, event.ConfigID, event.Type, event.VersionNum, event.Checksum, event.Actor, event.CreatedAt, event.Full,
+		pq.Array(event.Fields), string(data), validation).Scan(&event.Seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to append event: %w", err)
+	}
+	return true, nil
+}
+
+// ListEvents returns the events of a config from the game_dna_events table.
+func (p *PostgresStore) ListEvents(ctx context.Context, configID string) ([]*Event, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+eventColumns+` FROM game_dna_events WHERE config_id = $1 ORDER BY seq
+	`, configID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	var events []*Event
+	err = eachRow(rows, func() error {
+		event, err := p.scanEvent(rows)
+		if err == nil {
+			events = append(events, event)
+		}
+		return err
+	})
+	return events, err
+}
+
+// AnonymizeEvents renames principal in the game_dna_events table. Event data
+// is encoded, so every event that may set created_by is read and checked.
+func (p *PostgresStore) AnonymizeEvents(ctx context.Context, principal string, pseudonym string) error {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+eventColumns+` FROM game_dna_events
+		WHERE actor = $1 OR full_data OR 'created_by' = ANY(fields)
+	`, principal)
+	if err != nil {
+		return fmt.Errorf("failed to list events to anonymize: %w", err)
+	}
+	var events []*Event
+	err = eachRow(rows, func() error {
+		event, err := p.scanEvent(rows)
+		if err == nil && (event.Actor == principal || event.Data.GetCreatedBy() == principal) {
+			events = append(events, event)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, event := range events {
+		if event.Actor == principal {
+			event.Actor = pseudonym
+		}
+		if event.Data.GetCreatedBy() == principal {
+			event.Data.CreatedBy = pseudonym
+		}
+		data, err := p.marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE game_dna_events SET actor = $1, data = $2 WHERE seq = $3
+		`, event.Actor, string(data), event.Seq); err != nil {
+			return fmt.Errorf("failed to anonymize event %d: %w", event.Seq, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit event anonymization: %w", err)
+	}
+	return nil
+}
+
+// scanEvent reads a row of eventColumns.
+func (p *PostgresStore) scanEvent(row interface{ Scan(...interface{}) error }) (*Event, error) {
+	var e Event
+	var data []byte
+	var validation sql.NullString
+	if err := row.Scan(&e.Seq, &e.ConfigID, &e.Type, &e.VersionNum, &e.Checksum, &e.Actor, &e.CreatedAt, &e.Full,
+		pq.Array(&e.Fields), &data, &validation, &e.Size); err != nil {
+		return nil, fmt.Errorf("failed to read event: %w", err)
+	}
+	var err error
+	if e.Data, err = p.unmarshal(data); err != nil {
+		return nil, err
+	}
+	if e.Validation, err = unmarshalValidation(validation); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -3,113 +3,132 @@ package storage
 import (
     "context"
     "fmt"
+    "sort"
     "strings"
     "sync"
     "time"
 
     "github.com/google/uuid"
+    "github.com/entropic-engine/entropic-dna-api/internal/diff"
+    "github.com/entropic-engine/entropic-dna-api/internal/models"
     pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
 )
 
 // MemoryStore is an in-memory implementation of the Store interface.
 type MemoryStore struct {
     mu       sync.RWMutex
-    configs  map[string]*pb.GameDNA
-    versions map[string][]*VersionInfo
-}
+    configs   map[string]*pb.GameDNA
+    versions  map[string][]*VersionInfo
+    snapshots map[string]*Snapshot
+    mappings  map[string]*ImportMapping
+    tokens    map[string]*APIToken
+    checksumJobs map[string]*ChecksumJob
+    drafts       map[draftKey]*Draft
+    audit        []*AuditEntry // every config's, in ID order
+    waivers      map[string][]*Waiver // by config ID, oldest first
+    templates    map[string]*Template
+    unlocks      map[string]*TemporaryUnlock // by config ID
+    canaries     map[string]*Canary          // by config ID
+    releaseTags  map[string]map[string]*ReleaseTag // by config ID, then tag
+    webhooks     map[string]*Webhook
+    deliveries   map[string]*WebhookDelivery
+    idempotency  map[idempotencyKey]*IdempotencyKey
+    properties   map[string]map[string]*PropertySchema // by project, then name
+    events       map[string][]*Event                   // by config ID, oldest first
+    eventSeq     int64
+    search       *searchIndex
 
-// deepCopyGameDNA creates a deep copy of a GameDNA protobuf message
-func deepCopyGameDNA(src *pb.GameDNA) *pb.GameDNA {
-    if src == nil {
-        return nil
-    }
-    dst := &pb.GameDNA{
-        Id:                  src.Id,
-        Name:                src.Name,
-        Version:             src.Version,
-        CreatedAt:           src.CreatedAt,
-        LastModified:        src.LastModified,
-        CreatedBy:           src.CreatedBy,
-        Checksum:            src.Checksum,
-        IsLocked:            src.IsLocked,
-        Genre:               src.Genre,
-        Camera:              src.Camera,
-        Tone:                src.Tone,
-        WorldScale:          src.WorldScale,
-        TargetPlatforms:     append([]string{}, src.TargetPlatforms...),
-        PhysicsProfile:      src.PhysicsProfile,
-        MaxPlayers:          src.MaxPlayers,
-        IsCompetitive:       src.IsCompetitive,
-        SupportsCoop:        src.SupportsCoop,
-        Difficulty:          src.Difficulty,
-        Monetization:        src.Monetization,
-        TargetAudience:      src.TargetAudience,
-        EsrbRating:          src.EsrbRating,
-        TargetFps:           src.TargetFps,
-        MaxDrawDistance:     src.MaxDrawDistance,
-        MaxEntities:         src.MaxEntities,
-        MaxNpcCount:         src.MaxNpcCount,
-        TimeScale:           src.TimeScale,
-        WeatherEnabled:      src.WeatherEnabled,
-        SeasonsEnabled:      src.SeasonsEnabled,
-        DayNightCycle:       src.DayNightCycle,
-        PersistentWorld:     src.PersistentWorld,
-        NpcCount:            src.NpcCount,
-        AiEnabled:           src.AiEnabled,
-        AiDifficultyScaling: src.AiDifficultyScaling,
-        HasCampaign:         src.HasCampaign,
-        HasSideQuests:       src.HasSideQuests,
-        DynamicQuests:       src.DynamicQuests,
-        Tags:                append([]string{}, src.Tags...),
-        CustomProperties:    make(map[string]string),
-    }
-    for k, v := range src.CustomProperties {
-        dst.CustomProperties[k] = v
-    }
-    return dst
+    historyDepth int
 }
 
 // NewMemoryStore creates a new in-memory storage backend.
 func NewMemoryStore() *MemoryStore {
     return &MemoryStore{
-        configs:  make(map[string]*pb.GameDNA),
-        versions: make(map[string][]*VersionInfo),
+        configs:   make(map[string]*pb.GameDNA),
+        versions:  make(map[string][]*VersionInfo),
+        snapshots: make(map[string]*Snapshot),
+        mappings:  make(map[string]*ImportMapping),
+        tokens:    make(map[string]*APIToken),
+        checksumJobs: make(map[string]*ChecksumJob),
+        drafts:       make(map[draftKey]*Draft),
+        waivers:      make(map[string][]*Waiver),
+        templates:    make(map[string]*Template),
+        unlocks:      make(map[string]*TemporaryUnlock),
+        canaries:     make(map[string]*Canary),
+        releaseTags:  make(map[string]map[string]*ReleaseTag),
+        webhooks:     make(map[string]*Webhook),
+        deliveries:   make(map[string]*WebhookDelivery),
+        idempotency:  make(map[idempotencyKey]*IdempotencyKey),
+        properties:   make(map[string]map[string]*PropertySchema),
+        events:       make(map[string][]*Event),
+        search:       newSearchIndex(),
     }
 }
 
+// SetMaxHistoryDepth keeps at most n versions per config, plus any pinned by
+// a snapshot or named by a release tag. 0 keeps every version.
+func (m *MemoryStore) SetMaxHistoryDepth(n int) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.historyDepth = n
+}
+
+// createTime returns when dna was created, for ordering.
+func createTime(dna *pb.GameDNA) time.Time {
+    return models.TimeFromProto(dna.CreateTime)
+}
+
 // Create creates a new GameDNA configuration.
-func (m *MemoryStore) Create(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+func (m *MemoryStore) Create(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error) {
+    if err := models.NormalizeTimestamps(dna); err != nil {
+        return nil, err
+    }
+
     m.mu.Lock()
     defer m.mu.Unlock()
 
+    return m.createLocked(dna, applyWriteOptions(opts).validation), nil
+}
+
+// createLocked stores a new configuration. Callers must hold m.mu.
+func (m *MemoryStore) createLocked(dna *pb.GameDNA, report *pb.ValidationResponse) *pb.GameDNA {
     if dna.Id == "" {
         dna.Id = uuid.New().String()
     }
 
-    if dna.CreatedAt == "" {
-        dna.CreatedAt = time.Now().Format(time.RFC3339)
+    now := models.Now()
+    if dna.CreateTime == nil {
+        models.SetCreated(dna, now)
     }
-    if dna.LastModified == "" {
-        dna.LastModified = time.Now().Format(time.RFC3339)
+    if dna.UpdateTime == nil {
+        models.SetModified(dna, now)
     }
     if dna.Version == "" {
         dna.Version = "0.1.0"
     }
+    if dna.Slug == "" {
+        dna.Slug = Slugify(dna.Name)
+    }
+    dna.Slug = m.uniqueSlugLocked(dna.Slug, dna.Id)
 
     m.configs[dna.Id] = dna
+    m.search.add(dna)
 
     // Create initial version snapshot
     m.versions[dna.Id] = []*VersionInfo{
         {
-            VersionNum: 1,
-            Checksum:   dna.Checksum,
-            CreatedAt:  dna.CreatedAt,
-            CreatedBy:  dna.CreatedBy,
-            Data:       deepCopyGameDNA(dna),
+            VersionNum:    1,
+            Checksum:      dna.Checksum,
+            CreatedAt:     models.TimeFromProto(dna.CreateTime),
+            CreatedBy:     dna.CreatedBy,
+            Data:          models.Clone(dna),
+            Validation:    report,
+            ChangedFields: diff.ChangedFields(nil, dna),
+            Changes:       diff.Changes(nil, dna),
         },
     }
 
-    return dna, nil
+    return dna
 }
 
 // Read retrieves a GameDNA configuration by ID.
@@ -119,40 +138,192 @@ func (m *MemoryStore) Read(ctx context.Context, id string) (*pb.GameDNA, error)
 
     dna, exists := m.configs[id]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", id)
+        return nil, fmt.Errorf("config %s: %w", id, ErrNotFound)
     }
 
     return dna, nil
 }
 
+// ReadBySlug retrieves a GameDNA configuration by its slug.
+func (m *MemoryStore) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    for _, dna := range m.configs {
+        if dna.Slug == slug {
+            return dna, nil
+        }
+    }
+
+    return nil, fmt.Errorf("config %s: %w", slug, ErrNotFound)
+}
+
+// uniqueSlugLocked returns slug, or slug with a numeric suffix if another
+// config already uses it. Callers must hold m.mu.
+func (m *MemoryStore) uniqueSlugLocked(slug string, id string) string {
+    taken := func(candidate string) bool {
+        for _, dna := range m.configs {
+            if dna.Id != id && dna.Slug == candidate {
+                return true
+            }
+        }
+        return false
+    }
+
+    candidate := slug
+    for n := 2; taken(candidate); n++ {
+        candidate = nextSlug(slug, n)
+    }
+    return candidate
+}
+
+// checkSlugLocked rejects an update that would take another config's slug.
+// Callers must hold m.mu.
+func (m *MemoryStore) checkSlugLocked(dna *pb.GameDNA) error {
+    for _, other := range m.configs {
+        if other.Id != dna.Id && other.Slug == dna.Slug {
+            return fmt.Errorf("slug %s is already used by config %s: %w", dna.Slug, other.Id, ErrConflict)
+        }
+    }
+    return nil
+}
+
 // Update updates an existing GameDNA configuration.
-func (m *MemoryStore) Update(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+func (m *MemoryStore) Update(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error) {
     m.mu.Lock()
     defer m.mu.Unlock()
 
-    existing, exists := m.configs[dna.Id]
+    if err := m.checkUpdatableLocked(dna.Id); err != nil {
+        return nil, err
+    }
+    if dna.Slug == "" {
+        dna.Slug = m.configs[dna.Id].Slug
+    }
+    if err := m.checkSlugLocked(dna); err != nil {
+        return nil, err
+    }
+    if existing := m.configs[dna.Id]; unchanged(existing, dna) {
+        return existing, ErrNotModified
+    }
+
+    return m.updateLocked(dna, applyWriteOptions(opts).validation), nil
+}
+
+// checkUpdatableLocked reports whether the config exists and is unlocked. Callers must hold m.mu.
+func (m *MemoryStore) checkUpdatableLocked(id string) error {
+    existing, exists := m.configs[id]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", dna.Id)
+        return fmt.Errorf("config %s: %w", id, ErrNotFound)
     }
 
     if existing.IsLocked {
-        return nil, fmt.Errorf("config is locked: %s", dna.Id)
+        return fmt.Errorf("config %s: %w", id, ErrLocked)
     }
 
-    dna.LastModified = time.Now().Format(time.RFC3339)
+    return nil
+}
+
+// updateLocked replaces a configuration and appends a version snapshot. Callers must hold m.mu.
+func (m *MemoryStore) updateLocked(dna *pb.GameDNA, report *pb.ValidationResponse) *pb.GameDNA {
+    now := models.Now()
+    // The creation time is server-managed; whatever the caller sent is ignored.
+    models.SetCreated(dna, models.TimeFromProto(m.configs[dna.Id].CreateTime))
+    models.SetModified(dna, now)
     m.configs[dna.Id] = dna
+    m.search.add(dna)
 
     // Create new version snapshot
-    nextVersion := int64(len(m.versions[dna.Id]) + 1)
+    fields, changes := changedSince(m.versions[dna.Id], dna)
     m.versions[dna.Id] = append(m.versions[dna.Id], &VersionInfo{
-        VersionNum: nextVersion,
-        Checksum:   dna.Checksum,
-        CreatedAt:  dna.LastModified,
-        CreatedBy:  dna.CreatedBy,
-        Data:       deepCopyGameDNA(dna),
+        VersionNum:    nextVersionNum(m.versions[dna.Id]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     dna.CreatedBy,
+        Data:          models.Clone(dna),
+        Validation:    report,
+        ChangedFields: fields,
+        Changes:       changes,
     })
+    m.pruneHistoryLocked(dna.Id)
 
-    return dna, nil
+    return dna
+}
+
+// SaveSet applies a group of creates and updates atomically. All updates are
+// checked before anything is written so a failure leaves the store untouched.
+func (m *MemoryStore) SaveSet(ctx context.Context, set SaveSet) (*SaveSet, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    seen := make(map[string]bool)
+    for _, dna := range set.Updates {
+        if err := m.checkUpdatableLocked(dna.Id); err != nil {
+            return nil, err
+        }
+        if dna.Slug == "" {
+            dna.Slug = m.configs[dna.Id].Slug
+        }
+        if err := m.checkSlugLocked(dna); err != nil {
+            return nil, err
+        }
+        seen[dna.Id] = true
+    }
+    for _, dna := range set.Creates {
+        if err := models.NormalizeTimestamps(dna); err != nil {
+            return nil, err
+        }
+        if dna.Id == "" {
+            continue
+        }
+        if _, exists := m.configs[dna.Id]; exists || seen[dna.Id] {
+            return nil, fmt.Errorf("config %s already exists: %w", dna.Id, ErrConflict)
+        }
+        seen[dna.Id] = true
+    }
+
+    result := &SaveSet{}
+    for i, dna := range set.Creates {
+        result.Creates = append(result.Creates, m.createLocked(dna, reportAt(set.CreateReports, i)))
+    }
+    for i, dna := range set.Updates {
+        if existing := m.configs[dna.Id]; unchanged(existing, dna) {
+            result.Unchanged = append(result.Unchanged, existing)
+            continue
+        }
+        result.Updates = append(result.Updates, m.updateLocked(dna, reportAt(set.UpdateReports, i)))
+    }
+
+    return result, nil
+}
+
+// ImportConfig stores a config exported from another deployment under its own
+// ID, with versions as its history. Without versions the config is recorded
+// as version 1.
+func (m *MemoryStore) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*VersionInfo) (*pb.GameDNA, error) {
+    if err := models.NormalizeTimestamps(dna); err != nil {
+        return nil, err
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.configs[dna.Id]; exists {
+        return nil, fmt.Errorf("config %s already exists: %w", dna.Id, ErrConflict)
+    }
+
+    stored := m.createLocked(dna, nil)
+    if len(versions) > 0 {
+        history := make([]*VersionInfo, len(versions))
+        for i, v := range versions {
+            copied := *v
+            copied.Data = models.Clone(v.Data)
+            history[i] = &copied
+        }
+        m.versions[stored.Id] = history
+        m.pruneHistoryLocked(stored.Id)
+    }
+
+    return stored, nil
 }
 
 // Delete removes a GameDNA configuration.
@@ -161,11 +332,20 @@ func (m *MemoryStore) Delete(ctx context.Context, id string) error {
     defer m.mu.Unlock()
 
     if _, exists := m.configs[id]; !exists {
-        return fmt.Errorf("config not found: %s", id)
+        return fmt.Errorf("config %s: %w", id, ErrNotFound)
     }
 
     delete(m.configs, id)
     delete(m.versions, id)
+    m.search.remove(id)
+    delete(m.unlocks, id)
+    delete(m.canaries, id)
+    delete(m.releaseTags, id)
+    for key := range m.drafts {
+        if key.configID == id {
+            delete(m.drafts, key)
+        }
+    }
 
     return nil
 }
@@ -175,14 +355,91 @@ func (m *MemoryStore) List(ctx context.Context, filters ListFilters, pagination
     m.mu.RLock()
     defer m.mu.RUnlock()
 
+    result := m.matchLocked(filters)
+
+    total := int32(len(result))
+
+    // Apply pagination
+    if pagination.PageSize == 0 {
+        pagination.PageSize = DefaultPageSize
+    }
+    if pagination.Page == 0 {
+        pagination.Page = 1
+    }
+
+    start := (pagination.Page - 1) * pagination.PageSize
+    end := start + pagination.PageSize
+
+    if start >= int32(len(result)) {
+        return []*pb.GameDNA{}, total, nil
+    }
+    if end > int32(len(result)) {
+        end = int32(len(result))
+    }
+
+    return result[start:end], total, nil
+}
+
+// ListAfter returns the configs matching filters after the cursor.
+func (m *MemoryStore) ListAfter(ctx context.Context, filters ListFilters, after *ListCursor, limit int32) ([]*pb.GameDNA, *ListCursor, error) {
+    if limit <= 0 {
+        limit = DefaultPageSize
+    }
+
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := m.matchLocked(filters)
+    start := 0
+    if after != nil {
+        start = sort.Search(len(result), func(i int) bool {
+            ci := createTime(result[i])
+            return ci.Before(after.CreatedAt) || (ci.Equal(after.CreatedAt) && result[i].Id > after.ID)
+        })
+    }
+    end := start + int(limit)
+    if end >= len(result) {
+        return result[start:], nil, nil
+    }
+    last := result[end-1]
+    return result[start:end], &ListCursor{CreatedAt: createTime(last), ID: last.Id}, nil
+}
+
+// Each calls fn for every config matching filters, in List order. The matches
+// are collected under the lock, so fn may write to the store.
+func (m *MemoryStore) Each(ctx context.Context, filters ListFilters, fn func(*pb.GameDNA) error) error {
+    m.mu.RLock()
+    result := m.matchLocked(filters)
+    m.mu.RUnlock()
+
+    for _, dna := range result {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        if err := fn(dna); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// matchLocked returns the configs matching filters, newest first. Callers must hold m.mu.
+func (m *MemoryStore) matchLocked(filters ListFilters) []*pb.GameDNA {
     var result []*pb.GameDNA
+    nameFilter := strings.ToLower(filters.NameFilter)
 
     for _, dna := range m.configs {
         // Apply filters
         if filters.Genre != "" && dna.Genre != filters.Genre {
             continue
         }
-        if filters.NameFilter != "" && !strings.Contains(strings.ToLower(dna.Name), strings.ToLower(filters.NameFilter)) {
+        if filters.PublishedOnly && !dna.IsLocked {
+            continue
+        }
+        if filters.Environment != "" && EnvironmentOf(dna) != filters.Environment {
+            continue
+        }
+        if nameFilter != "" && !strings.Contains(strings.ToLower(dna.Name), nameFilter) {
             continue
         }
         if len(filters.Tags) > 0 {
@@ -208,26 +465,75 @@ func (m *MemoryStore) List(ctx context.Context, filters ListFilters, pagination
         result = append(result, dna)
     }
 
-    total := int32(len(result))
+    sortNewestFirst(result)
+    return result
+}
 
-    // Apply pagination
+// sortNewestFirst orders configs like PostgresStore lists them, so
+// pagination is stable across calls.
+func sortNewestFirst(result []*pb.GameDNA) {
+    sort.Slice(result, func(i, j int) bool {
+        if ci, cj := createTime(result[i]), createTime(result[j]); !ci.Equal(cj) {
+            return ci.After(cj)
+        }
+        return result[i].Id < result[j].Id
+    })
+}
+
+// Search returns a page of the configs matching query, newest first, and
+// how many match in all. Words are looked up in an inverted index.
+func (m *MemoryStore) Search(ctx context.Context, query SearchQuery, pagination Pagination) ([]*pb.GameDNA, int32, error) {
+    if err := query.Validate(); err != nil {
+        return nil, 0, err
+    }
+
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    var result []*pb.GameDNA
+    candidates, indexed := m.search.lookup(SearchTokens(query.Text))
+    matches := func(dna *pb.GameDNA) bool {
+        if len(query.Predicates) == 0 {
+            return true
+        }
+        values := numericValues(dna)
+        for _, p := range query.Predicates {
+            if !p.matches(values[p.Field]) {
+                return false
+            }
+        }
+        return true
+    }
+    if indexed {
+        for id := range candidates {
+            if dna := m.configs[id]; matches(dna) {
+                result = append(result, dna)
+            }
+        }
+    } else {
+        for _, dna := range m.configs {
+            if matches(dna) {
+                result = append(result, dna)
+            }
+        }
+    }
+    sortNewestFirst(result)
+
+    total := int32(len(result))
     if pagination.PageSize == 0 {
-        pagination.PageSize = 10
+        pagination.PageSize = DefaultPageSize
     }
     if pagination.Page == 0 {
         pagination.Page = 1
     }
-
     start := (pagination.Page - 1) * pagination.PageSize
-    end := start + pagination.PageSize
-
-    if start >= int32(len(result)) {
+    if start >= total {
         return []*pb.GameDNA{}, total, nil
     }
-    if end > int32(len(result)) {
-        end = int32(len(result))
+    end := start + pagination.PageSize
+    if end > total {
+        end = total
     }
-
     return result[start:end], total, nil
 }
 
@@ -238,155 +544,1918 @@ func (m *MemoryStore) GetVersionHistory(ctx context.Context, configID string) ([
 
     versions, exists := m.versions[configID]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", configID)
+        return nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
     }
 
     return versions, nil
 }
 
+// ListVersions returns a page of configID's versions, newest first.
+func (m *MemoryStore) ListVersions(ctx context.Context, configID string, page VersionPage) ([]*VersionInfo, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    versions, exists := m.versions[configID]
+    if !exists {
+        return nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+
+    var result []*VersionInfo
+    for i := len(versions) - 1; i >= 0; i-- {
+        if page.Limit > 0 && len(result) == page.Limit {
+            break
+        }
+        if page.Before > 0 && versions[i].VersionNum >= page.Before {
+            continue
+        }
+        v := sizedVersion(versions[i])
+        if page.WithoutData {
+            v.Data = nil
+        }
+        result = append(result, v)
+    }
+    return result, nil
+}
+
+// GetVersion returns one version of a config, with its data.
+func (m *MemoryStore) GetVersion(ctx context.Context, configID string, versionNum int64) (*VersionInfo, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    v, err := m.findVersionLocked(configID, versionNum)
+    if err != nil {
+        return nil, err
+    }
+    return sizedVersion(v), nil
+}
+
+// sizedVersion returns a copy of v with its Size set to that of its data as
+// PostgresStore would store it, before any codecs.
+func sizedVersion(v *VersionInfo) *VersionInfo {
+    sized := *v
+    if data, err := models.Marshal(v.Data); err == nil {
+        sized.Size = int64(len(data))
+    }
+    return &sized
+}
+
+// PruneVersionHistory deletes the versions of every config that retention
+// lets go.
+func (m *MemoryStore) PruneVersionHistory(ctx context.Context, retention HistoryRetention) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var pruned int64
+    for configID, versions := range m.versions {
+        pinned := m.pinnedVersionsLocked(configID)
+        var kept []*VersionInfo
+        for i, v := range versions {
+            age := len(versions) - 1 - i
+            expired := (retention.MaxVersions > 0 && age >= retention.MaxVersions) ||
+                (!retention.CreatedBefore.IsZero() && v.CreatedAt.Before(retention.CreatedBefore))
+            if age == 0 || !expired || pinned[v.VersionNum] {
+                kept = append(kept, v)
+            }
+        }
+        pruned += int64(len(versions) - len(kept))
+        m.versions[configID] = kept
+    }
+    return pruned, nil
+}
+
 // RollbackToVersion rolls back a configuration to a previous version.
 func (m *MemoryStore) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
     m.mu.Lock()
     defer m.mu.Unlock()
 
+    targetVersion, err := m.findVersionLocked(configID, versionNum)
+    if err != nil {
+        return nil, err
+    }
+
+    return m.rollbackLocked(configID, targetVersion, actor), nil
+}
+
+// findVersionLocked looks up a version snapshot. Callers must hold m.mu.
+func (m *MemoryStore) findVersionLocked(configID string, versionNum int64) (*VersionInfo, error) {
     versions, exists := m.versions[configID]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", configID)
+        return nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
     }
 
-    var targetVersion *VersionInfo
     for _, v := range versions {
         if v.VersionNum == versionNum {
-            targetVersion = v
-            break
+            return v, nil
         }
     }
 
-    if targetVersion == nil {
-        return nil, fmt.Errorf("version not found: %d", versionNum)
+    return nil, fmt.Errorf("version %d: %w", versionNum, ErrNotFound)
+}
+
+// rollbackLocked makes targetVersion current and records the rollback as a new
+// version carrying the target's validation report. Callers must hold m.mu.
+func (m *MemoryStore) rollbackLocked(configID string, targetVersion *VersionInfo, actor string) *pb.GameDNA {
+    versions := m.versions[configID]
+
+    // Rolling back to the current content is a no-op
+    if current := m.configs[configID]; unchanged(current, targetVersion.Data) {
+        return current
     }
 
     // Deep copy the version data and create new current config
-    rolledBack := deepCopyGameDNA(targetVersion.Data)
-    rolledBack.LastModified = time.Now().Format(time.RFC3339)
+    rolledBack := models.Clone(targetVersion.Data)
+    // Keep the current slug if the old one predates slugs or now belongs to another config
+    if rolledBack.Slug == "" || m.checkSlugLocked(rolledBack) != nil {
+        rolledBack.Slug = m.configs[configID].Slug
+    }
+    now := models.Now()
+    models.SetCreated(rolledBack, models.TimeFromProto(m.configs[configID].CreateTime))
+    models.SetModified(rolledBack, now)
     if actor != "" {
         rolledBack.CreatedBy = actor
     }
 
     m.configs[configID] = rolledBack
+    m.search.add(rolledBack)
 
     // Add rollback as a new version
-    nextVersion := int64(len(versions) + 1)
+    fields, changes := changedSince(versions, rolledBack)
     m.versions[configID] = append(versions, &VersionInfo{
-        VersionNum: nextVersion,
-        Checksum:   rolledBack.Checksum,
-        CreatedAt:  rolledBack.LastModified,
-        CreatedBy:  actor,
-        Data:       deepCopyGameDNA(rolledBack),
+        VersionNum:    nextVersionNum(versions),
+        Checksum:      rolledBack.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     actor,
+        Data:          models.Clone(rolledBack),
+        Validation:    targetVersion.Validation,
+        ChangedFields: fields,
+        Changes:       changes,
     })
+    m.pruneHistoryLocked(configID)
 
-    return rolledBack, nil
+    return rolledBack
 }
 
-// PublishVersion locks a configuration and creates an immutable snapshot.
-func (m *MemoryStore) PublishVersion(ctx context.Context, configID string, actor string) (*pb.GameDNA, error) {
+// PublishVersion locks a configuration and creates an immutable snapshot
+// carrying the validation report the publish was gated on.
+func (m *MemoryStore) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
     m.mu.Lock()
     defer m.mu.Unlock()
 
     dna, exists := m.configs[configID]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", configID)
+        return nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
     }
 
     if dna.IsLocked {
-        return nil, fmt.Errorf("config is already locked: %s", configID)
+        return nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+    }
+    if dna.Checksum != expectedChecksum {
+        return nil, fmt.Errorf("config %s changed since it was validated: %w", configID, ErrConflict)
     }
 
     dna.IsLocked = true
-    dna.LastModified = time.Now().Format(time.RFC3339)
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    now := models.Now()
+    models.SetModified(dna, now)
     if actor != "" {
         dna.CreatedBy = actor
     }
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
 
     m.configs[configID] = dna
+    delete(m.unlocks, configID)
+
+    fields, changes := changedSince(m.versions[configID], dna)
+    m.versions[configID] = append(m.versions[configID], &VersionInfo{
+        VersionNum:    nextVersionNum(m.versions[configID]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     dna.CreatedBy,
+        Data:          models.Clone(dna),
+        Validation:    report,
+        ChangedFields: fields,
+        Changes:       changes,
+    })
+    m.pruneHistoryLocked(configID)
 
     return dna, nil
 }
 
-// Clone creates a new configuration based on an existing one.
-func (m *MemoryStore) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+// UnpublishVersion unlocks a published configuration, records a version and
+// adds the unpublish to the audit log.
+func (m *MemoryStore) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *AuditEntry, error) {
     m.mu.Lock()
     defer m.mu.Unlock()
 
-    original, exists := m.configs[id]
+    return m.unlockLocked(configID, actor, AuditActionUnpublish, reason)
+}
+
+// UnlockTemporarily unpublishes a configuration until expiresAt.
+func (m *MemoryStore) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    dna, entry, err := m.unlockLocked(configID, actor, AuditActionTemporaryUnlock, reason)
+    if err != nil {
+        return nil, nil, err
+    }
+    m.unlocks[configID] = &TemporaryUnlock{
+        ConfigID:  configID,
+        Actor:     actor,
+        Reason:    reason,
+        CreatedAt: entry.CreatedAt,
+        ExpiresAt: expiresAt,
+    }
+
+    return dna, entry, nil
+}
+
+// unlockLocked unlocks a published configuration, records a version and adds
+// an action entry to the audit log. m.mu must be held.
+func (m *MemoryStore) unlockLocked(configID string, actor string, action string, reason string) (*pb.GameDNA, *AuditEntry, error) {
+    dna, exists := m.configs[configID]
     if !exists {
-        return nil, fmt.Errorf("config not found: %s", id)
-    }
-
-    // Create a deep copy
-    cloned := &pb.GameDNA{
-        Id:                  uuid.New().String(),
-        Name:                newName,
-        Version:             original.Version,
-        CreatedAt:           time.Now().Format(time.RFC3339),
-        LastModified:        time.Now().Format(time.RFC3339),
-        CreatedBy:           actor,
-        Checksum:            "",
-        IsLocked:            false,
-        Genre:               original.Genre,
-        Camera:              original.Camera,
-        Tone:                original.Tone,
-        WorldScale:          original.WorldScale,
-        TargetPlatforms:     append([]string{}, original.TargetPlatforms...),
-        PhysicsProfile:      original.PhysicsProfile,
-        MaxPlayers:          original.MaxPlayers,
-        IsCompetitive:       original.IsCompetitive,
-        SupportsCoop:        original.SupportsCoop,
-        Difficulty:          original.Difficulty,
-        Monetization:        original.Monetization,
-        TargetAudience:      original.TargetAudience,
-        EsrbRating:          original.EsrbRating,
-        TargetFps:           original.TargetFps,
-        MaxDrawDistance:     original.MaxDrawDistance,
-        MaxEntities:         original.MaxEntities,
-        MaxNpcCount:         original.MaxNpcCount,
-        TimeScale:           original.TimeScale,
-        WeatherEnabled:      original.WeatherEnabled,
-        SeasonsEnabled:      original.SeasonsEnabled,
-        DayNightCycle:       original.DayNightCycle,
-        PersistentWorld:     original.PersistentWorld,
-        NpcCount:            original.NpcCount,
-        AiEnabled:           original.AiEnabled,
-        AiDifficultyScaling: original.AiDifficultyScaling,
-        HasCampaign:         original.HasCampaign,
-        HasSideQuests:       original.HasSideQuests,
-        DynamicQuests:       original.DynamicQuests,
-        Tags:                append([]string{}, original.Tags...),
-        CustomProperties:    make(map[string]string),
-    }
-
-    // Deep copy custom properties
-    for k, v := range original.CustomProperties {
-        cloned.CustomProperties[k] = v
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
     }
 
-    m.configs[cloned.Id] = cloned
+    if !dna.IsLocked {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotLocked)
+    }
 
-    // Create initial version snapshot
-    m.versions[cloned.Id] = []*VersionInfo{
-        {
-            VersionNum: 1,
-            Checksum:   cloned.Checksum,
-            CreatedAt:  cloned.CreatedAt,
-            CreatedBy:  actor,
-            Data:       cloned,
-        },
+    // The checksum covers content only, so it stays as published
+    dna.IsLocked = false
+    dna.PublishedRulesVersion = ""
+    now := models.Now()
+    models.SetModified(dna, now)
+
+    fields, changes := changedSince(m.versions[configID], dna)
+    version := &VersionInfo{
+        VersionNum:    nextVersionNum(m.versions[configID]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     actor,
+        Data:          models.Clone(dna),
+        ChangedFields: fields,
+        Changes:       changes,
     }
+    m.versions[configID] = append(m.versions[configID], version)
+    m.pruneHistoryLocked(configID)
 
-    return cloned, nil
+    entry := &AuditEntry{
+        ID:         int64(len(m.audit)) + 1,
+        ConfigID:   configID,
+        Action:     action,
+        Actor:      actor,
+        Reason:     reason,
+        VersionNum: version.VersionNum,
+        CreatedAt:  now,
+    }
+    m.audit = append(m.audit, entry)
+
+    copied := *entry
+    return dna, &copied, nil
 }
 
-// Close closes the storage backend (no-op for memory storage).
-func (m *MemoryStore) Close() {
-    // No-op for in-memory storage
+// RelockVersion publishes a temporarily unlocked configuration again and
+// closes its window.
+func (m *MemoryStore) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, open := m.unlocks[configID]; !open {
+        return nil, nil, fmt.Errorf("temporary unlock of config %s: %w", configID, ErrNotFound)
+    }
+    dna, exists := m.configs[configID]
+    if !exists {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+    if dna.IsLocked {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+    }
+
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    now := models.Now()
+    models.SetModified(dna, now)
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+    delete(m.unlocks, configID)
+
+    fields, changes := changedSince(m.versions[configID], dna)
+    version := &VersionInfo{
+        VersionNum:    nextVersionNum(m.versions[configID]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     actor,
+        Data:          models.Clone(dna),
+        Validation:    report,
+        ChangedFields: fields,
+        Changes:       changes,
+    }
+    m.versions[configID] = append(m.versions[configID], version)
+    m.pruneHistoryLocked(configID)
+
+    entry := &AuditEntry{
+        ID:         int64(len(m.audit)) + 1,
+        ConfigID:   configID,
+        Action:     AuditActionRelock,
+        Actor:      actor,
+        Reason:     reason,
+        VersionNum: version.VersionNum,
+        CreatedAt:  now,
+    }
+    m.audit = append(m.audit, entry)
+
+    copied := *entry
+    return models.Clone(dna), &copied, nil
+}
+
+// ListTemporaryUnlocks returns every open window, soonest to close first.
+func (m *MemoryStore) ListTemporaryUnlocks(ctx context.Context) ([]*TemporaryUnlock, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*TemporaryUnlock, 0, len(m.unlocks))
+    for _, unlock := range m.unlocks {
+        copied := *unlock
+        result = append(result, &copied)
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if !result[i].ExpiresAt.Equal(result[j].ExpiresAt) {
+            return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+        }
+        return result[i].ConfigID < result[j].ConfigID
+    })
+
+    return result, nil
+}
+
+// StartCanary saves a config's running canary, replacing a finished one.
+func (m *MemoryStore) StartCanary(ctx context.Context, canary *Canary) (*Canary, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    dna, exists := m.configs[canary.ConfigID]
+    if !exists {
+        return nil, fmt.Errorf("config %s: %w", canary.ConfigID, ErrNotFound)
+    }
+    if !dna.IsLocked {
+        return nil, fmt.Errorf("config %s: %w", canary.ConfigID, ErrNotLocked)
+    }
+    if current, ok := m.canaries[canary.ConfigID]; ok && current.State == CanaryRunning {
+        return nil, fmt.Errorf("canary of config %s is already running: %w", canary.ConfigID, ErrConflict)
+    }
+
+    stored := copyCanary(canary)
+    m.canaries[canary.ConfigID] = stored
+    return copyCanary(stored), nil
+}
+
+// GetCanary returns a config's latest canary.
+func (m *MemoryStore) GetCanary(ctx context.Context, configID string) (*Canary, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    canary, ok := m.canaries[configID]
+    if !ok {
+        return nil, fmt.Errorf("canary of config %s: %w", configID, ErrNotFound)
+    }
+    return copyCanary(canary), nil
+}
+
+// ListRunningCanaries returns the running canaries, soonest to end first.
+func (m *MemoryStore) ListRunningCanaries(ctx context.Context) ([]*Canary, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*Canary, 0, len(m.canaries))
+    for _, canary := range m.canaries {
+        if canary.State == CanaryRunning {
+            result = append(result, copyCanary(canary))
+        }
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if !result[i].EndsAt.Equal(result[j].EndsAt) {
+            return result[i].EndsAt.Before(result[j].EndsAt)
+        }
+        return result[i].ConfigID < result[j].ConfigID
+    })
+
+    return result, nil
+}
+
+// RecordCanaryFeedback adds game server reports to a running canary.
+func (m *MemoryStore) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*Canary, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    canary, err := m.runningCanaryLocked(configID)
+    if err != nil {
+        return nil, err
+    }
+
+    updated := copyCanary(canary)
+    switch checksum {
+    case canary.Checksum:
+        updated.CandidateFeedback.Sessions += sessions
+        updated.CandidateFeedback.Errors += errors
+    case canary.BaseChecksum:
+        updated.StableFeedback.Sessions += sessions
+        updated.StableFeedback.Errors += errors
+    default:
+        return nil, fmt.Errorf("checksum %s is not served by the canary of config %s: %w", checksum, configID, ErrConflict)
+    }
+    m.canaries[configID] = updated
+
+    return copyCanary(updated), nil
+}
+
+// PromoteCanary replaces a published config's content with its canary's
+// candidate.
+func (m *MemoryStore) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *Canary, *AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    canary, err := m.runningCanaryLocked(configID)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    existing, exists := m.configs[configID]
+    if !exists {
+        return nil, nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+    if !existing.IsLocked {
+        return nil, nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotLocked)
+    }
+    if existing.Checksum != canary.BaseChecksum {
+        return nil, nil, nil, fmt.Errorf("config %s changed since its canary started: %w", configID, ErrConflict)
+    }
+
+    dna := models.Clone(canary.Candidate)
+    dna.Id = configID
+    dna.Slug = existing.Slug
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+    now := models.Now()
+    models.SetCreated(dna, models.TimeFromProto(existing.CreateTime))
+    models.SetModified(dna, now)
+    m.configs[configID] = dna
+    m.search.add(dna)
+
+    fields, changes := changedSince(m.versions[configID], dna)
+    version := &VersionInfo{
+        VersionNum:    nextVersionNum(m.versions[configID]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     actor,
+        Data:          models.Clone(dna),
+        Validation:    report,
+        ChangedFields: fields,
+        Changes:       changes,
+    }
+    m.versions[configID] = append(m.versions[configID], version)
+    m.pruneHistoryLocked(configID)
+
+    finished := copyCanary(canary)
+    finished.State = CanaryPromoted
+    finished.FinishedBy = actor
+    finished.FinishedAt = now
+    finished.Reason = reason
+    m.canaries[configID] = finished
+
+    entry := &AuditEntry{
+        ID:         int64(len(m.audit)) + 1,
+        ConfigID:   configID,
+        Action:     AuditActionCanaryPromote,
+        Actor:      actor,
+        Reason:     reason,
+        VersionNum: version.VersionNum,
+        CreatedAt:  now,
+    }
+    m.audit = append(m.audit, entry)
+
+    copied := *entry
+    return models.Clone(dna), copyCanary(finished), &copied, nil
+}
+
+// PromoteVersion replaces a configuration's content with promoted content,
+// publishes it and adds the promotion to the audit log.
+func (m *MemoryStore) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    existing, exists := m.configs[configID]
+    if !exists {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+
+    dna := models.Clone(content)
+    dna.Id = configID
+    dna.Slug = existing.Slug
+    dna.Environment = existing.Environment
+    dna.PromotedFrom = existing.PromotedFrom
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+    now := models.Now()
+    models.SetCreated(dna, models.TimeFromProto(existing.CreateTime))
+    models.SetModified(dna, now)
+    m.configs[configID] = dna
+    m.search.add(dna)
+    delete(m.unlocks, configID)
+
+    fields, changes := changedSince(m.versions[configID], dna)
+    version := &VersionInfo{
+        VersionNum:    nextVersionNum(m.versions[configID]),
+        Checksum:      dna.Checksum,
+        CreatedAt:     now,
+        CreatedBy:     actor,
+        Data:          models.Clone(dna),
+        Validation:    report,
+        ChangedFields: fields,
+        Changes:       changes,
+    }
+    m.versions[configID] = append(m.versions[configID], version)
+    m.pruneHistoryLocked(configID)
+
+    entry := &AuditEntry{
+        ID:         int64(len(m.audit)) + 1,
+        ConfigID:   configID,
+        Action:     AuditActionPromote,
+        Actor:      actor,
+        Reason:     reason,
+        VersionNum: version.VersionNum,
+        CreatedAt:  now,
+        ApprovedBy: approvedBy,
+    }
+    m.audit = append(m.audit, entry)
+
+    copied := *entry
+    return models.Clone(dna), &copied, nil
+}
+
+// RollbackCanary finishes a config's running canary without changing the
+// config.
+func (m *MemoryStore) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*Canary, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    canary, err := m.runningCanaryLocked(configID)
+    if err != nil {
+        return nil, err
+    }
+
+    finished := copyCanary(canary)
+    finished.State = CanaryRolledBack
+    finished.FinishedBy = actor
+    finished.FinishedAt = models.Now()
+    finished.Reason = reason
+    m.canaries[configID] = finished
+
+    return copyCanary(finished), nil
+}
+
+// TagVersion points a release tag at a version.
+func (m *MemoryStore) TagVersion(ctx context.Context, tag *ReleaseTag, move bool) (*ReleaseTag, int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, err := m.findVersionLocked(tag.ConfigID, tag.VersionNum); err != nil {
+        return nil, 0, err
+    }
+    var previous int64
+    if existing, ok := m.releaseTags[tag.ConfigID][tag.Tag]; ok {
+        if existing.VersionNum == tag.VersionNum {
+            unchanged := *existing
+            return &unchanged, existing.VersionNum, nil
+        }
+        if !move {
+            return nil, 0, fmt.Errorf("release tag %s of config %s names version %d: %w", tag.Tag, tag.ConfigID, existing.VersionNum, ErrConflict)
+        }
+        previous = existing.VersionNum
+    }
+
+    saved := *tag
+    saved.CreatedAt = models.Now()
+    if m.releaseTags[tag.ConfigID] == nil {
+        m.releaseTags[tag.ConfigID] = make(map[string]*ReleaseTag)
+    }
+    stored := saved
+    m.releaseTags[tag.ConfigID][tag.Tag] = &stored
+    return &saved, previous, nil
+}
+
+// GetTaggedVersion returns the version a release tag names.
+func (m *MemoryStore) GetTaggedVersion(ctx context.Context, configID string, tag string) (*VersionInfo, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    t, ok := m.releaseTags[configID][tag]
+    if !ok {
+        return nil, fmt.Errorf("release tag %s of config %s: %w", tag, configID, ErrNotFound)
+    }
+    return m.findVersionLocked(configID, t.VersionNum)
+}
+
+// ListReleaseTags returns a config's release tags, newest version first.
+func (m *MemoryStore) ListReleaseTags(ctx context.Context, configID string) ([]*ReleaseTag, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    tags := make([]*ReleaseTag, 0, len(m.releaseTags[configID]))
+    for _, t := range m.releaseTags[configID] {
+        copied := *t
+        tags = append(tags, &copied)
+    }
+    sortReleaseTags(tags)
+    return tags, nil
+}
+
+// sortReleaseTags orders tags newest version first, then by tag.
+func sortReleaseTags(tags []*ReleaseTag) {
+    sort.Slice(tags, func(i, j int) bool {
+        if tags[i].VersionNum != tags[j].VersionNum {
+            return tags[i].VersionNum > tags[j].VersionNum
+        }
+        return tags[i].Tag < tags[j].Tag
+    })
+}
+
+// runningCanaryLocked returns a config's running canary. m.mu must be held.
+func (m *MemoryStore) runningCanaryLocked(configID string) (*Canary, error) {
+    canary, ok := m.canaries[configID]
+    if !ok || canary.State != CanaryRunning {
+        return nil, fmt.Errorf("running canary of config %s: %w", configID, ErrNotFound)
+    }
+    return canary, nil
+}
+
+func copyCanary(src *Canary) *Canary {
+    dst := *src
+    dst.Candidate = models.Clone(src.Candidate)
+    return &dst
+}
+
+// ListAuditEntries returns the audit log of a configuration.
+func (m *MemoryStore) ListAuditEntries(ctx context.Context, configID string) ([]*AuditEntry, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    var entries []*AuditEntry
+    for _, entry := range m.audit {
+        if entry.ConfigID == configID {
+            copied := *entry
+            entries = append(entries, &copied)
+        }
+    }
+    return entries, nil
+}
+
+// Clone creates a new configuration based on an existing one.
+func (m *MemoryStore) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    original, exists := m.configs[id]
+    if !exists {
+        return nil, fmt.Errorf("config %s: %w", id, ErrNotFound)
+    }
+
+    cloned := models.Clone(original)
+    models.ClearServerFields(cloned)
+    cloned.Id = uuid.New().String()
+    cloned.Name = newName
+    cloned.CreatedBy = actor
+    cloned.Slug = m.uniqueSlugLocked(Slugify(newName), cloned.Id)
+    now := models.Now()
+    models.SetCreated(cloned, now)
+    models.SetModified(cloned, now)
+
+    m.configs[cloned.Id] = cloned
+    m.search.add(cloned)
+
+    // Create initial version snapshot
+    m.versions[cloned.Id] = []*VersionInfo{
+        {
+            VersionNum:    1,
+            Checksum:      cloned.Checksum,
+            CreatedAt:     now,
+            CreatedBy:     actor,
+            Data:          cloned,
+            ChangedFields: diff.ChangedFields(nil, cloned),
+            Changes:       diff.Changes(nil, cloned),
+        },
+    }
+
+    return cloned, nil
+}
+
+// pruneHistoryLocked drops versions older than the newest m.historyDepth,
+// keeping any a snapshot pins. Callers must hold m.mu.
+func (m *MemoryStore) pruneHistoryLocked(configID string) {
+    versions := m.versions[configID]
+    if m.historyDepth <= 0 || len(versions) <= m.historyDepth {
+        return
+    }
+
+    pinned := m.pinnedVersionsLocked(configID)
+    oldest := versions[len(versions)-1].VersionNum - int64(m.historyDepth) + 1
+    var kept []*VersionInfo
+    for _, v := range versions {
+        if v.VersionNum >= oldest || pinned[v.VersionNum] {
+            kept = append(kept, v)
+        }
+    }
+    m.versions[configID] = kept
+}
+
+// pinnedVersionsLocked returns the versions of configID a snapshot pins or a
+// release tag names. Callers must hold m.mu.
+func (m *MemoryStore) pinnedVersionsLocked(configID string) map[int64]bool {
+    pinned := make(map[int64]bool)
+    for _, snapshot := range m.snapshots {
+        for _, entry := range snapshot.Entries {
+            if entry.ConfigID == configID {
+                pinned[entry.VersionNum] = true
+            }
+        }
+    }
+    for _, tag := range m.releaseTags[configID] {
+        pinned[tag.VersionNum] = true
+    }
+    return pinned
+}
+
+// CreateSnapshot records the given config versions under a release name.
+// Entries with a zero VersionNum capture the config's latest version.
+func (m *MemoryStore) CreateSnapshot(ctx context.Context, snapshot *Snapshot) (*Snapshot, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.snapshots[snapshot.Name]; exists {
+        return nil, fmt.Errorf("snapshot %s already exists: %w", snapshot.Name, ErrConflict)
+    }
+
+    stored := &Snapshot{
+        Name:        snapshot.Name,
+        Description: snapshot.Description,
+        CreatedAt:   time.Now().Format(time.RFC3339),
+        CreatedBy:   snapshot.CreatedBy,
+    }
+    for _, entry := range snapshot.Entries {
+        versionNum := entry.VersionNum
+        if versionNum == 0 {
+            versions, exists := m.versions[entry.ConfigID]
+            if !exists {
+                return nil, fmt.Errorf("config %s: %w", entry.ConfigID, ErrNotFound)
+            }
+            versionNum = versions[len(versions)-1].VersionNum
+        }
+        if _, err := m.findVersionLocked(entry.ConfigID, versionNum); err != nil {
+            return nil, err
+        }
+        stored.Entries = append(stored.Entries, SnapshotEntry{ConfigID: entry.ConfigID, VersionNum: versionNum})
+    }
+
+    m.snapshots[stored.Name] = stored
+
+    return stored, nil
+}
+
+// GetSnapshot retrieves a snapshot by name. When withData is set, each entry
+// carries the config as it was at the captured version.
+func (m *MemoryStore) GetSnapshot(ctx context.Context, name string, withData bool) (*Snapshot, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    snapshot, exists := m.snapshots[name]
+    if !exists {
+        return nil, fmt.Errorf("snapshot %s: %w", name, ErrNotFound)
+    }
+
+    result := *snapshot
+    result.Entries = make([]SnapshotEntry, len(snapshot.Entries))
+    for i, entry := range snapshot.Entries {
+        if withData {
+            v, err := m.findVersionLocked(entry.ConfigID, entry.VersionNum)
+            if err != nil {
+                return nil, err
+            }
+            entry.Data = models.Clone(v.Data)
+        }
+        result.Entries[i] = entry
+    }
+
+    return &result, nil
+}
+
+// ListSnapshots returns all snapshots without config data, newest first.
+func (m *MemoryStore) ListSnapshots(ctx context.Context) ([]*Snapshot, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*Snapshot, 0, len(m.snapshots))
+    for _, snapshot := range m.snapshots {
+        result = append(result, snapshot)
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if result[i].CreatedAt != result[j].CreatedAt {
+            return result[i].CreatedAt > result[j].CreatedAt
+        }
+        return result[i].Name < result[j].Name
+    })
+
+    return result, nil
+}
+
+// RestoreSnapshot rolls every config in the snapshot back to its captured
+// version. All configs are checked first so a failure restores nothing.
+func (m *MemoryStore) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    snapshot, exists := m.snapshots[name]
+    if !exists {
+        return nil, fmt.Errorf("snapshot %s: %w", name, ErrNotFound)
+    }
+
+    targets := make([]*VersionInfo, len(snapshot.Entries))
+    for i, entry := range snapshot.Entries {
+        if err := m.checkUpdatableLocked(entry.ConfigID); err != nil {
+            return nil, err
+        }
+        v, err := m.findVersionLocked(entry.ConfigID, entry.VersionNum)
+        if err != nil {
+            return nil, err
+        }
+        targets[i] = v
+    }
+
+    var restored []*pb.GameDNA
+    for i, entry := range snapshot.Entries {
+        restored = append(restored, m.rollbackLocked(entry.ConfigID, targets[i], actor))
+    }
+
+    return restored, nil
+}
+
+// TransferOwnership reassigns all configs and webhooks created by from to to.
+// Each moved config gets a version carrying the previous version's validation
+// report, and an audit entry.
+func (m *MemoryStore) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*OwnershipTransfer, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var owned []*pb.GameDNA
+    for _, dna := range m.configs {
+        if dna.CreatedBy == from {
+            owned = append(owned, dna)
+        }
+    }
+    sort.Slice(owned, func(i, j int) bool {
+        if ci, cj := createTime(owned[i]), createTime(owned[j]); !ci.Equal(cj) {
+            return ci.Before(cj)
+        }
+        return owned[i].Id < owned[j].Id
+    })
+    var webhooks []*Webhook
+    for _, webhook := range m.webhooks {
+        if webhook.CreatedBy == from {
+            webhooks = append(webhooks, webhook)
+        }
+    }
+    sortWebhooks(webhooks)
+
+    if dryRun {
+        preview := &OwnershipTransfer{}
+        for _, dna := range owned {
+            preview.Configs = append(preview.Configs, models.Clone(dna))
+        }
+        for _, webhook := range webhooks {
+            preview.Webhooks = append(preview.Webhooks, copyWebhook(webhook))
+        }
+        return preview, nil
+    }
+
+    now := models.Now()
+    moved := &OwnershipTransfer{}
+    for _, dna := range owned {
+        dna.CreatedBy = to
+        models.SetModified(dna, now)
+
+        versions := m.versions[dna.Id]
+        var report *pb.ValidationResponse
+        if len(versions) > 0 {
+            report = versions[len(versions)-1].Validation
+        }
+        fields, changes := changedSince(versions, dna)
+        version := &VersionInfo{
+            VersionNum:    nextVersionNum(versions),
+            Checksum:      dna.Checksum,
+            CreatedAt:     now,
+            CreatedBy:     to,
+            Data:          models.Clone(dna),
+            Validation:    report,
+            ChangedFields: fields,
+            Changes:       changes,
+        }
+        m.versions[dna.Id] = append(versions, version)
+        m.pruneHistoryLocked(dna.Id)
+        m.audit = append(m.audit, &AuditEntry{
+            ID:         int64(len(m.audit)) + 1,
+            ConfigID:   dna.Id,
+            Action:     AuditActionTransfer,
+            Actor:      actor,
+            Reason:     fmt.Sprintf("from %s to %s", from, to),
+            VersionNum: version.VersionNum,
+            CreatedAt:  now,
+        })
+        moved.Configs = append(moved.Configs, models.Clone(dna))
+    }
+    for _, webhook := range webhooks {
+        webhook.CreatedBy = to
+        moved.Webhooks = append(moved.Webhooks, copyWebhook(webhook))
+    }
+
+    return moved, nil
+}
+
+// SaveImportMapping creates or replaces a project's import mapping.
+func (m *MemoryStore) SaveImportMapping(ctx context.Context, mapping *ImportMapping) (*ImportMapping, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    saved := copyImportMapping(mapping)
+    saved.UpdatedAt = time.Now().Format(time.RFC3339)
+    m.mappings[saved.Project] = saved
+
+    return copyImportMapping(saved), nil
+}
+
+// GetImportMapping retrieves a project's import mapping.
+func (m *MemoryStore) GetImportMapping(ctx context.Context, project string) (*ImportMapping, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    mapping, exists := m.mappings[project]
+    if !exists {
+        return nil, fmt.Errorf("import mapping for project %s: %w", project, ErrNotFound)
+    }
+
+    return copyImportMapping(mapping), nil
+}
+
+func copyImportMapping(src *ImportMapping) *ImportMapping {
+    dst := *src
+    dst.Columns = make(map[string]string, len(src.Columns))
+    for k, v := range src.Columns {
+        dst.Columns[k] = v
+    }
+    return &dst
+}
+
+// SaveAPIToken creates or replaces an API token.
+func (m *MemoryStore) SaveAPIToken(ctx context.Context, token *APIToken) (*APIToken, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    for _, other := range m.tokens {
+        if other.ID != token.ID && other.SecretHash == token.SecretHash {
+            return nil, fmt.Errorf("API token secret already in use: %w", ErrConflict)
+        }
+    }
+    saved := copyAPIToken(token)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    m.tokens[saved.ID] = saved
+
+    return copyAPIToken(saved), nil
+}
+
+// GetAPIToken retrieves an API token by ID.
+func (m *MemoryStore) GetAPIToken(ctx context.Context, id string) (*APIToken, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    token, exists := m.tokens[id]
+    if !exists {
+        return nil, fmt.Errorf("API token %s: %w", id, ErrNotFound)
+    }
+
+    return copyAPIToken(token), nil
+}
+
+// FindAPIToken retrieves the API token with a current or previous secret hash.
+func (m *MemoryStore) FindAPIToken(ctx context.Context, hash string) (*APIToken, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    for _, token := range m.tokens {
+        if token.SecretHash == hash || (token.PreviousHash != "" && token.PreviousHash == hash) {
+            return copyAPIToken(token), nil
+        }
+    }
+
+    return nil, fmt.Errorf("API token: %w", ErrNotFound)
+}
+
+// ListAPITokens returns all API tokens, oldest first.
+func (m *MemoryStore) ListAPITokens(ctx context.Context) ([]*APIToken, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*APIToken, 0, len(m.tokens))
+    for _, token := range m.tokens {
+        result = append(result, copyAPIToken(token))
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+            return result[i].CreatedAt.Before(result[j].CreatedAt)
+        }
+        return result[i].ID < result[j].ID
+    })
+
+    return result, nil
+}
+
+func copyAPIToken(src *APIToken) *APIToken {
+    dst := *src
+    dst.Slugs = append([]string(nil), src.Slugs...)
+    return &dst
+}
+
+// SaveWaiver creates or replaces a validation waiver.
+func (m *MemoryStore) SaveWaiver(ctx context.Context, waiver *Waiver) (*Waiver, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.configs[waiver.ConfigID]; !exists {
+        return nil, fmt.Errorf("config %s: %w", waiver.ConfigID, ErrNotFound)
+    }
+
+    saved := *waiver
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    waivers := m.waivers[waiver.ConfigID]
+    for i, w := range waivers {
+        if w.ID == waiver.ID {
+            waivers[i] = &saved
+            copied := saved
+            return &copied, nil
+        }
+    }
+    m.waivers[waiver.ConfigID] = append(waivers, &saved)
+
+    copied := saved
+    return &copied, nil
+}
+
+// ListWaivers returns every validation waiver of a configuration.
+func (m *MemoryStore) ListWaivers(ctx context.Context, configID string) ([]*Waiver, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    waivers := make([]*Waiver, 0, len(m.waivers[configID]))
+    for _, w := range m.waivers[configID] {
+        copied := *w
+        waivers = append(waivers, &copied)
+    }
+    return waivers, nil
+}
+
+// ConfigIDsAfter returns up to limit config IDs greater than after, in ID order.
+func (m *MemoryStore) ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    var ids []string
+    for id := range m.configs {
+        if id > after {
+            ids = append(ids, id)
+        }
+    }
+    sort.Strings(ids)
+    if len(ids) > limit {
+        ids = ids[:limit]
+    }
+    return ids, nil
+}
+
+// draftKey identifies a principal's draft of a config.
+type draftKey struct {
+    configID string
+    owner    string
+}
+
+// SaveDraft creates or replaces a draft.
+func (m *MemoryStore) SaveDraft(ctx context.Context, draft *Draft) (*Draft, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.configs[draft.ConfigID]; !exists {
+        return nil, fmt.Errorf("config %s: %w", draft.ConfigID, ErrNotFound)
+    }
+
+    key := draftKey{draft.ConfigID, draft.Owner}
+    saved := copyDraft(draft)
+    saved.UpdatedAt = models.Now()
+    if existing, ok := m.drafts[key]; ok {
+        saved.CreatedAt = existing.CreatedAt
+    } else {
+        saved.CreatedAt = saved.UpdatedAt
+    }
+    m.drafts[key] = saved
+
+    return copyDraft(saved), nil
+}
+
+// GetDraft retrieves owner's draft of a config.
+func (m *MemoryStore) GetDraft(ctx context.Context, configID, owner string) (*Draft, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    draft, exists := m.drafts[draftKey{configID, owner}]
+    if !exists {
+        return nil, fmt.Errorf("draft of config %s by %s: %w", configID, owner, ErrNotFound)
+    }
+
+    return copyDraft(draft), nil
+}
+
+// DeleteDraft deletes owner's draft of a config.
+func (m *MemoryStore) DeleteDraft(ctx context.Context, configID, owner string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    key := draftKey{configID, owner}
+    if _, exists := m.drafts[key]; !exists {
+        return fmt.Errorf("draft of config %s by %s: %w", configID, owner, ErrNotFound)
+    }
+    delete(m.drafts, key)
+
+    return nil
+}
+
+func copyDraft(src *Draft) *Draft {
+    dst := *src
+    dst.Data = models.Clone(src.Data)
+    return &dst
+}
+
+// SaveTemplate creates or replaces a template.
+func (m *MemoryStore) SaveTemplate(ctx context.Context, template *Template) (*Template, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    saved := copyTemplate(template)
+    saved.UpdatedAt = models.Now()
+    if existing, ok := m.templates[saved.Name]; ok {
+        saved.CreatedAt = existing.CreatedAt
+        saved.CreatedBy = existing.CreatedBy
+    } else {
+        saved.CreatedAt = saved.UpdatedAt
+    }
+    m.templates[saved.Name] = saved
+
+    return copyTemplate(saved), nil
+}
+
+// GetTemplate retrieves a template by name.
+func (m *MemoryStore) GetTemplate(ctx context.Context, name string) (*Template, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    template, exists := m.templates[name]
+    if !exists {
+        return nil, fmt.Errorf("template %s: %w", name, ErrNotFound)
+    }
+
+    return copyTemplate(template), nil
+}
+
+// ListTemplates returns every template, by name.
+func (m *MemoryStore) ListTemplates(ctx context.Context) ([]*Template, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*Template, 0, len(m.templates))
+    for _, template := range m.templates {
+        result = append(result, copyTemplate(template))
+    }
+    sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+    return result, nil
+}
+
+// DeleteTemplate deletes a template.
+func (m *MemoryStore) DeleteTemplate(ctx context.Context, name string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.templates[name]; !exists {
+        return fmt.Errorf("template %s: %w", name, ErrNotFound)
+    }
+    delete(m.templates, name)
+
+    return nil
+}
+
+func copyTemplate(src *Template) *Template {
+    dst := *src
+    dst.Data = models.Clone(src.Data)
+    return &dst
+}
+
+// SavePropertySchema creates or replaces a property schema.
+func (m *MemoryStore) SavePropertySchema(ctx context.Context, schema *PropertySchema) (*PropertySchema, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    saved := copyPropertySchema(schema)
+    saved.UpdatedAt = models.Now()
+    if m.properties[saved.Project] == nil {
+        m.properties[saved.Project] = make(map[string]*PropertySchema)
+    }
+    m.properties[saved.Project][saved.Name] = saved
+
+    return copyPropertySchema(saved), nil
+}
+
+// GetPropertySchema retrieves a property schema by project and name.
+func (m *MemoryStore) GetPropertySchema(ctx context.Context, project, name string) (*PropertySchema, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    schema, exists := m.properties[project][name]
+    if !exists {
+        return nil, fmt.Errorf("property %s of project %s: %w", name, project, ErrNotFound)
+    }
+
+    return copyPropertySchema(schema), nil
+}
+
+// ListPropertySchemas returns the property schemas of a project, by name.
+func (m *MemoryStore) ListPropertySchemas(ctx context.Context, project string) ([]*PropertySchema, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*PropertySchema, 0, len(m.properties[project]))
+    for _, schema := range m.properties[project] {
+        result = append(result, copyPropertySchema(schema))
+    }
+    sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+    return result, nil
+}
+
+// DeletePropertySchema deletes a property schema.
+func (m *MemoryStore) DeletePropertySchema(ctx context.Context, project, name string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.properties[project][name]; !exists {
+        return fmt.Errorf("property %s of project %s: %w", name, project, ErrNotFound)
+    }
+    delete(m.properties[project], name)
+    if len(m.properties[project]) == 0 {
+        delete(m.properties, project)
+    }
+
+    return nil
+}
+
+func copyPropertySchema(src *PropertySchema) *PropertySchema {
+    dst := *src
+    dst.AllowedValues = append([]string(nil), src.AllowedValues...)
+    return &dst
+}
+
+// SaveWebhook creates or replaces a webhook.
+func (m *MemoryStore) SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    saved := copyWebhook(webhook)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    m.webhooks[saved.ID] = saved
+
+    return copyWebhook(saved), nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (m *MemoryStore) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    webhook, exists := m.webhooks[id]
+    if !exists {
+        return nil, fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+    }
+
+    return copyWebhook(webhook), nil
+}
+
+// ListWebhooks returns every webhook, oldest first.
+func (m *MemoryStore) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    result := make([]*Webhook, 0, len(m.webhooks))
+    for _, webhook := range m.webhooks {
+        result = append(result, copyWebhook(webhook))
+    }
+    sortWebhooks(result)
+
+    return result, nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries.
+func (m *MemoryStore) DeleteWebhook(ctx context.Context, id string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.webhooks[id]; !exists {
+        return fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+    }
+    delete(m.webhooks, id)
+    for deliveryID, delivery := range m.deliveries {
+        if delivery.WebhookID == id {
+            delete(m.deliveries, deliveryID)
+        }
+    }
+
+    return nil
+}
+
+// SaveWebhookDelivery creates or replaces a webhook delivery.
+func (m *MemoryStore) SaveWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if _, exists := m.webhooks[delivery.WebhookID]; !exists {
+        return nil, fmt.Errorf("webhook %s: %w", delivery.WebhookID, ErrNotFound)
+    }
+    saved := copyWebhookDelivery(delivery)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    if saved.UpdatedAt.IsZero() {
+        saved.UpdatedAt = saved.CreatedAt
+    }
+    m.deliveries[saved.ID] = saved
+
+    return copyWebhookDelivery(saved), nil
+}
+
+// ClaimWebhookDeliveries returns the pending deliveries due at now and
+// pushes their next attempt back by lease.
+func (m *MemoryStore) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var due []*WebhookDelivery
+    for _, delivery := range m.deliveries {
+        if delivery.State == WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+            due = append(due, delivery)
+        }
+    }
+    sort.Slice(due, func(i, j int) bool {
+        if !due[i].CreatedAt.Equal(due[j].CreatedAt) {
+            return due[i].CreatedAt.Before(due[j].CreatedAt)
+        }
+        return due[i].ID < due[j].ID
+    })
+    if limit > 0 && len(due) > limit {
+        due = due[:limit]
+    }
+
+    result := make([]*WebhookDelivery, 0, len(due))
+    for _, delivery := range due {
+        claimed := copyWebhookDelivery(delivery)
+        claimed.NextAttemptAt = now.Add(lease)
+        m.deliveries[claimed.ID] = claimed
+        result = append(result, copyWebhookDelivery(claimed))
+    }
+
+    return result, nil
+}
+
+// ListWebhookDeliveries returns the deliveries to a webhook, newest first.
+func (m *MemoryStore) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*WebhookDelivery, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    var result []*WebhookDelivery
+    for _, delivery := range m.deliveries {
+        if delivery.WebhookID == webhookID && (state == "" || delivery.State == state) {
+            result = append(result, copyWebhookDelivery(delivery))
+        }
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+            return result[i].CreatedAt.After(result[j].CreatedAt)
+        }
+        return result[i].ID > result[j].ID
+    })
+    if limit > 0 && len(result) > limit {
+        result = result[:limit]
+    }
+
+    return result, nil
+}
+
+// PruneWebhookDeliveries deletes the deliveries that finished before
+// finishedBefore.
+func (m *MemoryStore) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var pruned int64
+    for id, delivery := range m.deliveries {
+        if delivery.State != WebhookDeliveryPending && delivery.UpdatedAt.Before(finishedBefore) {
+            delete(m.deliveries, id)
+            pruned++
+        }
+    }
+
+    return pruned, nil
+}
+
+func sortWebhooks(webhooks []*Webhook) {
+    sort.Slice(webhooks, func(i, j int) bool {
+        if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+            return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+        }
+        return webhooks[i].ID < webhooks[j].ID
+    })
+}
+
+func copyWebhook(src *Webhook) *Webhook {
+    dst := *src
+    dst.EventTypes = append([]string(nil), src.EventTypes...)
+    return &dst
+}
+
+func copyWebhookDelivery(src *WebhookDelivery) *WebhookDelivery {
+    dst := *src
+    dst.Payload = append([]byte(nil), src.Payload...)
+    return &dst
+}
+
+type idempotencyKey struct {
+    scope string
+    key   string
+}
+
+// ClaimIdempotencyKey saves key as a call in progress unless an unexpired
+// record of it exists.
+func (m *MemoryStore) ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    k := idempotencyKey{scope: key.Scope, key: key.Key}
+    if existing, ok := m.idempotency[k]; ok && existing.ExpiresAt.After(models.Now()) {
+        return copyIdempotencyKey(existing), fmt.Errorf("%w: idempotency key %q is taken", ErrConflict, key.Key)
+    }
+    saved := copyIdempotencyKey(key)
+    saved.Response = nil
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    m.idempotency[k] = saved
+
+    return copyIdempotencyKey(saved), nil
+}
+
+// CompleteIdempotencyKey saves the response of a claimed call, or deletes
+// the claim when response is nil.
+func (m *MemoryStore) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    k := idempotencyKey{scope: scope, key: key}
+    existing, ok := m.idempotency[k]
+    if !ok {
+        return fmt.Errorf("%w: idempotency key %q", ErrNotFound, key)
+    }
+    if response == nil {
+        delete(m.idempotency, k)
+        return nil
+    }
+    saved := copyIdempotencyKey(existing)
+    saved.Response = append([]byte(nil), response...)
+    saved.ExpiresAt = expiresAt
+    m.idempotency[k] = saved
+
+    return nil
+}
+
+// PruneIdempotencyKeys deletes the records that expired before
+// expiredBefore.
+func (m *MemoryStore) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var pruned int64
+    for k, key := range m.idempotency {
+        if key.ExpiresAt.Before(expiredBefore) {
+            delete(m.idempotency, k)
+            pruned++
+        }
+    }
+
+    return pruned, nil
+}
+
+func copyIdempotencyKey(src *IdempotencyKey) *IdempotencyKey {
+    dst := *src
+    if src.Response != nil {
+        dst.Response = append([]byte(nil), src.Response...)
+    }
+    return &dst
+}
+
+// FixChecksums replaces stored checksums that still match fix.Stored.
+func (m *MemoryStore) FixChecksums(ctx context.Context, fixes []ChecksumMismatch) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var fixed int64
+    for _, fix := range fixes {
+        if fix.VersionNum == 0 {
+            dna, ok := m.configs[fix.ConfigID]
+            if !ok || dna.Checksum != fix.Stored {
+                continue
+            }
+            dna = models.Clone(dna)
+            dna.Checksum = fix.Computed
+            m.configs[fix.ConfigID] = dna
+            fixed++
+            continue
+        }
+        for i, v := range m.versions[fix.ConfigID] {
+            if v.VersionNum != fix.VersionNum || v.Checksum != fix.Stored {
+                continue
+            }
+            updated := *v
+            updated.Checksum = fix.Computed
+            if v.Data != nil {
+                updated.Data = models.Clone(v.Data)
+                updated.Data.Checksum = fix.Computed
+            }
+            m.versions[fix.ConfigID][i] = &updated
+            fixed++
+        }
+    }
+    return fixed, nil
+}
+
+// SaveChecksumJob creates or replaces a checksum job.
+func (m *MemoryStore) SaveChecksumJob(ctx context.Context, job *ChecksumJob) (*ChecksumJob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    saved := copyChecksumJob(job)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    saved.UpdatedAt = models.Now()
+    m.checksumJobs[saved.ID] = saved
+
+    return copyChecksumJob(saved), nil
+}
+
+// GetChecksumJob retrieves a checksum job by ID.
+func (m *MemoryStore) GetChecksumJob(ctx context.Context, id string) (*ChecksumJob, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    job, exists := m.checksumJobs[id]
+    if !exists {
+        return nil, fmt.Errorf("checksum job %s: %w", id, ErrNotFound)
+    }
+
+    return copyChecksumJob(job), nil
+}
+
+func copyChecksumJob(src *ChecksumJob) *ChecksumJob {
+    dst := *src
+    dst.Mismatches = append([]ChecksumMismatch(nil), src.Mismatches...)
+    return &dst
+}
+
+// IndexStats returns nothing: the in-memory store has no indexes.
+func (m *MemoryStore) IndexStats(ctx context.Context) ([]IndexStat, error) {
+    return nil, nil
+}
+
+// Ping always succeeds: the in-memory store has nothing to reach.
+func (m *MemoryStore) Ping(ctx context.Context) error {
+    return nil
+}
+
+// Close closes the storage backend (no-op for memory storage).
+func (m *MemoryStore) Close() {
+    // No-op for in-memory storage
+}
+
+// FindPrincipalData returns every record naming principal.
+func (m *MemoryStore) FindPrincipalData(ctx context.Context, principal string) (*PrincipalData, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    return m.findPrincipalLocked(principal), nil
+}
+
+// AnonymizePrincipal replaces principal with pseudonym everywhere and deletes
+// the principal's drafts. Records are replaced rather than changed in place,
+// since readers may hold the old ones.
+func (m *MemoryStore) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*PrincipalData, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    found := m.findPrincipalLocked(principal)
+    rename := func(name string) string {
+        if name == principal {
+            return pseudonym
+        }
+        return name
+    }
+
+    for _, dna := range found.Configs {
+        renamed := models.Clone(m.configs[dna.Id])
+        renamed.CreatedBy = pseudonym
+        m.configs[dna.Id] = renamed
+    }
+    for _, pv := range found.Versions {
+        versions := m.versions[pv.ConfigID]
+        for i, v := range versions {
+            if v.VersionNum == pv.Version.VersionNum {
+                renamed := *v
+                renamed.CreatedBy = rename(v.CreatedBy)
+                renamed.Data = models.Clone(v.Data)
+                renamed.Data.CreatedBy = rename(v.Data.CreatedBy)
+                versions[i] = &renamed
+            }
+        }
+    }
+    for i, entry := range m.audit {
+        if entry.Actor == principal || entry.ApprovedBy == principal {
+            renamed := *entry
+            renamed.Actor = rename(entry.Actor)
+            renamed.ApprovedBy = rename(entry.ApprovedBy)
+            m.audit[i] = &renamed
+        }
+    }
+    for _, draft := range found.Drafts {
+        key := draftKey{draft.ConfigID, draft.Owner}
+        if draft.Owner == principal {
+            delete(m.drafts, key)
+            continue
+        }
+        renamed := copyDraft(m.drafts[key])
+        renamed.Data.CreatedBy = pseudonym
+        m.drafts[key] = renamed
+    }
+    for configID, waivers := range m.waivers {
+        for i, w := range waivers {
+            if w.Approver == principal || w.RevokedBy == principal {
+                renamed := *w
+                renamed.Approver = rename(w.Approver)
+                renamed.RevokedBy = rename(w.RevokedBy)
+                m.waivers[configID][i] = &renamed
+            }
+        }
+    }
+    for _, unlock := range found.Unlocks {
+        renamed := *m.unlocks[unlock.ConfigID]
+        renamed.Actor = pseudonym
+        m.unlocks[unlock.ConfigID] = &renamed
+    }
+    for _, snapshot := range found.Snapshots {
+        renamed := *m.snapshots[snapshot.Name]
+        renamed.CreatedBy = pseudonym
+        m.snapshots[snapshot.Name] = &renamed
+    }
+    for _, mapping := range found.ImportMappings {
+        renamed := copyImportMapping(m.mappings[mapping.Project])
+        renamed.UpdatedBy = pseudonym
+        m.mappings[mapping.Project] = renamed
+    }
+    for _, token := range found.APITokens {
+        renamed := copyAPIToken(m.tokens[token.ID])
+        renamed.CreatedBy = pseudonym
+        m.tokens[token.ID] = renamed
+    }
+    for _, template := range found.Templates {
+        renamed := copyTemplate(m.templates[template.Name])
+        renamed.CreatedBy = pseudonym
+        m.templates[template.Name] = renamed
+    }
+    for _, job := range found.ChecksumJobs {
+        renamed := copyChecksumJob(m.checksumJobs[job.ID])
+        renamed.StartedBy = pseudonym
+        m.checksumJobs[job.ID] = renamed
+    }
+    for _, canary := range found.Canaries {
+        renamed := copyCanary(m.canaries[canary.ConfigID])
+        renamed.StartedBy = rename(renamed.StartedBy)
+        renamed.FinishedBy = rename(renamed.FinishedBy)
+        renamed.Candidate.CreatedBy = rename(renamed.Candidate.CreatedBy)
+        m.canaries[canary.ConfigID] = renamed
+    }
+    for _, tag := range found.ReleaseTags {
+        renamed := *m.releaseTags[tag.ConfigID][tag.Tag]
+        renamed.CreatedBy = pseudonym
+        m.releaseTags[tag.ConfigID][tag.Tag] = &renamed
+    }
+    for _, webhook := range found.Webhooks {
+        renamed := copyWebhook(m.webhooks[webhook.ID])
+        renamed.CreatedBy = pseudonym
+        m.webhooks[webhook.ID] = renamed
+    }
+
+    return found, nil
+}
+
+// findPrincipalLocked collects copies of the records naming principal.
+func (m *MemoryStore) findPrincipalLocked(principal string) *PrincipalData {
+    found := &PrincipalData{}
+
+    for _, dna := range m.configs {
+        if dna.CreatedBy == principal {
+            found.Configs = append(found.Configs, models.Clone(dna))
+        }
+    }
+    sort.Slice(found.Configs, func(i, j int) bool {
+        if ci, cj := createTime(found.Configs[i]), createTime(found.Configs[j]); !ci.Equal(cj) {
+            return ci.Before(cj)
+        }
+        return found.Configs[i].Id < found.Configs[j].Id
+    })
+
+    for id, versions := range m.versions {
+        for _, v := range versions {
+            if v.CreatedBy == principal || v.Data.GetCreatedBy() == principal {
+                copied := *v
+                copied.Data = models.Clone(v.Data)
+                found.Versions = append(found.Versions, PrincipalVersion{ConfigID: id, Version: &copied})
+            }
+        }
+    }
+    sort.Slice(found.Versions, func(i, j int) bool {
+        vi, vj := found.Versions[i], found.Versions[j]
+        if !vi.Version.CreatedAt.Equal(vj.Version.CreatedAt) {
+            return vi.Version.CreatedAt.Before(vj.Version.CreatedAt)
+        }
+        if vi.ConfigID != vj.ConfigID {
+            return vi.ConfigID < vj.ConfigID
+        }
+        return vi.Version.VersionNum < vj.Version.VersionNum
+    })
+
+    for _, entry := range m.audit {
+        if entry.Actor == principal || entry.ApprovedBy == principal {
+            copied := *entry
+            found.AuditEntries = append(found.AuditEntries, &copied)
+        }
+    }
+
+    for _, draft := range m.drafts {
+        if draft.Owner == principal || draft.Data.GetCreatedBy() == principal {
+            found.Drafts = append(found.Drafts, copyDraft(draft))
+        }
+    }
+    sort.Slice(found.Drafts, func(i, j int) bool {
+        di, dj := found.Drafts[i], found.Drafts[j]
+        if !di.CreatedAt.Equal(dj.CreatedAt) {
+            return di.CreatedAt.Before(dj.CreatedAt)
+        }
+        if di.ConfigID != dj.ConfigID {
+            return di.ConfigID < dj.ConfigID
+        }
+        return di.Owner < dj.Owner
+    })
+
+    for _, waivers := range m.waivers {
+        for _, w := range waivers {
+            if w.Approver == principal || w.RevokedBy == principal {
+                copied := *w
+                found.Waivers = append(found.Waivers, &copied)
+            }
+        }
+    }
+    sort.Slice(found.Waivers, func(i, j int) bool {
+        if !found.Waivers[i].CreatedAt.Equal(found.Waivers[j].CreatedAt) {
+            return found.Waivers[i].CreatedAt.Before(found.Waivers[j].CreatedAt)
+        }
+        return found.Waivers[i].ID < found.Waivers[j].ID
+    })
+
+    for _, unlock := range m.unlocks {
+        if unlock.Actor == principal {
+            copied := *unlock
+            found.Unlocks = append(found.Unlocks, &copied)
+        }
+    }
+    sort.Slice(found.Unlocks, func(i, j int) bool {
+        if !found.Unlocks[i].CreatedAt.Equal(found.Unlocks[j].CreatedAt) {
+            return found.Unlocks[i].CreatedAt.Before(found.Unlocks[j].CreatedAt)
+        }
+        return found.Unlocks[i].ConfigID < found.Unlocks[j].ConfigID
+    })
+
+    for _, snapshot := range m.snapshots {
+        if snapshot.CreatedBy == principal {
+            copied := *snapshot
+            copied.Entries = nil
+            found.Snapshots = append(found.Snapshots, &copied)
+        }
+    }
+    sort.Slice(found.Snapshots, func(i, j int) bool { return found.Snapshots[i].Name < found.Snapshots[j].Name })
+
+    for _, mapping := range m.mappings {
+        if mapping.UpdatedBy == principal {
+            found.ImportMappings = append(found.ImportMappings, copyImportMapping(mapping))
+        }
+    }
+    sort.Slice(found.ImportMappings, func(i, j int) bool { return found.ImportMappings[i].Project < found.ImportMappings[j].Project })
+
+    for _, token := range m.tokens {
+        if token.CreatedBy == principal {
+            found.APITokens = append(found.APITokens, copyAPIToken(token))
+        }
+    }
+    sort.Slice(found.APITokens, func(i, j int) bool {
+        if !found.APITokens[i].CreatedAt.Equal(found.APITokens[j].CreatedAt) {
+            return found.APITokens[i].CreatedAt.Before(found.APITokens[j].CreatedAt)
+        }
+        return found.APITokens[i].ID < found.APITokens[j].ID
+    })
+
+    for _, template := range m.templates {
+        if template.CreatedBy == principal {
+            found.Templates = append(found.Templates, copyTemplate(template))
+        }
+    }
+    sort.Slice(found.Templates, func(i, j int) bool { return found.Templates[i].Name < found.Templates[j].Name })
+
+    for _, job := range m.checksumJobs {
+        if job.StartedBy == principal {
+            found.ChecksumJobs = append(found.ChecksumJobs, copyChecksumJob(job))
+        }
+    }
+    sort.Slice(found.ChecksumJobs, func(i, j int) bool {
+        if !found.ChecksumJobs[i].CreatedAt.Equal(found.ChecksumJobs[j].CreatedAt) {
+            return found.ChecksumJobs[i].CreatedAt.Before(found.ChecksumJobs[j].CreatedAt)
+        }
+        return found.ChecksumJobs[i].ID < found.ChecksumJobs[j].ID
+    })
+
+    for _, canary := range m.canaries {
+        if canary.StartedBy == principal || canary.FinishedBy == principal || canary.Candidate.GetCreatedBy() == principal {
+            found.Canaries = append(found.Canaries, copyCanary(canary))
+        }
+    }
+    sort.Slice(found.Canaries, func(i, j int) bool { return found.Canaries[i].ConfigID < found.Canaries[j].ConfigID })
+
+    for _, tags := range m.releaseTags {
+        for _, tag := range tags {
+            if tag.CreatedBy == principal {
+                copied := *tag
+                found.ReleaseTags = append(found.ReleaseTags, &copied)
+            }
+        }
+    }
+    sort.Slice(found.ReleaseTags, func(i, j int) bool {
+        a, b := found.ReleaseTags[i], found.ReleaseTags[j]
+        if !a.CreatedAt.Equal(b.CreatedAt) {
+            return a.CreatedAt.Before(b.CreatedAt)
+        }
+        if a.ConfigID != b.ConfigID {
+            return a.ConfigID < b.ConfigID
+        }
+        return a.Tag < b.Tag
+    })
+
+    for _, webhook := range m.webhooks {
+        if webhook.CreatedBy == principal {
+            found.Webhooks = append(found.Webhooks, copyWebhook(webhook))
+        }
+    }
+    sortWebhooks(found.Webhooks)
+
+    return found
 }
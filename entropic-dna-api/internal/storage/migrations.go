@@ -60,7 +60,7 @@ func Migrate(ctx context.Context, db *sql.DB) error {
 			return fmt.Errorf("read migration %s: %w", name, err)
 		}
 
-		upSQL := extractUpSQL(string(contentBytes))
+		upSQL, _ := splitMigration(string(contentBytes))
 		if strings.TrimSpace(upSQL) == "" {
 			return fmt.Errorf("migration %s has no Up SQL", name)
 		}
@@ -88,9 +88,94 @@ func Migrate(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
-func extractUpSQL(sqlText string) string {
-	parts := strings.Split(sqlText, "-- +migrate Down")
-	upPart := parts[0]
-	upPart = strings.ReplaceAll(upPart, "-- +migrate Up", "")
-	return upPart
+// MigrateDown reverts the steps most recently applied migrations, newest
+// first, each in its own transaction with its schema_migrations row, and
+// returns the names it reverted. Every migration to revert is checked for
+// Down SQL before any is run, so a migration that can't be reverted stops
+// the whole request rather than leaving it half done. If one fails, those
+// before it stay reverted.
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations ORDER BY name DESC LIMIT $1`, steps)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	var names []string
+	err = eachRow(rows, func() error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("scan applied migration: %w", err)
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(names) < steps {
+		return nil, fmt.Errorf("cannot revert %d migrations: only %d are applied", steps, len(names))
+	}
+
+	downSQL := make(map[string]string, len(names))
+	for _, name := range names {
+		contentBytes, err := migrationsFS.ReadFile(filepath.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s is unknown to this build; revert it with the build that applied it: %w", name, err)
+		}
+		_, down := splitMigration(string(contentBytes))
+		if !hasStatements(down) {
+			return nil, fmt.Errorf("migration %s has no Down SQL and cannot be reverted", name)
+		}
+		downSQL[name] = down
+	}
+
+	var reverted []string
+	for _, name := range names {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return reverted, fmt.Errorf("begin tx for %s: %w", name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, downSQL[name]); err != nil {
+			_ = tx.Rollback()
+			return reverted, fmt.Errorf("revert migration %s: %w", name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE name = $1`, name); err != nil {
+			_ = tx.Rollback()
+			return reverted, fmt.Errorf("unrecord migration %s: %w", name, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return reverted, fmt.Errorf("commit revert of %s: %w", name, err)
+		}
+		reverted = append(reverted, name)
+	}
+
+	return reverted, nil
+}
+
+// splitMigration returns the Up and Down sections of a migration file.
+func splitMigration(sqlText string) (up string, down string) {
+	up, down, _ = strings.Cut(sqlText, "-- +migrate Down")
+	up = strings.ReplaceAll(up, "-- +migrate Up", "")
+	return up, down
+}
+
+// hasStatements reports whether sqlText holds more than comments and blank
+// lines.
+func hasStatements(sqlText string) bool {
+	for _, line := range strings.Split(sqlText, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
 }
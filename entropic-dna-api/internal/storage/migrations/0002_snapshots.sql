@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_snapshots (
+  name VARCHAR(255) PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  created_by VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS game_dna_snapshot_entries (
+  snapshot_name VARCHAR(255) REFERENCES game_dna_snapshots(name) ON DELETE CASCADE,
+  config_id UUID NOT NULL,
+  version_num INT NOT NULL,
+  PRIMARY KEY (snapshot_name, config_id)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_snapshot_entries;
+DROP TABLE IF EXISTS game_dna_snapshots;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE game_dna_versions ADD COLUMN IF NOT EXISTS validation JSONB;
+
+-- +migrate Down
+ALTER TABLE game_dna_versions DROP COLUMN IF EXISTS validation;
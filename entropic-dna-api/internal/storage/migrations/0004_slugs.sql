@@ -0,0 +1,35 @@
+-- +migrate Up
+ALTER TABLE game_dna_configs ADD COLUMN IF NOT EXISTS slug VARCHAR(100);
+
+-- Backfill existing configs from their names, oldest first. A duplicate gets
+-- the first free numeric suffix, checked against every slug assigned so far,
+-- so "Arena", "Arena" and "Arena 2", in that order, become arena, arena-2
+-- and arena-2-2.
+DO $$
+DECLARE
+  c RECORD;
+  base TEXT;
+  candidate TEXT;
+  n INT;
+BEGIN
+  FOR c IN SELECT id, name FROM game_dna_configs WHERE slug IS NULL ORDER BY created_at, id LOOP
+    base := COALESCE(NULLIF(rtrim(left(trim(both '-' from lower(regexp_replace(c.name, '[^a-zA-Z0-9]+', '-', 'g'))), 90), '-'), ''), 'config');
+    candidate := base;
+    n := 1;
+    WHILE EXISTS (SELECT 1 FROM game_dna_configs WHERE slug = candidate) LOOP
+      n := n + 1;
+      candidate := base || '-' || n;
+    END LOOP;
+    UPDATE game_dna_configs
+    SET slug = candidate,
+        data = jsonb_set(data, '{slug}', to_jsonb(candidate))
+    WHERE id = c.id;
+  END LOOP;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_game_dna_slug ON game_dna_configs(slug);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_game_dna_slug;
+ALTER TABLE game_dna_configs DROP COLUMN IF EXISTS slug;
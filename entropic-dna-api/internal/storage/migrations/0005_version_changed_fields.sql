@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE game_dna_versions ADD COLUMN IF NOT EXISTS changed_fields TEXT[];
+
+-- +migrate Down
+ALTER TABLE game_dna_versions DROP COLUMN IF EXISTS changed_fields;
@@ -0,0 +1,13 @@
+-- +migrate Up
+-- List orders by newest first; the partial indexes serve the locked/unlocked splits.
+CREATE INDEX IF NOT EXISTS idx_game_dna_created_at ON game_dna_configs (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_game_dna_unlocked_created_at ON game_dna_configs (created_at DESC) WHERE is_locked = false;
+CREATE INDEX IF NOT EXISTS idx_game_dna_published_created_at ON game_dna_configs (created_at DESC) WHERE is_locked = true;
+-- Genre lives in the JSON payload.
+CREATE INDEX IF NOT EXISTS idx_game_dna_genre ON game_dna_configs ((data->>'genre'), created_at DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_game_dna_genre;
+DROP INDEX IF EXISTS idx_game_dna_published_created_at;
+DROP INDEX IF EXISTS idx_game_dna_unlocked_created_at;
+DROP INDEX IF EXISTS idx_game_dna_created_at;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_import_mappings (
+  project VARCHAR(255) PRIMARY KEY,
+  columns JSONB NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_by VARCHAR(255)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_import_mappings;
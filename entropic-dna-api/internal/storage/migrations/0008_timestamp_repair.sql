@@ -0,0 +1,26 @@
+-- +migrate Up
+-- Configs used to be stored with whatever created_at/last_modified strings the
+-- client sent. Reads now reject malformed ones, so replace them with the
+-- row's timestamp columns.
+UPDATE game_dna_configs
+SET data = jsonb_set(data, '{created_at}', to_jsonb(to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')))
+WHERE data->>'created_at' <> ''
+  AND data->>'created_at' !~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$';
+
+UPDATE game_dna_configs
+SET data = jsonb_set(data, '{last_modified}', to_jsonb(to_char(updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')))
+WHERE data->>'last_modified' <> ''
+  AND data->>'last_modified' !~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$';
+
+UPDATE game_dna_versions
+SET data = jsonb_set(data, '{created_at}', to_jsonb(to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')))
+WHERE data->>'created_at' <> ''
+  AND data->>'created_at' !~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$';
+
+UPDATE game_dna_versions
+SET data = jsonb_set(data, '{last_modified}', to_jsonb(to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')))
+WHERE data->>'last_modified' <> ''
+  AND data->>'last_modified' !~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$';
+
+-- +migrate Down
+-- The original malformed strings are not recoverable.
@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_api_tokens (
+  id VARCHAR(64) PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  slugs TEXT[] NOT NULL,
+  secret_hash VARCHAR(64) NOT NULL UNIQUE,
+  previous_hash VARCHAR(64),
+  previous_expires_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  created_by VARCHAR(255),
+  rotated_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_api_tokens_previous_hash ON game_dna_api_tokens (previous_hash);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_api_tokens;
@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_checksum_jobs (
+  id VARCHAR(64) PRIMARY KEY,
+  state VARCHAR(16) NOT NULL,
+  dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+  batch_size INT NOT NULL,
+  rate_per_second INT NOT NULL,
+  last_config_id VARCHAR(64) NOT NULL DEFAULT '',
+  configs_scanned BIGINT NOT NULL DEFAULT 0,
+  versions_scanned BIGINT NOT NULL DEFAULT 0,
+  config_mismatches BIGINT NOT NULL DEFAULT 0,
+  version_mismatches BIGINT NOT NULL DEFAULT 0,
+  fixed BIGINT NOT NULL DEFAULT 0,
+  mismatches JSONB NOT NULL DEFAULT '[]',
+  error TEXT,
+  started_by VARCHAR(255),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_checksum_jobs;
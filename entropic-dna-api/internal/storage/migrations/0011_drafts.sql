@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_drafts (
+  config_id UUID NOT NULL REFERENCES game_dna_configs(id) ON DELETE CASCADE,
+  owner VARCHAR(255) NOT NULL,
+  data JSONB NOT NULL,
+  base_checksum VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  PRIMARY KEY (config_id, owner)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_drafts;
@@ -0,0 +1,16 @@
+-- +migrate Up
+-- No foreign key: entries outlive the configs they describe.
+CREATE TABLE IF NOT EXISTS game_dna_audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  config_id UUID NOT NULL,
+  action VARCHAR(32) NOT NULL,
+  actor VARCHAR(255) NOT NULL,
+  reason TEXT NOT NULL,
+  version_num BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_audit_log_config ON game_dna_audit_log (config_id, id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_audit_log;
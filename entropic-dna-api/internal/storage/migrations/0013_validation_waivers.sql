@@ -0,0 +1,19 @@
+-- +migrate Up
+-- No foreign key: waivers stay on record after their config is deleted.
+CREATE TABLE IF NOT EXISTS game_dna_waivers (
+  id VARCHAR(64) PRIMARY KEY,
+  config_id UUID NOT NULL,
+  code VARCHAR(64) NOT NULL,
+  field VARCHAR(255) NOT NULL DEFAULT '',
+  justification TEXT NOT NULL,
+  approver VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  revoked_by VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_waivers_config ON game_dna_waivers (config_id, created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_waivers;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_templates (
+  name VARCHAR(255) PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT '',
+  data JSONB NOT NULL,
+  created_by VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_templates;
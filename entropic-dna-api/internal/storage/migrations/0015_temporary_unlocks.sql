@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_unlocks (
+  config_id UUID PRIMARY KEY REFERENCES game_dna_configs(id) ON DELETE CASCADE,
+  actor VARCHAR(255) NOT NULL,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_unlocks_expires ON game_dna_unlocks (expires_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_unlocks;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- Search columns are written by the service, since data may be encoded.
+-- search_document holds the words of the name, tags and custom properties;
+-- search_numbers the numeric fields. Rows written before this migration are
+-- filled in at startup.
+ALTER TABLE game_dna_configs ADD COLUMN IF NOT EXISTS search_document TSVECTOR;
+ALTER TABLE game_dna_configs ADD COLUMN IF NOT EXISTS search_numbers JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_search_document ON game_dna_configs USING GIN (search_document);
+-- The numeric fields searched most; others are filtered after the other predicates.
+CREATE INDEX IF NOT EXISTS idx_game_dna_search_target_fps ON game_dna_configs (((search_numbers->>'target_fps')::numeric));
+CREATE INDEX IF NOT EXISTS idx_game_dna_search_max_players ON game_dna_configs (((search_numbers->>'max_players')::numeric));
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_game_dna_search_max_players;
+DROP INDEX IF EXISTS idx_game_dna_search_target_fps;
+DROP INDEX IF EXISTS idx_game_dna_search_document;
+ALTER TABLE game_dna_configs DROP COLUMN IF EXISTS search_numbers;
+ALTER TABLE game_dna_configs DROP COLUMN IF EXISTS search_document;
@@ -0,0 +1,28 @@
+-- +migrate Up
+-- A config's latest canary; starting the next one replaces a finished one.
+CREATE TABLE IF NOT EXISTS game_dna_canaries (
+  config_id UUID PRIMARY KEY REFERENCES game_dna_configs(id) ON DELETE CASCADE,
+  candidate JSONB NOT NULL,
+  checksum VARCHAR(64) NOT NULL,
+  base_checksum VARCHAR(64) NOT NULL,
+  percent INTEGER NOT NULL,
+  max_error_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+  max_error_rate_increase DOUBLE PRECISION NOT NULL DEFAULT 0,
+  min_sessions BIGINT NOT NULL DEFAULT 0,
+  state VARCHAR(16) NOT NULL,
+  started_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  finished_by VARCHAR(255),
+  finished_at TIMESTAMP WITH TIME ZONE,
+  reason TEXT,
+  candidate_sessions BIGINT NOT NULL DEFAULT 0,
+  candidate_errors BIGINT NOT NULL DEFAULT 0,
+  stable_sessions BIGINT NOT NULL DEFAULT 0,
+  stable_errors BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_canaries_running ON game_dna_canaries (ends_at) WHERE state = 'running';
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_canaries;
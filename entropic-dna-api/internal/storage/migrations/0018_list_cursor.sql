@@ -0,0 +1,9 @@
+-- +migrate Up
+-- List pages by (created_at, id) so cursors seek straight to the next page;
+-- the id breaks ties between configs created in the same second.
+CREATE INDEX IF NOT EXISTS idx_game_dna_created_at_id ON game_dna_configs (created_at DESC, id);
+DROP INDEX IF EXISTS idx_game_dna_created_at;
+
+-- +migrate Down
+CREATE INDEX IF NOT EXISTS idx_game_dna_created_at ON game_dna_configs (created_at DESC);
+DROP INDEX IF EXISTS idx_game_dna_created_at_id;
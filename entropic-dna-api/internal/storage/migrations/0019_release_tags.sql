@@ -0,0 +1,13 @@
+-- +migrate Up
+-- A tag names one version of its config; the version is kept by pruning.
+CREATE TABLE IF NOT EXISTS game_dna_release_tags (
+  config_id UUID NOT NULL REFERENCES game_dna_configs(id) ON DELETE CASCADE,
+  tag VARCHAR(64) NOT NULL,
+  version_num BIGINT NOT NULL,
+  created_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  PRIMARY KEY (config_id, tag)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_release_tags;
@@ -0,0 +1,33 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_webhooks (
+  id VARCHAR(64) PRIMARY KEY,
+  url TEXT NOT NULL,
+  event_types TEXT[] NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  created_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Deliveries keep the config ID of deleted configs, so it has no foreign key.
+CREATE TABLE IF NOT EXISTS game_dna_webhook_deliveries (
+  id VARCHAR(64) PRIMARY KEY,
+  webhook_id VARCHAR(64) NOT NULL REFERENCES game_dna_webhooks(id) ON DELETE CASCADE,
+  event_type VARCHAR(64) NOT NULL,
+  config_id VARCHAR(64) NOT NULL,
+  payload JSONB NOT NULL,
+  state VARCHAR(16) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_status INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_webhook_deliveries_due ON game_dna_webhook_deliveries (state, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_game_dna_webhook_deliveries_webhook ON game_dna_webhook_deliveries (webhook_id, created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_webhook_deliveries;
+DROP TABLE IF EXISTS game_dna_webhooks;
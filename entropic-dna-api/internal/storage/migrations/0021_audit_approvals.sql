@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Who approved an audited action, for actions such as environment promotions
+-- that need approval. Empty for the others.
+ALTER TABLE game_dna_audit_log ADD COLUMN IF NOT EXISTS approved_by VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE game_dna_audit_log DROP COLUMN IF EXISTS approved_by;
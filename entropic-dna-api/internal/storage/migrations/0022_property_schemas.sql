@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS game_dna_property_schemas (
+  project VARCHAR(255) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  type VARCHAR(16) NOT NULL,
+  allowed_values TEXT[] NOT NULL DEFAULT '{}',
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  description TEXT NOT NULL DEFAULT '',
+  updated_by VARCHAR(255) NOT NULL DEFAULT '',
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  PRIMARY KEY (project, name)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_property_schemas;
@@ -0,0 +1,24 @@
+-- +migrate Up
+-- Append-only log of config mutations, kept when the server's version history
+-- is event-sourced. There is no foreign key to game_dna_configs: the log of a
+-- deleted config outlives it. A version is identified by its number and
+-- creation time, as a config recreated under a deleted one's ID starts over.
+CREATE TABLE IF NOT EXISTS game_dna_events (
+  seq BIGSERIAL PRIMARY KEY,
+  config_id VARCHAR(255) NOT NULL,
+  type VARCHAR(32) NOT NULL,
+  version_num BIGINT NOT NULL DEFAULT 0,
+  checksum VARCHAR(64) NOT NULL DEFAULT '',
+  actor VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  full_data BOOLEAN NOT NULL DEFAULT FALSE,
+  fields TEXT[] NOT NULL DEFAULT '{}',
+  data JSONB NOT NULL,
+  validation JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_events_config ON game_dna_events (config_id, seq);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_game_dna_events_version ON game_dna_events (config_id, version_num, created_at) WHERE version_num > 0;
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_events;
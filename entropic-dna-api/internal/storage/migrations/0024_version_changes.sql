@@ -0,0 +1,7 @@
+-- +migrate Up
+-- changed_fields with their values before and after, as a JSON array. NULL for
+-- versions written before it was recorded.
+ALTER TABLE game_dna_versions ADD COLUMN IF NOT EXISTS changes JSONB;
+
+-- +migrate Down
+ALTER TABLE game_dna_versions DROP COLUMN IF EXISTS changes;
@@ -0,0 +1,16 @@
+-- +migrate Up
+-- An archived version keeps its row without its data, which is kept in
+-- object storage under archive_key. archived_size is what the data took here.
+ALTER TABLE game_dna_versions ALTER COLUMN data DROP NOT NULL;
+ALTER TABLE game_dna_versions ADD COLUMN IF NOT EXISTS archive_key TEXT;
+ALTER TABLE game_dna_versions ADD COLUMN IF NOT EXISTS archived_size INTEGER;
+ALTER TABLE game_dna_versions DROP CONSTRAINT IF EXISTS game_dna_versions_data_or_archive;
+ALTER TABLE game_dna_versions ADD CONSTRAINT game_dna_versions_data_or_archive
+  CHECK (data IS NOT NULL OR archive_key IS NOT NULL);
+
+-- +migrate Down
+-- Fails while any version is archived: their data has to be restored first.
+ALTER TABLE game_dna_versions DROP CONSTRAINT IF EXISTS game_dna_versions_data_or_archive;
+ALTER TABLE game_dna_versions ALTER COLUMN data SET NOT NULL;
+ALTER TABLE game_dna_versions DROP COLUMN IF EXISTS archived_size;
+ALTER TABLE game_dna_versions DROP COLUMN IF EXISTS archive_key;
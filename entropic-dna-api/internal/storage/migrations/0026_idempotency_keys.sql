@@ -0,0 +1,17 @@
+-- +migrate Up
+-- Responses of calls made with an idempotency key, replayed to retries of
+-- them. response is NULL while the first call runs.
+CREATE TABLE IF NOT EXISTS game_dna_idempotency_keys (
+  scope VARCHAR(128) NOT NULL,
+  key VARCHAR(255) NOT NULL,
+  request_hash VARCHAR(64) NOT NULL,
+  response BYTEA,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_dna_idempotency_keys_expiry ON game_dna_idempotency_keys (expires_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS game_dna_idempotency_keys;
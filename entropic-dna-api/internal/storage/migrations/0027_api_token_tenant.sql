@@ -0,0 +1,7 @@
+-- +migrate Up
+-- The tenant a token acts for under data residency; tokens made before then
+-- act for none.
+ALTER TABLE game_dna_api_tokens ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE game_dna_api_tokens DROP COLUMN IF EXISTS tenant;
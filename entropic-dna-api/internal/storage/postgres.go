@@ -4,17 +4,41 @@ import (
     "context"
     "database/sql"
     "encoding/json"
+    "errors"
     "fmt"
+    "sort"
+    "strings"
     "time"
 
     "github.com/google/uuid"
     "github.com/lib/pq"
+    "github.com/entropic-engine/entropic-dna-api/internal/diff"
+    "github.com/entropic-engine/entropic-dna-api/internal/models"
     pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
 )
 
 // PostgresStore is a PostgreSQL implementation of the Store interface.
 type PostgresStore struct {
-    db *sql.DB
+    db           *sql.DB
+    url          string // for the change listener's own connection
+    historyDepth int
+    codecs       *CodecChain // encodes the data columns; nil stores plain JSON
+    archive      ObjectStore // holds the data of archived versions; nil when none are
+    observe      QueryObserver // told about each statement run; nil when none is
+}
+
+// PoolConfig sizes the database connection pool.
+type PoolConfig struct {
+    MaxOpenConns    int
+    MaxIdleConns    int
+    ConnMaxLifetime time.Duration
+}
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx so write paths can run inside a transaction.
+type dbtx interface {
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+    QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+    QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // DB returns the underlying database connection for migrations.
@@ -23,67 +47,175 @@ func (p *PostgresStore) DB() *sql.DB {
 }
 
 // NewPostgresStore creates a new PostgreSQL storage backend.
-func NewPostgresStore(connectionURL string) (*PostgresStore, error) {
-    db, err := sql.Open("postgres", connectionURL)
+func NewPostgresStore(connectionURL string, pool PoolConfig) (*PostgresStore, error) {
+    p := &PostgresStore{url: connectionURL}
+    connector, err := pq.NewConnector(connectionURL)
     if err != nil {
         return nil, fmt.Errorf("failed to open database connection: %w", err)
     }
+    db := sql.OpenDB(&observedConnector{Connector: connector, observe: &p.observe})
 
     if err := db.Ping(); err != nil {
         return nil, fmt.Errorf("failed to ping database: %w", err)
     }
 
-    db.SetMaxOpenConns(25)
-    db.SetMaxIdleConns(25)
-    db.SetConnMaxLifetime(5 * time.Minute)
+    db.SetMaxOpenConns(pool.MaxOpenConns)
+    db.SetMaxIdleConns(pool.MaxIdleConns)
+    db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+    p.db = db
+    return p, nil
+}
+
+// SetMaxHistoryDepth keeps at most n versions per config, plus any pinned by
+// a snapshot or named by a release tag. 0 keeps every version. Call it before
+// the store is shared.
+func (p *PostgresStore) SetMaxHistoryDepth(n int) {
+    p.historyDepth = n
+}
+
+// SetCodecs encodes config data written from now on with codecs, such as to
+// compress or encrypt it. Data written before, or with other codecs the chain
+// holds, stays readable. Call it before the store is shared.
+func (p *PostgresStore) SetCodecs(codecs *CodecChain) {
+    p.codecs = codecs
+}
+
+// SetQueryObserver tells observe about each statement the store runs from
+// now on, such as to time it. Call it before the store is shared.
+func (p *PostgresStore) SetQueryObserver(observe QueryObserver) {
+    p.observe = observe
+}
+
+// marshal returns dna's data column value, encoded by the store's codecs.
+func (p *PostgresStore) marshal(dna *pb.GameDNA) ([]byte, error) {
+    data, err := models.Marshal(dna)
+    if err != nil {
+        return nil, err
+    }
+    return p.codecs.encodeData(data, dna.Genre, EnvironmentOf(dna))
+}
+
+// unmarshal reads a data column value written by marshal.
+func (p *PostgresStore) unmarshal(data []byte) (*pb.GameDNA, error) {
+    plain, err := p.codecs.decodeData(data)
+    if err != nil {
+        return nil, err
+    }
+    return models.Unmarshal(plain)
+}
+
+// pruneHistory drops versions older than the newest p.historyDepth, keeping
+// any a snapshot pins.
+func (p *PostgresStore) pruneHistory(ctx context.Context, q dbtx, configID string) error {
+    if p.historyDepth <= 0 {
+        return nil
+    }
+    _, err := q.ExecContext(ctx, `
+        DELETE FROM game_dna_versions v
+        WHERE v.config_id = $1
+          AND v.version_num <= (SELECT MAX(version_num) FROM game_dna_versions WHERE config_id = $1) - $2
+          AND NOT EXISTS (
+              SELECT 1 FROM game_dna_snapshot_entries e
+              WHERE e.config_id = v.config_id AND e.version_num = v.version_num
+          )
+          AND NOT EXISTS (
+              SELECT 1 FROM game_dna_release_tags t
+              WHERE t.config_id = v.config_id AND t.version_num = v.version_num
+          )
+    `, configID, p.historyDepth)
+    if err != nil {
+        return fmt.Errorf("failed to prune version history: %w", err)
+    }
+    return nil
+}
+
+// Create creates a new GameDNA configuration. The config row and its first
+// version are written in one transaction, so neither is left without the other.
+func (p *PostgresStore) Create(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin create: %w", err)
+    }
+    defer tx.Rollback()
 
-    return &PostgresStore{db: db}, nil
+    created, err := p.create(ctx, tx, dna, applyWriteOptions(opts).validation)
+    if err != nil {
+        return nil, err
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit create: %w", conflictError(err))
+    }
+    return created, nil
 }
 
-// Create creates a new GameDNA configuration.
-func (p *PostgresStore) Create(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+func (p *PostgresStore) create(ctx context.Context, q dbtx, dna *pb.GameDNA, report *pb.ValidationResponse) (*pb.GameDNA, error) {
     if dna.Id == "" {
         dna.Id = uuid.New().String()
     }
-    if dna.CreatedAt == "" {
-        dna.CreatedAt = time.Now().Format(time.RFC3339)
+    if err := models.NormalizeTimestamps(dna); err != nil {
+        return nil, err
+    }
+    now := models.Now()
+    if dna.CreateTime == nil {
+        models.SetCreated(dna, now)
     }
-    if dna.LastModified == "" {
-        dna.LastModified = time.Now().Format(time.RFC3339)
+    if dna.UpdateTime == nil {
+        models.SetModified(dna, now)
     }
     if dna.Version == "" {
         dna.Version = "0.1.0"
     }
+    if dna.Slug == "" {
+        dna.Slug = Slugify(dna.Name)
+    }
+    slug, err := p.uniqueSlug(ctx, q, dna.Slug, dna.Id)
+    if err != nil {
+        return nil, err
+    }
+    dna.Slug = slug
 
-    dataJSON, err := json.Marshal(dna)
+    row, err := models.FromProto(dna)
+    if err != nil {
+        return nil, err
+    }
+    if row.Data, err = p.codecs.encodeData(row.Data, dna.Genre, EnvironmentOf(dna)); err != nil {
+        return nil, err
+    }
+    words, numbers, err := searchColumns(dna)
     if err != nil {
-        return nil, fmt.Errorf("failed to marshal game DNA: %w", err)
+        return nil, err
     }
 
     query := `
-        INSERT INTO game_dna_configs (id, name, version, data, checksum, is_locked, created_at, updated_at, created_by, tags)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        INSERT INTO game_dna_configs (id, name, version, data, checksum, is_locked, created_at, updated_at, created_by, tags, slug,
+            search_document, search_numbers)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, array_to_tsvector($12::text[]), $13)
         RETURNING id
     `
 
-    createdAt, _ := time.Parse(time.RFC3339, dna.CreatedAt)
-    updatedAt, _ := time.Parse(time.RFC3339, dna.LastModified)
-
-    err = p.db.QueryRowContext(
+    err = q.QueryRowContext(
         ctx, query,
-        dna.Id, dna.Name, dna.Version, string(dataJSON), dna.Checksum, dna.IsLocked,
-        createdAt, updatedAt, dna.CreatedBy, pq.Array(dna.Tags),
+        row.ID, row.Name, row.Version, string(row.Data), row.Checksum, row.IsLocked,
+        row.CreatedAt, row.LastModified, row.CreatedBy, pq.Array(row.Tags), row.Slug,
+        words, numbers,
     ).Scan(&dna.Id)
     if err != nil {
-        return nil, fmt.Errorf("failed to create game DNA: %w", err)
+        return nil, fmt.Errorf("failed to create game DNA: %w", conflictError(err))
+    }
+
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, err
     }
 
     // Create initial version snapshot
     versionQuery := `
-        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by)
-        VALUES ($1, 1, $2, $3, $4, $5)
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        VALUES ($1, 1, $2, $3, $4, $5, $6, $7, $8)
     `
-    _, err = p.db.ExecContext(ctx, versionQuery, dna.Id, string(dataJSON), dna.Checksum, createdAt, dna.CreatedBy)
+    _, err = q.ExecContext(ctx, versionQuery, dna.Id, string(row.Data), dna.Checksum, row.CreatedAt, dna.CreatedBy, reportJSON,
+        pq.Array(diff.ChangedFields(nil, dna)), marshalChanges(diff.Changes(nil, dna)))
     if err != nil {
         return nil, fmt.Errorf("failed to create version snapshot: %w", err)
     }
@@ -97,79 +229,175 @@ func (p *PostgresStore) Read(ctx context.Context, id string) (*pb.GameDNA, error
         SELECT data FROM game_dna_configs WHERE id = $1
     `
 
-    var dataJSON string
+    var dataJSON []byte
     err := p.db.QueryRowContext(ctx, query, id).Scan(&dataJSON)
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("config not found: %s", id)
+        return nil, fmt.Errorf("config %s: %w", id, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read game DNA: %w", err)
+    }
+
+    return p.unmarshal(dataJSON)
+}
+
+// ReadBySlug retrieves a GameDNA configuration by its slug.
+func (p *PostgresStore) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+    var dataJSON []byte
+    err := p.db.QueryRowContext(ctx, `SELECT data FROM game_dna_configs WHERE slug = $1`, slug).Scan(&dataJSON)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("config %s: %w", slug, ErrNotFound)
     }
     if err != nil {
         return nil, fmt.Errorf("failed to read game DNA: %w", err)
     }
 
-    var dna pb.GameDNA
-    if err := json.Unmarshal([]byte(dataJSON), &dna); err != nil {
-        return nil, fmt.Errorf("failed to unmarshal game DNA: %w", err)
+    return p.unmarshal(dataJSON)
+}
+
+// slugOwner returns the ID of another config using slug, or "" if it is free.
+func (p *PostgresStore) slugOwner(ctx context.Context, q dbtx, slug string, id string) (string, error) {
+    var owner string
+    err := q.QueryRowContext(ctx, `SELECT id FROM game_dna_configs WHERE slug = $1 AND id <> $2`, slug, id).Scan(&owner)
+    if err == sql.ErrNoRows {
+        return "", nil
+    }
+    if err != nil {
+        return "", fmt.Errorf("failed to check slug: %w", err)
+    }
+    return owner, nil
+}
+
+// uniqueSlug returns slug, or slug with a numeric suffix if another config
+// already uses it.
+func (p *PostgresStore) uniqueSlug(ctx context.Context, q dbtx, slug string, id string) (string, error) {
+    candidate := slug
+    for n := 2; ; n++ {
+        owner, err := p.slugOwner(ctx, q, candidate, id)
+        if err != nil {
+            return "", err
+        }
+        if owner == "" {
+            return candidate, nil
+        }
+        candidate = nextSlug(slug, n)
+    }
+}
+
+// Update updates an existing GameDNA configuration. The config row and its
+// new version are written in one transaction, which also holds the row lock
+// taken to check it, so concurrent updates cannot number versions alike.
+func (p *PostgresStore) Update(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin update: %w", err)
     }
+    defer tx.Rollback()
 
-    return &dna, nil
+    updated, err := p.update(ctx, tx, dna, applyWriteOptions(opts).validation)
+    if err != nil {
+        // ErrNotModified comes with the stored config; nothing was written
+        return updated, err
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit update: %w", conflictError(err))
+    }
+    return updated, nil
 }
 
-// Update updates an existing GameDNA configuration.
-func (p *PostgresStore) Update(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+func (p *PostgresStore) update(ctx context.Context, q dbtx, dna *pb.GameDNA, report *pb.ValidationResponse) (*pb.GameDNA, error) {
     // Check if exists and not locked
     var isLocked bool
-    checkQuery := `SELECT is_locked FROM game_dna_configs WHERE id = $1`
-    err := p.db.QueryRowContext(ctx, checkQuery, dna.Id).Scan(&isLocked)
+    var storedJSON string
+    checkQuery := `SELECT is_locked, data FROM game_dna_configs WHERE id = $1 FOR UPDATE`
+    err := q.QueryRowContext(ctx, checkQuery, dna.Id).Scan(&isLocked, &storedJSON)
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("config not found: %s", dna.Id)
+        return nil, fmt.Errorf("config %s: %w", dna.Id, ErrNotFound)
     }
     if err != nil {
         return nil, fmt.Errorf("failed to check config: %w", err)
     }
     if isLocked {
-        return nil, fmt.Errorf("config is locked: %s", dna.Id)
+        return nil, fmt.Errorf("config %s: %w", dna.Id, ErrLocked)
+    }
+
+    stored, err := p.unmarshal([]byte(storedJSON))
+    if err != nil {
+        return nil, err
+    }
+    if dna.Slug == "" {
+        dna.Slug = stored.Slug
+    }
+    owner, err := p.slugOwner(ctx, q, dna.Slug, dna.Id)
+    if err != nil {
+        return nil, err
+    }
+    if owner != "" {
+        return nil, fmt.Errorf("slug %s is already used by config %s: %w", dna.Slug, owner, ErrConflict)
+    }
+    if unchanged(stored, dna) {
+        return stored, ErrNotModified
     }
 
-    dna.LastModified = time.Now().Format(time.RFC3339)
+    // The creation time is server-managed; whatever the caller sent is ignored.
+    updatedAt := models.Now()
+    models.SetCreated(dna, models.TimeFromProto(stored.CreateTime))
+    models.SetModified(dna, updatedAt)
 
-    dataJSON, err := json.Marshal(dna)
+    row, err := models.FromProto(dna)
+    if err != nil {
+        return nil, err
+    }
+    if row.Data, err = p.codecs.encodeData(row.Data, dna.Genre, EnvironmentOf(dna)); err != nil {
+        return nil, err
+    }
+    words, numbers, err := searchColumns(dna)
     if err != nil {
-        return nil, fmt.Errorf("failed to marshal game DNA: %w", err)
+        return nil, err
     }
 
     updateQuery := `
         UPDATE game_dna_configs
-        SET data = $1, checksum = $2, updated_at = $3, tags = $4, name = $5, version = $6
-        WHERE id = $7
+        SET data = $1, checksum = $2, updated_at = $3, tags = $4, name = $5, version = $6, slug = $7,
+            search_document = array_to_tsvector($9::text[]), search_numbers = $10
+        WHERE id = $8
     `
 
-    updatedAt, _ := time.Parse(time.RFC3339, dna.LastModified)
-
-    _, err = p.db.ExecContext(
+    _, err = q.ExecContext(
         ctx, updateQuery,
-        string(dataJSON), dna.Checksum, updatedAt, pq.Array(dna.Tags), dna.Name, dna.Version, dna.Id,
+        string(row.Data), row.Checksum, row.LastModified, pq.Array(row.Tags), row.Name, row.Version, row.Slug, row.ID,
+        words, numbers,
     )
     if err != nil {
-        return nil, fmt.Errorf("failed to update game DNA: %w", err)
+        return nil, fmt.Errorf("failed to update game DNA: %w", conflictError(err))
     }
 
     // Create new version snapshot
     versionCountQuery := `SELECT COALESCE(MAX(version_num), 0) FROM game_dna_versions WHERE config_id = $1`
     var maxVersion int64
-    err = p.db.QueryRowContext(ctx, versionCountQuery, dna.Id).Scan(&maxVersion)
+    err = q.QueryRowContext(ctx, versionCountQuery, dna.Id).Scan(&maxVersion)
     if err != nil {
         return nil, fmt.Errorf("failed to get version count: %w", err)
     }
 
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, err
+    }
+
     nextVersion := maxVersion + 1
     versionQuery := `
-        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
     `
-    _, err = p.db.ExecContext(ctx, versionQuery, dna.Id, nextVersion, string(dataJSON), dna.Checksum, updatedAt, dna.CreatedBy)
+    _, err = q.ExecContext(ctx, versionQuery, dna.Id, nextVersion, string(row.Data), dna.Checksum, row.LastModified, dna.CreatedBy, reportJSON,
+        pq.Array(diff.ChangedFields(stored, dna)), marshalChanges(diff.Changes(stored, dna)))
     if err != nil {
         return nil, fmt.Errorf("failed to create version snapshot: %w", err)
     }
+    if err := p.pruneHistory(ctx, q, dna.Id); err != nil {
+        return nil, err
+    }
 
     return dna, nil
 }
@@ -187,7 +415,7 @@ func (p *PostgresStore) Delete(ctx context.Context, id string) error {
         return fmt.Errorf("failed to get affected rows: %w", err)
     }
     if rows == 0 {
-        return fmt.Errorf("config not found: %s", id)
+        return fmt.Errorf("config %s: %w", id, ErrNotFound)
     }
 
     return nil
@@ -196,33 +424,14 @@ func (p *PostgresStore) Delete(ctx context.Context, id string) error {
 // List retrieves all GameDNA configurations with filtering and pagination.
 func (p *PostgresStore) List(ctx context.Context, filters ListFilters, pagination Pagination) ([]*pb.GameDNA, int32, error) {
     if pagination.PageSize == 0 {
-        pagination.PageSize = 10
+        pagination.PageSize = DefaultPageSize
     }
     if pagination.Page == 0 {
         pagination.Page = 1
     }
 
-    whereClause := "WHERE 1=1"
-    args := []interface{}{}
-    argCount := 1
-
-    if filters.Genre != "" {
-        whereClause += fmt.Sprintf(" AND data->>'genre' = $%d", argCount)
-        args = append(args, filters.Genre)
-        argCount++
-    }
-
-    if filters.NameFilter != "" {
-        whereClause += fmt.Sprintf(" AND LOWER(name) LIKE LOWER($%d)", argCount)
-        args = append(args, "%"+filters.NameFilter+"%")
-        argCount++
-    }
-
-    if len(filters.Tags) > 0 {
-        whereClause += fmt.Sprintf(" AND tags @> $%d", argCount)
-        args = append(args, pq.Array(filters.Tags))
-        argCount++
-    }
+    whereClause, args := listWhere(filters)
+    argCount := len(args) + 1
 
     // Count total
     countQuery := "SELECT COUNT(*) FROM game_dna_configs " + whereClause
@@ -237,212 +446,3228 @@ func (p *PostgresStore) List(ctx context.Context, filters ListFilters, paginatio
     query := fmt.Sprintf(`
         SELECT data FROM game_dna_configs
         %s
-        ORDER BY created_at DESC
+        ORDER BY created_at DESC, id
         LIMIT $%d OFFSET $%d
     `, whereClause, argCount, argCount+1)
     args = append(args, pagination.PageSize, offset)
 
+    result := make([]*pb.GameDNA, 0, pagination.PageSize)
+    err = p.eachRow(ctx, query, args, func(dna *pb.GameDNA) error {
+        result = append(result, dna)
+        return nil
+    })
+    if err != nil {
+        return nil, 0, err
+    }
+
+    return result, total, nil
+}
+
+// ListAfter returns the configs matching filters after the cursor. It seeks
+// on the created_at index instead of skipping rows, so late pages cost the
+// same as the first.
+func (p *PostgresStore) ListAfter(ctx context.Context, filters ListFilters, after *ListCursor, limit int32) ([]*pb.GameDNA, *ListCursor, error) {
+    if limit <= 0 {
+        limit = DefaultPageSize
+    }
+
+    whereClause, args := listWhere(filters)
+    if after != nil {
+        whereClause += fmt.Sprintf(" AND (created_at < $%d OR (created_at = $%d AND id > $%d))", len(args)+1, len(args)+1, len(args)+2)
+        // created_at keeps microseconds; cursors made from a config's
+        // create_time may carry more
+        args = append(args, after.CreatedAt.Round(time.Microsecond), after.ID)
+    }
+    // One row more than the page tells whether there is a next one
+    query := fmt.Sprintf(`
+        SELECT data, created_at, id FROM game_dna_configs
+        %s
+        ORDER BY created_at DESC, id
+        LIMIT $%d
+    `, whereClause, len(args)+1)
+    args = append(args, limit+1)
+
     rows, err := p.db.QueryContext(ctx, query, args...)
     if err != nil {
-        return nil, 0, fmt.Errorf("failed to list game DNAs: %w", err)
+        return nil, nil, fmt.Errorf("failed to list game DNAs: %w", err)
     }
     defer rows.Close()
 
-    var result []*pb.GameDNA
+    result := make([]*pb.GameDNA, 0, limit)
+    var next *ListCursor
+    var last ListCursor
     for rows.Next() {
-        var dataJSON string
-        if err := rows.Scan(&dataJSON); err != nil {
-            return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+        if int32(len(result)) == limit {
+            next = &last
+            break
         }
-
-        var dna pb.GameDNA
-        if err := json.Unmarshal([]byte(dataJSON), &dna); err != nil {
-            return nil, 0, fmt.Errorf("failed to unmarshal game DNA: %w", err)
+        var data sql.RawBytes
+        if err := rows.Scan(&data, &last.CreatedAt, &last.ID); err != nil {
+            return nil, nil, fmt.Errorf("failed to scan row: %w", err)
         }
-
-        result = append(result, &dna)
+        dna, err := p.unmarshal(data)
+        if err != nil {
+            return nil, nil, err
+        }
+        result = append(result, dna)
     }
-
     if err := rows.Err(); err != nil {
-        return nil, 0, fmt.Errorf("row iteration error: %w", err)
+        return nil, nil, fmt.Errorf("row iteration error: %w", err)
     }
 
-    return result, total, nil
+    return result, next, nil
 }
 
-// GetVersionHistory retrieves the version history for a configuration.
-func (p *PostgresStore) GetVersionHistory(ctx context.Context, configID string) ([]*VersionInfo, error) {
-    query := `
-        SELECT version_num, checksum, created_at, created_by, data
-        FROM game_dna_versions
-        WHERE config_id = $1
-        ORDER BY version_num DESC
-    `
+// Each streams every config matching filters from a single query, so exports
+// don't pay for a COUNT and an ever-deeper OFFSET per page.
+func (p *PostgresStore) Each(ctx context.Context, filters ListFilters, fn func(*pb.GameDNA) error) error {
+    whereClause, args := listWhere(filters)
+    query := `SELECT data FROM game_dna_configs ` + whereClause + ` ORDER BY created_at DESC, id`
+    return p.eachRow(ctx, query, args, fn)
+}
 
-    rows, err := p.db.QueryContext(ctx, query, configID)
+// eachRow runs a query selecting config data and calls fn for each decoded
+// row. Rows are scanned as raw bytes to skip a copy per row.
+func (p *PostgresStore) eachRow(ctx context.Context, query string, args []interface{}, fn func(*pb.GameDNA) error) error {
+    rows, err := p.db.QueryContext(ctx, query, args...)
     if err != nil {
-        return nil, fmt.Errorf("failed to query version history: %w", err)
+        return fmt.Errorf("failed to list game DNAs: %w", err)
     }
     defer rows.Close()
 
-    var versions []*VersionInfo
     for rows.Next() {
-        var v VersionInfo
-        var dataJSON string
-        var createdAt time.Time
-
-        if err := rows.Scan(&v.VersionNum, &v.Checksum, &createdAt, &v.CreatedBy, &dataJSON); err != nil {
-            return nil, fmt.Errorf("failed to scan version row: %w", err)
+        var data sql.RawBytes
+        if err := rows.Scan(&data); err != nil {
+            return fmt.Errorf("failed to scan row: %w", err)
         }
 
-        v.CreatedAt = createdAt.Format(time.RFC3339)
-
-        var dna pb.GameDNA
-        if err := json.Unmarshal([]byte(dataJSON), &dna); err != nil {
-            return nil, fmt.Errorf("failed to unmarshal game DNA: %w", err)
+        dna, err := p.unmarshal(data)
+        if err != nil {
+            return err
+        }
+        if err := fn(dna); err != nil {
+            return err
         }
-        v.Data = &dna
-
-        versions = append(versions, &v)
     }
 
     if err := rows.Err(); err != nil {
-        return nil, fmt.Errorf("row iteration error: %w", err)
+        return fmt.Errorf("row iteration error: %w", err)
     }
-
-    return versions, nil
+    return nil
 }
 
-// RollbackToVersion rolls back a configuration to a previous version.
-func (p *PostgresStore) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
-    query := `
-        SELECT data FROM game_dna_versions
-        WHERE config_id = $1 AND version_num = $2
-    `
-
-    var dataJSON string
-    err := p.db.QueryRowContext(ctx, query, configID, versionNum).Scan(&dataJSON)
-    if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("version not found: %d", versionNum)
+// Search returns a page of the configs matching query, newest first, and
+// how many match in all. Words are matched against the search_document GIN
+// index and predicates against search_numbers.
+func (p *PostgresStore) Search(ctx context.Context, query SearchQuery, pagination Pagination) ([]*pb.GameDNA, int32, error) {
+    if err := query.Validate(); err != nil {
+        return nil, 0, err
     }
-    if err != nil {
-        return nil, fmt.Errorf("failed to read version: %w", err)
+    if pagination.PageSize == 0 {
+        pagination.PageSize = DefaultPageSize
+    }
+    if pagination.Page == 0 {
+        pagination.Page = 1
     }
 
-    var dna pb.GameDNA
-    if err := json.Unmarshal([]byte(dataJSON), &dna); err != nil {
-        return nil, fmt.Errorf("failed to unmarshal game DNA: %w", err)
+    whereClause := "WHERE 1=1"
+    var args []interface{}
+    if words := SearchTokens(query.Text); len(words) > 0 {
+        // Words hold only letters and digits, so quoting them is enough.
+        terms := make([]string, len(words))
+        for i, word := range words {
+            terms[i] = "'" + word + "'"
+        }
+        args = append(args, strings.Join(terms, " & "))
+        whereClause += fmt.Sprintf(" AND search_document @@ $%d::tsquery", len(args))
+    }
+    for _, pred := range query.Predicates {
+        // Validate checked the field against the config's numeric fields.
+        column := fmt.Sprintf("(search_numbers->>'%s')::numeric", pred.Field)
+        args = append(args, pred.Value)
+        if pred.Op == OpBetween {
+            args = append(args, pred.Upper)
+            whereClause += fmt.Sprintf(" AND %s BETWEEN $%d AND $%d", column, len(args)-1, len(args))
+            continue
+        }
+        whereClause += fmt.Sprintf(" AND %s %s $%d", column, pred.Op, len(args))
     }
 
-    // Update with new timestamp and actor
-    dna.LastModified = time.Now().Format(time.RFC3339)
-    if actor != "" {
-        dna.CreatedBy = actor
+    var total int32
+    if err := p.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM game_dna_configs "+whereClause, args...).Scan(&total); err != nil {
+        return nil, 0, fmt.Errorf("failed to count search results: %w", err)
     }
 
-    // Update the main config
-    return p.Update(ctx, &dna)
-}
+    offset := (pagination.Page - 1) * pagination.PageSize
+    searchQuery := fmt.Sprintf(`
+        SELECT data FROM game_dna_configs
+        %s
+        ORDER BY created_at DESC, id
+        LIMIT $%d OFFSET $%d
+    `, whereClause, len(args)+1, len(args)+2)
+    args = append(args, pagination.PageSize, offset)
 
-// PublishVersion locks a configuration and creates an immutable snapshot.
-func (p *PostgresStore) PublishVersion(ctx context.Context, configID string, actor string) (*pb.GameDNA, error) {
-    // Get current config
-    dna, err := p.Read(ctx, configID)
+    result := make([]*pb.GameDNA, 0, pagination.PageSize)
+    err := p.eachRow(ctx, searchQuery, args, func(dna *pb.GameDNA) error {
+        result = append(result, dna)
+        return nil
+    })
     if err != nil {
-        return nil, err
+        return nil, 0, err
     }
+    return result, total, nil
+}
 
-    if dna.IsLocked {
-        return nil, fmt.Errorf("config is already locked: %s", configID)
+// searchColumns returns the search_document words and search_numbers JSON
+// of dna.
+func searchColumns(dna *pb.GameDNA) (interface{}, string, error) {
+    numbers, err := json.Marshal(numericValues(dna))
+    if err != nil {
+        return nil, "", fmt.Errorf("failed to marshal search numbers: %w", err)
     }
+    return pq.Array(documentTokens(dna)), string(numbers), nil
+}
 
-    // Lock the config
-    dna.IsLocked = true
-    dna.LastModified = time.Now().Format(time.RFC3339)
-    if actor != "" {
-        dna.CreatedBy = actor
+// IndexSearchColumns fills in the search columns of configs written before
+// they existed, batchSize at a time, and returns how many it filled. It needs
+// the store's codecs to read encoded data.
+func (p *PostgresStore) IndexSearchColumns(ctx context.Context, batchSize int) (int, error) {
+    indexed := 0
+    for {
+        var batch []*pb.GameDNA
+        err := p.eachRow(ctx, `SELECT data FROM game_dna_configs WHERE search_document IS NULL ORDER BY id LIMIT $1`,
+            []interface{}{batchSize}, func(dna *pb.GameDNA) error {
+                batch = append(batch, dna)
+                return nil
+            })
+        if err != nil {
+            return indexed, err
+        }
+        if len(batch) == 0 {
+            return indexed, nil
+        }
+        for _, dna := range batch {
+            words, numbers, err := searchColumns(dna)
+            if err != nil {
+                return indexed, err
+            }
+            if _, err := p.db.ExecContext(ctx, `
+                UPDATE game_dna_configs SET search_document = array_to_tsvector($2::text[]), search_numbers = $3
+                WHERE id = $1
+            `, dna.Id, words, numbers); err != nil {
+                return indexed, fmt.Errorf("failed to index config %s: %w", dna.Id, err)
+            }
+            indexed++
+        }
     }
+}
 
-    dataJSON, err := json.Marshal(dna)
+// RepairMissingVersions finds configs without any version row and returns
+// their IDs. Before Create wrote the config and its first version in one
+// transaction, a crash between the two could leave such a config. Unless
+// dryRun, each is given a version 1 holding its current content.
+func (p *PostgresStore) RepairMissingVersions(ctx context.Context, dryRun bool) ([]string, error) {
+    query := `
+        SELECT c.id FROM game_dna_configs c
+        WHERE NOT EXISTS (SELECT 1 FROM game_dna_versions v WHERE v.config_id = c.id)
+        ORDER BY c.id
+    `
+    if !dryRun {
+        query = `
+            INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by)
+            SELECT c.id, 1, c.data, c.checksum, c.updated_at, c.created_by FROM game_dna_configs c
+            WHERE NOT EXISTS (SELECT 1 FROM game_dna_versions v WHERE v.config_id = c.id)
+            RETURNING config_id
+        `
+    }
+    rows, err := p.db.QueryContext(ctx, query)
     if err != nil {
-        return nil, fmt.Errorf("failed to marshal game DNA: %w", err)
+        return nil, fmt.Errorf("failed to repair missing versions: %w", err)
     }
+    defer rows.Close()
 
-    updateQuery := `
-        UPDATE game_dna_configs
-        SET is_locked = true, data = $1, updated_at = $2
-        WHERE id = $3
-    `
+    var ids []string
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("failed to scan config ID: %w", err)
+        }
+        ids = append(ids, id)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to repair missing versions: %w", err)
+    }
+    sort.Strings(ids)
+    return ids, nil
+}
 
-    updatedAt, _ := time.Parse(time.RFC3339, dna.LastModified)
-    _, err = p.db.ExecContext(ctx, updateQuery, string(dataJSON), updatedAt, configID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to publish config: %w", err)
+// listWhere builds the WHERE clause and arguments shared by List and Each.
+func listWhere(filters ListFilters) (string, []interface{}) {
+    whereClause := "WHERE 1=1"
+    args := []interface{}{}
+    argCount := 1
+
+    if filters.Genre != "" {
+        whereClause += fmt.Sprintf(" AND data->>'genre' = $%d", argCount)
+        args = append(args, filters.Genre)
+        argCount++
     }
 
-    return dna, nil
-}
+    if filters.NameFilter != "" {
+        whereClause += fmt.Sprintf(" AND LOWER(name) LIKE LOWER($%d)", argCount)
+        args = append(args, "%"+filters.NameFilter+"%")
+        argCount++
+    }
 
-// Clone creates a new configuration based on an existing one.
-func (p *PostgresStore) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
-    original, err := p.Read(ctx, id)
-    if err != nil {
-        return nil, err
+    if filters.PublishedOnly {
+        whereClause += " AND is_locked = true"
     }
 
-    // Create a new ID and reset metadata
-    cloned := &pb.GameDNA{
-        Id:                  uuid.New().String(),
-        Name:                newName,
-        Version:             original.Version,
-        CreatedAt:           time.Now().Format(time.RFC3339),
-        LastModified:        time.Now().Format(time.RFC3339),
-        CreatedBy:           actor,
-        Checksum:            "",
-        IsLocked:            false,
-        Genre:               original.Genre,
-        Camera:              original.Camera,
-        Tone:                original.Tone,
-        WorldScale:          original.WorldScale,
-        TargetPlatforms:     append([]string{}, original.TargetPlatforms...),
-        PhysicsProfile:      original.PhysicsProfile,
-        MaxPlayers:          original.MaxPlayers,
-        IsCompetitive:       original.IsCompetitive,
-        SupportsCoop:        original.SupportsCoop,
-        Difficulty:          original.Difficulty,
-        Monetization:        original.Monetization,
-        TargetAudience:      original.TargetAudience,
-        EsrbRating:          original.EsrbRating,
-        TargetFps:           original.TargetFps,
-        MaxDrawDistance:     original.MaxDrawDistance,
-        MaxEntities:         original.MaxEntities,
-        MaxNpcCount:         original.MaxNpcCount,
-        TimeScale:           original.TimeScale,
-        WeatherEnabled:      original.WeatherEnabled,
-        SeasonsEnabled:      original.SeasonsEnabled,
-        DayNightCycle:       original.DayNightCycle,
-        PersistentWorld:     original.PersistentWorld,
-        NpcCount:            original.NpcCount,
-        AiEnabled:           original.AiEnabled,
-        AiDifficultyScaling: original.AiDifficultyScaling,
-        HasCampaign:         original.HasCampaign,
-        HasSideQuests:       original.HasSideQuests,
-        DynamicQuests:       original.DynamicQuests,
-        Tags:                append([]string{}, original.Tags...),
-        CustomProperties:    make(map[string]string),
-    }
-
-    // Deep copy custom properties
-    for k, v := range original.CustomProperties {
-        cloned.CustomProperties[k] = v
+    if filters.Environment != "" {
+        whereClause += fmt.Sprintf(" AND COALESCE(NULLIF(data->>'environment', ''), '%s') = $%d", EnvironmentDev, argCount)
+        args = append(args, filters.Environment)
+        argCount++
     }
 
-    return p.Create(ctx, cloned)
+    if len(filters.Tags) > 0 {
+        whereClause += fmt.Sprintf(" AND tags @> $%d", argCount)
+        args = append(args, pq.Array(filters.Tags))
+    }
+
+    return whereClause, args
 }
 
-// Close closes the database connection.
-func (p *PostgresStore) Close() {
-    if p.db != nil {
-        p.db.Close()
+// GetVersionHistory retrieves the version history for a configuration.
+func (p *PostgresStore) GetVersionHistory(ctx context.Context, configID string) ([]*VersionInfo, error) {
+    query := `
+        SELECT ` + versionColumns + `
+        FROM game_dna_versions
+        WHERE config_id = $1
+        ORDER BY version_num DESC
+    `
+
+    rows, err := p.db.QueryContext(ctx, query, configID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query version history: %w", err)
+    }
+    defer rows.Close()
+
+    var versions []*VersionInfo
+    for rows.Next() {
+        v, err := p.scanVersion(rows)
+        if err != nil {
+            return nil, err
+        }
+        versions = append(versions, v)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("row iteration error: %w", err)
+    }
+
+    if err := p.rehydrate(ctx, configID, versions); err != nil {
+        return nil, err
+    }
+    return versions, nil
+}
+
+// ListVersions returns a page of configID's versions, newest first.
+func (p *PostgresStore) ListVersions(ctx context.Context, configID string, page VersionPage) ([]*VersionInfo, error) {
+    columns, scan := versionColumns, p.scanVersion
+    if page.WithoutData {
+        columns, scan = versionMetadataColumns, p.scanVersionMetadata
+    }
+    query := `
+        SELECT ` + columns + `
+        FROM game_dna_versions
+        WHERE config_id = $1 AND ($2::bigint = 0 OR version_num < $2)
+        ORDER BY version_num DESC
+    `
+    args := []interface{}{configID, page.Before}
+    if page.Limit > 0 {
+        query += ` LIMIT $3`
+        args = append(args, page.Limit)
+    }
+
+    rows, err := p.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query version history: %w", err)
+    }
+    defer rows.Close()
+
+    var versions []*VersionInfo
+    for rows.Next() {
+        v, err := scan(rows)
+        if err != nil {
+            return nil, err
+        }
+        versions = append(versions, v)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("row iteration error: %w", err)
+    }
+
+    if !page.WithoutData {
+        if err := p.rehydrate(ctx, configID, versions); err != nil {
+            return nil, err
+        }
+    }
+    return versions, nil
+}
+
+// GetVersion returns one version of a config, with its data.
+func (p *PostgresStore) GetVersion(ctx context.Context, configID string, versionNum int64) (*VersionInfo, error) {
+    v, err := p.scanVersion(p.db.QueryRowContext(ctx, `
+        SELECT `+versionColumns+`
+        FROM game_dna_versions
+        WHERE config_id = $1 AND version_num = $2
+    `, configID, versionNum))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("config %s version %d: %w", configID, versionNum, ErrNotFound)
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := p.rehydrate(ctx, configID, []*VersionInfo{v}); err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+// PruneVersionHistory deletes the versions of every config that retention
+// lets go, in one statement, keeping each config's newest version and the
+// versions snapshots pin or release tags name.
+func (p *PostgresStore) PruneVersionHistory(ctx context.Context, retention HistoryRetention) (int64, error) {
+    if retention.MaxVersions <= 0 && retention.CreatedBefore.IsZero() {
+        return 0, nil
+    }
+    createdBefore := sql.NullTime{Time: retention.CreatedBefore, Valid: !retention.CreatedBefore.IsZero()}
+    result, err := p.db.ExecContext(ctx, `
+        DELETE FROM game_dna_versions v
+        USING (
+            SELECT config_id, version_num, created_at,
+                   ROW_NUMBER() OVER (PARTITION BY config_id ORDER BY version_num DESC) AS age
+            FROM game_dna_versions
+        ) r
+        WHERE v.config_id = r.config_id AND v.version_num = r.version_num
+          AND r.age > 1
+          AND (($1::int > 0 AND r.age > $1) OR ($2::timestamptz IS NOT NULL AND r.created_at < $2))
+          AND NOT EXISTS (
+              SELECT 1 FROM game_dna_snapshot_entries e
+              WHERE e.config_id = v.config_id AND e.version_num = v.version_num
+          )
+          AND NOT EXISTS (
+              SELECT 1 FROM game_dna_release_tags t
+              WHERE t.config_id = v.config_id AND t.version_num = v.version_num
+          )
+    `, retention.MaxVersions, createdBefore)
+    if err != nil {
+        return 0, fmt.Errorf("failed to prune version history: %w", err)
+    }
+    return result.RowsAffected()
+}
+
+const versionColumns = `version_num, checksum, created_at, created_by, data, validation, changed_fields, changes, archive_key`
+
+// versionMetadataColumns reads a version without its data, only the size
+// of it, archived or not.
+const versionMetadataColumns = `version_num, checksum, created_at, created_by, validation, changed_fields, changes, COALESCE(octet_length(data), archived_size), archive_key`
+
+// scanVersionMetadata reads a row of versionMetadataColumns.
+func (p *PostgresStore) scanVersionMetadata(row interface{ Scan(...interface{}) error }) (*VersionInfo, error) {
+    var v VersionInfo
+    var validationJSON, changesJSON, archiveKey sql.NullString
+    err := row.Scan(&v.VersionNum, &v.Checksum, &v.CreatedAt, &v.CreatedBy, &validationJSON, pq.Array(&v.ChangedFields), &changesJSON, &v.Size, &archiveKey)
+    if err != nil {
+        return nil, fmt.Errorf("failed to scan version row: %w", err)
+    }
+    v.ArchiveKey = archiveKey.String
+    if v.Changes, err = unmarshalChanges(changesJSON); err != nil {
+        return nil, err
+    }
+    if v.Validation, err = unmarshalValidation(validationJSON); err != nil {
+        return nil, err
+    }
+    v.CreatedAt = v.CreatedAt.UTC()
+    return &v, nil
+}
+
+// scanVersion reads a row of versionColumns. sql.ErrNoRows is returned as is.
+// An archived version is read without its data, and its ArchiveKey set, for
+// rehydrate to fetch.
+func (p *PostgresStore) scanVersion(row interface{ Scan(...interface{}) error }) (*VersionInfo, error) {
+    var v VersionInfo
+    var dataJSON, validationJSON, changesJSON, archiveKey sql.NullString
+    err := row.Scan(&v.VersionNum, &v.Checksum, &v.CreatedAt, &v.CreatedBy, &dataJSON, &validationJSON, pq.Array(&v.ChangedFields), &changesJSON, &archiveKey)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to scan version row: %w", err)
+    }
+    if v.Changes, err = unmarshalChanges(changesJSON); err != nil {
+        return nil, err
+    }
+
+    if v.Validation, err = unmarshalValidation(validationJSON); err != nil {
+        return nil, err
+    }
+
+    v.CreatedAt = v.CreatedAt.UTC()
+    if !dataJSON.Valid && archiveKey.Valid {
+        v.ArchiveKey = archiveKey.String
+        return &v, nil
+    }
+    v.Size = int64(len(dataJSON.String))
+    if v.Data, err = p.unmarshal([]byte(dataJSON.String)); err != nil {
+        return nil, err
+    }
+    return &v, nil
+}
+
+// RollbackToVersion rolls back a configuration to a previous version. The new
+// version carries the target version's validation report.
+func (p *PostgresStore) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error) {
+    query := `
+        SELECT data, validation, archive_key FROM game_dna_versions
+        WHERE config_id = $1 AND version_num = $2
+    `
+
+    var dataJSON, validationJSON, archiveKey sql.NullString
+    err := p.db.QueryRowContext(ctx, query, configID, versionNum).Scan(&dataJSON, &validationJSON, &archiveKey)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("version %d: %w", versionNum, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read version: %w", err)
+    }
+
+    dna, _, err := p.versionData(ctx, configID, versionNum, dataJSON, archiveKey)
+    if err != nil {
+        return nil, err
+    }
+
+    report, err := unmarshalValidation(validationJSON)
+    if err != nil {
+        return nil, err
+    }
+
+    // Update stamps the new modification time; record the actor
+    if actor != "" {
+        dna.CreatedBy = actor
+    }
+    // Keep the current slug if the old one now belongs to another config
+    if owner, err := p.slugOwner(ctx, p.db, dna.Slug, configID); err != nil {
+        return nil, err
+    } else if owner != "" {
+        dna.Slug = ""
+    }
+
+    // Update the main config; rolling back to the current content is a no-op
+    updated, err := p.Update(ctx, dna, WithValidation(report))
+    if errors.Is(err, ErrNotModified) {
+        return updated, nil
+    }
+    return updated, err
+}
+
+// PublishVersion locks a configuration and creates an immutable snapshot
+// carrying the validation report the publish was gated on.
+func (p *PostgresStore) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+    // Get current config
+    dna, err := p.Read(ctx, configID)
+    if err != nil {
+        return nil, err
+    }
+
+    if dna.IsLocked {
+        return nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+    }
+    if dna.Checksum != expectedChecksum {
+        return nil, fmt.Errorf("config %s changed since it was validated: %w", configID, ErrConflict)
+    }
+
+    // Lock the config
+    before := models.Clone(dna)
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    updatedAt := models.Now()
+    models.SetModified(dna, updatedAt)
+    if actor != "" {
+        dna.CreatedBy = actor
+    }
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+
+    dataJSON, err := p.marshal(dna)
+    if err != nil {
+        return nil, err
+    }
+
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin publish: %w", err)
+    }
+    defer tx.Rollback()
+
+    // The row must still hold what was read, or the write would lock
+    // content nobody validated
+    updateQuery := `
+        UPDATE game_dna_configs
+        SET is_locked = true, data = $1, updated_at = $2, checksum = $3
+        WHERE id = $4 AND is_locked = false AND checksum = $5
+    `
+
+    result, err := tx.ExecContext(ctx, updateQuery, string(dataJSON), updatedAt, dna.Checksum, configID, expectedChecksum)
+    if err != nil {
+        return nil, fmt.Errorf("failed to publish config: %w", err)
+    }
+    if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+        // Nothing was updated: tell a concurrent publish from a concurrent edit
+        if latest, err := p.Read(ctx, configID); err == nil && latest.IsLocked {
+            return nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+        }
+        return nil, fmt.Errorf("config %s changed since it was validated: %w", configID, ErrConflict)
+    }
+
+    versionQuery := `
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5, $6, $7, $8
+        FROM game_dna_versions WHERE config_id = $1
+    `
+    _, err = tx.ExecContext(ctx, versionQuery, configID, string(dataJSON), dna.Checksum, updatedAt, dna.CreatedBy, reportJSON,
+        pq.Array(diff.ChangedFields(before, dna)), marshalChanges(diff.Changes(before, dna)))
+    if err != nil {
+        return nil, fmt.Errorf("failed to create published snapshot: %w", err)
+    }
+    if err := p.pruneHistory(ctx, tx, configID); err != nil {
+        return nil, err
+    }
+
+    // Publishing closes any temporary unlock window
+    if _, err := tx.ExecContext(ctx, `DELETE FROM game_dna_unlocks WHERE config_id = $1`, configID); err != nil {
+        return nil, fmt.Errorf("failed to close temporary unlock: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit publish: %w", err)
+    }
+
+    return dna, nil
+}
+
+// UnpublishVersion unlocks a published configuration, records a version and
+// adds the unpublish to the audit log in one transaction.
+func (p *PostgresStore) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *AuditEntry, error) {
+    return p.unlock(ctx, configID, actor, AuditActionUnpublish, reason, time.Time{})
+}
+
+// UnlockTemporarily unpublishes a configuration until expiresAt.
+func (p *PostgresStore) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *AuditEntry, error) {
+    return p.unlock(ctx, configID, actor, AuditActionTemporaryUnlock, reason, expiresAt)
+}
+
+// unlock unlocks a published configuration, records a version and adds an
+// action entry to the audit log in one transaction. A non-zero expiresAt also
+// opens a temporary unlock window.
+func (p *PostgresStore) unlock(ctx context.Context, configID string, actor string, action string, reason string, expiresAt time.Time) (*pb.GameDNA, *AuditEntry, error) {
+    dna, err := p.Read(ctx, configID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if !dna.IsLocked {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotLocked)
+    }
+
+    // The checksum covers content only, so it stays as published
+    before := models.Clone(dna)
+    dna.IsLocked = false
+    dna.PublishedRulesVersion = ""
+    updatedAt := models.Now()
+    models.SetModified(dna, updatedAt)
+
+    dataJSON, err := p.marshal(dna)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to begin unpublish: %w", err)
+    }
+    defer tx.Rollback()
+
+    updateQuery := `
+        UPDATE game_dna_configs
+        SET is_locked = false, data = $1, updated_at = $2
+        WHERE id = $3 AND is_locked = true
+    `
+
+    result, err := tx.ExecContext(ctx, updateQuery, string(dataJSON), updatedAt, configID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to unpublish config: %w", err)
+    }
+    if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotLocked)
+    }
+
+    versionQuery := `
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, changed_fields, changes)
+        SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5, $6, $7
+        FROM game_dna_versions WHERE config_id = $1
+        RETURNING version_num
+    `
+    entry := &AuditEntry{
+        ConfigID: configID,
+        Action:   action,
+        Actor:    actor,
+        Reason:   reason,
+    }
+    err = tx.QueryRowContext(ctx, versionQuery, configID, string(dataJSON), dna.Checksum, updatedAt, actor,
+        pq.Array(diff.ChangedFields(before, dna)), marshalChanges(diff.Changes(before, dna))).Scan(&entry.VersionNum)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create unpublished version: %w", err)
+    }
+    if err := p.pruneHistory(ctx, tx, configID); err != nil {
+        return nil, nil, err
+    }
+
+    auditQuery := `
+        INSERT INTO game_dna_audit_log (config_id, action, actor, reason, version_num, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `
+    entry.CreatedAt = updatedAt
+    err = tx.QueryRowContext(ctx, auditQuery, configID, entry.Action, actor, reason, entry.VersionNum, updatedAt).Scan(&entry.ID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to record audit entry: %w", err)
+    }
+
+    if !expiresAt.IsZero() {
+        _, err = tx.ExecContext(ctx, `
+            INSERT INTO game_dna_unlocks (config_id, actor, reason, created_at, expires_at)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (config_id) DO UPDATE
+            SET actor = EXCLUDED.actor, reason = EXCLUDED.reason,
+                created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+        `, configID, actor, reason, updatedAt, expiresAt)
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to open temporary unlock: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, nil, fmt.Errorf("failed to commit unpublish: %w", err)
+    }
+
+    return dna, entry, nil
+}
+
+// RelockVersion publishes a temporarily unlocked configuration again, closes
+// its window and adds the relock to the audit log in one transaction.
+func (p *PostgresStore) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error) {
+    dna, err := p.Read(ctx, configID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if dna.IsLocked {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+    }
+
+    before := models.Clone(dna)
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    updatedAt := models.Now()
+    models.SetModified(dna, updatedAt)
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+
+    dataJSON, err := p.marshal(dna)
+    if err != nil {
+        return nil, nil, err
+    }
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to begin relock: %w", err)
+    }
+    defer tx.Rollback()
+
+    // Closing the window first makes concurrent relocks of one config fail
+    result, err := tx.ExecContext(ctx, `DELETE FROM game_dna_unlocks WHERE config_id = $1`, configID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to close temporary unlock: %w", err)
+    }
+    if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+        return nil, nil, fmt.Errorf("temporary unlock of config %s: %w", configID, ErrNotFound)
+    }
+
+    result, err = tx.ExecContext(ctx, `
+        UPDATE game_dna_configs
+        SET is_locked = true, data = $1, updated_at = $2, checksum = $3
+        WHERE id = $4 AND is_locked = false
+    `, string(dataJSON), updatedAt, dna.Checksum, configID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to relock config: %w", err)
+    }
+    if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrLocked)
+    }
+
+    entry := &AuditEntry{
+        ConfigID:  configID,
+        Action:    AuditActionRelock,
+        Actor:     actor,
+        Reason:    reason,
+        CreatedAt: updatedAt,
+    }
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5, $6, $7, $8
+        FROM game_dna_versions WHERE config_id = $1
+        RETURNING version_num
+    `, configID, string(dataJSON), dna.Checksum, updatedAt, actor, reportJSON,
+        pq.Array(diff.ChangedFields(before, dna)), marshalChanges(diff.Changes(before, dna))).Scan(&entry.VersionNum)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create relocked version: %w", err)
+    }
+    if err := p.pruneHistory(ctx, tx, configID); err != nil {
+        return nil, nil, err
+    }
+
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_audit_log (config_id, action, actor, reason, version_num, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, configID, entry.Action, actor, reason, entry.VersionNum, updatedAt).Scan(&entry.ID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to record audit entry: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, nil, fmt.Errorf("failed to commit relock: %w", err)
+    }
+
+    return dna, entry, nil
+}
+
+// ListTemporaryUnlocks returns every open window, soonest to close first.
+func (p *PostgresStore) ListTemporaryUnlocks(ctx context.Context) ([]*TemporaryUnlock, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT config_id, actor, reason, created_at, expires_at FROM game_dna_unlocks
+        ORDER BY expires_at, config_id
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list temporary unlocks: %w", err)
+    }
+    defer rows.Close()
+
+    var unlocks []*TemporaryUnlock
+    for rows.Next() {
+        var u TemporaryUnlock
+        if err := rows.Scan(&u.ConfigID, &u.Actor, &u.Reason, &u.CreatedAt, &u.ExpiresAt); err != nil {
+            return nil, fmt.Errorf("failed to scan temporary unlock: %w", err)
+        }
+        unlocks = append(unlocks, &u)
+    }
+    return unlocks, rows.Err()
+}
+
+// canaryColumns lists the columns scanCanary reads, in order.
+const canaryColumns = `config_id, candidate, checksum, base_checksum, percent, max_error_rate, max_error_rate_increase,
+    min_sessions, state, started_by, created_at, ends_at, finished_by, finished_at, reason,
+    candidate_sessions, candidate_errors, stable_sessions, stable_errors`
+
+// StartCanary saves a config's running canary, replacing a finished one.
+func (p *PostgresStore) StartCanary(ctx context.Context, canary *Canary) (*Canary, error) {
+    saved := copyCanary(canary)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    candidate, err := p.marshal(saved.Candidate)
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin canary: %w", err)
+    }
+    defer tx.Rollback()
+
+    var isLocked bool
+    err = tx.QueryRowContext(ctx, `SELECT is_locked FROM game_dna_configs WHERE id = $1 FOR UPDATE`, saved.ConfigID).Scan(&isLocked)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("config %s: %w", saved.ConfigID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to check config: %w", err)
+    }
+    if !isLocked {
+        return nil, fmt.Errorf("config %s: %w", saved.ConfigID, ErrNotLocked)
+    }
+
+    // The config row lock orders concurrent starts, so a running canary
+    // can't appear between the upsert's check and its write.
+    result, err := tx.ExecContext(ctx, `
+        INSERT INTO game_dna_canaries (`+canaryColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULL, NULL, NULL, 0, 0, 0, 0)
+        ON CONFLICT (config_id) DO UPDATE
+        SET candidate = EXCLUDED.candidate, checksum = EXCLUDED.checksum, base_checksum = EXCLUDED.base_checksum,
+            percent = EXCLUDED.percent, max_error_rate = EXCLUDED.max_error_rate,
+            max_error_rate_increase = EXCLUDED.max_error_rate_increase, min_sessions = EXCLUDED.min_sessions,
+            state = EXCLUDED.state, started_by = EXCLUDED.started_by, created_at = EXCLUDED.created_at,
+            ends_at = EXCLUDED.ends_at, finished_by = NULL, finished_at = NULL, reason = NULL,
+            candidate_sessions = 0, candidate_errors = 0, stable_sessions = 0, stable_errors = 0
+        WHERE game_dna_canaries.state <> $9
+    `, saved.ConfigID, string(candidate), saved.Checksum, saved.BaseChecksum, saved.Percent, saved.MaxErrorRate,
+        saved.MaxErrorRateIncrease, saved.MinSessions, CanaryRunning, saved.StartedBy, saved.CreatedAt, saved.EndsAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save canary: %w", err)
+    }
+    if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+        return nil, fmt.Errorf("canary of config %s is already running: %w", saved.ConfigID, ErrConflict)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit canary: %w", err)
+    }
+
+    saved.State = CanaryRunning
+    saved.FinishedBy, saved.FinishedAt, saved.Reason = "", time.Time{}, ""
+    saved.CandidateFeedback, saved.StableFeedback = CanaryArm{}, CanaryArm{}
+    return saved, nil
+}
+
+// GetCanary returns a config's latest canary.
+func (p *PostgresStore) GetCanary(ctx context.Context, configID string) (*Canary, error) {
+    canary, err := p.scanCanary(p.db.QueryRowContext(ctx, `SELECT `+canaryColumns+` FROM game_dna_canaries WHERE config_id = $1`, configID))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("canary of config %s: %w", configID, ErrNotFound)
+    }
+    return canary, err
+}
+
+// ListRunningCanaries returns the running canaries, soonest to end first.
+func (p *PostgresStore) ListRunningCanaries(ctx context.Context) ([]*Canary, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT `+canaryColumns+` FROM game_dna_canaries WHERE state = $1 ORDER BY ends_at, config_id
+    `, CanaryRunning)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list canaries: %w", err)
+    }
+
+    var canaries []*Canary
+    err = eachRow(rows, func() error {
+        canary, err := p.scanCanary(rows)
+        if err == nil {
+            canaries = append(canaries, canary)
+        }
+        return err
+    })
+    return canaries, err
+}
+
+// RecordCanaryFeedback adds game server reports to a running canary.
+func (p *PostgresStore) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*Canary, error) {
+    // One statement, so concurrent reports add up rather than overwrite
+    canary, err := p.scanCanary(p.db.QueryRowContext(ctx, `
+        UPDATE game_dna_canaries
+        SET candidate_sessions = candidate_sessions + CASE WHEN checksum = $2 THEN $3 ELSE 0 END,
+            candidate_errors = candidate_errors + CASE WHEN checksum = $2 THEN $4 ELSE 0 END,
+            stable_sessions = stable_sessions + CASE WHEN checksum <> $2 THEN $3 ELSE 0 END,
+            stable_errors = stable_errors + CASE WHEN checksum <> $2 THEN $4 ELSE 0 END
+        WHERE config_id = $1 AND state = $5 AND (checksum = $2 OR base_checksum = $2)
+        RETURNING `+canaryColumns,
+        configID, checksum, sessions, errors, CanaryRunning))
+    if err != sql.ErrNoRows {
+        return canary, err
+    }
+
+    // Nothing was updated: tell a missing canary from a foreign checksum
+    if _, err := p.runningCanary(ctx, p.db, configID); err != nil {
+        return nil, err
+    }
+    return nil, fmt.Errorf("checksum %s is not served by the canary of config %s: %w", checksum, configID, ErrConflict)
+}
+
+// PromoteCanary replaces a published config's content with its canary's
+// candidate, marks the canary promoted and adds the promotion to the audit
+// log in one transaction.
+func (p *PostgresStore) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *Canary, *AuditEntry, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to begin promotion: %w", err)
+    }
+    defer tx.Rollback()
+
+    var isLocked bool
+    var storedJSON []byte
+    err = tx.QueryRowContext(ctx, `SELECT is_locked, data FROM game_dna_configs WHERE id = $1 FOR UPDATE`, configID).Scan(&isLocked, &storedJSON)
+    if err == sql.ErrNoRows {
+        return nil, nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to check config: %w", err)
+    }
+    canary, err := p.runningCanary(ctx, tx, configID)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    if !isLocked {
+        return nil, nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotLocked)
+    }
+    existing, err := p.unmarshal(storedJSON)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    if existing.Checksum != canary.BaseChecksum {
+        return nil, nil, nil, fmt.Errorf("config %s changed since its canary started: %w", configID, ErrConflict)
+    }
+
+    dna := models.Clone(canary.Candidate)
+    dna.Id = configID
+    dna.Slug = existing.Slug
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+    updatedAt := models.Now()
+    models.SetCreated(dna, models.TimeFromProto(existing.CreateTime))
+    models.SetModified(dna, updatedAt)
+
+    dataJSON, err := p.marshal(dna)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    words, numbers, err := searchColumns(dna)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE game_dna_configs
+        SET data = $1, checksum = $2, updated_at = $3, tags = $4, name = $5, version = $6,
+            search_document = array_to_tsvector($8::text[]), search_numbers = $9
+        WHERE id = $7
+    `, string(dataJSON), dna.Checksum, updatedAt, pq.Array(dna.Tags), dna.Name, dna.Version, configID, words, numbers)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to promote config: %w", conflictError(err))
+    }
+
+    entry := &AuditEntry{
+        ConfigID:  configID,
+        Action:    AuditActionCanaryPromote,
+        Actor:     actor,
+        Reason:    reason,
+        CreatedAt: updatedAt,
+    }
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5, $6, $7, $8
+        FROM game_dna_versions WHERE config_id = $1
+        RETURNING version_num
+    `, configID, string(dataJSON), dna.Checksum, updatedAt, actor, reportJSON,
+        pq.Array(diff.ChangedFields(existing, dna)), marshalChanges(diff.Changes(existing, dna))).Scan(&entry.VersionNum)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to create promoted version: %w", err)
+    }
+    if err := p.pruneHistory(ctx, tx, configID); err != nil {
+        return nil, nil, nil, err
+    }
+
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_audit_log (config_id, action, actor, reason, version_num, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, configID, entry.Action, actor, reason, entry.VersionNum, updatedAt).Scan(&entry.ID)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to record audit entry: %w", err)
+    }
+
+    finished, err := p.finishCanary(ctx, tx, configID, CanaryPromoted, actor, reason, updatedAt)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to commit promotion: %w", err)
+    }
+
+    return dna, finished, entry, nil
+}
+
+// PromoteVersion replaces a configuration's content with promoted content,
+// publishes it and adds the promotion to the audit log in one transaction.
+func (p *PostgresStore) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to begin promotion: %w", err)
+    }
+    defer tx.Rollback()
+
+    var storedJSON []byte
+    err = tx.QueryRowContext(ctx, `SELECT data FROM game_dna_configs WHERE id = $1 FOR UPDATE`, configID).Scan(&storedJSON)
+    if err == sql.ErrNoRows {
+        return nil, nil, fmt.Errorf("config %s: %w", configID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to check config: %w", err)
+    }
+    existing, err := p.unmarshal(storedJSON)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    dna := models.Clone(content)
+    dna.Id = configID
+    dna.Slug = existing.Slug
+    dna.Environment = existing.Environment
+    dna.PromotedFrom = existing.PromotedFrom
+    dna.IsLocked = true
+    dna.PublishedRulesVersion = report.GetRulesVersion()
+    if checksum != "" {
+        dna.Checksum = checksum
+    }
+    updatedAt := models.Now()
+    models.SetCreated(dna, models.TimeFromProto(existing.CreateTime))
+    models.SetModified(dna, updatedAt)
+
+    dataJSON, err := p.marshal(dna)
+    if err != nil {
+        return nil, nil, err
+    }
+    reportJSON, err := marshalValidation(report)
+    if err != nil {
+        return nil, nil, err
+    }
+    words, numbers, err := searchColumns(dna)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE game_dna_configs
+        SET data = $1, checksum = $2, updated_at = $3, tags = $4, name = $5, version = $6, is_locked = true,
+            search_document = array_to_tsvector($8::text[]), search_numbers = $9
+        WHERE id = $7
+    `, string(dataJSON), dna.Checksum, updatedAt, pq.Array(dna.Tags), dna.Name, dna.Version, configID, words, numbers)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to promote config: %w", conflictError(err))
+    }
+
+    entry := &AuditEntry{
+        ConfigID:   configID,
+        Action:     AuditActionPromote,
+        Actor:      actor,
+        Reason:     reason,
+        CreatedAt:  updatedAt,
+        ApprovedBy: approvedBy,
+    }
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+        SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5, $6, $7, $8
+        FROM game_dna_versions WHERE config_id = $1
+        RETURNING version_num
+    `, configID, string(dataJSON), dna.Checksum, updatedAt, actor, reportJSON,
+        pq.Array(diff.ChangedFields(existing, dna)), marshalChanges(diff.Changes(existing, dna))).Scan(&entry.VersionNum)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create promoted version: %w", err)
+    }
+    if err := p.pruneHistory(ctx, tx, configID); err != nil {
+        return nil, nil, err
+    }
+
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_audit_log (config_id, action, actor, reason, version_num, created_at, approved_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `, configID, entry.Action, actor, reason, entry.VersionNum, updatedAt, approvedBy).Scan(&entry.ID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to record audit entry: %w", err)
+    }
+
+    // Publishing closes any temporary unlock window
+    if _, err := tx.ExecContext(ctx, `DELETE FROM game_dna_unlocks WHERE config_id = $1`, configID); err != nil {
+        return nil, nil, fmt.Errorf("failed to close temporary unlock: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, nil, fmt.Errorf("failed to commit promotion: %w", err)
+    }
+
+    return dna, entry, nil
+}
+
+// RollbackCanary finishes a config's running canary without changing the
+// config.
+func (p *PostgresStore) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*Canary, error) {
+    return p.finishCanary(ctx, p.db, configID, CanaryRolledBack, actor, reason, models.Now())
+}
+
+// finishCanary moves a config's running canary to state.
+func (p *PostgresStore) finishCanary(ctx context.Context, q dbtx, configID string, state string, actor string, reason string, at time.Time) (*Canary, error) {
+    canary, err := p.scanCanary(q.QueryRowContext(ctx, `
+        UPDATE game_dna_canaries SET state = $2, finished_by = $3, finished_at = $4, reason = $5
+        WHERE config_id = $1 AND state = $6
+        RETURNING `+canaryColumns,
+        configID, state, actor, at, reason, CanaryRunning))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("running canary of config %s: %w", configID, ErrNotFound)
+    }
+    return canary, err
+}
+
+// runningCanary returns a config's running canary, locking its row.
+func (p *PostgresStore) runningCanary(ctx context.Context, q dbtx, configID string) (*Canary, error) {
+    canary, err := p.scanCanary(q.QueryRowContext(ctx, `
+        SELECT `+canaryColumns+` FROM game_dna_canaries WHERE config_id = $1 AND state = $2 FOR UPDATE
+    `, configID, CanaryRunning))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("running canary of config %s: %w", configID, ErrNotFound)
+    }
+    return canary, err
+}
+
+// scanCanary reads a row of canaryColumns. sql.ErrNoRows is returned as is.
+func (p *PostgresStore) scanCanary(row interface{ Scan(...interface{}) error }) (*Canary, error) {
+    var c Canary
+    var candidate []byte
+    var finishedBy, reason sql.NullString
+    var finishedAt sql.NullTime
+    err := row.Scan(&c.ConfigID, &candidate, &c.Checksum, &c.BaseChecksum, &c.Percent, &c.MaxErrorRate, &c.MaxErrorRateIncrease,
+        &c.MinSessions, &c.State, &c.StartedBy, &c.CreatedAt, &c.EndsAt, &finishedBy, &finishedAt, &reason,
+        &c.CandidateFeedback.Sessions, &c.CandidateFeedback.Errors, &c.StableFeedback.Sessions, &c.StableFeedback.Errors)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read canary: %w", err)
+    }
+
+    if c.Candidate, err = p.unmarshal(candidate); err != nil {
+        return nil, err
+    }
+    c.CreatedAt = c.CreatedAt.UTC()
+    c.EndsAt = c.EndsAt.UTC()
+    c.FinishedBy = finishedBy.String
+    if finishedAt.Valid {
+        c.FinishedAt = finishedAt.Time.UTC()
+    }
+    c.Reason = reason.String
+    return &c, nil
+}
+
+const releaseTagColumns = `config_id, tag, version_num, created_by, created_at`
+
+// TagVersion points a release tag at a version. The version row is locked so
+// history pruning can't remove it before the tag names it.
+func (p *PostgresStore) TagVersion(ctx context.Context, tag *ReleaseTag, move bool) (*ReleaseTag, int64, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to begin tagging: %w", err)
+    }
+    defer tx.Rollback()
+
+    var exists int
+    err = tx.QueryRowContext(ctx, `
+        SELECT 1 FROM game_dna_versions WHERE config_id = $1 AND version_num = $2 FOR SHARE
+    `, tag.ConfigID, tag.VersionNum).Scan(&exists)
+    if err == sql.ErrNoRows {
+        return nil, 0, fmt.Errorf("version %d of config %s: %w", tag.VersionNum, tag.ConfigID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to find version: %w", err)
+    }
+
+    existing, err := scanReleaseTag(tx.QueryRowContext(ctx, `
+        SELECT `+releaseTagColumns+` FROM game_dna_release_tags WHERE config_id = $1 AND tag = $2 FOR UPDATE
+    `, tag.ConfigID, tag.Tag))
+    var previous int64
+    switch {
+    case err == sql.ErrNoRows:
+    case err != nil:
+        return nil, 0, err
+    case existing.VersionNum == tag.VersionNum:
+        return existing, existing.VersionNum, nil
+    case !move:
+        return nil, 0, fmt.Errorf("release tag %s of config %s names version %d: %w", tag.Tag, tag.ConfigID, existing.VersionNum, ErrConflict)
+    default:
+        previous = existing.VersionNum
+    }
+
+    saved := *tag
+    saved.CreatedAt = models.Now()
+    _, err = tx.ExecContext(ctx, `
+        INSERT INTO game_dna_release_tags (`+releaseTagColumns+`)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (config_id, tag) DO UPDATE
+        SET version_num = EXCLUDED.version_num, created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at
+    `, saved.ConfigID, saved.Tag, saved.VersionNum, saved.CreatedBy, saved.CreatedAt)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to save release tag: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, 0, fmt.Errorf("failed to commit release tag: %w", err)
+    }
+    return &saved, previous, nil
+}
+
+// GetTaggedVersion returns the version a release tag names.
+func (p *PostgresStore) GetTaggedVersion(ctx context.Context, configID string, tag string) (*VersionInfo, error) {
+    v, err := p.scanVersion(p.db.QueryRowContext(ctx, `
+        SELECT v.`+strings.ReplaceAll(versionColumns, ", ", ", v.")+`
+        FROM game_dna_release_tags t
+        JOIN game_dna_versions v ON v.config_id = t.config_id AND v.version_num = t.version_num
+        WHERE t.config_id = $1 AND t.tag = $2
+    `, configID, tag))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("release tag %s of config %s: %w", tag, configID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := p.rehydrate(ctx, configID, []*VersionInfo{v}); err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+// ListReleaseTags returns a config's release tags, newest version first.
+func (p *PostgresStore) ListReleaseTags(ctx context.Context, configID string) ([]*ReleaseTag, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT `+releaseTagColumns+` FROM game_dna_release_tags
+        WHERE config_id = $1 ORDER BY version_num DESC, tag
+    `, configID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list release tags: %w", err)
+    }
+    tags := []*ReleaseTag{}
+    err = eachRow(rows, func() error {
+        tag, err := scanReleaseTag(rows)
+        if err == nil {
+            tags = append(tags, tag)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    return tags, nil
+}
+
+// scanReleaseTag reads a row of releaseTagColumns. sql.ErrNoRows is returned
+// as is.
+func scanReleaseTag(row interface{ Scan(...interface{}) error }) (*ReleaseTag, error) {
+    var t ReleaseTag
+    err := row.Scan(&t.ConfigID, &t.Tag, &t.VersionNum, &t.CreatedBy, &t.CreatedAt)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read release tag: %w", err)
+    }
+    t.CreatedAt = t.CreatedAt.UTC()
+    return &t, nil
+}
+
+// ListAuditEntries returns the audit log of a configuration.
+func (p *PostgresStore) ListAuditEntries(ctx context.Context, configID string) ([]*AuditEntry, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT id, action, actor, reason, version_num, created_at, approved_by FROM game_dna_audit_log
+        WHERE config_id = $1 ORDER BY id
+    `, configID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list audit entries: %w", err)
+    }
+    defer rows.Close()
+
+    var entries []*AuditEntry
+    for rows.Next() {
+        entry := &AuditEntry{ConfigID: configID}
+        if err := rows.Scan(&entry.ID, &entry.Action, &entry.Actor, &entry.Reason, &entry.VersionNum, &entry.CreatedAt, &entry.ApprovedBy); err != nil {
+            return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+        }
+        entries = append(entries, entry)
+    }
+    return entries, rows.Err()
+}
+
+// Clone creates a new configuration based on an existing one.
+func (p *PostgresStore) Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error) {
+    original, err := p.Read(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+
+    // Copy the content; Create assigns a new ID, slug and timestamps
+    cloned := models.Clone(original)
+    models.ClearServerFields(cloned)
+    cloned.Slug = ""
+    cloned.Name = newName
+    cloned.CreatedBy = actor
+
+    return p.Create(ctx, cloned)
+}
+
+// SaveSet applies a group of creates and updates in a single transaction.
+func (p *PostgresStore) SaveSet(ctx context.Context, set SaveSet) (*SaveSet, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin save set: %w", err)
+    }
+    defer tx.Rollback()
+
+    result := &SaveSet{}
+    for i, dna := range set.Creates {
+        created, err := p.create(ctx, tx, dna, reportAt(set.CreateReports, i))
+        if err != nil {
+            return nil, err
+        }
+        result.Creates = append(result.Creates, created)
+    }
+    for i, dna := range set.Updates {
+        updated, err := p.update(ctx, tx, dna, reportAt(set.UpdateReports, i))
+        if errors.Is(err, ErrNotModified) {
+            result.Unchanged = append(result.Unchanged, updated)
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        result.Updates = append(result.Updates, updated)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit save set: %w", err)
+    }
+
+    return result, nil
+}
+
+// ImportConfig stores a config exported from another deployment under its own
+// ID, with versions as its history, in one transaction. Without versions the
+// config is recorded as version 1.
+func (p *PostgresStore) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*VersionInfo) (*pb.GameDNA, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin import: %w", err)
+    }
+    defer tx.Rollback()
+
+    created, err := p.create(ctx, tx, dna, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(versions) > 0 {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM game_dna_versions WHERE config_id = $1`, created.Id); err != nil {
+            return nil, fmt.Errorf("failed to replace version history: %w", err)
+        }
+        for _, v := range versions {
+            dataJSON, err := p.marshal(v.Data)
+            if err != nil {
+                return nil, err
+            }
+            reportJSON, err := marshalValidation(v.Validation)
+            if err != nil {
+                return nil, err
+            }
+            _, err = tx.ExecContext(ctx, `
+                INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            `, created.Id, v.VersionNum, string(dataJSON), v.Checksum, v.CreatedAt, v.CreatedBy, reportJSON, pq.Array(v.ChangedFields), marshalChanges(v.Changes))
+            if err != nil {
+                return nil, fmt.Errorf("failed to import version %d of %s: %w", v.VersionNum, created.Id, conflictError(err))
+            }
+        }
+        if err := p.pruneHistory(ctx, tx, created.Id); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit import: %w", err)
+    }
+
+    return created, nil
+}
+
+// CreateSnapshot records the given config versions under a release name.
+// Entries with a zero VersionNum capture the config's latest version.
+func (p *PostgresStore) CreateSnapshot(ctx context.Context, snapshot *Snapshot) (*Snapshot, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin snapshot: %w", err)
+    }
+    defer tx.Rollback()
+
+    stored := &Snapshot{
+        Name:        snapshot.Name,
+        Description: snapshot.Description,
+        CreatedBy:   snapshot.CreatedBy,
+    }
+
+    var createdAt time.Time
+    err = tx.QueryRowContext(ctx, `
+        INSERT INTO game_dna_snapshots (name, description, created_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (name) DO NOTHING
+        RETURNING created_at
+    `, snapshot.Name, snapshot.Description, snapshot.CreatedBy).Scan(&createdAt)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("snapshot %s already exists: %w", snapshot.Name, ErrConflict)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to create snapshot: %w", err)
+    }
+    stored.CreatedAt = createdAt.Format(time.RFC3339)
+
+    for _, entry := range snapshot.Entries {
+        var versionNum int64
+        err := tx.QueryRowContext(ctx, `
+            SELECT version_num FROM game_dna_versions
+            WHERE config_id = $1 AND ($2 = 0 OR version_num = $2)
+            ORDER BY version_num DESC
+            LIMIT 1
+        `, entry.ConfigID, entry.VersionNum).Scan(&versionNum)
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("version %s@%d: %w", entry.ConfigID, entry.VersionNum, ErrNotFound)
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to resolve snapshot version: %w", err)
+        }
+
+        _, err = tx.ExecContext(ctx, `
+            INSERT INTO game_dna_snapshot_entries (snapshot_name, config_id, version_num)
+            VALUES ($1, $2, $3)
+        `, snapshot.Name, entry.ConfigID, versionNum)
+        if err != nil {
+            return nil, fmt.Errorf("failed to record snapshot entry: %w", err)
+        }
+        stored.Entries = append(stored.Entries, SnapshotEntry{ConfigID: entry.ConfigID, VersionNum: versionNum})
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit snapshot: %w", err)
+    }
+
+    return stored, nil
+}
+
+// GetSnapshot retrieves a snapshot by name. When withData is set, each entry
+// carries the config as it was at the captured version.
+func (p *PostgresStore) GetSnapshot(ctx context.Context, name string, withData bool) (*Snapshot, error) {
+    var snapshot Snapshot
+    var createdAt time.Time
+    var createdBy sql.NullString
+    err := p.db.QueryRowContext(ctx, `
+        SELECT name, description, created_at, created_by FROM game_dna_snapshots WHERE name = $1
+    `, name).Scan(&snapshot.Name, &snapshot.Description, &createdAt, &createdBy)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("snapshot %s: %w", name, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read snapshot: %w", err)
+    }
+    snapshot.CreatedAt = createdAt.Format(time.RFC3339)
+    snapshot.CreatedBy = createdBy.String
+
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT e.config_id, e.version_num, v.data, v.archive_key
+        FROM game_dna_snapshot_entries e
+        LEFT JOIN game_dna_versions v ON v.config_id = e.config_id AND v.version_num = e.version_num
+        WHERE e.snapshot_name = $1
+        ORDER BY e.config_id
+    `, name)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query snapshot entries: %w", err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var entry SnapshotEntry
+        var dataJSON, archiveKey sql.NullString
+        if err := rows.Scan(&entry.ConfigID, &entry.VersionNum, &dataJSON, &archiveKey); err != nil {
+            return nil, fmt.Errorf("failed to scan snapshot entry: %w", err)
+        }
+        if withData {
+            if !dataJSON.Valid && !archiveKey.Valid {
+                return nil, fmt.Errorf("version %s@%d: %w", entry.ConfigID, entry.VersionNum, ErrNotFound)
+            }
+            if entry.Data, _, err = p.versionData(ctx, entry.ConfigID, entry.VersionNum, dataJSON, archiveKey); err != nil {
+                return nil, err
+            }
+        }
+        snapshot.Entries = append(snapshot.Entries, entry)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("row iteration error: %w", err)
+    }
+
+    return &snapshot, nil
+}
+
+// ListSnapshots returns all snapshots without config data, newest first.
+func (p *PostgresStore) ListSnapshots(ctx context.Context) ([]*Snapshot, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT s.name, s.description, s.created_at, s.created_by, e.config_id, e.version_num
+        FROM game_dna_snapshots s
+        LEFT JOIN game_dna_snapshot_entries e ON e.snapshot_name = s.name
+        ORDER BY s.created_at DESC, s.name, e.config_id
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query snapshots: %w", err)
+    }
+    defer rows.Close()
+
+    var snapshots []*Snapshot
+    byName := make(map[string]*Snapshot)
+    for rows.Next() {
+        var name, description string
+        var createdAt time.Time
+        var createdBy, configID sql.NullString
+        var versionNum sql.NullInt64
+        if err := rows.Scan(&name, &description, &createdAt, &createdBy, &configID, &versionNum); err != nil {
+            return nil, fmt.Errorf("failed to scan snapshot row: %w", err)
+        }
+
+        snapshot, exists := byName[name]
+        if !exists {
+            snapshot = &Snapshot{
+                Name:        name,
+                Description: description,
+                CreatedAt:   createdAt.Format(time.RFC3339),
+                CreatedBy:   createdBy.String,
+            }
+            byName[name] = snapshot
+            snapshots = append(snapshots, snapshot)
+        }
+        if configID.Valid {
+            snapshot.Entries = append(snapshot.Entries, SnapshotEntry{ConfigID: configID.String, VersionNum: versionNum.Int64})
+        }
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("row iteration error: %w", err)
+    }
+
+    return snapshots, nil
+}
+
+// RestoreSnapshot rolls every config in the snapshot back to its captured
+// version inside a single transaction.
+func (p *PostgresStore) RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error) {
+    snapshot, err := p.GetSnapshot(ctx, name, true)
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin restore: %w", err)
+    }
+    defer tx.Rollback()
+
+    var restored []*pb.GameDNA
+    for _, entry := range snapshot.Entries {
+        var validationJSON sql.NullString
+        err := tx.QueryRowContext(ctx, `
+            SELECT validation FROM game_dna_versions WHERE config_id = $1 AND version_num = $2
+        `, entry.ConfigID, entry.VersionNum).Scan(&validationJSON)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read version: %w", err)
+        }
+        report, err := unmarshalValidation(validationJSON)
+        if err != nil {
+            return nil, err
+        }
+
+        dna := entry.Data
+        if actor != "" {
+            dna.CreatedBy = actor
+        }
+        if owner, err := p.slugOwner(ctx, tx, dna.Slug, dna.Id); err != nil {
+            return nil, err
+        } else if owner != "" {
+            dna.Slug = ""
+        }
+
+        updated, err := p.update(ctx, tx, dna, report)
+        if err != nil && !errors.Is(err, ErrNotModified) {
+            return nil, err
+        }
+        restored = append(restored, updated)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit restore: %w", err)
+    }
+
+    return restored, nil
+}
+
+// TransferOwnership reassigns all configs and webhooks created by from to to
+// in one transaction. Each moved config gets a version carrying the previous
+// version's validation report, and an audit entry.
+func (p *PostgresStore) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*OwnershipTransfer, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin ownership transfer: %w", err)
+    }
+    defer tx.Rollback()
+
+    rows, err := tx.QueryContext(ctx, `
+        SELECT data FROM game_dna_configs
+        WHERE created_by = $1
+        ORDER BY created_at, id
+        FOR UPDATE
+    `, from)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list owned configs: %w", err)
+    }
+
+    var owned []*pb.GameDNA
+    for rows.Next() {
+        var dataJSON string
+        if err := rows.Scan(&dataJSON); err != nil {
+            rows.Close()
+            return nil, fmt.Errorf("failed to scan config: %w", err)
+        }
+        dna, err := p.unmarshal([]byte(dataJSON))
+        if err != nil {
+            rows.Close()
+            return nil, err
+        }
+        owned = append(owned, dna)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to list owned configs: %w", err)
+    }
+
+    rows, err = tx.QueryContext(ctx, `SELECT `+webhookColumns+` FROM game_dna_webhooks WHERE created_by = $1 ORDER BY created_at, id FOR UPDATE`, from)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list owned webhooks: %w", err)
+    }
+    var webhooks []*Webhook
+    err = eachRow(rows, func() error {
+        webhook, err := scanWebhook(rows)
+        if err == nil {
+            webhooks = append(webhooks, webhook)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if dryRun {
+        return &OwnershipTransfer{Configs: owned, Webhooks: webhooks}, nil
+    }
+
+    updatedAt := models.Now()
+    for _, dna := range owned {
+        dna.CreatedBy = to
+        models.SetModified(dna, updatedAt)
+
+        dataJSON, err := p.marshal(dna)
+        if err != nil {
+            return nil, err
+        }
+
+        _, err = tx.ExecContext(ctx, `
+            UPDATE game_dna_configs
+            SET data = $1, created_by = $2, updated_at = $3
+            WHERE id = $4
+        `, string(dataJSON), to, updatedAt, dna.Id)
+        if err != nil {
+            return nil, fmt.Errorf("failed to transfer config %s: %w", dna.Id, err)
+        }
+
+        // created_by is not a tracked field, so the version changes no fields
+        var versionNum int64
+        err = tx.QueryRowContext(ctx, `
+            INSERT INTO game_dna_versions (config_id, version_num, data, checksum, created_at, created_by, validation, changed_fields, changes)
+            SELECT $1, COALESCE(MAX(version_num), 0) + 1, $2, $3, $4, $5,
+                (SELECT validation FROM game_dna_versions WHERE config_id = $1 ORDER BY version_num DESC LIMIT 1),
+                '{}', '[]'
+            FROM game_dna_versions WHERE config_id = $1
+            RETURNING version_num
+        `, dna.Id, string(dataJSON), dna.Checksum, updatedAt, to).Scan(&versionNum)
+        if err != nil {
+            return nil, fmt.Errorf("failed to record transfer of %s: %w", dna.Id, err)
+        }
+        if err := p.pruneHistory(ctx, tx, dna.Id); err != nil {
+            return nil, err
+        }
+
+        _, err = tx.ExecContext(ctx, `
+            INSERT INTO game_dna_audit_log (config_id, action, actor, reason, version_num, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6)
+        `, dna.Id, AuditActionTransfer, actor, fmt.Sprintf("from %s to %s", from, to), versionNum, updatedAt)
+        if err != nil {
+            return nil, fmt.Errorf("failed to record audit entry: %w", err)
+        }
+    }
+
+    if _, err := tx.ExecContext(ctx, `UPDATE game_dna_webhooks SET created_by = $2 WHERE created_by = $1`, from, to); err != nil {
+        return nil, fmt.Errorf("failed to transfer webhooks: %w", err)
+    }
+    for _, webhook := range webhooks {
+        webhook.CreatedBy = to
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit ownership transfer: %w", err)
+    }
+
+    return &OwnershipTransfer{Configs: owned, Webhooks: webhooks}, nil
+}
+
+// SaveImportMapping creates or replaces a project's import mapping.
+func (p *PostgresStore) SaveImportMapping(ctx context.Context, mapping *ImportMapping) (*ImportMapping, error) {
+    columnsJSON, err := json.Marshal(mapping.Columns)
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal import mapping: %w", err)
+    }
+
+    var updatedAt time.Time
+    err = p.db.QueryRowContext(ctx, `
+        INSERT INTO game_dna_import_mappings (project, columns, updated_at, updated_by)
+        VALUES ($1, $2, NOW(), $3)
+        ON CONFLICT (project) DO UPDATE
+        SET columns = EXCLUDED.columns, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by
+        RETURNING updated_at
+    `, mapping.Project, string(columnsJSON), mapping.UpdatedBy).Scan(&updatedAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save import mapping: %w", err)
+    }
+
+    saved := *mapping
+    saved.UpdatedAt = updatedAt.Format(time.RFC3339)
+    return &saved, nil
+}
+
+// GetImportMapping retrieves a project's import mapping.
+func (p *PostgresStore) GetImportMapping(ctx context.Context, project string) (*ImportMapping, error) {
+    mapping := ImportMapping{Project: project}
+    var columnsJSON string
+    var updatedAt time.Time
+    var updatedBy sql.NullString
+    err := p.db.QueryRowContext(ctx, `
+        SELECT columns, updated_at, updated_by FROM game_dna_import_mappings WHERE project = $1
+    `, project).Scan(&columnsJSON, &updatedAt, &updatedBy)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("import mapping for project %s: %w", project, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read import mapping: %w", err)
+    }
+
+    if err := json.Unmarshal([]byte(columnsJSON), &mapping.Columns); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal import mapping: %w", err)
+    }
+    mapping.UpdatedAt = updatedAt.Format(time.RFC3339)
+    mapping.UpdatedBy = updatedBy.String
+    return &mapping, nil
+}
+
+// apiTokenColumns lists the columns scanAPIToken reads, in order.
+const apiTokenColumns = `id, name, slugs, secret_hash, previous_hash, previous_expires_at, created_at, created_by, rotated_at, revoked_at, tenant`
+
+// SaveAPIToken creates or replaces an API token.
+func (p *PostgresStore) SaveAPIToken(ctx context.Context, token *APIToken) (*APIToken, error) {
+    saved := *token
+    saved.Slugs = append([]string(nil), token.Slugs...)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+
+    _, err := p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_api_tokens (`+apiTokenColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        ON CONFLICT (id) DO UPDATE
+        SET name = EXCLUDED.name, slugs = EXCLUDED.slugs, secret_hash = EXCLUDED.secret_hash,
+            previous_hash = EXCLUDED.previous_hash, previous_expires_at = EXCLUDED.previous_expires_at,
+            rotated_at = EXCLUDED.rotated_at, revoked_at = EXCLUDED.revoked_at
+    `, saved.ID, saved.Name, pq.Array(saved.Slugs), saved.SecretHash, nullString(saved.PreviousHash),
+        nullTime(saved.PreviousExpiresAt), saved.CreatedAt, saved.CreatedBy, nullTime(saved.RotatedAt), nullTime(saved.RevokedAt), saved.Tenant)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save API token: %w", conflictError(err))
+    }
+
+    return &saved, nil
+}
+
+// GetAPIToken retrieves an API token by ID.
+func (p *PostgresStore) GetAPIToken(ctx context.Context, id string) (*APIToken, error) {
+    row := p.db.QueryRowContext(ctx, `SELECT `+apiTokenColumns+` FROM game_dna_api_tokens WHERE id = $1`, id)
+    token, err := scanAPIToken(row)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("API token %s: %w", id, ErrNotFound)
+    }
+    return token, err
+}
+
+// FindAPIToken retrieves the API token with a current or previous secret hash.
+func (p *PostgresStore) FindAPIToken(ctx context.Context, hash string) (*APIToken, error) {
+    row := p.db.QueryRowContext(ctx, `
+        SELECT `+apiTokenColumns+` FROM game_dna_api_tokens
+        WHERE secret_hash = $1 OR previous_hash = $1
+        LIMIT 1
+    `, hash)
+    token, err := scanAPIToken(row)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("API token: %w", ErrNotFound)
+    }
+    return token, err
+}
+
+// ListAPITokens returns all API tokens, oldest first.
+func (p *PostgresStore) ListAPITokens(ctx context.Context) ([]*APIToken, error) {
+    rows, err := p.db.QueryContext(ctx, `SELECT `+apiTokenColumns+` FROM game_dna_api_tokens ORDER BY created_at, id`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list API tokens: %w", err)
+    }
+    defer rows.Close()
+
+    var tokens []*APIToken
+    for rows.Next() {
+        token, err := scanAPIToken(rows)
+        if err != nil {
+            return nil, err
+        }
+        tokens = append(tokens, token)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to list API tokens: %w", err)
+    }
+    return tokens, nil
+}
+
+// scanAPIToken reads a row of apiTokenColumns. sql.ErrNoRows is returned as is.
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*APIToken, error) {
+    var token APIToken
+    var previousHash, createdBy sql.NullString
+    var previousExpiresAt, rotatedAt, revokedAt sql.NullTime
+    err := row.Scan(&token.ID, &token.Name, pq.Array(&token.Slugs), &token.SecretHash, &previousHash,
+        &previousExpiresAt, &token.CreatedAt, &createdBy, &rotatedAt, &revokedAt, &token.Tenant)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read API token: %w", err)
+    }
+    token.PreviousHash = previousHash.String
+    token.PreviousExpiresAt = previousExpiresAt.Time
+    token.CreatedBy = createdBy.String
+    token.RotatedAt = rotatedAt.Time
+    token.RevokedAt = revokedAt.Time
+    return &token, nil
+}
+
+// SaveDraft creates or replaces a draft.
+func (p *PostgresStore) SaveDraft(ctx context.Context, draft *Draft) (*Draft, error) {
+    data, err := p.marshal(draft.Data)
+    if err != nil {
+        return nil, err
+    }
+    saved := *draft
+    saved.Data = models.Clone(draft.Data)
+    saved.UpdatedAt = models.Now()
+
+    // Selecting from the config makes a missing config insert nothing.
+    err = p.db.QueryRowContext(ctx, `
+        INSERT INTO game_dna_drafts (config_id, owner, data, base_checksum, created_at, updated_at)
+        SELECT id, $2, $3, $4, $5, $5 FROM game_dna_configs WHERE id = $1
+        ON CONFLICT (config_id, owner) DO UPDATE
+        SET data = EXCLUDED.data, base_checksum = EXCLUDED.base_checksum, updated_at = EXCLUDED.updated_at
+        RETURNING created_at
+    `, saved.ConfigID, saved.Owner, string(data), saved.BaseChecksum, saved.UpdatedAt).Scan(&saved.CreatedAt)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("config %s: %w", draft.ConfigID, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to save draft: %w", err)
+    }
+
+    return &saved, nil
+}
+
+// GetDraft retrieves owner's draft of a config.
+func (p *PostgresStore) GetDraft(ctx context.Context, configID, owner string) (*Draft, error) {
+    draft := Draft{ConfigID: configID, Owner: owner}
+    var data []byte
+    err := p.db.QueryRowContext(ctx, `
+        SELECT data, base_checksum, created_at, updated_at FROM game_dna_drafts
+        WHERE config_id = $1 AND owner = $2
+    `, configID, owner).Scan(&data, &draft.BaseChecksum, &draft.CreatedAt, &draft.UpdatedAt)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("draft of config %s by %s: %w", configID, owner, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read draft: %w", err)
+    }
+
+    if draft.Data, err = p.unmarshal(data); err != nil {
+        return nil, err
+    }
+    return &draft, nil
+}
+
+// DeleteDraft deletes owner's draft of a config.
+func (p *PostgresStore) DeleteDraft(ctx context.Context, configID, owner string) error {
+    result, err := p.db.ExecContext(ctx, `DELETE FROM game_dna_drafts WHERE config_id = $1 AND owner = $2`, configID, owner)
+    if err != nil {
+        return fmt.Errorf("failed to delete draft: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("draft of config %s by %s: %w", configID, owner, ErrNotFound)
+    }
+
+    return nil
+}
+
+const waiverColumns = `id, config_id, code, field, justification, approver, created_at, expires_at, revoked_at, revoked_by`
+
+// SaveWaiver creates or replaces a validation waiver.
+func (p *PostgresStore) SaveWaiver(ctx context.Context, waiver *Waiver) (*Waiver, error) {
+    saved := *waiver
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+
+    // Only insert for a config that exists; the table has no foreign key
+    result, err := p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_waivers (`+waiverColumns+`)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+        WHERE EXISTS (SELECT 1 FROM game_dna_configs WHERE id = $2)
+        ON CONFLICT (id) DO UPDATE
+        SET justification = EXCLUDED.justification, expires_at = EXCLUDED.expires_at,
+            revoked_at = EXCLUDED.revoked_at, revoked_by = EXCLUDED.revoked_by
+    `, saved.ID, saved.ConfigID, saved.Code, saved.Field, saved.Justification, saved.Approver,
+        saved.CreatedAt, saved.ExpiresAt, nullTime(saved.RevokedAt), nullString(saved.RevokedBy))
+    if err != nil {
+        return nil, fmt.Errorf("failed to save waiver: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return nil, fmt.Errorf("config %s: %w", saved.ConfigID, ErrNotFound)
+    }
+
+    return &saved, nil
+}
+
+// ListWaivers returns every validation waiver of a configuration.
+func (p *PostgresStore) ListWaivers(ctx context.Context, configID string) ([]*Waiver, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT `+waiverColumns+` FROM game_dna_waivers
+        WHERE config_id = $1 ORDER BY created_at, id
+    `, configID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list waivers: %w", err)
+    }
+    defer rows.Close()
+
+    var waivers []*Waiver
+    for rows.Next() {
+        w, err := scanWaiver(rows)
+        if err != nil {
+            return nil, err
+        }
+        waivers = append(waivers, w)
+    }
+    return waivers, rows.Err()
+}
+
+// scanWaiver reads a row of waiverColumns.
+func scanWaiver(row interface{ Scan(...interface{}) error }) (*Waiver, error) {
+    var w Waiver
+    var revokedAt sql.NullTime
+    var revokedBy sql.NullString
+    if err := row.Scan(&w.ID, &w.ConfigID, &w.Code, &w.Field, &w.Justification, &w.Approver,
+        &w.CreatedAt, &w.ExpiresAt, &revokedAt, &revokedBy); err != nil {
+        return nil, fmt.Errorf("failed to scan waiver: %w", err)
+    }
+    w.RevokedAt = revokedAt.Time
+    w.RevokedBy = revokedBy.String
+    return &w, nil
+}
+
+// SaveTemplate creates or replaces a template.
+func (p *PostgresStore) SaveTemplate(ctx context.Context, template *Template) (*Template, error) {
+    data, err := p.marshal(template.Data)
+    if err != nil {
+        return nil, err
+    }
+    saved := *template
+    saved.Data = models.Clone(template.Data)
+    saved.UpdatedAt = models.Now()
+
+    err = p.db.QueryRowContext(ctx, `
+        INSERT INTO game_dna_templates (name, description, data, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (name) DO UPDATE
+        SET description = EXCLUDED.description, data = EXCLUDED.data, updated_at = EXCLUDED.updated_at
+        RETURNING created_by, created_at
+    `, saved.Name, saved.Description, string(data), saved.CreatedBy, saved.UpdatedAt).Scan(&saved.CreatedBy, &saved.CreatedAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save template: %w", err)
+    }
+
+    return &saved, nil
+}
+
+const templateColumns = `name, description, data, created_by, created_at, updated_at`
+
+// scanTemplate reads a row selected with templateColumns.
+func (p *PostgresStore) scanTemplate(row interface{ Scan(...interface{}) error }) (*Template, error) {
+    var t Template
+    var data []byte
+    if err := row.Scan(&t.Name, &t.Description, &data, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+        return nil, err
+    }
+    var err error
+    if t.Data, err = p.unmarshal(data); err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+// GetTemplate retrieves a template by name.
+func (p *PostgresStore) GetTemplate(ctx context.Context, name string) (*Template, error) {
+    template, err := p.scanTemplate(p.db.QueryRowContext(ctx, `
+        SELECT `+templateColumns+` FROM game_dna_templates WHERE name = $1
+    `, name))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("template %s: %w", name, ErrNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read template: %w", err)
+    }
+    return template, nil
+}
+
+// ListTemplates returns every template, by name.
+func (p *PostgresStore) ListTemplates(ctx context.Context) ([]*Template, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT `+templateColumns+` FROM game_dna_templates ORDER BY name
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list templates: %w", err)
+    }
+    defer rows.Close()
+
+    var templates []*Template
+    for rows.Next() {
+        template, err := p.scanTemplate(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan template: %w", err)
+        }
+        templates = append(templates, template)
+    }
+    return templates, rows.Err()
+}
+
+// DeleteTemplate deletes a template.
+func (p *PostgresStore) DeleteTemplate(ctx context.Context, name string) error {
+    result, err := p.db.ExecContext(ctx, `DELETE FROM game_dna_templates WHERE name = $1`, name)
+    if err != nil {
+        return fmt.Errorf("failed to delete template: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("template %s: %w", name, ErrNotFound)
+    }
+
+    return nil
+}
+
+// propertySchemaColumns lists the columns scanPropertySchema reads, in order.
+const propertySchemaColumns = `project, name, type, allowed_values, required, description, updated_by, updated_at`
+
+// SavePropertySchema creates or replaces a property schema.
+func (p *PostgresStore) SavePropertySchema(ctx context.Context, schema *PropertySchema) (*PropertySchema, error) {
+    saved := *schema
+    saved.AllowedValues = append([]string(nil), schema.AllowedValues...)
+    saved.UpdatedAt = models.Now()
+
+    _, err := p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_property_schemas (`+propertySchemaColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (project, name) DO UPDATE
+        SET type = EXCLUDED.type, allowed_values = EXCLUDED.allowed_values, required = EXCLUDED.required,
+            description = EXCLUDED.description, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+    `, saved.Project, saved.Name, saved.Type, pq.Array(saved.AllowedValues), saved.Required, saved.Description,
+        saved.UpdatedBy, saved.UpdatedAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save property schema: %w", err)
+    }
+
+    return &saved, nil
+}
+
+// GetPropertySchema retrieves a property schema by project and name.
+func (p *PostgresStore) GetPropertySchema(ctx context.Context, project, name string) (*PropertySchema, error) {
+    row := p.db.QueryRowContext(ctx, `
+        SELECT `+propertySchemaColumns+` FROM game_dna_property_schemas WHERE project = $1 AND name = $2
+    `, project, name)
+    schema, err := scanPropertySchema(row)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("property %s of project %s: %w", name, project, ErrNotFound)
+    }
+    return schema, err
+}
+
+// ListPropertySchemas returns the property schemas of a project, by name.
+func (p *PostgresStore) ListPropertySchemas(ctx context.Context, project string) ([]*PropertySchema, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT `+propertySchemaColumns+` FROM game_dna_property_schemas WHERE project = $1 ORDER BY name
+    `, project)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list property schemas: %w", err)
+    }
+    var schemas []*PropertySchema
+    err = eachRow(rows, func() error {
+        schema, err := scanPropertySchema(rows)
+        if err == nil {
+            schemas = append(schemas, schema)
+        }
+        return err
+    })
+    return schemas, err
+}
+
+// DeletePropertySchema deletes a property schema.
+func (p *PostgresStore) DeletePropertySchema(ctx context.Context, project, name string) error {
+    result, err := p.db.ExecContext(ctx, `
+        DELETE FROM game_dna_property_schemas WHERE project = $1 AND name = $2
+    `, project, name)
+    if err != nil {
+        return fmt.Errorf("failed to delete property schema: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("property %s of project %s: %w", name, project, ErrNotFound)
+    }
+
+    return nil
+}
+
+// scanPropertySchema reads a row of propertySchemaColumns. sql.ErrNoRows is
+// returned as is.
+func scanPropertySchema(row interface{ Scan(...interface{}) error }) (*PropertySchema, error) {
+    var schema PropertySchema
+    err := row.Scan(&schema.Project, &schema.Name, &schema.Type, pq.Array(&schema.AllowedValues), &schema.Required,
+        &schema.Description, &schema.UpdatedBy, &schema.UpdatedAt)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read property schema: %w", err)
+    }
+    return &schema, nil
+}
+
+// webhookColumns lists the columns scanWebhook reads, in order.
+const webhookColumns = `id, url, event_types, secret, created_by, created_at`
+
+// SaveWebhook creates or replaces a webhook.
+func (p *PostgresStore) SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+    saved := *webhook
+    saved.EventTypes = append([]string(nil), webhook.EventTypes...)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+
+    _, err := p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_webhooks (`+webhookColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (id) DO UPDATE
+        SET url = EXCLUDED.url, event_types = EXCLUDED.event_types, secret = EXCLUDED.secret
+    `, saved.ID, saved.URL, pq.Array(saved.EventTypes), saved.Secret, saved.CreatedBy, saved.CreatedAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save webhook: %w", err)
+    }
+
+    return &saved, nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (p *PostgresStore) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+    row := p.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM game_dna_webhooks WHERE id = $1`, id)
+    webhook, err := scanWebhook(row)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+    }
+    return webhook, err
+}
+
+// ListWebhooks returns every webhook, oldest first.
+func (p *PostgresStore) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+    rows, err := p.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM game_dna_webhooks ORDER BY created_at, id`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list webhooks: %w", err)
+    }
+    var webhooks []*Webhook
+    err = eachRow(rows, func() error {
+        webhook, err := scanWebhook(rows)
+        if err == nil {
+            webhooks = append(webhooks, webhook)
+        }
+        return err
+    })
+    return webhooks, err
+}
+
+// DeleteWebhook deletes a webhook; its deliveries go with it by cascade.
+func (p *PostgresStore) DeleteWebhook(ctx context.Context, id string) error {
+    result, err := p.db.ExecContext(ctx, `DELETE FROM game_dna_webhooks WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("failed to delete webhook: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+    }
+
+    return nil
+}
+
+// scanWebhook reads a row of webhookColumns. sql.ErrNoRows is returned as is.
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*Webhook, error) {
+    var webhook Webhook
+    err := row.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.EventTypes), &webhook.Secret, &webhook.CreatedBy, &webhook.CreatedAt)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read webhook: %w", err)
+    }
+    return &webhook, nil
+}
+
+// webhookDeliveryColumns lists the columns scanWebhookDelivery reads, in order.
+const webhookDeliveryColumns = `id, webhook_id, event_type, config_id, payload, state, attempts, next_attempt_at,
+    last_status, last_error, created_at, updated_at, delivered_at`
+
+// SaveWebhookDelivery creates or replaces a webhook delivery. Payloads carry
+// config data, so they are encoded like the data columns.
+func (p *PostgresStore) SaveWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+    saved := *delivery
+    saved.Payload = append([]byte(nil), delivery.Payload...)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    if saved.UpdatedAt.IsZero() {
+        saved.UpdatedAt = saved.CreatedAt
+    }
+    payload, err := p.codecs.encodeData(saved.Payload, "", "")
+    if err != nil {
+        return nil, err
+    }
+
+    // Insert only for a webhook that exists, rather than fail on the foreign key
+    result, err := p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_webhook_deliveries (`+webhookDeliveryColumns+`)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+        WHERE EXISTS (SELECT 1 FROM game_dna_webhooks WHERE id = $2)
+        ON CONFLICT (id) DO UPDATE
+        SET state = EXCLUDED.state, attempts = EXCLUDED.attempts, next_attempt_at = EXCLUDED.next_attempt_at,
+            last_status = EXCLUDED.last_status, last_error = EXCLUDED.last_error,
+            updated_at = EXCLUDED.updated_at, delivered_at = EXCLUDED.delivered_at
+    `, saved.ID, saved.WebhookID, saved.EventType, saved.ConfigID, string(payload), saved.State, saved.Attempts,
+        saved.NextAttemptAt, saved.LastStatus, saved.LastError, saved.CreatedAt, saved.UpdatedAt, nullTime(saved.DeliveredAt))
+    if err != nil {
+        return nil, fmt.Errorf("failed to save webhook delivery: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return nil, fmt.Errorf("webhook %s: %w", saved.WebhookID, ErrNotFound)
+    }
+
+    return &saved, nil
+}
+
+// ClaimWebhookDeliveries returns the pending deliveries due at now and
+// pushes their next attempt back by lease. Rows another server is claiming
+// are skipped rather than waited for.
+func (p *PostgresStore) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+    rows, err := p.db.QueryContext(ctx, `
+        UPDATE game_dna_webhook_deliveries SET next_attempt_at = $3
+        WHERE id IN (
+            SELECT id FROM game_dna_webhook_deliveries
+            WHERE state = $1 AND next_attempt_at <= $2
+            ORDER BY created_at, id
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+webhookDeliveryColumns+`
+    `, WebhookDeliveryPending, now, now.Add(lease), limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+    }
+    var deliveries []*WebhookDelivery
+    err = eachRow(rows, func() error {
+        delivery, err := p.scanWebhookDelivery(rows)
+        if err == nil {
+            deliveries = append(deliveries, delivery)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    // RETURNING keeps no order
+    sort.Slice(deliveries, func(i, j int) bool {
+        if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+            return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+        }
+        return deliveries[i].ID < deliveries[j].ID
+    })
+    return deliveries, nil
+}
+
+// ListWebhookDeliveries returns the deliveries to a webhook, newest first.
+func (p *PostgresStore) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*WebhookDelivery, error) {
+    query := `SELECT ` + webhookDeliveryColumns + ` FROM game_dna_webhook_deliveries
+        WHERE webhook_id = $1 AND ($2::text = '' OR state = $2) ORDER BY created_at DESC, id DESC`
+    args := []interface{}{webhookID, state}
+    if limit > 0 {
+        query += ` LIMIT $3`
+        args = append(args, limit)
+    }
+    rows, err := p.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+    }
+    var deliveries []*WebhookDelivery
+    err = eachRow(rows, func() error {
+        delivery, err := p.scanWebhookDelivery(rows)
+        if err == nil {
+            deliveries = append(deliveries, delivery)
+        }
+        return err
+    })
+    return deliveries, err
+}
+
+// PruneWebhookDeliveries deletes the deliveries that finished before
+// finishedBefore.
+func (p *PostgresStore) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error) {
+    result, err := p.db.ExecContext(ctx, `
+        DELETE FROM game_dna_webhook_deliveries WHERE state <> $1 AND updated_at < $2
+    `, WebhookDeliveryPending, finishedBefore)
+    if err != nil {
+        return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+    }
+    n, _ := result.RowsAffected()
+    return n, nil
+}
+
+// ClaimIdempotencyKey saves key as a call in progress unless an unexpired
+// record of it exists. Of two servers claiming a key together, the primary
+// key lets one win.
+func (p *PostgresStore) ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error) {
+    saved := *key
+    saved.Response = nil
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+
+    // A record deleted between the insert and the read is claimed on the
+    // next try.
+    for attempt := 0; attempt < 3; attempt++ {
+        result, err := p.db.ExecContext(ctx, `
+            INSERT INTO game_dna_idempotency_keys (scope, key, request_hash, response, created_at, expires_at)
+            VALUES ($1, $2, $3, NULL, $4, $5)
+            ON CONFLICT (scope, key) DO UPDATE
+            SET request_hash = EXCLUDED.request_hash, response = NULL,
+                created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+            WHERE game_dna_idempotency_keys.expires_at <= EXCLUDED.created_at
+        `, saved.Scope, saved.Key, saved.RequestHash, saved.CreatedAt, saved.ExpiresAt)
+        if err != nil {
+            return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+        }
+        if n, _ := result.RowsAffected(); n > 0 {
+            return &saved, nil
+        }
+
+        var existing IdempotencyKey
+        var response []byte
+        err = p.db.QueryRowContext(ctx, `
+            SELECT scope, key, request_hash, response, created_at, expires_at
+            FROM game_dna_idempotency_keys WHERE scope = $1 AND key = $2
+        `, saved.Scope, saved.Key).Scan(&existing.Scope, &existing.Key, &existing.RequestHash, &response,
+            &existing.CreatedAt, &existing.ExpiresAt)
+        if err == sql.ErrNoRows {
+            continue
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read idempotency key: %w", err)
+        }
+        if response != nil {
+            if existing.Response, err = p.codecs.decodeData(response); err != nil {
+                return nil, err
+            }
+        }
+        return &existing, fmt.Errorf("%w: idempotency key %q is taken", ErrConflict, key.Key)
+    }
+    return nil, fmt.Errorf("failed to claim idempotency key %q: it keeps changing", key.Key)
+}
+
+// CompleteIdempotencyKey saves the response of a claimed call, or deletes
+// the claim when response is nil.
+func (p *PostgresStore) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+    var result sql.Result
+    var err error
+    if response == nil {
+        result, err = p.db.ExecContext(ctx, `
+            DELETE FROM game_dna_idempotency_keys WHERE scope = $1 AND key = $2
+        `, scope, key)
+    } else {
+        stored, encErr := p.codecs.encodeData(response, "", "")
+        if encErr != nil {
+            return encErr
+        }
+        result, err = p.db.ExecContext(ctx, `
+            UPDATE game_dna_idempotency_keys SET response = $3, expires_at = $4
+            WHERE scope = $1 AND key = $2
+        `, scope, key, stored, expiresAt)
+    }
+    if err != nil {
+        return fmt.Errorf("failed to complete idempotency key: %w", err)
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("%w: idempotency key %q", ErrNotFound, key)
+    }
+    return nil
+}
+
+// PruneIdempotencyKeys deletes the records that expired before
+// expiredBefore.
+func (p *PostgresStore) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+    result, err := p.db.ExecContext(ctx, `
+        DELETE FROM game_dna_idempotency_keys WHERE expires_at < $1
+    `, expiredBefore)
+    if err != nil {
+        return 0, fmt.Errorf("failed to prune idempotency keys: %w", err)
+    }
+    n, _ := result.RowsAffected()
+    return n, nil
+}
+
+// scanWebhookDelivery reads a row of webhookDeliveryColumns.
+func (p *PostgresStore) scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+    var d WebhookDelivery
+    var payload []byte
+    var deliveredAt sql.NullTime
+    if err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.ConfigID, &payload, &d.State, &d.Attempts, &d.NextAttemptAt,
+        &d.LastStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &deliveredAt); err != nil {
+        return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+    }
+    var err error
+    if d.Payload, err = p.codecs.decodeData(payload); err != nil {
+        return nil, err
+    }
+    d.DeliveredAt = deliveredAt.Time
+    return &d, nil
+}
+
+// ConfigIDsAfter returns up to limit config IDs greater than after, in ID order.
+func (p *PostgresStore) ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+    // UUIDs sort like their text form, so the primary key index serves this.
+    if after == "" {
+        after = uuid.Nil.String()
+    }
+    rows, err := p.db.QueryContext(ctx, `
+        SELECT id FROM game_dna_configs WHERE id > $1 ORDER BY id LIMIT $2
+    `, after, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list config IDs: %w", err)
+    }
+    defer rows.Close()
+
+    var ids []string
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("failed to scan config ID: %w", err)
+        }
+        ids = append(ids, id)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to list config IDs: %w", err)
+    }
+    return ids, nil
+}
+
+// FixChecksums replaces stored checksums that still match fix.Stored, in the
+// checksum column and the stored data alike, in one transaction.
+func (p *PostgresStore) FixChecksums(ctx context.Context, fixes []ChecksumMismatch) (int64, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    // The stored data is rewritten in Go rather than with jsonb_set, as it
+    // may be encoded.
+    var fixed int64
+    for _, fix := range fixes {
+        var stored, archiveKey sql.NullString
+        if fix.VersionNum == 0 {
+            err = tx.QueryRowContext(ctx, `
+                SELECT data FROM game_dna_configs WHERE id = $1 AND checksum = $2 FOR UPDATE
+            `, fix.ConfigID, fix.Stored).Scan(&stored)
+        } else {
+            err = tx.QueryRowContext(ctx, `
+                SELECT data, archive_key FROM game_dna_versions
+                WHERE config_id = $1 AND version_num = $2 AND COALESCE(checksum, '') = $3 FOR UPDATE
+            `, fix.ConfigID, fix.VersionNum, fix.Stored).Scan(&stored, &archiveKey)
+        }
+        if err == sql.ErrNoRows {
+            continue
+        }
+        if err != nil {
+            return 0, fmt.Errorf("failed to read checksum of %s: %w", fix.ConfigID, err)
+        }
+        dna, _, err := p.versionData(ctx, fix.ConfigID, fix.VersionNum, stored, archiveKey)
+        if err != nil {
+            return 0, err
+        }
+        dna.Checksum = fix.Computed
+        data, err := p.marshal(dna)
+        if err != nil {
+            return 0, err
+        }
+
+        if fix.VersionNum == 0 {
+            _, err = tx.ExecContext(ctx, `UPDATE game_dna_configs SET checksum = $2, data = $3 WHERE id = $1`,
+                fix.ConfigID, fix.Computed, string(data))
+        } else {
+            // A fixed archived version is back in the database until the
+            // next sweep archives it again.
+            _, err = tx.ExecContext(ctx, `
+                UPDATE game_dna_versions SET checksum = $3, data = $4, archive_key = NULL, archived_size = NULL
+                WHERE config_id = $1 AND version_num = $2
+            `, fix.ConfigID, fix.VersionNum, fix.Computed, string(data))
+        }
+        if err != nil {
+            return 0, fmt.Errorf("failed to fix checksum of %s: %w", fix.ConfigID, err)
+        }
+        fixed++
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("failed to commit checksum fixes: %w", err)
+    }
+    return fixed, nil
+}
+
+// checksumJobColumns lists the columns scanChecksumJob reads, in order.
+const checksumJobColumns = `id, state, dry_run, batch_size, rate_per_second, last_config_id, configs_scanned, versions_scanned,
+    config_mismatches, version_mismatches, fixed, mismatches, error, started_by, created_at, updated_at, finished_at`
+
+// SaveChecksumJob creates or replaces a checksum job.
+func (p *PostgresStore) SaveChecksumJob(ctx context.Context, job *ChecksumJob) (*ChecksumJob, error) {
+    saved := *job
+    saved.Mismatches = append([]ChecksumMismatch(nil), job.Mismatches...)
+    if saved.CreatedAt.IsZero() {
+        saved.CreatedAt = models.Now()
+    }
+    saved.UpdatedAt = models.Now()
+
+    mismatches, err := json.Marshal(saved.Mismatches)
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal checksum mismatches: %w", err)
+    }
+    _, err = p.db.ExecContext(ctx, `
+        INSERT INTO game_dna_checksum_jobs (`+checksumJobColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+        ON CONFLICT (id) DO UPDATE
+        SET state = EXCLUDED.state, last_config_id = EXCLUDED.last_config_id, configs_scanned = EXCLUDED.configs_scanned,
+            versions_scanned = EXCLUDED.versions_scanned, config_mismatches = EXCLUDED.config_mismatches,
+            version_mismatches = EXCLUDED.version_mismatches, fixed = EXCLUDED.fixed,
+            mismatches = EXCLUDED.mismatches, error = EXCLUDED.error, updated_at = EXCLUDED.updated_at,
+            finished_at = EXCLUDED.finished_at
+    `, saved.ID, saved.State, saved.DryRun, saved.BatchSize, saved.RatePerSecond, saved.Cursor,
+        saved.ConfigsScanned, saved.VersionsScanned, saved.ConfigMismatches, saved.VersionMismatches, saved.Fixed,
+        string(mismatches), nullString(saved.Error), saved.StartedBy, saved.CreatedAt, saved.UpdatedAt, nullTime(saved.FinishedAt))
+    if err != nil {
+        return nil, fmt.Errorf("failed to save checksum job: %w", err)
+    }
+
+    return &saved, nil
+}
+
+// GetChecksumJob retrieves a checksum job by ID.
+func (p *PostgresStore) GetChecksumJob(ctx context.Context, id string) (*ChecksumJob, error) {
+    job, err := scanChecksumJob(p.db.QueryRowContext(ctx, `SELECT `+checksumJobColumns+` FROM game_dna_checksum_jobs WHERE id = $1`, id))
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("checksum job %s: %w", id, ErrNotFound)
+    }
+    return job, err
+}
+
+// scanChecksumJob reads a row of checksumJobColumns. sql.ErrNoRows is
+// returned as is.
+func scanChecksumJob(row interface{ Scan(...interface{}) error }) (*ChecksumJob, error) {
+    var job ChecksumJob
+    var mismatches string
+    var jobErr, startedBy sql.NullString
+    var finishedAt sql.NullTime
+    err := row.Scan(
+        &job.ID, &job.State, &job.DryRun, &job.BatchSize, &job.RatePerSecond, &job.Cursor,
+        &job.ConfigsScanned, &job.VersionsScanned, &job.ConfigMismatches, &job.VersionMismatches, &job.Fixed,
+        &mismatches, &jobErr, &startedBy, &job.CreatedAt, &job.UpdatedAt, &finishedAt)
+    if err == sql.ErrNoRows {
+        return nil, err
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read checksum job: %w", err)
+    }
+
+    if err := json.Unmarshal([]byte(mismatches), &job.Mismatches); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal checksum mismatches: %w", err)
+    }
+    job.Error = jobErr.String
+    job.StartedBy = startedBy.String
+    job.FinishedAt = finishedAt.Time
+    return &job, nil
+}
+
+// nullString stores "" as SQL NULL.
+func nullString(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime stores the zero time as SQL NULL.
+func nullTime(t time.Time) sql.NullTime {
+    return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// marshalValidation encodes a validation report for the versions.validation
+// column, returning nil (SQL NULL) when there is no report.
+func marshalValidation(report *pb.ValidationResponse) (interface{}, error) {
+    if report == nil {
+        return nil, nil
+    }
+    b, err := json.Marshal(report)
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal validation report: %w", err)
+    }
+    return string(b), nil
+}
+
+// marshalChanges encodes a versions.changes column. Nil changes, of versions
+// copied from before they were recorded, stay NULL.
+func marshalChanges(changes []diff.Change) interface{} {
+    if changes == nil {
+        return nil
+    }
+    // Strings and bools always marshal.
+    b, _ := json.Marshal(changes)
+    return string(b)
+}
+
+// unmarshalChanges decodes a nullable versions.changes column.
+func unmarshalChanges(col sql.NullString) ([]diff.Change, error) {
+    if !col.Valid {
+        return nil, nil
+    }
+    var changes []diff.Change
+    if err := json.Unmarshal([]byte(col.String), &changes); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal version changes: %w", err)
+    }
+    return changes, nil
+}
+
+// unmarshalValidation decodes a nullable versions.validation column.
+func unmarshalValidation(col sql.NullString) (*pb.ValidationResponse, error) {
+    if !col.Valid {
+        return nil, nil
+    }
+    var report pb.ValidationResponse
+    if err := json.Unmarshal([]byte(col.String), &report); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal validation report: %w", err)
+    }
+    return &report, nil
+}
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation.
+const uniqueViolation = "23505"
+
+// conflictError wraps ErrConflict into err when it is a unique constraint
+// violation, such as a duplicate name and version.
+func conflictError(err error) error {
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+        return fmt.Errorf("%s: %w", pqErr.Message, ErrConflict)
+    }
+    return err
+}
+
+// Ping checks a connection to the database can be used.
+func (p *PostgresStore) Ping(ctx context.Context) error {
+    if err := p.db.PingContext(ctx); err != nil {
+        return fmt.Errorf("failed to ping database: %w", err)
+    }
+    return nil
+}
+
+// Close closes the database connection.
+func (p *PostgresStore) Close() {
+    if p.db != nil {
+        p.db.Close()
+    }
+}
+
+// FindPrincipalData returns every record naming principal. Stored data may
+// be encoded, so every version and draft is read to check the creator kept
+// in it.
+func (p *PostgresStore) FindPrincipalData(ctx context.Context, principal string) (*PrincipalData, error) {
+    tx, err := p.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin principal search: %w", err)
+    }
+    defer tx.Rollback()
+
+    return p.findPrincipal(ctx, tx, principal)
+}
+
+// AnonymizePrincipal replaces principal with pseudonym everywhere and deletes
+// the principal's drafts, in one transaction.
+func (p *PostgresStore) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*PrincipalData, error) {
+    tx, err := p.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to begin anonymization: %w", err)
+    }
+    defer tx.Rollback()
+
+    found, err := p.findPrincipal(ctx, tx, principal)
+    if err != nil {
+        return nil, err
+    }
+    rename := func(name string) string {
+        if name == principal {
+            return pseudonym
+        }
+        return name
+    }
+
+    for _, dna := range found.Configs {
+        renamed := models.Clone(dna)
+        renamed.CreatedBy = pseudonym
+        data, err := p.marshal(renamed)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE game_dna_configs SET data = $1, created_by = $2 WHERE id = $3
+        `, string(data), pseudonym, dna.Id); err != nil {
+            return nil, fmt.Errorf("failed to anonymize config %s: %w", dna.Id, err)
+        }
+    }
+    for _, pv := range found.Versions {
+        renamed := models.Clone(pv.Version.Data)
+        renamed.CreatedBy = rename(renamed.CreatedBy)
+        data, err := p.marshal(renamed)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE game_dna_versions SET data = $1, created_by = $2, archive_key = NULL, archived_size = NULL
+            WHERE config_id = $3 AND version_num = $4
+        `, string(data), rename(pv.Version.CreatedBy), pv.ConfigID, pv.Version.VersionNum); err != nil {
+            return nil, fmt.Errorf("failed to anonymize version %d of %s: %w", pv.Version.VersionNum, pv.ConfigID, err)
+        }
+    }
+    for _, draft := range found.Drafts {
+        if draft.Owner == principal {
+            continue
+        }
+        renamed := models.Clone(draft.Data)
+        renamed.CreatedBy = pseudonym
+        data, err := p.marshal(renamed)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE game_dna_drafts SET data = $1 WHERE config_id = $2 AND owner = $3
+        `, string(data), draft.ConfigID, draft.Owner); err != nil {
+            return nil, fmt.Errorf("failed to anonymize draft of %s: %w", draft.ConfigID, err)
+        }
+    }
+
+    for _, canary := range found.Canaries {
+        if canary.Candidate.GetCreatedBy() != principal {
+            continue
+        }
+        renamed := models.Clone(canary.Candidate)
+        renamed.CreatedBy = pseudonym
+        data, err := p.marshal(renamed)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE game_dna_canaries SET candidate = $1 WHERE config_id = $2
+        `, string(data), canary.ConfigID); err != nil {
+            return nil, fmt.Errorf("failed to anonymize canary of %s: %w", canary.ConfigID, err)
+        }
+    }
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM game_dna_drafts WHERE owner = $1`, principal); err != nil {
+        return nil, fmt.Errorf("failed to delete drafts: %w", err)
+    }
+    renames := []string{
+        `UPDATE game_dna_audit_log SET actor = $2 WHERE actor = $1`,
+        `UPDATE game_dna_audit_log SET approved_by = $2 WHERE approved_by = $1`,
+        `UPDATE game_dna_waivers SET approver = $2 WHERE approver = $1`,
+        `UPDATE game_dna_waivers SET revoked_by = $2 WHERE revoked_by = $1`,
+        `UPDATE game_dna_unlocks SET actor = $2 WHERE actor = $1`,
+        `UPDATE game_dna_snapshots SET created_by = $2 WHERE created_by = $1`,
+        `UPDATE game_dna_import_mappings SET updated_by = $2 WHERE updated_by = $1`,
+        `UPDATE game_dna_api_tokens SET created_by = $2 WHERE created_by = $1`,
+        `UPDATE game_dna_templates SET created_by = $2 WHERE created_by = $1`,
+        `UPDATE game_dna_checksum_jobs SET started_by = $2 WHERE started_by = $1`,
+        `UPDATE game_dna_canaries SET started_by = $2 WHERE started_by = $1`,
+        `UPDATE game_dna_canaries SET finished_by = $2 WHERE finished_by = $1`,
+        `UPDATE game_dna_release_tags SET created_by = $2 WHERE created_by = $1`,
+        `UPDATE game_dna_webhooks SET created_by = $2 WHERE created_by = $1`,
+    }
+    for _, stmt := range renames {
+        if _, err := tx.ExecContext(ctx, stmt, principal, pseudonym); err != nil {
+            return nil, fmt.Errorf("failed to anonymize principal: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("failed to commit anonymization: %w", err)
+    }
+    // Anonymized versions are back in the database; their archived copies
+    // still name the principal.
+    for _, pv := range found.Versions {
+        if pv.Version.ArchiveKey == "" {
+            continue
+        }
+        if err := p.archive.Delete(ctx, pv.Version.ArchiveKey); err != nil {
+            return nil, fmt.Errorf("anonymized, but failed to delete the archived copy of version %d of %s: %w", pv.Version.VersionNum, pv.ConfigID, err)
+        }
+    }
+    return found, nil
+}
+
+// findPrincipal collects the records naming principal, in the orders the
+// memory store uses.
+func (p *PostgresStore) findPrincipal(ctx context.Context, q dbtx, principal string) (*PrincipalData, error) {
+    found := &PrincipalData{}
+
+    rows, err := q.QueryContext(ctx, `
+        SELECT data FROM game_dna_configs WHERE created_by = $1 ORDER BY created_at, id
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find configs: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        var data []byte
+        if err := rows.Scan(&data); err != nil {
+            return fmt.Errorf("failed to scan config: %w", err)
+        }
+        dna, err := p.unmarshal(data)
+        if err == nil {
+            found.Configs = append(found.Configs, dna)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT config_id, version_num, checksum, created_at, created_by, data, validation, changed_fields, archive_key
+        FROM game_dna_versions ORDER BY created_at, config_id, version_num
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find versions: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        var v VersionInfo
+        var configID string
+        var createdBy, data, validationJSON, archiveKey sql.NullString
+        if err := rows.Scan(&configID, &v.VersionNum, &v.Checksum, &v.CreatedAt, &createdBy, &data, &validationJSON, pq.Array(&v.ChangedFields), &archiveKey); err != nil {
+            return fmt.Errorf("failed to scan version row: %w", err)
+        }
+        v.CreatedBy = createdBy.String
+        if !data.Valid {
+            v.ArchiveKey = archiveKey.String
+        }
+        // Archived versions are fetched too, as their data may name the
+        // principal.
+        var err error
+        if v.Data, _, err = p.versionData(ctx, configID, v.VersionNum, data, archiveKey); err != nil {
+            return err
+        }
+        if v.CreatedBy != principal && v.Data.GetCreatedBy() != principal {
+            return nil
+        }
+        if v.Validation, err = unmarshalValidation(validationJSON); err != nil {
+            return err
+        }
+        v.CreatedAt = v.CreatedAt.UTC()
+        found.Versions = append(found.Versions, PrincipalVersion{ConfigID: configID, Version: &v})
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT id, config_id, action, actor, reason, version_num, created_at, approved_by FROM game_dna_audit_log
+        WHERE actor = $1 OR approved_by = $1 ORDER BY id
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find audit entries: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        var entry AuditEntry
+        if err := rows.Scan(&entry.ID, &entry.ConfigID, &entry.Action, &entry.Actor, &entry.Reason, &entry.VersionNum, &entry.CreatedAt, &entry.ApprovedBy); err != nil {
+            return fmt.Errorf("failed to scan audit entry: %w", err)
+        }
+        found.AuditEntries = append(found.AuditEntries, &entry)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT config_id, owner, data, base_checksum, created_at, updated_at FROM game_dna_drafts
+        ORDER BY created_at, config_id, owner
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find drafts: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        var draft Draft
+        var data []byte
+        if err := rows.Scan(&draft.ConfigID, &draft.Owner, &data, &draft.BaseChecksum, &draft.CreatedAt, &draft.UpdatedAt); err != nil {
+            return fmt.Errorf("failed to scan draft: %w", err)
+        }
+        var err error
+        if draft.Data, err = p.unmarshal(data); err != nil {
+            return err
+        }
+        if draft.Owner == principal || draft.Data.GetCreatedBy() == principal {
+            found.Drafts = append(found.Drafts, &draft)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT `+waiverColumns+` FROM game_dna_waivers
+        WHERE approver = $1 OR revoked_by = $1 ORDER BY created_at, id
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find waivers: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        w, err := scanWaiver(rows)
+        if err == nil {
+            found.Waivers = append(found.Waivers, w)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT config_id, actor, reason, created_at, expires_at FROM game_dna_unlocks
+        WHERE actor = $1 ORDER BY created_at, config_id
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find temporary unlocks: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        var u TemporaryUnlock
+        if err := rows.Scan(&u.ConfigID, &u.Actor, &u.Reason, &u.CreatedAt, &u.ExpiresAt); err != nil {
+            return fmt.Errorf("failed to scan temporary unlock: %w", err)
+        }
+        found.Unlocks = append(found.Unlocks, &u)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT name, description, created_at FROM game_dna_snapshots WHERE created_by = $1 ORDER BY name
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find snapshots: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        snapshot := Snapshot{CreatedBy: principal}
+        var createdAt time.Time
+        if err := rows.Scan(&snapshot.Name, &snapshot.Description, &createdAt); err != nil {
+            return fmt.Errorf("failed to scan snapshot: %w", err)
+        }
+        snapshot.CreatedAt = createdAt.Format(time.RFC3339)
+        found.Snapshots = append(found.Snapshots, &snapshot)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `
+        SELECT project, columns, updated_at FROM game_dna_import_mappings WHERE updated_by = $1 ORDER BY project
+    `, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find import mappings: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        mapping := ImportMapping{UpdatedBy: principal}
+        var columnsJSON string
+        var updatedAt time.Time
+        if err := rows.Scan(&mapping.Project, &columnsJSON, &updatedAt); err != nil {
+            return fmt.Errorf("failed to scan import mapping: %w", err)
+        }
+        if err := json.Unmarshal([]byte(columnsJSON), &mapping.Columns); err != nil {
+            return fmt.Errorf("failed to unmarshal import mapping: %w", err)
+        }
+        mapping.UpdatedAt = updatedAt.Format(time.RFC3339)
+        found.ImportMappings = append(found.ImportMappings, &mapping)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+apiTokenColumns+` FROM game_dna_api_tokens WHERE created_by = $1 ORDER BY created_at, id`, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find API tokens: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        token, err := scanAPIToken(rows)
+        if err == nil {
+            found.APITokens = append(found.APITokens, token)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+templateColumns+` FROM game_dna_templates WHERE created_by = $1 ORDER BY name`, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find templates: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        template, err := p.scanTemplate(rows)
+        if err == nil {
+            found.Templates = append(found.Templates, template)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+checksumJobColumns+` FROM game_dna_checksum_jobs WHERE started_by = $1 ORDER BY created_at, id`, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find checksum jobs: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        job, err := scanChecksumJob(rows)
+        if err == nil {
+            found.ChecksumJobs = append(found.ChecksumJobs, job)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+canaryColumns+` FROM game_dna_canaries ORDER BY config_id`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find canaries: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        canary, err := p.scanCanary(rows)
+        if err != nil {
+            return err
+        }
+        if canary.StartedBy == principal || canary.FinishedBy == principal || canary.Candidate.GetCreatedBy() == principal {
+            found.Canaries = append(found.Canaries, canary)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+releaseTagColumns+` FROM game_dna_release_tags WHERE created_by = $1 ORDER BY created_at, config_id, tag`, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find release tags: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        tag, err := scanReleaseTag(rows)
+        if err == nil {
+            found.ReleaseTags = append(found.ReleaseTags, tag)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = q.QueryContext(ctx, `SELECT `+webhookColumns+` FROM game_dna_webhooks WHERE created_by = $1 ORDER BY created_at, id`, principal)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find webhooks: %w", err)
+    }
+    err = eachRow(rows, func() error {
+        webhook, err := scanWebhook(rows)
+        if err == nil {
+            found.Webhooks = append(found.Webhooks, webhook)
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return found, nil
+}
+
+// eachRow calls fn for every row and closes rows, stopping at the first error.
+func eachRow(rows *sql.Rows, fn func() error) error {
+    defer rows.Close()
+    for rows.Next() {
+        if err := fn(); err != nil {
+            return err
+        }
     }
+    return rows.Err()
 }
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// changeChannel is the NOTIFY channel that relays change events between
+// server instances sharing a database.
+const changeChannel = "game_dna_changes"
+
+// listenerPingInterval checks an idle listener connection is still alive.
+const listenerPingInterval = 90 * time.Second
+
+// ChangeNotice is a change event relayed between instances. It carries no
+// config, as NOTIFY payloads are capped at 8000 bytes; receivers read it.
+type ChangeNotice struct {
+	Origin   string `json:"origin"` // instance that made the change
+	Type     string `json:"type"`
+	ConfigID string `json:"config_id"`
+}
+
+// NotifyChange announces a change to every instance listening on the
+// database, this one included.
+func (p *PostgresStore) NotifyChange(ctx context.Context, notice ChangeNotice) error {
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change notice: %w", err)
+	}
+	if _, err := p.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, changeChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify change: %w", err)
+	}
+	return nil
+}
+
+// ListenChanges calls deliver with every change notice until ctx ends. The
+// listener holds its own connection and reconnects by itself; notices sent
+// while it is disconnected are lost. Connection and decoding problems go to
+// onError.
+func (p *PostgresStore) ListenChanges(ctx context.Context, deliver func(ChangeNotice), onError func(error)) error {
+	listener := pq.NewListener(p.url, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			onError(fmt.Errorf("change listener: %w", err))
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(changeChannel); err != nil {
+		return fmt.Errorf("failed to listen for changes: %w", err)
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			go listener.Ping()
+		case n := <-listener.Notify:
+			// A nil notification marks a reconnect.
+			if n == nil {
+				continue
+			}
+			var notice ChangeNotice
+			if err := json.Unmarshal([]byte(n.Extra), &notice); err != nil {
+				onError(fmt.Errorf("invalid change notice: %w", err))
+				continue
+			}
+			deliver(notice)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"time"
+)
+
+// QueryStats describes one SQL statement a PostgresStore ran. It never holds
+// the statement's parameter values, which may be config data or names.
+type QueryStats struct {
+	Method   string        // the Store method that ran it, as named by WithMethod; "" when none was
+	Query    string        // the statement, with its parameters as $1, $2 and so on
+	Args     int           // how many parameters it had
+	Rows     int64         // rows read, or for a write, rows affected
+	Duration time.Duration // from sending it until its last row was read
+	Err      error
+}
+
+// QueryObserver is told about each statement a PostgresStore runs, once it is
+// done. It is called on the goroutine that ran the statement, so it must be
+// quick.
+type QueryObserver func(ctx context.Context, q QueryStats)
+
+type methodKey struct{}
+
+// WithMethod returns ctx naming method, such as "List", as the Store method
+// whose statements run with it.
+func WithMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, methodKey{}, method)
+}
+
+// MethodFromContext returns the Store method ctx names, or "".
+func MethodFromContext(ctx context.Context) string {
+	method, _ := ctx.Value(methodKey{}).(string)
+	return method
+}
+
+// observedConnector opens connections whose queries and execs are reported
+// to the observer set on the store, if any. Prepared statements, which the
+// store doesn't use, aren't.
+type observedConnector struct {
+	driver.Connector
+	observe *QueryObserver
+}
+
+func (c *observedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &observedConn{Conn: conn, observe: c.observe}, nil
+}
+
+// observedConn passes every call through to the driver's connection,
+// reporting queries and execs as they finish.
+type observedConn struct {
+	driver.Conn
+	observe *QueryObserver
+}
+
+func (c *observedConn) report(ctx context.Context, query string, args int, rows int64, start time.Time, err error) {
+	if *c.observe == nil {
+		return
+	}
+	(*c.observe)(ctx, QueryStats{
+		Method:   MethodFromContext(ctx),
+		Query:    query,
+		Args:     args,
+		Rows:     rows,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
+
+func (c *observedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		if err != driver.ErrSkip {
+			c.report(ctx, query, len(args), 0, start, err)
+		}
+		return nil, err
+	}
+	return &observedRows{Rows: rows, done: func(n int64, err error) {
+		c.report(ctx, query, len(args), n, start, err)
+	}}, nil
+}
+
+func (c *observedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	var n int64
+	if err == nil {
+		n, _ = result.RowsAffected()
+	}
+	c.report(ctx, query, len(args), n, start, err)
+	return result, err
+}
+
+func (c *observedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *observedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *observedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// observedRows counts the rows read and reports the query when they are
+// closed, so its duration covers reading them.
+type observedRows struct {
+	driver.Rows
+	rows int64
+	err  error
+	done func(rows int64, err error)
+}
+
+func (r *observedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.rows++
+	case err != io.EOF:
+		r.err = err
+	}
+	return err
+}
+
+func (r *observedRows) Close() error {
+	err := r.Rows.Close()
+	if r.done != nil {
+		r.done(r.rows, r.err)
+		r.done = nil
+	}
+	return err
+}
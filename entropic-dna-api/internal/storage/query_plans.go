@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IndexStat reports how much an index has been used since Postgres statistics
+// were last reset.
+type IndexStat struct {
+	Table         string
+	Index         string
+	Scans         int64
+	TuplesRead    int64
+	TuplesFetched int64
+	SizeBytes     int64
+}
+
+// PlanCheck is the query plan chosen for one of the hot List queries.
+type PlanCheck struct {
+	Name    string
+	Plan    string
+	SeqScan bool // the plan still scans the whole table
+}
+
+// hotQueries mirror the List and Search shapes that dominate traffic.
+var hotQueries = []struct {
+	name  string
+	query string
+}{
+	{"list", `SELECT data FROM game_dna_configs ORDER BY created_at DESC, id LIMIT 10`},
+	{"list_after", `SELECT data FROM game_dna_configs WHERE created_at < now() OR (created_at = now() AND id > '00000000-0000-0000-0000-000000000000') ORDER BY created_at DESC, id LIMIT 11`},
+	{"list_unlocked", `SELECT data FROM game_dna_configs WHERE is_locked = false ORDER BY created_at DESC, id LIMIT 10`},
+	{"list_published", `SELECT data FROM game_dna_configs WHERE is_locked = true ORDER BY created_at DESC, id LIMIT 10`},
+	{"list_by_genre", `SELECT data FROM game_dna_configs WHERE data->>'genre' = 'FPS' ORDER BY created_at DESC, id LIMIT 10`},
+	{"list_by_tags", `SELECT data FROM game_dna_configs WHERE tags @> ARRAY['pvp'] ORDER BY created_at DESC, id LIMIT 10`},
+	{"search_text", `SELECT data FROM game_dna_configs WHERE search_document @@ 'arena'::tsquery ORDER BY created_at DESC, id LIMIT 10`},
+	{"search_target_fps", `SELECT data FROM game_dna_configs WHERE (search_numbers->>'target_fps')::numeric >= 60 ORDER BY created_at DESC, id LIMIT 10`},
+}
+
+// CheckQueryPlans explains each hot List query and reports whether Postgres
+// can answer it without a sequential scan. Sequential scans are disabled for
+// the check so small tables don't hide a missing index. It exists only on
+// the Postgres store: the in-memory store has no indexes or plans.
+func (p *PostgresStore) CheckQueryPlans(ctx context.Context) ([]PlanCheck, error) {
+	checks := make([]PlanCheck, 0, len(hotQueries))
+	for _, hq := range hotQueries {
+		plan, err := p.explain(ctx, hq.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explain %s: %w", hq.name, err)
+		}
+		checks = append(checks, ParsePlan(hq.name, plan))
+	}
+	return checks, nil
+}
+
+// ParsePlan builds the check of the query name from its EXPLAIN output. Only
+// a sequential scan of game_dna_configs itself counts; with sequential scans
+// disabled, Postgres still plans one only when no index can serve the query.
+func ParsePlan(name, plan string) PlanCheck {
+	check := PlanCheck{Name: name, Plan: plan}
+	for _, line := range strings.Split(plan, "\n") {
+		_, node, ok := strings.Cut(line, "Seq Scan on ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(node)
+		if len(fields) == 0 {
+			continue
+		}
+		// EXPLAIN VERBOSE qualifies the table with its schema
+		table := fields[0]
+		if i := strings.LastIndex(table, "."); i >= 0 {
+			table = table[i+1:]
+		}
+		if table == "game_dna_configs" {
+			check.SeqScan = true
+		}
+	}
+	return check
+}
+
+func (p *PostgresStore) explain(ctx context.Context, query string) (string, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL enable_seqscan = off`); err != nil {
+		return "", err
+	}
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// IndexStats reports usage of the indexes on the config tables, from
+// pg_stat_user_indexes.
+func (p *PostgresStore) IndexStats(ctx context.Context) ([]IndexStat, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT relname, indexrelname, idx_scan, idx_tup_read, idx_tup_fetch, pg_relation_size(indexrelid)
+		FROM pg_stat_user_indexes
+		WHERE relname LIKE 'game_dna%'
+		ORDER BY relname, indexrelname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []IndexStat
+	for rows.Next() {
+		var s IndexStat
+		if err := rows.Scan(&s.Table, &s.Index, &s.Scans, &s.TuplesRead, &s.TuplesFetched, &s.SizeBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan index stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Comparison operators of a NumericPredicate.
+const (
+	OpEq      = "="
+	OpNe      = "!="
+	OpLt      = "<"
+	OpLte     = "<="
+	OpGt      = ">"
+	OpGte     = ">="
+	OpBetween = "between"
+)
+
+// NumericPredicate compares a numeric config field, such as target_fps >= 60.
+// Between is inclusive of Value and Upper.
+type NumericPredicate struct {
+	Field string
+	Op    string
+	Value float64
+	Upper float64
+}
+
+// matches reports whether v satisfies the predicate.
+func (p NumericPredicate) matches(v float64) bool {
+	switch p.Op {
+	case OpEq:
+		return v == p.Value
+	case OpNe:
+		return v != p.Value
+	case OpLt:
+		return v < p.Value
+	case OpLte:
+		return v <= p.Value
+	case OpGt:
+		return v > p.Value
+	case OpGte:
+		return v >= p.Value
+	case OpBetween:
+		return v >= p.Value && v <= p.Upper
+	}
+	return false
+}
+
+// SearchQuery selects configs for Search. Every word of Text must appear in
+// the config's name, tags or custom properties, and every predicate must
+// hold. An empty query matches every config.
+type SearchQuery struct {
+	Text       string
+	Predicates []NumericPredicate
+}
+
+// Validate rejects unknown fields and operators.
+func (q SearchQuery) Validate() error {
+	for _, p := range q.Predicates {
+		if _, ok := numericFields[p.Field]; !ok {
+			return fmt.Errorf("%s is not a numeric field; want one of %s", p.Field, strings.Join(NumericFields(), ", "))
+		}
+		switch p.Op {
+		case OpEq, OpNe, OpLt, OpLte, OpGt, OpGte:
+		case OpBetween:
+			if p.Upper < p.Value {
+				return fmt.Errorf("%s between %g and %g is empty", p.Field, p.Value, p.Upper)
+			}
+		default:
+			return fmt.Errorf("unknown operator %q", p.Op)
+		}
+	}
+	return nil
+}
+
+// numericFields are the config's top-level numeric fields, by name.
+var numericFields = func() map[string]protoreflect.FieldDescriptor {
+	fields := make(map[string]protoreflect.FieldDescriptor)
+	all := (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < all.Len(); i++ {
+		fd := all.Get(i)
+		if fd.Cardinality() == protoreflect.Repeated {
+			continue
+		}
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+			protoreflect.FloatKind, protoreflect.DoubleKind:
+			fields[string(fd.Name())] = fd
+		}
+	}
+	return fields
+}()
+
+// NumericFields returns the fields predicates can compare, sorted.
+func NumericFields() []string {
+	names := make([]string, 0, len(numericFields))
+	for name := range numericFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// numericValues returns the value of every numeric field of dna.
+func numericValues(dna *pb.GameDNA) map[string]float64 {
+	msg := dna.ProtoReflect()
+	values := make(map[string]float64, len(numericFields))
+	for name, fd := range numericFields {
+		v := msg.Get(fd)
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Int64Kind:
+			values[name] = float64(v.Int())
+		case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+			values[name] = float64(v.Uint())
+		default:
+			values[name] = v.Float()
+		}
+	}
+	return values
+}
+
+// SearchTokens splits text into lowercase words of letters and digits, each
+// once, in order of first appearance.
+func SearchTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// documentTokens returns the words a config is found by: those of its name,
+// tags and custom property keys and values.
+func documentTokens(dna *pb.GameDNA) []string {
+	parts := []string{dna.Name}
+	parts = append(parts, dna.Tags...)
+	keys := make([]string, 0, len(dna.CustomProperties))
+	for k := range dna.CustomProperties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k, dna.CustomProperties[k])
+	}
+	return SearchTokens(strings.Join(parts, " "))
+}
+
+// searchIndex is an inverted index from words to the configs containing
+// them.
+type searchIndex struct {
+	postings map[string]map[string]struct{} // word -> config IDs
+	words    map[string][]string            // config ID -> its words
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[string]struct{}),
+		words:    make(map[string][]string),
+	}
+}
+
+// add indexes dna, replacing what was indexed for its ID before.
+func (x *searchIndex) add(dna *pb.GameDNA) {
+	x.remove(dna.Id)
+	words := documentTokens(dna)
+	for _, word := range words {
+		ids := x.postings[word]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			x.postings[word] = ids
+		}
+		ids[dna.Id] = struct{}{}
+	}
+	x.words[dna.Id] = words
+}
+
+// remove drops a config from the index.
+func (x *searchIndex) remove(id string) {
+	for _, word := range x.words[id] {
+		delete(x.postings[word], id)
+		if len(x.postings[word]) == 0 {
+			delete(x.postings, word)
+		}
+	}
+	delete(x.words, id)
+}
+
+// lookup returns the IDs of the configs containing every word, and false
+// when there are no words to look up.
+func (x *searchIndex) lookup(words []string) (map[string]struct{}, bool) {
+	if len(words) == 0 {
+		return nil, false
+	}
+	// Intersect starting from the rarest word.
+	sorted := append([]string(nil), words...)
+	sort.Slice(sorted, func(i, j int) bool { return len(x.postings[sorted[i]]) < len(x.postings[sorted[j]]) })
+	matched := make(map[string]struct{})
+	for id := range x.postings[sorted[0]] {
+		matched[id] = struct{}{}
+	}
+	for _, word := range sorted[1:] {
+		for id := range matched {
+			if _, ok := x.postings[word][id]; !ok {
+				delete(matched, id)
+			}
+		}
+	}
+	return matched, true
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MaxSlugLength bounds slugs so they stay readable in URLs.
+const MaxSlugLength = 100
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	slugSeparate = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Slugify derives a URL-safe slug from a config name, e.g.
+// "Arena Shooter (Prod)" becomes "arena-shooter-prod".
+func Slugify(name string) string {
+	slug := strings.Trim(slugSeparate.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "config"
+	}
+	return slug
+}
+
+// ValidateSlug checks that a client-supplied slug is URL-safe and cannot be
+// mistaken for a config ID.
+func ValidateSlug(slug string) error {
+	if len(slug) > MaxSlugLength {
+		return fmt.Errorf("slug must be at most %d characters", MaxSlugLength)
+	}
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("slug must be lowercase letters, digits and single dashes: %q", slug)
+	}
+	if _, err := uuid.Parse(slug); err == nil {
+		return fmt.Errorf("slug must not be a UUID: %q", slug)
+	}
+	return nil
+}
+
+// nextSlug returns base with a numeric suffix for the n-th collision
+// (n starts at 2), keeping the result within MaxSlugLength.
+func nextSlug(base string, n int) string {
+	suffix := fmt.Sprintf("-%d", n)
+	if len(base)+len(suffix) > MaxSlugLength {
+		base = strings.TrimRight(base[:MaxSlugLength-len(suffix)], "-")
+	}
+	return base + suffix
+}
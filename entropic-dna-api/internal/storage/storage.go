@@ -2,44 +2,721 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
 )
 
 // ListFilters provides basic filtering for list calls.
 type ListFilters struct {
-	Tags       []string
-	Genre      string
-	NameFilter string
+	Tags          []string
+	Genre         string
+	NameFilter    string
+	PublishedOnly bool
+	// Environment keeps configs serving it; configs with none serve
+	// EnvironmentDev.
+	Environment string
 }
 
+// DefaultPageSize is the page size List uses when none is given.
+const DefaultPageSize = 10
+
 // Pagination provides pagination for list calls.
 type Pagination struct {
 	Page     int32
 	PageSize int32
 }
 
+// ListCursor marks a position in List order: newest first, then by ID.
+type ListCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
 // VersionInfo represents a version snapshot.
 type VersionInfo struct {
 	VersionNum int64
 	Checksum   string
-	CreatedAt  string
+	CreatedAt  time.Time
 	CreatedBy  string
 	Data       *pb.GameDNA
+	Validation *pb.ValidationResponse // report recorded with the snapshot, if any
+	// ChangedFields lists the fields that differ from the previous version,
+	// recorded at write time. Nil for versions written before it was tracked.
+	ChangedFields []string
+	// Changes are ChangedFields with their values before and after, so
+	// history can show "target_fps 30 → 60" without diffing whole versions.
+	// Nil for versions written before they were recorded.
+	Changes []diff.Change
+	// Size is how many bytes Data takes in storage. Set by ListVersions and
+	// GetVersion only.
+	Size int64
+	// ArchiveKey names the object in the archive holding Data, for versions
+	// PostgresStore.ArchiveVersions moved out of the database.
+	ArchiveKey string
+}
+
+// VersionPage selects a page of a config's versions, newest first.
+type VersionPage struct {
+	// Before starts the page below this version number; 0 starts at the
+	// newest version.
+	Before int64
+	// Limit caps the versions returned; 0 returns every one.
+	Limit int
+	// WithoutData leaves Data nil, so the config blobs aren't read.
+	WithoutData bool
+}
+
+// HistoryRetention says which versions PruneVersionHistory deletes. A
+// config's newest version, and versions pinned by a snapshot or named by a
+// release tag, are always kept.
+type HistoryRetention struct {
+	// MaxVersions deletes versions older than each config's newest n; 0
+	// keeps any number.
+	MaxVersions int
+	// CreatedBefore deletes versions created before it; zero keeps versions
+	// of any age.
+	CreatedBefore time.Time
+}
+
+// SaveSet groups creates and updates that must be applied together.
+type SaveSet struct {
+	Creates []*pb.GameDNA
+	Updates []*pb.GameDNA
+
+	// Optional validation reports, parallel to Creates and Updates.
+	CreateReports []*pb.ValidationResponse
+	UpdateReports []*pb.ValidationResponse
+
+	// Unchanged is only set on results: updates skipped because their
+	// checksum matched the stored config.
+	Unchanged []*pb.GameDNA
+}
+
+// unchanged reports whether an update carries the same content checksum as
+// the stored config. Updates without a checksum are never treated as no-ops.
+func unchanged(stored, update *pb.GameDNA) bool {
+	return update.Checksum != "" && stored.Checksum == update.Checksum
+}
+
+// nextVersionNum returns the number of the version written after versions,
+// which may have had older entries pruned.
+func nextVersionNum(versions []*VersionInfo) int64 {
+	if len(versions) == 0 {
+		return 1
+	}
+	return versions[len(versions)-1].VersionNum + 1
+}
+
+// changedSince lists the fields of dna that differ from the latest version,
+// and returns the changes to them.
+func changedSince(versions []*VersionInfo, dna *pb.GameDNA) ([]string, []diff.Change) {
+	var prev *pb.GameDNA
+	if len(versions) > 0 {
+		prev = versions[len(versions)-1].Data
+	}
+	return diff.ChangedFields(prev, dna), diff.Changes(prev, dna)
+}
+
+// WriteOption customises a single Create or Update call.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	validation *pb.ValidationResponse
+}
+
+// WithValidation records the validation report alongside the version snapshot
+// written by the call.
+func WithValidation(report *pb.ValidationResponse) WriteOption {
+	return func(o *writeOptions) {
+		o.validation = report
+	}
+}
+
+func applyWriteOptions(opts []WriteOption) writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// reportAt returns reports[i], or nil when no report was supplied for that entry.
+func reportAt(reports []*pb.ValidationResponse, i int) *pb.ValidationResponse {
+	if i < len(reports) {
+		return reports[i]
+	}
+	return nil
+}
+
+// SnapshotEntry pins one config to a specific version within a snapshot.
+type SnapshotEntry struct {
+	ConfigID   string
+	VersionNum int64
+	Data       *pb.GameDNA // populated only when the snapshot is exported
+}
+
+// Snapshot is a named, coherent set of config versions (e.g. a release).
+type Snapshot struct {
+	Name        string
+	Description string
+	CreatedAt   string
+	CreatedBy   string
+	Entries     []SnapshotEntry
+}
+
+// ImportMapping is a saved template mapping sheet column headers to GameDNA
+// fields for one project's spreadsheets.
+type ImportMapping struct {
+	Project   string
+	Columns   map[string]string // column header -> field path
+	UpdatedAt string
+	UpdatedBy string
+}
+
+// APIToken is a read-only credential for published configs, meant to be
+// embedded in shipped game builds. Only hashes of its secrets are stored.
+type APIToken struct {
+	ID    string
+	Name  string
+	Slugs []string // published configs the token may read
+	// SecretHash is the SHA-256 of the current secret. PreviousHash, the secret
+	// replaced by the last rotation, is accepted until PreviousExpiresAt.
+	SecretHash        string
+	PreviousHash      string
+	PreviousExpiresAt time.Time
+	CreatedAt         time.Time
+	CreatedBy         string
+	RotatedAt         time.Time
+	RevokedAt         time.Time // zero while the token is active
+	Tenant            string    // tenant the token acts for under data residency
+}
+
+// Draft is a principal's private, unversioned edit of a config. Saving one
+// writes no version and sends no event; promoting it updates the config.
+type Draft struct {
+	ConfigID string
+	Owner    string
+	Data     *pb.GameDNA
+	// BaseChecksum is the config's checksum when the draft was started, so
+	// promoting can tell whether the config changed underneath it.
+	BaseChecksum string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Audit log actions.
+const (
+	AuditActionUnpublish       = "unpublish"
+	AuditActionTemporaryUnlock = "temporary_unlock"
+	AuditActionRelock          = "relock"
+	AuditActionCanaryPromote   = "canary_promote"
+	AuditActionPromote         = "promote"
+	AuditActionTransfer        = "transfer_ownership"
+)
+
+// AuditEntry records an administrative action on a config: what was done, by
+// whom and why.
+type AuditEntry struct {
+	ID         int64
+	ConfigID   string
+	Action     string
+	Actor      string
+	Reason     string
+	VersionNum int64 // version the action wrote to the config's history
+	CreatedAt  time.Time
+	ApprovedBy string // who approved the action, for actions that need approval
+}
+
+// TemporaryUnlock is an open window in which a published config may be
+// edited. The config is published again when the window closes.
+type TemporaryUnlock struct {
+	ConfigID  string
+	Actor     string
+	Reason    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Canary states.
+const (
+	CanaryRunning    = "running"
+	CanaryPromoted   = "promoted"
+	CanaryRolledBack = "rolled_back"
+)
+
+// CanaryArm counts what game servers reported for the sessions run on one
+// side of a canary.
+type CanaryArm struct {
+	Sessions int64
+	Errors   int64
+}
+
+// ErrorRate returns errors per session, or 0 before any session.
+func (a CanaryArm) ErrorRate() float64 {
+	if a.Sessions == 0 {
+		return 0
+	}
+	return float64(a.Errors) / float64(a.Sessions)
+}
+
+// Canary serves Candidate instead of a published config to Percent percent
+// of the clients reading it until EndsAt, while game servers report how
+// their sessions went on either side. The canary is then promoted, replacing
+// the published content, or rolled back. A finished canary is kept until the
+// config's next one starts.
+type Canary struct {
+	ConfigID  string
+	Candidate *pb.GameDNA
+	Checksum  string // of Candidate
+	// BaseChecksum is the published config's checksum when the canary
+	// started, so promoting can tell whether it changed underneath.
+	BaseChecksum string
+	Percent      int32
+
+	// Thresholds; a zero rate is not checked.
+	MaxErrorRate         float64 // candidate errors per session
+	MaxErrorRateIncrease float64 // candidate error rate minus the stable one
+	MinSessions          int64   // candidate sessions needed before judging
+
+	State      string
+	StartedBy  string
+	CreatedAt  time.Time
+	EndsAt     time.Time
+	FinishedBy string
+	FinishedAt time.Time
+	Reason     string // why it finished
+
+	CandidateFeedback CanaryArm
+	StableFeedback    CanaryArm
+}
+
+// ReleaseTag names a version of a config for people, such as "1.4.0-live"
+// or "playtest-july". A tag names one version of its config at a time, and
+// the version it names is kept however deep history is pruned.
+type ReleaseTag struct {
+	ConfigID   string
+	Tag        string
+	VersionNum int64
+	CreatedBy  string // who last pointed the tag at its version
+	CreatedAt  time.Time
+}
+
+// Waiver accepts one validation finding on a config, identified by its code
+// and field, until it expires or is revoked. Revoked waivers are kept so that
+// every waiver ever granted stays on record.
+type Waiver struct {
+	ID            string
+	ConfigID      string
+	Code          string
+	Field         string
+	Justification string
+	Approver      string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	RevokedAt     time.Time // zero while the waiver stands
+	RevokedBy     string
+}
+
+// Active reports whether the waiver applies at now.
+func (w *Waiver) Active(now time.Time) bool {
+	return w.RevokedAt.IsZero() && now.Before(w.ExpiresAt)
+}
+
+// Template is a user-defined starting point for new configs. Data holds only
+// config content; the server-managed fields are cleared.
+type Template struct {
+	Name        string
+	Description string
+	Data        *pb.GameDNA
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PropertySchema declares one custom property of the configs of a project,
+// those whose "project" custom property names it.
+type PropertySchema struct {
+	Project       string
+	Name          string   // custom property key
+	Type          string   // one of properties.Types
+	AllowedValues []string // values of an enum property
+	Required      bool
+	Description   string
+	UpdatedBy     string
+	UpdatedAt     time.Time
+}
+
+// Checksum job states.
+const (
+	ChecksumJobRunning   = "running"
+	ChecksumJobCompleted = "completed"
+	ChecksumJobCancelled = "cancelled"
+	ChecksumJobFailed    = "failed"
+)
+
+// ChecksumMismatch is a stored checksum that differs from the one computed
+// from the content it covers.
+type ChecksumMismatch struct {
+	ConfigID   string
+	VersionNum int64 // 0 for the config record itself
+	Stored     string
+	Computed   string
+}
+
+// ChecksumJob is a resumable pass that recomputes the checksum of every
+// stored config and version, walking configs in ID order.
+type ChecksumJob struct {
+	ID            string
+	State         string
+	DryRun        bool // report mismatches without fixing them
+	BatchSize     int
+	RatePerSecond int    // configs checked per second at most
+	Cursor        string // ID of the last config checked; the job resumes after it
+
+	ConfigsScanned    int64
+	VersionsScanned   int64
+	ConfigMismatches  int64
+	VersionMismatches int64
+	Fixed             int64 // records whose checksum was replaced
+	// Mismatches holds the first mismatches found; the counts cover all.
+	Mismatches []ChecksumMismatch
+
+	Error      string
+	StartedBy  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Webhook delivery states.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an HTTP endpoint that is sent lifecycle events as they happen.
+// Secret signs every delivery so the receiver can tell it came from us.
+type Webhook struct {
+	ID         string
+	URL        string
+	EventTypes []string // events sent; empty sends every event
+	Secret     string
+	CreatedBy  string
+	CreatedAt  time.Time
+}
+
+// WebhookDelivery is one event on its way to one webhook. A pending delivery
+// is attempted at NextAttemptAt until it succeeds or runs out of attempts.
+// Deliveries are deleted with their webhook.
+type WebhookDelivery struct {
+	ID            string
+	WebhookID     string
+	EventType     string
+	ConfigID      string
+	Payload       []byte // JSON body sent on every attempt
+	State         string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastStatus    int    // HTTP status of the last attempt; 0 if none was received
+	LastError     string // why the last attempt failed
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeliveredAt   time.Time // zero until the delivery succeeds
+}
+
+// IdempotencyKey records a call made with an idempotency key, so that a
+// retry with the same key gets the first call's response instead of running
+// again. Scope holds the call's method and a hash of its caller, so keys of
+// different callers never meet and no principal is stored in the clear.
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	RequestHash string // hex SHA-256 of the request, to catch a key reused for another one
+	// Response is the call's protobuf-encoded response; nil while the call
+	// runs.
+	Response  []byte
+	CreatedAt time.Time
+	// ExpiresAt ends the record: shortly after the claim while the call runs,
+	// so a server dying mid-call doesn't hold the key, and after the
+	// retention once the response is saved.
+	ExpiresAt time.Time
+}
+
+// OwnershipTransfer is what TransferOwnership moved from one principal to
+// another, or would move on a dry run. Both lists are oldest first.
+type OwnershipTransfer struct {
+	Configs  []*pb.GameDNA
+	Webhooks []*Webhook
+}
+
+// PrincipalVersion is a version of a config that names a principal.
+type PrincipalVersion struct {
+	ConfigID string
+	Version  *VersionInfo
+}
+
+// PrincipalData is every stored record naming one principal, for data
+// subject access and erasure requests. Records with a creation time are
+// listed oldest first, the rest by name.
+type PrincipalData struct {
+	Configs        []*pb.GameDNA      // created by the principal
+	Versions       []PrincipalVersion // written by the principal, or whose data names them as creator
+	AuditEntries   []*AuditEntry      // actions the principal took
+	Drafts         []*Draft           // owned by the principal, or whose data names them as creator
+	Waivers        []*Waiver          // approved or revoked by the principal
+	Unlocks        []*TemporaryUnlock // open windows the principal requested
+	Snapshots      []*Snapshot        // created by the principal; without entries
+	ImportMappings []*ImportMapping   // last saved by the principal
+	APITokens      []*APIToken        // created by the principal
+	Templates      []*Template        // created by the principal
+	ChecksumJobs   []*ChecksumJob     // started by the principal
+	Canaries       []*Canary          // started or finished by the principal
+	ReleaseTags    []*ReleaseTag      // pointed at their version by the principal
+	Webhooks       []*Webhook         // created by the principal
+}
+
+// Total returns the number of records found.
+func (d *PrincipalData) Total() int {
+	return len(d.Configs) + len(d.Versions) + len(d.AuditEntries) + len(d.Drafts) +
+		len(d.Waivers) + len(d.Unlocks) + len(d.Snapshots) + len(d.ImportMappings) +
+		len(d.APITokens) + len(d.Templates) + len(d.ChecksumJobs) + len(d.Canaries) +
+		len(d.ReleaseTags) + len(d.Webhooks)
 }
 
 // Store is the persistence interface for GameDNA.
 type Store interface {
-	Create(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error)
+	Create(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error)
 	Read(ctx context.Context, id string) (*pb.GameDNA, error)
-	Update(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error)
+	// ReadBySlug wraps ErrNotFound when no config uses the slug.
+	ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error)
+	// Update returns the stored config together with ErrNotModified when
+	// dna.Checksum matches it; no version is written in that case.
+	Update(ctx context.Context, dna *pb.GameDNA, opts ...WriteOption) (*pb.GameDNA, error)
+	// Delete wraps ErrNotFound when the config does not exist.
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filters ListFilters, pagination Pagination) ([]*pb.GameDNA, int32, error)
+	// ListAfter returns up to limit configs matching filters that come after
+	// the cursor in List order, from the start when after is nil, and the
+	// cursor to continue from, or nil when there are no more. Unlike paging
+	// by offset, configs created meanwhile don't shift later pages, and it
+	// doesn't count the matches.
+	ListAfter(ctx context.Context, filters ListFilters, after *ListCursor, limit int32) ([]*pb.GameDNA, *ListCursor, error)
+	// Each calls fn for every config matching filters, in List order, without
+	// paging or counting. It stops at the first error fn returns. fn must not
+	// modify the configs it is given.
+	Each(ctx context.Context, filters ListFilters, fn func(*pb.GameDNA) error) error
+	// Search returns a page of the configs matching query, in List order, and
+	// how many match in all. It rejects predicates query.Validate rejects.
+	Search(ctx context.Context, query SearchQuery, pagination Pagination) ([]*pb.GameDNA, int32, error)
 
 	GetVersionHistory(ctx context.Context, configID string) ([]*VersionInfo, error)
+	// ListVersions returns a page of configID's versions, newest first.
+	ListVersions(ctx context.Context, configID string, page VersionPage) ([]*VersionInfo, error)
+	// GetVersion returns one version of configID, with its data. It wraps
+	// ErrNotFound when the config has no such version, as when it was pruned.
+	GetVersion(ctx context.Context, configID string, versionNum int64) (*VersionInfo, error)
+	// PruneVersionHistory deletes the versions of every config that
+	// retention lets go, and returns how many it deleted.
+	PruneVersionHistory(ctx context.Context, retention HistoryRetention) (int64, error)
 	RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (*pb.GameDNA, error)
-	PublishVersion(ctx context.Context, configID string, actor string) (*pb.GameDNA, error)
+	// PublishVersion locks a config. expectedChecksum is the stored checksum
+	// of the content the caller validated; ErrConflict is returned when the
+	// config has changed since. A non-empty checksum replaces the stored one,
+	// so the lock records the checksum of exactly the published content.
+	PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error)
+	// UnpublishVersion unlocks a published config, writes a version and adds
+	// an AuditActionUnpublish entry for actor and reason to its audit log, all
+	// together. It wraps ErrNotLocked when the config isn't published.
+	UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (*pb.GameDNA, *AuditEntry, error)
+	// UnlockTemporarily unlocks a published config like UnpublishVersion, with
+	// an AuditActionTemporaryUnlock entry, and opens a TemporaryUnlock window
+	// that closes at expiresAt. It wraps ErrNotLocked when the config isn't
+	// published.
+	UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (*pb.GameDNA, *AuditEntry, error)
+	// RelockVersion ends a config's temporary unlock: it locks the config like
+	// PublishVersion, closes the window and adds an AuditActionRelock entry,
+	// all together. It wraps ErrNotFound when the config has no open window.
+	RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error)
+	// ListTemporaryUnlocks returns every open window, soonest to close first.
+	// Publishing or deleting a config closes its window.
+	ListTemporaryUnlocks(ctx context.Context) ([]*TemporaryUnlock, error)
+	// StartCanary saves canary as the running canary of its config, replacing
+	// a finished one. It wraps ErrNotFound when the config doesn't exist,
+	// ErrNotLocked when it isn't published and ErrConflict when a canary is
+	// already running.
+	StartCanary(ctx context.Context, canary *Canary) (*Canary, error)
+	// GetCanary returns a config's latest canary, running or finished. It
+	// wraps ErrNotFound when the config has none.
+	GetCanary(ctx context.Context, configID string) (*Canary, error)
+	// ListRunningCanaries returns the running canaries, soonest to end first.
+	ListRunningCanaries(ctx context.Context) ([]*Canary, error)
+	// RecordCanaryFeedback adds sessions and errors to the side of a config's
+	// running canary whose content has checksum. It wraps ErrNotFound when no
+	// canary is running and ErrConflict when checksum is neither side's.
+	RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (*Canary, error)
+	// PromoteCanary replaces a published config's content with its running
+	// canary's candidate: it writes a version carrying report, marks the
+	// canary promoted and adds an AuditActionCanaryPromote entry, all
+	// together. The config stays published. It wraps ErrNotFound when no
+	// canary is running, ErrNotLocked when the config isn't published and
+	// ErrConflict when its content changed since the canary started.
+	PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *Canary, *AuditEntry, error)
+	// PromoteVersion replaces a config's content with content promoted from
+	// the previous environment and publishes it: it writes a version carrying
+	// report and adds an AuditActionPromote entry naming approvedBy, all
+	// together. The config keeps its ID, slug, environment and promoted_from.
+	// It wraps ErrNotFound when the config doesn't exist.
+	PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, *AuditEntry, error)
+	// RollbackCanary finishes a config's running canary without changing the
+	// config. It wraps ErrNotFound when no canary is running.
+	RollbackCanary(ctx context.Context, configID string, actor string, reason string) (*Canary, error)
+	// TagVersion points tag.Tag at version tag.VersionNum of tag.ConfigID and
+	// returns the tag as saved with the version it named before, 0 for a new
+	// tag. Tagging the version a tag already names changes nothing. A tag
+	// naming another version is moved when move is set and wraps ErrConflict
+	// otherwise. It wraps ErrNotFound when the version doesn't exist.
+	TagVersion(ctx context.Context, tag *ReleaseTag, move bool) (*ReleaseTag, int64, error)
+	// GetTaggedVersion returns the version a release tag names. It wraps
+	// ErrNotFound when the config has no such tag.
+	GetTaggedVersion(ctx context.Context, configID string, tag string) (*VersionInfo, error)
+	// ListReleaseTags returns a config's release tags, newest version first,
+	// then by tag. Deleting a config deletes its tags.
+	ListReleaseTags(ctx context.Context, configID string) ([]*ReleaseTag, error)
+	// ListAuditEntries returns the audit log of a config, oldest first. Entries
+	// outlive their config, so the log of a deleted config can still be read.
+	ListAuditEntries(ctx context.Context, configID string) ([]*AuditEntry, error)
 	Clone(ctx context.Context, id string, newName string, actor string) (*pb.GameDNA, error)
 
+	SaveSet(ctx context.Context, set SaveSet) (*SaveSet, error)
+	// ImportConfig stores dna under its own ID with versions, oldest first, as
+	// its history. It wraps ErrConflict when the ID is already taken.
+	ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*VersionInfo) (*pb.GameDNA, error)
+
+	CreateSnapshot(ctx context.Context, snapshot *Snapshot) (*Snapshot, error)
+	GetSnapshot(ctx context.Context, name string, withData bool) (*Snapshot, error)
+	ListSnapshots(ctx context.Context) ([]*Snapshot, error)
+	RestoreSnapshot(ctx context.Context, name string, actor string) ([]*pb.GameDNA, error)
+
+	// TransferOwnership reassigns every config and webhook created by from to
+	// to. Each moved config gets a version and an AuditActionTransfer entry
+	// for actor. With dryRun it only returns what would move.
+	TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (*OwnershipTransfer, error)
+
+	// FindPrincipalData returns every record naming principal, as stored.
+	FindPrincipalData(ctx context.Context, principal string) (*PrincipalData, error)
+	// AnonymizePrincipal replaces principal with pseudonym in every record
+	// naming it, including the created_by kept in version and draft data,
+	// and deletes the principal's own drafts, all together. No versions are
+	// written and update times are left alone. It returns the records as they
+	// were before.
+	AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (*PrincipalData, error)
+
+	SaveImportMapping(ctx context.Context, mapping *ImportMapping) (*ImportMapping, error)
+	// GetImportMapping wraps ErrNotFound when the project has no saved mapping.
+	GetImportMapping(ctx context.Context, project string) (*ImportMapping, error)
+
+	// SaveAPIToken creates or replaces the token with token.ID.
+	SaveAPIToken(ctx context.Context, token *APIToken) (*APIToken, error)
+	// GetAPIToken wraps ErrNotFound when no token has the ID.
+	GetAPIToken(ctx context.Context, id string) (*APIToken, error)
+	// FindAPIToken returns the token whose current or previous secret has the
+	// given hash, revoked or not. It wraps ErrNotFound when there is none.
+	FindAPIToken(ctx context.Context, hash string) (*APIToken, error)
+	ListAPITokens(ctx context.Context) ([]*APIToken, error)
+
+	// SaveDraft creates or replaces owner's draft of draft.ConfigID, keeping
+	// the original CreatedAt. It wraps ErrNotFound when the config doesn't exist.
+	SaveDraft(ctx context.Context, draft *Draft) (*Draft, error)
+	// GetDraft wraps ErrNotFound when owner has no draft of the config.
+	GetDraft(ctx context.Context, configID, owner string) (*Draft, error)
+	// DeleteDraft wraps ErrNotFound when owner has no draft of the config.
+	// Deleting a config deletes its drafts.
+	DeleteDraft(ctx context.Context, configID, owner string) error
+
+	// SaveWaiver creates or replaces the waiver with waiver.ID. It wraps
+	// ErrNotFound when the config doesn't exist.
+	SaveWaiver(ctx context.Context, waiver *Waiver) (*Waiver, error)
+	// ListWaivers returns every waiver of a config, active or not, oldest
+	// first. Waivers outlive their config.
+	ListWaivers(ctx context.Context, configID string) ([]*Waiver, error)
+
+	// SaveTemplate creates or replaces the template with template.Name,
+	// keeping the original CreatedAt and CreatedBy.
+	SaveTemplate(ctx context.Context, template *Template) (*Template, error)
+	// GetTemplate wraps ErrNotFound when no template has the name.
+	GetTemplate(ctx context.Context, name string) (*Template, error)
+	// ListTemplates returns every template, by name.
+	ListTemplates(ctx context.Context) ([]*Template, error)
+	// DeleteTemplate wraps ErrNotFound when no template has the name.
+	DeleteTemplate(ctx context.Context, name string) error
+
+	// SavePropertySchema creates or replaces the schema of property
+	// schema.Name of schema.Project.
+	SavePropertySchema(ctx context.Context, schema *PropertySchema) (*PropertySchema, error)
+	// GetPropertySchema wraps ErrNotFound when the project declares no such
+	// property.
+	GetPropertySchema(ctx context.Context, project, name string) (*PropertySchema, error)
+	// ListPropertySchemas returns the property schemas of a project, by name.
+	ListPropertySchemas(ctx context.Context, project string) ([]*PropertySchema, error)
+	// DeletePropertySchema wraps ErrNotFound when the project declares no
+	// such property.
+	DeletePropertySchema(ctx context.Context, project, name string) error
+
+	// SaveWebhook creates or replaces the webhook with webhook.ID.
+	SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	// GetWebhook wraps ErrNotFound when no webhook has the ID.
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+	// ListWebhooks returns every webhook, oldest first.
+	ListWebhooks(ctx context.Context) ([]*Webhook, error)
+	// DeleteWebhook deletes a webhook and its deliveries. It wraps ErrNotFound
+	// when no webhook has the ID.
+	DeleteWebhook(ctx context.Context, id string) error
+	// SaveWebhookDelivery creates or replaces the delivery with delivery.ID. It
+	// wraps ErrNotFound when the webhook doesn't exist.
+	SaveWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error)
+	// ClaimWebhookDeliveries returns up to limit pending deliveries due at now,
+	// oldest first, and pushes their next attempt back by lease so that other
+	// servers leave them alone while they are sent.
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// ListWebhookDeliveries returns up to limit deliveries to a webhook, newest
+	// first, only those in state unless it is empty. 0 returns every one.
+	ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]*WebhookDelivery, error)
+	// PruneWebhookDeliveries deletes the deliveries that succeeded or failed
+	// before finishedBefore and returns how many it deleted.
+	PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (int64, error)
+
+	// ClaimIdempotencyKey saves key as a call in progress, unless an
+	// unexpired record of key.Scope and key.Key exists, which it returns
+	// with ErrConflict. An expired record is replaced.
+	ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error)
+	// CompleteIdempotencyKey saves the response of a claimed call and keeps
+	// it until expiresAt. A nil response deletes the claim instead, so the
+	// call may be retried with the key, as after it failed.
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error
+	// PruneIdempotencyKeys deletes the records that expired before
+	// expiredBefore and returns how many it deleted.
+	PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error)
+
+	// ConfigIDsAfter returns up to limit config IDs greater than after, in ID
+	// order, so jobs can walk every config in batches.
+	ConfigIDsAfter(ctx context.Context, after string, limit int) ([]string, error)
+	// FixChecksums replaces stored checksums with the computed ones. Each fix
+	// applies only while the stored checksum is still fix.Stored, so a write
+	// since the mismatch was found is never overwritten. No version is written
+	// and update times are left alone. It returns how many fixes applied.
+	FixChecksums(ctx context.Context, fixes []ChecksumMismatch) (int64, error)
+	// SaveChecksumJob creates or replaces the job with job.ID.
+	SaveChecksumJob(ctx context.Context, job *ChecksumJob) (*ChecksumJob, error)
+	// GetChecksumJob wraps ErrNotFound when no job has the ID.
+	GetChecksumJob(ctx context.Context, id string) (*ChecksumJob, error)
+
+	// IndexStats reports index usage; stores without indexes return none.
+	IndexStats(ctx context.Context) ([]IndexStat, error)
+
+	// Ping checks the backend is reachable, for readiness checks.
+	Ping(ctx context.Context) error
 	Close()
 }
@@ -0,0 +1,178 @@
+// Package templates holds the built-in GameDNA presets that new configs can
+// start from. User-defined templates live in storage; built-in names are
+// reserved so a stored template can never shadow one.
+package templates
+
+import (
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+)
+
+// Builtin is a preset shipped with the server.
+type Builtin struct {
+	Name        string
+	Description string
+	Data        *pb.GameDNA
+}
+
+// builtins are the presets, by name. Each validates cleanly against its
+// genre's profile once a config name is set.
+var builtins = []Builtin{
+	{
+		Name:        "competitive-moba",
+		Description: "Five-versus-five lane battler with free-to-play monetization",
+		Data: &pb.GameDNA{
+			Genre:           "Strategy",
+			Camera:          "Isometric",
+			Tone:            "Stylized",
+			WorldScale:      "MediumLevel",
+			TargetPlatforms: []string{"PC"},
+			PhysicsProfile:  "Arcade",
+			MaxPlayers:      10,
+			IsCompetitive:   true,
+			Difficulty:      "Medium",
+			Monetization:    "FreeToPlay",
+			TargetAudience:  "Teens and adults",
+			EsrbRating:      "T",
+			TargetFps:       60,
+			MaxDrawDistance: 500,
+			MaxEntities:     5000,
+			MaxNpcCount:     300,
+			TimeScale:       1,
+			NpcCount:        100,
+			AiEnabled:       true,
+			Tags:            []string{"moba", "esports"},
+		},
+	},
+	{
+		Name:        "fps-arena",
+		Description: "Fast competitive shooter on compact arena maps",
+		Data: &pb.GameDNA{
+			Genre:           "FPS",
+			Camera:          "Perspective3D",
+			Tone:            "Realistic",
+			WorldScale:      "MediumLevel",
+			TargetPlatforms: []string{"PC", "Console"},
+			PhysicsProfile:  "SemiRealistic",
+			MaxPlayers:      16,
+			IsCompetitive:   true,
+			Difficulty:      "Medium",
+			Monetization:    "PremiumBuy",
+			TargetAudience:  "Teens and adults",
+			EsrbRating:      "M",
+			TargetFps:       120,
+			MaxDrawDistance: 1500,
+			MaxEntities:     2000,
+			MaxNpcCount:     50,
+			TimeScale:       1,
+			Tags:            []string{"shooter", "arena"},
+		},
+	},
+	{
+		Name:        "mobile-puzzle",
+		Description: "Casual single-player puzzler for phones and tablets",
+		Data: &pb.GameDNA{
+			Genre:           "Puzzle",
+			Camera:          "Perspective2D",
+			Tone:            "Stylized",
+			WorldScale:      "TinyLevel",
+			TargetPlatforms: []string{"Mobile"},
+			PhysicsProfile:  "Arcade",
+			MaxPlayers:      1,
+			Difficulty:      "Easy",
+			Monetization:    "FreeToPlay",
+			TargetAudience:  "Everyone",
+			EsrbRating:      "E",
+			TargetFps:       60,
+			MaxDrawDistance: 100,
+			MaxEntities:     500,
+			TimeScale:       1,
+			Tags:            []string{"mobile", "casual"},
+		},
+	},
+	{
+		Name:        "open-world-rpg",
+		Description: "Single-player open-world RPG with a living world and quests",
+		Data: &pb.GameDNA{
+			Genre:               "RPG",
+			Camera:              "Perspective3D",
+			Tone:                "Cinematic",
+			WorldScale:          "OpenWorld",
+			TargetPlatforms:     []string{"PC", "Console"},
+			PhysicsProfile:      "Realistic",
+			MaxPlayers:          1,
+			Difficulty:          "Dynamic",
+			Monetization:        "PremiumBuy",
+			TargetAudience:      "Teens and adults",
+			EsrbRating:          "T",
+			TargetFps:           60,
+			MaxDrawDistance:     5000,
+			MaxEntities:         20000,
+			MaxNpcCount:         2000,
+			TimeScale:           1,
+			WeatherEnabled:      true,
+			SeasonsEnabled:      true,
+			DayNightCycle:       true,
+			PersistentWorld:     true,
+			NpcCount:            500,
+			AiEnabled:           true,
+			AiDifficultyScaling: true,
+			HasCampaign:         true,
+			HasSideQuests:       true,
+			DynamicQuests:       true,
+			Tags:                []string{"rpg", "open-world"},
+		},
+	},
+	{
+		Name:        "racing-arcade",
+		Description: "Arcade racer with online races against up to eight players",
+		Data: &pb.GameDNA{
+			Genre:           "Racing",
+			Camera:          "Perspective3D",
+			Tone:            "Arcade",
+			WorldScale:      "LargeLevel",
+			TargetPlatforms: []string{"PC", "Console"},
+			PhysicsProfile:  "Arcade",
+			MaxPlayers:      8,
+			IsCompetitive:   true,
+			Difficulty:      "Medium",
+			Monetization:    "PremiumBuy",
+			TargetAudience:  "Everyone",
+			EsrbRating:      "E",
+			TargetFps:       60,
+			MaxDrawDistance: 3000,
+			MaxEntities:     1000,
+			MaxNpcCount:     16,
+			TimeScale:       1,
+			DayNightCycle:   true,
+			NpcCount:        7,
+			AiEnabled:       true,
+			Tags:            []string{"racing"},
+		},
+	},
+}
+
+// List returns copies of the built-in presets, by name.
+func List() []Builtin {
+	result := make([]Builtin, len(builtins))
+	for i, b := range builtins {
+		result[i] = Builtin{Name: b.Name, Description: b.Description, Data: models.Clone(b.Data)}
+	}
+	return result
+}
+
+// Lookup returns a copy of the built-in preset with the name.
+func Lookup(name string) (Builtin, bool) {
+	for _, b := range builtins {
+		if b.Name == name {
+			return Builtin{Name: b.Name, Description: b.Description, Data: models.Clone(b.Data)}, true
+		}
+	}
+	return Builtin{}, false
+}
+
+// IsBuiltin reports whether name is reserved by a built-in preset.
+func IsBuiltin(name string) bool {
+	_, ok := Lookup(name)
+	return ok
+}
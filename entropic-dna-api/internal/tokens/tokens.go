@@ -0,0 +1,229 @@
+// Package tokens authenticates read-only API tokens. A token may only read
+// published configs, and only for the slugs it lists, so it can be embedded in a shipped game build without exposing
+// the rest of the API.
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SecretPrefix starts every token secret, so leaked ones are easy to spot
+// and other Authorization schemes pass through untouched.
+const SecretPrefix = "edt_"
+
+// authorizationMetadata carries "Bearer <secret>". The REST gateway forwards
+// the Authorization header under this key.
+const authorizationMetadata = "authorization"
+
+// AllowedMethods are the only gRPC methods a token may call.
+var AllowedMethods = map[string]bool{
+	"/entropic.dna.v1.GameDNAService/GetPublishedConfig":   true,
+	"/entropic.dna.v1.GameDNAService/SyncPublishedConfigs": true,
+}
+
+// ErrInvalid indicates an unknown, revoked or expired secret.
+var ErrInvalid = errors.New("invalid or revoked API token")
+
+// NewSecret returns a new random secret and its hash.
+func NewSecret() (secret string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token secret: %w", err)
+	}
+	secret = SecretPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, Hash(secret), nil
+}
+
+// Hash returns the hash a secret is stored and looked up by.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Accepts reports whether token accepts the secret with hash at now: its
+// current secret until revoked, or the rotated-out one until it expires.
+func Accepts(token *storage.APIToken, hash string, now time.Time) bool {
+	if !token.RevokedAt.IsZero() {
+		return false
+	}
+	if hash == token.SecretHash {
+		return true
+	}
+	return token.PreviousHash != "" && hash == token.PreviousHash && now.Before(token.PreviousExpiresAt)
+}
+
+// Allows reports whether token may read the config with slug.
+func Allows(token *storage.APIToken, slug string) bool {
+	return slices.Contains(token.Slugs, slug)
+}
+
+type tokenKey struct{}
+
+// WithToken returns ctx carrying the token the call authenticated with.
+func WithToken(ctx context.Context, token *storage.APIToken) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// FromContext returns the token the call authenticated with, if any.
+func FromContext(ctx context.Context) (*storage.APIToken, bool) {
+	token, ok := ctx.Value(tokenKey{}).(*storage.APIToken)
+	return token, ok
+}
+
+// Authenticator resolves secrets to tokens. Lookups are cached for ttl so
+// game-client reads don't each cost a database round trip; revocations and
+// rotations made through this instance apply at once, those made on other
+// instances within ttl.
+type Authenticator struct {
+	store storage.Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedToken
+}
+
+type cachedToken struct {
+	token   *storage.APIToken
+	expires time.Time
+}
+
+// NewAuthenticator creates an authenticator over store. A zero ttl disables
+// caching.
+func NewAuthenticator(store storage.Store, ttl time.Duration) *Authenticator {
+	return &Authenticator{
+		store: store,
+		ttl:   ttl,
+		cache: make(map[string]cachedToken),
+	}
+}
+
+// Authenticate returns the token that accepts secret, or an error wrapping
+// ErrInvalid. Under data residency each region has its own tokens, so the
+// token is looked up in the region of the tenant the call names; the caller
+// must still check the token belongs to that tenant.
+func (a *Authenticator) Authenticate(ctx context.Context, secret string) (*storage.APIToken, error) {
+	hash := Hash(secret)
+	requested := residency.RequestedTenant(ctx)
+	key := requested + "/" + hash
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.cache[key]
+	a.mu.Unlock()
+
+	token := cached.token
+	if !ok || now.After(cached.expires) {
+		found, err := a.store.FindAPIToken(residency.WithTenant(ctx, requested), hash)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalid
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up API token: %w", err)
+		}
+		token = found
+		if a.ttl > 0 {
+			a.mu.Lock()
+			a.cache[key] = cachedToken{token: found, expires: now.Add(a.ttl)}
+			a.mu.Unlock()
+		}
+	}
+
+	if !Accepts(token, hash, now) {
+		return nil, fmt.Errorf("API token %s: %w", token.ID, ErrInvalid)
+	}
+	return token, nil
+}
+
+// Forget drops cached lookups, so a revocation or rotation applies at once.
+func (a *Authenticator) Forget() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache = make(map[string]cachedToken)
+}
+
+// authenticate checks the token a call carries, if any, and returns ctx with
+// it, bound to the token's tenant. Calls carrying a token are limited to
+// AllowedMethods.
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	secret := bearerSecret(ctx)
+	if secret == "" {
+		return ctx, nil
+	}
+	if !AllowedMethods[method] {
+		return nil, status.Errorf(codes.PermissionDenied, "API tokens may not call %s", method)
+	}
+	token, err := a.Authenticate(ctx, secret)
+	if errors.Is(err, ErrInvalid) {
+		return nil, status.Error(codes.Unauthenticated, ErrInvalid.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = residency.Bind(WithToken(ctx, token), residency.RequestedTenant(ctx), token.Tenant)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return ctx, nil
+}
+
+// bearerSecret returns the API token secret sent with a call, or "". Other
+// Authorization schemes are ignored.
+func bearerSecret(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md.Get(authorizationMetadata) {
+		scheme, secret, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && strings.HasPrefix(secret, SecretPrefix) {
+			return secret
+		}
+	}
+	return ""
+}
+
+// UnaryInterceptor authenticates calls that carry an API token and limits
+// them to AllowedMethods. Calls without one pass through unchanged.
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls.
+func (a *Authenticator) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &tokenStream{ServerStream: ss, ctx: ctx})
+}
+
+// tokenStream overrides a stream's context with one carrying the token.
+type tokenStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tokenStream) Context() context.Context {
+	return s.ctx
+}
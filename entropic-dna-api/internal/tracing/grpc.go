@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryClientInterceptor records outgoing calls as client spans, children of
+// the span in the call's context, and sends the callee a traceparent for
+// them. Calls without a span in their context are passed through.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := startClientSpan(ctx, method)
+	if span == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	span.Finish(err)
+	return err
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streaming calls. The
+// span ends when the stream does.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := startClientSpan(ctx, method)
+	if span == nil {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		span.Finish(err)
+		return nil, err
+	}
+	return &spanStream{ClientStream: stream, span: span}, nil
+}
+
+// startClientSpan starts a client span for method and replaces the outgoing
+// traceparent with one naming it.
+func startClientSpan(ctx context.Context, method string) (context.Context, *Span) {
+	ctx, span := Start(ctx, strings.TrimPrefix(method, "/"), SpanKindClient)
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(TraceparentHeader, span.Traceparent())
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// spanStream ends its span when the stream ends.
+type spanStream struct {
+	grpc.ClientStream
+	span *Span
+}
+
+func (s *spanStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if errors.Is(err, io.EOF) {
+		s.span.Finish(nil)
+	} else if err != nil {
+		s.span.Finish(err)
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+package tracing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Per-request bounds, so a runaway bulk call can't fill memory.
+const (
+	maxLogLines = 200
+	maxChanges  = 1000
+)
+
+// LogLine is a log entry written while serving a request.
+type LogLine struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// Change is a config change made by a request.
+type Change struct {
+	Time     time.Time
+	Type     string // an events type, such as updated or published
+	ConfigID string
+	Checksum string // of the config as written; "" for deletes
+}
+
+// Entry is what the journal knows about one request.
+type Entry struct {
+	RequestID string
+	TraceID   string
+	Method    string
+	Code      string // gRPC status code; "" while the call is running
+	Error     string
+	Start     time.Time
+	Duration  time.Duration
+	Logs      []LogLine
+	Changes   []Change
+	// Truncated reports that log lines or changes past the per-request
+	// bounds were dropped.
+	Truncated bool
+}
+
+// Journal keeps the most recent requests by request ID. It is per instance
+// and in memory, so it covers the requests this instance served since it
+// started, up to its size. A nil Journal records nothing.
+type Journal struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+	order   []string // ring of request IDs, oldest at next
+	next    int
+}
+
+// NewJournal creates a journal holding up to size requests.
+func NewJournal(size int) *Journal {
+	return &Journal{
+		entries: make(map[string]*Entry, size),
+		order:   make([]string, 0, size),
+	}
+}
+
+// Size returns how many requests the journal holds at most.
+func (j *Journal) Size() int {
+	return cap(j.order)
+}
+
+// entry returns the entry for id, creating it and evicting the oldest when
+// the journal is full. The caller holds j.mu.
+func (j *Journal) entry(id string) *Entry {
+	if e, ok := j.entries[id]; ok {
+		return e
+	}
+	e := &Entry{RequestID: id, Start: time.Now()}
+	if len(j.order) < cap(j.order) {
+		j.order = append(j.order, id)
+	} else {
+		delete(j.entries, j.order[j.next])
+		j.order[j.next] = id
+		j.next = (j.next + 1) % len(j.order)
+	}
+	j.entries[id] = e
+	return e
+}
+
+// Begin records that a call of method started for request id. A REST request
+// and the gRPC call it makes share one entry.
+func (j *Journal) Begin(id, traceID, method string) {
+	if j == nil || id == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.entry(id)
+	e.TraceID = traceID
+	e.Method = method
+}
+
+// End records the outcome of request id.
+func (j *Journal) End(id, code, message string, took time.Duration) {
+	if j == nil || id == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.entry(id)
+	e.Code = code
+	e.Error = message
+	e.Duration = took
+}
+
+// NoteChange records a config change made by request id.
+func (j *Journal) NoteChange(id, eventType, configID, checksum string) {
+	if j == nil || id == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.entry(id)
+	if len(e.Changes) >= maxChanges {
+		e.Truncated = true
+		return
+	}
+	e.Changes = append(e.Changes, Change{Time: time.Now(), Type: eventType, ConfigID: configID, Checksum: checksum})
+}
+
+func (j *Journal) addLog(id string, line LogLine) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.entry(id)
+	if len(e.Logs) >= maxLogLines {
+		e.Truncated = true
+		return
+	}
+	e.Logs = append(e.Logs, line)
+}
+
+// Lookup returns a copy of the entry for request id.
+func (j *Journal) Lookup(id string) (Entry, bool) {
+	if j == nil {
+		return Entry{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.entries[id]
+	if !ok {
+		return Entry{}, false
+	}
+	cp := *e
+	cp.Logs = append([]LogLine(nil), e.Logs...)
+	cp.Changes = append([]Change(nil), e.Changes...)
+	return cp, true
+}
+
+// requestIDField is the log field that ties a log entry to a request.
+const requestIDField = "request_id"
+
+// WrapCore returns a zap core that also records, in the journal, every entry
+// carrying a request_id field. Entries are journaled at every level, debug
+// included, whatever the logger's level; only enabled ones reach core.
+func (j *Journal) WrapCore(core zapcore.Core) zapcore.Core {
+	return &journalCore{Core: core, journal: j}
+}
+
+type journalCore struct {
+	zapcore.Core
+	journal   *Journal
+	requestID string // set by With
+	fields    []zapcore.Field
+}
+
+func (c *journalCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *journalCore) With(fields []zapcore.Field) zapcore.Core {
+	id := requestIDOf(fields)
+	if id == "" {
+		id = c.requestID
+	}
+	return &journalCore{
+		Core:      c.Core.With(fields),
+		journal:   c.journal,
+		requestID: id,
+		fields:    append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *journalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *journalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	id := requestIDOf(fields)
+	if id == "" {
+		id = c.requestID
+	}
+	if id != "" {
+		c.journal.addLog(id, LogLine{
+			Time:    ent.Time,
+			Level:   ent.Level.String(),
+			Message: ent.Message,
+			Fields:  fieldStrings(append(append([]zapcore.Field(nil), c.fields...), fields...)),
+		})
+	}
+	if !c.Core.Enabled(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func requestIDOf(fields []zapcore.Field) string {
+	for _, f := range fields {
+		if f.Key == requestIDField && f.Type == zapcore.StringType {
+			return f.String
+		}
+	}
+	return ""
+}
+
+// fieldStrings renders log fields as strings, leaving out the request ID.
+func fieldStrings(fields []zapcore.Field) map[string]string {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Key != requestIDField {
+			f.AddTo(enc)
+		}
+	}
+	out := make(map[string]string, len(enc.Fields))
+	for k, v := range enc.Fields {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
@@ -0,0 +1,274 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SpanKind is a span's OTLP kind.
+type SpanKind int
+
+// Span kinds, numbered as in OTLP.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Export batching. Spans past a full queue are dropped rather than slowing
+// requests down.
+const (
+	spanQueueSize = 4096
+	spanBatchSize = 512
+	exportTimeout = 10 * time.Second
+)
+
+// SpanExporter sends finished spans to a tracing backend.
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// Attribute is a span attribute. Values are strings, int64s or bools.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is one timed operation within a trace. Methods on a nil Span do
+// nothing, so callers needn't check whether tracing is on.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string // "" for a root span
+	Name         string
+	Kind         SpanKind
+	Start        time.Time
+	End          time.Time
+	// Error is the status message of a failed span; "" when it succeeded.
+	Error string
+
+	mu         sync.Mutex
+	attributes []Attribute
+	tracer     *Tracer
+	sampled    bool
+}
+
+// Tracer starts spans and exports the sampled ones in batches. Methods on a
+// nil Tracer start no spans.
+type Tracer struct {
+	exporter    SpanExporter
+	sampleRatio float64
+	interval    time.Duration
+	logger      *zap.Logger
+
+	queue   chan *Span
+	stop    chan struct{}
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// NewTracer creates a tracer exporting to exporter every interval. It
+// samples sampleRatio of new traces, from 0 to 1, by trace ID, so every
+// instance in a trace makes the same decision.
+func NewTracer(exporter SpanExporter, sampleRatio float64, interval time.Duration, logger *zap.Logger) *Tracer {
+	t := &Tracer{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		interval:    interval,
+		logger:      logger,
+		queue:       make(chan *Span, spanQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// StartRemote starts a span continuing the trace of traceparent, as received
+// from a caller, or a new trace when traceparent is empty or malformed. A
+// caller's decision not to sample its trace is kept.
+func (t *Tracer) StartRemote(ctx context.Context, name string, kind SpanKind, traceparent string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	traceID, parentID, sampled, ok := ParseTraceparent(traceparent)
+	if !ok {
+		traceID, parentID, sampled = NewTraceID(), "", true
+	}
+	s := &Span{
+		TraceID:      traceID,
+		SpanID:       NewSpanID(),
+		ParentSpanID: parentID,
+		Name:         name,
+		Kind:         kind,
+		Start:        time.Now(),
+		tracer:       t,
+		sampled:      sampled && t.sample(traceID),
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Start starts a child of the span in ctx. Without one it starts nothing and
+// returns a nil span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	s := &Span{
+		TraceID:      parent.TraceID,
+		SpanID:       NewSpanID(),
+		ParentSpanID: parent.SpanID,
+		Name:         name,
+		Kind:         kind,
+		Start:        time.Now(),
+		tracer:       parent.tracer,
+		sampled:      parent.sampled,
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span ctx carries, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SetAttribute sets key to value, a string, int64 or bool.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+	s.mu.Unlock()
+}
+
+// Attributes returns the span's attributes, in the order they were set.
+func (s *Span) Attributes() []Attribute {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attribute(nil), s.attributes...)
+}
+
+// Traceparent returns the traceparent value that makes a callee's spans
+// children of s.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return "00-" + s.TraceID + "-" + s.SpanID + "-" + flags
+}
+
+// Finish ends the span, failed with err's message when err is set, and
+// queues it for export if its trace is sampled. Only the first call counts.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.End.IsZero() {
+		return
+	}
+	s.End = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if s.sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+// sample reports whether a new trace is sampled, using the low 8 bytes of
+// its ID as OpenTelemetry's TraceIdRatioBased sampler does.
+func (t *Tracer) sample(traceID string) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	if t.sampleRatio <= 0 {
+		return false
+	}
+	b, err := hex.DecodeString(traceID[16:])
+	if err != nil {
+		return false
+	}
+	return binary.BigEndian.Uint64(b)>>1 < uint64(t.sampleRatio*(1<<63))
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	select {
+	case t.queue <- s:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// run exports queued spans in batches, when a batch fills or every interval,
+// until Shutdown.
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			t.logger.Warn("Span export queue full; spans dropped", zap.Int64("dropped", dropped))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := t.exporter.ExportSpans(ctx, batch); err != nil {
+			t.logger.Warn("Failed to export spans", zap.Int("spans", len(batch)), zap.Error(err))
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= spanBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case s := <-t.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown exports the spans still queued, waiting until ctx is done at most.
+func (t *Tracer) Shutdown(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+	}
+}
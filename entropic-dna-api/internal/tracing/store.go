@@ -0,0 +1,481 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+)
+
+// store wraps a storage.Store, recording each call as a client span.
+type store struct {
+	next storage.Store
+}
+
+// WrapStore returns a store that records a "storage.<Method>" span, a child of
+// the span in the call's context, around each call to next. Calls without a
+// span in their context, such as background jobs, record none.
+func WrapStore(next storage.Store) storage.Store {
+	return &store{next: next}
+}
+
+func (s *store) Create(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.Create", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Create(ctx, dna, opts...)
+}
+
+func (s *store) Read(ctx context.Context, id string) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.Read", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Read(ctx, id)
+}
+
+func (s *store) ReadBySlug(ctx context.Context, slug string) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.ReadBySlug", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ReadBySlug(ctx, slug)
+}
+
+func (s *store) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.Update", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Update(ctx, dna, opts...)
+}
+
+func (s *store) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := Start(ctx, "storage.Delete", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Delete(ctx, id)
+}
+
+func (s *store) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) (_ []*pb.GameDNA, _ int32, err error) {
+	ctx, span := Start(ctx, "storage.List", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.List(ctx, filters, pagination)
+}
+
+func (s *store) ListAfter(ctx context.Context, filters storage.ListFilters, after *storage.ListCursor, limit int32) (_ []*pb.GameDNA, _ *storage.ListCursor, err error) {
+	ctx, span := Start(ctx, "storage.ListAfter", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListAfter(ctx, filters, after, limit)
+}
+
+func (s *store) Each(ctx context.Context, filters storage.ListFilters, fn func(*pb.GameDNA) error) (err error) {
+	ctx, span := Start(ctx, "storage.Each", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Each(ctx, filters, fn)
+}
+
+func (s *store) Search(ctx context.Context, query storage.SearchQuery, pagination storage.Pagination) (_ []*pb.GameDNA, _ int32, err error) {
+	ctx, span := Start(ctx, "storage.Search", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Search(ctx, query, pagination)
+}
+
+func (s *store) GetVersionHistory(ctx context.Context, configID string) (_ []*storage.VersionInfo, err error) {
+	ctx, span := Start(ctx, "storage.GetVersionHistory", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetVersionHistory(ctx, configID)
+}
+
+func (s *store) ListVersions(ctx context.Context, configID string, page storage.VersionPage) (_ []*storage.VersionInfo, err error) {
+	ctx, span := Start(ctx, "storage.ListVersions", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListVersions(ctx, configID, page)
+}
+
+func (s *store) GetVersion(ctx context.Context, configID string, versionNum int64) (_ *storage.VersionInfo, err error) {
+	ctx, span := Start(ctx, "storage.GetVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetVersion(ctx, configID, versionNum)
+}
+
+func (s *store) PruneVersionHistory(ctx context.Context, retention storage.HistoryRetention) (_ int64, err error) {
+	ctx, span := Start(ctx, "storage.PruneVersionHistory", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PruneVersionHistory(ctx, retention)
+}
+
+func (s *store) RollbackToVersion(ctx context.Context, configID string, versionNum int64, actor string) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.RollbackToVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.RollbackToVersion(ctx, configID, versionNum, actor)
+}
+
+func (s *store) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.PublishVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func (s *store) UnpublishVersion(ctx context.Context, configID string, actor string, reason string) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.UnpublishVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.UnpublishVersion(ctx, configID, actor, reason)
+}
+
+func (s *store) UnlockTemporarily(ctx context.Context, configID string, actor string, reason string, expiresAt time.Time) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.UnlockTemporarily", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.UnlockTemporarily(ctx, configID, actor, reason, expiresAt)
+}
+
+func (s *store) RelockVersion(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.RelockVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.RelockVersion(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) ListTemporaryUnlocks(ctx context.Context) (_ []*storage.TemporaryUnlock, err error) {
+	ctx, span := Start(ctx, "storage.ListTemporaryUnlocks", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListTemporaryUnlocks(ctx)
+}
+
+func (s *store) StartCanary(ctx context.Context, canary *storage.Canary) (_ *storage.Canary, err error) {
+	ctx, span := Start(ctx, "storage.StartCanary", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.StartCanary(ctx, canary)
+}
+
+func (s *store) GetCanary(ctx context.Context, configID string) (_ *storage.Canary, err error) {
+	ctx, span := Start(ctx, "storage.GetCanary", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetCanary(ctx, configID)
+}
+
+func (s *store) ListRunningCanaries(ctx context.Context) (_ []*storage.Canary, err error) {
+	ctx, span := Start(ctx, "storage.ListRunningCanaries", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListRunningCanaries(ctx)
+}
+
+func (s *store) RecordCanaryFeedback(ctx context.Context, configID string, checksum string, sessions int64, errors int64) (_ *storage.Canary, err error) {
+	ctx, span := Start(ctx, "storage.RecordCanaryFeedback", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.RecordCanaryFeedback(ctx, configID, checksum, sessions, errors)
+}
+
+func (s *store) PromoteCanary(ctx context.Context, configID string, actor string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.Canary, _ *storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.PromoteCanary", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PromoteCanary(ctx, configID, actor, reason, checksum, report)
+}
+
+func (s *store) PromoteVersion(ctx context.Context, configID string, content *pb.GameDNA, actor string, approvedBy string, reason string, checksum string, report *pb.ValidationResponse) (_ *pb.GameDNA, _ *storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.PromoteVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PromoteVersion(ctx, configID, content, actor, approvedBy, reason, checksum, report)
+}
+
+func (s *store) RollbackCanary(ctx context.Context, configID string, actor string, reason string) (_ *storage.Canary, err error) {
+	ctx, span := Start(ctx, "storage.RollbackCanary", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.RollbackCanary(ctx, configID, actor, reason)
+}
+
+func (s *store) TagVersion(ctx context.Context, tag *storage.ReleaseTag, move bool) (_ *storage.ReleaseTag, _ int64, err error) {
+	ctx, span := Start(ctx, "storage.TagVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.TagVersion(ctx, tag, move)
+}
+
+func (s *store) GetTaggedVersion(ctx context.Context, configID string, tag string) (_ *storage.VersionInfo, err error) {
+	ctx, span := Start(ctx, "storage.GetTaggedVersion", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetTaggedVersion(ctx, configID, tag)
+}
+
+func (s *store) ListReleaseTags(ctx context.Context, configID string) (_ []*storage.ReleaseTag, err error) {
+	ctx, span := Start(ctx, "storage.ListReleaseTags", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListReleaseTags(ctx, configID)
+}
+
+func (s *store) ListAuditEntries(ctx context.Context, configID string) (_ []*storage.AuditEntry, err error) {
+	ctx, span := Start(ctx, "storage.ListAuditEntries", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListAuditEntries(ctx, configID)
+}
+
+func (s *store) Clone(ctx context.Context, id string, newName string, actor string) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.Clone", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Clone(ctx, id, newName, actor)
+}
+
+func (s *store) SaveSet(ctx context.Context, set storage.SaveSet) (_ *storage.SaveSet, err error) {
+	ctx, span := Start(ctx, "storage.SaveSet", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveSet(ctx, set)
+}
+
+func (s *store) ImportConfig(ctx context.Context, dna *pb.GameDNA, versions []*storage.VersionInfo) (_ *pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.ImportConfig", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ImportConfig(ctx, dna, versions)
+}
+
+func (s *store) CreateSnapshot(ctx context.Context, snapshot *storage.Snapshot) (_ *storage.Snapshot, err error) {
+	ctx, span := Start(ctx, "storage.CreateSnapshot", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.CreateSnapshot(ctx, snapshot)
+}
+
+func (s *store) GetSnapshot(ctx context.Context, name string, withData bool) (_ *storage.Snapshot, err error) {
+	ctx, span := Start(ctx, "storage.GetSnapshot", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetSnapshot(ctx, name, withData)
+}
+
+func (s *store) ListSnapshots(ctx context.Context) (_ []*storage.Snapshot, err error) {
+	ctx, span := Start(ctx, "storage.ListSnapshots", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListSnapshots(ctx)
+}
+
+func (s *store) RestoreSnapshot(ctx context.Context, name string, actor string) (_ []*pb.GameDNA, err error) {
+	ctx, span := Start(ctx, "storage.RestoreSnapshot", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.RestoreSnapshot(ctx, name, actor)
+}
+
+func (s *store) TransferOwnership(ctx context.Context, from string, to string, actor string, dryRun bool) (_ *storage.OwnershipTransfer, err error) {
+	ctx, span := Start(ctx, "storage.TransferOwnership", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.TransferOwnership(ctx, from, to, actor, dryRun)
+}
+
+func (s *store) FindPrincipalData(ctx context.Context, principal string) (_ *storage.PrincipalData, err error) {
+	ctx, span := Start(ctx, "storage.FindPrincipalData", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.FindPrincipalData(ctx, principal)
+}
+
+func (s *store) AnonymizePrincipal(ctx context.Context, principal string, pseudonym string) (_ *storage.PrincipalData, err error) {
+	ctx, span := Start(ctx, "storage.AnonymizePrincipal", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.AnonymizePrincipal(ctx, principal, pseudonym)
+}
+
+func (s *store) SaveImportMapping(ctx context.Context, mapping *storage.ImportMapping) (_ *storage.ImportMapping, err error) {
+	ctx, span := Start(ctx, "storage.SaveImportMapping", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveImportMapping(ctx, mapping)
+}
+
+func (s *store) GetImportMapping(ctx context.Context, project string) (_ *storage.ImportMapping, err error) {
+	ctx, span := Start(ctx, "storage.GetImportMapping", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetImportMapping(ctx, project)
+}
+
+func (s *store) SaveAPIToken(ctx context.Context, token *storage.APIToken) (_ *storage.APIToken, err error) {
+	ctx, span := Start(ctx, "storage.SaveAPIToken", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveAPIToken(ctx, token)
+}
+
+func (s *store) GetAPIToken(ctx context.Context, id string) (_ *storage.APIToken, err error) {
+	ctx, span := Start(ctx, "storage.GetAPIToken", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetAPIToken(ctx, id)
+}
+
+func (s *store) FindAPIToken(ctx context.Context, hash string) (_ *storage.APIToken, err error) {
+	ctx, span := Start(ctx, "storage.FindAPIToken", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.FindAPIToken(ctx, hash)
+}
+
+func (s *store) ListAPITokens(ctx context.Context) (_ []*storage.APIToken, err error) {
+	ctx, span := Start(ctx, "storage.ListAPITokens", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListAPITokens(ctx)
+}
+
+func (s *store) SaveWaiver(ctx context.Context, waiver *storage.Waiver) (_ *storage.Waiver, err error) {
+	ctx, span := Start(ctx, "storage.SaveWaiver", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveWaiver(ctx, waiver)
+}
+
+func (s *store) ListWaivers(ctx context.Context, configID string) (_ []*storage.Waiver, err error) {
+	ctx, span := Start(ctx, "storage.ListWaivers", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListWaivers(ctx, configID)
+}
+
+func (s *store) SaveTemplate(ctx context.Context, template *storage.Template) (_ *storage.Template, err error) {
+	ctx, span := Start(ctx, "storage.SaveTemplate", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveTemplate(ctx, template)
+}
+
+func (s *store) GetTemplate(ctx context.Context, name string) (_ *storage.Template, err error) {
+	ctx, span := Start(ctx, "storage.GetTemplate", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetTemplate(ctx, name)
+}
+
+func (s *store) ListTemplates(ctx context.Context) (_ []*storage.Template, err error) {
+	ctx, span := Start(ctx, "storage.ListTemplates", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListTemplates(ctx)
+}
+
+func (s *store) DeleteTemplate(ctx context.Context, name string) (err error) {
+	ctx, span := Start(ctx, "storage.DeleteTemplate", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.DeleteTemplate(ctx, name)
+}
+
+func (s *store) SavePropertySchema(ctx context.Context, schema *storage.PropertySchema) (_ *storage.PropertySchema, err error) {
+	ctx, span := Start(ctx, "storage.SavePropertySchema", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SavePropertySchema(ctx, schema)
+}
+
+func (s *store) GetPropertySchema(ctx context.Context, project, name string) (_ *storage.PropertySchema, err error) {
+	ctx, span := Start(ctx, "storage.GetPropertySchema", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetPropertySchema(ctx, project, name)
+}
+
+func (s *store) ListPropertySchemas(ctx context.Context, project string) (_ []*storage.PropertySchema, err error) {
+	ctx, span := Start(ctx, "storage.ListPropertySchemas", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListPropertySchemas(ctx, project)
+}
+
+func (s *store) DeletePropertySchema(ctx context.Context, project, name string) (err error) {
+	ctx, span := Start(ctx, "storage.DeletePropertySchema", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.DeletePropertySchema(ctx, project, name)
+}
+
+func (s *store) SaveWebhook(ctx context.Context, webhook *storage.Webhook) (_ *storage.Webhook, err error) {
+	ctx, span := Start(ctx, "storage.SaveWebhook", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveWebhook(ctx, webhook)
+}
+
+func (s *store) GetWebhook(ctx context.Context, id string) (_ *storage.Webhook, err error) {
+	ctx, span := Start(ctx, "storage.GetWebhook", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetWebhook(ctx, id)
+}
+
+func (s *store) ListWebhooks(ctx context.Context) (_ []*storage.Webhook, err error) {
+	ctx, span := Start(ctx, "storage.ListWebhooks", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListWebhooks(ctx)
+}
+
+func (s *store) DeleteWebhook(ctx context.Context, id string) (err error) {
+	ctx, span := Start(ctx, "storage.DeleteWebhook", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *store) SaveWebhookDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (_ *storage.WebhookDelivery, err error) {
+	ctx, span := Start(ctx, "storage.SaveWebhookDelivery", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveWebhookDelivery(ctx, delivery)
+}
+
+func (s *store) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) (_ []*storage.WebhookDelivery, err error) {
+	ctx, span := Start(ctx, "storage.ClaimWebhookDeliveries", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ClaimWebhookDeliveries(ctx, now, limit, lease)
+}
+
+func (s *store) ListWebhookDeliveries(ctx context.Context, webhookID string, state string, limit int) (_ []*storage.WebhookDelivery, err error) {
+	ctx, span := Start(ctx, "storage.ListWebhookDeliveries", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ListWebhookDeliveries(ctx, webhookID, state, limit)
+}
+
+func (s *store) PruneWebhookDeliveries(ctx context.Context, finishedBefore time.Time) (_ int64, err error) {
+	ctx, span := Start(ctx, "storage.PruneWebhookDeliveries", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PruneWebhookDeliveries(ctx, finishedBefore)
+}
+
+func (s *store) ClaimIdempotencyKey(ctx context.Context, key *storage.IdempotencyKey) (_ *storage.IdempotencyKey, err error) {
+	ctx, span := Start(ctx, "storage.ClaimIdempotencyKey", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ClaimIdempotencyKey(ctx, key)
+}
+
+func (s *store) CompleteIdempotencyKey(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) (err error) {
+	ctx, span := Start(ctx, "storage.CompleteIdempotencyKey", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.CompleteIdempotencyKey(ctx, scope, key, response, expiresAt)
+}
+
+func (s *store) PruneIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (_ int64, err error) {
+	ctx, span := Start(ctx, "storage.PruneIdempotencyKeys", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.PruneIdempotencyKeys(ctx, expiredBefore)
+}
+
+func (s *store) ConfigIDsAfter(ctx context.Context, after string, limit int) (_ []string, err error) {
+	ctx, span := Start(ctx, "storage.ConfigIDsAfter", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.ConfigIDsAfter(ctx, after, limit)
+}
+
+func (s *store) SaveDraft(ctx context.Context, draft *storage.Draft) (_ *storage.Draft, err error) {
+	ctx, span := Start(ctx, "storage.SaveDraft", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveDraft(ctx, draft)
+}
+
+func (s *store) GetDraft(ctx context.Context, configID, owner string) (_ *storage.Draft, err error) {
+	ctx, span := Start(ctx, "storage.GetDraft", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetDraft(ctx, configID, owner)
+}
+
+func (s *store) DeleteDraft(ctx context.Context, configID, owner string) (err error) {
+	ctx, span := Start(ctx, "storage.DeleteDraft", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.DeleteDraft(ctx, configID, owner)
+}
+
+func (s *store) FixChecksums(ctx context.Context, fixes []storage.ChecksumMismatch) (_ int64, err error) {
+	ctx, span := Start(ctx, "storage.FixChecksums", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.FixChecksums(ctx, fixes)
+}
+
+func (s *store) SaveChecksumJob(ctx context.Context, job *storage.ChecksumJob) (_ *storage.ChecksumJob, err error) {
+	ctx, span := Start(ctx, "storage.SaveChecksumJob", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.SaveChecksumJob(ctx, job)
+}
+
+func (s *store) GetChecksumJob(ctx context.Context, id string) (_ *storage.ChecksumJob, err error) {
+	ctx, span := Start(ctx, "storage.GetChecksumJob", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.GetChecksumJob(ctx, id)
+}
+
+func (s *store) IndexStats(ctx context.Context) (_ []storage.IndexStat, err error) {
+	ctx, span := Start(ctx, "storage.IndexStats", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.IndexStats(ctx)
+}
+
+func (s *store) Ping(ctx context.Context) (err error) {
+	ctx, span := Start(ctx, "storage.Ping", SpanKindClient)
+	defer func() { span.Finish(err) }()
+	return s.next.Ping(ctx)
+}
+
+func (s *store) Close() {
+	s.next.Close()
+}
@@ -0,0 +1,78 @@
+// Package tracing correlates everything a request did. It propagates W3C
+// trace context, so the service's trace IDs match the caller's OpenTelemetry
+// traces, optionally exports spans for each call over OTLP, and keeps a
+// bounded journal of recent requests with their log lines and config changes
+// for incident triage.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// TraceparentHeader carries W3C trace context on REST requests and, under
+	// the same lowercase key, on gRPC calls.
+	TraceparentHeader = "traceparent"
+	// TraceIDHeader returns the trace ID on REST responses.
+	TraceIDHeader = "X-Trace-Id"
+	// TraceIDMetadata returns the trace ID in gRPC response headers and trailers.
+	TraceIDMetadata = "x-trace-id"
+)
+
+// NewTraceID returns a random 16-byte trace ID in hex.
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// NewSpanID returns a random 8-byte span ID in hex.
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+// Traceparent returns a traceparent value for traceID with a new span ID.
+func Traceparent(traceID string) string {
+	return "00-" + traceID + "-" + NewSpanID() + "-01"
+}
+
+// TraceID returns the trace ID of a traceparent value, or "" when the value
+// is malformed or the ID is all zeros.
+func TraceID(traceparent string) string {
+	traceID, _, _, _ := ParseTraceparent(traceparent)
+	return traceID
+}
+
+// ParseTraceparent returns the trace ID, parent span ID and sampled flag of a
+// traceparent value. ok is false, and the rest empty, when the value is
+// malformed or either ID is all zeros.
+func ParseTraceparent(traceparent string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false, false
+	}
+	traceID, spanID = strings.ToLower(parts[1]), strings.ToLower(parts[2])
+	if len(traceID) != 32 || !isHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return "", "", false, false
+	}
+	if len(spanID) != 16 || !isHex(spanID) || strings.Trim(spanID, "0") == "" {
+		return "", "", false, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return "", "", false, false
+	}
+	return traceID, spanID, flags[0]&1 == 1, true
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read only fails if the OS has no randomness source.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
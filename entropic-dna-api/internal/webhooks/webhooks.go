@@ -0,0 +1,197 @@
+// Package webhooks sends config lifecycle events to registered HTTP
+// endpoints. Events are queued in the store as deliveries and sent by a
+// background sweep, which retries failed attempts with exponential backoff.
+// Every request is signed with its webhook's secret:
+//
+//	X-Entropic-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+// Receivers recompute the HMAC over the raw body to check a delivery came
+// from this server, and may reject old timestamps to stop replays.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// SecretPrefix starts every signing secret, so leaked ones are easy to spot.
+const SecretPrefix = "whsec_"
+
+// Headers set on every delivery.
+const (
+	SignatureHeader = "X-Entropic-Signature"
+	EventHeader     = "X-Entropic-Event"
+	DeliveryHeader  = "X-Entropic-Delivery"
+)
+
+const (
+	// maxErrorBody bounds how much of a failed response is kept as its error.
+	maxErrorBody = 512
+	firstBackoff = 30 * time.Second
+	maxBackoff   = time.Hour
+)
+
+// ErrInvalidSignature indicates a signature header that doesn't match the
+// body, or is malformed.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Options configure a Sender.
+type Options struct {
+	// Timeout bounds one delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	MaxAttempts int
+	// Retention is how long finished deliveries are kept for the delivery
+	// status API.
+	Retention time.Duration
+}
+
+// Sender posts deliveries to their webhooks.
+type Sender struct {
+	opts   Options
+	client *http.Client
+}
+
+// NewSender creates a sender with opts.
+func NewSender(opts Options) *Sender {
+	return &Sender{opts: opts, client: &http.Client{Timeout: opts.Timeout}}
+}
+
+// Timeout returns how long one delivery attempt may take.
+func (s *Sender) Timeout() time.Duration { return s.opts.Timeout }
+
+// MaxAttempts returns how many times a delivery is tried.
+func (s *Sender) MaxAttempts() int { return s.opts.MaxAttempts }
+
+// Retention returns how long finished deliveries are kept.
+func (s *Sender) Retention() time.Duration { return s.opts.Retention }
+
+// NewSecret returns a new random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header against body. Signatures made more than
+// tolerance before or after now are rejected; a zero tolerance accepts any
+// time.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+		}
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type payload struct {
+	Event      string          `json:"event"`
+	ConfigID   string          `json:"configId"`
+	OccurredAt string          `json:"occurredAt"`
+	GameDNA    json.RawMessage `json:"gameDna,omitempty"`
+}
+
+// Payload returns the JSON body sent for an event:
+//
+//	{"event": "published", "configId": "...", "occurredAt": "...", "gameDna": {...}}
+//
+// gameDna is the config as the change left it, or as it was before a delete.
+func Payload(eventType, configID string, dna *pb.GameDNA, at time.Time) ([]byte, error) {
+	p := payload{Event: eventType, ConfigID: configID, OccurredAt: at.UTC().Format(time.RFC3339Nano)}
+	if dna != nil {
+		data, err := protojson.Marshal(dna)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		p.GameDNA = data
+	}
+	return json.Marshal(p)
+}
+
+// Send makes one attempt at a delivery. It returns the response status, 0 if
+// none was received, and an error unless the webhook answered with a 2xx.
+func (s *Sender) Send(ctx context.Context, webhook *storage.Webhook, delivery *storage.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if text := strings.TrimSpace(string(body)); text != "" {
+			return resp.StatusCode, fmt.Errorf("webhook returned %s: %s", resp.Status, text)
+		}
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns how long to wait after the given failed attempt, counting
+// from 1: 30s, doubling up to an hour.
+func Backoff(attempt int) time.Duration {
+	d := firstBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,307 @@
+// Package client is the Go SDK for the Entropic DNA API. It wraps the
+// generated gRPC stubs with what every caller ends up writing: credentials on
+// each call, a default deadline, retries with backoff for calls turned away
+// before they ran, errors comparable with errors.Is, an iterator over
+// ListGameDNA pages and a WatchGameDNA loop that resumes after disconnects.
+// Large responses arrive compressed with zstd or gzip.
+//
+//	c, err := client.Dial(ctx, "dna-api:50051", client.Options{APIKey: key})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	dna, err := c.Get(ctx, "arena-shooter")
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+//
+// Calls the helpers don't cover go through Service, which applies the same
+// options.
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	_ "github.com/entropic-engine/entropic-dna-api/internal/compression" // accept zstd and gzip responses
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Defaults for the zero Options.
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+)
+
+// maxServerWait is the server's default limit on long polls.
+const maxServerWait = time.Minute
+
+// apiKeyMetadata is the metadata key the server reads API keys from.
+const apiKeyMetadata = "x-api-key"
+
+// Options configure a Client. The zero value talks plaintext without
+// credentials and uses the defaults above.
+type Options struct {
+	// APIKey is sent as x-api-key on every call.
+	APIKey string
+	// Token is sent as a bearer token on every call.
+	Token string
+	// Timeout bounds unary calls whose context has no deadline; streams are
+	// not bounded. 0 uses DefaultTimeout, negative disables it.
+	Timeout time.Duration
+	// MaxRetries is how many times a unary call turned away with Unavailable
+	// or ResourceExhausted is tried again. 0 uses DefaultMaxRetries, negative
+	// disables retries.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// one after up to MaxBackoff. A RetryInfo sent by the server, as the rate
+	// limiter does, takes precedence. 0 uses the defaults.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// DialOptions are passed to grpc.DialContext by Dial. Without transport
+	// credentials among them the connection is plaintext.
+	DialOptions []grpc.DialOption
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = DefaultMaxRetries
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = DefaultInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	return o
+}
+
+// Client calls the API over one connection. It is safe for concurrent use.
+type Client struct {
+	conn *grpc.ClientConn
+	svc  pb.GameDNAServiceClient
+	opts Options
+}
+
+// Dial connects to the API at addr.
+func Dial(ctx context.Context, addr string, opts Options) (*Client, error) {
+	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts.DialOptions...)
+	conn, err := grpc.DialContext(ctx, addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+	c := New(conn, opts)
+	c.conn = conn
+	return c, nil
+}
+
+// New returns a client calling the API over cc, which the caller keeps
+// ownership of.
+func New(cc grpc.ClientConnInterface, opts Options) *Client {
+	opts = opts.withDefaults()
+	return &Client{svc: pb.NewGameDNAServiceClient(&conn{cc: cc, opts: opts}), opts: opts}
+}
+
+// Service returns the generated stub, with the client's credentials,
+// deadline, retries and errors applied to every call.
+func (c *Client) Service() pb.GameDNAServiceClient {
+	return c.svc
+}
+
+// Close closes the connection made by Dial. It does nothing for clients made
+// with New.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Get returns the config with the given ID or slug.
+func (c *Client) Get(ctx context.Context, id string) (*pb.GameDNA, error) {
+	resp, err := c.svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GameDna, nil
+}
+
+// WaitForChange long-polls the config with the given ID or slug until its
+// checksum differs from checksum, that of the caller's copy, or wait passes,
+// and reports whether it changed. A wait of 0 waits as long as the server
+// allows. Without a deadline in ctx the call may take wait plus the client's
+// Timeout.
+func (c *Client) WaitForChange(ctx context.Context, id, checksum string, wait time.Duration) (*pb.GameDNA, bool, error) {
+	if _, ok := ctx.Deadline(); !ok && c.opts.Timeout > 0 {
+		budget := wait
+		if budget <= 0 {
+			budget = maxServerWait
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget+c.opts.Timeout)
+		defer cancel()
+	}
+	resp, err := c.svc.GetGameDNA(ctx, &pb.GetGameDNARequest{
+		Id:           id,
+		WaitChecksum: checksum,
+		WaitSeconds:  int64((wait + time.Second - 1) / time.Second),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.GameDna, !resp.NotModified, nil
+}
+
+// GetPublished returns the published config with the given slug, as game
+// clients read it.
+func (c *Client) GetPublished(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	resp, err := c.svc.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: slug})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GameDna, nil
+}
+
+// Create creates dna and returns it as stored.
+func (c *Client) Create(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+	resp, err := c.svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GameDna, nil
+}
+
+// Update replaces the config dna.Id names with dna and returns it as stored.
+func (c *Client) Update(ctx context.Context, dna *pb.GameDNA) (*pb.GameDNA, error) {
+	resp, err := c.svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: dna.GetId(), GameDna: dna})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GameDna, nil
+}
+
+// Publish locks the config with the given ID or slug and returns it.
+func (c *Client) Publish(ctx context.Context, id string) (*pb.GameDNA, error) {
+	resp, err := c.svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GameDna, nil
+}
+
+// Delete deletes the config with the given ID. Deleting a config that no
+// longer exists succeeds, so deletes can be retried.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	_, err := c.svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: id, Idempotent: true})
+	return err
+}
+
+// conn applies Options to the calls made through a connection.
+type conn struct {
+	cc   grpc.ClientConnInterface
+	opts Options
+}
+
+func (c *conn) outgoing(ctx context.Context) context.Context {
+	var pairs []string
+	if c.opts.APIKey != "" {
+		pairs = append(pairs, apiKeyMetadata, c.opts.APIKey)
+	}
+	if c.opts.Token != "" {
+		pairs = append(pairs, "authorization", "Bearer "+c.opts.Token)
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func (c *conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	ctx = c.outgoing(ctx)
+	if _, ok := ctx.Deadline(); !ok && c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.cc.Invoke(ctx, method, args, reply, opts...)
+		if err == nil {
+			return nil
+		}
+		wait, retry := c.retryAfter(err, attempt)
+		if !retry {
+			return newError(err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return newError(err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := c.cc.NewStream(c.outgoing(ctx), desc, method, opts...)
+	if err != nil {
+		return nil, newError(err)
+	}
+	return &clientStream{ClientStream: stream}, nil
+}
+
+// retryAfter returns how long to wait before trying a call that failed with
+// err again, and whether to try at all. Only Unavailable and
+// ResourceExhausted are retried: the server's rate limiter answers with the
+// latter before a handler runs, and the transport with the former when the
+// call could not be sent.
+func (c *conn) retryAfter(err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.opts.MaxRetries {
+		return 0, false
+	}
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.Unavailable, codes.ResourceExhausted:
+	default:
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	wait := c.opts.InitialBackoff
+	for i := 0; i < attempt && wait < c.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, c.opts.MaxBackoff)
+	// Jitter keeps clients turned away together from retrying together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+// clientStream turns the errors of a stream into *Error.
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return newError(s.ClientStream.RecvMsg(m))
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	return newError(s.ClientStream.SendMsg(m))
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors matching the server's storage errors, for use with errors.Is on the
+// errors the client returns.
+var (
+	// ErrNotFound indicates the config, version or other entity does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+	// ErrLocked indicates the config is published, so it cannot be changed.
+	ErrLocked = errors.New("locked")
+	// ErrNotLocked indicates the config is not published, so it cannot be
+	// unpublished or canaried.
+	ErrNotLocked = errors.New("not locked")
+	// ErrConflict indicates a slug, name or other unique value is taken.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden indicates the caller may not make the call or reach the
+	// data.
+	ErrForbidden = errors.New("forbidden")
+	// ErrInvalid indicates a malformed request or a config that failed
+	// validation; Error.FieldViolations says which fields.
+	ErrInvalid = errors.New("invalid argument")
+	// ErrUnauthenticated indicates missing or rejected credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrUnavailable indicates the server could not be reached or was
+	// overloaded, and stayed so through the retries.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// FieldViolation is a problem with one field of a request or config.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error is a failed call. Its Code is the gRPC status code; errors.Is matches
+// it against the Err values above.
+type Error struct {
+	Code    codes.Code
+	Message string
+	// FieldViolations lists the fields a request or config was rejected for.
+	FieldViolations []FieldViolation
+	// RetryAfter is how long the server asked the caller to wait, if it did.
+	RetryAfter time.Duration
+	// RequestID identifies the call in the server's logs, if it sent one.
+	RequestID string
+
+	status *status.Status
+}
+
+// newError converts a status error to *Error. nil, io.EOF and errors that
+// carry no status are returned as they are.
+func newError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	e := &Error{Code: st.Code(), Message: st.Message(), status: st}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				e.FieldViolations = append(e.FieldViolations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		case *errdetails.RetryInfo:
+			if d.RetryDelay != nil {
+				e.RetryAfter = d.RetryDelay.AsDuration()
+			}
+		case *errdetails.RequestInfo:
+			e.RequestID = d.RequestId
+		}
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Code.String() + ": " + e.Message
+}
+
+// GRPCStatus returns the status the error was made from, so status.FromError
+// and status.Code keep working on it.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// Is reports whether target is the Err value for e's code. The server sends
+// locked and not-locked configs as FailedPrecondition, ending the message
+// with the storage error, so those are told apart by it.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == codes.NotFound
+	case ErrLocked:
+		return e.Code == codes.FailedPrecondition && strings.HasSuffix(e.Message, ": locked")
+	case ErrNotLocked:
+		return e.Code == codes.FailedPrecondition && strings.HasSuffix(e.Message, ": not locked")
+	case ErrConflict:
+		return e.Code == codes.AlreadyExists
+	case ErrForbidden:
+		return e.Code == codes.PermissionDenied
+	case ErrInvalid:
+		return e.Code == codes.InvalidArgument
+	case ErrUnauthenticated:
+		return e.Code == codes.Unauthenticated
+	case ErrUnavailable:
+		return e.Code == codes.Unavailable || e.Code == codes.ResourceExhausted
+	}
+	return false
+}
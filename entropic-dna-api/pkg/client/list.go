@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ListIterator walks every config matching a ListGameDNA request, fetching
+// pages by next_page_token as it goes:
+//
+//	it := c.List(ctx, &pb.ListGameDNARequest{Genre: "FPS"})
+//	for it.Next() {
+//		dna := it.GameDNA()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ListIterator struct {
+	ctx  context.Context
+	svc  pb.GameDNAServiceClient
+	req  *pb.ListGameDNARequest
+	page []*pb.GameDNA
+	cur  *pb.GameDNA
+	done bool
+	err  error
+}
+
+// List returns an iterator over the configs matching req, which is not
+// modified. req.PageSize sets how many are fetched per call; req.Page must
+// be unset, as the iterator pages by token.
+func (c *Client) List(ctx context.Context, req *pb.ListGameDNARequest) *ListIterator {
+	return &ListIterator{ctx: ctx, svc: c.svc, req: proto.Clone(req).(*pb.ListGameDNARequest)}
+}
+
+// Next advances to the next config, fetching the next page when the current
+// one is used up. It returns false once every config has been returned or a
+// call fails; Err tells which.
+func (it *ListIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			it.cur = nil
+			return false
+		}
+		resp, err := it.svc.ListGameDNA(it.ctx, it.req)
+		if err != nil {
+			it.err = err
+			continue
+		}
+		it.page = resp.Items
+		it.req.PageToken = resp.NextPageToken
+		it.done = resp.NextPageToken == ""
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// GameDNA returns the config Next advanced to.
+func (it *ListIterator) GameDNA() *pb.GameDNA {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+// ListAll returns every config matching req.
+func (c *Client) ListAll(ctx context.Context, req *pb.ListGameDNARequest) ([]*pb.GameDNA, error) {
+	var all []*pb.GameDNA
+	it := c.List(ctx, req)
+	for it.Next() {
+		all = append(all, it.GameDNA())
+	}
+	return all, it.Err()
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// errStreamEnded reports a watch the server ended without an error, as it
+// does when shutting down.
+var errStreamEnded = errors.New("watch ended by the server")
+
+// Watch streams the change events matching req to fn until ctx is done, fn
+// returns an error or the server rejects the watch. When the stream drops,
+// as it does when the server restarts or the watcher falls behind, Watch
+// reconnects with backoff and resumes after the last event fn was given, so
+// events retained by the server are not missed. It returns ctx.Err() once
+// ctx is done, and fn's error as is.
+func (c *Client) Watch(ctx context.Context, req *pb.WatchGameDNARequest, fn func(*pb.ConfigEvent) error) error {
+	req = proto.Clone(req).(*pb.WatchGameDNARequest)
+	failures := 0
+	for {
+		received, err := c.watchOnce(ctx, req, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var fnErr *callbackError
+		if errors.As(err, &fnErr) {
+			return fnErr.err
+		}
+		if err != errStreamEnded && !errors.Is(err, ErrUnavailable) {
+			return err
+		}
+
+		if received {
+			failures = 0
+		}
+		wait := c.opts.InitialBackoff
+		for i := 0; i < failures && wait < c.opts.MaxBackoff; i++ {
+			wait *= 2
+		}
+		failures++
+		timer := time.NewTimer(min(wait, c.opts.MaxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// callbackError carries an error returned by Watch's fn out of watchOnce.
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string { return e.err.Error() }
+
+// watchOnce runs one watch stream, advancing req.AfterSequence past each
+// event handled. It reports whether any event was received.
+func (c *Client) watchOnce(ctx context.Context, req *pb.WatchGameDNARequest, fn func(*pb.ConfigEvent) error) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.svc.WatchGameDNA(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	received := false
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return received, errStreamEnded
+		}
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if err := fn(ev); err != nil {
+			return received, &callbackError{err: err}
+		}
+		req.AfterSequence = ev.Sequence
+	}
+}
@@ -0,0 +1,47 @@
+import { createPromiseClient, type Interceptor, type PromiseClient } from '@connectrpc/connect';
+import { createGrpcWebTransport } from '@connectrpc/connect-web';
+import { GameDNAService } from './gen/entropic/dna/v1/service_connect.js';
+
+export * from './gen/entropic/dna/v1/game_dna_pb.js';
+export * from './gen/entropic/dna/v1/messages_pb.js';
+export * from './gen/entropic/dna/v1/service_pb.js';
+export * from './gen/entropic/dna/v1/validation_pb.js';
+export { GameDNAService };
+
+export type DnaClient = PromiseClient<typeof GameDNAService>;
+
+export interface DnaClientOptions {
+  /** The REST gateway's URL, such as https://dna.example.com. */
+  baseUrl: string;
+  /** An API key, sent as x-api-key. */
+  apiKey?: string;
+  /** Returns a JWT, sent as a bearer token on each call. */
+  token?: () => string | Promise<string>;
+  /** Sends base64 text frames instead of binary, for proxies that mangle binary bodies. */
+  textFormat?: boolean;
+  /** Run on each call after the credentials are set. */
+  interceptors?: Interceptor[];
+}
+
+/**
+ * Creates a client of every GameDNAService method but the client-streaming
+ * ImportGameDNA, which gRPC-Web can't carry. Server streams such as
+ * WatchGameDNA are async iterables.
+ */
+export function createDnaClient(options: DnaClientOptions): DnaClient {
+  const credentials: Interceptor = (next) => async (req) => {
+    if (options.apiKey) {
+      req.header.set('x-api-key', options.apiKey);
+    }
+    if (options.token) {
+      req.header.set('authorization', `Bearer ${await options.token()}`);
+    }
+    return next(req);
+  };
+  const transport = createGrpcWebTransport({
+    baseUrl: options.baseUrl.replace(/\/$/, ''),
+    useBinaryFormat: !options.textFormat,
+    interceptors: [credentials, ...(options.interceptors ?? [])],
+  });
+  return createPromiseClient(GameDNAService, transport);
+}
@@ -0,0 +1,63 @@
+using System;
+using System.Net.Http;
+using Entropic.Dna.V1;
+using Grpc.Core;
+using Grpc.Core.Interceptors;
+using Grpc.Net.Client;
+using Grpc.Net.Client.Web;
+
+namespace Entropic.Dna
+{
+    /// <summary>
+    /// Creates clients of the Game DNA service that call the gateway's REST
+    /// port over gRPC-Web, which runs on Unity's HTTP/1.1 stack. Every method
+    /// but the client-streaming ImportGameDNA works; server streams such as
+    /// WatchGameDNA are read with ResponseStream.MoveNext.
+    /// </summary>
+    public static class DnaClient
+    {
+        /// <summary>
+        /// Creates a client of the gateway at baseUrl, such as
+        /// https://dna.example.com, sending apiKey as x-api-key when given.
+        /// Dispose of the channel it returns when done with the client.
+        /// </summary>
+        public static GameDNAService.GameDNAServiceClient Create(string baseUrl, string apiKey, out GrpcChannel channel)
+        {
+            channel = GrpcChannel.ForAddress(baseUrl, new GrpcChannelOptions
+            {
+                HttpHandler = new GrpcWebHandler(GrpcWebMode.GrpcWeb, new HttpClientHandler()),
+            });
+            var invoker = channel.Intercept(metadata =>
+            {
+                if (!string.IsNullOrEmpty(apiKey))
+                {
+                    metadata.Add("x-api-key", apiKey);
+                }
+                return metadata;
+            });
+            return new GameDNAService.GameDNAServiceClient(invoker);
+        }
+
+        /// <summary>
+        /// Creates a client sending the JWT token returns, fetched per call,
+        /// as a bearer token.
+        /// </summary>
+        public static GameDNAService.GameDNAServiceClient CreateWithToken(string baseUrl, Func<string> token, out GrpcChannel channel)
+        {
+            if (token == null)
+            {
+                throw new ArgumentNullException(nameof(token));
+            }
+            channel = GrpcChannel.ForAddress(baseUrl, new GrpcChannelOptions
+            {
+                HttpHandler = new GrpcWebHandler(GrpcWebMode.GrpcWeb, new HttpClientHandler()),
+            });
+            var invoker = channel.Intercept(metadata =>
+            {
+                metadata.Add("authorization", "Bearer " + token());
+                return metadata;
+            });
+            return new GameDNAService.GameDNAServiceClient(invoker);
+        }
+    }
+}
@@ -1,11 +1,92 @@
 package tests
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	pb "github.com/entropic-engine/entropic-dna-api/gen/proto/entropic/dna/v1"
+	"github.com/entropic-engine/entropic-dna-api/internal/api"
+	"github.com/entropic-engine/entropic-dna-api/internal/archive"
+	"github.com/entropic-engine/entropic-dna-api/internal/auth"
+	"github.com/entropic-engine/entropic-dna-api/internal/budget"
+	"github.com/entropic-engine/entropic-dna-api/internal/cache"
+	"github.com/entropic-engine/entropic-dna-api/internal/compression"
+	"github.com/entropic-engine/entropic-dna-api/internal/config"
+	"github.com/entropic-engine/entropic-dna-api/internal/diff"
+	"github.com/entropic-engine/entropic-dna-api/internal/dnactl"
+	"github.com/entropic-engine/entropic-dna-api/internal/editing"
+	"github.com/entropic-engine/entropic-dna-api/internal/events"
+	"github.com/entropic-engine/entropic-dna-api/internal/eventsource"
+	"github.com/entropic-engine/entropic-dna-api/internal/faults"
+	"github.com/entropic-engine/entropic-dna-api/internal/ffi"
+	"github.com/entropic-engine/entropic-dna-api/internal/hooks"
+	"github.com/entropic-engine/entropic-dna-api/internal/integrity"
+	"github.com/entropic-engine/entropic-dna-api/internal/metrics"
+	"github.com/entropic-engine/entropic-dna-api/internal/models"
+	"github.com/entropic-engine/entropic-dna-api/internal/msgpack"
+	"github.com/entropic-engine/entropic-dna-api/internal/ratelimit"
+	"github.com/entropic-engine/entropic-dna-api/internal/replicas"
+	"github.com/entropic-engine/entropic-dna-api/internal/residency"
+	"github.com/entropic-engine/entropic-dna-api/internal/resilience"
+	"github.com/entropic-engine/entropic-dna-api/internal/schema"
+	"github.com/entropic-engine/entropic-dna-api/internal/server"
+	"github.com/entropic-engine/entropic-dna-api/internal/sheets"
 	"github.com/entropic-engine/entropic-dna-api/internal/storage"
+	"github.com/entropic-engine/entropic-dna-api/internal/tokens"
+	"github.com/entropic-engine/entropic-dna-api/internal/tracing"
+	"github.com/entropic-engine/entropic-dna-api/internal/webhooks"
+	sdk "github.com/entropic-engine/entropic-dna-api/pkg/client"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestMemoryStoreCRUD(t *testing.T) {
@@ -153,7 +234,8 @@ func TestMemoryStorePublish(t *testing.T) {
 	}
 
 	// Publish (lock)
-	published, err := store.PublishVersion(ctx, created.Id, "test")
+	report := &pb.ValidationResponse{IsValid: true}
+	published, err := store.PublishVersion(ctx, created.Id, "test", created.Checksum, "", report)
 	if err != nil {
 		t.Fatalf("Publish failed: %v", err)
 	}
@@ -161,6 +243,18 @@ func TestMemoryStorePublish(t *testing.T) {
 		t.Error("Expected config to be locked after publish")
 	}
 
+	// Publish records a snapshot carrying the validation report
+	versions, err := store.GetVersionHistory(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions after publish, got %d", len(versions))
+	}
+	if versions[1].Validation != report {
+		t.Error("Expected published snapshot to carry the validation report")
+	}
+
 	// Try to update locked config
 	published.Genre = "RPG"
 	_, err = store.Update(ctx, published)
@@ -219,3 +313,9366 @@ func TestMemoryStoreClone(t *testing.T) {
 		t.Errorf("Expected 2 items in list, got %d", len(items))
 	}
 }
+
+func TestMemoryStoreSaveSet(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	base, err := store.Create(ctx, &pb.GameDNA{
+		Name:            "Base Game",
+		Genre:           "FPS",
+		TargetPlatforms: []string{"PC"},
+		TargetFps:       60,
+		TimeScale:       1.0,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	saved, err := store.SaveSet(ctx, storage.SaveSet{
+		Creates: []*pb.GameDNA{{Name: "Console Variant", Genre: "FPS", TargetPlatforms: []string{"Console"}}},
+		Updates: []*pb.GameDNA{{Id: base.Id, Name: "Base Game", Genre: "RPG", TargetPlatforms: []string{"PC"}}},
+	})
+	if err != nil {
+		t.Fatalf("SaveSet failed: %v", err)
+	}
+	if len(saved.Creates) != 1 || saved.Creates[0].Id == "" {
+		t.Fatalf("Expected 1 created config with an ID, got %+v", saved.Creates)
+	}
+	if len(saved.Updates) != 1 || saved.Updates[0].Genre != "RPG" {
+		t.Fatalf("Expected base config updated to RPG, got %+v", saved.Updates)
+	}
+
+	// A set containing an update to a missing config must not apply its creates.
+	_, err = store.SaveSet(ctx, storage.SaveSet{
+		Creates: []*pb.GameDNA{{Name: "Orphan Variant"}},
+		Updates: []*pb.GameDNA{{Id: "missing", Name: "Missing"}},
+	})
+	if err == nil {
+		t.Fatal("Expected error for save set with missing config, got nil")
+	}
+
+	_, total, err := store.List(ctx, storage.ListFilters{}, storage.Pagination{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 configs after failed save set, got %d", total)
+	}
+}
+
+func TestMemoryStoreSnapshots(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	base, err := store.Create(ctx, &pb.GameDNA{Name: "Base", Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	variant, err := store.Create(ctx, &pb.GameDNA{Name: "PC Variant", Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	snapshot, err := store.CreateSnapshot(ctx, &storage.Snapshot{
+		Name:    "1.4.0 live set",
+		Entries: []storage.SnapshotEntry{{ConfigID: base.Id}, {ConfigID: variant.Id}},
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	if snapshot.Entries[0].VersionNum != 1 {
+		t.Errorf("Expected latest version 1 to be captured, got %d", snapshot.Entries[0].VersionNum)
+	}
+
+	if _, err := store.CreateSnapshot(ctx, &storage.Snapshot{Name: "1.4.0 live set"}); err == nil {
+		t.Error("Expected error creating duplicate snapshot, got nil")
+	}
+
+	// Diverge both configs, then restore the snapshot.
+	base.Genre = "RPG"
+	if _, err := store.Update(ctx, base); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	variant.Genre = "RPG"
+	if _, err := store.Update(ctx, variant); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	restored, err := store.RestoreSnapshot(ctx, "1.4.0 live set", "test")
+	if err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("Expected 2 restored configs, got %d", len(restored))
+	}
+	for _, dna := range restored {
+		if dna.Genre != "FPS" {
+			t.Errorf("Expected genre 'FPS' after restore, got '%s'", dna.Genre)
+		}
+	}
+
+	exported, err := store.GetSnapshot(ctx, "1.4.0 live set", true)
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+	for _, entry := range exported.Entries {
+		if entry.Data == nil || entry.Data.Genre != "FPS" {
+			t.Errorf("Expected exported entry data with genre 'FPS', got %+v", entry.Data)
+		}
+	}
+}
+
+func TestMemoryStoreVersionValidationReports(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	createReport := &pb.ValidationResponse{IsValid: true, RulesVersion: "go-basic/1"}
+	created, err := store.Create(ctx, &pb.GameDNA{Name: "Reported Game"}, storage.WithValidation(createReport))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	created.Genre = "RPG"
+	if _, err := store.Update(ctx, created); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if _, err := store.RollbackToVersion(ctx, created.Id, 1, "test"); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	versions, err := store.GetVersionHistory(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if versions[0].Validation.GetRulesVersion() != "go-basic/1" {
+		t.Errorf("Expected create snapshot to carry rules version, got %+v", versions[0].Validation)
+	}
+	if versions[1].Validation != nil {
+		t.Errorf("Expected no report on update without WithValidation, got %+v", versions[1].Validation)
+	}
+	if versions[2].Validation != createReport {
+		t.Error("Expected rollback snapshot to carry the restored version's report")
+	}
+}
+
+func TestMemoryStorePublishedOnlyFilter(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	draft, err := store.Create(ctx, &pb.GameDNA{Name: "Draft"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	live, err := store.Create(ctx, &pb.GameDNA{Name: "Live"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.PublishVersion(ctx, live.Id, "test", live.Checksum, "", &pb.ValidationResponse{IsValid: true, RulesVersion: "go-basic/1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	items, total, err := store.List(ctx, storage.ListFilters{PublishedOnly: true}, storage.Pagination{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total != 1 || items[0].Id != live.Id {
+		t.Fatalf("Expected only the published config, got %d items (draft %s)", total, draft.Id)
+	}
+	if items[0].PublishedRulesVersion != "go-basic/1" {
+		t.Errorf("Expected published rules version 'go-basic/1', got '%s'", items[0].PublishedRulesVersion)
+	}
+}
+
+func TestMemoryStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	arena, err := store.Create(ctx, &pb.GameDNA{Name: "Neon Arena", TargetFps: 120, MaxPlayers: 8, Tags: []string{"pvp"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	quest, err := store.Create(ctx, &pb.GameDNA{Name: "Quiet Quest", TargetFps: 30, MaxPlayers: 1, CustomProperties: map[string]string{"biome": "Neon forest"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	search := func(query storage.SearchQuery) []string {
+		t.Helper()
+		items, total, err := store.Search(ctx, query, storage.Pagination{Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if int(total) != len(items) {
+			t.Fatalf("Expected total %d to match %d items", total, len(items))
+		}
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	if got := search(storage.SearchQuery{Text: "NEON"}); !slices.Equal(got, []string{"Neon Arena", "Quiet Quest"}) {
+		t.Errorf("Expected both configs to match 'NEON' through name and custom properties, got %v", got)
+	}
+	if got := search(storage.SearchQuery{Text: "neon pvp"}); !slices.Equal(got, []string{"Neon Arena"}) {
+		t.Errorf("Expected only the tagged config to match 'neon pvp', got %v", got)
+	}
+	if got := search(storage.SearchQuery{Predicates: []storage.NumericPredicate{{Field: "target_fps", Op: storage.OpGte, Value: 60}}}); !slices.Equal(got, []string{"Neon Arena"}) {
+		t.Errorf("Expected target_fps >= 60 to match the arena, got %v", got)
+	}
+	if got := search(storage.SearchQuery{Text: "neon", Predicates: []storage.NumericPredicate{{Field: "max_players", Op: storage.OpBetween, Value: 1, Upper: 4}}}); !slices.Equal(got, []string{"Quiet Quest"}) {
+		t.Errorf("Expected max_players between 1 and 4 to match the quest, got %v", got)
+	}
+
+	arena.Name = "Solar Arena"
+	if _, err := store.Update(ctx, arena); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := search(storage.SearchQuery{Text: "neon"}); !slices.Equal(got, []string{"Quiet Quest"}) {
+		t.Errorf("Expected the renamed config to drop out of 'neon', got %v", got)
+	}
+	if got := search(storage.SearchQuery{Text: "solar"}); !slices.Equal(got, []string{"Solar Arena"}) {
+		t.Errorf("Expected the renamed config to match 'solar', got %v", got)
+	}
+
+	if err := store.Delete(ctx, quest.Id); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := search(storage.SearchQuery{Text: "forest"}); len(got) != 0 {
+		t.Errorf("Expected the deleted config to be gone from the index, got %v", got)
+	}
+
+	for _, p := range []storage.NumericPredicate{
+		{Field: "player_count", Op: storage.OpEq, Value: 1},
+		{Field: "target_fps", Op: "~", Value: 1},
+		{Field: "target_fps", Op: storage.OpBetween, Value: 60, Upper: 30},
+	} {
+		if _, _, err := store.Search(ctx, storage.SearchQuery{Predicates: []storage.NumericPredicate{p}}, storage.Pagination{}); err == nil {
+			t.Errorf("Expected %+v to be rejected", p)
+		}
+	}
+}
+
+func TestEventBrokerResumeAndFilter(t *testing.T) {
+	broker := events.NewBroker(2)
+
+	broker.Publish(events.TypeCreated, "a", &pb.GameDNA{Id: "a", Tags: []string{"pvp"}})
+	broker.Publish(events.TypeCreated, "b", &pb.GameDNA{Id: "b"})
+	broker.Publish(events.TypeUpdated, "a", &pb.GameDNA{Id: "a", Tags: []string{"pvp"}})
+
+	// Backlog holds only the last two events
+	replay, _, cancel := broker.Subscribe(0, events.Filter{})
+	cancel()
+	if len(replay) != 2 || replay[0].Sequence != 2 {
+		t.Fatalf("Expected events 2-3 in backlog, got %d events", len(replay))
+	}
+
+	replay, live, cancel := broker.Subscribe(2, events.Filter{Tags: []string{"pvp"}})
+	defer cancel()
+	if len(replay) != 1 || replay[0].Type != events.TypeUpdated {
+		t.Fatalf("Expected one resumed pvp event, got %d", len(replay))
+	}
+
+	broker.Publish(events.TypeDeleted, "b", nil)
+	broker.Publish(events.TypePublished, "a", &pb.GameDNA{Id: "a", Tags: []string{"pvp"}})
+
+	ev := <-live
+	if ev.Sequence != 5 || ev.ConfigId != "a" {
+		t.Errorf("Expected filtered live event 5 for a, got %d for %s", ev.Sequence, ev.ConfigId)
+	}
+}
+
+func TestValidationMetrics(t *testing.T) {
+	rust, err := ffi.NewRustFFI("", false)
+	if err != nil {
+		t.Fatalf("Failed to create FFI: %v", err)
+	}
+
+	if _, err := rust.ValidateGameDNA(&pb.GameDNA{Version: "0.1.0"}); err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+
+	vars := expvar.Get("validation").String()
+	for _, want := range []string{`"go": 1`, `"invalid": 1`, `"EMPTY_NAME": 1`} {
+		if !strings.Contains(vars, want) {
+			t.Errorf("Expected %s in validation metrics, got %s", want, vars)
+		}
+	}
+}
+
+func TestRetrySafeWrites(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	rust, err := ffi.NewRustFFI("", false)
+	if err != nil {
+		t.Fatalf("Failed to create FFI: %v", err)
+	}
+
+	// Checksums ignore server-managed metadata but see content changes
+	a := &pb.GameDNA{Name: "Retry Game", Version: "1.0.0", TargetFps: 60}
+	b := &pb.GameDNA{Id: "x", Name: "Retry Game", Version: "1.0.0", TargetFps: 60, CreatedAt: "2024-01-01T00:00:00Z"}
+	c := &pb.GameDNA{Name: "Retry Game", Version: "1.0.0", TargetFps: 30}
+	sumA, _ := rust.CalculateChecksum(a)
+	sumB, _ := rust.CalculateChecksum(b)
+	sumC, _ := rust.CalculateChecksum(c)
+	if sumA != sumB {
+		t.Error("Expected matching checksums for identical content")
+	}
+	if sumA == sumC {
+		t.Error("Expected different checksums for different content")
+	}
+
+	created, err := store.Create(ctx, a)
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if err := store.Delete(ctx, created.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if err := store.Delete(ctx, created.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestMemoryStoreNoOpUpdate(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	created, err := store.Create(ctx, &pb.GameDNA{Name: "Autosave Game", Checksum: "abc"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	same := &pb.GameDNA{Id: created.Id, Name: "Autosave Game", Checksum: "abc"}
+	got, err := store.Update(ctx, same)
+	if !errors.Is(err, storage.ErrNotModified) {
+		t.Fatalf("Expected ErrNotModified, got %v", err)
+	}
+	if got.Id != created.Id {
+		t.Errorf("Expected stored config to be returned, got %v", got)
+	}
+
+	saved, err := store.SaveSet(ctx, storage.SaveSet{
+		Updates: []*pb.GameDNA{{Id: created.Id, Name: "Autosave Game", Checksum: "abc"}},
+	})
+	if err != nil {
+		t.Fatalf("SaveSet failed: %v", err)
+	}
+	if len(saved.Updates) != 0 || len(saved.Unchanged) != 1 {
+		t.Errorf("Expected 1 unchanged update, got %d updated and %d unchanged", len(saved.Updates), len(saved.Unchanged))
+	}
+
+	versions, err := store.GetVersionHistory(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("Expected 1 version, got %d", len(versions))
+	}
+}
+
+// TestLockedNoOpUpdate checks a locked config is reported as locked even when
+// the update matches it, in both stores and through the API.
+func TestLockedNoOpUpdate(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]func() storage.Store{
+		"memory": func() storage.Store { return storage.NewMemoryStore() },
+	}
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		stores["postgres"] = func() storage.Store {
+			store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+			if err != nil {
+				t.Fatalf("NewPostgresStore failed: %v", err)
+			}
+			return store
+		}
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+			defer store.Close()
+			client := serveGameDNA(t, store)
+
+			created, err := client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+				Name: "Locked Arena", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+			}})
+			if err != nil {
+				t.Fatalf("CreateGameDNA failed: %v", err)
+			}
+			dna := created.GameDna
+			if _, err := store.PublishVersion(ctx, dna.Id, "test", dna.Checksum, "", &pb.ValidationResponse{IsValid: true}); err != nil {
+				t.Fatalf("PublishVersion failed: %v", err)
+			}
+
+			same := proto.Clone(dna).(*pb.GameDNA)
+			if _, err := store.Update(ctx, same); !errors.Is(err, storage.ErrLocked) {
+				t.Errorf("Expected ErrLocked from the store, got %v", err)
+			}
+			same = proto.Clone(dna).(*pb.GameDNA)
+			if _, err := client.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: dna.Id, GameDna: same}); status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("Expected FailedPrecondition updating with unchanged content, got %v", err)
+			}
+			same = proto.Clone(dna).(*pb.GameDNA)
+			if _, err := client.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: same}); status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("Expected FailedPrecondition applying unchanged content, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTransferOwnership(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	owned, err := store.Create(ctx, &pb.GameDNA{Name: "Orphan Game", CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.Create(ctx, &pb.GameDNA{Name: "Other Game", CreatedBy: "carol"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, webhook := range []*storage.Webhook{
+		{ID: "wh-alice", URL: "https://builds.example.com/hook", CreatedBy: "alice"},
+		{ID: "wh-carol", URL: "https://builds.example.com/hook", CreatedBy: "carol"},
+	} {
+		if _, err := store.SaveWebhook(ctx, webhook); err != nil {
+			t.Fatalf("SaveWebhook failed: %v", err)
+		}
+	}
+
+	preview, err := store.TransferOwnership(ctx, "alice", "bob", "admin", true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(preview.Configs) != 1 || preview.Configs[0].Id != owned.Id {
+		t.Fatalf("Expected preview of 1 config, got %d", len(preview.Configs))
+	}
+	if len(preview.Webhooks) != 1 || preview.Webhooks[0].ID != "wh-alice" {
+		t.Fatalf("Expected preview of alice's webhook, got %v", preview.Webhooks)
+	}
+	if read, _ := store.Read(ctx, owned.Id); read.CreatedBy != "alice" {
+		t.Errorf("Dry run changed owner to %s", read.CreatedBy)
+	}
+	if webhook, _ := store.GetWebhook(ctx, "wh-alice"); webhook.CreatedBy != "alice" {
+		t.Errorf("Dry run changed webhook owner to %s", webhook.CreatedBy)
+	}
+
+	if _, err := store.TransferOwnership(ctx, "alice", "bob", "admin", false); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	read, err := store.Read(ctx, owned.Id)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if read.CreatedBy != "bob" {
+		t.Errorf("Expected owner bob, got %s", read.CreatedBy)
+	}
+
+	versions, err := store.GetVersionHistory(ctx, owned.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(versions) != 2 || versions[1].CreatedBy != "bob" {
+		t.Errorf("Expected transfer recorded as version 2 by bob, got %d versions", len(versions))
+	}
+	audit, err := store.ListAuditEntries(ctx, owned.Id)
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(audit) != 1 || audit[0].Action != storage.AuditActionTransfer || audit[0].Actor != "admin" || audit[0].Reason != "from alice to bob" || audit[0].VersionNum != 2 {
+		t.Errorf("Expected the transfer in the audit log, got %+v", audit)
+	}
+	for id, owner := range map[string]string{"wh-alice": "bob", "wh-carol": "carol"} {
+		if webhook, _ := store.GetWebhook(ctx, id); webhook.CreatedBy != owner {
+			t.Errorf("Expected webhook %s owned by %s, got %s", id, owner, webhook.CreatedBy)
+		}
+	}
+}
+
+func TestMemoryStoreAnonymizePrincipal(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	owned, err := store.Create(ctx, &pb.GameDNA{Name: "Alice Game", CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	other, err := store.Create(ctx, &pb.GameDNA{Name: "Carol Game", CreatedBy: "carol"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.SaveDraft(ctx, &storage.Draft{ConfigID: other.Id, Owner: "alice", Data: other}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+
+	found, err := store.FindPrincipalData(ctx, "alice")
+	if err != nil {
+		t.Fatalf("FindPrincipalData failed: %v", err)
+	}
+	if len(found.Configs) != 1 || len(found.Versions) != 1 || len(found.Drafts) != 1 {
+		t.Fatalf("Expected 1 config, version and draft, got %d, %d and %d", len(found.Configs), len(found.Versions), len(found.Drafts))
+	}
+
+	if _, err := store.AnonymizePrincipal(ctx, "alice", "anon"); err != nil {
+		t.Fatalf("AnonymizePrincipal failed: %v", err)
+	}
+	if read, _ := store.Read(ctx, owned.Id); read.CreatedBy != "anon" {
+		t.Errorf("Expected owner anon, got %s", read.CreatedBy)
+	}
+	versions, err := store.GetVersionHistory(ctx, owned.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Data.CreatedBy != "anon" {
+		t.Errorf("Expected the version data anonymized without a new version, got %d versions", len(versions))
+	}
+	if _, err := store.GetDraft(ctx, other.Id, "alice"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected alice's draft deleted, got %v", err)
+	}
+	if left, _ := store.FindPrincipalData(ctx, "alice"); left.Total() != 0 {
+		t.Errorf("Expected nothing left naming alice, got %d records", left.Total())
+	}
+}
+
+func TestMemoryStoreSlugs(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	first, err := store.Create(ctx, &pb.GameDNA{Name: "Arena Shooter (Prod)", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := store.Create(ctx, &pb.GameDNA{Name: "Arena Shooter (Prod)", Version: "2.0.0"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.Slug != "arena-shooter-prod" || second.Slug != "arena-shooter-prod-2" {
+		t.Errorf("Unexpected slugs %q and %q", first.Slug, second.Slug)
+	}
+
+	read, err := store.ReadBySlug(ctx, "arena-shooter-prod-2")
+	if err != nil {
+		t.Fatalf("ReadBySlug failed: %v", err)
+	}
+	if read.Id != second.Id {
+		t.Errorf("Expected config %s, got %s", second.Id, read.Id)
+	}
+
+	// Taking another config's slug is rejected
+	if _, err := store.Update(ctx, &pb.GameDNA{Id: second.Id, Name: second.Name, Slug: first.Slug}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	if err := storage.ValidateSlug(first.Id); err == nil {
+		t.Error("Expected UUID-shaped slug to be rejected")
+	}
+}
+
+func TestVersionDiffRendering(t *testing.T) {
+	before := &pb.GameDNA{Id: "a", Name: "Arena", TargetFps: 30, Genre: "FPS", LastModified: "t1"}
+	after := &pb.GameDNA{Id: "a", Name: "Arena", TargetFps: 60, Genre: "Shooter", LastModified: "t2"}
+
+	changes := diff.Compare(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Field != "genre" || changes[1].Field != "target_fps" || !changes[1].Risky {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+
+	md, contentType, err := diff.Render(diff.FormatMarkdown, "Arena", changes)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if contentType != "text/markdown; charset=utf-8" || !strings.Contains(md, "#### Performance") {
+		t.Errorf("Unexpected markdown rendering:\n%s", md)
+	}
+
+	if _, _, err := diff.Render("pdf", "Arena", changes); err == nil {
+		t.Error("Expected unsupported format to fail")
+	}
+}
+
+func TestEditingRegistry(t *testing.T) {
+	registry := editing.NewRegistry(50 * time.Millisecond)
+
+	alice := registry.Start("config-1", "alice")
+	bob := registry.Start("config-1", "bob")
+	registry.Start("config-2", "carol")
+
+	active := registry.Active("config-1")
+	if len(active) != 2 || active[0].Actor != "alice" || active[1].Actor != "bob" {
+		t.Fatalf("Expected alice and bob editing, got %+v", active)
+	}
+
+	if _, err := registry.Heartbeat("config-2", alice.ID); !errors.Is(err, editing.ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for wrong config, got %v", err)
+	}
+
+	registry.Stop("config-1", bob.ID)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := registry.Heartbeat("config-1", alice.ID); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	active = registry.Active("config-1")
+	if len(active) != 1 || active[0].ID != alice.ID {
+		t.Errorf("Expected only alice's heartbeated session, got %+v", active)
+	}
+	if got := registry.Active("config-2"); len(got) != 0 {
+		t.Errorf("Expected carol's session to expire, got %+v", got)
+	}
+
+	var nilRegistry *editing.Registry
+	if got := nilRegistry.Active("config-1"); got != nil {
+		t.Errorf("Expected nil registry to track nothing, got %+v", got)
+	}
+}
+
+func TestMemoryStoreChangedFields(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	created, err := store.Create(ctx, &pb.GameDNA{Name: "Blame Game", TargetFps: 30, CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	update := &pb.GameDNA{
+		Id:        created.Id,
+		Name:      created.Name,
+		Version:   created.Version,
+		Slug:      created.Slug,
+		TargetFps: 60,
+		CreatedBy: "bob",
+	}
+	if _, err := store.Update(ctx, update); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	versions, err := store.GetVersionHistory(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %d", len(versions))
+	}
+	if !slices.Contains(versions[0].ChangedFields, "name") || !slices.Contains(versions[0].ChangedFields, "target_fps") {
+		t.Errorf("Expected first version to set name and target_fps, got %v", versions[0].ChangedFields)
+	}
+	if got := versions[1].ChangedFields; len(got) != 1 || got[0] != "target_fps" {
+		t.Errorf("Expected second version to change only target_fps, got %v", got)
+	}
+	if got := versions[1].Changes; len(got) != 1 || got[0].Field != "target_fps" || got[0].Old != "30" || got[0].New != "60" {
+		t.Errorf("Expected second version to record target_fps 30 → 60, got %+v", got)
+	}
+}
+
+func TestListGuardrails(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	rust, err := ffi.NewRustFFI("", false)
+	if err != nil {
+		t.Fatalf("Failed to create FFI: %v", err)
+	}
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		List: api.ListLimits{MaxPageSize: 50, MaxOffset: 100, MinNameFilterLength: 3},
+	}, zap.NewNop())
+
+	rejected := []*pb.ListGameDNARequest{
+		{PageSize: 51},
+		{Page: 4, PageSize: 50},
+		{NameFilter: "%a%"},
+		{NameFilter: "  ab "},
+	}
+	for _, req := range rejected {
+		if _, err := svc.ListGameDNA(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+
+	allowed := []*pb.ListGameDNARequest{
+		{},
+		{Page: 3, PageSize: 50},
+		{NameFilter: "arena"},
+	}
+	for _, req := range allowed {
+		if _, err := svc.ListGameDNA(ctx, req); err != nil {
+			t.Errorf("Expected %v to be allowed, got %v", req, err)
+		}
+	}
+}
+
+func TestSheetImportParsing(t *testing.T) {
+	csvRows, err := sheets.Read("", []byte("Game,FPS\nNeon Drift,60\n"), 0)
+	if err != nil {
+		t.Fatalf("CSV read failed: %v", err)
+	}
+	if len(csvRows) != 2 || csvRows[1][0] != "Neon Drift" {
+		t.Errorf("Unexpected CSV rows: %v", csvRows)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>Game</t></si><si><t>FPS</t></si><si><r><t>Neon </t></r><r><t>Drift</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row><row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>60</v></c></row></sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create failed: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	xlsxRows, err := sheets.Read("", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("XLSX read failed: %v", err)
+	}
+	if len(xlsxRows) != 2 || xlsxRows[0][2] != "FPS" || xlsxRows[1][0] != "Neon Drift" || xlsxRows[1][2] != "60" {
+		t.Errorf("Unexpected XLSX rows: %q", xlsxRows)
+	}
+
+	dna := &pb.GameDNA{}
+	for path, value := range map[string]string{
+		"target_fps":               "60.0",
+		"target_platforms":         "PC; Console",
+		"is_competitive":           "yes",
+		"time_scale":               "1.5",
+		"custom_properties.rarity": "epic",
+	} {
+		if err := sheets.SetField(dna, path, value); err != nil {
+			t.Fatalf("SetField(%s) failed: %v", path, err)
+		}
+	}
+	if dna.TargetFps != 60 || len(dna.TargetPlatforms) != 2 || !dna.IsCompetitive || dna.TimeScale != 1.5 || dna.CustomProperties["rarity"] != "epic" {
+		t.Errorf("Unexpected config after SetField: %v", dna)
+	}
+	if err := sheets.SetField(dna, "target_fps", "fast"); err == nil {
+		t.Error("Expected error for non-numeric target_fps")
+	}
+	if err := sheets.CheckField("checksum"); err == nil {
+		t.Error("Expected checksum to be rejected as an import field")
+	}
+}
+
+func TestMemoryStoreImportMappings(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+
+	if _, err := store.GetImportMapping(ctx, "racer"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	columns := map[string]string{"FPS": "target_fps"}
+	if _, err := store.SaveImportMapping(ctx, &storage.ImportMapping{Project: "racer", Columns: columns}); err != nil {
+		t.Fatalf("SaveImportMapping failed: %v", err)
+	}
+	columns["Game"] = "name"
+
+	mapping, err := store.GetImportMapping(ctx, "racer")
+	if err != nil {
+		t.Fatalf("GetImportMapping failed: %v", err)
+	}
+	if len(mapping.Columns) != 1 || mapping.Columns["FPS"] != "target_fps" {
+		t.Errorf("Expected the saved columns to be isolated from the caller, got %v", mapping.Columns)
+	}
+
+	// Snapshot exports carry the mappings of the requested projects.
+	dna, err := store.Create(ctx, &pb.GameDNA{Name: "Racer", Genre: "Racing"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.CreateSnapshot(ctx, &storage.Snapshot{Name: "move", Entries: []storage.SnapshotEntry{{ConfigID: dna.Id}}}); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	exported, err := svc.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Name: "move", Projects: []string{"racer", "unmapped", "racer"}})
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if len(exported.ImportMappings) != 1 || exported.ImportMappings[0].Project != "racer" || exported.ImportMappings[0].Columns["FPS"] != "target_fps" {
+		t.Errorf("Expected only the racer mapping in the export, got %v", exported.ImportMappings)
+	}
+	if len(exported.Snapshot.Entries) != 1 || exported.Snapshot.Entries[0].Data == nil {
+		t.Errorf("Expected the config data in the export, got %v", exported.Snapshot.Entries)
+	}
+}
+
+func TestImportSnapshot(t *testing.T) {
+	ctx := context.Background()
+	rust, _ := ffi.NewRustFFI("", false)
+	source := storage.NewMemoryStore()
+	defer source.Close()
+	from := api.NewGameDNAServiceServer(source, rust, api.ServerOptions{}, zap.NewNop())
+
+	dna, err := source.Create(ctx, &pb.GameDNA{Name: "Racer", Genre: "Racing", CustomProperties: map[string]string{"project": "racer", "tier": "gold"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := source.SaveImportMapping(ctx, &storage.ImportMapping{Project: "racer", Columns: map[string]string{"FPS": "target_fps"}}); err != nil {
+		t.Fatalf("SaveImportMapping failed: %v", err)
+	}
+	if _, err := source.SavePropertySchema(ctx, &storage.PropertySchema{Project: "racer", Name: "tier", Type: "enum", AllowedValues: []string{"gold", "silver"}}); err != nil {
+		t.Fatalf("SavePropertySchema failed: %v", err)
+	}
+	if _, err := source.CreateSnapshot(ctx, &storage.Snapshot{Name: "release-1", Entries: []storage.SnapshotEntry{{ConfigID: dna.Id}}}); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	exported, err := from.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Name: "release-1", Projects: []string{"racer"}})
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if len(exported.PropertySchemas) != 1 || exported.PropertySchemas[0].Name != "tier" {
+		t.Fatalf("Expected the racer property schema in the export, got %v", exported.PropertySchemas)
+	}
+
+	target := storage.NewMemoryStore()
+	defer target.Close()
+	to := api.NewGameDNAServiceServer(target, rust, api.ServerOptions{}, zap.NewNop())
+	resp, err := to.ImportSnapshot(ctx, &pb.ImportSnapshotRequest{Export: exported, Actor: "release-bot"})
+	if err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	if resp.Snapshot == nil || len(resp.Snapshot.Entries) != 1 || resp.Snapshot.Entries[0].ConfigId != dna.Id {
+		t.Fatalf("Expected the snapshot to be recreated pinning %s, got %v (%s)", dna.Id, resp.Snapshot, resp.Message)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Status != "created" {
+		t.Errorf("Expected the config to be created, got %v", resp.Results)
+	}
+	if got, err := target.Read(ctx, dna.Id); err != nil || got.Name != "Racer" {
+		t.Errorf("Expected the config on the target, got %v, %v", got, err)
+	}
+	if mapping, err := target.GetImportMapping(ctx, "racer"); err != nil || mapping.Columns["FPS"] != "target_fps" {
+		t.Errorf("Expected the import mapping on the target, got %v, %v", mapping, err)
+	}
+	schemas, err := target.ListPropertySchemas(ctx, "racer")
+	if err != nil || len(schemas) != 1 || schemas[0].Type != "enum" || schemas[0].UpdatedBy != "release-bot" {
+		t.Errorf("Expected the property schema on the target, got %v, %v", schemas, err)
+	}
+
+	// The same snapshot cannot be imported twice.
+	if _, err := to.ImportSnapshot(ctx, &pb.ImportSnapshotRequest{Export: exported}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists for a second import, got %v", err)
+	}
+
+	// A locked config that differs leaves the snapshot uncreated.
+	exported.Snapshot.Name = "release-2"
+	exported.Snapshot.Entries[0].Data.Genre = "Arcade"
+	imported, _ := target.Read(ctx, dna.Id)
+	if _, err := target.PublishVersion(ctx, dna.Id, "test", imported.Checksum, "", &pb.ValidationResponse{IsValid: true}); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+	resp, err = to.ImportSnapshot(ctx, &pb.ImportSnapshotRequest{Export: exported, Overwrite: true})
+	if err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	if resp.Snapshot != nil || len(resp.Results) != 1 || resp.Results[0].Status != "locked" {
+		t.Errorf("Expected no snapshot and a locked result, got %v", resp)
+	}
+	if _, err := target.GetSnapshot(ctx, "release-2", false); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected release-2 not to be created, got %v", err)
+	}
+}
+
+func TestGoogleSheetsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	var sheet [][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.FormValue("assertion") == "" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"test-token","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v4/spreadsheets/sheet-1/values/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":clear"):
+			sheet = nil
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodPut:
+			var body struct {
+				Values [][]string `json:"values"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			sheet = body.Values
+			w.Write([]byte(`{}`))
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"values": sheet})
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	credentials, _ := json.Marshal(map[string]string{
+		"client_email": "dna@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    srv.URL + "/token",
+	})
+	client, err := sheets.NewGoogleClient(credentials, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGoogleClient failed: %v", err)
+	}
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Sheets: client}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Sheet Game", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	exported, err := svc.ExportToGoogleSheet(ctx, &pb.ExportToGoogleSheetRequest{SpreadsheetId: "sheet-1"})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if exported.Exported != 1 || len(sheet) != 2 || sheet[0][0] != "id" {
+		t.Fatalf("Unexpected exported sheet: %v", sheet)
+	}
+
+	// Unedited rows come back unchanged
+	preview, err := svc.PullFromGoogleSheet(ctx, &pb.PullFromGoogleSheetRequest{SpreadsheetId: "sheet-1"})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if preview.Counts["unchanged"] != 1 {
+		t.Errorf("Expected unchanged row, got %v %v", preview.Counts, preview.Rows)
+	}
+
+	for i, header := range sheet[0] {
+		if header == "target_fps" {
+			sheet[1][i] = "60"
+		}
+	}
+	preview, err = svc.PullFromGoogleSheet(ctx, &pb.PullFromGoogleSheetRequest{SpreadsheetId: "sheet-1"})
+	if err != nil {
+		t.Fatalf("Pull preview failed: %v", err)
+	}
+	if preview.Rows[0].Status != "would_update" || len(preview.Rows[0].Changes) != 1 || preview.Rows[0].Changes[0].NewValue != "60" {
+		t.Fatalf("Unexpected preview: %v", preview.Rows[0])
+	}
+	if current, _ := store.Read(ctx, created.GameDna.Id); current.TargetFps != 30 {
+		t.Errorf("Preview changed the config")
+	}
+
+	if _, err := svc.PullFromGoogleSheet(ctx, &pb.PullFromGoogleSheetRequest{SpreadsheetId: "sheet-1", Apply: true}); err != nil {
+		t.Fatalf("Pull apply failed: %v", err)
+	}
+	if current, _ := store.Read(ctx, created.GameDna.Id); current.TargetFps != 60 {
+		t.Errorf("Expected target_fps 60 after apply, got %d", current.TargetFps)
+	}
+}
+
+func TestApplyGameDNA(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	desired := func() *pb.GameDNA {
+		return &pb.GameDNA{
+			Slug: "iac-arena", Name: "IaC Arena", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+		}
+	}
+
+	if _, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: &pb.GameDNA{Name: "No Slug"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a slug, got %v", err)
+	}
+
+	plan, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: desired(), Plan: true})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if plan.Action != "create" || !plan.Planned || len(plan.Changes) == 0 {
+		t.Fatalf("Unexpected create plan: %v", plan)
+	}
+	if _, err := store.ReadBySlug(ctx, "iac-arena"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Plan created the config: %v", err)
+	}
+
+	created, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: desired()})
+	if err != nil || created.Action != "create" {
+		t.Fatalf("Apply create failed: %v %v", created, err)
+	}
+
+	again, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: desired()})
+	if err != nil || again.Action != "noop" || len(again.Changes) != 0 {
+		t.Fatalf("Expected noop on re-apply, got %v %v", again, err)
+	}
+	if history, _ := store.GetVersionHistory(ctx, created.GameDna.Id); len(history) != 1 {
+		t.Errorf("Expected no new version on noop, got %d", len(history))
+	}
+
+	drifted := desired()
+	drifted.TargetFps = 60
+	plan, err = svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: drifted, Plan: true})
+	if err != nil || plan.Action != "update" || len(plan.Changes) != 1 || plan.Changes[0].Field != "target_fps" {
+		t.Fatalf("Unexpected update plan: %v %v", plan, err)
+	}
+	updated, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: drifted})
+	if err != nil || updated.Action != "update" || updated.GameDna.Id != created.GameDna.Id || updated.GameDna.TargetFps != 60 {
+		t.Fatalf("Apply update failed: %v %v", updated, err)
+	}
+
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: created.GameDna.Id}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if _, err := svc.ApplyGameDNA(ctx, &pb.ApplyGameDNARequest{GameDna: desired()}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for drifted locked config, got %v", err)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	ctx := context.Background()
+	injector := faults.NewInjector()
+	store := faults.WrapStore(storage.NewMemoryStore(), injector)
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	rust.SetFaults(injector)
+	broker := events.NewBroker(16)
+	broker.SetFaults(injector)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker, Faults: injector}, zap.NewNop())
+
+	disabled := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := disabled.GetFaults(ctx, &pb.GetFaultsRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition when disabled, got %v", err)
+	}
+	if _, err := svc.SetFaults(ctx, &pb.SetFaultsRequest{Rules: []*pb.FaultRule{{Target: "cache.Get"}}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for unknown component, got %v", err)
+	}
+
+	dna := func() *pb.GameDNA {
+		return &pb.GameDNA{Name: "Fault Game", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+
+	resp, err := svc.SetFaults(ctx, &pb.SetFaultsRequest{Rules: []*pb.FaultRule{
+		{Target: "storage.Create", ErrorRate: 1, Message: "disk full"},
+		{Target: "events.*", ErrorRate: 1},
+	}})
+	if err != nil || len(resp.Rules) != 2 {
+		t.Fatalf("SetFaults failed: %v %v", resp, err)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna()}); !errors.Is(err, faults.ErrInjected) {
+		t.Errorf("Expected injected storage error, got %v", err)
+	}
+
+	if _, err := svc.SetFaults(ctx, &pb.SetFaultsRequest{Rules: []*pb.FaultRule{{Target: "events.Publish", ErrorRate: 1}}}); err != nil {
+		t.Fatalf("SetFaults failed: %v", err)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna()}); err != nil {
+		t.Fatalf("Create failed after clearing storage fault: %v", err)
+	}
+	backlog, _, cancel := broker.Subscribe(0, events.Filter{})
+	cancel()
+	if len(backlog) != 0 {
+		t.Errorf("Expected the event to be dropped, got %d events", len(backlog))
+	}
+
+	if _, err := svc.SetFaults(ctx, &pb.SetFaultsRequest{Rules: []*pb.FaultRule{{Target: "ffi.ValidateGameDNA", LatencyMs: 50}}}); err != nil {
+		t.Fatalf("SetFaults failed: %v", err)
+	}
+	start := time.Now()
+	if _, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{GameDna: dna()}); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected injected latency, took %v", elapsed)
+	}
+
+	if resp, err := svc.SetFaults(ctx, &pb.SetFaultsRequest{}); err != nil || len(resp.Rules) != 0 {
+		t.Errorf("Expected rules to be cleared, got %v %v", resp, err)
+	}
+}
+
+// benchmarkStore fills a memory store with n configs for the export benchmarks.
+func benchmarkStore(b *testing.B, n int) storage.Store {
+	b.Helper()
+	store := storage.NewMemoryStore()
+	for i := 0; i < n; i++ {
+		_, err := store.Create(context.Background(), &pb.GameDNA{
+			Name: fmt.Sprintf("Bench Game %d", i), Genre: "FPS", TargetFps: 60, TimeScale: 1,
+			TargetPlatforms: []string{"PC"}, Tags: []string{"bench"},
+		})
+		if err != nil {
+			b.Fatalf("Create failed: %v", err)
+		}
+	}
+	return store
+}
+
+// BenchmarkExportPagedList walks every config a page at a time, as exports did
+// before Each.
+func BenchmarkExportPagedList(b *testing.B) {
+	store := benchmarkStore(b, 5000)
+	defer store.Close()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		seen := 0
+		for page := int32(1); ; page++ {
+			items, total, err := store.List(ctx, storage.ListFilters{}, storage.Pagination{Page: page, PageSize: 100})
+			if err != nil {
+				b.Fatalf("List failed: %v", err)
+			}
+			seen += len(items)
+			if len(items) == 0 || page*100 >= total {
+				break
+			}
+		}
+		if seen != 5000 {
+			b.Fatalf("Expected 5000 configs, saw %d", seen)
+		}
+	}
+}
+
+func BenchmarkExportEach(b *testing.B) {
+	store := benchmarkStore(b, 5000)
+	defer store.Close()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		seen := 0
+		err := store.Each(ctx, storage.ListFilters{}, func(*pb.GameDNA) error {
+			seen++
+			return nil
+		})
+		if err != nil || seen != 5000 {
+			b.Fatalf("Each failed after %d configs: %v", seen, err)
+		}
+	}
+}
+
+func BenchmarkChecksum(b *testing.B) {
+	rust, _ := ffi.NewRustFFI("", false)
+	dna := &pb.GameDNA{
+		Name: "Bench Game", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC", "PS5"},
+		Tags: []string{"bench", "pvp"}, CustomProperties: map[string]string{"rarity": "epic"},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := rust.CalculateChecksum(dna); err != nil {
+			b.Fatalf("Checksum failed: %v", err)
+		}
+	}
+}
+
+func TestPublishedCache(t *testing.T) {
+	ctx := context.Background()
+	injector := faults.NewInjector()
+	rust, _ := ffi.NewRustFFI("", false)
+	published := cache.NewPublishedStore(faults.WrapStore(storage.NewMemoryStore(), injector), rust.CalculateChecksum)
+	defer published.Close()
+	svc := api.NewGameDNAServiceServer(published, rust, api.ServerOptions{}, zap.NewNop())
+
+	dna := func(name string) *pb.GameDNA {
+		return &pb.GameDNA{Name: name, Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	live, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Cached Game")})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: live.GameDna.Id}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	draft, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Draft Game")})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Locked without sealing the checksum: the stored one predates the version default
+	legacy, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Legacy Game")})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := published.PublishVersion(ctx, legacy.GameDna.Id, "test", legacy.GameDna.Checksum, "", &pb.ValidationResponse{IsValid: true}); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+
+	result, err := published.Warm(ctx)
+	if err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	if result.Loaded != 1 || len(result.Mismatched) != 1 || result.Mismatched[0] != legacy.GameDna.Id {
+		t.Fatalf("Unexpected warm result: %+v", result)
+	}
+
+	// Cached reads never reach the store
+	if err := injector.Set([]faults.Rule{{Target: "storage.*", ErrorRate: 1}}); err != nil {
+		t.Fatalf("Set faults failed: %v", err)
+	}
+	if got, err := published.Read(ctx, live.GameDna.Id); err != nil || got.Name != "Cached Game" {
+		t.Errorf("Expected cached read, got %v %v", got, err)
+	}
+	if _, err := published.ReadBySlug(ctx, live.GameDna.Slug); err != nil {
+		t.Errorf("Expected cached slug read, got %v", err)
+	}
+	if _, err := published.Read(ctx, draft.GameDna.Id); !errors.Is(err, faults.ErrInjected) {
+		t.Errorf("Expected drafts to be read from the store, got %v", err)
+	}
+	injector.Set(nil)
+
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: live.GameDna.Id}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := published.Read(ctx, live.GameDna.Id); err == nil {
+		t.Errorf("Expected deleted config to be evicted")
+	}
+	if published.Len() != 0 {
+		t.Errorf("Expected empty cache, got %d entries", published.Len())
+	}
+}
+
+func TestReplicaHedgedReads(t *testing.T) {
+	ctx := context.Background()
+	rust, _ := ffi.NewRustFFI("", false)
+
+	// Each store names the config after itself, so reads show who served them
+	seed := func(store storage.Store, name string) {
+		dna, err := store.Create(ctx, &pb.GameDNA{Name: name, Slug: "launch-config"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := store.PublishVersion(ctx, dna.Id, "test", dna.Checksum, "", &pb.ValidationResponse{IsValid: true}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	primary := storage.NewMemoryStore()
+	seed(primary, "primary")
+	slow := faults.NewInjector()
+	replicaA := faults.WrapStore(storage.NewMemoryStore(), slow)
+	seed(replicaA, "replica-a")
+	replicaB := storage.NewMemoryStore()
+	seed(replicaB, "replica-b")
+
+	store := replicas.NewStore(primary, []storage.Store{replicaA, replicaB}, replicas.Options{
+		HedgePercentile: 95,
+		MinDelay:        time.Millisecond,
+		MaxDelay:        20 * time.Millisecond,
+	})
+	defer store.Close()
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	if got, err := store.ReadBySlug(ctx, "launch-config"); err != nil || got.Name != "primary" {
+		t.Errorf("Expected reads that don't allow stale data to go to the primary, got %v %v", got, err)
+	}
+	served := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		resp, err := svc.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: "launch-config"})
+		if err != nil {
+			t.Fatalf("GetPublishedConfig failed: %v", err)
+		}
+		served[resp.GameDna.Name] = true
+	}
+	if !served["replica-a"] || !served["replica-b"] {
+		t.Errorf("Expected published reads to take turns on the replicas, got %v", served)
+	}
+
+	// A stalled replica is hedged with the other one well before it answers
+	hedged := func() int64 {
+		if v := expvar.Get("replica_reads").(*expvar.Map).Get("hedged"); v != nil {
+			return v.(*expvar.Int).Value()
+		}
+		return 0
+	}
+	before := hedged()
+	if err := slow.Set([]faults.Rule{{Target: "storage.ReadBySlug", Latency: 500 * time.Millisecond}}); err != nil {
+		t.Fatalf("Set faults failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		got, err := store.ReadBySlug(replicas.AllowStale(ctx), "launch-config")
+		if err != nil || got.Name != "replica-b" {
+			t.Errorf("Expected the fast replica to answer, got %v %v", got, err)
+		}
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Errorf("Expected the hedge to answer before the stalled replica, took %v", elapsed)
+		}
+	}
+	if hedged() != before+1 {
+		t.Errorf("Expected one hedged read, got %d", hedged()-before)
+	}
+	slow.Set(nil)
+
+	// Replicas that haven't caught up yet fall back to the primary
+	fresh, err := primary.Create(ctx, &pb.GameDNA{Name: "fresh", Slug: "fresh-config"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got, err := store.Read(replicas.AllowStale(ctx), fresh.Id); err != nil || got.Name != "fresh" {
+		t.Errorf("Expected a read missing on the replicas to be served by the primary, got %v %v", got, err)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	// Older clients only send the RFC3339 strings
+	legacy := &pb.GameDNA{Name: "Legacy Client", Genre: "RPG", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+		CreatedAt: "2023-04-05T06:07:08+02:00"}
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: legacy})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	dna := created.GameDna
+	want := time.Date(2023, 4, 5, 4, 7, 8, 0, time.UTC)
+	if !dna.CreateTime.AsTime().Equal(want) || dna.CreatedAt != "2023-04-05T04:07:08Z" {
+		t.Errorf("Expected create_time filled from created_at, got %v %q", dna.CreateTime, dna.CreatedAt)
+	}
+	if dna.UpdateTime == nil || dna.LastModified != models.FormatTime(dna.UpdateTime.AsTime()) {
+		t.Errorf("Expected update_time and last_modified in sync, got %v %q", dna.UpdateTime, dna.LastModified)
+	}
+
+	bad := &pb.GameDNA{Name: "Bad Clock", Genre: "RPG", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+		CreatedAt: "yesterday"}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: bad}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for malformed created_at, got %v", err)
+	}
+	if _, err := models.FromProto(bad); err == nil {
+		t.Error("Expected FromProto to reject a malformed timestamp instead of using now")
+	}
+
+	// The creation time is server-managed on update
+	update := proto.Clone(dna).(*pb.GameDNA)
+	update.TargetFps = 60
+	update.CreateTime = nil
+	update.CreatedAt = ""
+	updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: dna.Id, GameDna: update})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !updated.GameDna.CreateTime.AsTime().Equal(want) || updated.GameDna.CreatedAt != dna.CreatedAt {
+		t.Errorf("Expected creation time kept on update, got %v %q", updated.GameDna.CreateTime, updated.GameDna.CreatedAt)
+	}
+
+	history, err := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: dna.Id})
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	for _, v := range history.Versions {
+		if v.CreateTime == nil || v.CreatedAt != models.FormatTime(v.CreateTime.AsTime()) {
+			t.Errorf("Version %d timestamps out of sync: %v %q", v.VersionNum, v.CreateTime, v.CreatedAt)
+		}
+	}
+
+	model, err := models.FromProto(updated.GameDna)
+	if err != nil || !model.CreatedAt.Equal(want) {
+		t.Fatalf("FromProto failed: %v %v", model, err)
+	}
+	back, _ := model.ToProto()
+	if !proto.Equal(back.CreateTime, updated.GameDna.CreateTime) || back.CreatedAt != updated.GameDna.CreatedAt {
+		t.Errorf("Round trip changed the creation time: %v %q", back.CreateTime, back.CreatedAt)
+	}
+}
+
+// populateGameDNA sets every field of a GameDNA to a non-zero value, failing
+// on field kinds it doesn't know so new fields can't slip past the coverage tests.
+func populateGameDNA(t *testing.T) *pb.GameDNA {
+	t.Helper()
+	dna := &pb.GameDNA{}
+	msg := dna.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		switch {
+		case fd.IsMap():
+			m := msg.Mutable(fd).Map()
+			m.Set(protoreflect.ValueOfString("key").MapKey(), protoreflect.ValueOfString(string(fd.Name())))
+		case fd.IsList():
+			msg.Mutable(fd).List().Append(protoreflect.ValueOfString(string(fd.Name())))
+		case fd.Kind() == protoreflect.StringKind:
+			msg.Set(fd, protoreflect.ValueOfString(string(fd.Name())))
+		case fd.Kind() == protoreflect.BoolKind:
+			msg.Set(fd, protoreflect.ValueOfBool(true))
+		case fd.Kind() == protoreflect.Uint32Kind:
+			msg.Set(fd, protoreflect.ValueOfUint32(uint32(fd.Number())))
+		case fd.Kind() == protoreflect.FloatKind:
+			msg.Set(fd, protoreflect.ValueOfFloat32(float32(fd.Number())+0.5))
+		case fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() == "google.protobuf.Timestamp":
+			// Set below so the RFC3339 copies agree
+		default:
+			t.Fatalf("populateGameDNA: unhandled field %s (%s)", fd.Name(), fd.Kind())
+		}
+	}
+	models.SetCreated(dna, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	models.SetModified(dna, time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC))
+	return dna
+}
+
+func TestModelsFieldCoverage(t *testing.T) {
+	dna := populateGameDNA(t)
+
+	row, err := models.FromProto(dna)
+	if err != nil {
+		t.Fatalf("FromProto failed: %v", err)
+	}
+	back, err := row.ToProto()
+	if err != nil {
+		t.Fatalf("ToProto failed: %v", err)
+	}
+	if !proto.Equal(back, dna) {
+		t.Errorf("Round trip lost fields:\n got %v\nwant %v", back, dna)
+	}
+
+	stored, err := models.Marshal(dna)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if decoded, err := models.Unmarshal(stored); err != nil || !proto.Equal(decoded, dna) {
+		t.Errorf("Marshal round trip lost fields: %v %v", decoded, err)
+	}
+	if clone := models.Clone(dna); !proto.Equal(clone, dna) {
+		t.Errorf("Clone lost fields: %v", clone)
+	}
+
+	// Every field is either server-managed or config content that survives
+	content := models.Clone(dna)
+	models.ClearServerFields(content)
+	serverFields := map[protoreflect.Name]bool{
+		"id": true, "created_at": true, "last_modified": true, "create_time": true, "update_time": true,
+		"created_by": true, "checksum": true, "is_locked": true, "published_rules_version": true, "promoted_from": true,
+	}
+	fields := dna.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if got := content.ProtoReflect().Has(fd); got == serverFields[fd.Name()] {
+			t.Errorf("ClearServerFields: field %s set=%v, server-managed=%v", fd.Name(), got, serverFields[fd.Name()])
+		}
+	}
+
+	// Store clones copy all content under the new name
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	created, err := store.Create(ctx, models.Clone(dna))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	cloned, err := store.Clone(ctx, created.Id, "Coverage Copy", "tester")
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	want := models.Clone(content)
+	want.Name = "Coverage Copy"
+	got := models.Clone(cloned)
+	models.ClearServerFields(got)
+	got.Slug = want.Slug
+	if !proto.Equal(got, want) {
+		t.Errorf("Store clone lost content:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	store.SetMaxHistoryDepth(3)
+
+	dna, err := store.Create(ctx, &pb.GameDNA{Name: "Deep History", Genre: "FPS", TargetFps: 30})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	update := func(fps uint32) {
+		t.Helper()
+		dna.TargetFps = fps
+		if dna, err = store.Update(ctx, dna); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	update(40)
+	if _, err := store.CreateSnapshot(ctx, &storage.Snapshot{Name: "pinned", Entries: []storage.SnapshotEntry{{ConfigID: dna.Id}}}); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	for _, fps := range []uint32{50, 60, 70, 80} {
+		update(fps)
+	}
+
+	history, err := store.GetVersionHistory(ctx, dna.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	var nums []int64
+	for _, v := range history {
+		nums = append(nums, v.VersionNum)
+	}
+	if !slices.Equal(nums, []int64{2, 4, 5, 6}) {
+		t.Errorf("Expected the newest 3 versions plus pinned version 2, got %v", nums)
+	}
+	if _, err := store.RollbackToVersion(ctx, dna.Id, 3, "tester"); err == nil {
+		t.Error("Expected rollback to a pruned version to fail")
+	}
+	if _, err := store.RestoreSnapshot(ctx, "pinned", "tester"); err != nil {
+		t.Fatalf("RestoreSnapshot of a pinned version failed: %v", err)
+	}
+	history, _ = store.GetVersionHistory(ctx, dna.Id)
+	if last := history[len(history)-1]; last.VersionNum != 7 || last.Data.TargetFps != 40 {
+		t.Errorf("Expected restore to write version 7 with fps 40, got %d %d", last.VersionNum, last.Data.TargetFps)
+	}
+
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		List: api.ListLimits{DefaultPageSize: 2, MaxPageSize: 50},
+	}, zap.NewNop())
+	for _, name := range []string{"Page One", "Page Two"} {
+		if _, err := store.Create(ctx, &pb.GameDNA{Name: name, Genre: "FPS"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	list, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Items) != 2 || list.Pagination.PageSize != 2 || list.Pagination.TotalPages != 2 {
+		t.Errorf("Expected the configured default page size, got %d items %v", len(list.Items), list.Pagination)
+	}
+
+	cfg := config.DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Default config invalid: %v", err)
+	}
+	cfg.Limits.DefaultPageSize = cfg.List.MaxPageSize + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a default page size above the list maximum to be rejected")
+	}
+	cfg = config.DefaultConfig()
+	cfg.Limits.DBMaxIdleConns = cfg.Limits.DBMaxOpenConns + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected more idle than open connections to be rejected")
+	}
+}
+
+func TestEmbeddedEventBroker(t *testing.T) {
+	dir := t.TempDir()
+	broker, err := events.NewEmbeddedBroker(3, dir, true)
+	if err != nil {
+		t.Fatalf("NewEmbeddedBroker failed: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		broker.Publish(events.TypeCreated, id, &pb.GameDNA{Id: id, Tags: []string{"pvp"}})
+	}
+	if err := broker.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Simulate a crash part-way through writing a record
+	f, err := os.OpenFile(filepath.Join(dir, "events.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Open journal failed: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 9, 1})
+	f.Close()
+
+	broker, err = events.NewEmbeddedBroker(3, dir, true)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer broker.Close()
+
+	replay, live, cancel := broker.Subscribe(4, events.Filter{Tags: []string{"pvp"}})
+	defer cancel()
+	if len(replay) != 2 || replay[0].Sequence != 5 || replay[1].ConfigId != "f" {
+		t.Fatalf("Expected events 5-6 resumed after restart, got %v", replay)
+	}
+
+	broker.Publish(events.TypeUpdated, "f", &pb.GameDNA{Id: "f", Tags: []string{"pvp"}})
+	if ev := <-live; ev.Sequence != 7 {
+		t.Errorf("Expected the sequence to continue at 7, got %d", ev.Sequence)
+	}
+	if vars := expvar.Get("event_journal").String(); strings.Contains(vars, "errors") {
+		t.Errorf("Unexpected journal errors: %s", vars)
+	}
+}
+
+func TestGRPCStatusCodes(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	// call runs a handler through the interceptor the server installs.
+	call := func(handler func(ctx context.Context) (interface{}, error)) error {
+		_, err := api.UnaryErrorInterceptor(ctx, nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return handler(ctx)
+		})
+		return err
+	}
+
+	err := call(func(ctx context.Context) (interface{}, error) {
+		return svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: "missing"})
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a missing config, got %v", err)
+	}
+
+	valid := func(name, slug string) *pb.GameDNA {
+		return &pb.GameDNA{Name: name, Slug: slug, Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	first, err := store.Create(ctx, valid("First", "first"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := store.Create(ctx, valid("Second", "second"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	taken := valid("Second", "first")
+	taken.Id = second.Id
+	err = call(func(ctx context.Context) (interface{}, error) {
+		return svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: second.Id, GameDna: taken})
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists for a taken slug, got %v", err)
+	}
+
+	if _, err := store.PublishVersion(ctx, first.Id, "tester", first.Checksum, "", nil); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+	locked := valid("First v2", "first")
+	locked.Id = first.Id
+	err = call(func(ctx context.Context) (interface{}, error) {
+		return svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: first.Id, GameDna: locked})
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a locked config, got %v", err)
+	}
+
+	// Errors that already carry a status keep it.
+	err = call(func(ctx context.Context) (interface{}, error) {
+		return svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: "Bad", CreatedAt: "yesterday"}})
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument to pass through, got %v", err)
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	ctx := context.Background()
+	registry := server.DefaultRegistry(zap.NewNop(), server.Options{})
+
+	if _, err := registry.Chain([]string{"recovery", "cors"}); err == nil {
+		t.Error("Expected an unknown middleware name to be rejected")
+	}
+	if err := registry.Register(server.Middleware{Name: "logging"}); err == nil {
+		t.Error("Expected a duplicate middleware name to be rejected")
+	}
+
+	// Middleware runs in the configured order, outermost first.
+	var order []string
+	trace := func(name string) server.Middleware {
+		return server.Middleware{
+			Name: name,
+			Unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				order = append(order, name)
+				return handler(ctx, req)
+			},
+			HTTP: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(w, r)
+				})
+			},
+		}
+	}
+	for _, name := range []string{"first", "second"} {
+		if err := registry.Register(trace(name)); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	chain, err := registry.Chain([]string{"second", "first", "recovery", "request_id"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	handler := chain.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(api.RequestIDHeader) == "" {
+			t.Error("Expected a generated request ID")
+		}
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/game-dna", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected a recovered HTTP panic to return 500, got %d", rec.Code)
+	}
+	if rec.Header().Get(api.RequestIDHeader) == "" {
+		t.Error("Expected the request ID on the response")
+	}
+	if !slices.Equal(order, []string{"second", "first"}) {
+		t.Errorf("Expected HTTP middleware in configured order, got %v", order)
+	}
+
+	// The gRPC chain is exercised through a real server.
+	order = nil
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, panicOnGet{api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+	if _, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: "x"}); status.Code(err) != codes.Internal {
+		t.Errorf("Expected a recovered gRPC panic to return Internal, got %v", err)
+	}
+	if !slices.Equal(order, []string{"second", "first"}) {
+		t.Errorf("Expected gRPC interceptors in configured order, got %v", order)
+	}
+	if _, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{}); err != nil {
+		t.Errorf("Expected the server to keep serving after a panic, got %v", err)
+	}
+}
+
+// panicOnGet is a service whose GetGameDNA handler panics.
+type panicOnGet struct {
+	*api.GameDNAServiceServer
+}
+
+func (panicOnGet) GetGameDNA(context.Context, *pb.GetGameDNARequest) (*pb.GameDNAResponse, error) {
+	panic("boom")
+}
+
+// crashLog collects crash reports.
+type crashLog struct {
+	mu      sync.Mutex
+	reports []server.CrashReport
+}
+
+func (c *crashLog) Report(report server.CrashReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reports = append(c.reports, report)
+}
+
+func TestCrashReports(t *testing.T) {
+	ctx := context.Background()
+	crashes := &crashLog{}
+	chain, err := server.DefaultRegistry(zap.NewNop(), server.Options{CrashReporter: crashes}).Chain([]string{"recovery", "request_id"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, panicOnGet{api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	panicCount := func() string {
+		if v := expvar.Get("panics").(*expvar.Map).Get("grpc"); v != nil {
+			return v.String()
+		}
+		return "0"
+	}
+	before := panicCount()
+	callCtx := metadata.AppendToOutgoingContext(ctx, api.RequestIDMetadata, "req-123")
+	_, err = pb.NewGameDNAServiceClient(conn).GetGameDNA(callCtx, &pb.GetGameDNARequest{Id: "x"})
+	st := status.Convert(err)
+	if st.Code() != codes.Internal || !strings.Contains(st.Message(), "req-123") {
+		t.Errorf("Expected Internal naming the request ID, got %v", err)
+	}
+	var detailID string
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RequestInfo); ok {
+			detailID = info.RequestId
+		}
+	}
+	if detailID != "req-123" {
+		t.Errorf("Expected a RequestInfo detail with the request ID, got %q", detailID)
+	}
+	if after := panicCount(); after == before {
+		t.Errorf("Expected the panics metric to count the panic, still %s", after)
+	}
+
+	crashes.mu.Lock()
+	if len(crashes.reports) != 1 {
+		t.Fatalf("Expected one crash report, got %d", len(crashes.reports))
+	}
+	report := crashes.reports[0]
+	crashes.mu.Unlock()
+	if report.RequestID != "req-123" || report.Transport != "grpc" || !strings.HasSuffix(report.Method, "/GetGameDNA") {
+		t.Errorf("Unexpected crash report: %+v", report)
+	}
+	if !strings.Contains(report.Request, "GetGameDNARequest") || report.Panic != "boom" || !strings.Contains(report.Stack, "panicOnGet") {
+		t.Errorf("Expected the report to summarise the request and carry the stack, got %+v", report)
+	}
+
+	// Reports are forwarded to Sentry's store endpoint for the DSN.
+	if _, err := server.NewSentryReporter("https://sentry.example.com/42", zap.NewNop()); err == nil {
+		t.Error("Expected a DSN without a key to be rejected")
+	}
+	var gotPath, gotAuth string
+	var event map[string]interface{}
+	sentryAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("X-Sentry-Auth")
+		_ = json.NewDecoder(r.Body).Decode(&event)
+	}))
+	defer sentryAPI.Close()
+	sentry, err := server.NewSentryReporter(strings.Replace(sentryAPI.URL, "://", "://public-key@", 1)+"/prefix/42", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewSentryReporter failed: %v", err)
+	}
+	sentry.Report(report)
+	sentry.Close()
+	if gotPath != "/prefix/api/42/store/" || !strings.Contains(gotAuth, "sentry_key=public-key") {
+		t.Errorf("Unexpected Sentry request: path %q, auth %q", gotPath, gotAuth)
+	}
+	if tags, _ := event["tags"].(map[string]interface{}); tags["request_id"] != "req-123" {
+		t.Errorf("Expected the request ID as a Sentry tag, got %v", event["tags"])
+	}
+}
+
+func TestDataResidency(t *testing.T) {
+	ctx := context.Background()
+	eu, us := storage.NewMemoryStore(), storage.NewMemoryStore()
+	router := residency.NewRouter(
+		map[string]storage.Store{"eu": eu, "us": us},
+		map[string]string{"studio-eu": "eu", "studio-eu2": "eu", "studio-us": "us", "studio-ap": "ap"},
+	)
+	defer router.Close()
+
+	euCtx := residency.WithTenant(ctx, "studio-eu")
+	dna, err := router.Create(euCtx, &pb.GameDNA{Name: "Resident", Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := eu.Read(ctx, dna.Id); err != nil {
+		t.Errorf("Expected the config in the EU store, got %v", err)
+	}
+	if _, err := us.Read(ctx, dna.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the config to stay out of the US store, got %v", err)
+	}
+
+	// Tenants only ever reach their own region's store.
+	if _, err := router.Read(residency.WithTenant(ctx, "studio-us"), dna.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected a US tenant not to find an EU config, got %v", err)
+	}
+	for _, tenant := range []string{"", "unknown", "studio-ap"} {
+		if _, err := router.Read(residency.WithTenant(ctx, tenant), dna.Id); !errors.Is(err, storage.ErrForbidden) {
+			t.Errorf("Expected tenant %q to be denied, got %v", tenant, err)
+		}
+	}
+
+	// Calls act for the tenant of their credentials. The tenant a call names
+	// must match it, and denials map to PermissionDenied.
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(router, rust, api.ServerOptions{}, zap.NewNop())
+	authn, err := auth.New([]auth.Key{
+		{Name: "eu", KeySHA256: auth.HashKey("eu-key"), Scopes: []string{auth.ScopeRead}, Tenant: "studio-eu"},
+		{Name: "ap", KeySHA256: auth.HashKey("ap-key"), Scopes: []string{auth.ScopeRead}, Tenant: "studio-ap"},
+		{Name: "ops", KeySHA256: auth.HashKey("ops-key"), Scopes: []string{auth.ScopeRead}},
+	}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/entropic.dna.v1.GameDNAService/GetGameDNA"}
+	get := func(key, tenant string) error {
+		md := metadata.Pairs(auth.APIKeyMetadata, key)
+		if tenant != "" {
+			md.Append(residency.TenantMetadata, tenant)
+		}
+		_, err := api.UnaryErrorInterceptor(metadata.NewIncomingContext(ctx, md), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return authn.UnaryInterceptor(ctx, req, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				return svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id})
+			})
+		})
+		return err
+	}
+	for _, tenant := range []string{"", "studio-eu"} {
+		if err := get("eu-key", tenant); err != nil {
+			t.Errorf("Expected the EU key naming tenant %q to read its config, got %v", tenant, err)
+		}
+	}
+	for _, c := range []struct{ key, tenant, why string }{
+		{"eu-key", "studio-us", "another tenant's header"},
+		{"ap-key", "", "an unserved region"},
+		{"ops-key", "studio-eu", "a header on a key without a tenant"},
+		{"ops-key", "", "a key without a tenant"},
+	} {
+		if err := get(c.key, c.tenant); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected PermissionDenied for %s, got %v", c.why, err)
+		}
+	}
+
+	// API tokens act for the tenant they were created for, even when another
+	// tenant shares their region's store.
+	token, err := eu.SaveAPIToken(ctx, &storage.APIToken{ID: "t1", Name: "game", Slugs: []string{"resident"}, SecretHash: tokens.Hash("edt_eu"), Tenant: "studio-eu"})
+	if err != nil {
+		t.Fatalf("SaveAPIToken failed: %v", err)
+	}
+	tokenAuth := tokens.NewAuthenticator(router, 0)
+	tokenTenant := func(tenant string) (string, error) {
+		md := metadata.Pairs("authorization", "Bearer edt_eu", residency.TenantMetadata, tenant)
+		info := &grpc.UnaryServerInfo{FullMethod: "/entropic.dna.v1.GameDNAService/GetPublishedConfig"}
+		got, err := tokenAuth.UnaryInterceptor(metadata.NewIncomingContext(ctx, md), nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return residency.TenantFromContext(ctx), nil
+		})
+		tenant, _ = got.(string)
+		return tenant, err
+	}
+	if got, err := tokenTenant("studio-eu"); err != nil || got != token.Tenant {
+		t.Errorf("Expected the token to act for %s, got %q, %v", token.Tenant, got, err)
+	}
+	if _, err := tokenTenant("studio-eu2"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a token used by another tenant in its region, got %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Residency.Databases = map[string]string{"eu": "memory"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected residency with the published cache enabled to be rejected")
+	}
+	cfg.Cache.Enabled = false
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected residency without auth to be rejected")
+	}
+	cfg.Auth.Enabled = true
+	cfg.Auth.APIKeys = []config.APIKeyConfig{{Name: "eu", KeySHA256: auth.HashKey("eu-key"), Scopes: []string{auth.ScopeRead}, Tenant: "studio-eu"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected residency with auth and without the cache to be valid, got %v", err)
+	}
+}
+
+// serveGameDNA serves store over gRPC on a local port and returns a client.
+func serveGameDNA(t *testing.T, store storage.Store) pb.GameDNAServiceClient {
+	return serveGameDNAWith(t, store, api.ServerOptions{})
+}
+
+// serveGameDNAWith is serveGameDNA with service options.
+func serveGameDNAWith(t *testing.T, store storage.Store, opts api.ServerOptions) pb.GameDNAServiceClient {
+	t.Helper()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, api.UnaryValidationInterceptor),
+		grpc.ChainStreamInterceptor(api.StreamErrorInterceptor, api.StreamValidationInterceptor),
+	)
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, opts, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGameDNAServiceClient(conn)
+}
+
+func TestBulkExportImport(t *testing.T) {
+	ctx := context.Background()
+	staging, prod := storage.NewMemoryStore(), storage.NewMemoryStore()
+	defer staging.Close()
+	defer prod.Close()
+	stagingClient, prodClient := serveGameDNA(t, staging), serveGameDNA(t, prod)
+
+	shooter, err := staging.Create(ctx, &pb.GameDNA{Name: "Shooter", Genre: "FPS", Tags: []string{"live"}, Checksum: "a"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	v2 := models.Clone(shooter)
+	v2.MaxPlayers, v2.Checksum = 16, "b"
+	if _, err := staging.Update(ctx, v2); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := staging.Create(ctx, &pb.GameDNA{Name: "Puzzle", Genre: "Puzzle", Checksum: "c"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	export := func(req *pb.ExportGameDNARequest) []byte {
+		t.Helper()
+		stream, err := stagingClient.ExportGameDNA(ctx, req)
+		if err != nil {
+			t.Fatalf("ExportGameDNA failed: %v", err)
+		}
+		var data []byte
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return data
+			}
+			if err != nil {
+				t.Fatalf("ExportGameDNA failed: %v", err)
+			}
+			data = append(data, chunk.Data...)
+		}
+	}
+	importInto := func(client pb.GameDNAServiceClient, opts *pb.ImportGameDNARequest, data []byte) *pb.ImportGameDNAResponse {
+		t.Helper()
+		stream, err := client.ImportGameDNA(ctx)
+		if err != nil {
+			t.Fatalf("ImportGameDNA failed: %v", err)
+		}
+		// Send the options with the first half and the rest separately, as a
+		// chunked upload does.
+		opts.Data = data[:len(data)/2]
+		if err := stream.Send(opts); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if err := stream.Send(&pb.ImportGameDNARequest{Data: data[len(data)/2:]}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		resp, err := stream.CloseAndRecv()
+		if err != nil {
+			t.Fatalf("ImportGameDNA failed: %v", err)
+		}
+		return resp
+	}
+
+	// Filters narrow the export; NDJSON has one config per line.
+	ndjson := export(&pb.ExportGameDNARequest{Tags: []string{"live"}})
+	if lines := strings.Split(strings.TrimSpace(string(ndjson)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], shooter.Id) {
+		t.Errorf("Expected one NDJSON line for the tagged config, got %q", ndjson)
+	}
+
+	archive := export(&pb.ExportGameDNARequest{Format: "zip", IncludeHistory: true})
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Errorf("Expected one zip entry per config, got %d", len(zr.File))
+	}
+
+	// A dry run writes nothing.
+	resp := importInto(prodClient, &pb.ImportGameDNARequest{DryRun: true}, archive)
+	if resp.Counts["would_create"] != 2 {
+		t.Errorf("Expected 2 would_create on dry run, got %v", resp.Counts)
+	}
+	if _, err := prod.Read(ctx, shooter.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the dry run to write nothing, got %v", err)
+	}
+
+	// Restored configs keep their IDs and history.
+	resp = importInto(prodClient, &pb.ImportGameDNARequest{}, archive)
+	if resp.Counts["created"] != 2 {
+		t.Fatalf("Expected 2 created, got %v", resp.Counts)
+	}
+	history, err := prod.GetVersionHistory(ctx, shooter.Id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Data.MaxPlayers != 0 || history[1].Data.MaxPlayers != 16 {
+		t.Fatalf("Expected the two staging versions to be restored, got %d", len(history))
+	}
+	// The recorded changes come through the export too.
+	if changes := history[1].Changes; len(changes) != 1 || changes[0].Field != "max_players" || changes[0].Old != "0" || changes[0].New != "16" {
+		t.Errorf("Expected version 2 to keep its max_players change, got %+v", changes)
+	}
+
+	// Existing configs are skipped unless overwrite is set, which adds a version.
+	v3 := models.Clone(v2)
+	v3.MaxPlayers, v3.Checksum = 32, "d"
+	if _, err := staging.Update(ctx, v3); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	changed := export(&pb.ExportGameDNARequest{Ids: []string{shooter.Id}})
+	if resp := importInto(prodClient, &pb.ImportGameDNARequest{}, changed); resp.Counts["skipped"] != 1 {
+		t.Errorf("Expected the existing config to be skipped, got %v", resp.Counts)
+	}
+	if resp := importInto(prodClient, &pb.ImportGameDNARequest{Overwrite: true}, changed); resp.Counts["updated"] != 1 {
+		t.Errorf("Expected the existing config to be updated, got %v", resp.Counts)
+	}
+	if history, _ := prod.GetVersionHistory(ctx, shooter.Id); len(history) != 3 || history[2].Data.MaxPlayers != 32 {
+		t.Errorf("Expected the overwrite to add a third version, got %d", len(history))
+	}
+	if resp := importInto(prodClient, &pb.ImportGameDNARequest{Overwrite: true}, changed); resp.Counts["unchanged"] != 1 {
+		t.Errorf("Expected an identical config to be unchanged, got %v", resp.Counts)
+	}
+
+	stream, err := prodClient.ImportGameDNA(ctx)
+	if err != nil {
+		t.Fatalf("ImportGameDNA failed: %v", err)
+	}
+	if err := stream.Send(&pb.ImportGameDNARequest{Format: "zip", Data: []byte("not a zip")}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed archive, got %v", err)
+	}
+
+	// Zip limits apply to the archive as a whole, not to each file.
+	importErr := func(build func(zw *zip.Writer)) error {
+		t.Helper()
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		build(zw)
+		if err := zw.Close(); err != nil {
+			t.Fatalf("zip close failed: %v", err)
+		}
+		stream, err := prodClient.ImportGameDNA(ctx)
+		if err != nil {
+			t.Fatalf("ImportGameDNA failed: %v", err)
+		}
+		for data := buf.Bytes(); len(data) > 0; {
+			n := min(len(data), 1<<20)
+			if err := stream.Send(&pb.ImportGameDNARequest{Format: "zip", Data: data[:n]}); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			data = data[n:]
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	}
+	err = importErr(func(zw *zip.Writer) {
+		for i := 0; i < 2; i++ {
+			// Each file alone is under the limit; together they are over it.
+			w, err := zw.CreateRaw(&zip.FileHeader{
+				Name:               fmt.Sprintf("configs/%d.json", i),
+				Method:             zip.Deflate,
+				UncompressedSize64: 200 << 20,
+			})
+			if err != nil {
+				t.Fatalf("zip create failed: %v", err)
+			}
+			w.Write([]byte{0x03, 0x00})
+		}
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "decompresses") {
+		t.Errorf("Expected InvalidArgument for an archive decompressing past the limit, got %v", err)
+	}
+	err = importErr(func(zw *zip.Writer) {
+		for i := 0; i <= 100000; i++ {
+			if _, err := zw.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("%d.txt", i), Method: zip.Store}); err != nil {
+				t.Fatalf("zip create failed: %v", err)
+			}
+		}
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "files") {
+		t.Errorf("Expected InvalidArgument for an archive with too many files, got %v", err)
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	auth := tokens.NewAuthenticator(store, time.Minute)
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, auth.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(api.StreamErrorInterceptor, auth.StreamInterceptor),
+	)
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Tokens: auth}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+
+	for _, name := range []string{"Shooter", "Racer"} {
+		dna, err := store.Create(ctx, &pb.GameDNA{Name: name, Genre: "Action", Checksum: name + "-1"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := store.PublishVersion(ctx, dna.Id, "test", dna.Checksum, dna.Checksum, nil); err != nil {
+			t.Fatalf("PublishVersion failed: %v", err)
+		}
+	}
+	if _, err := store.Create(ctx, &pb.GameDNA{Name: "Draft", Genre: "Action"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	created, err := client.CreateAPIToken(ctx, &pb.CreateAPITokenRequest{Name: "shooter build", Slugs: []string{"shooter", "draft"}, Actor: "ops"})
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, tokens.SecretPrefix) || created.Token.CreatedBy != "ops" {
+		t.Fatalf("unexpected token: %+v", created)
+	}
+	withSecret := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+secret)
+	}
+	tokenCtx := withSecret(created.Secret)
+
+	// Published reads require a token once tokens are enabled.
+	if _, err := client.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: "shooter"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("read without token: got %v, want Unauthenticated", err)
+	}
+	got, err := client.GetPublishedConfig(tokenCtx, &pb.GetPublishedConfigRequest{Slug: "shooter"})
+	if err != nil || got.GameDna.Name != "Shooter" || got.Checksum != "Shooter-1" {
+		t.Fatalf("GetPublishedConfig = %+v, %v", got, err)
+	}
+	if _, err := client.GetPublishedConfig(tokenCtx, &pb.GetPublishedConfigRequest{Slug: "racer"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("read outside allowlist: got %v, want PermissionDenied", err)
+	}
+	if _, err := client.GetPublishedConfig(tokenCtx, &pb.GetPublishedConfigRequest{Slug: "draft"}); status.Code(err) != codes.NotFound {
+		t.Errorf("read of draft: got %v, want NotFound", err)
+	}
+	if _, err := client.GetGameDNA(tokenCtx, &pb.GetGameDNARequest{Id: "shooter"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("token on admin API: got %v, want PermissionDenied", err)
+	}
+	if _, err := client.GetPublishedConfig(withSecret(tokens.SecretPrefix+"bogus"), &pb.GetPublishedConfigRequest{Slug: "shooter"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unknown token: got %v, want Unauthenticated", err)
+	}
+
+	sync, err := client.SyncPublishedConfigs(tokenCtx, &pb.SyncPublishedConfigsRequest{})
+	if err != nil {
+		t.Fatalf("SyncPublishedConfigs failed: %v", err)
+	}
+	if len(sync.Changed) != 1 || sync.Changed[0].Slug != "shooter" || !slices.Equal(sync.Missing, []string{"draft"}) {
+		t.Errorf("sync = %+v", sync)
+	}
+	sync, err = client.SyncPublishedConfigs(tokenCtx, &pb.SyncPublishedConfigsRequest{
+		Slugs:     []string{"shooter", "racer"},
+		Checksums: map[string]string{"shooter": "Shooter-1"},
+	})
+	if err != nil {
+		t.Fatalf("SyncPublishedConfigs failed: %v", err)
+	}
+	if len(sync.Changed) != 0 || !slices.Equal(sync.Missing, []string{"racer"}) {
+		t.Errorf("sync with checksums = %+v", sync)
+	}
+
+	// The old secret keeps working through the rotation grace period only.
+	rotated, err := client.RotateAPIToken(ctx, &pb.RotateAPITokenRequest{Id: created.Token.Id, GraceSeconds: 3600})
+	if err != nil {
+		t.Fatalf("RotateAPIToken failed: %v", err)
+	}
+	for _, secret := range []string{created.Secret, rotated.Secret} {
+		if _, err := client.GetPublishedConfig(withSecret(secret), &pb.GetPublishedConfigRequest{Slug: "shooter"}); err != nil {
+			t.Errorf("read during grace period failed: %v", err)
+		}
+	}
+	rotated, err = client.RotateAPIToken(ctx, &pb.RotateAPITokenRequest{Id: created.Token.Id})
+	if err != nil {
+		t.Fatalf("RotateAPIToken failed: %v", err)
+	}
+	if _, err := client.GetPublishedConfig(tokenCtx, &pb.GetPublishedConfigRequest{Slug: "shooter"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("rotated-out secret: got %v, want Unauthenticated", err)
+	}
+
+	// Revocation applies at once despite the lookup cache.
+	if _, err := client.RevokeAPIToken(ctx, &pb.RevokeAPITokenRequest{Id: created.Token.Id}); err != nil {
+		t.Fatalf("RevokeAPIToken failed: %v", err)
+	}
+	if _, err := client.GetPublishedConfig(withSecret(rotated.Secret), &pb.GetPublishedConfigRequest{Slug: "shooter"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("revoked token: got %v, want Unauthenticated", err)
+	}
+	if _, err := client.RotateAPIToken(ctx, &pb.RotateAPITokenRequest{Id: created.Token.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("rotate revoked token: got %v, want FailedPrecondition", err)
+	}
+	list, err := client.ListAPITokens(ctx, &pb.ListAPITokensRequest{})
+	if err != nil || len(list.Tokens) != 1 || !list.Tokens[0].Revoked {
+		t.Errorf("ListAPITokens = %+v, %v", list, err)
+	}
+
+	// Token admin RPCs are off unless tokens are enabled.
+	disabled := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := disabled.CreateAPIToken(ctx, &pb.CreateAPITokenRequest{Name: "x", Slugs: []string{"shooter"}}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("CreateAPIToken while disabled: got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	ctx := context.Background()
+
+	// A collector that keeps the spans it is sent, by name.
+	type otlpSpan struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Kind         int    `json:"kind"`
+		Status       struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	}
+	var (
+		mu    sync.Mutex
+		spans = make(map[string][]otlpSpan)
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Bearer otlp" {
+			t.Errorf("unexpected export to %s", r.URL.Path)
+		}
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode export: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					spans[span.Name] = append(spans[span.Name], span)
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	exporter, err := tracing.NewOTLPExporter(collector.URL, "entropic-dna-api", map[string]string{"Authorization": "Bearer otlp"})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter failed: %v", err)
+	}
+	tracer := tracing.NewTracer(exporter, 1, time.Hour, zap.NewNop())
+	chain, err := server.DefaultRegistry(zap.NewNop(), server.Options{Tracer: tracer}).Chain([]string{"recovery", "request_id", "tracing", "errors"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(tracing.WrapStore(store), rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+
+	// A gRPC caller's trace is continued.
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callCtx := metadata.AppendToOutgoingContext(ctx, tracing.TraceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	created, err := client.CreateGameDNA(callCtx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Traced", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+
+	// A REST request is traced through the proxied call, as the gateway makes it.
+	handler := chain.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := client.GetGameDNA(r.Context(), &pb.GetGameDNARequest{Id: created.GameDna.Id})
+		if err != nil {
+			t.Errorf("GetGameDNA failed: %v", err)
+		}
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/game-dna/"+created.GameDna.Id, nil))
+	restTrace := rec.Header().Get(tracing.TraceIDHeader)
+
+	// Callers declining to sample are respected.
+	unsampled := metadata.AppendToOutgoingContext(ctx, tracing.TraceparentHeader, "00-5bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if _, err := client.ListGameDNA(unsampled, &pb.ListGameDNARequest{}); err != nil {
+		t.Fatalf("ListGameDNA failed: %v", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	tracer.Shutdown(shutdownCtx)
+	mu.Lock()
+	defer mu.Unlock()
+
+	createSpans := spans["entropic.dna.v1.GameDNAService/CreateGameDNA"]
+	if len(createSpans) != 1 {
+		t.Fatalf("Expected one CreateGameDNA span, got %v", spans)
+	}
+	create := createSpans[0]
+	if create.TraceID != traceID || create.ParentSpanID != "00f067aa0ba902b7" || create.Kind != int(tracing.SpanKindServer) {
+		t.Errorf("CreateGameDNA span does not continue the caller's trace: %+v", create)
+	}
+	for _, name := range []string{"storage.Create", "ffi.ValidateGameDNA"} {
+		var found bool
+		for _, span := range spans[name] {
+			found = found || (span.TraceID == traceID && span.ParentSpanID == create.SpanID)
+		}
+		if !found {
+			t.Errorf("Expected a %s child of the CreateGameDNA span, got %v", name, spans[name])
+		}
+	}
+
+	// REST request -> gateway hop -> RPC -> storage query, all in one trace.
+	httpSpans, getSpans, clientSpans := spans["HTTP GET"], spans["entropic.dna.v1.GameDNAService/GetGameDNA"], 0
+	if len(httpSpans) != 1 || httpSpans[0].TraceID != restTrace || httpSpans[0].ParentSpanID != "" {
+		t.Fatalf("Expected one root HTTP span in trace %s, got %v", restTrace, httpSpans)
+	}
+	var server otlpSpan
+	for _, span := range getSpans {
+		if span.Kind == int(tracing.SpanKindClient) {
+			clientSpans++
+			if span.ParentSpanID != httpSpans[0].SpanID {
+				t.Errorf("gateway hop span is not a child of the HTTP span: %+v", span)
+			}
+		} else {
+			server = span
+		}
+	}
+	if clientSpans != 1 || server.TraceID != restTrace {
+		t.Errorf("Expected a client and a server GetGameDNA span in trace %s, got %v", restTrace, getSpans)
+	}
+	if read := spans["storage.Read"]; len(read) != 1 || read[0].ParentSpanID != server.SpanID {
+		t.Errorf("Expected a storage.Read child of the GetGameDNA span, got %v", read)
+	}
+	if len(spans["entropic.dna.v1.GameDNAService/ListGameDNA"]) != 0 || len(spans["storage.List"]) != 0 {
+		t.Error("Expected no spans for an unsampled trace")
+	}
+}
+
+func TestRequestTracing(t *testing.T) {
+	ctx := context.Background()
+	journal := tracing.NewJournal(3)
+	logger := zap.NewNop().WithOptions(zap.WrapCore(journal.WrapCore))
+	chain, err := server.DefaultRegistry(logger, server.Options{Journal: journal}).Chain([]string{"recovery", "request_id", "tracing", "logging", "errors"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Journal: journal}, logger))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+
+	// The caller's trace is continued and echoed with the request ID.
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callCtx := metadata.AppendToOutgoingContext(ctx,
+		api.RequestIDMetadata, "req-create",
+		tracing.TraceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	var header, trailer metadata.MD
+	created, err := client.CreateGameDNA(callCtx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Traced", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	if got := header.Get(tracing.TraceIDMetadata); !slices.Equal(got, []string{traceID}) {
+		t.Errorf("trace ID header = %v, want %s", got, traceID)
+	}
+	if got := trailer.Get(api.RequestIDMetadata); !slices.Equal(got, []string{"req-create"}) {
+		t.Errorf("request ID trailer = %v", got)
+	}
+
+	// Errors carry both IDs; callers without a trace get a new one.
+	_, err = client.GetGameDNA(metadata.AppendToOutgoingContext(ctx, api.RequestIDMetadata, "req-missing"),
+		&pb.GetGameDNARequest{Id: "no-such-config"}, grpc.Header(&header))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	var info *errdetails.RequestInfo
+	for _, d := range status.Convert(err).Details() {
+		if ri, ok := d.(*errdetails.RequestInfo); ok {
+			info = ri
+		}
+	}
+	newTrace := header.Get(tracing.TraceIDMetadata)
+	if info == nil || info.RequestId != "req-missing" || len(newTrace) != 1 || info.ServingData != "trace_id="+newTrace[0] {
+		t.Errorf("Expected RequestInfo with request and trace IDs, got %v (header %v)", info, newTrace)
+	}
+
+	got, err := client.LookupRequest(ctx, &pb.LookupRequestRequest{RequestId: "req-create"})
+	if err != nil {
+		t.Fatalf("LookupRequest failed: %v", err)
+	}
+	if got.TraceId != traceID || got.Code != codes.OK.String() || !strings.HasSuffix(got.Method, "/CreateGameDNA") {
+		t.Errorf("unexpected lookup: %+v", got)
+	}
+	if len(got.AuditEntries) != 1 || got.AuditEntries[0].Type != events.TypeCreated || got.AuditEntries[0].ConfigId != created.GameDna.Id {
+		t.Errorf("audit entries = %v", got.AuditEntries)
+	}
+	if len(got.Versions) != 1 || got.Versions[0].Checksum != created.GameDna.Checksum {
+		t.Errorf("versions = %v", got.Versions)
+	}
+	if len(got.Logs) == 0 || got.Logs[0].Fields["trace_id"] != traceID {
+		t.Errorf("logs = %v", got.Logs)
+	}
+
+	// The journal keeps only its most recent requests.
+	if _, err := client.LookupRequest(ctx, &pb.LookupRequestRequest{RequestId: "req-create"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected an evicted request to be NotFound, got %v", err)
+	}
+	disabled := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := disabled.LookupRequest(ctx, &pb.LookupRequestRequest{RequestId: "x"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a journal, got %v", err)
+	}
+}
+
+func TestWatchGameDNA(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	broker := events.NewBroker(16)
+	client := serveGameDNAWith(t, store, api.ServerOptions{Events: broker, Watch: broker})
+
+	dna := func(name string, tags ...string) *pb.GameDNA {
+		return &pb.GameDNA{Name: name, Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}, Tags: tags}
+	}
+	live, err := client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Live Shooter", "live")})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+
+	// A tag watch sees new events only, filtered by tag and type.
+	watch, err := client.WatchGameDNA(ctx, &pb.WatchGameDNARequest{Tags: []string{"live"}, Types: []string{events.TypeCreated, events.TypePublished}})
+	if err != nil {
+		t.Fatalf("WatchGameDNA failed: %v", err)
+	}
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("Expected headers once the watch is registered, got %v", err)
+	}
+
+	if _, err := client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Draft Puzzle")}); err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	if _, err := client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna("Live Racer", "live")}); err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	if _, err := client.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: live.GameDna.Id}); err != nil {
+		t.Fatalf("PublishGameDNA failed: %v", err)
+	}
+	var got []string
+	for len(got) < 2 {
+		ev, err := watch.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		got = append(got, ev.Type+" "+ev.GetGameDna().GetName())
+	}
+	if want := []string{"created Live Racer", "published Live Shooter"}; !slices.Equal(got, want) {
+		t.Errorf("watched %v, want %v", got, want)
+	}
+
+	// A slug watch resumes after a sequence and sees deletes.
+	byID, err := client.WatchGameDNA(ctx, &pb.WatchGameDNARequest{ConfigId: live.GameDna.Slug, AfterSequence: 1})
+	if err != nil {
+		t.Fatalf("WatchGameDNA failed: %v", err)
+	}
+	ev, err := byID.Recv()
+	if err != nil || ev.Type != events.TypePublished || ev.ConfigId != live.GameDna.Id {
+		t.Fatalf("replayed %v, %v", ev, err)
+	}
+
+	for req, want := range map[*pb.WatchGameDNARequest]codes.Code{
+		{Types: []string{"renamed"}}: codes.InvalidArgument,
+		{ConfigId: "no-such-slug"}:   codes.NotFound,
+		{AfterSequence: -1}:          codes.InvalidArgument,
+	} {
+		w, err := client.WatchGameDNA(ctx, req)
+		if err == nil {
+			_, err = w.Recv()
+		}
+		if status.Code(err) != want {
+			t.Errorf("WatchGameDNA(%v): got %v, want %v", req, err, want)
+		}
+	}
+	disabled := serveGameDNAWith(t, store, api.ServerOptions{Events: broker})
+	w, err := disabled.WatchGameDNA(ctx, &pb.WatchGameDNARequest{})
+	if err == nil {
+		_, err = w.Recv()
+	}
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without Watch, got %v", err)
+	}
+}
+
+func TestAuthScopes(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	secret := "jwt-test-secret"
+	authn, err := auth.New([]auth.Key{
+		{Name: "dashboard", KeySHA256: auth.HashKey("read-key"), Scopes: []string{auth.ScopeRead}},
+		{Name: "ci", KeySHA256: auth.HashKey("write-key"), Scopes: []string{auth.ScopeRead, auth.ScopeWrite}},
+		{Name: "ops", KeySHA256: auth.HashKey("admin-key"), Scopes: []string{auth.ScopeAdmin}},
+	}, auth.JWT{Secret: secret, Audience: "entropic-dna"})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	if _, err := auth.New([]auth.Key{{Name: "bad", KeySHA256: auth.HashKey("x"), Scopes: []string{"delete"}}}, auth.JWT{}); err == nil {
+		t.Error("Expected an unknown scope to be rejected")
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, authn.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(api.StreamErrorInterceptor, authn.StreamInterceptor),
+	)
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, auth.APIKeyMetadata, key)
+	}
+	jwt := func(claims map[string]interface{}) string {
+		enc := func(v interface{}) string {
+			b, _ := json.Marshal(v)
+			return base64.RawURLEncoding.EncodeToString(b)
+		}
+		unsigned := enc(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + enc(claims)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	withJWT := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	publisher := jwt(map[string]interface{}{"sub": "release-bot", "aud": "entropic-dna", "exp": exp, "scope": "read publish"})
+
+	// Missing and unknown credentials are rejected before any handler runs.
+	for name, callCtx := range map[string]context.Context{
+		"none":          ctx,
+		"unknown key":   withKey("nope"),
+		"expired":       withJWT(jwt(map[string]interface{}{"aud": "entropic-dna", "exp": float64(time.Now().Add(-time.Hour).Unix()), "scope": "read"})),
+		"no exp":        withJWT(jwt(map[string]interface{}{"aud": "entropic-dna", "scope": "read"})),
+		"wrong aud":     withJWT(jwt(map[string]interface{}{"aud": "other", "exp": exp, "scope": "read"})),
+		"bad signature": withJWT(publisher[:len(publisher)-2] + "AA"),
+	} {
+		if _, err := client.ListGameDNA(callCtx, &pb.ListGameDNARequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: expected Unauthenticated, got %v", name, err)
+		}
+	}
+
+	// Each method needs its scope; admin grants them all.
+	dna := &pb.GameDNA{Name: "Scoped", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	if _, err := client.ListGameDNA(withKey("read-key"), &pb.ListGameDNARequest{}); err != nil {
+		t.Errorf("read key: ListGameDNA failed: %v", err)
+	}
+	if _, err := client.CreateGameDNA(withKey("read-key"), &pb.CreateGameDNARequest{GameDna: dna}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("read key: expected CreateGameDNA to be PermissionDenied, got %v", err)
+	}
+	created, err := client.CreateGameDNA(withKey("write-key"), &pb.CreateGameDNARequest{GameDna: dna})
+	if err != nil {
+		t.Fatalf("write key: CreateGameDNA failed: %v", err)
+	}
+	publish := &pb.PublishGameDNARequest{Id: created.GameDna.Id}
+	if _, err := client.PublishGameDNA(withKey("write-key"), publish); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("write key: expected PublishGameDNA to be PermissionDenied, got %v", err)
+	}
+	if _, err := client.PublishGameDNA(withJWT(publisher), publish); err != nil {
+		t.Errorf("publish JWT: PublishGameDNA failed: %v", err)
+	}
+	if _, err := client.LookupRequest(withJWT(publisher), &pb.LookupRequestRequest{RequestId: "x"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("publish JWT: expected LookupRequest to be PermissionDenied, got %v", err)
+	}
+	// Past authentication, the disabled journal answers FailedPrecondition.
+	if _, err := client.LookupRequest(withKey("admin-key"), &pb.LookupRequestRequest{RequestId: "x"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("admin key: expected LookupRequest to reach the handler, got %v", err)
+	}
+	if _, err := client.GetGameDNA(withKey("admin-key"), &pb.GetGameDNARequest{Id: created.GameDna.Id}); err != nil {
+		t.Errorf("admin key: GetGameDNA failed: %v", err)
+	}
+	if got := auth.RequiredScope("/grpc.health.v1.Health/Check"); got != "" {
+		t.Errorf("Expected health checks to need no scope, got %q", got)
+	}
+
+	// REST handlers outside the gateway are wrapped with Require.
+	events := httptest.NewServer(authn.Require(auth.ScopeWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.FromContext(r.Context()); !ok || p.Name != "ci" {
+			t.Errorf("Expected the ci principal, got %+v", p)
+		}
+	})))
+	defer events.Close()
+	for key, want := range map[string]int{"": http.StatusUnauthorized, "read-key": http.StatusForbidden, "write-key": http.StatusOK} {
+		req, _ := http.NewRequest(http.MethodGet, events.URL, nil)
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("key %q: got status %d, want %d", key, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestDefaultsMaterialization(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	client := serveGameDNAWith(t, store, api.ServerOptions{})
+
+	stored, err := store.Create(ctx, &pb.GameDNA{Name: "Sparse", Genre: "RPG", TargetPlatforms: []string{"Console"}, Checksum: "sparse-1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.PublishVersion(ctx, stored.Id, "test", stored.Checksum, stored.Checksum, nil); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+
+	raw, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: stored.Id})
+	if err != nil {
+		t.Fatalf("GetGameDNA failed: %v", err)
+	}
+	if raw.GameDna.MaxPlayers != 0 || raw.GameDna.Camera != "" || raw.DefaultsVersion != 0 {
+		t.Errorf("Expected raw values by default, got %+v", raw)
+	}
+
+	got, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: stored.Id, Defaults: api.DefaultsMaterialized})
+	if err != nil {
+		t.Fatalf("GetGameDNA failed: %v", err)
+	}
+	dna := got.GameDna
+	if dna.MaxPlayers != 1 || dna.TargetFps != 60 || dna.Camera != "Perspective3D" || dna.TimeScale != 1 {
+		t.Errorf("Expected engine defaults for unset fields, got %+v", dna)
+	}
+	// Set fields keep their values, and the checksum stays that of the stored record.
+	if dna.Genre != "RPG" || !slices.Equal(dna.TargetPlatforms, []string{"Console"}) || dna.Checksum != "sparse-1" {
+		t.Errorf("Expected stored values to be kept, got %+v", dna)
+	}
+	if got.DefaultsVersion != 1 {
+		t.Errorf("Expected defaults version 1, got %d", got.DefaultsVersion)
+	}
+	again, _ := store.Read(ctx, stored.Id)
+	if again.MaxPlayers != 0 {
+		t.Errorf("Expected materializing not to touch the stored record, got max_players %d", again.MaxPlayers)
+	}
+
+	list, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{Defaults: api.DefaultsMaterialized})
+	if err != nil {
+		t.Fatalf("ListGameDNA failed: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].TargetFps != 60 || list.DefaultsVersion != 1 {
+		t.Errorf("Expected materialized list items, got %+v", list)
+	}
+	if _, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{Defaults: "engine"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an unknown defaults mode to be InvalidArgument, got %v", err)
+	}
+
+	// With the server default flipped, requests can still ask for raw values.
+	materializing := serveGameDNAWith(t, store, api.ServerOptions{MaterializeDefaults: true})
+	sync, err := materializing.SyncPublishedConfigs(ctx, &pb.SyncPublishedConfigsRequest{Slugs: []string{stored.Slug}})
+	if err != nil {
+		t.Fatalf("SyncPublishedConfigs failed: %v", err)
+	}
+	if len(sync.Changed) != 1 || sync.Changed[0].MaxEntities != 10000 || sync.DefaultsVersion != 1 {
+		t.Errorf("Expected materialized sync, got %+v", sync)
+	}
+	published, err := materializing.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: stored.Slug, Defaults: api.DefaultsRaw})
+	if err != nil {
+		t.Fatalf("GetPublishedConfig failed: %v", err)
+	}
+	if published.GameDna.MaxEntities != 0 || published.DefaultsVersion != 0 {
+		t.Errorf("Expected raw values when asked, got %+v", published)
+	}
+
+	schema, err := client.GetSchema(ctx, &pb.GetSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetSchema failed: %v", err)
+	}
+	fields := make(map[string]*pb.SchemaField)
+	for _, f := range schema.Fields {
+		fields[f.Name] = f
+	}
+	if schema.DefaultsVersion != 1 || schema.LatestDefaultsVersion != 1 {
+		t.Errorf("Expected defaults version 1, got %+v", schema)
+	}
+	if f := fields["target_fps"]; f == nil || !f.HasDefault || f.DefaultValue != "60" || f.Type != "uint32" || f.JsonName != "targetFps" {
+		t.Errorf("Unexpected target_fps schema: %+v", f)
+	}
+	if f := fields["target_platforms"]; f == nil || f.DefaultValue != `["PC"]` || f.Type != "repeated string" {
+		t.Errorf("Unexpected target_platforms schema: %+v", f)
+	}
+	if f := fields["weather_enabled"]; f == nil || !f.HasDefault || f.DefaultValue != "false" {
+		t.Errorf("Unexpected weather_enabled schema: %+v", f)
+	}
+	if f := fields["esrb_rating"]; f == nil || f.HasDefault {
+		t.Errorf("Expected esrb_rating to have no default, got %+v", f)
+	}
+	if _, err := client.GetSchema(ctx, &pb.GetSchemaRequest{DefaultsVersion: 99}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected an unknown defaults version to be NotFound, got %v", err)
+	}
+}
+
+func TestChecksumMigration(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	checksums := integrity.NewChecksums(store, rust.CalculateChecksum, zap.NewNop())
+	client := serveGameDNAWith(t, store, api.ServerOptions{Checksums: checksums})
+
+	// Records written with stale checksums, as by an older checksum algorithm.
+	for i := 0; i < 3; i++ {
+		if _, err := store.Create(ctx, &pb.GameDNA{Name: fmt.Sprintf("Stale %d", i), Genre: "RPG", Checksum: fmt.Sprintf("stale-%d", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	good := &pb.GameDNA{Name: "Good", Slug: "good", Version: "1.0.0", Genre: "FPS"}
+	good.Checksum, _ = rust.CalculateChecksum(good)
+	if _, err := store.Create(ctx, good); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	run := func(req *pb.StartChecksumMigrationRequest) *pb.ChecksumMigration {
+		t.Helper()
+		started, err := client.StartChecksumMigration(ctx, req)
+		if err != nil {
+			t.Fatalf("StartChecksumMigration failed: %v", err)
+		}
+		checksums.Wait()
+		got, err := client.GetChecksumMigration(ctx, &pb.GetChecksumMigrationRequest{Id: started.Migration.Id})
+		if err != nil {
+			t.Fatalf("GetChecksumMigration failed: %v", err)
+		}
+		return got.Migration
+	}
+
+	dry := run(&pb.StartChecksumMigrationRequest{DryRun: true, BatchSize: 2, Actor: "ops"})
+	if dry.State != storage.ChecksumJobCompleted || dry.ConfigsScanned != 4 || dry.ConfigMismatches != 3 || dry.VersionMismatches != 3 || dry.Fixed != 0 {
+		t.Fatalf("Unexpected dry run result: %+v", dry)
+	}
+	if len(dry.Mismatches) != 6 || dry.StartedBy != "ops" {
+		t.Errorf("Expected every mismatch recorded, got %+v", dry.Mismatches)
+	}
+	if dna, _ := store.Read(ctx, dry.Mismatches[0].ConfigId); dna.Checksum == dry.Mismatches[0].Computed {
+		t.Error("Expected a dry run not to fix checksums")
+	}
+
+	fixed := run(&pb.StartChecksumMigrationRequest{BatchSize: 2})
+	if fixed.State != storage.ChecksumJobCompleted || fixed.Fixed != 6 {
+		t.Fatalf("Expected 6 checksums fixed, got %+v", fixed)
+	}
+	for _, m := range fixed.Mismatches {
+		dna, _ := store.Read(ctx, m.ConfigId)
+		if m.Version == 0 && dna.Checksum != m.Computed {
+			t.Errorf("Expected config %s to have checksum %s, got %s", m.ConfigId, m.Computed, dna.Checksum)
+		}
+	}
+	if clean := run(&pb.StartChecksumMigrationRequest{DryRun: true}); clean.ConfigMismatches+clean.VersionMismatches != 0 {
+		t.Errorf("Expected no mismatches after fixing, got %+v", clean)
+	}
+
+	// Two configs a second: cancel after the first batch, then resume.
+	started, err := client.StartChecksumMigration(ctx, &pb.StartChecksumMigrationRequest{DryRun: true, BatchSize: 1, MaxConfigsPerSecond: 2})
+	if err != nil {
+		t.Fatalf("StartChecksumMigration failed: %v", err)
+	}
+	if _, err := client.StartChecksumMigration(ctx, &pb.StartChecksumMigrationRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected a second job to be refused while one runs, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancelled, err := client.CancelChecksumMigration(ctx, &pb.CancelChecksumMigrationRequest{Id: started.Migration.Id})
+	if err != nil {
+		t.Fatalf("CancelChecksumMigration failed: %v", err)
+	}
+	if m := cancelled.Migration; m.State != storage.ChecksumJobCancelled || m.ConfigsScanned == 0 || m.ConfigsScanned == 4 {
+		t.Fatalf("Expected a partly done cancelled job, got %+v", m)
+	}
+	resumed := run(&pb.StartChecksumMigrationRequest{ResumeId: started.Migration.Id})
+	if resumed.Id != started.Migration.Id || resumed.State != storage.ChecksumJobCompleted || resumed.ConfigsScanned != 4 {
+		t.Errorf("Expected the resumed job to finish the remaining configs, got %+v", resumed)
+	}
+	if _, err := client.StartChecksumMigration(ctx, &pb.StartChecksumMigrationRequest{ResumeId: started.Migration.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected resuming a completed job to fail, got %v", err)
+	}
+	if _, err := client.GetChecksumMigration(ctx, &pb.GetChecksumMigrationRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected an unknown job to be NotFound, got %v", err)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	broker := events.NewBroker(16)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Drafted", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	eventCount := func() int {
+		backlog, _, cancel := broker.Subscribe(0, events.Filter{})
+		cancel()
+		return len(backlog)
+	}
+	eventsBefore := eventCount()
+
+	edit := models.Clone(created.GameDna)
+	for _, fps := range []uint32{45, 60, 90} {
+		edit.TargetFps = fps
+		if _, err := svc.SaveDraft(ctx, &pb.SaveDraftRequest{ConfigId: created.GameDna.Slug, GameDna: edit, Actor: "alice"}); err != nil {
+			t.Fatalf("SaveDraft failed: %v", err)
+		}
+	}
+	if _, err := svc.SaveDraft(ctx, &pb.SaveDraftRequest{ConfigId: id, GameDna: edit}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a draft without an owner to be InvalidArgument, got %v", err)
+	}
+
+	// Saving drafts leaves the config, its history and the event stream alone.
+	if history, _ := store.GetVersionHistory(ctx, id); len(history) != 1 {
+		t.Errorf("Expected drafts not to write versions, got %d", len(history))
+	}
+	if dna, _ := store.Read(ctx, id); dna.TargetFps != 30 {
+		t.Errorf("Expected the config to be unchanged, got target_fps %d", dna.TargetFps)
+	}
+	if got := eventCount(); got != eventsBefore {
+		t.Errorf("Expected drafts not to send events, got %d new", got-eventsBefore)
+	}
+
+	got, err := svc.GetDraft(ctx, &pb.GetDraftRequest{ConfigId: id, Actor: "alice"})
+	if err != nil {
+		t.Fatalf("GetDraft failed: %v", err)
+	}
+	if got.Draft.GameDna.TargetFps != 90 || got.Draft.Owner != "alice" || got.Draft.Stale || !got.Validation.IsValid {
+		t.Errorf("Unexpected draft: %+v", got)
+	}
+	if _, err := svc.GetDraft(ctx, &pb.GetDraftRequest{ConfigId: id, Actor: "bob"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected drafts to be private to their owner, got %v", err)
+	}
+
+	// Bob's update makes Alice's draft stale; promoting it then needs force.
+	bobs := models.Clone(created.GameDna)
+	bobs.MaxPlayers = 8
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: bobs}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := svc.GetDraft(ctx, &pb.GetDraftRequest{ConfigId: id, Actor: "alice"}); !got.Draft.Stale {
+		t.Error("Expected the draft to be stale after the config changed")
+	}
+	if _, err := svc.PromoteDraft(ctx, &pb.PromoteDraftRequest{ConfigId: id, Actor: "alice"}); status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected promoting a stale draft to be Aborted, got %v", err)
+	}
+	eventsBefore = eventCount()
+	promoted, err := svc.PromoteDraft(ctx, &pb.PromoteDraftRequest{ConfigId: id, Actor: "alice", Force: true})
+	if err != nil {
+		t.Fatalf("PromoteDraft failed: %v", err)
+	}
+	if promoted.GameDna.TargetFps != 90 {
+		t.Errorf("Expected the draft's values, got %+v", promoted.GameDna)
+	}
+	if history, _ := store.GetVersionHistory(ctx, id); len(history) != 3 {
+		t.Errorf("Expected one version for the promote, got %d in total", len(history))
+	}
+	if got := eventCount(); got != eventsBefore+1 {
+		t.Errorf("Expected one event for the promote, got %d", got-eventsBefore)
+	}
+	if _, err := svc.GetDraft(ctx, &pb.GetDraftRequest{ConfigId: id, Actor: "alice"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the promoted draft to be discarded, got %v", err)
+	}
+
+	if _, err := svc.SaveDraft(ctx, &pb.SaveDraftRequest{ConfigId: id, GameDna: edit, Actor: "bob"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+	if _, err := svc.DiscardDraft(ctx, &pb.DiscardDraftRequest{ConfigId: id, Actor: "bob"}); err != nil {
+		t.Fatalf("DiscardDraft failed: %v", err)
+	}
+	if _, err := svc.DiscardDraft(ctx, &pb.DiscardDraftRequest{ConfigId: id, Actor: "bob"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected discarding twice to be NotFound, got %v", err)
+	}
+
+	// Deleting the config deletes its drafts.
+	if _, err := svc.SaveDraft(ctx, &pb.SaveDraftRequest{ConfigId: id, GameDna: edit, Actor: "bob"}); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.GetDraft(ctx, id, "bob"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected drafts to go with their config, got %v", err)
+	}
+}
+
+func TestStorageCodecs(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	data := bytes.Repeat([]byte(`{"name":"Arena","genre":"FPS"}`), 50)
+
+	// Compressed, then encrypted; reading reverses the order.
+	chain, err := storage.NewCodecs([]string{storage.CodecGzip, storage.CodecAESGCM}, key)
+	if err != nil {
+		t.Fatalf("NewCodecs failed: %v", err)
+	}
+	if names := chain.Names(); !slices.Equal(names, []string{"gzip", "aes-gcm"}) {
+		t.Errorf("Names = %v", names)
+	}
+	encoded, err := chain.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if bytes.Contains(encoded, []byte("Arena")) || len(encoded) >= len(data) {
+		t.Errorf("Expected compressed ciphertext, got %d bytes for %d", len(encoded), len(data))
+	}
+	decoded, err := chain.Decode(chain.Names(), encoded)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("Decode = %q, %v", decoded, err)
+	}
+
+	// Data stays readable by a chain holding the same codecs in another order,
+	// but not by one missing a codec or holding another key.
+	reordered, _ := storage.NewCodecs([]string{storage.CodecAESGCM, storage.CodecGzip}, key)
+	if decoded, err := reordered.Decode([]string{"gzip", "aes-gcm"}, encoded); err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("reordered Decode = %v", err)
+	}
+	gzipOnly, _ := storage.NewCodecs([]string{storage.CodecGzip}, nil)
+	if _, err := gzipOnly.Decode([]string{"gzip", "aes-gcm"}, encoded); err == nil {
+		t.Error("Expected decoding without the aes-gcm codec to fail")
+	}
+	otherKey, _ := storage.NewCodecs([]string{storage.CodecGzip, storage.CodecAESGCM}, make([]byte, 32))
+	if _, err := otherKey.Decode(chain.Names(), encoded); err == nil {
+		t.Error("Expected decoding with another key to fail")
+	}
+
+	for _, bad := range [][]string{{"zstd"}, {"gzip", "gzip"}} {
+		if _, err := storage.NewCodecs(bad, key); err == nil {
+			t.Errorf("Expected codecs %v to be rejected", bad)
+		}
+	}
+	if _, err := storage.NewCodecs([]string{storage.CodecAESGCM}, key[:10]); err == nil {
+		t.Error("Expected a 10-byte key to be rejected")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Database.Codecs = []string{"gzip", "aes-gcm"}
+	cfg.Database.EncryptionKey = base64.StdEncoding.EncodeToString(key)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected gzip and aes-gcm with a 32-byte key to be valid: %v", err)
+	}
+	cfg.Database.EncryptionKey = base64.StdEncoding.EncodeToString(key[:10])
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a short encryption key to be rejected")
+	}
+	cfg.Database.Codecs = []string{"lz4"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an unknown codec to be rejected")
+	}
+}
+
+func TestValidationProfiles(t *testing.T) {
+	rust, _ := ffi.NewRustFFI("", false)
+	base := func(genre string) *pb.GameDNA {
+		return &pb.GameDNA{Name: "Profiled", Genre: genre, TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	codes := func(resp *pb.ValidationResponse) (errs, warnings []string) {
+		for _, e := range resp.Errors {
+			errs = append(errs, e.Code+":"+e.Field)
+		}
+		for _, w := range resp.Warnings {
+			warnings = append(warnings, w.Code+":"+w.Field)
+		}
+		return errs, warnings
+	}
+
+	// VR needs 72 FPS and a camera; the profile is picked case-insensitively.
+	vr := base("vr")
+	resp, _ := rust.ValidateGameDNA(vr)
+	errs, _ := codes(resp)
+	if resp.IsValid || resp.Profile != "vr" || !slices.Equal(errs, []string{"MISSING_REQUIRED_FIELD:camera", "FPS_BELOW_PROFILE_MINIMUM:target_fps"}) {
+		t.Errorf("VR at 60 FPS without a camera: profile %q, errors %v", resp.Profile, errs)
+	}
+	vr.TargetFps, vr.Camera = 90, "first_person"
+	if resp, _ := rust.ValidateGameDNA(vr); !resp.IsValid {
+		t.Errorf("Expected VR at 90 FPS with a camera to pass: %v", resp.Errors)
+	}
+
+	// An entity count fine for an RTS is warned about for an FPS.
+	rts := base("RTS")
+	rts.Camera, rts.MaxEntities = "top_down", 50000
+	if resp, _ := rust.ValidateGameDNA(rts); !resp.IsValid || len(resp.Warnings) != 0 || resp.Profile != "rts" {
+		t.Errorf("RTS with 50000 entities: valid %v, warnings %v", resp.IsValid, resp.Warnings)
+	}
+	fps := base("FPS")
+	fps.MaxEntities = 50000
+	resp, _ = rust.ValidateGameDNA(fps)
+	if _, warnings := codes(resp); !resp.IsValid || !slices.Equal(warnings, []string{"ABOVE_PROFILE_LIMIT:max_entities"}) {
+		t.Errorf("FPS with 50000 entities: valid %v, warnings %v", resp.IsValid, warnings)
+	}
+
+	// Genres without a profile get the default one.
+	if resp, _ := rust.ValidateGameDNA(base("Racing")); resp.Profile != ffi.DefaultProfile || resp.RulesVersion != ffi.BasicRulesVersion {
+		t.Errorf("Racing: profile %q, rules %q", resp.Profile, resp.RulesVersion)
+	}
+
+	// Projects override their configs' profiles.
+	if err := rust.SetProfileOverrides(map[string]ffi.ProfileOverride{
+		"arena":  {MaxEntities: 100000},
+		"mobile": {Profile: "puzzle", RequiredFields: []string{"monetization"}},
+	}); err != nil {
+		t.Fatalf("SetProfileOverrides failed: %v", err)
+	}
+	fps.CustomProperties = map[string]string{ffi.ProjectProperty: "arena"}
+	if resp, _ := rust.ValidateGameDNA(fps); len(resp.Warnings) != 0 {
+		t.Errorf("Expected the arena project's entity limit to apply, got %v", resp.Warnings)
+	}
+	mobile := base("FPS")
+	mobile.CustomProperties = map[string]string{ffi.ProjectProperty: "mobile"}
+	resp, _ = rust.ValidateGameDNA(mobile)
+	if errs, _ := codes(resp); resp.Profile != "puzzle" || !slices.Equal(errs, []string{"MISSING_REQUIRED_FIELD:monetization"}) {
+		t.Errorf("mobile project: profile %q, errors %v", resp.Profile, errs)
+	}
+
+	for _, bad := range []map[string]ffi.ProfileOverride{
+		{"x": {Profile: "racing"}},
+		{"x": {RequiredFields: []string{"no_such_field"}}},
+	} {
+		if err := rust.SetProfileOverrides(bad); err == nil {
+			t.Errorf("Expected %v to be rejected", bad)
+		}
+	}
+}
+
+func TestPartialUpdate(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Patched", Genre: "FPS", TargetFps: 60, MaxEntities: 500, Camera: "first_person",
+		TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	update := func(values *pb.GameDNA, paths ...string) (*pb.GameDNAResponse, error) {
+		return svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+			Id: id, GameDna: values, UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+		})
+	}
+
+	// Only masked fields change; the rest of the sparse request is ignored.
+	resp, err := update(&pb.GameDNA{TargetFps: 90, Name: "Ignored"}, "target_fps", "camera")
+	if err != nil {
+		t.Fatalf("Partial update failed: %v", err)
+	}
+	got := resp.GameDna
+	if got.TargetFps != 90 || got.Camera != "" || got.Name != "Patched" || got.MaxEntities != 500 || got.Slug != created.GameDna.Slug {
+		t.Errorf("Unexpected merge: fps %d, camera %q, name %q, entities %d, slug %q", got.TargetFps, got.Camera, got.Name, got.MaxEntities, got.Slug)
+	}
+	if history, _ := store.GetVersionHistory(ctx, id); len(history) != 2 {
+		t.Errorf("Expected the partial update to record a version, got %d", len(history))
+	}
+
+	// The merged config, not the sparse request, is validated.
+	if _, err := update(&pb.GameDNA{Genre: "VR"}, "genre"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a VR genre without a camera to fail validation, got %v", err)
+	}
+	if dna, _ := store.Read(ctx, id); dna.Genre != "FPS" {
+		t.Errorf("Expected a rejected update to leave the config alone, got genre %q", dna.Genre)
+	}
+
+	for _, paths := range [][]string{{"no_such_field"}, {"checksum"}, {"camera.fov"}} {
+		if _, err := update(&pb.GameDNA{}, paths...); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected mask %v to be InvalidArgument, got %v", paths, err)
+		}
+	}
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+		Id: "missing", GameDna: &pb.GameDNA{}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected a missing config to be ErrNotFound, got %v", err)
+	}
+}
+
+func TestUnpublish(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	broker := events.NewBroker(16)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Shipped", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+
+	if _, err := svc.UnpublishGameDNA(ctx, &pb.UnpublishGameDNARequest{Id: id, Reason: "early", Actor: "ops"}); !errors.Is(err, storage.ErrNotLocked) {
+		t.Errorf("Expected unpublishing an unlocked config to be ErrNotLocked, got %v", err)
+	}
+	published, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if _, err := svc.UnpublishGameDNA(ctx, &pb.UnpublishGameDNARequest{Id: id, Actor: "ops"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an unpublish without a reason to be InvalidArgument, got %v", err)
+	}
+	if got := auth.RequiredScope("/entropic.dna.v1.GameDNAService/UnpublishGameDNA"); got != auth.ScopeAdmin {
+		t.Errorf("Expected UnpublishGameDNA to need the admin scope, got %q", got)
+	}
+
+	// The authenticated principal is recorded, not the actor in the request.
+	adminCtx := auth.WithPrincipal(ctx, &auth.Principal{Name: "release-admin", Scopes: []string{auth.ScopeAdmin}})
+	resp, err := svc.UnpublishGameDNA(adminCtx, &pb.UnpublishGameDNARequest{Id: created.GameDna.Slug, Reason: "spawn rate exploit", Actor: "ignored"})
+	if err != nil {
+		t.Fatalf("Unpublish failed: %v", err)
+	}
+	if resp.GameDna.IsLocked || resp.GameDna.PublishedRulesVersion != "" || resp.GameDna.Checksum != published.Checksum {
+		t.Errorf("Unexpected unpublished config: locked %v, rules %q, checksum %q", resp.GameDna.IsLocked, resp.GameDna.PublishedRulesVersion, resp.GameDna.Checksum)
+	}
+	entry := resp.AuditEntry
+	if entry.Action != storage.AuditActionUnpublish || entry.Actor != "release-admin" || entry.Reason != "spawn rate exploit" || entry.VersionNum != 3 {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+	history, _ := store.GetVersionHistory(ctx, id)
+	if len(history) != 3 || history[2].CreatedBy != "release-admin" || !slices.Contains(history[2].ChangedFields, "is_locked") {
+		t.Errorf("Expected unpublish to record version 3 by release-admin, got %d versions", len(history))
+	}
+	backlog, _, cancel := broker.Subscribe(0, events.Filter{Types: []string{events.TypeUnpublished}})
+	cancel()
+	if len(backlog) != 1 || backlog[0].ConfigId != id {
+		t.Errorf("Expected one unpublished event, got %d", len(backlog))
+	}
+
+	// The config is editable again, and the audit log survives its deletion.
+	edit := models.Clone(resp.GameDna)
+	edit.TargetFps = 90
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: edit}); err != nil {
+		t.Errorf("Expected the unpublished config to be editable, got %v", err)
+	}
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	log, err := svc.GetAuditLog(ctx, &pb.GetAuditLogRequest{ConfigId: id})
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(log.Entries) != 1 || log.Entries[0].Id != entry.Id || log.Entries[0].CreateTime == nil {
+		t.Errorf("Expected the audit entry to outlive the config, got %v", log.Entries)
+	}
+}
+
+func TestValidationWaivers(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		PublishMaxWarnings: 0,
+		MaxWaiverDuration:  30 * 24 * time.Hour,
+	}, zap.NewNop())
+
+	// 5000 entities is above the FPS profile's limit, which blocks publishing.
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Waived", Genre: "FPS", TargetFps: 60, MaxEntities: 5000, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected the warning to block publishing, got %v", err)
+	}
+
+	expires := timestamppb.New(time.Now().Add(7 * 24 * time.Hour))
+	for _, bad := range []*pb.CreateValidationWaiverRequest{
+		{ConfigId: id, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities", Approver: "lead", ExpireTime: expires},
+		{ConfigId: id, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities", Justification: "ok", Approver: "lead"},
+		{ConfigId: id, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities", Justification: "ok", Approver: "lead", ExpireTime: timestamppb.New(time.Now().Add(-time.Hour))},
+		{ConfigId: id, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities", Justification: "ok", Approver: "lead", ExpireTime: timestamppb.New(time.Now().Add(60 * 24 * time.Hour))},
+	} {
+		if _, err := svc.CreateValidationWaiver(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected %v to be InvalidArgument, got %v", bad, err)
+		}
+	}
+
+	approverCtx := auth.WithPrincipal(ctx, &auth.Principal{Name: "tech-lead", Scopes: []string{auth.ScopePublish}})
+	waiver, err := svc.CreateValidationWaiver(approverCtx, &pb.CreateValidationWaiverRequest{
+		ConfigId: created.GameDna.Slug, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities",
+		Justification: "Stress-test map", ExpireTime: expires,
+	})
+	if err != nil {
+		t.Fatalf("CreateValidationWaiver failed: %v", err)
+	}
+	if waiver.Waiver.Approver != "tech-lead" || !waiver.Waiver.Active || waiver.Waiver.ConfigId != id {
+		t.Errorf("Unexpected waiver: %+v", waiver.Waiver)
+	}
+
+	// The warning is reported as waived and no longer blocks publishing.
+	resp, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{Id: id})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(resp.Warnings) != 0 || len(resp.Waived) != 1 || resp.Waived[0].WaiverId != waiver.Waiver.Id || resp.Waived[0].IsError {
+		t.Errorf("Expected the warning to be waived, got warnings %v, waived %v", resp.Warnings, resp.Waived)
+	}
+	published, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+	if err != nil {
+		t.Fatalf("Expected the waived warning not to block publishing, got %v", err)
+	}
+	if len(published.Validation.Waived) != 1 {
+		t.Errorf("Expected the published report to record the waiver, got %v", published.Validation.Waived)
+	}
+
+	// Revoked and expired waivers stop applying but stay listed.
+	revoked, err := svc.RevokeValidationWaiver(ctx, &pb.RevokeValidationWaiverRequest{ConfigId: id, Id: waiver.Waiver.Id, Actor: "auditor"})
+	if err != nil {
+		t.Fatalf("RevokeValidationWaiver failed: %v", err)
+	}
+	if revoked.Waiver.Active || revoked.Waiver.RevokedBy != "auditor" || revoked.Waiver.RevokeTime == nil {
+		t.Errorf("Unexpected revoked waiver: %+v", revoked.Waiver)
+	}
+	if _, err := store.SaveWaiver(ctx, &storage.Waiver{
+		ID: "lapsed", ConfigID: id, Code: "ABOVE_PROFILE_LIMIT", Field: "max_entities",
+		Justification: "Old exemption", Approver: "tech-lead", ExpiresAt: time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatalf("SaveWaiver failed: %v", err)
+	}
+	resp, _ = svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{Id: id})
+	if len(resp.Warnings) != 1 || len(resp.Waived) != 0 {
+		t.Errorf("Expected the warning to resurface, got warnings %v, waived %v", resp.Warnings, resp.Waived)
+	}
+	active, _ := svc.ListValidationWaivers(ctx, &pb.ListValidationWaiversRequest{ConfigId: id})
+	all, _ := svc.ListValidationWaivers(ctx, &pb.ListValidationWaiversRequest{ConfigId: id, IncludeInactive: true})
+	if len(active.Waivers) != 0 || len(all.Waivers) != 2 {
+		t.Errorf("Expected 0 active and 2 recorded waivers, got %d and %d", len(active.Waivers), len(all.Waivers))
+	}
+	if _, err := svc.RevokeValidationWaiver(ctx, &pb.RevokeValidationWaiverRequest{ConfigId: id, Id: "missing", Actor: "auditor"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected revoking a missing waiver to be NotFound, got %v", err)
+	}
+}
+
+func TestListFieldEnums(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	resp, err := svc.ListFieldEnums(ctx, &pb.ListFieldEnumsRequest{})
+	if err != nil {
+		t.Fatalf("ListFieldEnums failed: %v", err)
+	}
+	if resp.RulesVersion != ffi.BasicRulesVersion {
+		t.Errorf("Expected rules version %s, got %s", ffi.BasicRulesVersion, resp.RulesVersion)
+	}
+
+	// Every enum names a string field, and the engine defaults are among its values.
+	fields := (&pb.GameDNA{}).ProtoReflect().Descriptor().Fields()
+	defaults := schema.LatestDefaults().Values.ProtoReflect()
+	var names []string
+	for _, e := range resp.Enums {
+		names = append(names, e.Field)
+		fd := fields.ByName(protoreflect.Name(e.Field))
+		if fd == nil || fd.Kind() != protoreflect.StringKind || len(e.Values) == 0 {
+			t.Errorf("%s is not a string field with values", e.Field)
+			continue
+		}
+		def := defaults.Get(fd).String()
+		if def != "" && !slices.ContainsFunc(e.Values, func(v *pb.EnumValue) bool { return v.Value == def }) {
+			t.Errorf("Default %s of %s is not among its values", def, e.Field)
+		}
+	}
+	for _, want := range []string{"genre", "camera", "tone", "physics_profile", "monetization", "esrb_rating"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("Expected an enum for %s, got %v", want, names)
+		}
+	}
+
+	only, err := svc.ListFieldEnums(ctx, &pb.ListFieldEnumsRequest{Fields: []string{"tone", "camera"}})
+	if err != nil || len(only.Enums) != 2 || only.Enums[0].Field != "camera" {
+		t.Errorf("Expected camera and tone in schema order, got %v (%v)", only.GetEnums(), err)
+	}
+	if _, err := svc.ListFieldEnums(ctx, &pb.ListFieldEnumsRequest{Fields: []string{"target_fps"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a non-enum field to be InvalidArgument, got %v", err)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	// Every built-in preset creates a config that validates without warnings.
+	list, err := svc.ListTemplates(ctx, &pb.ListTemplatesRequest{})
+	if err != nil {
+		t.Fatalf("ListTemplates failed: %v", err)
+	}
+	if len(list.Templates) == 0 {
+		t.Fatal("Expected built-in templates")
+	}
+	for _, tmpl := range list.Templates {
+		if !tmpl.Builtin {
+			t.Errorf("Expected only built-in templates, got %s", tmpl.Name)
+		}
+		created, err := svc.CreateFromTemplate(ctx, &pb.CreateFromTemplateRequest{Template: tmpl.Name, Name: "From " + tmpl.Name, Actor: "designer"})
+		if err != nil {
+			t.Errorf("CreateFromTemplate(%s) failed: %v", tmpl.Name, err)
+			continue
+		}
+		if created.GameDna.Genre != tmpl.GameDna.Genre || created.GameDna.CreatedBy != "designer" {
+			t.Errorf("Unexpected config from %s: %+v", tmpl.Name, created.GameDna)
+		}
+		resp, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{Id: created.GameDna.Id})
+		if err != nil || !resp.IsValid || len(resp.Warnings) > 0 {
+			t.Errorf("Config from %s does not validate cleanly: %v %v", tmpl.Name, resp, err)
+		}
+	}
+
+	// Overrides apply only to the masked fields.
+	created, err := svc.CreateFromTemplate(ctx, &pb.CreateFromTemplateRequest{
+		Template:   "fps-arena",
+		Name:       "Big Arena",
+		Slug:       "big-arena",
+		Overrides:  &pb.GameDNA{MaxPlayers: 32, Tone: "Arcade"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"max_players"}},
+		Actor:      "designer",
+	})
+	if err != nil {
+		t.Fatalf("CreateFromTemplate with overrides failed: %v", err)
+	}
+	if created.GameDna.MaxPlayers != 32 || created.GameDna.Tone != "Realistic" || created.GameDna.Slug != "big-arena" {
+		t.Errorf("Unexpected overridden config: %+v", created.GameDna)
+	}
+	for _, bad := range []*pb.CreateFromTemplateRequest{
+		{Template: "fps-arena", Actor: "designer"},
+		{Template: "fps-arena", Name: "No mask", Overrides: &pb.GameDNA{MaxPlayers: 32}, Actor: "designer"},
+		{Template: "fps-arena", Name: "Bad mask", Overrides: &pb.GameDNA{}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}}, Actor: "designer"},
+		{Template: "fps-arena", Name: "No actor"},
+	} {
+		if _, err := svc.CreateFromTemplate(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected %v to be InvalidArgument, got %v", bad, err)
+		}
+	}
+	if _, err := svc.CreateFromTemplate(ctx, &pb.CreateFromTemplateRequest{Template: "missing", Name: "Missing", Actor: "designer"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected a missing template to be not found, got %v", err)
+	}
+
+	// User-defined templates keep only config content and their first author.
+	source, _ := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: created.GameDna.Id})
+	saved, err := svc.SaveTemplate(ctx, &pb.SaveTemplateRequest{Name: "studio-arena", Description: "House rules", GameDna: source.GameDna, Actor: "lead"})
+	if err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	data := saved.Template.GameDna
+	if saved.Template.Builtin || saved.Template.CreatedBy != "lead" || data.Id != "" || data.Name != "" || data.Slug != "" || data.Checksum != "" || data.MaxPlayers != 32 {
+		t.Errorf("Unexpected saved template: %+v", saved.Template)
+	}
+	resaved, err := svc.SaveTemplate(ctx, &pb.SaveTemplateRequest{Name: "studio-arena", Description: "House rules v2", GameDna: source.GameDna, Actor: "someone-else"})
+	if err != nil {
+		t.Fatalf("SaveTemplate replace failed: %v", err)
+	}
+	if resaved.Template.CreatedBy != "lead" || resaved.Template.Description != "House rules v2" {
+		t.Errorf("Unexpected replaced template: %+v", resaved.Template)
+	}
+	for _, bad := range []*pb.SaveTemplateRequest{
+		{Name: "fps-arena", GameDna: source.GameDna, Actor: "lead"},
+		{Name: "Not A Slug", GameDna: source.GameDna, Actor: "lead"},
+		{Name: "empty-template", Actor: "lead"},
+	} {
+		if _, err := svc.SaveTemplate(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected %v to be InvalidArgument, got %v", bad.Name, err)
+		}
+	}
+
+	fromUser, err := svc.CreateFromTemplate(ctx, &pb.CreateFromTemplateRequest{Template: "studio-arena", Name: "Studio Arena", Actor: "designer"})
+	if err != nil || fromUser.GameDna.MaxPlayers != 32 {
+		t.Fatalf("CreateFromTemplate(studio-arena) failed: %v %v", fromUser, err)
+	}
+	fps, _ := svc.ListTemplates(ctx, &pb.ListTemplatesRequest{Genre: "fps"})
+	if names := templateNames(fps.Templates); !slices.Equal(names, []string{"fps-arena", "studio-arena"}) {
+		t.Errorf("Unexpected FPS templates: %v", names)
+	}
+
+	if _, err := svc.DeleteTemplate(ctx, &pb.DeleteTemplateRequest{Name: "fps-arena"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected deleting a built-in template to fail, got %v", err)
+	}
+	if _, err := svc.DeleteTemplate(ctx, &pb.DeleteTemplateRequest{Name: "studio-arena"}); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if _, err := svc.GetTemplate(ctx, &pb.GetTemplateRequest{Name: "studio-arena"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the deleted template to be gone, got %v", err)
+	}
+	if _, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: fromUser.GameDna.Id}); err != nil {
+		t.Errorf("Expected configs created from a deleted template to remain: %v", err)
+	}
+}
+
+func templateNames(templates []*pb.Template) []string {
+	var names []string
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestTemporaryUnlock(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{MaxUnlockWindow: 2 * time.Hour}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Live", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	if _, err := svc.RequestTemporaryUnlock(ctx, &pb.RequestTemporaryUnlockRequest{Id: id, Reason: "hotfix", Actor: "oncall"}); !errors.Is(err, storage.ErrNotLocked) {
+		t.Errorf("Expected unlocking an unpublished config to be ErrNotLocked, got %v", err)
+	}
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	for _, bad := range []*pb.RequestTemporaryUnlockRequest{
+		{Id: id, Actor: "oncall"},
+		{Id: id, Reason: "hotfix", Actor: "oncall", DurationSeconds: -1},
+		{Id: id, Reason: "hotfix", Actor: "oncall", DurationSeconds: 3 * 3600},
+		{Id: id, Reason: "hotfix"},
+	} {
+		if _, err := svc.RequestTemporaryUnlock(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected %v to be InvalidArgument, got %v", bad, err)
+		}
+	}
+	if got := auth.RequiredScope("/entropic.dna.v1.GameDNAService/RequestTemporaryUnlock"); got != auth.ScopePublish {
+		t.Errorf("Expected RequestTemporaryUnlock to need the publish scope, got %q", got)
+	}
+
+	// Without a duration the window lasts 30 minutes.
+	unlocked, err := svc.RequestTemporaryUnlock(ctx, &pb.RequestTemporaryUnlockRequest{Id: created.GameDna.Slug, Reason: "spawn crash", Actor: "oncall"})
+	if err != nil {
+		t.Fatalf("RequestTemporaryUnlock failed: %v", err)
+	}
+	window := unlocked.Unlock.ExpireTime.AsTime().Sub(unlocked.Unlock.CreateTime.AsTime())
+	if unlocked.GameDna.IsLocked || window != 30*time.Minute || unlocked.AuditEntry.Action != storage.AuditActionTemporaryUnlock {
+		t.Errorf("Unexpected unlock: locked %v, window %s, audit %+v", unlocked.GameDna.IsLocked, window, unlocked.AuditEntry)
+	}
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+		Id: id, GameDna: &pb.GameDNA{TargetFps: 90}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}},
+	}); err != nil {
+		t.Fatalf("Hotfix update failed: %v", err)
+	}
+	if n, err := svc.RelockExpired(ctx); err != nil || n != 0 {
+		t.Errorf("Expected an open window not to be relocked, got %d %v", n, err)
+	}
+	list, _ := svc.ListTemporaryUnlocks(ctx, &pb.ListTemporaryUnlocksRequest{})
+	if len(list.Unlocks) != 1 || list.Unlocks[0].ConfigId != id || list.Unlocks[0].Actor != "oncall" {
+		t.Errorf("Unexpected open unlocks: %v", list.Unlocks)
+	}
+
+	// Finishing publishes the hotfix as a new version.
+	history, _ := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id})
+	finished, err := svc.FinishTemporaryUnlock(ctx, &pb.FinishTemporaryUnlockRequest{Id: id, Actor: "oncall"})
+	if err != nil {
+		t.Fatalf("FinishTemporaryUnlock failed: %v", err)
+	}
+	if !finished.GameDna.IsLocked || finished.GameDna.TargetFps != 90 || finished.Validation == nil || finished.Unlock != nil {
+		t.Errorf("Unexpected relocked config: %+v", finished)
+	}
+	if e := finished.AuditEntry; e.Action != storage.AuditActionRelock || e.Actor != "oncall" || e.VersionNum != int64(len(history.Versions))+1 {
+		t.Errorf("Unexpected relock audit entry: %+v", e)
+	}
+	if _, err := svc.FinishTemporaryUnlock(ctx, &pb.FinishTemporaryUnlockRequest{Id: id, Actor: "oncall"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected finishing twice to be ErrNotFound, got %v", err)
+	}
+
+	// Expired windows are relocked by the sweep, as the system.
+	if _, _, err := store.UnlockTemporarily(ctx, id, "oncall", "another hotfix", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("UnlockTemporarily failed: %v", err)
+	}
+	if n, err := svc.RelockExpired(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected one expired unlock to be relocked, got %d %v", n, err)
+	}
+	current, _ := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id})
+	audit, _ := svc.GetAuditLog(ctx, &pb.GetAuditLogRequest{ConfigId: id})
+	last := audit.Entries[len(audit.Entries)-1]
+	if !current.GameDna.IsLocked || last.Action != storage.AuditActionRelock || last.Actor != "system" || len(audit.Entries) != 4 {
+		t.Errorf("Unexpected state after expiry: locked %v, audit %v", current.GameDna.IsLocked, audit.Entries)
+	}
+
+	// Publishing during a window closes it.
+	if _, err := svc.RequestTemporaryUnlock(ctx, &pb.RequestTemporaryUnlockRequest{Id: id, Reason: "tweak", Actor: "oncall", DurationSeconds: 600}); err != nil {
+		t.Fatalf("RequestTemporaryUnlock failed: %v", err)
+	}
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if list, _ := svc.ListTemporaryUnlocks(ctx, &pb.ListTemporaryUnlocksRequest{}); len(list.Unlocks) != 0 {
+		t.Errorf("Expected publishing to close the window, got %v", list.Unlocks)
+	}
+}
+
+func TestCanary(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	live := func(fps uint32) *pb.GameDNA {
+		return &pb.GameDNA{Name: "Arena", Genre: "FPS", TargetFps: fps, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: live(60)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id, slug := created.GameDna.Id, created.GameDna.Slug
+	if _, err := svc.StartCanary(ctx, &pb.StartCanaryRequest{Id: id, GameDna: live(90), Actor: "lead"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected a canary of a draft to be FailedPrecondition, got %v", err)
+	}
+	published, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	for _, bad := range []*pb.StartCanaryRequest{
+		{Id: id, Actor: "lead"},
+		{Id: id, GameDna: live(90), Actor: "lead", Percent: 100},
+		{Id: id, GameDna: live(90), Actor: "lead", MaxErrorRate: 2},
+		{Id: id, GameDna: live(90), Actor: "lead", MinSessions: -1},
+		{Id: id, GameDna: live(60), Actor: "lead"},
+	} {
+		if _, err := svc.StartCanary(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected %v to be InvalidArgument, got %v", bad, err)
+		}
+	}
+	if got := auth.RequiredScope("/entropic.dna.v1.GameDNAService/StartCanary"); got != auth.ScopePublish {
+		t.Errorf("Expected StartCanary to need the publish scope, got %q", got)
+	}
+	if tokens.AllowedMethods["/entropic.dna.v1.GameDNAService/ReportConfigFeedback"] {
+		t.Error("Expected API tokens not to be allowed to report feedback")
+	}
+	if got := auth.RequiredScope("/entropic.dna.v1.GameDNAService/ReportConfigFeedback"); got != auth.ScopeWrite {
+		t.Errorf("Expected ReportConfigFeedback to need the write scope, got %q", got)
+	}
+
+	started, err := svc.StartCanary(ctx, &pb.StartCanaryRequest{
+		Id: slug, GameDna: live(90), Actor: "lead", Percent: 50, MaxErrorRate: 0.2, MinSessions: 10, DurationSeconds: 600,
+	})
+	if err != nil {
+		t.Fatalf("StartCanary failed: %v", err)
+	}
+	c := started.Canary
+	if c.State != storage.CanaryRunning || c.BaseChecksum != published.Checksum || c.Checksum == c.BaseChecksum ||
+		c.Candidate.Slug != slug || c.MaxErrorRateIncrease != 0.01 || c.EndTime.AsTime().Sub(c.CreateTime.AsTime()) != 10*time.Minute {
+		t.Errorf("Unexpected canary: %+v", c)
+	}
+	if _, err := svc.StartCanary(ctx, &pb.StartCanaryRequest{Id: id, GameDna: live(120), Actor: "lead"}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected a second running canary to be ErrConflict, got %v", err)
+	}
+
+	// Readers are split by bucket key; readers without one get the published content.
+	inCanary := ""
+	served := 0
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("player-%d", i)
+		resp, err := svc.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: slug, BucketKey: key})
+		if err != nil {
+			t.Fatalf("GetPublishedConfig failed: %v", err)
+		}
+		if resp.Canary != (resp.Checksum == c.Checksum) || (resp.GameDna.TargetFps == 90) != resp.Canary {
+			t.Fatalf("Response for %s doesn't match its canary flag: %+v", key, resp)
+		}
+		if resp.Canary {
+			served++
+			inCanary = key
+		}
+	}
+	if served < 70 || served > 130 {
+		t.Errorf("Expected about half of the readers in a 50%% canary, got %d of 200", served)
+	}
+	if resp, _ := svc.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: slug}); resp.Canary || resp.Checksum != published.Checksum {
+		t.Errorf("Expected a read without a bucket key to be served the published content, got %+v", resp)
+	}
+	sync, err := svc.SyncPublishedConfigs(ctx, &pb.SyncPublishedConfigsRequest{
+		Slugs: []string{slug}, Checksums: map[string]string{slug: published.Checksum}, BucketKey: inCanary,
+	})
+	if err != nil || len(sync.Changed) != 1 || sync.Changed[0].Checksum != c.Checksum {
+		t.Errorf("Expected sync to return the candidate to a canary reader, got %v %v", sync, err)
+	}
+
+	// The candidate is judged once it has min_sessions sessions.
+	report := func(checksum string, sessions, errs int64) *pb.ReportConfigFeedbackResponse {
+		t.Helper()
+		resp, err := svc.ReportConfigFeedback(ctx, &pb.ReportConfigFeedbackRequest{Slug: slug, Checksum: checksum, Sessions: sessions, Errors: errs})
+		if err != nil {
+			t.Fatalf("ReportConfigFeedback failed: %v", err)
+		}
+		return resp
+	}
+	if _, err := svc.ReportConfigFeedback(ctx, &pb.ReportConfigFeedbackRequest{Slug: slug, Checksum: c.Checksum, Sessions: 1, Errors: 2}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected more errors than sessions to be InvalidArgument, got %v", err)
+	}
+	if _, err := svc.ReportConfigFeedback(ctx, &pb.ReportConfigFeedbackRequest{Slug: slug, Checksum: c.Checksum, Sessions: 10001}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a report of more than 10000 sessions to be InvalidArgument, got %v", err)
+	}
+	if resp := report("unknown", 5, 0); resp.Recorded {
+		t.Error("Expected feedback on unknown content to be dropped")
+	}
+	report(published.Checksum, 100, 1)
+	report(c.Checksum, 5, 5)
+	if got, _ := svc.GetCanary(ctx, &pb.GetCanaryRequest{Id: id}); got.Canary.State != storage.CanaryRunning ||
+		got.Canary.CandidateFeedback.Sessions != 5 || got.Canary.StableFeedback.ErrorRate != 0.01 {
+		t.Errorf("Expected the canary to keep running below min_sessions, got %+v", got.Canary)
+	}
+	if resp := report(c.Checksum, 10, 0); !resp.Recorded {
+		t.Error("Expected candidate feedback to be recorded")
+	}
+	rolledBack, _ := svc.GetCanary(ctx, &pb.GetCanaryRequest{Id: id})
+	if rb := rolledBack.Canary; rb.State != storage.CanaryRolledBack || rb.FinishedBy != "system" || !strings.Contains(rb.Reason, "exceeds 0.2000") {
+		t.Errorf("Expected a 33%% error rate to roll the canary back, got %+v", rb)
+	}
+	if resp, _ := svc.GetPublishedConfig(ctx, &pb.GetPublishedConfigRequest{Slug: slug, BucketKey: inCanary}); resp.Canary {
+		t.Error("Expected a rolled back canary not to be served")
+	}
+	if resp := report(c.Checksum, 1, 0); resp.Recorded {
+		t.Error("Expected feedback after the canary finished to be dropped")
+	}
+
+	// Ended canaries are finished by the sweep: rolled back without enough
+	// sessions, promoted otherwise.
+	restart := func() {
+		t.Helper()
+		if _, err := store.StartCanary(ctx, &storage.Canary{
+			ConfigID: id, Candidate: models.Clone(c.Candidate), Checksum: c.Checksum, BaseChecksum: c.BaseChecksum,
+			Percent: 50, MinSessions: 10, State: storage.CanaryRunning, StartedBy: "lead",
+			CreatedAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(-time.Second),
+		}); err != nil {
+			t.Fatalf("StartCanary failed: %v", err)
+		}
+	}
+	restart()
+	if n, err := svc.FinishCanaries(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected one ended canary to be finished, got %d %v", n, err)
+	}
+	if got, _ := svc.GetCanary(ctx, &pb.GetCanaryRequest{Id: id}); got.Canary.State != storage.CanaryRolledBack {
+		t.Errorf("Expected a canary without feedback to be rolled back, got %+v", got.Canary)
+	}
+
+	restart()
+	report(c.Checksum, 10, 0)
+	history, _ := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id})
+	if n, err := svc.FinishCanaries(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected one ended canary to be finished, got %d %v", n, err)
+	}
+	current, _ := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id})
+	if !current.GameDna.IsLocked || current.GameDna.TargetFps != 90 || current.GameDna.Checksum != c.Checksum {
+		t.Errorf("Expected the candidate to be published, got %+v", current.GameDna)
+	}
+	after, _ := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id})
+	audit, _ := svc.GetAuditLog(ctx, &pb.GetAuditLogRequest{ConfigId: id})
+	last := audit.Entries[len(audit.Entries)-1]
+	if len(after.Versions) != len(history.Versions)+1 || last.Action != storage.AuditActionCanaryPromote || last.Actor != "system" {
+		t.Errorf("Unexpected promotion records: %d versions, audit %+v", len(after.Versions), last)
+	}
+	if _, err := svc.PromoteCanary(ctx, &pb.PromoteCanaryRequest{Id: id, Actor: "lead"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected promoting a finished canary to be ErrNotFound, got %v", err)
+	}
+
+	// The canary is part of its starter's data.
+	data, err := svc.ExportPrincipalData(ctx, &pb.ExportPrincipalDataRequest{Principal: "lead"})
+	if err != nil || len(data.Canaries) != 1 || data.Canaries[0].State != storage.CanaryPromoted {
+		t.Errorf("Expected the canary in the principal's data, got %v %v", data, err)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	ctx := context.Background()
+	injector := faults.NewInjector()
+	store := faults.WrapStore(storage.NewMemoryStore(), injector)
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	rust.SetFaults(injector)
+	checks := []api.ReadinessCheck{
+		{Name: "database", Check: store.Ping},
+		{Name: "validation", Check: rust.Check},
+	}
+
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{Readiness: checks}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	ready := func() (int, string) {
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+		return rec.Code, rec.Body.String()
+	}
+	if code, body := ready(); code != http.StatusOK || body != "grpc: ok\ndatabase: ok\nvalidation: ok\n" {
+		t.Errorf("Expected every check to pass, got %d %q", code, body)
+	}
+	if err := api.CheckReadiness(ctx, checks); err != nil {
+		t.Errorf("Expected CheckReadiness to pass, got %v", err)
+	}
+
+	if err := injector.Set([]faults.Rule{{Target: "storage.Ping", ErrorRate: 1, Message: "connection refused"}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if code, body := ready(); code != http.StatusServiceUnavailable ||
+		!strings.Contains(body, "database: ") || !strings.Contains(body, "connection refused") || !strings.Contains(body, "validation: ok") {
+		t.Errorf("Expected the database check to fail, got %d %q", code, body)
+	}
+	if err := api.CheckReadiness(ctx, checks); !errors.Is(err, faults.ErrInjected) || !strings.HasPrefix(err.Error(), "database: ") {
+		t.Errorf("Expected CheckReadiness to name the failed check, got %v", err)
+	}
+
+	if err := injector.Set([]faults.Rule{{Target: "ffi.Check", ErrorRate: 1}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if code, body := ready(); code != http.StatusServiceUnavailable || !strings.Contains(body, "validation: ") || !strings.Contains(body, "database: ok") {
+		t.Errorf("Expected the validation check to fail, got %d %q", code, body)
+	}
+
+	// A gRPC server that is shutting down is not ready, whatever the
+	// dependencies say.
+	if err := injector.Set(nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	healthServer.Shutdown()
+	if code, body := ready(); code != http.StatusServiceUnavailable || !strings.Contains(body, "grpc: status NOT_SERVING") {
+		t.Errorf("Expected the gRPC check to fail after shutdown, got %d %q", code, body)
+	}
+
+	rec := httptest.NewRecorder()
+	gateway.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz to stay up while not ready, got %d", rec.Code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	authn, err := auth.New([]auth.Key{
+		{Name: "ci", KeySHA256: auth.HashKey("ci-key"), Scopes: []string{auth.ScopeAdmin}},
+	}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	// Slow enough that no bucket refills during the test.
+	slow := func(burst int) ratelimit.Limit { return ratelimit.Limit{Rate: 0.01, Burst: burst} }
+	if _, err := ratelimit.New(ratelimit.Config{Methods: map[string]ratelimit.Limit{"DropTables": slow(1)}}, authn); err == nil {
+		t.Error("Expected an unknown method to be rejected")
+	}
+	if _, err := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{Rate: 1}}, authn); err == nil {
+		t.Error("Expected a zero burst to be rejected")
+	}
+	limiter, err := ratelimit.New(ratelimit.Config{
+		Default: slow(3),
+		Writes:  slow(1),
+		Methods: map[string]ratelimit.Limit{"ValidateGameDNA": slow(1)},
+		Keys:    map[string]ratelimit.Limit{"ci": slow(5)},
+	}, authn)
+	if err != nil {
+		t.Fatalf("ratelimit.New failed: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(limiter.StreamInterceptor),
+	)
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+
+	from := func(ip string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", ip)
+	}
+	schema := func(callCtx context.Context) error {
+		_, err := client.GetSchema(callCtx, &pb.GetSchemaRequest{})
+		return err
+	}
+
+	// Each caller gets the default burst, then is turned away with a delay
+	// to retry after.
+	for i := 0; i < 3; i++ {
+		if err := schema(ctx); err != nil {
+			t.Fatalf("Call %d within the burst failed: %v", i, err)
+		}
+	}
+	err = schema(ctx)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted past the burst, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() <= 0 {
+		t.Errorf("Expected a RetryInfo with a delay, got %v", status.Convert(err).Details())
+	}
+	if err := schema(metadata.AppendToOutgoingContext(ctx, auth.APIKeyMetadata, "made-up")); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected an invalid key to count as the caller's IP, got %v", err)
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Expected health checks not to be limited, got %v", err)
+	}
+
+	// Calls relayed from loopback, as the gateway's are, count against the
+	// forwarded address.
+	if err := schema(from("203.0.113.7")); err != nil {
+		t.Errorf("Expected another IP to have its own limit, got %v", err)
+	}
+
+	// A key's limit replaces the default and follows it across addresses.
+	ci := metadata.AppendToOutgoingContext(ctx, auth.APIKeyMetadata, "ci-key")
+	for i := 0; i < 5; i++ {
+		if err := schema(ci); err != nil {
+			t.Fatalf("Call %d within the key's burst failed: %v", i, err)
+		}
+	}
+	if err := schema(metadata.AppendToOutgoingContext(ci, "x-forwarded-for", "203.0.113.9")); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected the key's limit to apply from any address, got %v", err)
+	}
+
+	// Writes and limited methods draw on their own buckets as well.
+	writer := from("203.0.113.8")
+	dna := &pb.GameDNA{Name: "Limited Game", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	if _, err := client.CreateGameDNA(writer, &pb.CreateGameDNARequest{GameDna: dna}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := client.CreateGameDNA(writer, &pb.CreateGameDNARequest{GameDna: dna}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected the write limit to apply, got %v", err)
+	}
+	if err := schema(writer); err != nil {
+		t.Errorf("Expected reads to stay within the default limit, got %v", err)
+	}
+	if _, err := client.ValidateGameDNA(writer, &pb.ValidateGameDNARequest{GameDna: dna}); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if _, err := client.ValidateGameDNA(writer, &pb.ValidateGameDNARequest{GameDna: dna}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected the method limit to apply, got %v", err)
+	}
+
+	// REST callers are limited by the gRPC server and get 429 with Retry-After.
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+	rest := func(handler http.Handler, path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 3; i++ {
+		if rec := rest(gateway.Handler(), "/api/v1/schema", "198.51.100.4:5555"); rec.Code != http.StatusOK {
+			t.Fatalf("REST call %d within the burst failed: %d %s", i, rec.Code, rec.Body)
+		}
+	}
+	rec := rest(gateway.Handler(), "/api/v1/schema", "198.51.100.4:5555")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+
+	// Endpoints outside the gRPC service are wrapped instead, drawing on the
+	// same buckets.
+	if rec := rest(limiter.Handler(http.NotFoundHandler()), "/api/v1/events", "198.51.100.4:5555"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the gateway caller to be limited on wrapped endpoints too, got %d", rec.Code)
+	}
+	events := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		if rec := rest(events, "/api/v1/events", "198.51.100.5:5555"); rec.Code != http.StatusOK {
+			t.Fatalf("Wrapped call %d within the burst failed: %d", i, rec.Code)
+		}
+	}
+	if rec := rest(events, "/api/v1/events", "198.51.100.5:5555"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "100" {
+		t.Errorf("Expected 429 with Retry-After 100, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+// countingStore counts the reads that reach the store.
+type countingStore struct {
+	storage.Store
+	mu    sync.Mutex
+	reads int
+	lists int
+}
+
+func (s *countingStore) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+	return s.Store.Read(ctx, id)
+}
+
+func (s *countingStore) ReadBySlug(ctx context.Context, slug string) (*pb.GameDNA, error) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+	return s.Store.ReadBySlug(ctx, slug)
+}
+
+func (s *countingStore) List(ctx context.Context, filters storage.ListFilters, pagination storage.Pagination) ([]*pb.GameDNA, int32, error) {
+	s.mu.Lock()
+	s.lists++
+	s.mu.Unlock()
+	return s.Store.List(ctx, filters, pagination)
+}
+
+func (s *countingStore) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads, s.lists
+}
+
+// fakeRedis serves GET, SET, DEL, INCR and PING from a map, ignoring TTLs.
+type fakeRedis struct {
+	lis  net.Listener
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	f := &fakeRedis{lis: lis, data: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+				return
+			}
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+		f.mu.Lock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "GET":
+			if v, ok := f.data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case "DEL":
+			for _, key := range args[1:] {
+				delete(f.data, key)
+			}
+			reply = fmt.Sprintf(":%d\r\n", len(args)-1)
+		case "INCR":
+			var v int
+			fmt.Sscan(f.data[args[1]], &v)
+			f.data[args[1]] = fmt.Sprint(v + 1)
+			reply = fmt.Sprintf(":%d\r\n", v+1)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestReadCache(t *testing.T) {
+	ctx := context.Background()
+
+	lru := cache.NewLRU(2)
+	for _, key := range []string{"a", "b", "c"} {
+		lru.Set(ctx, key, []byte(key), time.Minute)
+	}
+	if _, found, _ := lru.Get(ctx, "a"); found || lru.Len() != 2 {
+		t.Errorf("Expected the least recently used entry to be evicted, got %d entries", lru.Len())
+	}
+	lru.Set(ctx, "short", []byte("x"), -time.Second)
+	if _, found, _ := lru.Get(ctx, "short"); found {
+		t.Error("Expected an expired entry to be a miss")
+	}
+
+	fake := newFakeRedis(t)
+	defer fake.lis.Close()
+	if _, err := cache.NewRedis(ctx, "http://"+fake.lis.Addr().String()); err == nil {
+		t.Error("Expected a non-redis URL to be rejected")
+	}
+	redis, err := cache.NewRedis(ctx, "redis://"+fake.lis.Addr().String())
+	if err != nil {
+		t.Fatalf("NewRedis failed: %v", err)
+	}
+	defer redis.Close()
+
+	for name, backend := range map[string]cache.Backend{"memory": cache.NewLRU(100), "redis": redis} {
+		t.Run(name, func(t *testing.T) {
+			counting := &countingStore{Store: storage.NewMemoryStore()}
+			defer counting.Close()
+			store := cache.NewReadStore(counting, backend, cache.ReadOptions{
+				Prefix:  name + ":",
+				ReadTTL: time.Minute,
+				ListTTL: time.Minute,
+			}, zap.NewNop())
+
+			created, err := store.Create(ctx, &pb.GameDNA{Name: "Cached Read", Slug: "cached-read", Genre: "FPS"})
+			if err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			for i := 0; i < 3; i++ {
+				if _, err := store.Read(ctx, created.Id); err != nil {
+					t.Fatalf("Read failed: %v", err)
+				}
+				if dna, err := store.ReadBySlug(ctx, "cached-read"); err != nil || dna.Id != created.Id {
+					t.Fatalf("ReadBySlug = %v, %v", dna, err)
+				}
+				if items, total, err := store.List(ctx, storage.ListFilters{Genre: "FPS"}, storage.Pagination{Page: 1, PageSize: 10}); err != nil || len(items) != 1 || total != 1 {
+					t.Fatalf("List = %d items, total %d, %v", len(items), total, err)
+				}
+			}
+			if reads, lists := counting.counts(); reads != 1 || lists != 1 {
+				t.Errorf("Expected one store read and one store list, got %d and %d", reads, lists)
+			}
+
+			// Writes through the cache are seen at once
+			update := proto.Clone(created).(*pb.GameDNA)
+			update.Name = "Renamed Read"
+			update.Genre = "RPG"
+			if _, err := store.Update(ctx, update); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if dna, err := store.Read(ctx, created.Id); err != nil || dna.Name != "Renamed Read" {
+				t.Errorf("Expected the update to be read, got %v, %v", dna.GetName(), err)
+			}
+			if items, _, err := store.List(ctx, storage.ListFilters{Genre: "FPS"}, storage.Pagination{Page: 1, PageSize: 10}); err != nil || len(items) != 0 {
+				t.Errorf("Expected the cached list page to be retired, got %d items, %v", len(items), err)
+			}
+
+			// Writes elsewhere are seen once evicted
+			update.Name = "Elsewhere"
+			if _, err := counting.Store.Update(ctx, update); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if dna, _ := store.Read(ctx, created.Id); dna.GetName() != "Renamed Read" {
+				t.Errorf("Expected the cached read before eviction, got %q", dna.GetName())
+			}
+			store.Evict(created.Id)
+			if dna, _ := store.Read(ctx, created.Id); dna.GetName() != "Elsewhere" {
+				t.Errorf("Expected the new name after eviction, got %q", dna.GetName())
+			}
+
+			if err := store.Delete(ctx, created.Id); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := store.Read(ctx, created.Id); err == nil {
+				t.Error("Expected a deleted config not to be read from the cache")
+			}
+			if _, err := store.ReadBySlug(ctx, "cached-read"); err == nil {
+				t.Error("Expected a deleted config's slug not to be read from the cache")
+			}
+		})
+	}
+
+	// A Redis outage falls through to the store
+	counting := &countingStore{Store: storage.NewMemoryStore()}
+	defer counting.Close()
+	store := cache.NewReadStore(counting, redis, cache.ReadOptions{ReadTTL: time.Minute, ListTTL: time.Minute}, zap.NewNop())
+	created, err := store.Create(ctx, &pb.GameDNA{Name: "Outage Read"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	fake.lis.Close()
+	redis.Close()
+	if _, err := store.Read(ctx, created.Id); err != nil {
+		t.Errorf("Expected reads to survive a Redis outage, got %v", err)
+	}
+	if _, _, err := store.List(ctx, storage.ListFilters{}, storage.Pagination{Page: 1, PageSize: 10}); err != nil {
+		t.Errorf("Expected lists to survive a Redis outage, got %v", err)
+	}
+	if v := expvar.Get("read_cache").(*expvar.Map).Get("errors"); v == nil {
+		t.Error("Expected the Redis outage to be counted")
+	}
+}
+
+// TestListPageTokens checks paging by token doesn't drift when configs are
+// created mid-iteration, and rejects tokens it can't honour.
+func TestListPageTokens(t *testing.T) {
+	ctx := context.Background()
+	rust, _ := ffi.NewRustFFI("", false)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		dna, err := store.Create(ctx, &pb.GameDNA{
+			Name:       fmt.Sprintf("Paged %d", i),
+			Genre:      "FPS",
+			CreateTime: timestamppb.New(base.Add(-time.Duration(i) * time.Minute)),
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ids = append(ids, dna.Id)
+	}
+
+	first, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 2, Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("ListGameDNA failed: %v", err)
+	}
+	if first.NextPageToken == "" || first.Pagination.Total != 5 || first.Pagination.TotalPages != 3 {
+		t.Fatalf("Expected a token and the legacy counts on the first page, got %q and %v", first.NextPageToken, first.Pagination)
+	}
+	// By page number, a config created now would shift "Paged 1" onto page 2
+	if _, err := store.Create(ctx, &pb.GameDNA{Name: "Paged late", Genre: "FPS"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 2, Genre: "FPS", PageToken: first.NextPageToken})
+	if err != nil {
+		t.Fatalf("ListGameDNA with token failed: %v", err)
+	}
+	if len(second.Items) != 2 || second.Items[0].Id != ids[2] || second.Items[1].Id != ids[3] {
+		t.Errorf("Expected the third and fourth configs after the token, got %v", second.Items)
+	}
+	if second.Pagination.PageSize != 2 || second.Pagination.Total != 0 {
+		t.Errorf("Expected only the page size on a token page, got %v", second.Pagination)
+	}
+	last, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 2, Genre: "FPS", PageToken: second.NextPageToken})
+	if err != nil {
+		t.Fatalf("ListGameDNA with token failed: %v", err)
+	}
+	if len(last.Items) != 1 || last.Items[0].Id != ids[4] || last.NextPageToken != "" {
+		t.Errorf("Expected the fifth config and no token on the last page, got %v and %q", last.Items, last.NextPageToken)
+	}
+	if page3, _ := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{Page: 3, PageSize: 2, Genre: "FPS"}); page3.GetNextPageToken() != "" {
+		t.Errorf("Expected no token on a page by number past the first")
+	}
+
+	for name, req := range map[string]*pb.ListGameDNARequest{
+		"garbage":       {PageToken: "not-a-token", Genre: "FPS"},
+		"other filters": {PageToken: first.NextPageToken, Genre: "RPG"},
+		"with page":     {PageToken: first.NextPageToken, Genre: "FPS", Page: 2},
+	} {
+		if _, err := svc.ListGameDNA(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+}
+
+func TestReleaseTags(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	store.SetMaxHistoryDepth(2)
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	dna, err := store.Create(ctx, &pb.GameDNA{Name: "Tagged", Genre: "FPS", TargetFps: 30})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	tag := func(version int64, name string, move bool) (*pb.TagVersionResponse, error) {
+		return svc.TagVersion(ctx, &pb.TagVersionRequest{ConfigId: dna.Slug, VersionNum: version, Tag: name, Move: move, Actor: "ops"})
+	}
+	if _, err := tag(1, "1.0.0-live", false); err != nil {
+		t.Fatalf("TagVersion failed: %v", err)
+	}
+	for _, fps := range []uint32{40, 50, 60} {
+		dna.TargetFps = fps
+		if dna, err = store.Update(ctx, dna); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	// The tagged version outlives the history depth
+	got, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, ReleaseTag: "1.0.0-live"})
+	if err != nil {
+		t.Fatalf("GetGameDNA by tag failed: %v", err)
+	}
+	if got.VersionNum != 1 || got.GameDna.TargetFps != 30 {
+		t.Errorf("Expected version 1 with fps 30, got %d %d", got.VersionNum, got.GameDna.TargetFps)
+	}
+
+	if _, err := tag(4, "1.0.0-live", false); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected a conflict retagging without move, got %v", err)
+	}
+	moved, err := tag(4, "1.0.0-live", true)
+	if err != nil {
+		t.Fatalf("TagVersion with move failed: %v", err)
+	}
+	if moved.PreviousVersionNum != 1 || moved.ReleaseTag.VersionNum != 4 || moved.ReleaseTag.CreatedBy != "ops" {
+		t.Errorf("Expected the tag moved from version 1 to 4 by ops, got %v", moved)
+	}
+	again, err := tag(4, "1.0.0-live", false)
+	if err != nil || again.PreviousVersionNum != 0 {
+		t.Errorf("Expected retagging the same version to succeed unchanged, got %v %v", again, err)
+	}
+	if _, err := tag(4, "playtest-july", false); err != nil {
+		t.Fatalf("TagVersion failed: %v", err)
+	}
+
+	if _, err := tag(2, "old", false); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected tagging a pruned version to fail, got %v", err)
+	}
+	if _, err := tag(4, "bad/tag", false); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an invalid tag to be rejected, got %v", err)
+	}
+	if _, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, ReleaseTag: "missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected an unknown tag to be not found, got %v", err)
+	}
+
+	history, err := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: dna.Id})
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	for _, v := range history.Versions {
+		want := []string(nil)
+		if v.VersionNum == 4 {
+			want = []string{"1.0.0-live", "playtest-july"}
+		}
+		if !slices.Equal(v.ReleaseTags, want) {
+			t.Errorf("Expected version %d tagged %v, got %v", v.VersionNum, want, v.ReleaseTags)
+		}
+	}
+
+	found, err := store.FindPrincipalData(ctx, "ops")
+	if err != nil {
+		t.Fatalf("FindPrincipalData failed: %v", err)
+	}
+	if len(found.ReleaseTags) != 2 {
+		t.Errorf("Expected both tags in the principal's data, got %d", len(found.ReleaseTags))
+	}
+}
+
+func TestDnactl(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	grpcServer := grpc.NewServer()
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ctl := func(stdin string, args ...string) (string, string, int) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args = append(args, "-addr", lis.Addr().String())
+		code := dnactl.Run(ctx, args, dnactl.IO{Stdin: strings.NewReader(stdin), Stdout: &stdout, Stderr: &stderr})
+		return stdout.String(), stderr.String(), code
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "arena.yaml")
+	os.WriteFile(file, []byte("name: CLI Arena\ngenre: FPS\ntarget_fps: 60\ntimeScale: 1\ntargetPlatforms: [PC]\ntags: [cli]\n"), 0o644)
+	out, errOut, code := ctl("", "create", "-f", file, "-o", "json")
+	if code != 0 {
+		t.Fatalf("create exited %d: %s", code, errOut)
+	}
+	var created pb.GameDNAResponse
+	if err := protojson.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("create printed invalid JSON: %v\n%s", err, out)
+	}
+	id := created.GameDna.Id
+	if created.GameDna.TargetFps != 60 || created.GameDna.Name != "CLI Arena" {
+		t.Errorf("Expected the YAML fields to be created, got %v", created.GameDna)
+	}
+
+	// Flags may follow positional arguments; JSON input works too
+	if _, errOut, code := ctl(`{"targetFps": 90}`, "update", id, "-f", "-", "-field", "target_fps"); code != 0 {
+		t.Fatalf("update exited %d: %s", code, errOut)
+	}
+	out, _, code = ctl("", "get", "cli-arena")
+	if code != 0 || !regexp.MustCompile(`(?m)^target_fps\s+90$`).MatchString(out) || !strings.Contains(out, "PC") {
+		t.Errorf("Expected get to show the updated fields, got %d:\n%s", code, out)
+	}
+	out, _, code = ctl("", "list", "-tag", "cli")
+	if code != 0 || !strings.HasPrefix(out, "ID") || !strings.Contains(out, id) {
+		t.Errorf("Expected a table listing the config, got %d:\n%s", code, out)
+	}
+	if _, errOut, code := ctl("", "rollback", id, "1"); code != 0 {
+		t.Fatalf("rollback exited %d: %s", code, errOut)
+	}
+	out, errOut, code = ctl("", "clone", id, "-name", "CLI Copy")
+	if code != 0 || !strings.Contains(out, "CLI Copy") {
+		t.Errorf("Expected clone to print the copy, got %d %s:\n%s", code, errOut, out)
+	}
+	if _, errOut, code := ctl("", "publish", id); code != 0 {
+		t.Fatalf("publish exited %d: %s", code, errOut)
+	}
+	dna, _ := store.Read(ctx, id)
+	if !dna.IsLocked || dna.TargetFps != 60 {
+		t.Errorf("Expected the rolled back config published, got locked %t fps %d", dna.IsLocked, dna.TargetFps)
+	}
+
+	archive := filepath.Join(dir, "export.ndjson")
+	if _, errOut, code := ctl("", "export", "-out", archive, id); code != 0 {
+		t.Fatalf("export exited %d: %s", code, errOut)
+	}
+	out, errOut, code = ctl("", "import", "-f", archive, "-dry-run", "-overwrite")
+	if code != 0 || !regexp.MustCompile(id+`\s+CLI Arena\s+\w+`).MatchString(out) {
+		t.Errorf("Expected import to report the exported config, got %d %s:\n%s", code, errOut, out)
+	}
+
+	if _, _, code := ctl("", "rollback", id); code != 2 {
+		t.Errorf("Expected a usage error for a missing version, got exit %d", code)
+	}
+	if _, errOut, code := ctl("", "get", "missing-config"); code != 1 || !strings.Contains(errOut, "not found") {
+		t.Errorf("Expected get of a missing config to fail, got %d %s", code, errOut)
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	ctx := context.Background()
+	if _, err := storage.MigrateDown(ctx, nil, 1); err == nil {
+		t.Error("Expected MigrateDown without a database to fail")
+	}
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	defer store.Close()
+	db := store.DB()
+	if err := storage.Migrate(ctx, db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	applied := func() []string {
+		t.Helper()
+		rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations ORDER BY name`)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			rows.Scan(&name)
+			names = append(names, name)
+		}
+		return names
+	}
+	before := applied()
+
+	reverted, err := storage.MigrateDown(ctx, db, 2)
+	if err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	want := []string{before[len(before)-1], before[len(before)-2]}
+	if !slices.Equal(reverted, want) {
+		t.Errorf("Expected the newest two migrations reverted newest first, got %v want %v", reverted, want)
+	}
+	if after := applied(); !slices.Equal(after, before[:len(before)-2]) {
+		t.Errorf("Expected only the older migrations recorded, got %v", after)
+	}
+	if _, err := storage.MigrateDown(ctx, db, len(before)-2); err == nil {
+		t.Error("Expected reverting past a migration without Down SQL to fail")
+	}
+
+	// Up again restores the schema the rest of the tests rely on
+	if err := storage.Migrate(ctx, db); err != nil {
+		t.Fatalf("Migrate after MigrateDown failed: %v", err)
+	}
+	if after := applied(); !slices.Equal(after, before) {
+		t.Errorf("Expected every migration reapplied, got %v", after)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	newDNA := func() *pb.GameDNA {
+		return &pb.GameDNA{Name: "Preview", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+
+	preview, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: newDNA(), DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry-run create failed: %v", err)
+	}
+	if !preview.DryRun || preview.GameDna.Id != "" || preview.GameDna.Checksum == "" || preview.GameDna.Slug != "preview" || !preview.Validation.GetIsValid() {
+		t.Errorf("Expected an unsaved, checksummed preview with its validation, got %v", preview)
+	}
+	if _, total, _ := store.List(ctx, storage.ListFilters{}, storage.Pagination{Page: 1, PageSize: 10}); total != 0 {
+		t.Fatalf("Expected a dry-run create to store nothing, found %d configs", total)
+	}
+	invalid := newDNA()
+	invalid.TargetFps = 5000
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: invalid, DryRun: true}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a dry run to validate, got %v", err)
+	}
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: newDNA()})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.GameDna.Checksum != preview.GameDna.Checksum {
+		t.Errorf("Expected the dry run to predict the checksum %s, got %s", created.GameDna.Checksum, preview.GameDna.Checksum)
+	}
+	changed := models.Clone(created.GameDna)
+	changed.TargetFps = 60
+	updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: changed.Id, GameDna: changed, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry-run update failed: %v", err)
+	}
+	if !updated.DryRun || updated.NotModified || updated.GameDna.TargetFps != 60 {
+		t.Errorf("Expected a preview of the update, got %v", updated)
+	}
+	if !proto.Equal(updated.GameDna.CreateTime, created.GameDna.CreateTime) {
+		t.Errorf("Expected the preview to keep the creation time")
+	}
+	versions, _ := store.GetVersionHistory(ctx, created.GameDna.Id)
+	stored, _ := store.Read(ctx, created.GameDna.Id)
+	if len(versions) != 1 || stored.TargetFps != 30 {
+		t.Errorf("Expected a dry-run update to write nothing, got %d versions and fps %d", len(versions), stored.TargetFps)
+	}
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: changed.Id, GameDna: models.Clone(changed)}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	same, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: changed.Id, GameDna: models.Clone(changed), DryRun: true})
+	if err != nil || !same.NotModified {
+		t.Errorf("Expected a dry run of the saved content to report not modified, got %v %v", same, err)
+	}
+
+	saved, _ := store.Read(ctx, created.GameDna.Id)
+	if _, err := store.PublishVersion(ctx, created.GameDna.Id, "tester", saved.Checksum, "", nil); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: changed.Id, GameDna: changed, DryRun: true}); !errors.Is(err, storage.ErrLocked) {
+		t.Errorf("Expected a dry-run update of a published config to fail, got %v", err)
+	}
+}
+
+func TestConfigInheritance(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	create := func(dna *pb.GameDNA) *pb.GameDNA {
+		t.Helper()
+		resp, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+		if err != nil {
+			t.Fatalf("Create %s failed: %v", dna.Name, err)
+		}
+		return resp.GameDna
+	}
+
+	base := create(&pb.GameDNA{
+		Name: "Arena", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+		CustomProperties: map[string]string{"studio": "north", "region": "eu"},
+	})
+	variant := create(&pb.GameDNA{
+		Name: "Arena Switch", ParentId: base.Id, TargetFps: 30, TargetPlatforms: []string{"Switch"},
+		CustomProperties: map[string]string{"region": "jp"},
+	})
+	handheld := create(&pb.GameDNA{Name: "Arena Handheld", ParentId: variant.Id, MaxPlayers: 2})
+
+	got, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: handheld.Slug})
+	if err != nil {
+		t.Fatalf("GetGameDNA failed: %v", err)
+	}
+	dna := got.GameDna
+	if dna.Id != handheld.Id || dna.Name != "Arena Handheld" || dna.ParentId != variant.Id {
+		t.Errorf("Expected the config's own identity, got %s %q parent %s", dna.Id, dna.Name, dna.ParentId)
+	}
+	if dna.Genre != "FPS" || dna.TargetFps != 30 || dna.MaxPlayers != 2 || !slices.Equal(dna.TargetPlatforms, []string{"Switch"}) {
+		t.Errorf("Expected values merged nearest parent first, got %v", dna)
+	}
+	if dna.CustomProperties["studio"] != "north" || dna.CustomProperties["region"] != "jp" {
+		t.Errorf("Expected custom properties merged by key, got %v", dna.CustomProperties)
+	}
+
+	eff, err := svc.GetEffectiveGameDNA(ctx, &pb.GetEffectiveGameDNARequest{Id: handheld.Id})
+	if err != nil {
+		t.Fatalf("GetEffectiveGameDNA failed: %v", err)
+	}
+	if eff.Overrides.Genre != "" || eff.Effective.Genre != "FPS" {
+		t.Errorf("Expected raw overrides next to the merged config, got %q and %q", eff.Overrides.Genre, eff.Effective.Genre)
+	}
+	if !slices.Equal(eff.ParentChain, []string{variant.Id, base.Id}) {
+		t.Errorf("Expected the chain nearest parent first, got %v", eff.ParentChain)
+	}
+	if !slices.Equal(eff.OverriddenFields, []string{"max_players"}) {
+		t.Errorf("Expected only max_players overridden, got %v", eff.OverriddenFields)
+	}
+	for field, want := range map[string]string{
+		"genre":                    base.Id,
+		"target_fps":               variant.Id,
+		"custom_properties.region": variant.Id,
+		"custom_properties.studio": base.Id,
+	} {
+		if eff.InheritedFrom[field] != want {
+			t.Errorf("Expected %s inherited from %s, got %q", field, want, eff.InheritedFrom[field])
+		}
+	}
+
+	// Changes to a parent show through
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+		Id: base.Id, GameDna: &pb.GameDNA{MaxEntities: 500}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"max_entities"}},
+	}); err != nil {
+		t.Fatalf("UpdateGameDNA failed: %v", err)
+	}
+	if got, _ := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: handheld.Id}); got.GetGameDna().GetMaxEntities() != 500 {
+		t.Errorf("Expected the parent's new max_entities, got %v", got)
+	}
+
+	reparent := func(id, parent string) error {
+		_, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+			Id: id, GameDna: &pb.GameDNA{ParentId: parent}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"parent_id"}},
+		})
+		return err
+	}
+	if err := reparent(base.Id, handheld.Id); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected a cycle to be refused, got %v", err)
+	}
+	if err := reparent(base.Id, base.Id); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected inheriting from itself to be refused, got %v", err)
+	}
+	if err := reparent(base.Id, "00000000-0000-4000-8000-000000000000"); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected a missing parent to be refused, got %v", err)
+	}
+	if err := reparent(base.Id, "arena"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a parent_id that is not an ID to be refused, got %v", err)
+	}
+
+	// Children are validated with what they inherit
+	invalid, err := store.Create(ctx, &pb.GameDNA{Name: "Invalid", Genre: "FPS", TargetFps: 5000})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: "Child", ParentId: invalid.Id}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected the inherited target_fps to fail validation, got %v", err)
+	}
+
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: variant.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected deleting a parent to be refused, got %v", err)
+	}
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: handheld.Id}); err != nil {
+		t.Fatalf("DeleteGameDNA failed: %v", err)
+	}
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: variant.Id}); err != nil {
+		t.Errorf("Expected a parent without children to be deletable, got %v", err)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	var calls []received
+	failures := 1 // answers before the bake endpoint starts accepting
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, received{r.Header.Clone(), body})
+		switch {
+		case r.URL.Path == "/down":
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		case failures > 0:
+			failures--
+			http.Error(w, "busy", http.StatusInternalServerError)
+		}
+	}))
+	defer receiver.Close()
+
+	disabled := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := disabled.ListWebhooks(ctx, &pb.ListWebhooksRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition with webhooks disabled, got %v", err)
+	}
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		Webhooks: webhooks.NewSender(webhooks.Options{Timeout: 5 * time.Second, MaxAttempts: 2, Retention: time.Hour}),
+	}, zap.NewNop())
+
+	for _, req := range []*pb.CreateWebhookRequest{
+		{Url: "ftp://ci.example.com/bake", Actor: "ci"},
+		{Url: receiver.URL, EventTypes: []string{"baked"}, Actor: "ci"},
+	} {
+		if _, err := svc.CreateWebhook(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+	bake, err := svc.CreateWebhook(ctx, &pb.CreateWebhookRequest{Url: receiver.URL + "/bake", EventTypes: []string{events.TypePublished}, Actor: "ci"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if !strings.HasPrefix(bake.Secret, webhooks.SecretPrefix) || bake.Webhook.CreatedBy != "ci" {
+		t.Errorf("Expected a signing secret and the creator, got %q and %q", bake.Secret, bake.Webhook.CreatedBy)
+	}
+	down, err := svc.CreateWebhook(ctx, &pb.CreateWebhookRequest{Url: receiver.URL + "/down", Actor: "ci"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	list, err := svc.ListWebhooks(ctx, &pb.ListWebhooksRequest{})
+	if err != nil || len(list.Webhooks) != 2 {
+		t.Fatalf("Expected both webhooks, got %v, %v", list, err)
+	}
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Bake Arena", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	id := created.GameDna.Id
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("PublishGameDNA failed: %v", err)
+	}
+	deliveries := func(webhookID, state string) []*pb.WebhookDelivery {
+		t.Helper()
+		resp, err := svc.ListWebhookDeliveries(ctx, &pb.ListWebhookDeliveriesRequest{WebhookId: webhookID, State: state})
+		if err != nil {
+			t.Fatalf("ListWebhookDeliveries failed: %v", err)
+		}
+		return resp.Deliveries
+	}
+	if got := deliveries(bake.Webhook.Id, ""); len(got) != 1 || got[0].EventType != events.TypePublished || got[0].State != storage.WebhookDeliveryPending {
+		t.Fatalf("Expected only the publish queued for the bake webhook, got %v", got)
+	}
+	if got := deliveries(down.Webhook.Id, ""); len(got) != 2 {
+		t.Fatalf("Expected the create and publish queued for the catch-all webhook, got %v", got)
+	}
+	// Pushes every pending delivery's next attempt into the past
+	due := func() {
+		t.Helper()
+		for _, hook := range []string{bake.Webhook.Id, down.Webhook.Id} {
+			pending, _ := store.ListWebhookDeliveries(ctx, hook, storage.WebhookDeliveryPending, 0)
+			for _, d := range pending {
+				d.NextAttemptAt = time.Now().Add(-time.Second)
+				if _, err := store.SaveWebhookDelivery(ctx, d); err != nil {
+					t.Fatalf("SaveWebhookDelivery failed: %v", err)
+				}
+			}
+		}
+	}
+
+	if n, err := svc.DeliverWebhooks(ctx); err != nil || n != 0 {
+		t.Fatalf("Expected no delivery to succeed on the first pass, got %d, %v", n, err)
+	}
+	retry := deliveries(bake.Webhook.Id, "")[0]
+	if retry.State != storage.WebhookDeliveryPending || retry.Attempts != 1 || retry.LastStatus != http.StatusInternalServerError ||
+		!strings.Contains(retry.LastError, "busy") || !retry.NextAttemptTime.AsTime().After(time.Now()) {
+		t.Errorf("Expected a retry scheduled after the 500, got %v", retry)
+	}
+	if n, _ := svc.DeliverWebhooks(ctx); n != 0 {
+		t.Errorf("Expected nothing sent before the backoff ends, got %d", n)
+	}
+
+	due()
+	if n, err := svc.DeliverWebhooks(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected the publish delivered on retry, got %d, %v", n, err)
+	}
+	done := deliveries(bake.Webhook.Id, storage.WebhookDeliverySucceeded)
+	if len(done) != 1 || done[0].Attempts != 2 || done[0].LastStatus != http.StatusOK || done[0].DeliverTime == nil || done[0].NextAttemptTime != nil {
+		t.Errorf("Expected the delivery succeeded on its second attempt, got %v", done)
+	}
+	if failed := deliveries(down.Webhook.Id, storage.WebhookDeliveryFailed); len(failed) != 2 || failed[0].Attempts != 2 || failed[0].LastStatus != http.StatusServiceUnavailable {
+		t.Errorf("Expected both deliveries failed after their last attempt, got %v", failed)
+	}
+
+	mu.Lock()
+	var last received
+	for _, call := range calls {
+		if call.header.Get(webhooks.DeliveryHeader) == done[0].Id {
+			last = call
+		}
+	}
+	mu.Unlock()
+	if last.body == nil {
+		t.Fatalf("Expected the bake endpoint to receive the delivery")
+	}
+	sig := last.header.Get(webhooks.SignatureHeader)
+	if err := webhooks.Verify(bake.Secret, sig, last.body, time.Now(), time.Minute); err != nil {
+		t.Errorf("Expected a valid signature, got %v (%q)", err, sig)
+	}
+	if err := webhooks.Verify(down.Secret, sig, last.body, time.Now(), time.Minute); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected another webhook's secret to fail the signature, got %v", err)
+	}
+	if err := webhooks.Verify(bake.Secret, sig, append(last.body, ' '), time.Now(), time.Minute); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected a changed body to fail the signature, got %v", err)
+	}
+	if err := webhooks.Verify(bake.Secret, sig, last.body, time.Now().Add(time.Hour), time.Minute); err == nil {
+		t.Error("Expected an old signature to fail outside the tolerance")
+	}
+	var payload struct {
+		Event    string          `json:"event"`
+		ConfigID string          `json:"configId"`
+		GameDNA  json.RawMessage `json:"gameDna"`
+	}
+	if err := json.Unmarshal(last.body, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	var sent pb.GameDNA
+	if err := protojson.Unmarshal(payload.GameDNA, &sent); err != nil {
+		t.Fatalf("Failed to decode payload config: %v", err)
+	}
+	if payload.Event != events.TypePublished || payload.ConfigID != id || !sent.IsLocked || last.header.Get(webhooks.EventHeader) != events.TypePublished {
+		t.Errorf("Expected the published config in the payload, got %s %s locked=%v", payload.Event, payload.ConfigID, sent.IsLocked)
+	}
+
+	if got := []time.Duration{webhooks.Backoff(1), webhooks.Backoff(2), webhooks.Backoff(20)}; !slices.Equal(got, []time.Duration{30 * time.Second, time.Minute, time.Hour}) {
+		t.Errorf("Expected backoff doubling from 30s up to an hour, got %v", got)
+	}
+
+	report, err := svc.ExportPrincipalData(ctx, &pb.ExportPrincipalDataRequest{Principal: "ci"})
+	if err != nil || len(report.Webhooks) != 2 {
+		t.Errorf("Expected the webhooks in the creator's data, got %v, %v", report, err)
+	}
+
+	if _, err := svc.DeleteWebhook(ctx, &pb.DeleteWebhookRequest{Id: down.Webhook.Id}); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if _, err := svc.ListWebhookDeliveries(ctx, &pb.ListWebhookDeliveriesRequest{WebhookId: down.Webhook.Id}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted webhook's deliveries, got %v", err)
+	}
+	if left, _ := store.ListWebhookDeliveries(ctx, down.Webhook.Id, "", 0); len(left) != 0 {
+		t.Errorf("Expected the deliveries deleted with their webhook, got %d", len(left))
+	}
+}
+
+func TestEnvironmentPromotion(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	publish := func(id string) {
+		t.Helper()
+		if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); err != nil {
+			t.Fatalf("PublishGameDNA failed: %v", err)
+		}
+	}
+
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: "Arena QA", Genre: "FPS", TargetFps: 60, Environment: "qa"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unknown environment, got %v", err)
+	}
+
+	base, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: "Arena Base", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}})
+	if err != nil {
+		t.Fatalf("Create base failed: %v", err)
+	}
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: "Arena", Slug: "arena-dev", ParentId: base.GameDna.Id, TargetFps: 60, Environment: "dev"}})
+	if err != nil {
+		t.Fatalf("Create arena failed: %v", err)
+	}
+	dev := created.GameDna
+
+	promote := func(req *pb.PromoteGameDNARequest) (*pb.PromoteGameDNAResponse, error) {
+		if req.Actor == "" {
+			req.Actor = "dev-lead"
+		}
+		if req.ApprovedBy == "" {
+			req.ApprovedBy = "qa-lead"
+		}
+		return svc.PromoteGameDNA(ctx, req)
+	}
+	if _, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition promoting an unpublished config, got %v", err)
+	}
+	publish(dev.Id)
+	if _, err := svc.PromoteGameDNA(ctx, &pb.PromoteGameDNARequest{Id: dev.Id, Actor: "dev-lead"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without an approver, got %v", err)
+	}
+	if _, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id, ApprovedBy: "dev-lead"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a self-approved promotion, got %v", err)
+	}
+	if _, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id, TargetEnvironment: "prod"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition skipping staging, got %v", err)
+	}
+	if _, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id, VersionNum: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition promoting an unpublished version, got %v", err)
+	}
+
+	first, err := promote(&pb.PromoteGameDNARequest{Id: dev.Slug, Reason: "playtest build"})
+	if err != nil {
+		t.Fatalf("PromoteGameDNA failed: %v", err)
+	}
+	staging := first.GameDna
+	if !first.Created || first.SourceEnvironment != "dev" || first.TargetEnvironment != "staging" || first.SourceVersionNum != 2 {
+		t.Errorf("Expected a new staging config from dev version 2, got %v", first)
+	}
+	if staging.Id == dev.Id || staging.Slug != "arena-staging" || staging.Environment != "staging" || staging.PromotedFrom != dev.Id || !staging.IsLocked {
+		t.Errorf("Expected a published arena-staging promoted from %s, got %v", dev.Id, staging)
+	}
+	if staging.ParentId != "" || staging.Genre != "FPS" || staging.TargetFps != 60 {
+		t.Errorf("Expected the inherited values copied into a standalone config, got %v", staging)
+	}
+	entry := first.AuditEntry
+	if entry.Action != storage.AuditActionPromote || entry.Actor != "dev-lead" || entry.ApprovedBy != "qa-lead" || entry.Reason != "playtest build" {
+		t.Errorf("Expected the approval in the audit entry, got %v", entry)
+	}
+
+	// Later promotions update the same target
+	if _, err := svc.UnpublishGameDNA(ctx, &pb.UnpublishGameDNARequest{Id: dev.Id, Reason: "tune", Actor: "dev-lead"}); err != nil {
+		t.Fatalf("UnpublishGameDNA failed: %v", err)
+	}
+	current, _ := store.Read(ctx, dev.Id)
+	edited := proto.Clone(current).(*pb.GameDNA)
+	edited.TargetFps = 120
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: dev.Id, GameDna: edited}); err != nil {
+		t.Fatalf("UpdateGameDNA failed: %v", err)
+	}
+	publish(dev.Id)
+	second, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id, TargetEnvironment: "staging"})
+	if err != nil {
+		t.Fatalf("Second PromoteGameDNA failed: %v", err)
+	}
+	if second.Created || second.GameDna.Id != staging.Id || second.GameDna.TargetFps != 120 || second.GameDna.Slug != "arena-staging" {
+		t.Errorf("Expected the staging config updated in place, got %v", second)
+	}
+	if second.AuditEntry.Reason != "promoted from dev config arena-dev version 5" {
+		t.Errorf("Expected a default reason naming the source, got %q", second.AuditEntry.Reason)
+	}
+	rolledBack, err := promote(&pb.PromoteGameDNARequest{Id: dev.Id, VersionNum: 2})
+	if err != nil || rolledBack.GameDna.TargetFps != 60 || rolledBack.SourceVersionNum != 2 {
+		t.Errorf("Expected an earlier published version promoted, got %v, %v", rolledBack, err)
+	}
+
+	prod, err := promote(&pb.PromoteGameDNARequest{Id: staging.Id})
+	if err != nil {
+		t.Fatalf("Promote to prod failed: %v", err)
+	}
+	if prod.GameDna.Slug != "arena-prod" || prod.GameDna.PromotedFrom != staging.Id || prod.TargetEnvironment != "prod" {
+		t.Errorf("Expected arena-prod promoted from staging, got %v", prod.GameDna)
+	}
+	if _, err := promote(&pb.PromoteGameDNARequest{Id: prod.GameDna.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition promoting past prod, got %v", err)
+	}
+
+	for env, want := range map[string][]string{
+		"dev":     {dev.Id, base.GameDna.Id},
+		"staging": {staging.Id},
+		"prod":    {prod.GameDna.Id},
+	} {
+		list, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{Environment: env, PageSize: 10})
+		if err != nil {
+			t.Fatalf("ListGameDNA %s failed: %v", env, err)
+		}
+		var ids []string
+		for _, dna := range list.Items {
+			ids = append(ids, dna.Id)
+		}
+		slices.Sort(ids)
+		slices.Sort(want)
+		if !slices.Equal(ids, want) {
+			t.Errorf("Expected %s to list %v, got %v", env, want, ids)
+		}
+	}
+	if _, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{Environment: "qa"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument listing an unknown environment, got %v", err)
+	}
+
+	log, err := svc.GetAuditLog(ctx, &pb.GetAuditLogRequest{ConfigId: staging.Slug})
+	if err != nil || len(log.Entries) != 3 || log.Entries[0].ApprovedBy != "qa-lead" {
+		t.Errorf("Expected the staging config's three promotions in its audit log, got %v, %v", log, err)
+	}
+	report, err := svc.ExportPrincipalData(ctx, &pb.ExportPrincipalDataRequest{Principal: "qa-lead"})
+	if err != nil || len(report.AuditEntries) != 4 {
+		t.Errorf("Expected the approver's audit entries in their data, got %v, %v", report, err)
+	}
+}
+
+func TestRequestFieldRules(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	client := serveGameDNA(t, store)
+
+	violations := func(err error) map[string]string {
+		t.Helper()
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("Expected InvalidArgument, got %v", err)
+		}
+		fields := map[string]string{}
+		for _, detail := range status.Convert(err).Details() {
+			if br, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range br.FieldViolations {
+					fields[v.Field] = v.Description
+				}
+			}
+		}
+		return fields
+	}
+
+	_, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{})
+	if got := violations(err); got["id"] != "is required" || len(got) != 1 {
+		t.Errorf("Expected a violation for the empty id, got %v", got)
+	}
+	_, err = client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{})
+	if got := violations(err); got["game_dna"] != "is required" {
+		t.Errorf("Expected a violation for the missing game_dna, got %v", got)
+	}
+
+	_, err = client.ListGameDNA(ctx, &pb.ListGameDNARequest{Page: -1, PageSize: -5})
+	got := violations(err)
+	if got["page"] != "must not be negative" || got["page_size"] != "must not be negative" {
+		t.Errorf("Expected violations for the negative page and page_size, got %v", got)
+	}
+	if msg := status.Convert(err).Message(); !strings.Contains(msg, "(and 1 more)") {
+		t.Errorf("Expected the message to count the other violation, got %q", msg)
+	}
+	_, err = client.SearchGameDNA(ctx, &pb.SearchGameDNARequest{PageSize: 1000000})
+	if got := violations(err); got["page_size"] != "must be at most 1000, got 1000000" {
+		t.Errorf("Expected a violation for the absurd page_size, got %v", got)
+	}
+	if _, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 10}); err != nil {
+		t.Errorf("Expected a valid list request to pass, got %v", err)
+	}
+
+	stream, err := client.WatchGameDNA(ctx, &pb.WatchGameDNARequest{AfterSequence: -1})
+	if err != nil {
+		t.Fatalf("WatchGameDNA failed: %v", err)
+	}
+	_, err = stream.Recv()
+	if got := violations(err); got["after_sequence"] != "must not be negative" {
+		t.Errorf("Expected a violation for the negative after_sequence, got %v", got)
+	}
+}
+
+func TestGoClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	broker := events.NewBroker(16)
+
+	// The first call to each method is turned away, as by a restarting
+	// server, so every helper goes through a retry or a reconnect.
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	flaky := func(method string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[method]++
+		if attempts[method] == 1 {
+			return status.Error(codes.Unavailable, "restarting")
+		}
+		return nil
+	}
+	var apiKeys []string
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			mu.Lock()
+			apiKeys = append(apiKeys, md.Get(auth.APIKeyMetadata)...)
+			mu.Unlock()
+			if err := flaky(info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}, api.UnaryErrorInterceptor, api.UnaryValidationInterceptor),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := flaky(info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}, api.StreamErrorInterceptor, api.StreamValidationInterceptor),
+	)
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker, Watch: broker}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	c, err := sdk.Dial(ctx, lis.Addr().String(), sdk.Options{APIKey: "sdk-key", InitialBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+
+	dna := func(name string) *pb.GameDNA {
+		return &pb.GameDNA{Name: name, Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	created, err := c.Create(ctx, dna("SDK Arena"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got := attempts[pb.GameDNAService_CreateGameDNA_FullMethodName]; got != 2 {
+		t.Errorf("Expected Create to be retried once, got %d attempts", got)
+	}
+	if len(apiKeys) == 0 || apiKeys[0] != "sdk-key" {
+		t.Errorf("Expected the API key on every call, got %v", apiKeys)
+	}
+	if got, err := c.Get(ctx, created.Slug); err != nil || got.Id != created.Id {
+		t.Fatalf("Get by slug = %v, %v", got, err)
+	}
+
+	// Errors match the storage errors they come from.
+	_, err = c.Get(ctx, "no-such-config")
+	if !errors.Is(err, sdk.ErrNotFound) || status.Code(err) != codes.NotFound {
+		t.Errorf("Expected ErrNotFound with its status kept, got %v", err)
+	}
+	if _, err := c.Publish(ctx, created.Id); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	locked := models.Clone(created)
+	locked.TargetFps = 60
+	if _, err := c.Update(ctx, locked); !errors.Is(err, sdk.ErrLocked) || errors.Is(err, sdk.ErrNotLocked) {
+		t.Errorf("Expected ErrLocked updating a published config, got %v", err)
+	}
+	_, err = c.Service().ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: -1})
+	var sdkErr *sdk.Error
+	if !errors.As(err, &sdkErr) || !errors.Is(err, sdk.ErrInvalid) || len(sdkErr.FieldViolations) != 1 || sdkErr.FieldViolations[0].Field != "page_size" {
+		t.Errorf("Expected ErrInvalid naming page_size, got %#v", err)
+	}
+
+	// The iterator follows page tokens through every config.
+	for i := 0; i < 4; i++ {
+		if _, err := c.Create(ctx, dna(fmt.Sprintf("SDK Level %d", i))); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	it := c.List(ctx, &pb.ListGameDNARequest{PageSize: 2})
+	seen := map[string]bool{}
+	for it.Next() {
+		seen[it.GameDNA().Id] = true
+	}
+	if err := it.Err(); err != nil || len(seen) != 5 {
+		t.Errorf("Expected 5 configs over 3 pages, got %d, %v", len(seen), err)
+	}
+	if all, err := c.ListAll(ctx, &pb.ListGameDNARequest{PageSize: 2, Genre: "Puzzle"}); err != nil || len(all) != 0 {
+		t.Errorf("Expected no puzzle configs, got %d, %v", len(all), err)
+	}
+
+	// Watch reconnects after the first stream is turned away, replays what it
+	// missed and stops with the callback's error.
+	if err := c.Delete(ctx, created.Id); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	stop := errors.New("stop")
+	var got []*pb.ConfigEvent
+	err = c.Watch(ctx, &pb.WatchGameDNARequest{AfterSequence: 1, Types: []string{events.TypeDeleted}}, func(ev *pb.ConfigEvent) error {
+		got = append(got, ev)
+		return stop
+	})
+	if err != stop || len(got) != 1 || got[0].ConfigId != created.Id {
+		t.Errorf("Expected the delete event and the callback's error, got %v, %v", got, err)
+	}
+	if got := attempts[pb.GameDNAService_WatchGameDNA_FullMethodName]; got != 2 {
+		t.Errorf("Expected Watch to reconnect once, got %d attempts", got)
+	}
+}
+
+func TestRESTErrorBodies(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, api.UnaryValidationInterceptor),
+	)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	type errorObject struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+		Source *struct {
+			Field string `json:"field"`
+		} `json:"source"`
+		Links *struct {
+			About string `json:"about"`
+		} `json:"links"`
+	}
+	var body struct {
+		Code            json.RawMessage `json:"code"`
+		Message         string          `json:"message"`
+		Errors          []errorObject   `json:"errors"`
+		RequestID       string          `json:"request_id"`
+		FieldViolations []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"field_violations"`
+	}
+	call := func(gateway *api.RESTGateway, method, path, reqBody string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(reqBody))
+		req.Header.Set(api.RequestIDHeader, "req-123")
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, req)
+		body.Errors, body.FieldViolations = nil, nil
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Error body is not JSON: %v: %s", err, rec.Body)
+		}
+		return rec
+	}
+	invalid := `{"gameDna": {"name": "Too Fast", "genre": "FPS", "targetFps": 5000, "timeScale": 1, "targetPlatforms": ["PC"]}}`
+
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{ErrorDocsURL: "https://docs.example.com/errors"}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	// The gateway's code and message stay next to the error objects.
+	rec := call(gateway, "GET", "/api/v1/game-dna/no-such-config", "")
+	if rec.Code != http.StatusNotFound || string(body.Code) != "5" || body.Message == "" {
+		t.Fatalf("Expected 404 with the gateway's fields, got %d %s", rec.Code, rec.Body)
+	}
+	if len(body.Errors) != 1 || body.Errors[0].Code != "NOT_FOUND" || body.Errors[0].Status != "404" || body.Errors[0].Detail != body.Message {
+		t.Errorf("Expected one NOT_FOUND error object, got %+v", body.Errors)
+	}
+	if links := body.Errors[0].Links; links == nil || links.About != "https://docs.example.com/errors#not-found" {
+		t.Errorf("Expected a documentation link, got %+v", links)
+	}
+	if body.RequestID != "req-123" {
+		t.Errorf("Expected the request ID, got %q", body.RequestID)
+	}
+
+	// Validation findings become one object each, with the validator's code.
+	rec = call(gateway, "POST", "/api/v1/game-dna", invalid)
+	if rec.Code != http.StatusBadRequest || len(body.Errors) != 1 {
+		t.Fatalf("Expected 400 with one error object, got %d %s", rec.Code, rec.Body)
+	}
+	if e := body.Errors[0]; e.Code != "INVALID_FPS" || e.Source == nil || e.Source.Field != "target_fps" || e.Title != "Bad Request" {
+		t.Errorf("Expected the finding on target_fps, got %+v", e)
+	}
+
+	// Request rule violations have no validator code, so carry the gRPC one.
+	call(gateway, "GET", "/api/v1/game-dna?pageSize=-1", "")
+	if len(body.Errors) != 1 || body.Errors[0].Code != "INVALID_ARGUMENT" || body.Errors[0].Source == nil || body.Errors[0].Source.Field != "page_size" {
+		t.Errorf("Expected an INVALID_ARGUMENT object for page_size, got %+v", body.Errors)
+	}
+
+	// problem+json carries the validator's codes too.
+	problems, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{ProblemJSON: true}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer problems.Shutdown(ctx)
+	rec = call(problems, "POST", "/api/v1/game-dna", invalid)
+	if rec.Header().Get("Content-Type") != "application/problem+json" || len(body.FieldViolations) != 1 || body.FieldViolations[0].Code != "INVALID_FPS" {
+		t.Errorf("Expected a problem with the finding's code, got %s", rec.Body)
+	}
+}
+
+func TestWriteStrictness(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		StrictEnvironments: []string{storage.EnvironmentProd},
+	}, zap.NewNop())
+
+	// 5000 entities is above the FPS profile's limit: a warning, not an error.
+	warned := func(name, env string) *pb.GameDNA {
+		return &pb.GameDNA{
+			Name: name, Genre: "FPS", TargetFps: 60, MaxEntities: 5000, TimeScale: 1,
+			TargetPlatforms: []string{"PC"}, Environment: env,
+		}
+	}
+
+	// Lenient by default, with the warning returned rather than dropped.
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: warned("Dev Shooter", "")})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if v := created.Validation; v == nil || len(v.Warnings) != 1 || v.Warnings[0].Code != "ABOVE_PROFILE_LIMIT" {
+		t.Errorf("Expected the warning in the create response, got %v", v)
+	}
+
+	// Strict on request.
+	_, err = svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: warned("Strict Shooter", ""), Strictness: api.StrictnessStrict})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected a strict create to be rejected, got %v", err)
+	}
+	var br *errdetails.BadRequest
+	var report *pb.ValidationResponse
+	for _, d := range status.Convert(err).Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			br = d
+		case *pb.ValidationResponse:
+			report = d
+		}
+	}
+	if br == nil || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "max_entities" {
+		t.Errorf("Expected a violation for max_entities, got %v", br)
+	}
+	if report == nil || len(report.Warnings) != 1 {
+		t.Errorf("Expected the validation report in the error, got %v", report)
+	}
+
+	// Strict for prod configs, unless the request says otherwise.
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: warned("Prod Shooter", storage.EnvironmentProd)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a prod create with warnings to be rejected, got %v", err)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: warned("Prod Shooter", storage.EnvironmentProd), Strictness: api.StrictnessLenient}); err != nil {
+		t.Errorf("Expected a lenient prod create to be accepted, got %v", err)
+	}
+
+	// Updates too.
+	update := warned("Dev Shooter", "")
+	update.MaxEntities = 6000
+	if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: created.GameDna.Id, GameDna: update, Strictness: api.StrictnessStrict}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a strict update to be rejected, got %v", err)
+	}
+	updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: created.GameDna.Id, GameDna: update})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.Validation == nil || len(updated.Validation.Warnings) != 1 {
+		t.Errorf("Expected the warning in the update response, got %v", updated.Validation)
+	}
+
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: warned("Odd", ""), Strictness: "pedantic"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an unknown strictness to be rejected, got %v", err)
+	}
+}
+
+func TestWriteResponseValidation(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	// Four players with no mode is a warning, AI without NPCs a suggestion.
+	dna := &pb.GameDNA{
+		Name: "Noted", Genre: "RPG", Camera: "Perspective3D", TargetFps: 60, TimeScale: 1,
+		TargetPlatforms: []string{"PC"}, MaxPlayers: 4, AiEnabled: true,
+	}
+	noted := func(desc string, v *pb.ValidationResponse) {
+		t.Helper()
+		if v == nil || !v.IsValid || len(v.Warnings) != 1 || v.Warnings[0].Code != "MULTIPLAYER_NOT_CONFIGURED" ||
+			len(v.Suggestions) != 1 || v.RulesVersion != rust.RulesVersion() {
+			t.Errorf("%s: expected the warning and suggestion, got %v", desc, v)
+		}
+	}
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	noted("create", created.Validation)
+
+	update := proto.Clone(created.GameDna).(*pb.GameDNA)
+	update.TargetFps = 120
+	updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: update.Id, GameDna: update})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	noted("update", updated.Validation)
+	unchanged, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: update.Id, GameDna: update})
+	if err != nil || !unchanged.NotModified {
+		t.Fatalf("Expected an unchanged update, got %v, %v", unchanged, err)
+	}
+	noted("unchanged update", unchanged.Validation)
+
+	clean := &pb.GameDNA{Name: "Clean", Genre: "RPG", Camera: "Perspective3D", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	update.TargetFps = 90
+	saved, err := svc.SaveSet(ctx, &pb.SaveSetRequest{Creates: []*pb.GameDNA{clean}, Updates: []*pb.GameDNA{update}})
+	if err != nil {
+		t.Fatalf("SaveSet failed: %v", err)
+	}
+	if len(saved.CreateValidations) != 1 || len(saved.CreateValidations[0].Warnings) != 0 || len(saved.UpdateValidations) != 1 {
+		t.Fatalf("Expected a report per entry, got creates %v, updates %v", saved.CreateValidations, saved.UpdateValidations)
+	}
+	noted("save set update", saved.UpdateValidations[0])
+}
+
+func TestVersionHistoryPagingAndPruning(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{MaxHistoryDepth: 3}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Long History", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	dna := created.GameDna
+	for fps := uint32(61); fps <= 65; fps++ {
+		dna = proto.Clone(dna).(*pb.GameDNA)
+		dna.TargetFps = fps
+		updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: dna})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		dna = updated.GameDna
+	}
+
+	// Unpaged, every version with its data, newest first.
+	all, err := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id})
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	if len(all.Versions) != 6 || all.Versions[0].VersionNum != 6 || all.Versions[0].Data == nil || all.NextPageToken != "" {
+		t.Fatalf("Expected 6 versions newest first with data, got %d: %v", len(all.Versions), all.Versions)
+	}
+
+	// Paged without data.
+	var nums []int64
+	req := &pb.GetVersionHistoryRequest{ConfigId: id, PageSize: 4, IncludeData: proto.Bool(false)}
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatalf("Expected the pages to end, got versions %v", nums)
+		}
+		page, err := svc.GetVersionHistory(ctx, req)
+		if err != nil {
+			t.Fatalf("GetVersionHistory failed: %v", err)
+		}
+		for _, v := range page.Versions {
+			if v.Data != nil || v.Checksum == "" {
+				t.Errorf("Expected version %d without data but with metadata, got %v", v.VersionNum, v)
+			}
+			nums = append(nums, v.VersionNum)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		req.PageToken = page.NextPageToken
+	}
+	if !slices.Equal(nums, []int64{6, 5, 4, 3, 2, 1}) {
+		t.Errorf("Expected every version once, newest first, got %v", nums)
+	}
+
+	other, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Other History", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	first, _ := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id, PageSize: 1})
+	for _, bad := range []*pb.GetVersionHistoryRequest{
+		{ConfigId: other.GameDna.Id, PageToken: first.NextPageToken},
+		{ConfigId: id, PageToken: "not-a-token"},
+	} {
+		if _, err := svc.GetVersionHistory(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected page token %q for %s to be rejected, got %v", bad.PageToken, bad.ConfigId, err)
+		}
+	}
+
+	// The pruner keeps the newest three and the tagged version 1.
+	if _, err := svc.TagVersion(ctx, &pb.TagVersionRequest{ConfigId: id, VersionNum: 1, Tag: "launch", Actor: "ops"}); err != nil {
+		t.Fatalf("TagVersion failed: %v", err)
+	}
+	pruned, err := svc.PruneVersionHistory(ctx)
+	if err != nil || pruned != 2 {
+		t.Fatalf("Expected 2 versions pruned, got %d, %v", pruned, err)
+	}
+	versionNums := func() []int64 {
+		t.Helper()
+		resp, err := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id, IncludeData: proto.Bool(false)})
+		if err != nil {
+			t.Fatalf("GetVersionHistory failed: %v", err)
+		}
+		var nums []int64
+		for _, v := range resp.Versions {
+			nums = append(nums, v.VersionNum)
+		}
+		return nums
+	}
+	if got := versionNums(); !slices.Equal(got, []int64{6, 5, 4, 1}) {
+		t.Errorf("Expected versions 6, 5, 4 and the tagged 1 after pruning by depth, got %v", got)
+	}
+
+	// By age every version goes but the newest and the tagged one.
+	if _, err := store.PruneVersionHistory(ctx, storage.HistoryRetention{CreatedBefore: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("PruneVersionHistory failed: %v", err)
+	}
+	if got := versionNums(); !slices.Equal(got, []int64{6, 1}) {
+		t.Errorf("Expected versions 6 and the tagged 1 after pruning by age, got %v", got)
+	}
+	if resp, _ := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: other.GameDna.Id}); len(resp.GetVersions()) != 1 {
+		t.Errorf("Expected a config's only version to be kept, got %v", resp.GetVersions())
+	}
+}
+
+func TestVersionMetadataAndGetVersion(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	client := serveGameDNA(t, store)
+
+	created, err := client.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Sidebar", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	update := proto.Clone(created.GameDna).(*pb.GameDNA)
+	update.TargetFps = 120
+	if _, err := client.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: update}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := client.TagVersion(ctx, &pb.TagVersionRequest{ConfigId: id, VersionNum: 1, Tag: "alpha", Actor: "ops"}); err != nil {
+		t.Fatalf("TagVersion failed: %v", err)
+	}
+
+	first, err := client.ListVersionMetadata(ctx, &pb.ListVersionMetadataRequest{ConfigId: id, PageSize: 1})
+	if err != nil {
+		t.Fatalf("ListVersionMetadata failed: %v", err)
+	}
+	if len(first.Versions) != 1 || first.Versions[0].VersionNum != 2 || first.NextPageToken == "" ||
+		!slices.Equal(first.Versions[0].ChangedFields, []string{"target_fps"}) {
+		t.Fatalf("Expected version 2 and a token, got %v", first)
+	}
+	rest, err := client.ListVersionMetadata(ctx, &pb.ListVersionMetadataRequest{ConfigId: id, PageToken: first.NextPageToken})
+	if err != nil {
+		t.Fatalf("ListVersionMetadata failed: %v", err)
+	}
+	if changes := first.Versions[0].Changes; len(changes) != 1 || changes[0].Field != "target_fps" || changes[0].OldValue != "60" || changes[0].NewValue != "120" {
+		t.Errorf("Expected version 2 to list its target_fps change, got %v", changes)
+	}
+	if len(rest.Versions) != 1 || rest.NextPageToken != "" {
+		t.Fatalf("Expected version 1 on the last page, got %v", rest)
+	}
+	meta := rest.Versions[0]
+	if meta.VersionNum != 1 || meta.Size <= 0 || meta.Checksum == "" || meta.CreateTime == nil ||
+		!slices.Equal(meta.ReleaseTags, []string{"alpha"}) {
+		t.Errorf("Unexpected metadata of version 1: %v", meta)
+	}
+
+	got, err := client.GetVersion(ctx, &pb.GetVersionRequest{ConfigId: id, VersionNum: 1})
+	if err != nil {
+		t.Fatalf("GetVersion failed: %v", err)
+	}
+	if v := got.Version; v.Data.GetTargetFps() != 60 || v.Size != meta.Size || v.Checksum != meta.Checksum ||
+		!slices.Equal(v.ReleaseTags, []string{"alpha"}) {
+		t.Errorf("Expected version 1 with its data, got %v", v)
+	}
+	if _, err := client.GetVersion(ctx, &pb.GetVersionRequest{ConfigId: id, VersionNum: 9}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a missing version, got %v", err)
+	}
+	if _, err := client.GetVersion(ctx, &pb.GetVersionRequest{ConfigId: id}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a version number, got %v", err)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	ctx := context.Background()
+	chain, err := server.DefaultRegistry(zap.NewNop(), server.Options{}).Chain([]string{"recovery", "metrics", "errors"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	const method = "entropic.dna.v1.GameDNAService/GetGameDNA"
+	rpcCount := func(key, name string) int64 {
+		if v := expvar.Get("rpcs").(*expvar.Map).Get(key).(*expvar.Map).Get(name); v != nil {
+			return v.(*expvar.Int).Value()
+		}
+		return 0
+	}
+	calls, errs, notFound := rpcCount("calls", method), rpcCount("errors", method), rpcCount("codes", "NotFound")
+
+	// The store's not-found error is counted as the NotFound the caller gets.
+	if _, err := pb.NewGameDNAServiceClient(conn).GetGameDNA(ctx, &pb.GetGameDNARequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if got := rpcCount("calls", method) - calls; got != 1 {
+		t.Errorf("Expected one call counted, got %d", got)
+	}
+	if got := rpcCount("errors", method) - errs; got != 1 {
+		t.Errorf("Expected one error counted, got %d", got)
+	}
+	if got := rpcCount("codes", "NotFound") - notFound; got != 1 {
+		t.Errorf("Expected one NotFound counted, got %d", got)
+	}
+}
+
+// flakyStore fails Read and Update with queued errors before passing them on,
+// counting the calls that reach it.
+type flakyStore struct {
+	storage.Store
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (s *flakyStore) fail(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, errs...)
+}
+
+func (s *flakyStore) next() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func (s *flakyStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *flakyStore) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return s.Store.Read(ctx, id)
+}
+
+func (s *flakyStore) Update(ctx context.Context, dna *pb.GameDNA, opts ...storage.WriteOption) (*pb.GameDNA, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return s.Store.Update(ctx, dna, opts...)
+}
+
+func TestDatabaseResilience(t *testing.T) {
+	ctx := context.Background()
+	mem := storage.NewMemoryStore()
+	dna, err := mem.Create(ctx, &pb.GameDNA{Name: "Flaky", Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	flaky := &flakyStore{Store: mem}
+	store := resilience.WrapStore(flaky, resilience.Options{
+		MaxRetries:       2,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       2 * time.Millisecond,
+		FailureThreshold: 3,
+		Cooldown:         50 * time.Millisecond,
+	}, zap.NewNop())
+	defer store.Close()
+	lost := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	// A read that loses its connection is retried until it gets through.
+	flaky.fail(lost, driver.ErrBadConn)
+	if _, err := store.Read(ctx, dna.Id); err != nil {
+		t.Fatalf("Expected the read to be retried, got %v", err)
+	}
+
+	// A write is retried after a serialization failure, but not after losing
+	// its connection, as it may have been committed.
+	flaky.fail(&pq.Error{Code: "40001"})
+	if _, err := store.Update(ctx, dna); err != nil && !errors.Is(err, storage.ErrNotModified) {
+		t.Fatalf("Expected the write to be retried, got %v", err)
+	}
+	before := flaky.count()
+	flaky.fail(lost)
+	if _, err := store.Update(ctx, dna); !errors.Is(err, storage.ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if got := flaky.count() - before; got != 1 {
+		t.Errorf("Expected the write to be tried once, got %d calls", got)
+	}
+
+	// Errors other than transient ones are returned as they are.
+	if _, err := store.Read(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrUnavailable) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Three calls in a row losing the database open the breaker, which then
+	// fails calls fast with Unavailable.
+	rejected := func() int64 {
+		if v := expvar.Get("database").(*expvar.Map).Get("rejected"); v != nil {
+			return v.(*expvar.Int).Value()
+		}
+		return 0
+	}()
+	flaky.fail(lost, lost, lost)
+	if _, err := store.Read(ctx, dna.Id); !errors.Is(err, storage.ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable after the retries, got %v", err)
+	}
+	before = flaky.count()
+	client := serveGameDNA(t, store)
+	if _, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable from an open breaker, got %v", err)
+	}
+	if got := flaky.count() - before; got != 0 {
+		t.Errorf("Expected the open breaker to keep calls off the store, got %d", got)
+	}
+	if got := expvar.Get("database").(*expvar.Map).Get("rejected").(*expvar.Int).Value() - rejected; got != 1 {
+		t.Errorf("Expected one rejected call counted, got %d", got)
+	}
+
+	// After the cooldown a trial call reaches the store and closes it.
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id}); err != nil {
+		t.Fatalf("Expected the breaker to close, got %v", err)
+	}
+	if _, err := store.Read(ctx, dna.Id); err != nil {
+		t.Fatalf("Expected the breaker to stay closed, got %v", err)
+	}
+}
+
+func TestRepairMissingVersions(t *testing.T) {
+	ctx := context.Background()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	defer store.Close()
+	if err := storage.Migrate(ctx, store.DB()); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	dna, err := store.Create(ctx, &pb.GameDNA{Name: fmt.Sprintf("Orphan %d", time.Now().UnixNano()), Genre: "FPS"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer store.Delete(ctx, dna.Id)
+	// A config left without versions, as a crash between the two writes of an
+	// untransacted create would leave it
+	if _, err := store.DB().ExecContext(ctx, `DELETE FROM game_dna_versions WHERE config_id = $1`, dna.Id); err != nil {
+		t.Fatalf("Delete versions failed: %v", err)
+	}
+
+	ids, err := store.RepairMissingVersions(ctx, true)
+	if err != nil {
+		t.Fatalf("RepairMissingVersions failed: %v", err)
+	}
+	if !slices.Contains(ids, dna.Id) {
+		t.Fatalf("Expected the dry run to find %s, got %v", dna.Id, ids)
+	}
+	if history, _ := store.GetVersionHistory(ctx, dna.Id); len(history) != 0 {
+		t.Fatalf("Expected the dry run to write nothing, got %d versions", len(history))
+	}
+
+	if ids, err = store.RepairMissingVersions(ctx, false); err != nil || !slices.Contains(ids, dna.Id) {
+		t.Fatalf("Expected %s repaired, got %v, %v", dna.Id, ids, err)
+	}
+	history, err := store.GetVersionHistory(ctx, dna.Id)
+	if err != nil || len(history) != 1 || history[0].VersionNum != 1 || history[0].Data.Name != dna.Name {
+		t.Fatalf("Expected version 1 with the config's content, got %v, %v", history, err)
+	}
+	if ids, _ := store.RepairMissingVersions(ctx, true); slices.Contains(ids, dna.Id) {
+		t.Errorf("Expected %s to have versions after the repair", dna.Id)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	ctx := context.Background()
+
+	// Reloaded API keys replace the old ones; invalid ones change nothing.
+	authn, err := auth.New([]auth.Key{{Name: "old", KeySHA256: auth.HashKey("old-key"), Scopes: []string{auth.ScopeRead}}}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	if err := authn.Reload([]auth.Key{{Name: "new", KeySHA256: auth.HashKey("new-key"), Scopes: []string{auth.ScopeAdmin}}}, auth.JWT{}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if _, err := authn.Authenticate("old-key", ""); err == nil {
+		t.Error("Expected the old key to be rejected after the reload")
+	}
+	if p, err := authn.Authenticate("new-key", ""); err != nil || p.Name != "new" {
+		t.Errorf("Expected the new key to authenticate, got %v, %v", p, err)
+	}
+	if err := authn.Reload([]auth.Key{{Name: "bad", KeySHA256: "nope", Scopes: []string{auth.ScopeRead}}}, auth.JWT{}); err == nil {
+		t.Error("Expected an invalid key to be rejected")
+	}
+	if _, err := authn.Authenticate("new-key", ""); err != nil {
+		t.Errorf("Expected a failed reload to keep the keys, got %v", err)
+	}
+
+	// Reloaded rate limits apply at once, with full buckets.
+	limiter, err := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{Rate: 0.01, Burst: 1}}, nil)
+	if err != nil {
+		t.Fatalf("ratelimit.New failed: %v", err)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/entropic.dna.v1.GameDNAService/GetSchema"}
+	call := func() error {
+		_, err := limiter.UnaryInterceptor(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+	if err := call(); err != nil {
+		t.Fatalf("Call within the burst failed: %v", err)
+	}
+	if err := call(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted past the burst, got %v", err)
+	}
+	if err := limiter.SetConfig(ratelimit.Config{Default: ratelimit.Limit{Rate: 1}}); err == nil {
+		t.Error("Expected a zero burst to be rejected")
+	}
+	if err := limiter.SetConfig(ratelimit.Config{Default: ratelimit.Limit{Rate: 0.01, Burst: 3}}); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := call(); err != nil {
+			t.Fatalf("Call %d within the reloaded burst failed: %v", i, err)
+		}
+	}
+
+	// Reloaded write strictness applies to the next write.
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	// 5000 entities is above the FPS profile's limit: a warning, not an error.
+	warned := &pb.GameDNA{
+		Name: "Reloaded", Genre: "FPS", TargetFps: 60, MaxEntities: 5000, TimeScale: 1,
+		TargetPlatforms: []string{"PC"},
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: proto.Clone(warned).(*pb.GameDNA), DryRun: true}); err != nil {
+		t.Fatalf("Expected a lenient create to pass, got %v", err)
+	}
+	if err := svc.SetWriteStrictness("pedantic", nil); err == nil {
+		t.Error("Expected an unknown strictness to be rejected")
+	}
+	if err := svc.SetWriteStrictness(api.StrictnessStrict, nil); err != nil {
+		t.Fatalf("SetWriteStrictness failed: %v", err)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: proto.Clone(warned).(*pb.GameDNA), DryRun: true}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected the reloaded strictness to reject warnings, got %v", err)
+	}
+
+	// ReloadConfig needs the server to be able to reload.
+	if _, err := svc.ReloadConfig(ctx, &pb.ReloadConfigRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a reloader, got %v", err)
+	}
+	reloading := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		Reload: func() ([]string, error) { return []string{"logging.level", "rate_limit"}, nil },
+	}, zap.NewNop())
+	resp, err := reloading.ReloadConfig(ctx, &pb.ReloadConfigRequest{})
+	if err != nil || !slices.Equal(resp.Changed, []string{"logging.level", "rate_limit"}) {
+		t.Errorf("Expected the changed settings, got %v, %v", resp, err)
+	}
+}
+
+func TestAdminListener(t *testing.T) {
+	ctx := context.Background()
+	freeAddr := func() string {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer lis.Close()
+		return lis.Addr().String()
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Server.AdminPort = 50052
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an admin port without admin keys to be rejected")
+	}
+	cfg.Server.AdminAPIKeys = []config.AdminKeyConfig{{Name: "ops", KeySHA256: auth.HashKey("ops-key")}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected the admin listener config to be valid, got %v", err)
+	}
+	cfg.Server.AdminPort = cfg.Server.GRPCPort
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an admin port equal to the gRPC port to be rejected")
+	}
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	adminAuth, err := auth.New([]auth.Key{{Name: "ops", KeySHA256: auth.HashKey("ops-key"), Scopes: []string{auth.ScopeAdmin}}}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	opts := server.Options{
+		GRPCAddr:     freeAddr(),
+		HTTPAddr:     freeAddr(),
+		Middleware:   []string{"errors", "validation"},
+		AdminAddr:    freeAddr(),
+		AdminMethods: []string{"GetAuditLog"},
+		AdminAuth:    adminAuth,
+	}
+	if _, err := server.New(svc, opts, zap.NewNop()); err == nil {
+		t.Error("Expected an admin listener without the auth middleware to be rejected")
+	}
+	opts.Middleware = []string{"errors", "auth", "validation"}
+	opts.AdminMethods = []string{"GetAudit"}
+	if _, err := server.New(svc, opts, zap.NewNop()); err == nil {
+		t.Error("Expected an unknown admin method to be rejected")
+	}
+	opts.AdminMethods = []string{"GetAuditLog"}
+	srv, err := server.New(svc, opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("server.New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Shutdown(ctx)
+
+	dial := func(addr string) pb.GameDNAServiceClient {
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("Failed to dial %s: %v", addr, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewGameDNAServiceClient(conn)
+	}
+	public, admin := dial(opts.GRPCAddr), dial(opts.AdminAddr)
+	withKey := metadata.AppendToOutgoingContext(ctx, auth.APIKeyMetadata, "ops-key")
+
+	// Admin methods, and the ones moved with them, are gone from the public port.
+	if _, err := public.GetSchema(ctx, &pb.GetSchemaRequest{}); err != nil {
+		t.Errorf("Expected GetSchema on the public port, got %v", err)
+	}
+	if _, err := public.GetIndexStats(withKey, &pb.GetIndexStatsRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented for an admin method on the public port, got %v", err)
+	}
+	if _, err := public.GetAuditLog(ctx, &pb.GetAuditLogRequest{ConfigId: "a"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented for a moved method on the public port, got %v", err)
+	}
+
+	// The admin port serves them, to admin keys only, and nothing else.
+	if _, err := admin.GetIndexStats(ctx, &pb.GetIndexStatsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without an admin key, got %v", err)
+	}
+	if _, err := admin.GetIndexStats(withKey, &pb.GetIndexStatsRequest{}); status.Code(err) == codes.Unimplemented || status.Code(err) == codes.Unauthenticated {
+		t.Errorf("Expected GetIndexStats on the admin port, got %v", err)
+	}
+	if _, err := admin.GetSchema(withKey, &pb.GetSchemaRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented for a public method on the admin port, got %v", err)
+	}
+
+	// Reflection is off unless asked for.
+	for _, conn := range []string{opts.GRPCAddr, opts.AdminAddr} {
+		cc, err := grpc.Dial(conn, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("Failed to dial %s: %v", conn, err)
+		}
+		stream, err := reflectionpb.NewServerReflectionClient(cc).ServerReflectionInfo(ctx)
+		if err == nil {
+			stream.Send(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}})
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unimplemented {
+			t.Errorf("Expected reflection to be off on %s, got %v", conn, err)
+		}
+		cc.Close()
+	}
+}
+
+func TestRatingCompliance(t *testing.T) {
+	rust, _ := ffi.NewRustFFI("", false)
+	base := func() *pb.GameDNA {
+		return &pb.GameDNA{Name: "Rated", Genre: "RPG", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	errorsOf := func(dna *pb.GameDNA) []string {
+		resp, err := rust.ValidateGameDNA(dna)
+		if err != nil {
+			t.Fatalf("ValidateGameDNA failed: %v", err)
+		}
+		var got []string
+		for _, e := range resp.Errors {
+			got = append(got, e.Code+":"+e.Field+":"+e.Details)
+		}
+		return got
+	}
+
+	// Off until enabled.
+	kids := base()
+	kids.EsrbRating, kids.Monetization = "E", "Gambling"
+	if got := errorsOf(kids); len(got) != 0 {
+		t.Errorf("Expected no compliance errors while disabled, got %v", got)
+	}
+
+	if err := rust.SetComplianceRules(ffi.DefaultComplianceRules); err != nil {
+		t.Fatalf("SetComplianceRules failed: %v", err)
+	}
+	if got := rust.RulesVersion(); got != ffi.BasicRulesVersion+"+compliance/1" {
+		t.Errorf("Expected the compliance version in the rules version, got %q", got)
+	}
+
+	// Each conflict is an error naming the lowest rating allowing the value.
+	kids.TargetAudience, kids.Tone = "adults", "Gore"
+	kids.CustomProperties = map[string]string{ffi.PEGIProperty: "18"}
+	if got := errorsOf(kids); !slices.Equal(got, []string{
+		"RATING_MONETIZATION_CONFLICT:monetization:Rate the game T or higher, or choose another monetization",
+		"RATING_AUDIENCE_CONFLICT:target_audience:Rate the game T or higher, or choose another target audience",
+		"RATING_TONE_CONFLICT:tone:Rate the game M or higher, or choose another tone",
+		"RATING_PEGI_MISMATCH:custom_properties.pegi_rating:ESRB E corresponds to PEGI 3 or 7; change one of the ratings",
+	}) {
+		t.Errorf("Unexpected compliance errors: %v", got)
+	}
+	mature := base()
+	mature.EsrbRating, mature.Monetization, mature.TargetAudience = "m", "RealMoneyGambling", "Teens and adults"
+	mature.CustomProperties = map[string]string{ffi.PEGIProperty: "18"}
+	if got := errorsOf(mature); !slices.Equal(got, []string{
+		"RATING_MONETIZATION_CONFLICT:monetization:Rate the game AO or higher, or choose another monetization",
+	}) {
+		t.Errorf("Unexpected compliance errors for M: %v", got)
+	}
+	pending := base()
+	pending.EsrbRating, pending.Monetization = "RP", "Gambling"
+	if got := errorsOf(pending); len(got) != 0 {
+		t.Errorf("Expected unlisted ratings not to be checked, got %v", got)
+	}
+
+	// A compliance file replaces the built-in rules.
+	rules, err := ffi.ParseComplianceRules([]byte(`
+version: studio-compliance/1
+ratings:
+  - esrb: E
+    forbidden_monetization: [Lootbox]
+`))
+	if err != nil {
+		t.Fatalf("ParseComplianceRules failed: %v", err)
+	}
+	if err := rust.SetComplianceRules(rules); err != nil {
+		t.Fatalf("SetComplianceRules failed: %v", err)
+	}
+	if got := errorsOf(kids); len(got) != 0 {
+		t.Errorf("Expected the file's rules to replace the built-in ones, got %v", got)
+	}
+	kids.Monetization = "lootbox"
+	if got := errorsOf(kids); !slices.Equal(got, []string{
+		"RATING_MONETIZATION_CONFLICT:monetization:Choose another monetization; no rating allows lootbox",
+	}) {
+		t.Errorf("Unexpected compliance errors from the file: %v", got)
+	}
+
+	for _, bad := range []string{
+		"version: v1\nratings: []",
+		"ratings: [{esrb: E}]",
+		"version: v1\nratings: [{esrb: E}, {esrb: e}]",
+		"version: v1\nratings: [{esrb: E, banned: [x]}]",
+	} {
+		rules, err := ffi.ParseComplianceRules([]byte(bad))
+		if err == nil {
+			err = rust.SetComplianceRules(rules)
+		}
+		if err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+// fixedCostModel estimates every platform at the same cost.
+type fixedCostModel struct{ cost budget.Estimate }
+
+func (m fixedCostModel) Name() string { return "fixed/1" }
+
+func (m fixedCostModel) Estimate(dna *pb.GameDNA, platform string) budget.Estimate {
+	e := m.cost
+	e.Platform = platform
+	return e
+}
+
+func TestEstimatePerformanceBudget(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	// Unset fields are estimated at the engine defaults: 10000 entities,
+	// 1000 NPCs, a draw distance of 1000 at 60 FPS.
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Budgeted", Genre: "RPG", TargetPlatforms: []string{"PC", "Mobile"}, TargetFps: 60, TimeScale: 1,
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	resp, err := svc.EstimatePerformanceBudget(ctx, &pb.EstimatePerformanceBudgetRequest{Id: created.GameDna.Slug})
+	if err != nil {
+		t.Fatalf("EstimatePerformanceBudget failed: %v", err)
+	}
+	if resp.Feasible || resp.Model != "coefficients/1" || resp.DefaultsVersion != 1 || len(resp.Budgets) != 2 {
+		t.Fatalf("Unexpected estimate: %v", resp)
+	}
+	pc, mobile := resp.Budgets[0], resp.Budgets[1]
+	if pc.Platform != "PC" || !pc.Feasible || pc.MemoryMb != 2923 || pc.CpuMs != 12 || pc.DrawCalls != 2700 {
+		t.Errorf("Unexpected PC estimate: %v", pc)
+	}
+	if mobile.Feasible || !slices.Equal(mobile.OverBudget, []string{budget.ResourceCPU, budget.ResourceDrawCalls}) {
+		t.Errorf("Expected Mobile to be over its CPU and draw call budgets, got %v", mobile)
+	}
+
+	// A shorter draw distance draws fewer entities, and a higher frame rate
+	// leaves less time per frame.
+	resp, err = svc.EstimatePerformanceBudget(ctx, &pb.EstimatePerformanceBudgetRequest{GameDna: &pb.GameDNA{
+		TargetPlatforms: []string{"PC"}, TargetFps: 240, MaxEntities: 10000, MaxNpcCount: 1000, MaxDrawDistance: 500,
+	}})
+	if err != nil {
+		t.Fatalf("EstimatePerformanceBudget failed: %v", err)
+	}
+	if got := resp.Budgets[0]; got.DrawCalls != 1450 || got.CpuBudgetMs != 1000.0/240 ||
+		!slices.Equal(got.OverBudget, []string{budget.ResourceCPU}) {
+		t.Errorf("Unexpected estimate at 240 FPS: %v", got)
+	}
+
+	if _, err := svc.EstimatePerformanceBudget(ctx, &pb.EstimatePerformanceBudgetRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a config, got %v", err)
+	}
+
+	// The cost model is pluggable.
+	custom := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{
+		CostModel: fixedCostModel{budget.Estimate{MemoryMB: 1, MemoryBudgetMB: 2, CPUBudgetMs: 1, DrawCallBudget: 1}},
+	}, zap.NewNop())
+	resp, err = custom.EstimatePerformanceBudget(ctx, &pb.EstimatePerformanceBudgetRequest{Id: created.GameDna.Id})
+	if err != nil {
+		t.Fatalf("EstimatePerformanceBudget failed: %v", err)
+	}
+	if !resp.Feasible || resp.Model != "fixed/1" || resp.Budgets[1].Platform != "Mobile" || resp.Budgets[1].MemoryMb != 1 {
+		t.Errorf("Expected the custom model's estimates, got %v", resp)
+	}
+}
+
+func TestCompareGameDNA(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	create := func(dna *pb.GameDNA) *pb.GameDNA {
+		resp, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: dna})
+		if err != nil {
+			t.Fatalf("CreateGameDNA failed: %v", err)
+		}
+		return resp.GameDna
+	}
+	pc := create(&pb.GameDNA{
+		Name: "Arena PC", Genre: "FPS", TargetPlatforms: []string{"PC"}, TargetFps: 120, TimeScale: 1,
+		MaxEntities: 1500, NpcCount: 40, AiEnabled: true, Monetization: "PremiumBuy",
+	})
+	// The Switch build inherits from the PC one and overrides the platform
+	// and performance.
+	sw := create(&pb.GameDNA{
+		Name: "Arena Switch", ParentId: pc.Id, TargetPlatforms: []string{"Console"}, TargetFps: 30, MaxEntities: 800,
+	})
+
+	fields := func(resp *pb.CompareGameDNAResponse) []string {
+		var got []string
+		for _, section := range resp.Sections {
+			for _, c := range section.Changes {
+				got = append(got, section.Section+"/"+c.Field+":"+c.OldValue+"->"+c.NewValue)
+			}
+		}
+		return got
+	}
+	resp, err := svc.CompareGameDNA(ctx, &pb.CompareGameDNARequest{LeftId: pc.Slug, RightId: sw.Id})
+	if err != nil {
+		t.Fatalf("CompareGameDNA failed: %v", err)
+	}
+	if got := fields(resp); resp.LeftId != pc.Id || resp.DifferingFields != 3 || !slices.Equal(got, []string{
+		"Core configuration/target_platforms:[PC]->[Console]",
+		"Performance/target_fps:120->30",
+		"Performance/max_entities:1500->800",
+	}) {
+		t.Errorf("Unexpected comparison: %v (%d fields)", got, resp.DifferingFields)
+	}
+
+	// Raw values show what the Switch build leaves to its parent.
+	resp, err = svc.CompareGameDNA(ctx, &pb.CompareGameDNARequest{LeftId: pc.Id, RightId: sw.Id, Raw: true})
+	if err != nil {
+		t.Fatalf("CompareGameDNA failed: %v", err)
+	}
+	if got := fields(resp); !slices.Contains(got, "Core configuration/genre:FPS->") || !slices.Contains(got, "AI and NPCs/ai_enabled:true->false") {
+		t.Errorf("Expected inherited fields to differ in a raw comparison, got %v", got)
+	}
+
+	resp, err = svc.CompareGameDNA(ctx, &pb.CompareGameDNARequest{LeftId: pc.Id, RightId: sw.Id, Format: "markdown"})
+	if err != nil {
+		t.Fatalf("CompareGameDNA failed: %v", err)
+	}
+	if !strings.Contains(resp.Rendered, "| Field | Arena PC | Arena Switch |") || !strings.Contains(resp.Rendered, "risky field(s) differ") {
+		t.Errorf("Expected a column per config, got:\n%s", resp.Rendered)
+	}
+	resp, err = svc.CompareGameDNA(ctx, &pb.CompareGameDNARequest{LeftId: pc.Id, RightId: pc.Id, Format: "html"})
+	if err != nil || resp.DifferingFields != 0 || !strings.Contains(resp.Rendered, "No differences.") {
+		t.Errorf("Expected a config to match itself, got %v, %v", resp, err)
+	}
+	if _, err := svc.CompareGameDNA(ctx, &pb.CompareGameDNARequest{LeftId: pc.Id, RightId: "missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected NotFound for a missing config, got %v", err)
+	}
+}
+
+func TestPropertySchemas(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	for _, req := range []*pb.SavePropertySchemaRequest{
+		{Project: "arena", Name: "rarity", Type: "enum", AllowedValues: []string{"Common", "Rare"}, Required: true, Actor: "designer"},
+		{Project: "arena", Name: "max_level", Type: "int", Actor: "designer"},
+		{Project: "arena", Name: "hardcore", Type: "bool", Actor: "designer"},
+		{Project: "arena", Name: "drop_rate", Type: "float", Actor: "designer"},
+	} {
+		if _, err := svc.SavePropertySchema(ctx, req); err != nil {
+			t.Fatalf("SavePropertySchema %s failed: %v", req.Name, err)
+		}
+	}
+	for _, bad := range []*pb.SavePropertySchemaRequest{
+		{Project: "arena", Name: "kind", Type: "enum"},
+		{Project: "arena", Name: "kind", Type: "enum", AllowedValues: []string{"a", "A"}},
+		{Project: "arena", Name: "level", Type: "int", AllowedValues: []string{"1"}},
+		{Project: "arena", Name: "level", Type: "integer"},
+		{Project: "arena", Name: "max level", Type: "int"},
+		{Project: "arena", Name: ffi.ProjectProperty, Type: "string"},
+	} {
+		if _, err := svc.SavePropertySchema(ctx, bad); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", bad, err)
+		}
+	}
+	list, err := svc.ListPropertySchemas(ctx, &pb.ListPropertySchemasRequest{Project: "arena"})
+	if err != nil {
+		t.Fatalf("ListPropertySchemas failed: %v", err)
+	}
+	var names []string
+	for _, schema := range list.Schemas {
+		names = append(names, schema.Name)
+	}
+	if !slices.Equal(names, []string{"drop_rate", "hardcore", "max_level", "rarity"}) || list.Schemas[3].UpdatedBy != "designer" {
+		t.Errorf("Unexpected schemas: %v", list.Schemas)
+	}
+
+	// Valid values are stored in canonical form; undeclared keys are
+	// warnings naming the key they likely misspell.
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Arena", Genre: "FPS", TargetPlatforms: []string{"PC"}, TargetFps: 60, TimeScale: 1,
+		CustomProperties: map[string]string{
+			ffi.ProjectProperty: "arena", ffi.PEGIProperty: "16",
+			"rarity": " rare", "max_level": "042", "hardcore": "YES", "drop_rate": "0.50", "max_lvel": "5",
+		},
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	props := created.GameDna.CustomProperties
+	if props["rarity"] != "Rare" || props["max_level"] != "42" || props["hardcore"] != "true" || props["drop_rate"] != "0.5" {
+		t.Errorf("Expected canonical values, got %v", props)
+	}
+	var warnings []string
+	for _, w := range created.Validation.Warnings {
+		if strings.HasPrefix(w.Code, "PROPERTY_") {
+			warnings = append(warnings, w.Code+":"+w.Field+":"+w.Suggestion)
+		}
+	}
+	if !slices.Equal(warnings, []string{"PROPERTY_UNDECLARED:custom_properties.max_lvel:Did you mean max_level?"}) {
+		t.Errorf("Unexpected property warnings: %v", warnings)
+	}
+
+	// A variant inherits its parent's project, and its missing or mistyped
+	// properties are errors.
+	variant := &pb.GameDNA{Name: "Arena Mobile", ParentId: created.GameDna.Id, CustomProperties: map[string]string{
+		"rarity": "", "hardcore": "maybe",
+	}}
+	report, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{GameDna: variant})
+	if err != nil {
+		t.Fatalf("ValidateGameDNA failed: %v", err)
+	}
+	var errs []string
+	for _, e := range report.Errors {
+		errs = append(errs, e.Code+":"+e.Field+":"+e.Details)
+	}
+	if report.IsValid || !slices.Equal(errs, []string{
+		"PROPERTY_TYPE_MISMATCH:custom_properties.hardcore:Set hardcore to a bool value",
+		"PROPERTY_REQUIRED:custom_properties.rarity:Set rarity to one of Common, Rare",
+	}) {
+		t.Errorf("Unexpected property errors: %v", errs)
+	}
+	if _, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: variant}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected the variant to be rejected, got %v", err)
+	}
+
+	// Configs of other projects, or of none, aren't checked.
+	other := &pb.GameDNA{Name: "Other", Genre: "FPS", TargetPlatforms: []string{"PC"}, TargetFps: 60, TimeScale: 1,
+		CustomProperties: map[string]string{"hardcore": "maybe"}}
+	if report, err := svc.ValidateGameDNA(ctx, &pb.ValidateGameDNARequest{GameDna: other}); err != nil || !report.IsValid || len(report.Warnings) != 0 {
+		t.Errorf("Expected a config without a project not to be checked, got %v, %v", report, err)
+	}
+
+	if _, err := svc.DeletePropertySchema(ctx, &pb.DeletePropertySchemaRequest{Project: "arena", Name: "rarity"}); err != nil {
+		t.Fatalf("DeletePropertySchema failed: %v", err)
+	}
+	if _, err := svc.GetPropertySchema(ctx, &pb.GetPropertySchemaRequest{Project: "arena", Name: "rarity"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the schema to be gone, got %v", err)
+	}
+	if _, err := svc.DeletePropertySchema(ctx, &pb.DeletePropertySchemaRequest{Project: "arena", Name: "rarity"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected NotFound deleting twice, got %v", err)
+	}
+}
+
+func TestEventSourcedHistory(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	store.SetMaxHistoryDepth(2)
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(eventsource.NewStore(store, store, zap.NewNop()), rust, api.ServerOptions{EventLog: store}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Event Arena", Genre: "FPS", TargetPlatforms: []string{"PC"}, TargetFps: 60, TimeScale: 1, MaxPlayers: 8,
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	id := created.GameDna.Id
+	for _, players := range []uint32{16, 32, 64} {
+		update := proto.Clone(created.GameDna).(*pb.GameDNA)
+		update.MaxPlayers = players
+		if _, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: update}); err != nil {
+			t.Fatalf("UpdateGameDNA failed: %v", err)
+		}
+	}
+
+	// The store keeps the newest two versions; the log rebuilds the others.
+	history, err := svc.GetVersionHistory(ctx, &pb.GetVersionHistoryRequest{ConfigId: id})
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	var players []uint32
+	for _, v := range history.Versions {
+		players = append(players, v.Data.GetMaxPlayers())
+	}
+	if !slices.Equal(players, []uint32{64, 32, 16, 8}) {
+		t.Errorf("Expected every version rebuilt, got max_players %v", players)
+	}
+	first, err := svc.GetVersion(ctx, &pb.GetVersionRequest{ConfigId: id, VersionNum: 1})
+	if err != nil {
+		t.Fatalf("GetVersion of a pruned version failed: %v", err)
+	}
+	if first.Version.Data.MaxPlayers != 8 || first.Version.Checksum != created.GameDna.Checksum {
+		t.Errorf("Unexpected rebuilt version 1: %v", first.Version)
+	}
+
+	asOf, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id, AsOf: timestamppb.Now()})
+	if err != nil {
+		t.Fatalf("GetGameDNA as of now failed: %v", err)
+	}
+	if asOf.GameDna.MaxPlayers != 64 || asOf.VersionNum != 4 {
+		t.Errorf("Expected version 4 as of now, got version %d with max_players %d", asOf.VersionNum, asOf.GameDna.MaxPlayers)
+	}
+	_, err = svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id, AsOf: timestamppb.New(time.Now().Add(-time.Hour))})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected NotFound before the config existed, got %v", err)
+	}
+
+	// Rolling back to a pruned version writes its rebuilt content.
+	rolled, err := svc.RollbackToVersion(ctx, &pb.RollbackToVersionRequest{ConfigId: id, VersionNum: 1})
+	if err != nil {
+		t.Fatalf("RollbackToVersion to a pruned version failed: %v", err)
+	}
+	if rolled.GameDna.MaxPlayers != 8 {
+		t.Errorf("Expected max_players 8 after rollback, got %d", rolled.GameDna.MaxPlayers)
+	}
+
+	if _, err := svc.DeleteGameDNA(ctx, &pb.DeleteGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("DeleteGameDNA failed: %v", err)
+	}
+	log, err := svc.GetEventLog(ctx, &pb.GetEventLogRequest{ConfigId: id})
+	if err != nil {
+		t.Fatalf("GetEventLog of a deleted config failed: %v", err)
+	}
+	var types []string
+	for _, e := range log.Events {
+		types = append(types, e.Type)
+	}
+	want := []string{storage.EventConfigCreated, storage.EventFieldChanged, storage.EventFieldChanged, storage.EventFieldChanged, storage.EventRolledBack, storage.EventConfigDeleted}
+	if !slices.Equal(types, want) {
+		t.Errorf("Expected events %v, got %v", want, types)
+	}
+	if e := log.Events[1]; e.Full || !slices.Contains(e.ChangedFields, "max_players") || e.SizeBytes >= log.Events[0].SizeBytes {
+		t.Errorf("Expected a small field change event, got %v", e)
+	}
+	if _, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id, AsOf: timestamppb.Now()}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected NotFound after deletion, got %v", err)
+	}
+
+	// Reads as of a time replay the events up to it, clearing the fields a
+	// change unset.
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	crafted := storage.NewMemoryStore()
+	for _, e := range []*storage.Event{
+		{ConfigID: "crafted", Type: storage.EventConfigCreated, VersionNum: 1, CreatedAt: at, Full: true,
+			Data: &pb.GameDNA{Id: "crafted", Name: "Crafted", MaxPlayers: 8, Tone: "Dark"}},
+		{ConfigID: "crafted", Type: storage.EventFieldChanged, VersionNum: 2, CreatedAt: at.Add(time.Hour),
+			Fields: []string{"max_players", "tone"}, Data: &pb.GameDNA{MaxPlayers: 16}},
+		{ConfigID: "crafted", Type: storage.EventConfigDeleted, CreatedAt: at.Add(2 * time.Hour), Data: &pb.GameDNA{}},
+	} {
+		if _, err := crafted.AppendEvent(ctx, e); err != nil {
+			t.Fatalf("AppendEvent failed: %v", err)
+		}
+	}
+	replayed := api.NewGameDNAServiceServer(crafted, rust, api.ServerOptions{EventLog: crafted}, zap.NewNop())
+	for _, tc := range []struct {
+		at      time.Time
+		version int64
+		players uint32
+		tone    string
+	}{
+		{at.Add(30 * time.Minute), 1, 8, "Dark"},
+		{at.Add(90 * time.Minute), 2, 16, ""},
+	} {
+		resp, err := replayed.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: "crafted", AsOf: timestamppb.New(tc.at)})
+		if err != nil {
+			t.Fatalf("GetGameDNA as of %v failed: %v", tc.at, err)
+		}
+		if resp.VersionNum != tc.version || resp.GameDna.MaxPlayers != tc.players || resp.GameDna.Tone != tc.tone || resp.GameDna.Name != "Crafted" {
+			t.Errorf("Unexpected config as of %v: version %d, %v", tc.at, resp.VersionNum, resp.GameDna)
+		}
+	}
+	if _, err := replayed.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: "crafted", AsOf: timestamppb.New(at.Add(3 * time.Hour))}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected NotFound after deletion, got %v", err)
+	}
+
+	plain := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := plain.GetEventLog(ctx, &pb.GetEventLogRequest{ConfigId: id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without an event log, got %v", err)
+	}
+}
+
+func TestGetGameDNAWaitForChange(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	broker := events.NewBroker(16)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker, Watch: broker, MaxWait: 50 * time.Millisecond}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Polled Arena", Genre: "FPS", TargetPlatforms: []string{"PC"}, TargetFps: 60, TimeScale: 1, MaxPlayers: 8,
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	dna := created.GameDna
+
+	// A stale copy is answered at once.
+	resp, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, WaitChecksum: "stale", WaitSeconds: 30})
+	if err != nil {
+		t.Fatalf("GetGameDNA with a stale checksum failed: %v", err)
+	}
+	if resp.NotModified || resp.GameDna.Checksum != dna.Checksum {
+		t.Errorf("Expected the current config at once, got %v", resp)
+	}
+
+	// An unchanged config is returned once the wait, capped by MaxWait, runs out.
+	start := time.Now()
+	resp, err = svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Slug, WaitChecksum: dna.Checksum, WaitSeconds: 30})
+	if err != nil {
+		t.Fatalf("GetGameDNA waiting on an unchanged config failed: %v", err)
+	}
+	if !resp.NotModified || resp.GameDna.Id != dna.Id {
+		t.Errorf("Expected not_modified after the wait, got %v", resp)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected the wait capped at 50ms, took %v", elapsed)
+	}
+
+	// A change ends the wait.
+	waiting := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Events: broker, Watch: broker, MaxWait: 10 * time.Second}, zap.NewNop())
+	done := make(chan *pb.GameDNAResponse, 1)
+	go func() {
+		resp, err := waiting.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, WaitChecksum: dna.Checksum})
+		if err != nil {
+			t.Errorf("GetGameDNA waiting for a change failed: %v", err)
+		}
+		done <- resp
+	}()
+	time.Sleep(20 * time.Millisecond)
+	update := proto.Clone(dna).(*pb.GameDNA)
+	update.MaxPlayers = 16
+	if _, err := waiting.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: dna.Id, GameDna: update}); err != nil {
+		t.Fatalf("UpdateGameDNA failed: %v", err)
+	}
+	select {
+	case resp := <-done:
+		if resp == nil || resp.NotModified || resp.GameDna.MaxPlayers != 16 {
+			t.Errorf("Expected the updated config, got %v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The wait did not end on update")
+	}
+
+	if _, err := svc.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, WaitChecksum: dna.Checksum, ReleaseTag: "1.0"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument combining wait_checksum and release_tag, got %v", err)
+	}
+	plain := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	if _, err := plain.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: dna.Id, WaitChecksum: dna.Checksum}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without change events, got %v", err)
+	}
+}
+
+func TestRESTContentNegotiation(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, api.UnaryValidationInterceptor),
+	)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	call := func(method, path, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A MessagePack request body is read with the JSON field names.
+	body, err := msgpack.FromJSON([]byte(`{"gameDna": {"name": "Packed Arena", "genre": "FPS", "targetFps": 60, "timeScale": 1, "targetPlatforms": ["PC"]}}`))
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+	rec := call("POST", "/api/v1/game-dna", "application/msgpack", "application/msgpack", body)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("Expected a MessagePack response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	decoded, err := msgpack.ToJSON(rec.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Response is not MessagePack: %v", err)
+	}
+	created := &pb.GameDNAResponse{}
+	if err := protojson.Unmarshal(decoded, created); err != nil {
+		t.Fatalf("Response does not map to GameDNAResponse: %v", err)
+	}
+	if created.GameDna.GetName() != "Packed Arena" || created.GameDna.GetTargetFps() != 60 {
+		t.Fatalf("Expected the created config, got %v", created.GameDna)
+	}
+	path := "/api/v1/game-dna/" + created.GameDna.Id
+
+	// The same read is smaller as MessagePack than as JSON, and smaller again
+	// as protobuf.
+	jsonBody := call("GET", path, "", "", nil).Body.Bytes()
+	packed := call("GET", path, "", "application/x-msgpack", nil)
+	if packed.Header().Get("Content-Type") != "application/msgpack" || packed.Body.Len() >= len(jsonBody) {
+		t.Errorf("Expected MessagePack under %d bytes, got %d bytes of %q", len(jsonBody), packed.Body.Len(), packed.Header().Get("Content-Type"))
+	}
+	binary := call("GET", path, "", "application/x-protobuf", nil)
+	read := &pb.GameDNAResponse{}
+	if err := proto.Unmarshal(binary.Body.Bytes(), read); err != nil || read.GameDna.GetId() != created.GameDna.Id {
+		t.Fatalf("Expected a protobuf response, got %v: %v", err, read)
+	}
+	if binary.Body.Len() >= packed.Body.Len() {
+		t.Errorf("Expected protobuf under %d bytes, got %d", packed.Body.Len(), binary.Body.Len())
+	}
+
+	// A protobuf request body works the same way.
+	update, _ := proto.Marshal(&pb.UpdateGameDNARequest{GameDna: &pb.GameDNA{TargetFps: 120}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}}})
+	rec = call("PUT", path, "application/x-protobuf", "", update)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the protobuf update to succeed, got %d %s", rec.Code, rec.Body)
+	}
+	if err := proto.Unmarshal(rec.Body.Bytes(), read); err != nil || read.GameDna.GetTargetFps() != 120 {
+		t.Errorf("Expected target_fps 120, got %v: %v", err, read.GameDna)
+	}
+
+	// Errors carry their error objects in MessagePack too.
+	rec = call("GET", "/api/v1/game-dna/no-such-config", "", "application/msgpack", nil)
+	decoded, err = msgpack.ToJSON(rec.Body.Bytes())
+	if rec.Code != http.StatusNotFound || err != nil {
+		t.Fatalf("Expected a MessagePack 404, got %d: %v", rec.Code, err)
+	}
+	var errBody struct {
+		Code   int `json:"code"`
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(decoded, &errBody); err != nil || errBody.Code != 5 || len(errBody.Errors) != 1 || errBody.Errors[0].Code != "NOT_FOUND" {
+		t.Errorf("Expected a NOT_FOUND error object, got %s", decoded)
+	}
+
+	// A malformed body is rejected, not read as an empty request.
+	rec = call("POST", "/api/v1/game-dna", "application/msgpack", "", []byte{0x81, 0xa7})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a truncated body, got %d", rec.Code)
+	}
+}
+
+// payloadStats records the compression and sizes of the responses a client
+// receives.
+type payloadStats struct {
+	mu          sync.Mutex
+	compression string
+	wire, size  int
+}
+
+func (s *payloadStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context   { return ctx }
+func (s *payloadStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context { return ctx }
+func (s *payloadStats) HandleConn(context.Context, stats.ConnStats)                       {}
+
+func (s *payloadStats) HandleRPC(_ context.Context, rs stats.RPCStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch rs := rs.(type) {
+	case *stats.InHeader:
+		s.compression = rs.Compression
+	case *stats.InPayload:
+		s.wire, s.size = rs.CompressedLength, rs.Length
+	}
+}
+
+func TestResponseCompression(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := config.DefaultConfig()
+	if !cfg.Compression.Enabled || !slices.Equal(cfg.Compression.Algorithms, []string{"zstd", "gzip"}) {
+		t.Errorf("Expected zstd then gzip compression by default, got %+v", cfg.Compression)
+	}
+	cfg.Compression.Algorithms = []string{"brotli"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an unknown algorithm to be rejected")
+	}
+	if _, err := compression.New(compression.Options{Algorithms: []string{"gzip", "gzip"}}); err == nil {
+		t.Error("Expected a repeated algorithm to be rejected")
+	}
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	var id string
+	for i := 0; i < 20; i++ {
+		created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+			Name: fmt.Sprintf("Compressed Arena %d", i), Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+		}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		id = created.GameDna.Id
+	}
+
+	serve := func(policy *compression.Policy) string {
+		t.Helper()
+		grpcServer := grpc.NewServer(
+			grpc.ChainUnaryInterceptor(api.UnaryErrorInterceptor, policy.UnaryInterceptor),
+			grpc.ChainStreamInterceptor(api.StreamErrorInterceptor, policy.StreamInterceptor),
+		)
+		pb.RegisterGameDNAServiceServer(grpcServer, svc)
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		return lis.Addr().String()
+	}
+	dial := func(addr string) (pb.GameDNAServiceClient, *payloadStats) {
+		t.Helper()
+		recorded := &payloadStats{}
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithStatsHandler(recorded))
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewGameDNAServiceClient(conn), recorded
+	}
+
+	// A large response goes out with the preferred algorithm the client
+	// accepts, though the request was uncompressed; a small one as it is.
+	policy, err := compression.New(compression.Options{MinSize: 1024})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	addr := serve(policy)
+	client, recorded := dial(addr)
+	if _, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 20}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if recorded.compression != "zstd" || recorded.wire >= recorded.size {
+		t.Errorf("Expected a smaller zstd list, got %q with %d of %d bytes", recorded.compression, recorded.wire, recorded.size)
+	}
+	if _, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id}); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if recorded.compression != "identity" || recorded.wire != recorded.size {
+		t.Errorf("Expected an uncompressed read under 1024 bytes, got %q with %d of %d bytes", recorded.compression, recorded.wire, recorded.size)
+	}
+	// Clients that compress their requests may get small responses plain too.
+	if _, err := client.GetGameDNA(ctx, &pb.GetGameDNARequest{Id: id}, grpc.UseCompressor("gzip")); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if recorded.compression != "identity" {
+		t.Errorf("Expected a small response uncompressed, got %q", recorded.compression)
+	}
+
+	// The configured order decides.
+	gzipOnly, _ := compression.New(compression.Options{MinSize: 1024, Algorithms: []string{"gzip"}})
+	client, recorded = dial(serve(gzipOnly))
+	if _, err := client.ListGameDNA(ctx, &pb.ListGameDNARequest{PageSize: 20}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if recorded.compression != "gzip" {
+		t.Errorf("Expected gzip, got %q", recorded.compression)
+	}
+
+	// REST responses follow Accept-Encoding.
+	gateway, err := api.NewRESTGateway(ctx, addr, "", api.GatewayOptions{Middleware: policy.Handler}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+	get := func(handler http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	plain := get(gateway.Handler(), "/api/v1/game-dna?pageSize=20", "")
+	if plain.Header().Get("Content-Encoding") != "" || plain.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected an uncompressed list varying by Accept-Encoding, got %v", plain.Header())
+	}
+	rec := get(gateway.Handler(), "/api/v1/game-dna?pageSize=20", "gzip, deflate")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip, got %v", rec.Header())
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Body is not gzip: %v", err)
+	}
+	if body, err := io.ReadAll(gz); err != nil || !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Errorf("Expected the gzip body to decode to the plain one: %v", err)
+	}
+	rec = get(gateway.Handler(), "/api/v1/game-dna?pageSize=20", "gzip;q=0.5, zstd")
+	if rec.Header().Get("Content-Encoding") != "zstd" || rec.Body.Len() >= plain.Body.Len() {
+		t.Fatalf("Expected a smaller zstd body, got %v with %d bytes", rec.Header(), rec.Body.Len())
+	}
+	dec, _ := zstd.NewReader(nil)
+	defer dec.Close()
+	if body, err := dec.DecodeAll(rec.Body.Bytes(), nil); err != nil || !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Errorf("Expected the zstd body to decode to the plain one: %v", err)
+	}
+	if rec := get(gateway.Handler(), "/api/v1/game-dna?pageSize=20", "zstd;q=0, gzip;q=0"); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected refused algorithms to be skipped, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec := get(gateway.Handler(), "/api/v1/game-dna/no-such-config", "gzip"); rec.Header().Get("Content-Encoding") != "" || rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a small error uncompressed, got %d %v", rec.Code, rec.Header())
+	}
+
+	// Archives pass through, and a flush compresses what follows.
+	big := bytes.Repeat([]byte("entropic "), 1000)
+	zipped := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(big)
+	}))
+	if rec := get(zipped, "/", "gzip"); rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != len(big) {
+		t.Errorf("Expected a zip to pass through, got %v", rec.Header())
+	}
+	streamed := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {}\n\n"))
+		w.(http.Flusher).Flush()
+	}))
+	if rec := get(streamed, "/", "gzip"); rec.Header().Get("Content-Encoding") != "gzip" || !rec.Flushed {
+		t.Errorf("Expected a flushed stream compressed, got %v", rec.Header())
+	}
+}
+
+// fakeBucket serves S3 object requests from memory, checking each is signed
+// for its payload.
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != fmt.Sprintf("%x", sum) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>`)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newFakeBucket(t *testing.T) (*archive.Bucket, *fakeBucket) {
+	t.Helper()
+	fake := &fakeBucket{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	bucket, err := archive.New(archive.Options{
+		Endpoint:        srv.URL,
+		Region:          "auto",
+		Bucket:          "dna",
+		Prefix:          "test/",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("archive.New failed: %v", err)
+	}
+	return bucket, fake
+}
+
+func TestArchiveBucket(t *testing.T) {
+	ctx := context.Background()
+	bucket, fake := newFakeBucket(t)
+
+	if err := bucket.Put(ctx, "versions/a b/1.json", []byte(`{"name":"Archived"}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := fake.objects["/dna/test/versions/a b/1.json"]; !ok {
+		t.Errorf("Expected the object under the bucket and prefix, got %v", fake.objects)
+	}
+	data, err := bucket.Get(ctx, "versions/a b/1.json")
+	if err != nil || string(data) != `{"name":"Archived"}` {
+		t.Errorf("Expected the object back, got %q, %v", data, err)
+	}
+	if _, err := bucket.Get(ctx, "versions/missing.json"); err == nil || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Errorf("Expected a missing object to fail with S3's code, got %v", err)
+	}
+	if err := bucket.Delete(ctx, "versions/a b/1.json"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := bucket.Delete(ctx, "versions/a b/1.json"); err != nil {
+		t.Errorf("Expected deleting a missing object to succeed, got %v", err)
+	}
+	if len(fake.objects) != 0 {
+		t.Errorf("Expected the object deleted, got %v", fake.objects)
+	}
+
+	for _, opts := range []archive.Options{
+		{Endpoint: "s3.amazonaws.com", Region: "us-east-1", Bucket: "dna", AccessKeyID: "k", SecretAccessKey: "s"},
+		{Endpoint: "https://s3.amazonaws.com", Region: "us-east-1", AccessKeyID: "k", SecretAccessKey: "s"},
+		{Endpoint: "https://s3.amazonaws.com", Region: "us-east-1", Bucket: "dna", AccessKeyID: "k"},
+	} {
+		if _, err := archive.New(opts); err == nil {
+			t.Errorf("Expected archive.New to reject %+v", opts)
+		}
+	}
+}
+
+func TestPostgresArchiveVersions(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	defer store.Close()
+	if err := storage.Migrate(ctx, store.DB()); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	bucket, fake := newFakeBucket(t)
+	store.SetArchive(bucket)
+
+	created, err := store.Create(ctx, &pb.GameDNA{Name: fmt.Sprintf("Archive %d", time.Now().UnixNano()), TargetFps: 30, CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer store.Delete(ctx, created.Id)
+	for _, fps := range []uint32{60, 90} {
+		update := models.Clone(created)
+		update.TargetFps = fps
+		if created, err = store.Update(ctx, update); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	// Other configs in the database may be archived too
+	if _, err := store.ArchiveVersions(ctx, storage.HistoryRetention{MaxVersions: 1}, 1000); err != nil {
+		t.Fatalf("ArchiveVersions failed: %v", err)
+	}
+	if _, ok := fake.objects["/dna/test/versions/"+created.Id+"/1.json"]; !ok {
+		t.Fatalf("Expected version 1 archived, got %d objects", len(fake.objects))
+	}
+	if _, ok := fake.objects["/dna/test/versions/"+created.Id+"/3.json"]; ok {
+		t.Error("Expected the newest version kept in the database")
+	}
+
+	v, err := store.GetVersion(ctx, created.Id, 1)
+	if err != nil || v.Data.GetTargetFps() != 30 || v.ArchiveKey == "" || v.Size == 0 {
+		t.Fatalf("Expected version 1 read back from the archive, got %+v, %v", v, err)
+	}
+	history, err := store.GetVersionHistory(ctx, created.Id)
+	if err != nil || len(history) != 3 || history[1].Data.GetTargetFps() != 60 {
+		t.Fatalf("Expected the whole history read back, got %v", err)
+	}
+	metadata, err := store.ListVersions(ctx, created.Id, storage.VersionPage{WithoutData: true})
+	if err != nil || len(metadata) != 3 || metadata[2].Size == 0 || metadata[2].Data != nil {
+		t.Fatalf("Expected metadata with the archived size, got %v", err)
+	}
+	rolled, err := store.RollbackToVersion(ctx, created.Id, 1, "bob")
+	if err != nil || rolled.TargetFps != 30 {
+		t.Fatalf("Expected a rollback to an archived version, got %v, %v", rolled, err)
+	}
+
+	store.SetArchive(nil)
+	if _, err := store.GetVersion(ctx, created.Id, 1); err == nil {
+		t.Error("Expected an archived version to fail without an archive")
+	}
+}
+
+func TestGatewayAPIDocuments(t *testing.T) {
+	gateway, err := api.NewRESTGateway(context.Background(), "127.0.0.1:1", "", api.GatewayOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var doc map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s is not JSON: %v", path, err)
+		}
+		return rec, doc
+	}
+
+	rec, doc := get("/openapi.json")
+	if rec.Code != http.StatusOK || doc["openapi"] != "3.1.0" {
+		t.Fatalf("Expected an OpenAPI 3.1 document, got %d %v", rec.Code, doc["openapi"])
+	}
+	paths := doc["paths"].(map[string]interface{})
+	getDNA, _ := paths["/api/v1/game-dna/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	if getDNA["operationId"] != "GetGameDNA" {
+		t.Errorf("Expected GET /api/v1/game-dna/{id} to be GetGameDNA, got %v", getDNA["operationId"])
+	}
+	param := getDNA["parameters"].([]interface{})[0].(map[string]interface{})
+	if param["name"] != "id" || param["in"] != "path" || param["required"] != true {
+		t.Errorf("Expected the id path parameter first, got %v", param)
+	}
+	create := paths["/api/v1/game-dna"].(map[string]interface{})["post"].(map[string]interface{})
+	body := create["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	if required, _ := body["required"].([]interface{}); len(required) != 1 || required[0] != "gameDna" {
+		t.Errorf("Expected CreateGameDNA's body to require gameDna, got %v", body["required"])
+	}
+	list := paths["/api/v1/game-dna"].(map[string]interface{})["get"].(map[string]interface{})
+	var pageSize map[string]interface{}
+	for _, p := range list["parameters"].([]interface{}) {
+		if p := p.(map[string]interface{}); p["name"] == "pageSize" {
+			pageSize = p["schema"].(map[string]interface{})
+		}
+	}
+	if pageSize == nil || pageSize["maximum"] != float64(1000) {
+		t.Errorf("Expected ListGameDNA's pageSize query parameter to be at most 1000, got %v", pageSize)
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	if _, ok := schemas["entropic.dna.v1.GameDNA"]; !ok {
+		t.Error("Expected a GameDNA component schema")
+	}
+
+	rec, doc = get("/schema/gamedna.json")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/schema+json" {
+		t.Fatalf("Expected a JSON Schema, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	props := doc["properties"].(map[string]interface{})
+	fps := props["targetFps"].(map[string]interface{})
+	if fps["type"] != "integer" || fps["default"] != float64(60) {
+		t.Errorf("Expected targetFps to be an integer defaulting to 60, got %v", fps)
+	}
+	if platforms := props["targetPlatforms"].(map[string]interface{}); platforms["type"] != "array" {
+		t.Errorf("Expected targetPlatforms to be an array, got %v", platforms)
+	}
+
+	req := httptest.NewRequest("GET", "/schema/gamedna.json", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	gateway.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected a matching ETag to be not modified, got %d", rec.Code)
+	}
+}
+
+func TestGatewayAPIExplorer(t *testing.T) {
+	docs := func(opts api.GatewayOptions) *httptest.ResponseRecorder {
+		gateway, err := api.NewRESTGateway(context.Background(), "127.0.0.1:1", "", opts, zap.NewNop())
+		if err != nil {
+			t.Fatalf("NewRESTGateway failed: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/docs", nil))
+		return rec
+	}
+
+	rec := docs(api.GatewayOptions{APIExplorer: true, APIExplorerAssets: "https://assets.example.com/swagger-ui/"})
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected the explorer page, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	page := rec.Body.String()
+	if !strings.Contains(page, `src="https://assets.example.com/swagger-ui/swagger-ui-bundle.js"`) {
+		t.Errorf("Expected the page to load Swagger UI from the assets URL, got %s", page)
+	}
+	if !strings.Contains(page, `url: "openapi.json"`) {
+		t.Errorf("Expected the page to load the gateway's OpenAPI document, got %s", page)
+	}
+
+	if rec := docs(api.GatewayOptions{}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no explorer when it is off, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{IdempotencyKeyTTL: time.Hour}, zap.NewNop())
+	withKey := func(key string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(api.IdempotencyKeyMetadata, key))
+	}
+	dna := func(name string) *pb.CreateGameDNARequest {
+		return &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Name: name, Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}}
+	}
+
+	first, err := svc.CreateGameDNA(withKey("create-1"), dna("Retried Game"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	retry, err := svc.CreateGameDNA(withKey("create-1"), dna("Retried Game"))
+	if err != nil {
+		t.Fatalf("Retried create failed: %v", err)
+	}
+	if retry.GameDna.Id != first.GameDna.Id {
+		t.Errorf("Expected the retry to replay config %s, got %s", first.GameDna.Id, retry.GameDna.Id)
+	}
+	if _, err := svc.CreateGameDNA(withKey("create-1"), dna("Other Game")); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a key reused with another body, got %v", err)
+	}
+	if _, err := svc.CreateGameDNA(withKey(strings.Repeat("k", 256)), dna("Retried Game")); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an overlong key, got %v", err)
+	}
+
+	// A failed call holds on to nothing, so the key can carry a fixed request.
+	if _, err := svc.CreateGameDNA(withKey("create-2"), &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{Genre: "FPS"}}); err == nil {
+		t.Fatal("Expected an invalid config to be rejected")
+	}
+	if _, err := svc.CreateGameDNA(withKey("create-2"), dna("Fixed Game")); err != nil {
+		t.Errorf("Expected a failed call's key to be reusable, got %v", err)
+	}
+
+	// Keys are kept apart per method.
+	clone, err := svc.CloneGameDNA(withKey("create-1"), &pb.CloneGameDNARequest{Id: first.GameDna.Id, NewName: "Clone"})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	again, err := svc.CloneGameDNA(withKey("create-1"), &pb.CloneGameDNARequest{Id: first.GameDna.Id, NewName: "Clone"})
+	if err != nil || again.GameDna.Id != clone.GameDna.Id {
+		t.Errorf("Expected the retried clone to replay %s, got %v %v", clone.GameDna.Id, again, err)
+	}
+
+	// Without a key every call runs.
+	if _, err := svc.CreateGameDNA(context.Background(), dna("Retried Game")); err != nil {
+		t.Fatalf("Create without a key failed: %v", err)
+	}
+	list, _, err := store.List(context.Background(), storage.ListFilters{}, storage.Pagination{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 4 {
+		t.Errorf("Expected 4 configs, got %d", len(list))
+	}
+
+	if n, err := store.PruneIdempotencyKeys(context.Background(), time.Now().Add(2*time.Hour)); err != nil || n != 3 {
+		t.Errorf("Expected 3 lapsed keys pruned, got %d %v", n, err)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	authn, err := auth.New([]auth.Key{{Name: "ci", KeySHA256: auth.HashKey("write-key"), Scopes: []string{auth.ScopeRead, auth.ScopeWrite}}}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	freeAddr := func() string {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer lis.Close()
+		return lis.Addr().String()
+	}
+	opts := server.Options{
+		GRPCAddr:   freeAddr(),
+		HTTPAddr:   freeAddr(),
+		Middleware: []string{"recovery", "request_id", "tracing", "logging", "metrics", "errors", "auth", "validation"},
+		Auth:       authn,
+	}
+	srv, err := server.New(svc, opts, logger)
+	if err != nil {
+		t.Fatalf("server.New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Shutdown(ctx)
+	conn, err := grpc.Dial(opts.GRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGameDNAServiceClient(conn)
+	withKey := metadata.AppendToOutgoingContext(ctx, auth.APIKeyMetadata, "write-key", api.RequestIDMetadata, "req-logged")
+
+	created, err := client.CreateGameDNA(withKey, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Logged", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateGameDNA failed: %v", err)
+	}
+	if _, err := client.GetGameDNA(withKey, &pb.GetGameDNARequest{Id: "no-such-config"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+
+	// Each call is one debug entry with the same fields; the config ID of a
+	// create comes from its response.
+	requests := logs.FilterMessage("gRPC request").All()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 request logs, got %d", len(requests))
+	}
+	for i, want := range []struct{ method, code, configID string }{
+		{"/entropic.dna.v1.GameDNAService/CreateGameDNA", "OK", created.GameDna.Id},
+		{"/entropic.dna.v1.GameDNAService/GetGameDNA", "NotFound", "no-such-config"},
+	} {
+		entry := requests[i]
+		fields := entry.ContextMap()
+		if entry.Level != zap.DebugLevel || fields["method"] != want.method || fields["code"] != want.code ||
+			fields["config_id"] != want.configID || fields["actor"] != "ci" || fields["request_id"] != "req-logged" {
+			t.Errorf("Unexpected request log %d: %v %v", i, entry.Level, fields)
+		}
+		if _, ok := fields["duration"]; !ok {
+			t.Errorf("Expected a duration in request log %d, got %v", i, fields)
+		}
+		if n, _ := fields["request_bytes"].(int64); n <= 0 {
+			t.Errorf("Expected the request size in request log %d, got %v", i, fields["request_bytes"])
+		}
+	}
+
+	// Handlers leave per-request logging to the middleware.
+	if n := logs.FilterLevelExact(zap.InfoLevel).FilterField(zap.String("request_id", "req-logged")).Len(); n != 0 {
+		t.Errorf("Expected no info-level request logs, got %d", n)
+	}
+}
+
+func TestActorFromAuth(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Attributed", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	ci := auth.WithPrincipal(ctx, &auth.Principal{Name: "ci", Scopes: []string{auth.ScopeWrite, auth.ScopePublish}})
+	editor := auth.WithPrincipal(ctx, &auth.Principal{Name: "editor-backend", Scopes: []string{auth.ScopeWrite, auth.ScopeActAs}})
+
+	// Without authentication the request names the actor, or "system" does.
+	cloned, err := svc.CloneGameDNA(ctx, &pb.CloneGameDNARequest{Id: id, NewName: "Unattributed"})
+	if err != nil || cloned.GameDna.CreatedBy != "system" {
+		t.Errorf("Expected an anonymous clone by system, got %v %v", cloned.GetGameDna().GetCreatedBy(), err)
+	}
+	cloned, err = svc.CloneGameDNA(ctx, &pb.CloneGameDNARequest{Id: id, NewName: "Named", Actor: "dana"})
+	if err != nil || cloned.GameDna.CreatedBy != "dana" {
+		t.Errorf("Expected a clone by dana, got %v %v", cloned.GetGameDna().GetCreatedBy(), err)
+	}
+
+	// With it, the caller is the actor, whatever the request says, unless it
+	// may act as users.
+	published, err := svc.PublishGameDNA(ci, &pb.PublishGameDNARequest{Id: id, Actor: "dana"})
+	if err != nil || published.GameDna.CreatedBy != "ci" {
+		t.Errorf("Expected a publish by ci, got %v %v", published.GetGameDna().GetCreatedBy(), err)
+	}
+	rolled, err := svc.RollbackToVersion(editor, &pb.RollbackToVersionRequest{ConfigId: id, VersionNum: 1, Actor: "dana"})
+	if err != nil || rolled.GameDna.CreatedBy != "dana" {
+		t.Errorf("Expected a rollback by dana, got %v %v", rolled.GetGameDna().GetCreatedBy(), err)
+	}
+	cloned, err = svc.CloneGameDNA(editor, &pb.CloneGameDNARequest{Id: id, NewName: "For no one"})
+	if err != nil || cloned.GameDna.CreatedBy != "editor-backend" {
+		t.Errorf("Expected a clone by editor-backend, got %v %v", cloned.GetGameDna().GetCreatedBy(), err)
+	}
+	history, err := store.GetVersionHistory(ctx, id)
+	if err != nil {
+		t.Fatalf("GetVersionHistory failed: %v", err)
+	}
+	var authors []string
+	for _, v := range history {
+		authors = append(authors, v.CreatedBy)
+	}
+	if !slices.Contains(authors, "ci") || !slices.Contains(authors, "dana") {
+		t.Errorf("Expected versions by ci and dana, got %v", authors)
+	}
+
+	snap, err := svc.CreateSnapshot(editor, &pb.CreateSnapshotRequest{Name: "release", Entries: []*pb.SnapshotEntry{{ConfigId: id}}, Actor: "dana"})
+	if err != nil || snap.Snapshot.CreatedBy != "dana" {
+		t.Errorf("Expected a snapshot by dana, got %v %v", snap.GetSnapshot().GetCreatedBy(), err)
+	}
+}
+
+// methodStore records the storage method named in each Read's context.
+type methodStore struct {
+	storage.Store
+	methods []string
+}
+
+func (s *methodStore) Read(ctx context.Context, id string) (*pb.GameDNA, error) {
+	s.methods = append(s.methods, storage.MethodFromContext(ctx))
+	return s.Store.Read(ctx, id)
+}
+
+func TestStorageMetrics(t *testing.T) {
+	ctx := context.Background()
+	inner := &methodStore{Store: storage.NewMemoryStore()}
+	store := metrics.WrapStore(inner)
+	defer store.Close()
+
+	count := func(key, name string) int64 {
+		if v := expvar.Get("storage").(*expvar.Map).Get(key).(*expvar.Map).Get(name); v != nil {
+			return v.(*expvar.Int).Value()
+		}
+		return 0
+	}
+	bucket := func(method, le string) int64 {
+		hist, _ := expvar.Get("storage").(*expvar.Map).Get("latency_ms").(*expvar.Map).Get(method).(*expvar.Map)
+		if hist == nil {
+			return 0
+		}
+		if v := hist.Get(le); v != nil {
+			return v.(*expvar.Int).Value()
+		}
+		return 0
+	}
+	reads, readErrs, lists, listsInf := count("calls", "Read"), count("errors", "Read"), count("calls", "List"), bucket("List", "le_inf")
+
+	created, err := store.Create(ctx, &pb.GameDNA{Name: "Timed", Version: "0.1.0", Genre: "FPS", Camera: "Perspective3D", TargetPlatforms: []string{"PC"}, TargetFps: 60, TimeScale: 1.0})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.Read(ctx, created.Id); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if _, err := store.Read(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := store.List(ctx, storage.ListFilters{}, storage.Pagination{}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	// The method is named for the statements the wrapped store runs.
+	if len(inner.methods) != 2 || inner.methods[0] != "Read" {
+		t.Errorf("Expected Read named in the context, got %v", inner.methods)
+	}
+	if got := count("calls", "Read") - reads; got != 2 {
+		t.Errorf("Expected two Read calls counted, got %d", got)
+	}
+	if got := count("errors", "Read") - readErrs; got != 1 {
+		t.Errorf("Expected one Read error counted, got %d", got)
+	}
+	if got := count("calls", "List") - lists; got != 1 {
+		t.Errorf("Expected one List call counted, got %d", got)
+	}
+	// Buckets are cumulative, so a fast call lands in the widest ones too.
+	if got := bucket("List", "le_inf") - listsInf; got != 1 {
+		t.Errorf("Expected one List call in le_inf, got %d", got)
+	}
+	if bucket("List", "le_5000") < bucket("List", "le_1") {
+		t.Errorf("Expected cumulative buckets, got le_1=%d le_5000=%d", bucket("List", "le_1"), bucket("List", "le_5000"))
+	}
+
+	// Statements run outside a named method are counted as other.
+	other, slow := count("queries", "other"), count("slow_queries", "List")
+	metrics.ObserveQuery(storage.QueryStats{Query: "SELECT 1", Rows: 1}, false)
+	metrics.ObserveQuery(storage.QueryStats{Method: "List", Query: "SELECT COUNT(*) FROM game_dna", Args: 2, Rows: 1, Duration: time.Second}, true)
+	if got := count("queries", "other") - other; got != 1 {
+		t.Errorf("Expected one other statement counted, got %d", got)
+	}
+	if got := count("slow_queries", "List") - slow; got != 1 {
+		t.Errorf("Expected one slow List statement counted, got %d", got)
+	}
+}
+
+func TestGatewayGRPCWeb(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{
+		GRPCWeb:        true,
+		GRPCWebOrigins: []string{"https://editor.example.com"},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	// call sends req to method, returning the response messages and the
+	// trailers of the last frame.
+	call := func(method string, req proto.Message, text bool) ([][]byte, string) {
+		t.Helper()
+		msg, err := proto.Marshal(req)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		body := append([]byte{0, 0, 0, 0, 0}, msg...)
+		binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+		contentType := "application/grpc-web+proto"
+		if text {
+			body = []byte(base64.StdEncoding.EncodeToString(body))
+			contentType = "application/grpc-web-text"
+		}
+		r := httptest.NewRequest("POST", "/entropic.dna.v1.GameDNAService/"+method, bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), contentType[:len("application/grpc-web")]) {
+			t.Fatalf("%s: expected a gRPC-Web response, got %d %q", method, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		data := rec.Body.Bytes()
+		if text {
+			// Each frame is encoded on its own, so padding may fall mid-body.
+			var decoded []byte
+			for rest := string(data); rest != ""; {
+				n := strings.IndexByte(rest, '=')
+				chunk := rest
+				if n >= 0 {
+					for n < len(rest) && rest[n] == '=' {
+						n++
+					}
+					chunk = rest[:n]
+				}
+				part, err := base64.StdEncoding.DecodeString(chunk)
+				if err != nil {
+					t.Fatalf("%s: invalid base64 body: %v", method, err)
+				}
+				decoded = append(decoded, part...)
+				rest = rest[len(chunk):]
+			}
+			data = decoded
+		}
+		var msgs [][]byte
+		for len(data) >= 5 {
+			n := binary.BigEndian.Uint32(data[1:5])
+			frame := data[5 : 5+n]
+			if data[0]&0x80 != 0 {
+				return msgs, string(frame)
+			}
+			msgs = append(msgs, frame)
+			data = data[5+n:]
+		}
+		t.Fatalf("%s: expected a trailer frame", method)
+		return nil, ""
+	}
+
+	msgs, trailer := call("CreateGameDNA", &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Web", Genre: "FPS", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}}, false)
+	if !strings.Contains(trailer, "grpc-status: 0\r\n") || len(msgs) != 1 {
+		t.Fatalf("Expected one message and OK, got %d messages and %q", len(msgs), trailer)
+	}
+	var created pb.GameDNAResponse
+	if err := proto.Unmarshal(msgs[0], &created); err != nil || created.GameDna.GetName() != "Web" {
+		t.Fatalf("Expected the created config, got %v %v", created.GameDna, err)
+	}
+
+	msgs, trailer = call("GetGameDNA", &pb.GetGameDNARequest{Id: created.GameDna.Id}, true)
+	if !strings.Contains(trailer, "grpc-status: 0\r\n") || len(msgs) != 1 {
+		t.Errorf("Expected a text-mode read to succeed, got %d messages and %q", len(msgs), trailer)
+	}
+	if _, trailer = call("GetGameDNA", &pb.GetGameDNARequest{Id: "no-such-config"}, false); strings.Contains(trailer, "grpc-status: 0\r\n") || !strings.Contains(trailer, "not found") {
+		t.Errorf("Expected the error in the trailers, got %q", trailer)
+	}
+	// Requests can't be streamed over gRPC-Web.
+	if _, trailer = call("ImportGameDNA", &pb.ImportGameDNARequest{}, false); !strings.Contains(trailer, fmt.Sprintf("grpc-status: %d\r\n", codes.Unimplemented)) {
+		t.Errorf("Expected Unimplemented for a client stream, got %q", trailer)
+	}
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("OPTIONS", "/entropic.dna.v1.GameDNAService/GetGameDNA", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,x-api-key")
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, r)
+		return rec
+	}
+	rec := preflight("https://editor.example.com")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://editor.example.com" ||
+		!strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "x-api-key") {
+		t.Errorf("Expected the allowed origin's preflight to pass, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := preflight("https://elsewhere.example.com"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected another origin to be refused, got %d", rec.Code)
+	}
+}
+
+func TestGRPCWebForwardedFor(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	limiter, err := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{Rate: 0.01, Burst: 1}}, nil)
+	if err != nil {
+		t.Fatalf("ratelimit.New failed: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor))
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop()))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{GRPCWeb: true}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	// Each call claims another address, but comes from the same one.
+	call := func(forwardedFor string) string {
+		msg, _ := proto.Marshal(&pb.GetSchemaRequest{})
+		body := append([]byte{0, 0, 0, 0, 0}, msg...)
+		binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+		r := httptest.NewRequest("POST", "/entropic.dna.v1.GameDNAService/GetSchema", bytes.NewReader(body))
+		r.RemoteAddr = "198.51.100.7:4000"
+		r.Header.Set("Content-Type", "application/grpc-web+proto")
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		r.Header.Set("X-Real-IP", forwardedFor)
+		rec := httptest.NewRecorder()
+		gateway.Handler().ServeHTTP(rec, r)
+		return rec.Body.String()
+	}
+	if trailer := call("203.0.113.1"); !strings.Contains(trailer, "grpc-status: 0\r\n") {
+		t.Fatalf("Expected the first call to pass, got %q", trailer)
+	}
+	if trailer := call("203.0.113.2"); !strings.Contains(trailer, fmt.Sprintf("grpc-status: %d\r\n", codes.ResourceExhausted)) {
+		t.Errorf("Expected a forged X-Forwarded-For to be limited by the real address, got %q", trailer)
+	}
+}
+
+// editingStore runs edit, once, just before a publish reaches the store, as
+// an update landing between validation and the lock would.
+type editingStore struct {
+	storage.Store
+	edit func()
+}
+
+func (s *editingStore) PublishVersion(ctx context.Context, configID string, actor string, expectedChecksum string, checksum string, report *pb.ValidationResponse) (*pb.GameDNA, error) {
+	if edit := s.edit; edit != nil {
+		s.edit = nil
+		edit()
+	}
+	return s.Store.PublishVersion(ctx, configID, actor, expectedChecksum, checksum, report)
+}
+
+func TestPublishRefusesConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemoryStore()
+	defer memory.Close()
+	store := &editingStore{Store: memory}
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+		Name: "Racy", Genre: "FPS", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+
+	var edited *pb.GameDNA
+	store.edit = func() {
+		changed := models.Clone(created.GameDna)
+		changed.TargetFps = 30
+		resp, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{Id: id, GameDna: changed})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		edited = resp.GameDna
+	}
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected a publish overtaken by an update to be Aborted, got %v", err)
+	}
+	stored, _ := memory.Read(ctx, id)
+	if stored.IsLocked || stored.TargetFps != 30 || stored.Checksum != edited.Checksum {
+		t.Errorf("Expected the update to stand unpublished, got locked %v fps %d checksum %q", stored.IsLocked, stored.TargetFps, stored.Checksum)
+	}
+	if _, err := memory.PublishVersion(ctx, id, "test", created.GameDna.Checksum, "", nil); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected a stale expected checksum to be ErrConflict, got %v", err)
+	}
+
+	published, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if !published.GameDna.IsLocked || published.GameDna.TargetFps != 30 || published.Checksum != edited.Checksum {
+		t.Errorf("Expected the retry to lock the updated content, got %v", published.GameDna)
+	}
+}
+
+func TestAccessMiddleware(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.Server.Middleware = slices.DeleteFunc(slices.Clone(cfg.Server.Middleware), func(name string) bool { return name == "auth" })
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected auth to be optional while it is disabled, got %v", err)
+	}
+	cfg.Auth.Enabled = true
+	cfg.Auth.APIKeys = []config.APIKeyConfig{{Name: "ci", KeySHA256: auth.HashKey("ci-key"), Scopes: []string{auth.ScopeRead}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected enabled auth without the auth middleware to be rejected")
+	}
+	cfg = config.DefaultConfig()
+	cfg.Server.Middleware = []string{"recovery", "errors"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a middleware list without validation to be rejected")
+	}
+
+	authn, err := auth.New([]auth.Key{{Name: "ci", KeySHA256: auth.HashKey("ci-key"), Scopes: []string{auth.ScopeRead}}}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	limiter, err := ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{Rate: 0.01, Burst: 1}}, authn)
+	if err != nil {
+		t.Fatalf("ratelimit.New failed: %v", err)
+	}
+	registry := server.DefaultRegistry(zap.NewNop(), server.Options{Auth: authn, RateLimit: limiter})
+	chain, err := registry.Chain([]string{"rate_limit", "auth"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+
+	// The events endpoint answers without the gRPC server, so the chain
+	// guards it directly; other REST routes are left to the gRPC server.
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	get := func(handler http.Handler, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+		req.RemoteAddr = "198.51.100.9:4000"
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := get(chain.Handler(ok), ""); code != http.StatusOK {
+		t.Errorf("Expected gateway routes to be left to the gRPC server, got %d", code)
+	}
+	events := chain.EventsHandler(ok)
+	if code := get(events, ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected events without credentials to be 401, got %d", code)
+	}
+	if code := get(events, "ci-key"); code != http.StatusOK {
+		t.Errorf("Expected events with a read key to be served, got %d", code)
+	}
+	if code := get(events, "ci-key"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected events past the burst to be 429, got %d", code)
+	}
+
+	// Only named middleware runs: auth guards the gRPC server once listed,
+	// and configuring it without listing it is an error.
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+	call := func(middleware []string, authn *auth.Authenticator) error {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		addr := lis.Addr().String()
+		lis.Close()
+		srv, err := server.New(svc, server.Options{GRPCAddr: addr, HTTPAddr: "127.0.0.1:0", Middleware: middleware, Auth: authn}, zap.NewNop())
+		if err != nil {
+			t.Fatalf("server.New failed: %v", err)
+		}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer srv.Shutdown(ctx)
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		_, err = pb.NewGameDNAServiceClient(conn).GetSchema(ctx, &pb.GetSchemaRequest{})
+		return err
+	}
+	if err := call([]string{"errors", "validation"}, nil); err != nil {
+		t.Errorf("Expected a call to pass without the auth middleware, got %v", err)
+	}
+	if _, err := server.New(svc, server.Options{GRPCAddr: "127.0.0.1:0", HTTPAddr: "127.0.0.1:0", Middleware: []string{"errors", "validation"}, Auth: authn}, zap.NewNop()); err == nil {
+		t.Error("Expected auth without the auth middleware to be rejected")
+	}
+	if err := call([]string{"errors", "auth", "validation"}, authn); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a call without credentials to be Unauthenticated, got %v", err)
+	}
+}
+
+func TestBulkUpdateField(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	client := serveGameDNAWith(t, store, api.ServerOptions{})
+
+	racer := func(name string, timeScale float32) *pb.GameDNA {
+		return &pb.GameDNA{Name: name, Genre: "Racing", TargetFps: 30, TimeScale: timeScale, TargetPlatforms: []string{"PC"}}
+	}
+	fast, err := store.Create(ctx, racer("Fast", 1))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	shipped, err := store.Create(ctx, racer("Shipped", 1))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.PublishVersion(ctx, shipped.Id, "test", shipped.Checksum, "", nil); err != nil {
+		t.Fatalf("PublishVersion failed: %v", err)
+	}
+	// Saved before its time scale was checked, so no change makes it valid
+	broken, err := store.Create(ctx, racer("Broken", 0))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.Create(ctx, &pb.GameDNA{Name: "Puzzler", Genre: "Puzzle", TargetFps: 30, TimeScale: 1, TargetPlatforms: []string{"PC"}}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	bulk := func(paths []string, dryRun bool) (map[string]*pb.BulkUpdateFieldResult, error) {
+		stream, err := client.BulkUpdateField(ctx, &pb.BulkUpdateFieldRequest{
+			Genre:      "Racing",
+			Values:     &pb.GameDNA{TargetFps: 60, Checksum: "forged", IsLocked: true},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+			DryRun:     dryRun,
+		})
+		if err != nil {
+			return nil, err
+		}
+		results := make(map[string]*pb.BulkUpdateFieldResult)
+		for {
+			result, err := stream.Recv()
+			if err == io.EOF {
+				return results, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			results[result.Name] = result
+		}
+	}
+
+	for _, paths := range [][]string{nil, {"checksum"}, {"target_fps", "is_locked"}, {"created_by"}, {"no_such_field"}} {
+		if _, err := bulk(paths, false); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected update mask %v to be InvalidArgument, got %v", paths, err)
+		}
+	}
+
+	statuses := func(results map[string]*pb.BulkUpdateFieldResult) map[string]string {
+		out := make(map[string]string)
+		for name, result := range results {
+			out[name] = result.Status
+		}
+		return out
+	}
+	results, err := bulk([]string{"target_fps"}, true)
+	if err != nil {
+		t.Fatalf("Dry-run BulkUpdateField failed: %v", err)
+	}
+	want := map[string]string{"Fast": "would_update", "Shipped": "locked", "Broken": "invalid"}
+	if got := statuses(results); !maps.Equal(got, want) {
+		t.Errorf("Expected dry-run statuses %v, got %v", want, got)
+	}
+	if v := results["Broken"].Validation; v.GetIsValid() || len(v.GetErrors()) == 0 || v.Errors[0].Field != "time_scale" {
+		t.Errorf("Expected the invalid result to carry its validation errors, got %v", v)
+	}
+	stored, _ := store.Read(ctx, fast.Id)
+	versions, _ := store.GetVersionHistory(ctx, fast.Id)
+	if stored.TargetFps != 30 || len(versions) != 1 {
+		t.Errorf("Expected a dry run to write nothing, got fps %d and %d versions", stored.TargetFps, len(versions))
+	}
+
+	results, err = bulk([]string{"target_fps"}, false)
+	if err != nil {
+		t.Fatalf("BulkUpdateField failed: %v", err)
+	}
+	want = map[string]string{"Fast": "updated", "Shipped": "locked", "Broken": "invalid"}
+	if got := statuses(results); !maps.Equal(got, want) {
+		t.Errorf("Expected statuses %v, got %v", want, got)
+	}
+	stored, _ = store.Read(ctx, fast.Id)
+	if stored.TargetFps != 60 || stored.Checksum == "" || stored.Checksum == "forged" || stored.IsLocked {
+		t.Errorf("Expected only the masked field to change, got fps %d checksum %q locked %v", stored.TargetFps, stored.Checksum, stored.IsLocked)
+	}
+	for _, id := range []string{shipped.Id, broken.Id} {
+		if skipped, _ := store.Read(ctx, id); skipped.TargetFps != 30 {
+			t.Errorf("Expected %s to be skipped, got fps %d", skipped.Name, skipped.TargetFps)
+		}
+	}
+
+	results, err = bulk([]string{"target_fps"}, false)
+	if err != nil {
+		t.Fatalf("BulkUpdateField failed: %v", err)
+	}
+	if got := results["Fast"].GetStatus(); got != "unchanged" {
+		t.Errorf("Expected a repeated update to be unchanged, got %q", got)
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	plans := map[string]struct {
+		plan    string
+		seqScan bool
+	}{
+		"index scan": {`Limit  (cost=0.15..1.02 rows=10 width=32)
+  ->  Index Scan using idx_game_dna_configs_created_at on game_dna_configs  (cost=0.15..52.45 rows=600 width=48)`, false},
+		"bitmap scan": {`Limit  (cost=12.09..12.11 rows=10 width=48)
+  ->  Sort  (cost=12.09..12.11 rows=6 width=48)
+        Sort Key: created_at DESC, id
+        ->  Bitmap Heap Scan on game_dna_configs  (cost=8.05..12.01 rows=6 width=48)
+              Recheck Cond: (tags @> '{pvp}'::text[])
+              ->  Bitmap Index Scan on idx_game_dna_configs_tags  (cost=0.00..8.05 rows=6 width=0)`, false},
+		"seq scan": {`Limit  (cost=10000000025.57..10000000025.60 rows=10 width=48)
+  ->  Sort  (cost=10000000025.57..10000000025.59 rows=6 width=48)
+        ->  Seq Scan on game_dna_configs  (cost=10000000000.00..10000000025.50 rows=6 width=48)
+              Filter: ((data ->> 'genre'::text) = 'FPS'::text)`, true},
+		"parallel seq scan": {`Gather  (cost=10000001000.00..10000011000.00 rows=10 width=48)
+  ->  Parallel Seq Scan on game_dna_configs c  (cost=10000000000.00..10000010000.00 rows=4 width=48)`, true},
+		"schema qualified": {`Seq Scan on public.game_dna_configs  (cost=10000000000.00..10000000025.50 rows=6 width=48)`, true},
+		"other table":      {`Seq Scan on game_dna_configs_archive  (cost=10000000000.00..10000000025.50 rows=6 width=48)`, false},
+		"empty":            {"", false},
+	}
+	for name, tc := range plans {
+		check := storage.ParsePlan("list", tc.plan)
+		if check.Name != "list" || check.Plan != tc.plan || check.SeqScan != tc.seqScan {
+			t.Errorf("%s: expected a sequential scan %v, got %+v", name, tc.seqScan, check)
+		}
+	}
+}
+
+// TestQueryPlans needs Postgres: the check explains queries and reads index
+// statistics, which the in-memory store doesn't have.
+func TestQueryPlans(t *testing.T) {
+	ctx := context.Background()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	defer store.Close()
+	if err := storage.Migrate(ctx, store.DB()); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	checks, err := store.CheckQueryPlans(ctx)
+	if err != nil {
+		t.Fatalf("CheckQueryPlans failed: %v", err)
+	}
+	if len(checks) == 0 {
+		t.Fatal("Expected the hot queries to be checked")
+	}
+	for _, check := range checks {
+		if check.SeqScan {
+			t.Errorf("Expected %s to use an index, got plan:\n%s", check.Name, check.Plan)
+		}
+	}
+
+	stats, err := store.IndexStats(ctx)
+	if err != nil {
+		t.Fatalf("IndexStats failed: %v", err)
+	}
+	found := false
+	for _, stat := range stats {
+		if !strings.HasPrefix(stat.Table, "game_dna") {
+			t.Errorf("Expected only config table indexes, got %s on %s", stat.Index, stat.Table)
+		}
+		if stat.Table == "game_dna_configs" && stat.SizeBytes > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the config table's indexes in the stats, got %v", stats)
+	}
+}
+
+func TestSheetReadLimits(t *testing.T) {
+	xlsx := func(sheetData string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("xl/worksheets/sheet1.xml")
+		if err != nil {
+			t.Fatalf("zip create failed: %v", err)
+		}
+		w.Write([]byte("<worksheet><sheetData>" + sheetData + "</sheetData></worksheet>"))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	if _, err := sheets.Read("", xlsx(`<row r="1"><c r="XFD1"><v>1</v></c></row>`), 0); err != nil {
+		t.Errorf("Expected column XFD to be readable, got %v", err)
+	}
+	for _, ref := range []string{"XFE1", "ZZZZZZZZ1", "ZZZZZZZZZZZZZZZZZZZZ1"} {
+		if _, err := sheets.Read("", xlsx(`<row r="1"><c r="`+ref+`"><v>1</v></c></row>`), 0); err == nil {
+			t.Errorf("Expected cell %s to be rejected", ref)
+		}
+	}
+
+	rows := strings.Repeat(`<row><c><v>1</v></c></row>`, 4)
+	if got, err := sheets.Read("", xlsx(rows), 4); err != nil || len(got) != 4 {
+		t.Errorf("Expected 4 XLSX rows within the limit, got %d rows, %v", len(got), err)
+	}
+	if _, err := sheets.Read("", xlsx(rows), 3); !errors.Is(err, sheets.ErrTooManyRows) {
+		t.Errorf("Expected ErrTooManyRows for XLSX, got %v", err)
+	}
+	csvData := []byte(strings.Repeat("a,b\n", 4))
+	if got, err := sheets.Read("", csvData, 4); err != nil || len(got) != 4 {
+		t.Errorf("Expected 4 CSV rows within the limit, got %d rows, %v", len(got), err)
+	}
+	if _, err := sheets.Read("", csvData, 3); !errors.Is(err, sheets.ErrTooManyRows) {
+		t.Errorf("Expected ErrTooManyRows for CSV, got %v", err)
+	}
+}
+
+func TestSlugBackfillMigration(t *testing.T) {
+	ctx := context.Background()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	defer store.Close()
+	migration, err := os.ReadFile(filepath.Join("..", "internal", "storage", "migrations", "0004_slugs.sql"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	up, _, _ := strings.Cut(string(migration), "-- +migrate Down")
+
+	// Run the migration against a pre-slug table in a scratch schema, on one
+	// connection so the search path sticks.
+	conn, err := store.DB().Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	defer conn.Close()
+	schema := fmt.Sprintf("slug_backfill_%d", time.Now().UnixNano())
+	for _, stmt := range []string{
+		`CREATE SCHEMA ` + schema,
+		`SET search_path TO ` + schema,
+		`CREATE TABLE game_dna_configs (id UUID PRIMARY KEY, name VARCHAR(255) NOT NULL, data JSONB NOT NULL, created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW())`,
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	defer conn.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`)
+	defer conn.ExecContext(ctx, `SET search_path TO DEFAULT`)
+
+	configs := []struct{ id, name, want string }{
+		{"00000000-0000-0000-0000-000000000001", "Arena", "arena"},
+		{"00000000-0000-0000-0000-000000000002", "Arena", "arena-2"},
+		{"00000000-0000-0000-0000-000000000003", "Arena 2", "arena-2-2"},
+	}
+	for i, c := range configs {
+		_, err := conn.ExecContext(ctx, `INSERT INTO game_dna_configs (id, name, data, created_at) VALUES ($1, $2, '{}', $3)`,
+			c.id, c.name, time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, up); err != nil {
+		t.Fatalf("Slug migration failed: %v", err)
+	}
+	for _, c := range configs {
+		var slug, dataSlug string
+		if err := conn.QueryRowContext(ctx, `SELECT slug, data->>'slug' FROM game_dna_configs WHERE id = $1`, c.id).Scan(&slug, &dataSlug); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if slug != c.want || dataSlug != c.want {
+			t.Errorf("Expected %q to get slug %s, got %s (data %s)", c.name, c.want, slug, dataSlug)
+		}
+	}
+}
+
+func TestMetricsEndpointAuth(t *testing.T) {
+	authn, err := auth.New([]auth.Key{
+		{Name: "dashboard", KeySHA256: auth.HashKey("read-key"), Scopes: []string{auth.ScopeRead}},
+		{Name: "ops", KeySHA256: auth.HashKey("admin-key"), Scopes: []string{auth.ScopeAdmin}},
+	}, auth.JWT{})
+	if err != nil {
+		t.Fatalf("auth.New failed: %v", err)
+	}
+	chain, err := server.DefaultRegistry(zap.NewNop(), server.Options{Auth: authn}).Chain([]string{"recovery", "auth"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+	vars := chain.MetricsHandler(expvar.Handler())
+	for key, want := range map[string]int{
+		"":          http.StatusUnauthorized,
+		"read-key":  http.StatusForbidden,
+		"admin-key": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, metrics.Path, nil)
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		vars.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Expected %d for %s with key %q, got %d", want, metrics.Path, key, rec.Code)
+		}
+	}
+}
+
+// updateGolden rewrites the golden API fixtures instead of comparing against
+// them: go test ./tests -run TestGoldenAPI -update
+var updateGolden = flag.Bool("update", false, "rewrite the golden API fixtures in testdata/golden")
+
+// goldenDir holds one fixture per RPC, named after it.
+const goldenDir = "testdata/golden"
+
+// goldenExempt lists the RPCs without a golden fixture, and why.
+var goldenExempt = map[string]string{
+	"WatchGameDNA": "gRPC-only server stream with no REST mapping; covered by TestWatchGameDNA",
+}
+
+var (
+	goldenUUID      = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	goldenTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// goldenExchange is one recorded REST call, canonicalised.
+type goldenExchange struct {
+	Request  goldenRequest  `json:"request"`
+	Response goldenResponse `json:"response"`
+}
+
+type goldenRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+type goldenResponse struct {
+	Status int         `json:"status"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// goldenAPI drives the REST gateway and records every call under its RPC.
+// IDs and timestamps are replaced with stable placeholders, and JSON is
+// re-encoded with sorted keys, so fixtures only change when the wire format
+// does.
+type goldenAPI struct {
+	t       *testing.T
+	handler http.Handler
+	// authorization, when set, is sent as the Authorization header.
+	authorization string
+	ids           map[string]string
+	calls         int
+	exchanges     map[string][]goldenExchange
+}
+
+// call sends a request and records it as an exchange of rpc. body is sent as
+// JSON, or as is when it's a string. The decoded response is returned so the
+// scenario can pick IDs out of it.
+func (g *goldenAPI) call(rpc, method, path string, body interface{}) map[string]interface{} {
+	g.t.Helper()
+	reqBody, status, respBody := g.send(method, path, body)
+	g.exchanges[rpc] = append(g.exchanges[rpc], goldenExchange{
+		Request:  goldenRequest{Method: method, Path: g.canonical(path), Body: g.canonicalBody(reqBody)},
+		Response: goldenResponse{Status: status, Body: g.canonicalBody(respBody)},
+	})
+	var decoded map[string]interface{}
+	json.Unmarshal(respBody, &decoded)
+	return decoded
+}
+
+// send makes a request without recording it.
+func (g *goldenAPI) send(method, path string, body interface{}) ([]byte, int, []byte) {
+	g.t.Helper()
+	var reqBody []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reqBody = []byte(b)
+	default:
+		var err error
+		if reqBody, err = json.Marshal(b); err != nil {
+			g.t.Fatalf("Failed to encode %s %s body: %v", method, path, err)
+		}
+	}
+	// Request IDs are echoed in errors and journaled, so they're fixed too.
+	g.calls++
+	req := httptest.NewRequest(method, path, bytes.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.RequestIDHeader, fmt.Sprintf("golden-%03d", g.calls))
+	if g.authorization != "" {
+		req.Header.Set("Authorization", g.authorization)
+	}
+	rec := httptest.NewRecorder()
+	g.handler.ServeHTTP(rec, req)
+	return reqBody, rec.Code, rec.Body.Bytes()
+}
+
+// canonical replaces IDs, numbered in order of first appearance, and
+// timestamps in s.
+func (g *goldenAPI) canonical(s string) string {
+	s = goldenUUID.ReplaceAllStringFunc(s, func(id string) string {
+		if _, ok := g.ids[id]; !ok {
+			g.ids[id] = fmt.Sprintf("<id-%d>", len(g.ids)+1)
+		}
+		return g.ids[id]
+	})
+	return goldenTimestamp.ReplaceAllString(s, "<timestamp>")
+}
+
+// canonicalBody returns body as decoded JSON, or as lines of JSON for NDJSON
+// streams, or as a string when it's neither.
+func (g *goldenAPI) canonicalBody(body []byte) interface{} {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	text := g.canonical(string(body))
+	if v, ok := goldenDecode(text); ok {
+		return v
+	}
+	var lines []interface{}
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		v, ok := goldenDecode(line)
+		if !ok {
+			return text
+		}
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func goldenDecode(text string) (interface{}, bool) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil || dec.More() {
+		return nil, false
+	}
+	return goldenRedact(v), true
+}
+
+// goldenRedactKeys name fields whose values differ on every run.
+var goldenRedactKeys = map[string]bool{
+	"secret":      true,
+	"durationMs":  true,
+	"servingData": true,
+	"traceId":     true,
+	"spanId":      true,
+	// Checksum migrations hash server fields too.
+	"computed": true,
+}
+
+func goldenRedact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// The checksum of a config with a parent hashes the parent's ID
+		if parent, _ := v["parentId"].(string); parent != "" && v["checksum"] != nil {
+			v["checksum"] = "<redacted>"
+		}
+		for k, field := range v {
+			if goldenRedactKeys[k] {
+				v[k] = "<redacted>"
+				continue
+			}
+			v[k] = goldenRedact(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = goldenRedact(v[i])
+		}
+	}
+	return v
+}
+
+// goldenString digs a string out of a decoded response.
+func goldenString(v map[string]interface{}, path ...string) string {
+	var cur interface{} = v
+	for _, key := range path {
+		m, _ := cur.(map[string]interface{})
+		cur = m[key]
+	}
+	s, _ := cur.(string)
+	return s
+}
+
+// check compares the recorded exchanges with the fixtures, or rewrites them
+// with -update, and checks every RPC has a fixture or an exemption.
+func (g *goldenAPI) check() {
+	g.t.Helper()
+	service := pb.File_entropic_dna_v1_service_proto.Services().Get(0)
+	methods := map[string]bool{}
+	for i := 0; i < service.Methods().Len(); i++ {
+		name := string(service.Methods().Get(i).Name())
+		methods[name] = true
+		_, recorded := g.exchanges[name]
+		reason, exempt := goldenExempt[name]
+		switch {
+		case recorded && exempt:
+			g.t.Errorf("%s is exempt from golden fixtures (%s) but was recorded", name, reason)
+		case !recorded && !exempt:
+			g.t.Errorf("%s has no golden fixture: add it to the TestGoldenAPI scenario, or to goldenExempt with a reason", name)
+		}
+	}
+
+	if *updateGolden {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			g.t.Fatalf("Failed to clear %s: %v", goldenDir, err)
+		}
+		if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+			g.t.Fatalf("Failed to create %s: %v", goldenDir, err)
+		}
+	}
+	for rpc, exchanges := range g.exchanges {
+		if !methods[rpc] {
+			g.t.Errorf("Recorded exchanges for unknown RPC %s", rpc)
+			continue
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exchanges); err != nil {
+			g.t.Fatalf("Failed to encode %s fixture: %v", rpc, err)
+		}
+		path := filepath.Join(goldenDir, rpc+".json")
+		if *updateGolden {
+			if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				g.t.Fatalf("Failed to write %s: %v", path, err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			g.t.Errorf("Missing golden fixture %s (run with -update to create it): %v", path, err)
+			continue
+		}
+		if !bytes.Equal(want, buf.Bytes()) {
+			g.t.Errorf("%s wire format changed; if intended, rerun with -update and review the fixture diff:\n%s", rpc, goldenFirstDiff(string(want), buf.String()))
+		}
+	}
+	if !*updateGolden {
+		files, _ := filepath.Glob(filepath.Join(goldenDir, "*.json"))
+		for _, file := range files {
+			if _, ok := g.exchanges[strings.TrimSuffix(filepath.Base(file), ".json")]; !ok {
+				g.t.Errorf("Stale golden fixture %s (run with -update to remove it)", file)
+			}
+		}
+	}
+}
+
+// goldenFirstDiff describes the first line where want and got differ.
+func goldenFirstDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return "no difference"
+}
+
+// goldenDNA is a config that validates cleanly, as a REST body.
+func goldenDNA(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            name,
+		"genre":           "FPS",
+		"camera":          "Perspective3D",
+		"tone":            "Realistic",
+		"worldScale":      "MediumLevel",
+		"targetPlatforms": []string{"PC", "Console"},
+		"physicsProfile":  "SemiRealistic",
+		"maxPlayers":      16,
+		"isCompetitive":   true,
+		"difficulty":      "Medium",
+		"monetization":    "PremiumBuy",
+		"targetAudience":  "Teens and adults",
+		"esrbRating":      "M",
+		"targetFps":       120,
+		"maxDrawDistance": 1500,
+		"maxEntities":     2000,
+		"maxNpcCount":     50,
+		"timeScale":       1,
+		"tags":            []string{"golden"},
+	}
+}
+
+// TestGoldenAPI calls every RPC through the REST gateway and compares the
+// canonicalised requests and responses with testdata/golden, so wire-visible
+// changes show up as fixture diffs in review.
+func TestGoldenAPI(t *testing.T) {
+	ctx := context.Background()
+	journal := tracing.NewJournal(500)
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	tokenAuth := tokens.NewAuthenticator(store, time.Minute)
+	registry := server.DefaultRegistry(zap.NewNop(), server.Options{Journal: journal, Tokens: tokenAuth})
+	chain, err := registry.Chain([]string{"recovery", "request_id", "tracing", "errors", "tokens", "validation"})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+	grpcServer := grpc.NewServer(chain.ServerOptions()...)
+	pb.RegisterGameDNAServiceServer(grpcServer, api.NewGameDNAServiceServer(eventsource.NewStore(store, store, zap.NewNop()), rust, api.ServerOptions{
+		EventLog:  store,
+		Editing:   editing.NewRegistry(time.Minute),
+		Faults:    faults.NewInjector(),
+		Journal:   journal,
+		Checksums: integrity.NewChecksums(store, rust.CalculateChecksum, zap.NewNop()),
+		Tokens:    tokenAuth,
+		Webhooks:  webhooks.NewSender(webhooks.Options{Timeout: time.Second, MaxAttempts: 3, Retention: time.Hour}),
+		Reload:    func() ([]string, error) { return []string{"logging.level"}, nil },
+	}, zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	gateway, err := api.NewRESTGateway(ctx, lis.Addr().String(), "", api.GatewayOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRESTGateway failed: %v", err)
+	}
+	defer gateway.Shutdown(ctx)
+
+	g := &goldenAPI{t: t, handler: gateway.Handler(), ids: map[string]string{}, exchanges: map[string][]goldenExchange{}}
+	const missing = "00000000-0000-0000-0000-000000000000"
+
+	g.call("GetSchema", "GET", "/api/v1/schema", nil)
+	g.call("ListFieldEnums", "GET", "/api/v1/schema/enums", nil)
+
+	// Lifecycle of one config.
+	created := g.call("CreateGameDNA", "POST", "/api/v1/game-dna", map[string]interface{}{"gameDna": goldenDNA("Golden Arena")})
+	id := goldenString(created, "gameDna", "id")
+	slug := goldenString(created, "gameDna", "slug")
+	if id == "" {
+		t.Fatalf("CreateGameDNA returned no ID: %v", created)
+	}
+	invalid := goldenDNA("Golden Invalid")
+	invalid["targetFps"] = 5000
+	g.call("CreateGameDNA", "POST", "/api/v1/game-dna", map[string]interface{}{"gameDna": invalid})
+	migration := g.call("StartChecksumMigration", "POST", "/api/v1/admin/checksum-migrations", map[string]interface{}{"dryRun": true, "actor": "golden"})
+	migrationPath := "/api/v1/admin/checksum-migrations/" + goldenString(migration, "migration", "id")
+	// The migration runs in the background; record it once it's finished.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		_, _, body := g.send("GET", migrationPath, nil)
+		var got map[string]interface{}
+		json.Unmarshal(body, &got)
+		if state := goldenString(got, "migration", "state"); state != "running" && state != "pending" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Checksum migration did not finish: %s", body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	g.call("GetChecksumMigration", "GET", migrationPath, nil)
+	g.call("CancelChecksumMigration", "POST", migrationPath+"/cancel", map[string]interface{}{})
+	g.call("GetGameDNA", "GET", "/api/v1/game-dna/"+id, nil)
+	g.call("GetGameDNA", "GET", "/api/v1/game-dna/"+missing, nil)
+	g.call("ListGameDNA", "GET", "/api/v1/game-dna?page_size=5&tags=golden", nil)
+	g.call("SearchGameDNA", "GET", "/api/v1/game-dna/search?q=golden+arena&where=target_fps%3E%3D60&where=max_players+between+8+and+32", nil)
+	g.call("SearchGameDNA", "GET", "/api/v1/game-dna/search?where=player_count%3E1", nil)
+	updated := goldenDNA("Golden Arena")
+	updated["maxPlayers"] = 24
+	g.call("UpdateGameDNA", "PUT", "/api/v1/game-dna/"+id, map[string]interface{}{"gameDna": updated})
+	g.call("UpdateGameDNA", "PUT", "/api/v1/game-dna/"+id, map[string]interface{}{
+		"gameDna":    map[string]interface{}{"targetFps": 90},
+		"updateMask": "targetFps",
+	})
+	g.call("CreateGameDNA", "POST", "/api/v1/game-dna", map[string]interface{}{"gameDna": goldenDNA("Golden Preview"), "dryRun": true})
+	g.call("UpdateGameDNA", "PUT", "/api/v1/game-dna/"+id, map[string]interface{}{
+		"gameDna":    map[string]interface{}{"maxPlayers": 12},
+		"updateMask": "maxPlayers",
+		"dryRun":     true,
+	})
+	g.call("ValidateGameDNA", "POST", "/api/v1/game-dna/"+id+"/validate", map[string]interface{}{})
+	g.call("EstimatePerformanceBudget", "POST", "/api/v1/game-dna/"+id+"/budget", map[string]interface{}{})
+	g.call("PublishGameDNA", "POST", "/api/v1/game-dna/"+id+"/publish", map[string]interface{}{})
+	g.call("TagVersion", "POST", "/api/v1/game-dna/"+id+"/versions/2/tags", map[string]interface{}{"tag": "1.0.0-live", "actor": "golden"})
+	g.call("TagVersion", "POST", "/api/v1/game-dna/"+id+"/versions/3/tags", map[string]interface{}{"tag": "1.0.0-live", "actor": "golden"})
+	g.call("GetGameDNA", "GET", "/api/v1/game-dna/"+id+"?release_tag=1.0.0-live", nil)
+	g.call("GetVersionHistory", "GET", "/api/v1/game-dna/"+id+"/versions", nil)
+	g.call("ListVersionMetadata", "GET", "/api/v1/game-dna/"+id+"/version-metadata", nil)
+	g.call("GetVersion", "GET", "/api/v1/game-dna/"+id+"/versions/2", nil)
+	g.call("DiffVersions", "GET", "/api/v1/game-dna/"+id+"/diff?from_version=1&to_version=3", nil)
+	g.call("BlameGameDNA", "GET", "/api/v1/game-dna/"+id+"/blame", nil)
+	token := g.call("CreateAPIToken", "POST", "/api/v1/admin/tokens", map[string]interface{}{"name": "golden", "slugs": []string{slug}, "actor": "golden"})
+	tokenID := goldenString(token, "token", "id")
+	g.call("GetPublishedConfig", "GET", "/api/v1/published/"+slug, nil)
+	g.authorization = "Bearer " + goldenString(token, "secret")
+	g.call("GetPublishedConfig", "GET", "/api/v1/published/"+slug, nil)
+	g.call("SyncPublishedConfigs", "POST", "/api/v1/published/sync", map[string]interface{}{"slugs": []string{slug}})
+	g.authorization = ""
+
+	// Snapshots of the published config.
+	g.call("CreateSnapshot", "POST", "/api/v1/snapshots", map[string]interface{}{
+		"name":        "golden-snapshot",
+		"description": "golden",
+		"entries":     []interface{}{map[string]interface{}{"configId": id, "versionNum": 1}},
+	})
+	g.call("GetSnapshot", "GET", "/api/v1/snapshots/golden-snapshot", nil)
+	g.call("ListSnapshots", "GET", "/api/v1/snapshots", nil)
+	g.call("ExportSnapshot", "GET", "/api/v1/snapshots/golden-snapshot/export", nil)
+	g.call("ImportSnapshot", "POST", "/api/v1/snapshots/import", map[string]interface{}{
+		"export": map[string]interface{}{
+			"snapshot": map[string]interface{}{
+				"name": "golden-imported",
+				"entries": []interface{}{map[string]interface{}{
+					"configId": "golden-imported-config",
+					"data":     map[string]interface{}{"id": "golden-imported-config", "name": "Imported", "genre": "Puzzle", "targetFps": 60},
+				}},
+			},
+			"importMappings": []interface{}{map[string]interface{}{"project": "golden-imported", "columns": map[string]string{"FPS": "target_fps"}}},
+		},
+		"actor": "golden",
+	})
+	g.call("RequestTemporaryUnlock", "POST", "/api/v1/game-dna/"+id+"/temporary-unlock", map[string]interface{}{
+		"durationSeconds": 600, "reason": "hotfix", "actor": "golden",
+	})
+	g.call("ListTemporaryUnlocks", "GET", "/api/v1/temporary-unlocks", nil)
+	g.call("FinishTemporaryUnlock", "POST", "/api/v1/game-dna/"+id+"/temporary-unlock/finish", map[string]interface{}{"actor": "golden"})
+	g.call("UnpublishGameDNA", "POST", "/api/v1/game-dna/"+id+"/unpublish", map[string]interface{}{"reason": "golden", "actor": "golden"})
+	g.call("RestoreSnapshot", "POST", "/api/v1/snapshots/golden-snapshot/restore", map[string]interface{}{})
+	g.call("GetAuditLog", "GET", "/api/v1/game-dna/"+id+"/audit-log", nil)
+	g.call("RollbackToVersion", "POST", "/api/v1/game-dna/"+id+"/rollback", map[string]interface{}{"versionNum": 1})
+
+	// Editing sessions and drafts.
+	session := g.call("StartEditing", "POST", "/api/v1/game-dna/"+id+"/editing", map[string]interface{}{"actor": "golden"})
+	sessionID := goldenString(session, "session", "sessionId")
+	g.call("HeartbeatEditing", "POST", "/api/v1/game-dna/"+id+"/editing/"+sessionID+"/heartbeat", map[string]interface{}{})
+	g.call("StopEditing", "DELETE", "/api/v1/game-dna/"+id+"/editing/"+sessionID, nil)
+	draft := goldenDNA("Golden Arena")
+	draft["maxPlayers"] = 32
+	g.call("SaveDraft", "PUT", "/api/v1/game-dna/"+id+"/draft", map[string]interface{}{"gameDna": draft, "actor": "golden"})
+	g.call("GetDraft", "GET", "/api/v1/game-dna/"+id+"/draft?actor=golden", nil)
+	g.call("PromoteDraft", "POST", "/api/v1/game-dna/"+id+"/draft/promote", map[string]interface{}{"actor": "golden"})
+	g.call("SaveDraft", "PUT", "/api/v1/game-dna/"+id+"/draft", map[string]interface{}{"gameDna": draft, "actor": "golden"})
+	g.call("DiscardDraft", "DELETE", "/api/v1/game-dna/"+id+"/draft?actor=golden", nil)
+	g.call("GetDraft", "GET", "/api/v1/game-dna/"+id+"/draft?actor=golden", nil)
+
+	// Validation waivers.
+	waiver := g.call("CreateValidationWaiver", "POST", "/api/v1/game-dna/"+id+"/waivers", map[string]interface{}{
+		"code":          "HIGH_FPS",
+		"field":         "target_fps",
+		"justification": "golden",
+		"expireTime":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"approver":      "golden",
+	})
+	g.call("ListValidationWaivers", "GET", "/api/v1/game-dna/"+id+"/waivers", nil)
+	g.call("RevokeValidationWaiver", "DELETE", "/api/v1/game-dna/"+id+"/waivers/"+goldenString(waiver, "waiver", "id")+"?actor=golden", nil)
+
+	// Sets and bulk operations. Configs created in the same second list in
+	// ID order, which differs between runs, so calls over several configs
+	// are filtered down to one.
+	clone := g.call("CloneGameDNA", "POST", "/api/v1/game-dna/"+id+"/clone", map[string]interface{}{"newName": "Golden Arena Copy"})
+	cloneID := goldenString(clone, "gameDna", "id")
+	cloneUpdate := goldenDNA("Golden Arena Copy")
+	cloneUpdate["id"] = cloneID
+	cloneUpdate["maxPlayers"] = 8
+	g.call("SaveSet", "POST", "/api/v1/game-dna/save-set", map[string]interface{}{
+		"creates": []interface{}{goldenDNA("Golden Set Member")},
+		"updates": []interface{}{cloneUpdate},
+	})
+	batchInvalid := goldenDNA("Golden Batch Invalid")
+	batchInvalid["targetFps"] = 5000
+	g.call("BatchCreateGameDNA", "POST", "/api/v1/game-dna/batch-create", map[string]interface{}{
+		"requests": []interface{}{map[string]interface{}{"gameDna": goldenDNA("Golden Batch Member")}},
+	})
+	g.call("BatchCreateGameDNA", "POST", "/api/v1/game-dna/batch-create", map[string]interface{}{
+		"requests": []interface{}{
+			map[string]interface{}{"gameDna": goldenDNA("Golden Batch Skipped")},
+			map[string]interface{}{"gameDna": batchInvalid},
+		},
+	})
+	g.call("BatchUpdateGameDNA", "POST", "/api/v1/game-dna/batch-update", map[string]interface{}{
+		"requests": []interface{}{map[string]interface{}{
+			"id": cloneID, "gameDna": map[string]interface{}{"maxPlayers": 16}, "updateMask": "maxPlayers",
+		}},
+	})
+	g.call("BulkUpdateField", "POST", "/api/v1/game-dna/bulk-update", map[string]interface{}{
+		"nameFilter": "Golden Set Member", "updateMask": "maxPlayers", "values": map[string]interface{}{"maxPlayers": 12}, "dryRun": true,
+	})
+	g.call("RevalidateAgainstLatest", "POST", "/api/v1/game-dna/revalidate", map[string]interface{}{})
+	g.call("ApplyGameDNA", "POST", "/api/v1/game-dna/apply", map[string]interface{}{
+		"gameDna": map[string]interface{}{"slug": "golden-applied", "name": "Golden Applied", "genre": "FPS", "targetFps": 60, "timeScale": 1, "targetPlatforms": []string{"PC"}},
+		"plan":    true,
+		"actor":   "golden",
+	})
+	_, _, exported := g.send("GET", "/api/v1/game-dna/bulk-export?format=ndjson&ids="+id, nil)
+	g.call("ExportGameDNA", "GET", "/api/v1/game-dna/bulk-export?format=ndjson&ids="+id, nil)
+	g.call("ImportGameDNA", "POST", "/api/v1/game-dna/bulk-import?format=ndjson&dry_run=true&overwrite=true", string(exported))
+
+	// Imports. Google Sheets isn't configured, so its RPCs record the error.
+	g.call("SaveImportMapping", "PUT", "/api/v1/import-mappings/golden", map[string]interface{}{"columns": map[string]string{"Title": "name"}, "actor": "golden"})
+	g.call("GetImportMapping", "GET", "/api/v1/import-mappings/golden", nil)
+	g.call("ImportSheet", "POST", "/api/v1/game-dna/import", map[string]interface{}{
+		"data":    base64.StdEncoding.EncodeToString([]byte("Title,genre,target_fps,time_scale,target_platforms\nGolden Sheet,FPS,60,1,PC\n")),
+		"format":  "csv",
+		"project": "golden",
+		"dryRun":  true,
+		"actor":   "golden",
+	})
+	g.call("ExportToGoogleSheet", "POST", "/api/v1/google-sheets/export", map[string]interface{}{"spreadsheetId": "golden"})
+	g.call("PullFromGoogleSheet", "POST", "/api/v1/google-sheets/pull", map[string]interface{}{"spreadsheetId": "golden"})
+
+	// Templates.
+	g.call("ListTemplates", "GET", "/api/v1/templates?genre=FPS", nil)
+	g.call("GetTemplate", "GET", "/api/v1/templates/fps-arena", nil)
+	g.call("SaveTemplate", "PUT", "/api/v1/templates/golden-template", map[string]interface{}{
+		"description": "golden", "gameDna": goldenDNA("ignored"), "actor": "golden",
+	})
+	fromTemplate := g.call("CreateFromTemplate", "POST", "/api/v1/templates/golden-template/create", map[string]interface{}{"name": "Golden From Template", "actor": "golden"})
+	g.call("DeleteTemplate", "DELETE", "/api/v1/templates/golden-template", nil)
+
+	// Property schemas.
+	g.call("SavePropertySchema", "PUT", "/api/v1/property-schemas/golden-project/rarity", map[string]interface{}{
+		"type": "enum", "allowedValues": []string{"Common", "Rare"}, "required": true, "description": "golden", "actor": "golden",
+	})
+	g.call("GetPropertySchema", "GET", "/api/v1/property-schemas/golden-project/rarity", nil)
+	g.call("ListPropertySchemas", "GET", "/api/v1/property-schemas/golden-project", nil)
+	g.call("DeletePropertySchema", "DELETE", "/api/v1/property-schemas/golden-project/rarity", nil)
+
+	// Event log.
+	g.call("GetEventLog", "GET", "/api/v1/game-dna/"+goldenString(fromTemplate, "gameDna", "id")+"/event-log", nil)
+
+	// Administration.
+	g.call("ListAPITokens", "GET", "/api/v1/admin/tokens", nil)
+	g.call("RotateAPIToken", "POST", "/api/v1/admin/tokens/"+tokenID+"/rotate", map[string]interface{}{"graceSeconds": 60})
+	g.call("RevokeAPIToken", "POST", "/api/v1/admin/tokens/"+tokenID+"/revoke", map[string]interface{}{})
+	g.call("TransferOwnership", "POST", "/api/v1/admin/transfer-ownership", map[string]interface{}{"fromActor": "golden", "toActor": "golden-2", "dryRun": true})
+	g.send("PUT", "/api/v1/templates/golden-departed", map[string]interface{}{
+		"description": "golden", "gameDna": goldenDNA("ignored"), "actor": "golden-departed",
+	})
+	g.call("ExportPrincipalData", "GET", "/api/v1/admin/principals/golden-departed/data", nil)
+	g.call("AnonymizePrincipal", "POST", "/api/v1/admin/principals/golden-departed/anonymize", map[string]interface{}{"pseudonym": "golden-anonymized", "dryRun": true})
+	g.call("GetIndexStats", "GET", "/api/v1/admin/index-stats", nil)
+	g.call("LookupRequest", "GET", "/api/v1/admin/requests/golden-003", nil)
+	g.call("SetFaults", "PUT", "/api/v1/admin/faults", map[string]interface{}{"rules": []interface{}{
+		map[string]interface{}{"target": "storage.Read", "errorRate": 0, "message": "golden"},
+	}})
+	g.call("GetFaults", "GET", "/api/v1/admin/faults", nil)
+	g.call("SetFaults", "PUT", "/api/v1/admin/faults", map[string]interface{}{})
+	g.call("ReloadConfig", "POST", "/api/v1/admin/config/reload", map[string]interface{}{})
+
+	// Canaries of a config of their own, so the ones above keep their history.
+	_, _, body := g.send("POST", "/api/v1/game-dna", map[string]interface{}{"gameDna": goldenDNA("Golden Canary")})
+	var canaried map[string]interface{}
+	if err := json.Unmarshal(body, &canaried); err != nil {
+		t.Fatalf("Failed to decode created config: %v", err)
+	}
+	canaryID, canarySlug := goldenString(canaried, "gameDna", "id"), goldenString(canaried, "gameDna", "slug")
+	g.send("POST", "/api/v1/game-dna/"+canaryID+"/publish", map[string]interface{}{})
+	candidate := goldenDNA("Golden Canary")
+	candidate["targetFps"] = 144
+	started := g.call("StartCanary", "POST", "/api/v1/game-dna/"+canaryID+"/canary", map[string]interface{}{
+		"gameDna": candidate, "percent": 99, "durationSeconds": 600, "minSessions": 10, "actor": "golden",
+	})
+	g.call("GetCanary", "GET", "/api/v1/game-dna/"+canaryID+"/canary", nil)
+	_, _, body = g.send("POST", "/api/v1/admin/tokens", map[string]interface{}{"name": "golden-canary", "slugs": []string{canarySlug}, "actor": "golden"})
+	var canaryToken map[string]interface{}
+	if err := json.Unmarshal(body, &canaryToken); err != nil {
+		t.Fatalf("Failed to decode API token: %v", err)
+	}
+	g.authorization = "Bearer " + goldenString(canaryToken, "secret")
+	g.call("GetPublishedConfig", "GET", "/api/v1/published/"+canarySlug+"?bucket_key=player-1", nil)
+	g.authorization = ""
+	g.call("ReportConfigFeedback", "POST", "/api/v1/published/"+canarySlug+"/feedback", map[string]interface{}{
+		"checksum": goldenString(started, "canary", "checksum"), "sessions": 10, "errors": 0,
+	})
+	g.call("PromoteCanary", "POST", "/api/v1/game-dna/"+canaryID+"/canary/promote", map[string]interface{}{"reason": "golden", "actor": "golden"})
+	candidate["targetFps"] = 60
+	g.send("POST", "/api/v1/game-dna/"+canaryID+"/canary", map[string]interface{}{"gameDna": candidate, "actor": "golden"})
+	g.call("RollbackCanary", "POST", "/api/v1/game-dna/"+canaryID+"/canary/rollback", map[string]interface{}{"actor": "golden"})
+
+	// The canary's config promoted from dev to a new staging config.
+	promoted := g.call("PromoteGameDNA", "POST", "/api/v1/game-dna/"+canaryID+"/promote", map[string]interface{}{
+		"approvedBy": "golden-approver", "reason": "golden", "actor": "golden",
+	})
+	g.send("DELETE", "/api/v1/game-dna/"+goldenString(promoted, "gameDna", "id"), nil)
+
+	// A variant inheriting from the first config; its parent can't be
+	// deleted while it exists.
+	variant := g.call("CreateGameDNA", "POST", "/api/v1/game-dna", map[string]interface{}{
+		"gameDna": map[string]interface{}{"name": "Golden Arena Variant", "parentId": id, "targetFps": 30},
+	})
+	variantID := goldenString(variant, "gameDna", "id")
+	g.call("GetEffectiveGameDNA", "GET", "/api/v1/game-dna/"+variantID+"/effective", nil)
+	g.call("CompareGameDNA", "GET", "/api/v1/game-dna/"+id+"/compare/"+variantID, nil)
+	g.call("DeleteGameDNA", "DELETE", "/api/v1/game-dna/"+id, nil)
+	g.send("DELETE", "/api/v1/game-dna/"+variantID, nil)
+
+	// A webhook for deletes; no sweep runs, so its delivery stays pending.
+	hook := g.call("CreateWebhook", "POST", "/api/v1/admin/webhooks", map[string]interface{}{
+		"url": "https://ci.example.com/hooks/level-bake", "eventTypes": []string{"deleted"}, "actor": "golden",
+	})
+	hookID := goldenString(hook, "webhook", "id")
+	g.call("ListWebhooks", "GET", "/api/v1/admin/webhooks", nil)
+
+	g.call("DeleteGameDNA", "DELETE", "/api/v1/game-dna/"+cloneID, nil)
+	g.call("DeleteGameDNA", "DELETE", "/api/v1/game-dna/"+cloneID+"?idempotent=true", nil)
+
+	g.call("ListWebhookDeliveries", "GET", "/api/v1/admin/webhooks/"+hookID+"/deliveries", nil)
+	g.call("DeleteWebhook", "DELETE", "/api/v1/admin/webhooks/"+hookID, nil)
+
+	g.check()
+}
+
+// paginationStores returns the stores pagination invariants are checked
+// against: memory always, and Postgres when TEST_DATABASE_URL is set.
+func paginationStores(t *testing.T) map[string]func(t *testing.T) storage.Store {
+	stores := map[string]func(t *testing.T) storage.Store{
+		"memory": func(t *testing.T) storage.Store {
+			store := storage.NewMemoryStore()
+			t.Cleanup(store.Close)
+			return store
+		},
+	}
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		return stores
+	}
+	stores["postgres"] = func(t *testing.T) storage.Store {
+		store, err := storage.NewPostgresStore(url, storage.PoolConfig{})
+		if err != nil {
+			t.Fatalf("NewPostgresStore failed: %v", err)
+		}
+		t.Cleanup(store.Close)
+		if err := storage.Migrate(context.Background(), store.DB()); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+		return store
+	}
+	return stores
+}
+
+// TestPaginationInvariants lists random datasets with random filters and
+// page sizes, through the store and through ListGameDNA, and checks that
+// paging neither drops, repeats nor reorders configs.
+func TestPaginationInvariants(t *testing.T) {
+	ctx := context.Background()
+	rust, _ := ffi.NewRustFFI("", false)
+	genres := []string{"FPS", "RPG", "Puzzle"}
+	words := []string{"Alpha", "Bravo", "Charlie", "Delta"}
+	tagSet := []string{"red", "green", "blue"}
+
+	for name, open := range paginationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{}, zap.NewNop())
+
+			for seed := int64(1); seed <= 20; seed++ {
+				rng := mrand.New(mrand.NewSource(seed))
+				// Every config of a seed carries its run tag, which every
+				// filter includes, so runs sharing a database stay apart.
+				run := fmt.Sprintf("pagination-%d-%d", seed, time.Now().UnixNano())
+
+				var created []*pb.GameDNA
+				for i, n := 0, rng.Intn(40); i < n; i++ {
+					tags := []string{run}
+					for _, tag := range tagSet {
+						if rng.Intn(2) == 0 {
+							tags = append(tags, tag)
+						}
+					}
+					dna, err := store.Create(ctx, &pb.GameDNA{
+						Name:            fmt.Sprintf("%s %s %d", words[rng.Intn(len(words))], words[rng.Intn(len(words))], i),
+						Genre:           genres[rng.Intn(len(genres))],
+						Tags:            tags,
+						TargetFps:       60,
+						TimeScale:       1,
+						TargetPlatforms: []string{"PC"},
+					})
+					if err != nil {
+						t.Fatalf("seed %d: Create failed: %v", seed, err)
+					}
+					created = append(created, dna)
+				}
+				t.Cleanup(func() {
+					for _, dna := range created {
+						store.Delete(ctx, dna.Id)
+					}
+				})
+
+				for trial := 0; trial < 10; trial++ {
+					filters := storage.ListFilters{Tags: []string{run}}
+					if rng.Intn(2) == 0 {
+						filters.Genre = genres[rng.Intn(len(genres))]
+					}
+					if rng.Intn(3) == 0 {
+						filters.Tags = append(filters.Tags, tagSet[rng.Intn(len(tagSet))])
+					}
+					if rng.Intn(3) == 0 {
+						filters.NameFilter = strings.ToLower(words[rng.Intn(len(words))])
+					}
+					pageSize := int32(1 + rng.Intn(12))
+					checkPagination(t, ctx, store, svc, created, filters, pageSize, fmt.Sprintf("seed %d trial %d %+v page size %d", seed, trial, filters, pageSize))
+				}
+			}
+		})
+	}
+}
+
+// checkPagination pages through filters and checks the pages against Each
+// and against the configs in created that match.
+func checkPagination(t *testing.T, ctx context.Context, store storage.Store, svc *api.GameDNAServiceServer, created []*pb.GameDNA, filters storage.ListFilters, pageSize int32, desc string) {
+	t.Helper()
+	want := map[string]bool{}
+	for _, dna := range created {
+		if paginationMatches(dna, filters) {
+			want[dna.Id] = true
+		}
+	}
+	var each []string
+	if err := store.Each(ctx, filters, func(dna *pb.GameDNA) error {
+		each = append(each, dna.Id)
+		return nil
+	}); err != nil {
+		t.Fatalf("%s: Each failed: %v", desc, err)
+	}
+	if len(each) != len(want) {
+		t.Fatalf("%s: Each returned %d configs, want %d", desc, len(each), len(want))
+	}
+
+	var paged []*pb.GameDNA
+	seen := map[string]bool{}
+	totalPages := (int32(len(want)) + pageSize - 1) / pageSize
+	for page := int32(1); page <= totalPages+1; page++ {
+		items, total, err := store.List(ctx, filters, storage.Pagination{Page: page, PageSize: pageSize})
+		if err != nil {
+			t.Fatalf("%s: List page %d failed: %v", desc, page, err)
+		}
+		if int(total) != len(want) {
+			t.Fatalf("%s: page %d total %d, want %d", desc, page, total, len(want))
+		}
+		switch {
+		case page > totalPages && len(items) != 0:
+			t.Fatalf("%s: page %d past the end returned %d configs", desc, page, len(items))
+		case page < totalPages && int32(len(items)) != pageSize:
+			t.Fatalf("%s: page %d returned %d configs, want a full page of %d", desc, page, len(items), pageSize)
+		case int32(len(items)) > pageSize:
+			t.Fatalf("%s: page %d returned %d configs, more than the page size", desc, page, len(items))
+		}
+		for _, dna := range items {
+			if seen[dna.Id] {
+				t.Fatalf("%s: config %s repeated on page %d", desc, dna.Id, page)
+			}
+			if !want[dna.Id] {
+				t.Fatalf("%s: config %s on page %d doesn't match the filters", desc, dna.Id, page)
+			}
+			seen[dna.Id] = true
+			paged = append(paged, dna)
+		}
+
+		resp, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{
+			Page:       page,
+			PageSize:   pageSize,
+			Tags:       filters.Tags,
+			Genre:      filters.Genre,
+			NameFilter: filters.NameFilter,
+		})
+		if err != nil {
+			t.Fatalf("%s: ListGameDNA page %d failed: %v", desc, page, err)
+		}
+		info := resp.Pagination
+		if info.Page != page || info.PageSize != pageSize || int(info.Total) != len(want) || info.TotalPages != totalPages {
+			t.Fatalf("%s: ListGameDNA page %d pagination %v, want total %d in %d pages", desc, page, info, len(want), totalPages)
+		}
+		if len(resp.Items) != len(items) {
+			t.Fatalf("%s: ListGameDNA page %d returned %d configs, the store %d", desc, page, len(resp.Items), len(items))
+		}
+		for i := range items {
+			if resp.Items[i].Id != items[i].Id {
+				t.Fatalf("%s: ListGameDNA page %d differs from the store at %d", desc, page, i)
+			}
+		}
+	}
+
+	// Following page tokens from the first page visits the same configs.
+	var token string
+	var cursored []string
+	for calls := 0; calls == 0 || token != ""; calls++ {
+		if calls > len(want) {
+			t.Fatalf("%s: page tokens never ran out", desc)
+		}
+		resp, err := svc.ListGameDNA(ctx, &pb.ListGameDNARequest{
+			PageSize:   pageSize,
+			PageToken:  token,
+			Tags:       filters.Tags,
+			Genre:      filters.Genre,
+			NameFilter: filters.NameFilter,
+		})
+		if err != nil {
+			t.Fatalf("%s: ListGameDNA after token %q failed: %v", desc, token, err)
+		}
+		if int32(len(resp.Items)) > pageSize || (resp.NextPageToken != "" && int32(len(resp.Items)) != pageSize) {
+			t.Fatalf("%s: token page returned %d configs with page size %d", desc, len(resp.Items), pageSize)
+		}
+		for _, dna := range resp.Items {
+			cursored = append(cursored, dna.Id)
+		}
+		token = resp.NextPageToken
+	}
+	if !slices.Equal(cursored, each) {
+		t.Fatalf("%s: paging by token listed %v, Each %v", desc, cursored, each)
+	}
+
+	// Pages in sequence are the Each order: newest first, ties by ID.
+	for i, dna := range paged {
+		if dna.Id != each[i] {
+			t.Fatalf("%s: config %d is %s when paged, %s in Each", desc, i, dna.Id, each[i])
+		}
+		if i == 0 {
+			continue
+		}
+		prev := paged[i-1]
+		prevTime, curTime := prev.CreateTime.AsTime(), dna.CreateTime.AsTime()
+		if prevTime.Before(curTime) || (prevTime.Equal(curTime) && prev.Id > dna.Id) {
+			t.Fatalf("%s: config %s listed before %s out of order", desc, prev.Id, dna.Id)
+		}
+	}
+}
+
+// paginationMatches reports whether dna passes filters, as List documents.
+func paginationMatches(dna *pb.GameDNA, filters storage.ListFilters) bool {
+	if filters.Genre != "" && dna.Genre != filters.Genre {
+		return false
+	}
+	if filters.NameFilter != "" && !strings.Contains(strings.ToLower(dna.Name), strings.ToLower(filters.NameFilter)) {
+		return false
+	}
+	for _, tag := range filters.Tags {
+		if !slices.Contains(dna.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidationRules(t *testing.T) {
+	rust, _ := ffi.NewRustFFI("", false)
+	base := func() *pb.GameDNA {
+		return &pb.GameDNA{Name: "Ruled", Genre: "RPG", Camera: "Perspective3D", TargetFps: 60, TimeScale: 1, TargetPlatforms: []string{"PC"}}
+	}
+	findings := func(resp *pb.ValidationResponse) []string {
+		var got []string
+		for _, e := range resp.Errors {
+			got = append(got, "error:"+e.Code+":"+e.Field+":"+e.Details)
+		}
+		for _, w := range resp.Warnings {
+			got = append(got, "warning:"+w.Code+":"+w.Field+":"+w.Suggestion)
+		}
+		for _, s := range resp.Suggestions {
+			got = append(got, "suggestion:"+s)
+		}
+		return got
+	}
+
+	// The built-in rules report as the hardcoded checks did.
+	broken := base()
+	broken.TargetFps, broken.TimeScale, broken.MaxPlayers = 2000, 0, 4
+	resp, _ := rust.ValidateGameDNA(broken)
+	if got := findings(resp); resp.IsValid || !slices.Equal(got, []string{
+		"error:INVALID_FPS:target_fps:Current value: 2000",
+		"error:INVALID_TIME_SCALE:time_scale:Current value: 0.000000",
+		"warning:MULTIPLAYER_NOT_CONFIGURED:max_players:Set is_competitive or supports_coop to true",
+	}) {
+		t.Errorf("Unexpected built-in findings: %v", got)
+	}
+
+	// A studio rule file: loot boxes need an ESRB rating, and RPGs on
+	// mobile are warned about above 60 FPS.
+	rules, err := ffi.ParseRuleSet([]byte(`
+version: studio/1
+rules:
+  - id: LOOTBOX_NEEDS_ESRB
+    severity: error
+    field: esrb_rating
+    message: Configs with loot boxes must have an ESRB rating
+    hint: "monetization is {monetization}"
+    when:
+      - {field: monetization, equals: lootbox}
+    require:
+      - {field: esrb_rating, set: true}
+  - id: MOBILE_RPG_FPS
+    severity: warning
+    field: target_fps
+    message: Mobile RPGs should target 60 FPS or less
+    hint: "Lower target_fps from {target_fps}"
+    when:
+      - {field: genre, equals: RPG}
+      - {field: target_platforms, equals: Mobile}
+    require:
+      - {field: target_fps, max: 60}
+  - id: STUDIO_TAG
+    severity: suggestion
+    message: Tag configs with their studio
+    require:
+      - {field: custom_properties.studio, matches: "^[a-z]+$"}
+`))
+	if err != nil {
+		t.Fatalf("ParseRuleSet failed: %v", err)
+	}
+	if err := rust.SetRuleSet(rules); err != nil {
+		t.Fatalf("SetRuleSet failed: %v", err)
+	}
+	if got := rust.RulesVersion(); got != ffi.BasicRulesVersion+"+studio/1" {
+		t.Errorf("Expected the rule set's version in the rules version, got %s", got)
+	}
+
+	lootbox := base()
+	lootbox.Monetization = "Lootbox"
+	lootbox.TargetPlatforms = []string{"PC", "Mobile"}
+	lootbox.TargetFps = 90
+	resp, _ = rust.ValidateGameDNA(lootbox)
+	if got := findings(resp); resp.IsValid || resp.RulesVersion != rust.RulesVersion() || !slices.Equal(got, []string{
+		"error:LOOTBOX_NEEDS_ESRB:esrb_rating:monetization is Lootbox",
+		"warning:MOBILE_RPG_FPS:target_fps:Lower target_fps from 90",
+		"suggestion:Tag configs with their studio",
+	}) {
+		t.Errorf("Unexpected studio findings: %v", got)
+	}
+	lootbox.EsrbRating, lootbox.TargetFps = "T", 60
+	lootbox.CustomProperties = map[string]string{"studio": "north"}
+	resp, _ = rust.ValidateGameDNA(lootbox)
+	if got := findings(resp); !resp.IsValid || len(got) != 0 {
+		t.Errorf("Expected the fixed config to pass the studio rules, got %v", got)
+	}
+
+	// Go rules are registered the same way.
+	if err := rust.SetRuleSet(ffi.RuleSet{Version: "go/1", Rules: []ffi.Rule{{
+		ID:       "NAME_TOO_LONG",
+		Severity: ffi.SeverityWarning,
+		Field:    "name",
+		Message:  "Names longer than 8 characters are truncated in the launcher",
+		Hint:     "Shorten {name}",
+		Check:    func(dna *pb.GameDNA) bool { return len(dna.Name) <= 8 },
+	}}}); err != nil {
+		t.Fatalf("SetRuleSet failed: %v", err)
+	}
+	long := base()
+	long.Name = "Much Too Long"
+	resp, _ = rust.ValidateGameDNA(long)
+	if got := findings(resp); !resp.IsValid || !slices.Equal(got, []string{"warning:NAME_TOO_LONG:name:Shorten Much Too Long"}) {
+		t.Errorf("Unexpected Go rule findings: %v", got)
+	}
+
+	for name, bad := range map[string]string{
+		"two tests":     "version: v\nrules:\n  - {id: X, severity: error, message: m, require: [{field: name, set: true, equals: a}]}",
+		"unknown field": "version: v\nrules:\n  - {id: X, severity: error, message: m, require: [{field: nope, set: true}]}",
+		"unknown key":   "version: v\nrules:\n  - {id: X, severity: error, message: m, require: [{field: name, set: true}], colour: red}",
+		"min on string": "version: v\nrules:\n  - {id: X, severity: error, message: m, require: [{field: name, min: 1}]}",
+		"no require":    "version: v\nrules:\n  - {id: X, severity: error, message: m}",
+	} {
+		if _, err := ffi.ParseRuleSet([]byte(bad)); err == nil {
+			t.Errorf("%s: expected the rules file to be rejected", name)
+		}
+	}
+	check := func(*pb.GameDNA) bool { return true }
+	for name, bad := range map[string]ffi.RuleSet{
+		"built-in id":  {Version: "v", Rules: []ffi.Rule{{ID: "EMPTY_NAME", Severity: ffi.SeverityError, Message: "m", Check: check}}},
+		"severity":     {Version: "v", Rules: []ffi.Rule{{ID: "X", Severity: "fatal", Message: "m", Check: check}}},
+		"field":        {Version: "v", Rules: []ffi.Rule{{ID: "X", Severity: ffi.SeverityError, Field: "custom_properties", Message: "m", Check: check}}},
+		"no version":   {Rules: []ffi.Rule{{ID: "X", Severity: ffi.SeverityError, Message: "m", Check: check}}},
+		"no predicate": {Version: "v", Rules: []ffi.Rule{{ID: "X", Severity: ffi.SeverityError, Message: "m"}}},
+	} {
+		if err := rust.SetRuleSet(bad); err == nil {
+			t.Errorf("%s: expected the rule set to be rejected", name)
+		}
+	}
+}
+
+func TestBatchGameDNA(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{MaxBatchSize: 3}, zap.NewNop())
+
+	dna := func(name string, fps uint32) *pb.CreateGameDNARequest {
+		return &pb.CreateGameDNARequest{GameDna: &pb.GameDNA{
+			Name: name, Genre: "FPS", TargetFps: fps, MaxEntities: 500, Camera: "first_person",
+			TimeScale: 1, TargetPlatforms: []string{"PC"},
+		}}
+	}
+	count := func() int32 {
+		_, total, err := store.List(ctx, storage.ListFilters{}, storage.Pagination{Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		return total
+	}
+
+	// One invalid item rejects the whole batch, with per-item results.
+	_, err := svc.BatchCreateGameDNA(ctx, &pb.BatchCreateGameDNARequest{Requests: []*pb.CreateGameDNARequest{
+		dna("Batch One", 60), dna("Batch Bad", 5000), {},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected a rejected batch to be InvalidArgument, got %v", err)
+	}
+	var rejected *pb.BatchGameDNAResponse
+	var violations []string
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *pb.BatchGameDNAResponse:
+			rejected = d
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				violations = append(violations, v.Field)
+			}
+		}
+	}
+	if rejected == nil || len(rejected.Results) != 3 {
+		t.Fatalf("Expected per-item results on the error, got %v", rejected)
+	}
+	for i, want := range []string{"not_applied", "invalid", "failed"} {
+		if got := rejected.Results[i].Status; got != want {
+			t.Errorf("Item %d: expected status %q, got %q", i, want, got)
+		}
+	}
+	if strings.Join(violations, ",") != "requests[1].target_fps,requests[2]" {
+		t.Errorf("Unexpected field violations: %v", violations)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("Expected a rejected batch to create nothing, got %d configs", n)
+	}
+
+	if _, err := svc.BatchCreateGameDNA(ctx, &pb.BatchCreateGameDNARequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an empty batch to be InvalidArgument, got %v", err)
+	}
+	if _, err := svc.BatchCreateGameDNA(ctx, &pb.BatchCreateGameDNARequest{Requests: []*pb.CreateGameDNARequest{
+		dna("A", 60), dna("B", 60), dna("C", 60), dna("D", 60),
+	}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a batch over the limit to be InvalidArgument, got %v", err)
+	}
+
+	created, err := svc.BatchCreateGameDNA(ctx, &pb.BatchCreateGameDNARequest{Requests: []*pb.CreateGameDNARequest{
+		dna("Batch One", 60), dna("Batch Two", 60),
+	}})
+	if err != nil {
+		t.Fatalf("Batch create failed: %v", err)
+	}
+	for i, result := range created.Results {
+		if result.Status != "created" || result.GameDna.GetId() == "" || result.Index != int32(i) {
+			t.Errorf("Item %d: unexpected result %v", i, result)
+		}
+	}
+	if n := count(); n != 2 {
+		t.Errorf("Expected 2 configs, got %d", n)
+	}
+	one, two := created.Results[0].GameDna, created.Results[1].GameDna
+
+	// Masked updates merge into the stored config; identical ones add no version.
+	updated, err := svc.BatchUpdateGameDNA(ctx, &pb.BatchUpdateGameDNARequest{Requests: []*pb.UpdateGameDNARequest{
+		{Id: one.Id, GameDna: &pb.GameDNA{TargetFps: 90}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}}},
+		{Id: two.Id, GameDna: &pb.GameDNA{TargetFps: 120}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Batch update failed: %v", err)
+	}
+	if got := updated.Results[0]; got.Status != "updated" || got.GameDna.TargetFps != 90 || got.GameDna.Name != "Batch One" {
+		t.Errorf("Unexpected masked update result: %v", got)
+	}
+	again, err := svc.BatchUpdateGameDNA(ctx, &pb.BatchUpdateGameDNARequest{Requests: []*pb.UpdateGameDNARequest{
+		{Id: two.Id, GameDna: updated.Results[1].GameDna},
+	}})
+	if err != nil {
+		t.Fatalf("Batch update failed: %v", err)
+	}
+	if got := again.Results[0].Status; got != "unchanged" {
+		t.Errorf("Expected an identical update to be unchanged, got %q", got)
+	}
+	if history, _ := store.GetVersionHistory(ctx, two.Id); len(history) != 2 {
+		t.Errorf("Expected an unchanged item to add no version, got %d", len(history))
+	}
+
+	// A missing or repeated ID rejects the batch and leaves everything alone.
+	_, err = svc.BatchUpdateGameDNA(ctx, &pb.BatchUpdateGameDNARequest{Requests: []*pb.UpdateGameDNARequest{
+		{Id: one.Id, GameDna: &pb.GameDNA{TargetFps: 30}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}}},
+		{Id: one.Id, GameDna: &pb.GameDNA{TargetFps: 45}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}}},
+		{Id: "missing", GameDna: &pb.GameDNA{}},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a batch with failed items to be InvalidArgument, got %v", err)
+	}
+	if got, _ := store.Read(ctx, one.Id); got.TargetFps != 90 {
+		t.Errorf("Expected a rejected batch to leave the config alone, got fps %d", got.TargetFps)
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	rust, _ := ffi.NewRustFFI("", false)
+	registry := hooks.NewRegistry(hooks.Options{Timeout: time.Second}, zap.NewNop())
+	svc := api.NewGameDNAServiceServer(store, rust, api.ServerOptions{Hooks: registry}, zap.NewNop())
+
+	// A Go hook tags every new config; pre hooks run before validation.
+	err := registry.Register("tagger", hooks.HookFunc(func(ctx context.Context, event hooks.Event) (*pb.GameDNA, error) {
+		if event.GameDNA.Name == "Forbidden" {
+			return nil, errors.New("name is reserved")
+		}
+		event.GameDNA.Tags = append(event.GameDNA.Tags, "studio")
+		return event.GameDNA, nil
+	}), hooks.Options{}, hooks.PreCreate)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register("tagger", hooks.HookFunc(nil), hooks.Options{}, hooks.PostCreate); err == nil {
+		t.Error("Expected a duplicate hook name to be rejected")
+	}
+
+	var mu sync.Mutex
+	var observed []hooks.Stage
+	registry.Register("observer", hooks.HookFunc(func(ctx context.Context, event hooks.Event) (*pb.GameDNA, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, event.Stage)
+		return nil, errors.New("post hook failures are only logged")
+	}), hooks.Options{}, hooks.PostCreate, hooks.PostUpdate, hooks.PostPublish)
+
+	// An HTTP hook vetoes updates that drop below 30 FPS, and an ignored
+	// one times out without holding anything up.
+	var calls []map[string]interface{}
+	hookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		calls = append(calls, body)
+		mu.Unlock()
+		if r.Header.Get("X-Hook-Secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		dna, _ := body["gameDna"].(map[string]interface{})
+		if fps, _ := dna["targetFps"].(float64); fps < 30 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{"error": "targetFps below studio minimum"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hookServer.Close()
+	registry.Register("fps-policy", hooks.NewHTTPHook(hookServer.URL, map[string]string{"X-Hook-Secret": "s3cret"}), hooks.Options{}, hooks.PreUpdate)
+	registry.Register("slow", hooks.HookFunc(func(ctx context.Context, event hooks.Event) (*pb.GameDNA, error) {
+		time.Sleep(time.Second)
+		return &pb.GameDNA{Name: "Never used"}, nil
+	}), hooks.Options{Timeout: 20 * time.Millisecond, Policy: hooks.FailOpen}, hooks.PreUpdate)
+
+	base := &pb.GameDNA{
+		Name: "Hooked", Genre: "FPS", TargetFps: 60, MaxEntities: 500, Camera: "first_person",
+		TimeScale: 1, TargetPlatforms: []string{"PC"},
+	}
+	created, err := svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: models.Clone(base)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	id := created.GameDna.Id
+	if tags := created.GameDna.Tags; len(tags) != 1 || tags[0] != "studio" {
+		t.Errorf("Expected the pre_create hook's change to be stored, got tags %v", tags)
+	}
+
+	forbidden := models.Clone(base)
+	forbidden.Name = "Forbidden"
+	_, err = svc.CreateGameDNA(ctx, &pb.CreateGameDNARequest{GameDna: forbidden})
+	if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), "name is reserved") {
+		t.Errorf("Expected the pre_create hook to reject the create, got %v", err)
+	}
+
+	_, err = svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+		Id: id, GameDna: &pb.GameDNA{TargetFps: 20}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}},
+	})
+	if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), "targetFps below studio minimum") {
+		t.Errorf("Expected the HTTP hook to reject the update, got %v", err)
+	}
+	if dna, _ := store.Read(ctx, id); dna.TargetFps != 60 {
+		t.Errorf("Expected a rejected update to leave the config alone, got fps %d", dna.TargetFps)
+	}
+
+	start := time.Now()
+	updated, err := svc.UpdateGameDNA(ctx, &pb.UpdateGameDNARequest{
+		Id: id, GameDna: &pb.GameDNA{TargetFps: 90}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"target_fps"}},
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.GameDna.TargetFps != 90 || updated.GameDna.Name != "Hooked" {
+		t.Errorf("Expected the timed-out hook to be ignored, got %v", updated.GameDna)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the slow hook to be cut off at its timeout, took %v", elapsed)
+	}
+	mu.Lock()
+	if len(calls) == 0 || calls[0]["stage"] != "pre_update" || calls[0]["id"] != id {
+		t.Errorf("Unexpected HTTP hook payload: %v", calls)
+	}
+	mu.Unlock()
+
+	// Batches reject an item a hook rejects, like any other failed item.
+	_, err = svc.BatchCreateGameDNA(ctx, &pb.BatchCreateGameDNARequest{Requests: []*pb.CreateGameDNARequest{
+		{GameDna: models.Clone(base)}, {GameDna: models.Clone(forbidden)},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a batch with a rejected item to be InvalidArgument, got %v", err)
+	}
+
+	// Publish hooks can veto a publish.
+	registry.Register("freeze", hooks.HookFunc(func(ctx context.Context, event hooks.Event) (*pb.GameDNA, error) {
+		return nil, errors.New("release freeze")
+	}), hooks.Options{}, hooks.PrePublish)
+	if _, err := svc.PublishGameDNA(ctx, &pb.PublishGameDNARequest{Id: id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected the pre_publish hook to block the publish, got %v", err)
+	}
+	if dna, _ := store.Read(ctx, id); dna.IsLocked {
+		t.Error("Expected a vetoed publish to leave the config unlocked")
+	}
+
+	registry.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(observed) != 2 || observed[0] != hooks.PostCreate || observed[1] != hooks.PostUpdate {
+		t.Errorf("Expected post hooks for the create and the update, got %v", observed)
+	}
+}